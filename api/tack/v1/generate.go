@@ -0,0 +1,4 @@
+// Package tackv1 holds the generated gRPC API served by "tack serve --grpc".
+package tackv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative tack/v1/tack.proto
@@ -0,0 +1,898 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: tack/v1/tack.proto
+
+package tackv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListPluginsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPluginsRequest) Reset() {
+	*x = ListPluginsRequest{}
+	mi := &file_tack_v1_tack_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPluginsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPluginsRequest) ProtoMessage() {}
+
+func (x *ListPluginsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPluginsRequest.ProtoReflect.Descriptor instead.
+func (*ListPluginsRequest) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{0}
+}
+
+type ListPluginsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plugins       []*Plugin              `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPluginsResponse) Reset() {
+	*x = ListPluginsResponse{}
+	mi := &file_tack_v1_tack_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPluginsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPluginsResponse) ProtoMessage() {}
+
+func (x *ListPluginsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPluginsResponse.ProtoReflect.Descriptor instead.
+func (*ListPluginsResponse) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListPluginsResponse) GetPlugins() []*Plugin {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+// Plugin is an installed plugin.
+type Plugin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Services      []*Service             `protobuf:"bytes,4,rep,name=services,proto3" json:"services,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plugin) Reset() {
+	*x = Plugin{}
+	mi := &file_tack_v1_tack_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plugin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plugin) ProtoMessage() {}
+
+func (x *Plugin) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plugin.ProtoReflect.Descriptor instead.
+func (*Plugin) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Plugin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Plugin) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Plugin) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Plugin) GetServices() []*Service {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+// Service is a plugin service and its operations.
+type Service struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Operations    []*Operation           `protobuf:"bytes,3,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	mi := &file_tack_v1_tack_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Service) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Service) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Service) GetOperations() []*Operation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// Operation is a plugin operation.
+type Operation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	mi := &file_tack_v1_tack_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Operation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Operation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type DescribeOperationRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Plugin string                 `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// Service may be empty for single-service plugins.
+	Service       string `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Operation     string `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeOperationRequest) Reset() {
+	*x = DescribeOperationRequest{}
+	mi := &file_tack_v1_tack_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeOperationRequest) ProtoMessage() {}
+
+func (x *DescribeOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeOperationRequest.ProtoReflect.Descriptor instead.
+func (*DescribeOperationRequest) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DescribeOperationRequest) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *DescribeOperationRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *DescribeOperationRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+type DescribeOperationResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Plugin      string                 `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Service     string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Operation   string                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// InputFields are the config fields the operation reads.
+	InputFields []string `protobuf:"bytes,5,rep,name=input_fields,json=inputFields,proto3" json:"input_fields,omitempty"`
+	// ConfigSchema is the plugin's config JSON Schema.
+	ConfigSchema *structpb.Struct `protobuf:"bytes,6,opt,name=config_schema,json=configSchema,proto3" json:"config_schema,omitempty"`
+	// OutputSchema is the JSON Schema of the result data.
+	OutputSchema  *structpb.Struct `protobuf:"bytes,7,opt,name=output_schema,json=outputSchema,proto3" json:"output_schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeOperationResponse) Reset() {
+	*x = DescribeOperationResponse{}
+	mi := &file_tack_v1_tack_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeOperationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeOperationResponse) ProtoMessage() {}
+
+func (x *DescribeOperationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeOperationResponse.ProtoReflect.Descriptor instead.
+func (*DescribeOperationResponse) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DescribeOperationResponse) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *DescribeOperationResponse) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *DescribeOperationResponse) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *DescribeOperationResponse) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *DescribeOperationResponse) GetInputFields() []string {
+	if x != nil {
+		return x.InputFields
+	}
+	return nil
+}
+
+func (x *DescribeOperationResponse) GetConfigSchema() *structpb.Struct {
+	if x != nil {
+		return x.ConfigSchema
+	}
+	return nil
+}
+
+func (x *DescribeOperationResponse) GetOutputSchema() *structpb.Struct {
+	if x != nil {
+		return x.OutputSchema
+	}
+	return nil
+}
+
+// Invocation names an operation and its config.
+type Invocation struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Plugin string                 `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// Service may be empty for single-service plugins.
+	Service       string           `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Operation     string           `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"`
+	Config        *structpb.Struct `protobuf:"bytes,4,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Invocation) Reset() {
+	*x = Invocation{}
+	mi := &file_tack_v1_tack_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invocation) ProtoMessage() {}
+
+func (x *Invocation) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invocation.ProtoReflect.Descriptor instead.
+func (*Invocation) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Invocation) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *Invocation) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Invocation) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *Invocation) GetConfig() *structpb.Struct {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type ExecuteRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Invocations []*Invocation          `protobuf:"bytes,1,rep,name=invocations,proto3" json:"invocations,omitempty"`
+	// Concurrency bounds how many invocations run at once (default 1).
+	Concurrency   uint32 `protobuf:"varint,2,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteRequest) Reset() {
+	*x = ExecuteRequest{}
+	mi := &file_tack_v1_tack_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteRequest) ProtoMessage() {}
+
+func (x *ExecuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteRequest) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExecuteRequest) GetInvocations() []*Invocation {
+	if x != nil {
+		return x.Invocations
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetConcurrency() uint32 {
+	if x != nil {
+		return x.Concurrency
+	}
+	return 0
+}
+
+// ExecuteResponse is the outcome of one invocation.
+type ExecuteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Index is the position of the invocation in the request.
+	Index uint32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	// Result is the plugin result, unset if the operation could not run.
+	Result *Result `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	// Error explains why the operation could not run (unknown operation,
+	// timeout, plugin load failure).
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteResponse) Reset() {
+	*x = ExecuteResponse{}
+	mi := &file_tack_v1_tack_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteResponse) ProtoMessage() {}
+
+func (x *ExecuteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteResponse) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExecuteResponse) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ExecuteResponse) GetResult() *Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Result is a plugin result.
+type Result struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Status is "success", "failure", or "error".
+	Status        string           `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string           `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Data          *structpb.Struct `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Error         *ResultError     `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_tack_v1_tack_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Result) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Result) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Result) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Result) GetError() *ResultError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// ResultError describes a result with status "error".
+type ResultError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultError) Reset() {
+	*x = ResultError{}
+	mi := &file_tack_v1_tack_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultError) ProtoMessage() {}
+
+func (x *ResultError) ProtoReflect() protoreflect.Message {
+	mi := &file_tack_v1_tack_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultError.ProtoReflect.Descriptor instead.
+func (*ResultError) Descriptor() ([]byte, []int) {
+	return file_tack_v1_tack_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ResultError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ResultError) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ResultError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+var File_tack_v1_tack_proto protoreflect.FileDescriptor
+
+const file_tack_v1_tack_proto_rawDesc = "" +
+	"\n" +
+	"\x12tack/v1/tack.proto\x12\atack.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x14\n" +
+	"\x12ListPluginsRequest\"@\n" +
+	"\x13ListPluginsResponse\x12)\n" +
+	"\aplugins\x18\x01 \x03(\v2\x0f.tack.v1.PluginR\aplugins\"\x86\x01\n" +
+	"\x06Plugin\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12,\n" +
+	"\bservices\x18\x04 \x03(\v2\x10.tack.v1.ServiceR\bservices\"s\n" +
+	"\aService\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x122\n" +
+	"\n" +
+	"operations\x18\x03 \x03(\v2\x12.tack.v1.OperationR\n" +
+	"operations\"A\n" +
+	"\tOperation\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"j\n" +
+	"\x18DescribeOperationRequest\x12\x16\n" +
+	"\x06plugin\x18\x01 \x01(\tR\x06plugin\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\tR\toperation\"\xac\x02\n" +
+	"\x19DescribeOperationResponse\x12\x16\n" +
+	"\x06plugin\x18\x01 \x01(\tR\x06plugin\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\tR\toperation\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12!\n" +
+	"\finput_fields\x18\x05 \x03(\tR\vinputFields\x12<\n" +
+	"\rconfig_schema\x18\x06 \x01(\v2\x17.google.protobuf.StructR\fconfigSchema\x12<\n" +
+	"\routput_schema\x18\a \x01(\v2\x17.google.protobuf.StructR\foutputSchema\"\x8d\x01\n" +
+	"\n" +
+	"Invocation\x12\x16\n" +
+	"\x06plugin\x18\x01 \x01(\tR\x06plugin\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12\x1c\n" +
+	"\toperation\x18\x03 \x01(\tR\toperation\x12/\n" +
+	"\x06config\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x06config\"i\n" +
+	"\x0eExecuteRequest\x125\n" +
+	"\vinvocations\x18\x01 \x03(\v2\x13.tack.v1.InvocationR\vinvocations\x12 \n" +
+	"\vconcurrency\x18\x02 \x01(\rR\vconcurrency\"f\n" +
+	"\x0fExecuteResponse\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12'\n" +
+	"\x06result\x18\x02 \x01(\v2\x0f.tack.v1.ResultR\x06result\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x93\x01\n" +
+	"\x06Result\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12+\n" +
+	"\x04data\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x04data\x12*\n" +
+	"\x05error\x18\x04 \x01(\v2\x14.tack.v1.ResultErrorR\x05error\"O\n" +
+	"\vResultError\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code2\xf3\x01\n" +
+	"\vTackService\x12H\n" +
+	"\vListPlugins\x12\x1b.tack.v1.ListPluginsRequest\x1a\x1c.tack.v1.ListPluginsResponse\x12Z\n" +
+	"\x11DescribeOperation\x12!.tack.v1.DescribeOperationRequest\x1a\".tack.v1.DescribeOperationResponse\x12>\n" +
+	"\aExecute\x12\x17.tack.v1.ExecuteRequest\x1a\x18.tack.v1.ExecuteResponse0\x01B4Z2github.com/whiskeyjimb/tack-cli/api/tack/v1;tackv1b\x06proto3"
+
+var (
+	file_tack_v1_tack_proto_rawDescOnce sync.Once
+	file_tack_v1_tack_proto_rawDescData []byte
+)
+
+func file_tack_v1_tack_proto_rawDescGZIP() []byte {
+	file_tack_v1_tack_proto_rawDescOnce.Do(func() {
+		file_tack_v1_tack_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tack_v1_tack_proto_rawDesc), len(file_tack_v1_tack_proto_rawDesc)))
+	})
+	return file_tack_v1_tack_proto_rawDescData
+}
+
+var file_tack_v1_tack_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_tack_v1_tack_proto_goTypes = []any{
+	(*ListPluginsRequest)(nil),        // 0: tack.v1.ListPluginsRequest
+	(*ListPluginsResponse)(nil),       // 1: tack.v1.ListPluginsResponse
+	(*Plugin)(nil),                    // 2: tack.v1.Plugin
+	(*Service)(nil),                   // 3: tack.v1.Service
+	(*Operation)(nil),                 // 4: tack.v1.Operation
+	(*DescribeOperationRequest)(nil),  // 5: tack.v1.DescribeOperationRequest
+	(*DescribeOperationResponse)(nil), // 6: tack.v1.DescribeOperationResponse
+	(*Invocation)(nil),                // 7: tack.v1.Invocation
+	(*ExecuteRequest)(nil),            // 8: tack.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),           // 9: tack.v1.ExecuteResponse
+	(*Result)(nil),                    // 10: tack.v1.Result
+	(*ResultError)(nil),               // 11: tack.v1.ResultError
+	(*structpb.Struct)(nil),           // 12: google.protobuf.Struct
+}
+var file_tack_v1_tack_proto_depIdxs = []int32{
+	2,  // 0: tack.v1.ListPluginsResponse.plugins:type_name -> tack.v1.Plugin
+	3,  // 1: tack.v1.Plugin.services:type_name -> tack.v1.Service
+	4,  // 2: tack.v1.Service.operations:type_name -> tack.v1.Operation
+	12, // 3: tack.v1.DescribeOperationResponse.config_schema:type_name -> google.protobuf.Struct
+	12, // 4: tack.v1.DescribeOperationResponse.output_schema:type_name -> google.protobuf.Struct
+	12, // 5: tack.v1.Invocation.config:type_name -> google.protobuf.Struct
+	7,  // 6: tack.v1.ExecuteRequest.invocations:type_name -> tack.v1.Invocation
+	10, // 7: tack.v1.ExecuteResponse.result:type_name -> tack.v1.Result
+	12, // 8: tack.v1.Result.data:type_name -> google.protobuf.Struct
+	11, // 9: tack.v1.Result.error:type_name -> tack.v1.ResultError
+	0,  // 10: tack.v1.TackService.ListPlugins:input_type -> tack.v1.ListPluginsRequest
+	5,  // 11: tack.v1.TackService.DescribeOperation:input_type -> tack.v1.DescribeOperationRequest
+	8,  // 12: tack.v1.TackService.Execute:input_type -> tack.v1.ExecuteRequest
+	1,  // 13: tack.v1.TackService.ListPlugins:output_type -> tack.v1.ListPluginsResponse
+	6,  // 14: tack.v1.TackService.DescribeOperation:output_type -> tack.v1.DescribeOperationResponse
+	9,  // 15: tack.v1.TackService.Execute:output_type -> tack.v1.ExecuteResponse
+	13, // [13:16] is the sub-list for method output_type
+	10, // [10:13] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_tack_v1_tack_proto_init() }
+func file_tack_v1_tack_proto_init() {
+	if File_tack_v1_tack_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tack_v1_tack_proto_rawDesc), len(file_tack_v1_tack_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tack_v1_tack_proto_goTypes,
+		DependencyIndexes: file_tack_v1_tack_proto_depIdxs,
+		MessageInfos:      file_tack_v1_tack_proto_msgTypes,
+	}.Build()
+	File_tack_v1_tack_proto = out.File
+	file_tack_v1_tack_proto_goTypes = nil
+	file_tack_v1_tack_proto_depIdxs = nil
+}
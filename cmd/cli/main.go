@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 
 	internalcli "github.com/whiskeyjimb/tack-cli/internal/cli"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
 	"github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
 )
 
 func main() {
@@ -18,34 +23,117 @@ func main() {
 	defer cancel()
 
 	// Load config
+	done := internalcli.StartupPhase("config load")
 	cfg, err := config.Load(config.DefaultConfigPath())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: config error: %v\n", err)
 		cfg = config.DefaultConfig()
 	}
+	// A trusted workspace's config is read over the user's
+	if dir := workspace.Dir(); dir != "" && workspace.Trusted(dir) {
+		if err := cfg.LoadOverlay(filepath.Join(dir, "config.yaml")); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: workspace config error: %v\n", err)
+		}
+	}
 	cfg.ApplyEnvOverrides()
 
+	// The log file starts before anything else can warn or fail
+	logFile := ""
+	for i, arg := range os.Args {
+		if arg == "--"+internalcli.LogFileFlag && i+1 < len(os.Args) {
+			logFile = os.Args[i+1]
+		}
+		if v, ok := strings.CutPrefix(arg, "--"+internalcli.LogFileFlag+"="); ok {
+			logFile = v
+		}
+	}
+	if path := internalcli.LogPath(cfg, logFile); path != "" {
+		if err := internalcli.StartLogging(cfg, path, os.Args); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: log file: %v\n", err)
+		}
+	}
+
+	if err := internalcli.ConfigureNetwork(cfg, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: network config: %v\n", err)
+	}
+
+	if limit, err := cfg.ArtifactSizeLimit(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the default\n", err)
+	} else {
+		plugin.SetMaxArtifactSize(limit)
+	}
+	if limits, err := cfg.RegistryArtifactSizeLimits(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; ignoring per-registry limits\n", err)
+	} else if len(limits) > 0 {
+		plugin.SetRegistryArtifactSizes(limits)
+	}
+	plugin.SetCompressCache(cfg.CompressPlugins)
+
+	// The organization policy comes from the environment, not user config,
+	// and a policy that cannot be loaded and verified stops the CLI
+	policy, err := plugin.LoadPolicy(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", plugin.PolicyEnv, err)
+		internalcli.Exit(1)
+	}
+	plugin.SetPolicy(policy)
+
+	// Like the policy, mounts that cannot be applied stop the CLI rather
+	// than leave plugins an unrestricted view of the disk
+	mounts := make([]runtime.FSMount, 0, len(cfg.FSMounts))
+	for _, m := range cfg.FSMounts {
+		mounts = append(mounts, runtime.FSMount{HostPath: m.Host, Path: m.Path, ReadOnly: m.ReadOnly})
+	}
+	if err := runtime.SetFSMounts(mounts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: fs_mounts: %v\n", err)
+		internalcli.Exit(1)
+	}
+
+	if lp := cfg.Licenses; len(lp.Allowed) > 0 || len(lp.Forbidden) > 0 {
+		if lp.Mode != "" && lp.Mode != "enforce" && lp.Mode != "warn" {
+			fmt.Fprintf(os.Stderr, "Warning: licenses.mode %q is not enforce or warn; enforcing\n", lp.Mode)
+		}
+		plugin.SetLicensePolicy(&plugin.LicensePolicy{Allowed: lp.Allowed, Forbidden: lp.Forbidden, WarnOnly: lp.Mode == "warn"})
+	}
+
 	if err := cfg.ValidateGroups(); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: invalid group config: %v\n", err)
 		cfg.Groups = nil
 	}
+	done()
 
 	// Initialize plugin service stack
+	done = internalcli.StartupPhase("stack init")
 	stack, err := plugin.NewPluginStack(plugin.PluginServiceConfig{
-		RequireSigning: cfg.RequireSigning,
+		RequireSigning:      cfg.RequireSigning || (policy != nil && policy.RequireSignatures),
+		PlainHTTPRegistries: cfg.Network.PlainHTTP,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to initialize plugin service: %v\n", err)
 		// We continue without the stack (OCI fallback will be disabled)
 	}
+	done()
 
+	done = internalcli.StartupPhase("root command")
 	root := internalcli.NewRootCommand(cfg, stack, config.DefaultConfigPath())
+	done()
+	// Commands on the root before plugins are registered are built in,
+	// apart from aliases; the rest are listed when a command is unknown
+	builtins := map[string]bool{"help": true}
+	for _, cmd := range root.Commands() {
+		if _, alias := cfg.Aliases[cmd.Name()]; !alias {
+			builtins[cmd.Name()] = true
+		}
+	}
 
 	// Discover and register plugin commands
 	outputFormat := cfg.Output
 	verbose := false
+	quiet := cfg.Quiet
 	trustPlugins := false
-	// Find --output, --verbose, and --trust-plugins in args (simple scan before cobra parsing)
+	egressReport := false
+	stats := false
+	// Find --output, --quiet, --verbose, --trust-plugins, --read-only, --egress-report, --stats, and --no-cache in args (simple scan before cobra parsing)
 	for i, arg := range os.Args {
 		if arg == "--output" && i+1 < len(os.Args) {
 			outputFormat = os.Args[i+1]
@@ -56,16 +144,81 @@ func main() {
 		if arg == "--trust-plugins" {
 			trustPlugins = true
 		}
+		if arg == "--quiet" {
+			quiet = true
+		}
+		if arg == "--read-only" {
+			cfg.ReadOnly = true
+		}
+		if arg == "--"+internalcli.EgressReportFlag {
+			egressReport = true
+		}
+		if arg == "--"+internalcli.StatsFlag {
+			stats = true
+		}
+		if arg == "--"+internalcli.NoCacheFlag {
+			plugin.SetTagCacheTTL(0)
+		}
+	}
+	runtime.SetReadOnly(cfg.ReadOnly)
+	runtime.SetAllowExecPlugins(cfg.ExecPluginsAllowed())
+	runtime.SetEgressRecording(egressReport)
+	runtime.SetUsageRecording(stats)
+	// One WASM runtime serves discovery and the operation that runs
+	runner := runtime.NewSharedRunner(runtime.WithVerbose(verbose), runtime.WithTrustPlugins(trustPlugins))
+	if internalcli.NeedsPluginCommands(os.Args[1:]) {
+		_ = internalcli.RegisterPluginCommands(root, &outputFormat, &verbose, &trustPlugins, cfg, stack, runner)
+	}
+
+	// Unknown commands run a tack-<name> executable when there is one,
+	// after built-in and WASM plugin commands had their chance
+	if path, args, ok := internalcli.ExternalCommand(root, cfg, os.Args[1:]); ok {
+		format := outputFormat
+		if quiet {
+			format = "quiet"
+		}
+		code, err := internalcli.RunExternalPlugin(ctx, cfg, path, args, format)
+		_ = runner.Close(context.Background())
+		if err != nil {
+			internalcli.WriteError(os.Stderr, outputFormat, err)
+			code = errcode.ExitCode(err)
+		}
+		internalcli.Exit(code)
 	}
-	_ = internalcli.RegisterPluginCommands(root, &outputFormat, &verbose, &trustPlugins, cfg, stack)
 
-	if err := root.ExecuteContext(ctx); err != nil {
+	done = internalcli.StartupPhase("command run")
+	err = root.ExecuteContext(ctx)
+	done()
+	_ = runner.Close(context.Background())
+	if os.Getenv(internalcli.DebugTimingEnv) != "" {
+		_ = internalcli.WriteStartupTiming(os.Stderr)
+	}
+	if egressReport {
+		_ = internalcli.WriteEgressReport(os.Stderr)
+	}
+	if stats {
+		_ = internalcli.WriteUsageReport(os.Stderr)
+		if err := internalcli.RecordUsage(history.Open(history.DefaultPath())); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording stats: %v\n", err)
+		}
+	}
+	if err == nil && !quiet {
+		internalcli.NotifyNewVersion(os.Stderr, cfg, outputFormat)
+	}
+	if err != nil {
 		msg := err.Error()
 		if strings.Contains(msg, "unknown command") {
 			parts := strings.Split(msg, "\"")
 			if len(parts) >= 2 {
 				unknownCmd := parts[1]
-				fmt.Fprintf(os.Stderr, "Error: plugin %q not found\n", unknownCmd)
+				if code, ok := internalcli.OfferInstall(ctx, cfg, unknownCmd, os.Args[1:], quiet); ok {
+					internalcli.Exit(code)
+				}
+				notFound := errcode.Errorf(errcode.PluginNotFound, "plugin %q not found", unknownCmd)
+				internalcli.WriteError(os.Stderr, outputFormat, notFound)
+				if outputFormat == "json" {
+					internalcli.Exit(errcode.ExitCode(notFound))
+				}
 
 				// Check if the unknown command is a plugin inside a group
 				if cfg.Groups != nil {
@@ -81,23 +234,29 @@ func main() {
 
 				// List available top-level commands
 				var installed []string
-				staticCmds := map[string]bool{
-					"completion": true, "help": true, "plugin": true,
-					"version": true, "group": true,
-				}
 				for _, cmd := range root.Commands() {
-					if !staticCmds[cmd.Name()] {
+					if !builtins[cmd.Name()] {
 						installed = append(installed, cmd.Name())
 					}
 				}
 				if len(installed) > 0 {
 					fmt.Fprintf(os.Stderr, "  Available: %s\n", strings.Join(installed, ", "))
 				}
-				fmt.Fprintf(os.Stderr, "  To install: %s plugin install %s\n", meta.AppName, unknownCmd)
-				os.Exit(1)
+				// Plugins published by several indexes are installed by
+				// their source-qualified name
+				sources := plugin.CachedIndexSources(unknownCmd)
+				if len(sources) > 1 || (len(sources) == 1 && sources[0] != "official") {
+					for _, src := range sources {
+						fmt.Fprintf(os.Stderr, "  To install: %s plugin install %s/%s\n", meta.AppName, src, unknownCmd)
+					}
+				} else {
+					fmt.Fprintf(os.Stderr, "  To install: %s plugin install %s\n", meta.AppName, unknownCmd)
+				}
+				internalcli.Exit(errcode.ExitCode(notFound))
 			}
 		}
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		internalcli.WriteError(os.Stderr, outputFormat, err)
+		internalcli.Exit(errcode.ExitCode(err))
 	}
+	internalcli.StopLogging()
 }
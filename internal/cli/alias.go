@@ -29,7 +29,7 @@ func registerAliases(root *cobra.Command, aliases map[string]string) {
 			DisableFlagParsing: true,
 			RunE: func(cmd *cobra.Command, args []string) error {
 				// Split the alias target into parts and append any additional args
-				targetParts := strings.Fields(aliasTarget)
+				targetParts := splitAliasTarget(aliasTarget)
 				allArgs := append(targetParts, args...)
 
 				// Reset and re-execute the root with the expanded args
@@ -41,3 +41,49 @@ func registerAliases(root *cobra.Command, aliases map[string]string) {
 		root.AddCommand(cmd)
 	}
 }
+
+// splitAliasTarget splits an alias target into arguments on whitespace,
+// keeping quoted strings and {{ }} template actions whole so that aliases
+// such as `dig: dns resolve --hostname '{{ env "HOST" }}'` keep their
+// templates. Quotes around an argument are removed.
+func splitAliasTarget(target string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quote   byte
+		inToken bool
+	)
+	for i := 0; i < len(target); i++ {
+		c := target[i]
+		switch {
+		case strings.HasPrefix(target[i:], "{{"):
+			end := strings.Index(target[i:], "}}")
+			if end < 0 {
+				end = len(target) - i - 2
+			}
+			current.WriteString(target[i : i+end+2])
+			i += end + 1
+			inToken = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inToken = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inToken {
+				args = append(args, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inToken {
+		args = append(args, current.String())
+	}
+	return args
+}
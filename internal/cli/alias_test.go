@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -37,3 +38,22 @@ func TestRegisterAliases(t *testing.T) {
 		t.Errorf("unexpected short description: %q", sgCmd.Short)
 	}
 }
+
+func TestSplitAliasTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"aws ec2 describe_security_groups", []string{"aws", "ec2", "describe_security_groups"}},
+		{"dns resolve --hostname {{ env \"HOST\" }}", []string{"dns", "resolve", "--hostname", "{{ env \"HOST\" }}"}},
+		{"dns resolve --hostname '{{ env \"HOST\" }}'", []string{"dns", "resolve", "--hostname", "{{ env \"HOST\" }}"}},
+		{"dns resolve --hostname \"{{ env \"HOST\" }}.internal\"", []string{"dns", "resolve", "--hostname", "{{ env \"HOST\" }}.internal"}},
+		{"http get --header 'X-Team: ops'  --body ''", []string{"http", "get", "--header", "X-Team: ops", "--body", ""}},
+	}
+	for _, tt := range tests {
+		got := splitAliasTarget(tt.target)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitAliasTarget(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
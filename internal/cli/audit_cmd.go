@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// severityRank orders advisory severities; unknown severities rank highest
+// so they are never filtered out.
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// auditFinding is an advisory affecting an installed plugin version.
+type auditFinding struct {
+	Plugin   string `json:"plugin" yaml:"plugin"`
+	Version  string `json:"version" yaml:"version"`
+	Advisory string `json:"advisory" yaml:"advisory"`
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Revoked  bool   `json:"revoked,omitempty" yaml:"revoked,omitempty"`
+	Summary  string `json:"summary" yaml:"summary"`
+	FixedIn  string `json:"fixed_in,omitempty" yaml:"fixed_in,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+	Source   string `json:"source" yaml:"source"`
+}
+
+// installedArtifact identifies one cached plugin version.
+type installedArtifact struct {
+	Name, Version, Digest string
+}
+
+// newAuditCommand creates the "audit" command group.
+func newAuditCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report the security state of installed plugins",
+		Long: fmt.Sprintf(`Report the security state of installed plugins in one place: the network
+destinations and commands each plugin's module requests, whether its
+signature was checked, whether an OCI plugin is pinned to a digest or
+version, the capability grants remembered on disk and their age, and
+quarantined artifacts. A plugin whose manifest section does not match its
+module is reported as refused.
+
+Signatures of local plugins are checked against the default public key.
+OCI plugins are "enforced" when signing is required by config or policy,
+since unverified pulls are then refused, and "unverified" otherwise.
+
+Use "audit plugins" to check installed versions against advisories.
+
+Examples:
+  %s audit
+  %s audit --output json`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			var verifier signature.Verifier
+			if _, err := os.Stat(internalplugin.DefaultPublicKeyPath()); err == nil {
+				if verifier, err = internalplugin.LoadVerifier(internalplugin.DefaultPublicKeyPath()); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v; local signatures are not checked\n", err)
+				}
+			}
+			policy := internalplugin.ActivePolicy()
+			report, err := securityAudit(securityAuditInput{
+				discovered:      discovered,
+				installed:       internalplugin.LoadCache(internalplugin.DefaultCachePath()).Installed,
+				grantsPath:      runtime.GrantsPath(),
+				quarantineDir:   stack.QuarantineDir,
+				signingRequired: cfg.RequireSigning || (policy != nil && policy.RequireSignatures),
+				verifier:        verifier,
+				now:             time.Now(),
+				manifest: func(dp internalplugin.DiscoveredPlugin) (abi.Manifest, error) {
+					return internalplugin.ModuleManifest(ctx, dp)
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderSecurityReport(cmd.OutOrStdout(), format, report)
+		},
+	}
+	cmd.AddCommand(newAuditPluginsCommand(stack, cfg))
+	return cmd
+}
+
+// newAuditPluginsCommand creates the "audit plugins" command.
+func newAuditPluginsCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var minSeverity string
+
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Flag installed plugin versions with known advisories",
+		Long: fmt.Sprintf(`Check every plugin version in the local cache against the advisories
+published by the configured indexes, either in the index itself or in the
+document its advisories_url names. An advisory matches by version range or
+by artifact digest; advisories marked revoked flag artifacts whose
+signatures must no longer be trusted.
+
+The command exits non-zero when any advisory matches, so CI can gate on it.
+
+Examples:
+  %s audit plugins
+  %s audit plugins --min-severity high --output json`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, ok := severityRank[minSeverity]; minSeverity != "" && !ok {
+				return fmt.Errorf("invalid --min-severity %q (supported: low, medium, high, critical)", minSeverity)
+			}
+
+			ctx := cmd.Context()
+			plugins, err := stack.Service.ListCachedPlugins(ctx)
+			if err != nil {
+				return fmt.Errorf("listing installed plugins: %w", err)
+			}
+			installed := make([]installedArtifact, 0, len(plugins))
+			for _, p := range plugins {
+				installed = append(installed, installedArtifact{
+					Name:    p.Metadata().Name(),
+					Version: p.Metadata().Version(),
+					Digest:  p.Digest().String(),
+				})
+			}
+
+			advisories, err := internalplugin.FetchAdvisories(ctx, buildIndexSources(cfg))
+			if err != nil {
+				return err
+			}
+
+			findings := auditPlugins(installed, advisories, minSeverity)
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			if err := renderAuditFindings(cmd.OutOrStdout(), format, findings); err != nil {
+				return err
+			}
+			if len(findings) > 0 {
+				return fmt.Errorf("%d advisories affect installed plugins", len(findings))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "Ignore advisories below this severity: low, medium, high, critical (revoked signatures are always reported)")
+	return cmd
+}
+
+// auditPlugins matches installed plugin versions against advisories. Each
+// advisory is reported once per affected version, ordered by plugin and
+// version. Advisories with an invalid range are skipped with a warning.
+func auditPlugins(installed []installedArtifact, advisories []internalplugin.SourcedAdvisory, minSeverity string) []auditFinding {
+	findings := []auditFinding{}
+	seen := map[string]bool{}
+	for _, a := range advisories {
+		if !a.Revoked && minSeverity != "" && severityRank[a.Severity] != 0 &&
+			severityRank[a.Severity] < severityRank[minSeverity] {
+			continue
+		}
+		for _, p := range installed {
+			affected, err := a.Affects(p.Name, p.Version, p.Digest)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s index: %v\n", a.Source, err)
+				break
+			}
+			key := a.ID + "\x00" + p.Name + "\x00" + p.Version
+			if !affected || seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, auditFinding{
+				Plugin:   p.Name,
+				Version:  p.Version,
+				Advisory: a.ID,
+				Severity: a.Severity,
+				Revoked:  a.Revoked,
+				Summary:  a.Summary,
+				FixedIn:  a.FixedIn,
+				URL:      a.URL,
+				Source:   a.Source,
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Plugin != findings[j].Plugin {
+			return findings[i].Plugin < findings[j].Plugin
+		}
+		return internalplugin.NewerVersion(findings[i].Version, findings[j].Version)
+	})
+	return findings
+}
+
+// renderAuditFindings writes audit findings in the given output format.
+func renderAuditFindings(w io.Writer, format string, findings []auditFinding) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(findings)
+	case "table", "":
+		if len(findings) == 0 {
+			_, _ = fmt.Fprintln(w, "No known advisories affect installed plugins.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tVERSION\tADVISORY\tSEVERITY\tFIXED IN\tSUMMARY")
+		for _, f := range findings {
+			severity := f.Severity
+			if f.Revoked {
+				severity = "revoked"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				f.Plugin, f.Version, f.Advisory, severity, f.FixedIn, f.Summary)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
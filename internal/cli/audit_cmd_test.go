@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func TestAuditPlugins(t *testing.T) {
+	installed := []installedArtifact{
+		{Name: "dns", Version: "1.0.0", Digest: "sha256:a"},
+		{Name: "dns", Version: "1.2.0", Digest: "sha256:b"},
+		{Name: "tcp", Version: "0.3.0", Digest: "sha256:c"},
+	}
+	advisories := []internalplugin.SourcedAdvisory{
+		{Advisory: internalplugin.Advisory{ID: "A-1", Plugin: "dns", Affected: "< 1.1.0", Severity: "high", FixedIn: "1.1.0"}, Source: "official"},
+		{Advisory: internalplugin.Advisory{ID: "A-2", Plugin: "dns", Affected: "*", Severity: "low"}, Source: "official"},
+		{Advisory: internalplugin.Advisory{ID: "A-3", Plugin: "tcp", Digests: []string{"sha256:c"}, Revoked: true}, Source: "official"},
+		{Advisory: internalplugin.Advisory{ID: "A-4", Plugin: "http", Affected: "*"}, Source: "official"},
+	}
+
+	findings := auditPlugins(installed, advisories, "")
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Plugin+"@"+f.Version+":"+f.Advisory)
+	}
+	if strings.Join(got, ",") != "dns@1.2.0:A-2,dns@1.0.0:A-1,dns@1.0.0:A-2,tcp@0.3.0:A-3" {
+		t.Errorf("unexpected findings: %v", got)
+	}
+
+	// Low-severity advisories are dropped; revoked signatures never are.
+	findings = auditPlugins(installed, advisories, "medium")
+	if len(findings) != 2 || findings[0].Advisory != "A-1" || findings[1].Advisory != "A-3" {
+		t.Errorf("unexpected filtered findings: %+v", findings)
+	}
+
+	var buf bytes.Buffer
+	if err := renderAuditFindings(&buf, "table", findings); err != nil {
+		t.Fatalf("renderAuditFindings: %v", err)
+	}
+	if !strings.Contains(buf.String(), "revoked") || !strings.Contains(buf.String(), "1.1.0") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestSecurityAudit(t *testing.T) {
+	dir := t.TempDir()
+	unsigned := filepath.Join(dir, "local.wasm")
+	signed := filepath.Join(dir, "signed.wasm")
+	for _, p := range []string{unsigned, signed} {
+		if err := os.WriteFile(p, []byte("wasm"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(signed+".sig", []byte("c2ln"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	grantsPath := filepath.Join(dir, "grants.yaml")
+	grants := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"ls"}}}
+	if err := grantstore.NewFileStore(grantstore.WithPath(grantsPath)).Save(grants); err != nil {
+		t.Fatal(err)
+	}
+	quarantineDir := filepath.Join(dir, "quarantine")
+	bad := filepath.Join(dir, "bad.wasm")
+	if err := os.WriteFile(bad, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := internalplugin.Quarantine(quarantineDir, bad, "digest mismatch"); err != nil {
+		t.Fatal(err)
+	}
+
+	withCaps := func(name, source, path string, caps hostfunc.GrantSet) internalplugin.DiscoveredPlugin {
+		return internalplugin.DiscoveredPlugin{Manifest: abi.Manifest{Name: name, Version: "1.0.0", Capabilities: caps}, Source: source, Path: path}
+	}
+	network := hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"example.com"}, Ports: []string{"443"}}}}}
+	report, err := securityAudit(securityAuditInput{
+		discovered: []internalplugin.DiscoveredPlugin{
+			withCaps("web", "oci", filepath.Join(dir, "web"), network),
+			withCaps("dns", "embedded", "", hostfunc.GrantSet{}),
+			withCaps("local", "local", unsigned, hostfunc.GrantSet{}),
+			withCaps("signed", "local", signed, hostfunc.GrantSet{}),
+			withCaps("tip", "oci", filepath.Join(dir, "tip"), hostfunc.GrantSet{}),
+		},
+		installed: map[string]internalplugin.InstallRecord{
+			"web": {Reference: "ghcr.io/acme/plugins/web:1.0.0"},
+			"tip": {Reference: "ghcr.io/acme/plugins/tip:latest"},
+		},
+		grantsPath:    grantsPath,
+		quarantineDir: quarantineDir,
+		now:           time.Now().Add(48 * time.Hour),
+		manifest: func(dp internalplugin.DiscoveredPlugin) (abi.Manifest, error) {
+			switch dp.Manifest.Name {
+			case "tip":
+				return abi.Manifest{}, fmt.Errorf("tip: %w", internalplugin.ErrManifestMismatch)
+			case "local":
+				// The module requests what its section left out
+				return withCaps("local", "local", unsigned, network).Manifest, nil
+			}
+			return dp.Manifest, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][2]string{
+		"dns":    {signatureEmbedded, ""},
+		"local":  {signatureUnsigned, ""},
+		"signed": {signatureUnverified, ""},
+		"tip":    {signatureUnverified, pinUnpinned},
+		"web":    {signatureUnverified, pinVersion},
+	}
+	if len(report.Plugins) != len(want) {
+		t.Fatalf("plugins = %+v", report.Plugins)
+	}
+	for _, p := range report.Plugins {
+		if got := [2]string{p.Signature, p.Pin}; got != want[p.Name] {
+			t.Errorf("%s: signature, pin = %v, want %v", p.Name, got, want[p.Name])
+		}
+	}
+	if web := report.Plugins[4]; len(web.Network) != 1 || web.Network[0] != "example.com:443" {
+		t.Errorf("web network = %v", web.Network)
+	}
+	if local := report.Plugins[1]; len(local.Network) != 1 || local.Refused != "" {
+		t.Errorf("local = %+v, want the network access its module requests", local)
+	}
+	if tip := report.Plugins[3]; tip.Refused == "" {
+		t.Errorf("expected tip to be refused, got %+v", tip)
+	}
+	if g := report.Grants; g == nil || g.AgeDays != 2 || len(g.Grants) != 1 || g.Grants[0] != `command "ls"` {
+		t.Errorf("grants = %+v", g)
+	}
+	if len(report.Quarantined) != 1 || report.Quarantined[0].Reason != "digest mismatch" {
+		t.Errorf("quarantined = %+v", report.Quarantined)
+	}
+
+	var buf bytes.Buffer
+	if err := renderSecurityReport(&buf, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"example.com:443", "Grants: " + grantsPath, "digest mismatch", "Refused: tip: "} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("table output missing %q:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestPinOf(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/acme/plugins/web@sha256:abc": pinDigest,
+		"ghcr.io/acme/plugins/web:1.2.0":      pinVersion,
+		"ghcr.io/acme/plugins/web:latest":     pinUnpinned,
+		"localhost:5000/acme/plugins/web":     pinUnpinned,
+	}
+	for ref, want := range tests {
+		if got := pinOf(ref); got != want {
+			t.Errorf("pinOf(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
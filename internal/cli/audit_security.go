@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
+	"github.com/sigstore/sigstore/pkg/signature"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// Signature states of an installed plugin in the security report.
+const (
+	signatureEmbedded   = "embedded"   // shipped in the binary
+	signatureVerified   = "verified"   // local .sig verifies with the default key
+	signatureInvalid    = "invalid"    // local .sig does not verify
+	signatureUnverified = "unverified" // signed or pulled, but never checked
+	signatureUnsigned   = "unsigned"   // local file without a signature
+	signatureEnforced   = "enforced"   // pulled while signatures were required
+)
+
+// Pin states of an installed plugin in the security report.
+const (
+	pinDigest   = "digest"
+	pinVersion  = "version"
+	pinUnpinned = "unpinned"
+	pinUnknown  = "unknown"
+)
+
+// securityReport is the output of "audit".
+type securityReport struct {
+	Plugins     []pluginSecurity                  `json:"plugins" yaml:"plugins"`
+	Grants      *grantsState                      `json:"grants,omitempty" yaml:"grants,omitempty"`
+	Quarantined []internalplugin.QuarantineRecord `json:"quarantined" yaml:"quarantined"`
+}
+
+// pluginSecurity is the security state of one installed plugin.
+type pluginSecurity struct {
+	Name      string   `json:"name" yaml:"name"`
+	Version   string   `json:"version" yaml:"version"`
+	Source    string   `json:"source" yaml:"source"`
+	Reference string   `json:"reference,omitempty" yaml:"reference,omitempty"`
+	Variant   string   `json:"variant,omitempty" yaml:"variant,omitempty"`
+	Network   []string `json:"network,omitempty" yaml:"network,omitempty"`
+	Exec      []string `json:"exec,omitempty" yaml:"exec,omitempty"`
+	Signature string   `json:"signature" yaml:"signature"`
+
+	// Refused says why the plugin will not run, such as a manifest section
+	// that claims other capabilities than its module requests.
+	Refused string `json:"refused,omitempty" yaml:"refused,omitempty"`
+
+	// Pin says how an OCI plugin was installed: by digest, by version, or
+	// by a moving tag such as latest. It is empty for other sources.
+	Pin string `json:"pin,omitempty" yaml:"pin,omitempty"`
+}
+
+// grantsState describes the capability grants remembered on disk.
+type grantsState struct {
+	Path       string    `json:"path" yaml:"path"`
+	ModifiedAt time.Time `json:"modified_at" yaml:"modified_at"`
+	AgeDays    int       `json:"age_days" yaml:"age_days"`
+	Grants     []string  `json:"grants" yaml:"grants"`
+}
+
+// securityAuditInput is what the security report is built from.
+type securityAuditInput struct {
+	discovered      []internalplugin.DiscoveredPlugin
+	installed       map[string]internalplugin.InstallRecord
+	grantsPath      string
+	quarantineDir   string
+	signingRequired bool
+	verifier        signature.Verifier // nil when no public key is configured
+	now             time.Time
+
+	// manifest returns the manifest a plugin's module reports; nil uses
+	// the discovered one
+	manifest func(internalplugin.DiscoveredPlugin) (abi.Manifest, error)
+}
+
+// securityAudit builds the security report.
+func securityAudit(in securityAuditInput) (securityReport, error) {
+	report := securityReport{Plugins: []pluginSecurity{}}
+	for _, dp := range in.discovered {
+		p := pluginSecurity{
+			Name:    dp.Manifest.Name,
+			Version: dp.Manifest.Version,
+			Source:  dp.Source,
+		}
+		manifest := dp.Manifest
+		if in.manifest != nil {
+			var err error
+			if manifest, err = in.manifest(dp); err != nil {
+				p.Refused = err.Error()
+			}
+		}
+		p.Network, p.Exec = networkAndExec(&manifest.Capabilities)
+		switch dp.Source {
+		case "embedded":
+			p.Signature = signatureEmbedded
+		case "oci":
+			p.Signature = signatureUnverified
+			if in.signingRequired {
+				p.Signature = signatureEnforced
+			}
+			p.Pin = pinUnknown
+			if record, ok := in.installed[dp.Manifest.Name]; ok {
+				p.Reference = record.Reference
+				p.Variant = record.Variant
+				p.Pin = pinOf(record.Reference)
+			}
+		default:
+			p.Signature = localSignature(dp.Path, in.verifier)
+		}
+		report.Plugins = append(report.Plugins, p)
+	}
+	sort.Slice(report.Plugins, func(i, j int) bool { return report.Plugins[i].Name < report.Plugins[j].Name })
+
+	grants, err := readGrantsState(in.grantsPath, in.now)
+	if err != nil {
+		return securityReport{}, err
+	}
+	report.Grants = grants
+
+	quarantined, err := internalplugin.ListQuarantine(in.quarantineDir)
+	if err != nil {
+		return securityReport{}, fmt.Errorf("listing quarantine: %w", err)
+	}
+	report.Quarantined = quarantined
+	if report.Quarantined == nil {
+		report.Quarantined = []internalplugin.QuarantineRecord{}
+	}
+	return report, nil
+}
+
+// networkAndExec lists the network destinations and commands caps request.
+func networkAndExec(caps *hostfunc.GrantSet) (network, exec []string) {
+	if caps.Network != nil {
+		for _, rule := range caps.Network.Rules {
+			for _, host := range rule.Hosts {
+				for _, port := range rule.Ports {
+					network = append(network, host+":"+port)
+				}
+			}
+		}
+	}
+	if caps.Exec != nil {
+		exec = append(exec, caps.Exec.Commands...)
+	}
+	return network, exec
+}
+
+// pinOf classifies how an OCI reference selects its artifact.
+func pinOf(ref string) string {
+	if strings.Contains(ref, "@sha256:") {
+		return pinDigest
+	}
+	tag := ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		tag = ref[i+1:]
+	}
+	if tag == "" || tag == "latest" {
+		return pinUnpinned
+	}
+	return pinVersion
+}
+
+// localSignature checks the signature file next to a local plugin.
+func localSignature(path string, verifier signature.Verifier) string {
+	sig, err := os.ReadFile(path + ".sig")
+	if err != nil {
+		if _, err := os.Stat(path + ".bundle"); err == nil {
+			return signatureUnverified
+		}
+		return signatureUnsigned
+	}
+	if verifier == nil {
+		return signatureUnverified
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || internalplugin.VerifyBlob(data, string(sig), verifier) != nil {
+		return signatureInvalid
+	}
+	return signatureVerified
+}
+
+// readGrantsState reads the grants file, or returns nil when there is none.
+func readGrantsState(path string, now time.Time) (*grantsState, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading grants: %w", err)
+	}
+	grants, err := grantstore.NewFileStore(grantstore.WithPath(path)).Load()
+	if err != nil {
+		return nil, fmt.Errorf("reading grants %s: %w", path, err)
+	}
+	list := internalplugin.AddedCapabilities(nil, grants)
+	if list == nil {
+		list = []string{}
+	}
+	return &grantsState{
+		Path:       path,
+		ModifiedAt: info.ModTime(),
+		AgeDays:    int(now.Sub(info.ModTime()).Hours() / 24),
+		Grants:     list,
+	}, nil
+}
+
+// renderSecurityReport writes the security report in the given output format.
+func renderSecurityReport(w io.Writer, format string, report securityReport) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tVERSION\tSOURCE\tNETWORK\tEXEC\tSIGNATURE\tPIN")
+		for _, p := range report.Plugins {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				p.Name, p.Version, p.Source, listOrDash(p.Network), listOrDash(p.Exec), p.Signature, orDash(p.Pin))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		for _, p := range report.Plugins {
+			if p.Refused != "" {
+				_, _ = fmt.Fprintf(w, "Refused: %s\n", p.Refused)
+			}
+		}
+
+		_, _ = fmt.Fprintln(w)
+		if g := report.Grants; g == nil {
+			_, _ = fmt.Fprintln(w, "Grants: none")
+		} else {
+			_, _ = fmt.Fprintf(w, "Grants: %s (modified %s, %d days ago)\n", g.Path, g.ModifiedAt.Local().Format(time.DateTime), g.AgeDays)
+			for _, grant := range g.Grants {
+				_, _ = fmt.Fprintf(w, "  %s\n", grant)
+			}
+		}
+
+		_, _ = fmt.Fprintln(w)
+		if len(report.Quarantined) == 0 {
+			_, err := fmt.Fprintln(w, "Quarantine: none")
+			return err
+		}
+		_, _ = fmt.Fprintln(w, "Quarantine:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tPLUGIN\tQUARANTINED\tREASON")
+		for _, r := range report.Quarantined {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.ID, r.Name, r.QuarantinedAt.Local().Format(time.DateTime), r.Reason)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+func listOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"golang.org/x/term"
+)
+
+// autoInstalledEnv is set for the command run after an on-demand install.
+const autoInstalledEnv = "TACK_AUTO_INSTALLED"
+
+// OfferInstall handles a command naming a plugin that is not installed but
+// is published by exactly one cached index. With auto_install set, or once
+// confirmed at a prompt, it installs the plugin and runs the command line
+// again, returning its exit code and true. It returns false, leaving the
+// caller to report the unknown command, when there is nothing to install,
+// the plugin is published by several indexes, read-only mode is on, or the
+// install is declined or fails.
+func OfferInstall(ctx context.Context, cfg *config.Config, name string, args []string, quiet bool) (int, bool) {
+	// The command run after an install never installs again, so a plugin
+	// that still does not resolve cannot loop
+	if cfg.ReadOnly || os.Getenv(autoInstalledEnv) != "" {
+		return 0, false
+	}
+	target, source, ok := installCandidate(name, pluginpkg.CachedIndexSources(name))
+	if !ok {
+		return 0, false
+	}
+	if !cfg.AutoInstall {
+		interactive := !quiet && term.IsTerminal(int(os.Stdin.Fd()))
+		if !interactive || !confirmInstall(os.Stdin, os.Stderr, name, source) {
+			return 0, false
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, false
+	}
+	if err := runSelf(ctx, exe, nil, "plugin", "install", target); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: installing %s: %v\n", target, err)
+		return 0, false
+	}
+	if err := runSelf(ctx, exe, []string{autoInstalledEnv + "=" + name}, args...); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode(), true
+		}
+		return 1, true
+	}
+	return 0, true
+}
+
+// installCandidate returns what "plugin install" is given for name, and
+// the index publishing it, when exactly one index does. The official index
+// installs by plain name, others by source-qualified name.
+func installCandidate(name string, sources []string) (target, source string, ok bool) {
+	if len(sources) != 1 {
+		return "", "", false
+	}
+	if sources[0] == "official" {
+		return name, sources[0], true
+	}
+	return sources[0] + "/" + name, sources[0], true
+}
+
+// confirmInstall asks whether to install name from source and run the
+// command. Only "y" or "yes" confirms.
+func confirmInstall(in io.Reader, out io.Writer, name, source string) bool {
+	_, _ = fmt.Fprintf(out, "Plugin %q is not installed, but the %s index has it. Install it and run the command? [y/N] ", name, source)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// runSelf runs this binary with args and extra environment variables,
+// connected to the standard streams.
+func runSelf(ctx context.Context, exe string, env []string, args ...string) error {
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.Run()
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInstallCandidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		sources    []string
+		wantTarget string
+		wantOK     bool
+	}{
+		{name: "none", sources: nil, wantOK: false},
+		{name: "several", sources: []string{"official", "acme"}, wantOK: false},
+		{name: "official", sources: []string{"official"}, wantTarget: "dns", wantOK: true},
+		{name: "other", sources: []string{"acme"}, wantTarget: "acme/dns", wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _, ok := installCandidate("dns", tt.sources)
+			if ok != tt.wantOK || target != tt.wantTarget {
+				t.Errorf("installCandidate() = %q, %v; want %q, %v", target, ok, tt.wantTarget, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConfirmInstall(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirmInstall(strings.NewReader(tt.input), &out, "dns", "official"); got != tt.want {
+			t.Errorf("confirmInstall(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), `Plugin "dns" is not installed`) {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
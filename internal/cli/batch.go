@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// Flags shared by commands that run many operations in one go.
+const (
+	failFastFlag    = "fail-fast"
+	stepTimeoutFlag = "step-timeout"
+)
+
+// failFastMessage explains why a run was skipped after --fail-fast tripped.
+const failFastMessage = "not run: an earlier run did not succeed (fail-fast)"
+
+// batchSummary counts the outcomes of a batch of runs.
+type batchSummary struct {
+	Total    int
+	Passed   int
+	Failed   int
+	Errored  int
+	Skipped  int
+	Duration time.Duration
+}
+
+// summarize counts statuses by outcome. Statuses other than success,
+// failure and error count as skipped.
+func summarize(statuses []string, duration time.Duration) batchSummary {
+	s := batchSummary{Total: len(statuses), Duration: duration}
+	for _, status := range statuses {
+		switch status {
+		case string(abi.ResultStatusSuccess):
+			s.Passed++
+		case string(abi.ResultStatusFailure):
+			s.Failed++
+		case string(abi.ResultStatusError):
+			s.Errored++
+		default:
+			s.Skipped++
+		}
+	}
+	return s
+}
+
+// write prints the summary as a block, for stderr so that it does not mix
+// with machine-readable output on stdout.
+func (s batchSummary) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "\nSummary:")
+	_, _ = fmt.Fprintf(tw, "  Total:\t%d\n", s.Total)
+	_, _ = fmt.Fprintf(tw, "  Passed:\t%d\n", s.Passed)
+	_, _ = fmt.Fprintf(tw, "  Failed:\t%d\n", s.Failed)
+	_, _ = fmt.Fprintf(tw, "  Errored:\t%d\n", s.Errored)
+	_, _ = fmt.Fprintf(tw, "  Skipped:\t%d\n", s.Skipped)
+	_, _ = fmt.Fprintf(tw, "  Duration:\t%s\n", s.Duration.Round(time.Millisecond))
+	return tw.Flush()
+}
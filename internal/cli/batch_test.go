@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchSummary(t *testing.T) {
+	s := summarize([]string{"success", "failure", "error", "success", "skipped"}, 1234*time.Millisecond)
+	if s.Total != 5 || s.Passed != 2 || s.Failed != 1 || s.Errored != 1 || s.Skipped != 1 {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := s.write(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Summary:", "Total:    5", "Passed:   2", "Errored:  1", "Duration: 1.234s"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("summary missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// embeddedPluginsDir is where the embed_plugins build embeds plugins from,
+// relative to the module root.
+const embeddedPluginsDir = "internal/plugin/plugins"
+
+// newBundleCommand creates the "bundle" command.
+func newBundleCommand(stack *internalplugin.PluginStack) *cobra.Command {
+	var (
+		output       string
+		requirements string
+		source       string
+		goos, goarch string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bundle [name[@version]...]",
+		Short: "Build a binary with installed plugins embedded",
+		Long: fmt.Sprintf(`Build a single %[1]s binary with plugins embedded, for teams that want one
+file to distribute. The plugins come from the local cache: the named ones
+(newest installed version unless one is given), those in a requirements
+document written by "plugin freeze" (-f), or every installed plugin.
+
+The %[1]s source is copied to a temporary directory, the plugins are staged
+where the embed_plugins build tag embeds them from, and the binary is built
+with "go build -tags embed_plugins", so a Go toolchain is required. The
+source is --source, the current directory when it is a checkout of %[1]s,
+or the module version this binary was built from, fetched with
+"go mod download". --os and --arch cross-compile.
+
+Examples:
+  %[1]s bundle -o dist/%[1]s-team dns http
+  %[1]s plugin freeze > plugins.yaml && %[1]s bundle -f plugins.yaml -o dist/%[1]s-team
+  %[1]s bundle --os linux --arch arm64 -o dist/%[1]s-linux-arm64`, meta.AppName),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if requirements != "" && len(args) > 0 {
+				return fmt.Errorf("bundle either named plugins or -f %s, not both", requirements)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			cached, err := stack.Service.ListCachedPlugins(ctx)
+			if err != nil {
+				return fmt.Errorf("listing installed plugins: %w", err)
+			}
+			var reqs *internalplugin.Requirements
+			if requirements != "" {
+				if reqs, err = internalplugin.LoadRequirements(requirements); err != nil {
+					return err
+				}
+			}
+			selected, err := selectBundlePlugins(cached, args, reqs)
+			if err != nil {
+				return err
+			}
+
+			plugins := make(map[string][]byte, len(selected))
+			for _, p := range selected {
+				data, err := readCached(ctx, stack, p)
+				if err != nil {
+					return err
+				}
+				plugins[p.Metadata().Name()] = data
+			}
+
+			src, err := bundleSource(ctx, source)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = meta.AppName + "-bundle"
+			}
+			out := cmd.OutOrStdout()
+			if err := buildBundle(ctx, src, output, plugins, goos, goarch, cmd.ErrOrStderr()); err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(plugins))
+			for name := range plugins {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			_, _ = fmt.Fprintf(out, "Built %s with %d plugins: %s\n", output, len(names), strings.Join(names, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "out", "o", "", fmt.Sprintf("Output binary path (default: %s-bundle)", meta.AppName))
+	cmd.Flags().StringVarP(&requirements, "file", "f", "", `Bundle the plugins in a requirements document ("-" for stdin)`)
+	cmd.Flags().StringVar(&source, "source", "", fmt.Sprintf("Directory of the %s source to build", meta.AppName))
+	cmd.Flags().StringVar(&goos, "os", "", "Target operating system (GOOS)")
+	cmd.Flags().StringVar(&goarch, "arch", "", "Target architecture (GOARCH)")
+	return cmd
+}
+
+// selectBundlePlugins picks the cached plugins to bundle: those named, those
+// a requirements document lists, or, with neither, the newest version of
+// every installed plugin.
+func selectBundlePlugins(cached []*hostentities.Plugin, names []string, reqs *internalplugin.Requirements) ([]*hostentities.Plugin, error) {
+	var selected []*hostentities.Plugin
+	switch {
+	case reqs != nil:
+		for _, r := range reqs.Plugins {
+			p := findCached(cached, r.Name, r.Version)
+			if p == nil {
+				return nil, fmt.Errorf("%s %s is not installed; run \"%s plugin install -f\" first", r.Name, r.Version, meta.AppName)
+			}
+			if r.Digest != "" && p.Digest().String() != r.Digest {
+				return nil, fmt.Errorf("%s %s: %w: required %s, installed %s", r.Name, r.Version, internalplugin.ErrDigestMismatch, r.Digest, p.Digest())
+			}
+			selected = append(selected, p)
+		}
+	case len(names) > 0:
+		for _, arg := range names {
+			name, version := parseNameVersion(arg)
+			p := findCached(cached, name, version)
+			if p == nil {
+				return nil, fmt.Errorf("plugin %q is not installed", arg)
+			}
+			selected = append(selected, p)
+		}
+	default:
+		var seen []string
+		for _, p := range cached {
+			if name := p.Metadata().Name(); !slices.Contains(seen, name) {
+				seen = append(seen, name)
+				selected = append(selected, findCached(cached, name, ""))
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no plugins to bundle; install some first")
+	}
+	return selected, nil
+}
+
+// findCached returns the newest cached version of the named plugin, or the
+// given version when one is set, or nil when none is cached.
+func findCached(cached []*hostentities.Plugin, name, version string) *hostentities.Plugin {
+	var found *hostentities.Plugin
+	for _, p := range cached {
+		m := p.Metadata()
+		if m.Name() != name || (version != "" && m.Version() != version && p.Reference().Version() != version) {
+			continue
+		}
+		if found == nil || internalplugin.NewerVersion(m.Version(), found.Metadata().Version()) {
+			found = p
+		}
+	}
+	return found
+}
+
+// readCached reads a cached plugin's binary, refusing it when it no longer
+// has the digest recorded in the plugin repository.
+func readCached(ctx context.Context, stack *internalplugin.PluginStack, p *hostentities.Plugin) ([]byte, error) {
+	_, path, err := stack.Repository.Find(ctx, p.Reference())
+	if err != nil {
+		return nil, fmt.Errorf("finding %s: %w", p.Reference(), err)
+	}
+	data, digest, err := internalplugin.ReadInstalled(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", p.Metadata().Name(), err)
+	}
+	if digest != p.Digest().String() {
+		return nil, fmt.Errorf("reading %s: %w: recorded %s, found %s", p.Metadata().Name(), internalplugin.ErrDigestMismatch, p.Digest(), digest)
+	}
+	return data, nil
+}
+
+// bundleSource returns the directory of the source to build: dir when set,
+// the working directory when it is a checkout of this module, or this
+// binary's module version from the module cache.
+func bundleSource(ctx context.Context, dir string) (string, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", fmt.Errorf("reading build info: unavailable")
+	}
+	module := info.Main.Path
+
+	if dir != "" {
+		if path, err := modulePath(dir); err != nil || path != module {
+			return "", fmt.Errorf("%s is not a checkout of %s", dir, module)
+		}
+		return dir, nil
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if path, err := modulePath(wd); err == nil && path == module {
+			return wd, nil
+		}
+	}
+
+	version := info.Main.Version
+	if version == "" || version == "(devel)" {
+		return "", fmt.Errorf("this binary was not built from a released version of %s; run from a checkout or pass --source", module)
+	}
+	download := exec.CommandContext(ctx, "go", "mod", "download", "-json", module+"@"+version)
+	data, err := download.Output()
+	if err != nil {
+		return "", fmt.Errorf("downloading %s@%s: %w", module, version, err)
+	}
+	var mod struct{ Dir string }
+	if err := json.Unmarshal(data, &mod); err != nil || mod.Dir == "" {
+		return "", fmt.Errorf("downloading %s@%s: no source directory reported", module, version)
+	}
+	return mod.Dir, nil
+}
+
+// modulePath returns the module path declared by dir/go.mod.
+func modulePath(dir string) (string, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%s/go.mod declares no module", dir)
+}
+
+// buildBundle copies the source in src to a temporary directory, stages
+// plugins (by name) as the embedded plugins, and builds the CLI to output
+// with the embed_plugins tag, streaming compiler output to log.
+func buildBundle(ctx context.Context, src, output string, plugins map[string][]byte, goos, goarch string, log io.Writer) error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return fmt.Errorf("go not found in PATH: %w", err)
+	}
+	output, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	work, err := os.MkdirTemp("", meta.AppName+"-bundle-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(work) }()
+	if err := copySourceTree(src, work); err != nil {
+		return fmt.Errorf("copying source: %w", err)
+	}
+
+	staged := filepath.Join(work, filepath.FromSlash(embeddedPluginsDir))
+	if err := os.MkdirAll(staged, 0o755); err != nil {
+		return err
+	}
+	for name, data := range plugins {
+		if err := os.WriteFile(filepath.Join(staged, name+".wasm"), data, 0o644); err != nil {
+			return fmt.Errorf("staging %s: %w", name, err)
+		}
+	}
+
+	module, err := modulePath(src)
+	if err != nil {
+		return err
+	}
+	ldflags := fmt.Sprintf("-s -w -X %[1]s/internal/cli.Version=%[2]s -X %[1]s/internal/cli.Commit=%[3]s -X %[1]s/internal/cli.BuildTime=%[4]s",
+		module, Version, Commit, time.Now().UTC().Format(time.RFC3339))
+	build := exec.CommandContext(ctx, "go", "build", "-trimpath", "-tags", "embed_plugins", "-ldflags", ldflags, "-o", output, "./cmd/cli")
+	build.Dir = work
+	build.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if goos != "" {
+		build.Env = append(build.Env, "GOOS="+goos)
+	}
+	if goarch != "" {
+		build.Env = append(build.Env, "GOARCH="+goarch)
+	}
+	build.Stdout = log
+	build.Stderr = log
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("go build failed: %w", err)
+	}
+	return nil
+}
+
+// copySourceTree copies src to dst, leaving out hidden directories and any
+// plugins already staged for embedding.
+func copySourceTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if rel != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		if filepath.ToSlash(filepath.Dir(rel)) == embeddedPluginsDir || !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
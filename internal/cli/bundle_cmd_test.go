@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func cachedPlugin(t *testing.T, ref, version string, b byte) *hostentities.Plugin {
+	t.Helper()
+	r, err := hostvalues.ParsePluginReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := hostvalues.NewDigest("sha256", strings.Repeat(string('a'+b), 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hostentities.NewPlugin(r, d, hostvalues.NewPluginMetadata(r.Name(), version, "", nil))
+}
+
+func TestSelectBundlePlugins(t *testing.T) {
+	cached := []*hostentities.Plugin{
+		cachedPlugin(t, "ghcr.io/acme/plugins/dns:1.0.0", "1.0.0", 0),
+		cachedPlugin(t, "ghcr.io/acme/plugins/dns:1.2.0", "1.2.0", 1),
+		cachedPlugin(t, "custom", "local", 2),
+	}
+	versions := func(ps []*hostentities.Plugin) string {
+		var out []string
+		for _, p := range ps {
+			out = append(out, p.Metadata().Name()+"@"+p.Metadata().Version())
+		}
+		return strings.Join(out, ",")
+	}
+
+	all, err := selectBundlePlugins(cached, nil, nil)
+	if err != nil || versions(all) != "dns@1.2.0,custom@local" {
+		t.Errorf("all = %s (%v), want the newest of each plugin", versions(all), err)
+	}
+	named, err := selectBundlePlugins(cached, []string{"dns@1.0.0"}, nil)
+	if err != nil || versions(named) != "dns@1.0.0" {
+		t.Errorf("named = %s (%v)", versions(named), err)
+	}
+	if _, err := selectBundlePlugins(cached, []string{"http"}, nil); err == nil {
+		t.Error("expected an error for a plugin that is not installed")
+	}
+
+	reqs := &internalplugin.Requirements{Plugins: []internalplugin.Requirement{
+		{Name: "dns", Version: "1.2.0", Registry: "ghcr.io/acme/plugins", Digest: "sha256:" + strings.Repeat("c", 64)},
+	}}
+	if _, err := selectBundlePlugins(cached, nil, reqs); !errors.Is(err, internalplugin.ErrDigestMismatch) {
+		t.Errorf("expected a digest mismatch against the requirements, got %v", err)
+	}
+	if _, err := selectBundlePlugins(nil, nil, nil); err == nil {
+		t.Error("expected an error with nothing to bundle")
+	}
+}
+
+func TestBuildBundle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found in PATH")
+	}
+
+	// A module laid out like this one: plugins embedded from
+	// internal/plugin/plugins under the embed_plugins tag
+	src := t.TempDir()
+	files := map[string]string{
+		"go.mod":                             "module example.com/fake\n\ngo 1.22\n",
+		"internal/plugin/embedded.go":        "//go:build embed_plugins\n\npackage plugin\n\nimport \"embed\"\n\n//go:embed plugins/*.wasm\nvar FS embed.FS\n",
+		"internal/plugin/none.go":            "//go:build !embed_plugins\n\npackage plugin\n\nimport \"embed\"\n\nvar FS embed.FS\n",
+		"internal/plugin/plugins/stale.wasm": "stale",
+		".git/HEAD":                          "ref: refs/heads/main\n",
+		"cmd/cli/main.go": `package main
+
+import (
+	"fmt"
+	"io/fs"
+
+	"example.com/fake/internal/plugin"
+)
+
+func main() {
+	entries, _ := fs.ReadDir(plugin.FS, "plugins")
+	for _, e := range entries {
+		data, _ := fs.ReadFile(plugin.FS, "plugins/"+e.Name())
+		fmt.Printf("%s=%s\n", e.Name(), data)
+	}
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "bundle")
+	var log bytes.Buffer
+	if err := buildBundle(context.Background(), src, output, map[string][]byte{"dns": []byte("dns-wasm")}, "", "", &log); err != nil {
+		t.Fatalf("buildBundle: %v\n%s", err, log.String())
+	}
+	got, err := exec.Command(output).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "dns.wasm=dns-wasm\n" {
+		t.Errorf("bundled plugins = %q, want only the staged dns plugin", got)
+	}
+	if _, err := os.Stat(filepath.Join(src, "internal/plugin/plugins/dns.wasm")); !os.IsNotExist(err) {
+		t.Error("expected the source tree to be left untouched")
+	}
+}
@@ -2,11 +2,17 @@
 package cli
 
 import (
+	"slices"
+	"sort"
+
 	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
 )
 
 // newCompletionCommand creates the "completion" command that generates
-// shell completion scripts for bash, zsh, fish, and powershell.
+// shell completion scripts for bash, zsh, fish, and powershell, and
+// completion specs for other tools through "completion spec".
 //
 // Usage:
 //
@@ -53,6 +59,10 @@ PowerShell:
   # To load completions for every new session, run:
   PS> cli completion powershell > cli.ps1
   # and source this file from your PowerShell profile.
+
+Carapace and Fig:
+  $ cli completion spec --format carapace
+  $ cli completion spec --format fig
 `,
 		DisableFlagsInUseLine: true,
 		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
@@ -75,6 +85,7 @@ PowerShell:
 		},
 	}
 
+	cmd.AddCommand(newCompletionSpecCommand())
 	return cmd
 }
 
@@ -86,6 +97,110 @@ func registerOutputFormatCompletion(cmd *cobra.Command) {
 			"table\tHuman-readable table (default)",
 			"json\tJSON output for scripting",
 			"yaml\tYAML output",
+			"gha\tTable plus GitHub Actions annotations and step summary",
+			"codequality\tGitLab Code Quality report of failed checks",
 		}, cobra.ShellCompDirectiveNoFileComp
 	})
 }
+
+// completeGroupNames completes the first argument with configured group
+// names. Later arguments get no completions.
+func completeGroupNames(cfg *config.Config) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return groupCompletions(cfg), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeGroupAdd completes "group add": a group name first, then installed
+// plugins and other groups that are not already members.
+func completeGroupAdd(cfg *config.Config, installed func() map[string]string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return groupCompletions(cfg), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		skip := map[string]bool{args[0]: true, "top": true}
+		for _, p := range cfg.Groups[args[0]].Plugins {
+			skip[p] = true
+		}
+		for _, a := range args[1:] {
+			skip[a] = true
+		}
+
+		var completions []string
+		for name, desc := range installed() {
+			if !skip[name] {
+				completions = append(completions, name+"\t"+desc)
+			}
+		}
+		for name, group := range cfg.Groups {
+			if !skip[name] {
+				completions = append(completions, name+"\tgroup: "+group.Description)
+			}
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completePluginNames completes installed plugin names not yet given.
+func completePluginNames(installed func() map[string]string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var completions []string
+		for name, desc := range installed() {
+			if !slices.Contains(args, name) {
+				completions = append(completions, name+"\t"+desc)
+			}
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeGroupRemove completes "group remove": a group name first, then
+// the entries of that group not yet named on the command line.
+func completeGroupRemove(cfg *config.Config) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return groupCompletions(cfg), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		named := make(map[string]bool, len(args))
+		for _, a := range args[1:] {
+			named[a] = true
+		}
+		var completions []string
+		for _, p := range cfg.Groups[args[0]].Plugins {
+			if !named[p] {
+				completions = append(completions, p)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// groupCompletions returns the configured groups with their descriptions.
+func groupCompletions(cfg *config.Config) []string {
+	completions := make([]string, 0, len(cfg.Groups))
+	for name, group := range cfg.Groups {
+		completions = append(completions, name+"\t"+group.Description)
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// cachedPlugins returns installed plugin names and descriptions from the
+// discovery cache, so completion does not have to load any WASM modules.
+func cachedPlugins() map[string]string {
+	cache := pluginpkg.LoadCache(pluginpkg.DefaultCachePath())
+	plugins := make(map[string]string, len(cache.Files))
+	for _, entry := range cache.Files {
+		if entry.Manifest.Name != "" {
+			plugins[entry.Manifest.Name] = entry.Manifest.Description
+		}
+	}
+	return plugins
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"gopkg.in/yaml.v3"
+)
+
+// newCompletionSpecCommand creates the "completion spec" command.
+func newCompletionSpecCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "spec",
+		Short: "Export the command tree as a carapace or Fig completion spec",
+		Long: fmt.Sprintf(`Export every command, including installed plugin operations with their
+flags and allowed values, as a completion spec for shells beyond the four
+cobra supports natively.
+
+  carapace   YAML spec for carapace-bin (save under ~/.config/carapace/specs/)
+  fig        TypeScript spec for Fig and its successors (src/%s.ts)
+
+Re-export after installing or removing plugins.
+
+Examples:
+  %s completion spec --format carapace > ~/.config/carapace/specs/%s.yaml
+  %s completion spec --format fig > src/%s.ts`, meta.AppName, meta.AppName, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec := describeCommand(cmd.Root())
+			switch format {
+			case "carapace":
+				return writeCarapaceSpec(cmd.OutOrStdout(), spec)
+			case "fig":
+				return writeFigSpec(cmd.OutOrStdout(), spec)
+			default:
+				return fmt.Errorf("unsupported spec format: %q (supported: carapace, fig)", format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "carapace", "Spec format: carapace, fig")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"carapace", "fig"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// ownFlags splits the flags of a command spec into those it defines and
+// the persistent ones among them, dropping flags inherited from ancestors.
+func ownFlags(spec commandSpec, inherited map[string]bool) (local, persistent []flagSpec) {
+	for _, f := range spec.Flags {
+		switch {
+		case f.Persistent && inherited[f.Name]:
+		case f.Persistent:
+			persistent = append(persistent, f)
+		default:
+			local = append(local, f)
+		}
+	}
+	return local, persistent
+}
+
+// withPersistent returns inherited extended by the given persistent flags.
+func withPersistent(inherited map[string]bool, persistent []flagSpec) map[string]bool {
+	out := make(map[string]bool, len(inherited)+len(persistent))
+	for name := range inherited {
+		out[name] = true
+	}
+	for _, f := range persistent {
+		out[f.Name] = true
+	}
+	return out
+}
+
+// takesValue reports whether a flag consumes an argument.
+func (f flagSpec) takesValue() bool {
+	return f.Type != "bool" && f.Type != "count"
+}
+
+// repeatable reports whether a flag may be given more than once.
+func (f flagSpec) repeatable() bool {
+	return f.Type == "count" || strings.HasSuffix(f.Type, "Slice") ||
+		strings.HasSuffix(f.Type, "Array") || strings.HasPrefix(f.Type, "stringTo")
+}
+
+// carapaceCommand is a command in a carapace-spec document.
+type carapaceCommand struct {
+	Name            string              `yaml:"name"`
+	Aliases         []string            `yaml:"aliases,omitempty"`
+	Description     string              `yaml:"description,omitempty"`
+	Flags           map[string]string   `yaml:"flags,omitempty"`
+	PersistentFlags map[string]string   `yaml:"persistentflags,omitempty"`
+	Completion      *carapaceCompletion `yaml:"completion,omitempty"`
+	Commands        []carapaceCommand   `yaml:"commands,omitempty"`
+}
+
+// carapaceCompletion holds value completions keyed by flag name.
+type carapaceCompletion struct {
+	Flag map[string][]string `yaml:"flag,omitempty"`
+}
+
+// writeCarapaceSpec writes spec in the carapace-spec YAML format.
+func writeCarapaceSpec(w io.Writer, spec commandSpec) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(carapaceSpec(spec, nil)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func carapaceSpec(spec commandSpec, inherited map[string]bool) carapaceCommand {
+	local, persistent := ownFlags(spec, inherited)
+	out := carapaceCommand{
+		Name:        spec.Name,
+		Aliases:     spec.Aliases,
+		Description: spec.Short,
+	}
+
+	flags := func(fs []flagSpec) map[string]string {
+		if len(fs) == 0 {
+			return nil
+		}
+		m := make(map[string]string, len(fs))
+		for _, f := range fs {
+			key := "--" + f.Name
+			if f.Shorthand != "" {
+				key = "-" + f.Shorthand + ", " + key
+			}
+			if f.takesValue() {
+				key += "="
+			}
+			if f.repeatable() {
+				key += "*"
+			}
+			if f.Required {
+				key += "!"
+			}
+			m[key] = f.Usage
+			if len(f.Enum) > 0 {
+				if out.Completion == nil {
+					out.Completion = &carapaceCompletion{Flag: map[string][]string{}}
+				}
+				out.Completion.Flag[f.Name] = f.Enum
+			}
+		}
+		return m
+	}
+	out.Flags = flags(local)
+	out.PersistentFlags = flags(persistent)
+
+	inherited = withPersistent(inherited, persistent)
+	for _, sub := range spec.Commands {
+		out.Commands = append(out.Commands, carapaceSpec(sub, inherited))
+	}
+	return out
+}
+
+// figSubcommand is a subcommand in a Fig completion spec.
+type figSubcommand struct {
+	Name        any             `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Subcommands []figSubcommand `json:"subcommands,omitempty"`
+	Options     []figOption     `json:"options,omitempty"`
+}
+
+// figOption is a flag in a Fig completion spec.
+type figOption struct {
+	Name         []string `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	IsPersistent bool     `json:"isPersistent,omitempty"`
+	IsRequired   bool     `json:"isRequired,omitempty"`
+	IsRepeatable bool     `json:"isRepeatable,omitempty"`
+	Args         *figArg  `json:"args,omitempty"`
+}
+
+// figArg is the value a Fig option takes.
+type figArg struct {
+	Name        string   `json:"name"`
+	Default     string   `json:"default,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// writeFigSpec writes spec as a Fig completion spec module.
+func writeFigSpec(w io.Writer, spec commandSpec) error {
+	data, err := json.MarshalIndent(figSpec(spec, nil), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "const completionSpec: Fig.Spec = %s;\n\nexport default completionSpec;\n", data)
+	return err
+}
+
+func figSpec(spec commandSpec, inherited map[string]bool) figSubcommand {
+	local, persistent := ownFlags(spec, inherited)
+	out := figSubcommand{Name: spec.Name, Description: spec.Short}
+	if len(spec.Aliases) > 0 {
+		out.Name = append([]string{spec.Name}, spec.Aliases...)
+	}
+
+	option := func(f flagSpec, isPersistent bool) figOption {
+		opt := figOption{
+			Name:         []string{"--" + f.Name},
+			Description:  f.Usage,
+			IsPersistent: isPersistent,
+			IsRequired:   f.Required,
+			IsRepeatable: f.repeatable(),
+		}
+		if f.Shorthand != "" {
+			opt.Name = []string{"-" + f.Shorthand, "--" + f.Name}
+		}
+		if f.takesValue() {
+			opt.Args = &figArg{Name: f.Name, Default: f.Default, Suggestions: f.Enum}
+		}
+		return opt
+	}
+	for _, f := range local {
+		out.Options = append(out.Options, option(f, false))
+	}
+	for _, f := range persistent {
+		out.Options = append(out.Options, option(f, true))
+	}
+
+	inherited = withPersistent(inherited, persistent)
+	for _, sub := range spec.Commands {
+		out.Subcommands = append(out.Subcommands, figSpec(sub, inherited))
+	}
+	return out
+}
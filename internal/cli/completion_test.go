@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
 )
 
@@ -87,3 +88,113 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestCompleteGroupAdd(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"top":     {Plugins: []string{"dns"}},
+		"network": {Description: "Network tools", Plugins: []string{"dns"}},
+		"cloud":   {Description: "Cloud tools"},
+	}
+	installed := func() map[string]string {
+		return map[string]string{"dns": "DNS", "http": "HTTP", "tcp": "TCP"}
+	}
+	complete := completeGroupAdd(cfg, installed)
+
+	got, _ := complete(nil, nil, "")
+	if strings.Join(got, ",") != "cloud\tCloud tools,network\tNetwork tools,top\t" {
+		t.Errorf("expected group names first, got %q", got)
+	}
+
+	got, _ = complete(nil, []string{"network", "tcp"}, "")
+	if strings.Join(got, ",") != "cloud\tgroup: Cloud tools,http\tHTTP" {
+		t.Errorf("expected non-member plugins and groups, got %q", got)
+	}
+}
+
+func TestCompleteGroupRemove(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"network": {Plugins: []string{"dns", "http", "tcp"}},
+	}
+	complete := completeGroupRemove(cfg)
+
+	got, _ := complete(nil, []string{"network", "http"}, "")
+	if strings.Join(got, ",") != "dns,tcp" {
+		t.Errorf("expected remaining members, got %q", got)
+	}
+	if got, _ := complete(nil, []string{"missing"}, ""); len(got) != 0 {
+		t.Errorf("expected no completions for unknown group, got %q", got)
+	}
+}
+
+func TestCompletionSpec(t *testing.T) {
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "tack"}
+		root.PersistentFlags().String("output", "table", "Output format")
+		op := &cobra.Command{Use: "resolve", Short: "Resolve hostname", RunE: func(*cobra.Command, []string) error { return nil }}
+		op.Flags().String("record-type", "A", "Record type")
+		_ = op.Flags().SetAnnotation("record-type", enumAnnotation, []string{"A", "AAAA"})
+		op.Flags().String("hostname", "", "Hostname")
+		_ = op.MarkFlagRequired("hostname")
+		dns := &cobra.Command{Use: "dns"}
+		dns.AddCommand(op)
+		root.AddCommand(dns, newCompletionCommand())
+		return root
+	}
+	run := func(format string) string {
+		root := newRoot()
+		var buf bytes.Buffer
+		root.SetOut(&buf)
+		root.SetArgs([]string{"completion", "spec", "--format", format})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%s: Execute: %v", format, err)
+		}
+		return buf.String()
+	}
+
+	carapace := run("carapace")
+	for _, want := range []string{"persistentflags:\n  --output=: Output format", "--hostname=!: Hostname", "record-type:\n"} {
+		if !strings.Contains(carapace, want) {
+			t.Errorf("carapace spec missing %q:\n%s", want, carapace)
+		}
+	}
+	if strings.Count(carapace, "--output=") != 1 {
+		t.Errorf("expected --output only on the root:\n%s", carapace)
+	}
+
+	fig := run("fig")
+	for _, want := range []string{"const completionSpec: Fig.Spec = {", `"isPersistent": true`, `"isRequired": true`, `"AAAA"`, "export default completionSpec;"} {
+		if !strings.Contains(fig, want) {
+			t.Errorf("fig spec missing %q:\n%s", want, fig)
+		}
+	}
+
+	root := newRoot()
+	root.SetArgs([]string{"completion", "spec", "--format", "nope"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestNeedsPluginCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"version"}, false},
+		{[]string{"--output", "json", "version"}, false},
+		{[]string{"completion", "zsh"}, false},
+		{[]string{"completion", "spec"}, true},
+		{[]string{"completion"}, true},
+		{[]string{"__complete", "dns", ""}, true},
+		{[]string{"dns", "version"}, true},
+		{[]string{"--help"}, true},
+	}
+	for _, tt := range tests {
+		if got := NeedsPluginCommands(tt.args); got != tt.want {
+			t.Errorf("NeedsPluginCommands(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
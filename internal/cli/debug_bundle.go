@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// debugBundleInput is what a debug bundle is built from.
+type debugBundleInput struct {
+	cfg          *config.Config
+	cachePath    string
+	grantsPath   string
+	history      *history.Store
+	historyLimit int
+	logPath      string // empty when no log was given
+	logLines     int
+	now          time.Time
+}
+
+// newDebugBundleCommand creates the "debug bundle" command.
+func newDebugBundleCommand(cfg *config.Config) *cobra.Command {
+	var (
+		file         string
+		historyLimit int
+		logPath      string
+		logLines     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Write a diagnostic archive to attach to bug reports",
+		Long: fmt.Sprintf(`Write a gzipped tarball with what is needed to diagnose a problem:
+version information, the effective config, the plugin discovery cache, a
+summary of capability grants, and the most recent history records.
+
+The last lines of the log file are included when logging to one is
+configured (see --%s); --log picks another file, such as the captured
+output of "%s schedule run".
+
+Secrets are removed first: config values whose names suggest credentials,
+notification URLs and headers, credentials in URLs, and registry passwords.`, LogFileFlag, meta.AppName),
+		Example: fmt.Sprintf(`  %s debug bundle
+  %s debug bundle --file /tmp/bug.tar.gz --log schedule.log`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			if file == "" {
+				file = fmt.Sprintf("%s-debug-%s.tar.gz", meta.AppName, now.Format("20060102-150405"))
+			}
+			if logPath == "" {
+				if path := LogPath(cfg, ""); path != "" {
+					if _, err := os.Stat(path); err == nil {
+						logPath = path
+					}
+				}
+			}
+			f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+			if err != nil {
+				return fmt.Errorf("creating bundle: %w", err)
+			}
+			err = writeDebugBundle(f, debugBundleInput{
+				cfg:          cfg,
+				cachePath:    internalplugin.DefaultCachePath(),
+				grantsPath:   runtime.GrantsPath(),
+				history:      history.Open(history.DefaultPath()),
+				historyLimit: historyLimit,
+				logPath:      logPath,
+				logLines:     logLines,
+				now:          now,
+			})
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(file)
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Archive to write (default: ./"+meta.AppName+"-debug-<time>.tar.gz)")
+	cmd.Flags().IntVar(&historyLimit, "history", 50, "Most recent history records to include")
+	cmd.Flags().StringVar(&logPath, "log", "", "Log file whose last lines to include (default: the configured log file)")
+	cmd.Flags().IntVar(&logLines, "log-lines", 200, "Lines of the --log file to include")
+	return cmd
+}
+
+// writeDebugBundle writes the diagnostic archive to w. Files that do not
+// exist yet, such as the grants of a fresh install, are left out.
+func writeDebugBundle(w io.Writer, in debugBundleInput) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: in.now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var version bytes.Buffer
+	if err := renderVersion(&version, "table", buildVersionInfo()); err != nil {
+		return err
+	}
+	if err := add("version.txt", version.Bytes()); err != nil {
+		return err
+	}
+
+	cfgYAML, err := redactedConfig(in.cfg)
+	if err != nil {
+		return err
+	}
+	if err := add("config.yaml", cfgYAML); err != nil {
+		return err
+	}
+
+	if data, err := os.ReadFile(in.cachePath); err == nil {
+		if err := add("discovery_cache.json", []byte(internalplugin.RedactSecrets(string(data)))); err != nil {
+			return err
+		}
+	}
+
+	grants, err := readGrantsState(in.grantsPath, in.now)
+	if err != nil {
+		return err
+	}
+	if grants != nil {
+		data, err := yaml.Marshal(grants)
+		if err != nil {
+			return err
+		}
+		if err := add("grants.yaml", data); err != nil {
+			return err
+		}
+	}
+
+	records, err := in.history.List(in.historyLimit)
+	if err != nil {
+		return err
+	}
+	var hist bytes.Buffer
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		hist.WriteString(internalplugin.RedactSecrets(string(line)))
+		hist.WriteByte('\n')
+	}
+	if err := add("history.jsonl", hist.Bytes()); err != nil {
+		return err
+	}
+
+	if in.logPath != "" {
+		tail, err := tailLines(in.logPath, in.logLines)
+		if err != nil {
+			return err
+		}
+		if err := add("log.txt", []byte(internalplugin.RedactSecrets(tail))); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// secretKey matches config keys whose values are likely credentials. Keys
+// ending in _env name an environment variable, not a secret, and are kept.
+var secretKey = regexp.MustCompile(`(?i)(token|secret|passw(or)?d|credential|api_?key|auth)`)
+
+// redactedConfig returns cfg as YAML with credentials removed: values of
+// keys that look like credentials, notification URLs and header values,
+// which typically embed tokens, and credentials in any URL.
+func redactedConfig(cfg *config.Config) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	redactNode(&doc, false)
+	return yaml.Marshal(&doc)
+}
+
+func redactNode(n *yaml.Node, inNotification bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i].Value, n.Content[i+1]
+			switch {
+			case key == "notifications":
+				for _, sink := range value.Content {
+					redactNode(sink, true)
+				}
+				continue
+			case inNotification && (key == "url" || key == "headers"),
+				secretKey.MatchString(key) && !strings.HasSuffix(key, "_env"):
+				redactAll(value)
+				continue
+			}
+			redactNode(value, inNotification)
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			redactNode(c, inNotification)
+		}
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			redactNode(c, inNotification)
+		}
+	case yaml.ScalarNode:
+		n.Value = internalplugin.RedactSecrets(n.Value)
+	}
+}
+
+// redactAll replaces every scalar under n, leaving mapping keys readable.
+func redactAll(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Value != "" {
+			n.Value, n.Tag, n.Style = redactedValue, "!!str", 0
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			redactAll(n.Content[i])
+		}
+	default:
+		for _, c := range n.Content {
+			redactAll(c)
+		}
+	}
+}
+
+// redactedValue replaces redacted config values.
+const redactedValue = "[REDACTED]"
+
+// tailLines returns the last n lines of the file at path.
+func tailLines(path string, n int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("reading log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading log %s: %w", path, err)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"gopkg.in/yaml.v3"
+)
+
+// DebugTimingEnv, when set to a non-empty value, makes the CLI print the
+// startup breakdown to stderr once the command has run.
+const DebugTimingEnv = "TACK_DEBUG_TIMING"
+
+// startup records the startup phases of this process.
+var startup = newStartupTrace()
+
+// StartupPhase starts timing a startup phase; call the returned func when
+// the phase ends.
+func StartupPhase(name string) func() {
+	return startup.phase(name)
+}
+
+// WriteStartupTiming writes the startup breakdown recorded so far as a
+// table.
+func WriteStartupTiming(w io.Writer) error {
+	return renderStartupReport(w, "table", startup.report())
+}
+
+// startupTrace records how long startup phases and plugin manifest reads
+// take. It implements plugin.DiscoveryTracer.
+type startupTrace struct {
+	start time.Time
+
+	mu        sync.Mutex
+	phases    []phaseTiming
+	cacheLoad time.Duration
+	plugins   []pluginTiming
+}
+
+type phaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+type pluginTiming struct {
+	Name     string
+	Duration time.Duration
+	Cached   bool
+}
+
+func newStartupTrace() *startupTrace {
+	return &startupTrace{start: time.Now()}
+}
+
+func (t *startupTrace) phase(name string) func() {
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.phases = append(t.phases, phaseTiming{Name: name, Duration: d})
+	}
+}
+
+// CacheLoaded implements plugin.DiscoveryTracer.
+func (t *startupTrace) CacheLoaded(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cacheLoad = d
+}
+
+// ManifestRead implements plugin.DiscoveryTracer.
+func (t *startupTrace) ManifestRead(name string, d time.Duration, cached bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.plugins = append(t.plugins, pluginTiming{Name: name, Duration: d, Cached: cached})
+}
+
+// startupReport is the rendered form of a startup trace. Durations are in
+// milliseconds.
+type startupReport struct {
+	Phases      []startupPhaseReport  `json:"phases" yaml:"phases"`
+	CacheLoadMS float64               `json:"cache_load_ms" yaml:"cache_load_ms"`
+	Plugins     []startupPluginReport `json:"plugins" yaml:"plugins"`
+	TotalMS     float64               `json:"total_ms" yaml:"total_ms"`
+}
+
+type startupPhaseReport struct {
+	Name       string  `json:"name" yaml:"name"`
+	DurationMS float64 `json:"duration_ms" yaml:"duration_ms"`
+}
+
+type startupPluginReport struct {
+	Name       string  `json:"name" yaml:"name"`
+	DurationMS float64 `json:"duration_ms" yaml:"duration_ms"`
+	Cached     bool    `json:"cached" yaml:"cached"`
+}
+
+// report snapshots the trace. Plugins are listed slowest first.
+func (t *startupTrace) report() startupReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := startupReport{
+		Phases:      []startupPhaseReport{},
+		CacheLoadMS: milliseconds(t.cacheLoad),
+		Plugins:     []startupPluginReport{},
+		TotalMS:     milliseconds(time.Since(t.start)),
+	}
+	for _, p := range t.phases {
+		r.Phases = append(r.Phases, startupPhaseReport{Name: p.Name, DurationMS: milliseconds(p.Duration)})
+	}
+	plugins := append([]pluginTiming(nil), t.plugins...)
+	sort.SliceStable(plugins, func(i, j int) bool { return plugins[i].Duration > plugins[j].Duration })
+	for _, p := range plugins {
+		r.Plugins = append(r.Plugins, startupPluginReport{Name: p.Name, DurationMS: milliseconds(p.Duration), Cached: p.Cached})
+	}
+	return r
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// newDebugCommand creates the "debug" command.
+func newDebugCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug",
+		Short: "Diagnose the CLI itself",
+	}
+	cmd.AddCommand(
+		newDebugStartupCommand(),
+		newDebugBundleCommand(cfg),
+	)
+	return cmd
+}
+
+func newDebugStartupCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "startup",
+		Short: "Show where startup time goes",
+		Long: fmt.Sprintf(`Show how long each startup phase took for this invocation: config load,
+plugin service init, discovery cache load, each plugin's manifest, and
+command registration. Manifests marked cached were not read from the plugin
+binary. Manifests are read in parallel, so their times overlap.
+
+Set %s=1 to print the same breakdown after any command.`, DebugTimingEnv),
+		Example: fmt.Sprintf(`  %s debug startup
+  %s debug startup --output json
+  %s=1 %s dns lookup --hostname example.com`, meta.AppName, meta.AppName, DebugTimingEnv, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderStartupReport(cmd.OutOrStdout(), format, startup.report())
+		},
+	}
+}
+
+// renderStartupReport writes a startup report in the given format. The
+// table nests the cache load and plugin manifests under discovery.
+func renderStartupReport(w io.Writer, format string, r startupReport) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(r)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PHASE\tDURATION")
+		for _, p := range r.Phases {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", p.Name, formatMS(p.DurationMS))
+			if p.Name != phasePluginDiscovery {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "  cache load\t%s\n", formatMS(r.CacheLoadMS))
+			for _, pl := range r.Plugins {
+				name := pl.Name
+				if pl.Cached {
+					name += " (cached)"
+				}
+				_, _ = fmt.Fprintf(tw, "  %s\t%s\n", name, formatMS(pl.DurationMS))
+			}
+		}
+		_, _ = fmt.Fprintf(tw, "total\t%s\n", formatMS(r.TotalMS))
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+// phasePluginDiscovery names the phase under which plugin timings nest.
+const phasePluginDiscovery = "plugin discovery"
+
+func formatMS(ms float64) string {
+	return fmt.Sprintf("%.1fms", ms)
+}
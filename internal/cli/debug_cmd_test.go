@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartupTraceReport(t *testing.T) {
+	trace := newStartupTrace()
+	trace.phase("config load")()
+	done := trace.phase(phasePluginDiscovery)
+	trace.CacheLoaded(2 * time.Millisecond)
+	trace.ManifestRead("dns", time.Millisecond, true)
+	trace.ManifestRead("aws", 30*time.Millisecond, false)
+	done()
+
+	report := trace.report()
+	if len(report.Phases) != 2 || report.Phases[1].Name != phasePluginDiscovery {
+		t.Fatalf("unexpected phases: %+v", report.Phases)
+	}
+	if len(report.Plugins) != 2 || report.Plugins[0].Name != "aws" || report.CacheLoadMS != 2 {
+		t.Fatalf("expected the slowest plugin first, got %+v", report)
+	}
+
+	var buf bytes.Buffer
+	if err := renderStartupReport(&buf, "table", report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"config load", "  cache load", "  aws ", "  dns (cached)", "total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "aws") > strings.Index(out, "dns") {
+		t.Errorf("expected aws before dns:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := renderStartupReport(&buf, "json", report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded startupReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Plugins[1].Cached != true {
+		t.Errorf("unexpected json %s (%v)", buf.String(), err)
+	}
+}
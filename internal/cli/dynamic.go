@@ -1,10 +1,12 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	abi "github.com/reglet-dev/reglet-abi"
 	"github.com/spf13/cobra"
@@ -13,6 +15,9 @@ import (
 	"github.com/whiskeyjimb/tack-cli/internal/runtime"
 )
 
+// defaultsFunc returns the flag defaults for one operation of a plugin.
+type defaultsFunc func(service, operation string) map[string]string
+
 // generatePluginCommand creates a cobra command tree from a plugin manifest.
 //
 // Single-service plugins (1 service): operations become direct subcommands.
@@ -23,7 +28,14 @@ import (
 //
 //	cli aws iam get_account_summary
 //	cli aws ec2 describe_security_groups
-func generatePluginCommand(manifest abi.Manifest, wasmLoader func() ([]byte, error), outputFormat *string, verbose *bool, trustPlugins *bool, defaults map[string]string) *cobra.Command {
+//
+// digest identifies the binary wasmLoader returns, so an instance created
+// for it during discovery can be reused; it may be empty.
+// runner, when set, runs operations on the process-wide runtime instead of
+// one created per call.
+// defaults supplies flag defaults per operation and may be nil.
+// timeout is the default for each operation's --timeout flag; zero means no limit.
+func generatePluginCommand(manifest abi.Manifest, digest string, wasmLoader func() ([]byte, error), runner *runtime.SharedRunner, outputFormat *string, verbose *bool, trustPlugins *bool, defaults defaultsFunc, timeout time.Duration) *cobra.Command {
 	pluginCmd := &cobra.Command{
 		Use:   manifest.Name,
 		Short: manifest.Description,
@@ -47,7 +59,7 @@ func generatePluginCommand(manifest abi.Manifest, wasmLoader func() ([]byte, err
 		for _, svc := range manifest.Services {
 			for _, op := range svc.Operations {
 				pluginCmd.AddCommand(
-					createOperationCommand(manifest.Name, svc.Name, op, schema, wasmLoader, outputFormat, verbose, trustPlugins, defaults, isMulti),
+					createOperationCommand(manifest.Name, svc.Name, op, schema, digest, wasmLoader, runner, outputFormat, verbose, trustPlugins, defaults, timeout, isMulti),
 				)
 				if len(op.Examples) > 0 {
 					rootExamples = append(rootExamples, formatExamplesForHelp(manifest.Name, svc.Name, op, isMulti))
@@ -65,7 +77,7 @@ func generatePluginCommand(manifest abi.Manifest, wasmLoader func() ([]byte, err
 			var svcExamples []string
 			for _, op := range svc.Operations {
 				svcCmd.AddCommand(
-					createOperationCommand(manifest.Name, svcName, op, schema, wasmLoader, outputFormat, verbose, trustPlugins, defaults, isMulti),
+					createOperationCommand(manifest.Name, svcName, op, schema, digest, wasmLoader, runner, outputFormat, verbose, trustPlugins, defaults, timeout, isMulti),
 				)
 				if len(op.Examples) > 0 {
 					svcExamples = append(svcExamples, formatExamplesForHelp(manifest.Name, svcName, op, isMulti))
@@ -92,51 +104,96 @@ func createOperationCommand(
 	pluginName, serviceName string,
 	op abi.OperationManifest,
 	schema *parsedSchema,
+	digest string,
 	wasmLoader func() ([]byte, error),
+	runner *runtime.SharedRunner,
 	outputFormat *string,
 	verbose *bool,
 	trustPlugins *bool,
-	defaults map[string]string,
+	defaults defaultsFunc,
+	timeout time.Duration,
 	isMulti bool,
 ) *cobra.Command {
+	// Set by PreRunE: the config, built once so templates are expanded and
+	// @file values read once, and the targets or matrix it is run for
+	var (
+		config      map[string]any
+		targets     []string
+		targetField string
+		matrix      []matrixDimension
+	)
+
 	cmd := &cobra.Command{
 		Use:   op.Name,
 		Short: op.Description,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if targets, err = fanOutTargets(cmd); err != nil {
+				return err
+			}
+			if targetField, err = prepareFanOut(cmd, schema, targets); err != nil {
+				return err
+			}
+			if matrix, err = matrixDimensions(cmd); err != nil {
+				return err
+			}
+			if err := prepareMatrix(cmd, schema, matrix); err != nil {
+				return err
+			}
+			if err := validateFlags(cmd, schema); err != nil {
+				return err
+			}
+			if config, err = operationConfig(cmd, schema, serviceName, op.Name); err != nil || schema == nil {
+				return err
+			}
+			return schema.validateConfig(config)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := cmd.Context()
-
-			// Load WASM plugin
-			wasmBytes, err := wasmLoader()
-			if err != nil {
-				return fmt.Errorf("loading plugin: %w", err)
+			// Each run is bounded by --timeout
+			execute := func(ctx context.Context, config map[string]any) (abi.Result, error) {
+				ctx, cancel := withTimeout(ctx, timeout)
+				defer cancel()
+				return executeOperation(ctx, runner, digest, wasmLoader, config, *verbose, *trustPlugins)
 			}
-
-			// Create runtime
-			runner, err := runtime.NewPluginRunner(ctx,
-				runtime.WithVerbose(*verbose),
-				runtime.WithTrustPlugins(*trustPlugins),
-			)
-			if err != nil {
-				return fmt.Errorf("creating runtime: %w", err)
+			if len(targets) > 0 {
+				return runOperationTargets(cmd, pluginName, serviceName, op, isMulti, *outputFormat, schema, config, targetField, targets, execute)
+			}
+			if len(matrix) > 0 {
+				return runOperationMatrix(cmd, pluginName, serviceName, op, isMulti, *outputFormat, schema, config, matrix, execute)
 			}
-			defer func() { _ = runner.Close(ctx) }()
 
-			plugin, err := runner.LoadPlugin(ctx, wasmBytes)
+			result, err := execute(cmd.Context(), config)
 			if err != nil {
-				return fmt.Errorf("loading plugin: %w", err)
+				return err
 			}
 
-			// Build config from flags
-			config := buildConfigFromFlags(cmd, serviceName, op.Name)
+			if show, _ := cmd.Flags().GetBool(ShowSecretsFlag); !show {
+				result.Data = output.MaskSensitive(result.Data, op.OutputSchema)
+			}
 
-			// Execute
-			result, err := plugin.Check(ctx, config)
+			format := resultFormat(*outputFormat, cmd.Flags().Changed("output"))
+			formatter, err := output.NewFormatter(format)
 			if err != nil {
-				return fmt.Errorf("executing operation: %w", err)
+				return err
+			}
+			switch f := formatter.(type) {
+			case *output.GHAFormatter:
+				f.Name = pluginName + " " + op.Name
+				if isMulti {
+					f.Name = pluginName + " " + serviceName + " " + op.Name
+				}
+			case *output.CodeQualityFormatter:
+				f.Plugin, f.Operation = pluginName, op.Name
+				if isMulti {
+					f.Service = serviceName
+				}
 			}
 
 			// Report errors from result
 			if result.IsError() && result.Error != nil {
+				if format == "gha" || format == "codequality" {
+					_ = formatter.Format(os.Stdout, result, op.OutputSchema)
+				}
 				fmt.Fprintf(os.Stderr, "Error: %s\n", result.Error.Message)
 				if result.Error.Type != "" {
 					fmt.Fprintf(os.Stderr, "  Type: %s\n", result.Error.Type)
@@ -144,22 +201,17 @@ func createOperationCommand(
 				if result.Error.Code != "" {
 					fmt.Fprintf(os.Stderr, "  Code: %s\n", result.Error.Code)
 				}
-				os.Exit(1)
+				Exit(1)
 			}
 
 			// Format output
-			formatter, err := output.NewFormatter(*outputFormat)
-			if err != nil {
-				return err
-			}
-
 			if err := formatter.Format(os.Stdout, result, op.OutputSchema); err != nil {
 				return fmt.Errorf("formatting output: %w", err)
 			}
 
 			// Set exit code for non-success results (Failed results)
 			if !result.IsSuccess() {
-				os.Exit(1)
+				Exit(1)
 			}
 
 			return nil
@@ -167,7 +219,20 @@ func createOperationCommand(
 	}
 
 	// Add operation-specific flags from schema
-	addFlagsForOperation(cmd, schema, op.InputFields, defaults)
+	var opDefaults map[string]string
+	if defaults != nil {
+		opDefaults = defaults(serviceName, op.Name)
+	}
+	addFlagsForOperation(cmd, schema, op.InputFields, opDefaults)
+	addSetFlag(cmd)
+	addFanOutFlags(cmd, schema, op.InputFields)
+	addMatrixFlag(cmd)
+
+	// Plugins that declare their own "timeout" field keep it; the execution
+	// bound then falls back to the config-wide default.
+	if cmd.Flags().Lookup("timeout") == nil {
+		cmd.Flags().DurationVar(&timeout, "timeout", timeout, "Maximum time for this operation (0 for no limit)")
+	}
 
 	// Add examples to help text
 	if len(op.Examples) > 0 {
@@ -177,6 +242,15 @@ func createOperationCommand(
 	return cmd
 }
 
+// resultFormat returns the format for commands that report check results.
+// Inside GitHub Actions, an --output the user did not set becomes "gha".
+func resultFormat(format string, explicit bool) string {
+	if !explicit && format != "quiet" && output.InGitHubActions() {
+		return "gha"
+	}
+	return format
+}
+
 // formatExamplesForHelp converts operation examples to CLI help text.
 //
 // For single-service plugins, the command format is:
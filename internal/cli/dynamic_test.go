@@ -2,9 +2,12 @@ package cli
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
 )
 
 func TestGeneratePluginCommand_SingleService(t *testing.T) {
@@ -26,7 +29,7 @@ func TestGeneratePluginCommand_SingleService(t *testing.T) {
 	verbose := false
 	trustPlugins := false
 	loader := func() ([]byte, error) { return nil, nil }
-	cmd := generatePluginCommand(manifest, loader, &outputFormat, &verbose, &trustPlugins, nil)
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trustPlugins, nil, 0)
 
 	if cmd.Use != "dns" {
 		t.Errorf("expected Use='dns', got %q", cmd.Use)
@@ -65,13 +68,76 @@ func TestGeneratePluginCommand_MultiService(t *testing.T) {
 	verbose := false
 	trustPlugins := false
 	loader := func() ([]byte, error) { return nil, nil }
-	cmd := generatePluginCommand(manifest, loader, &outputFormat, &verbose, &trustPlugins, nil)
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trustPlugins, nil, 0)
 
 	if len(cmd.Commands()) != 2 {
 		t.Fatalf("expected 2 service subcommands, got %d", len(cmd.Commands()))
 	}
 }
 
+func TestCreateOperationCommand_TimeoutFlag(t *testing.T) {
+	manifest := abi.Manifest{
+		Name: "dns",
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{Name: "resolve"}}},
+		},
+	}
+
+	outputFormat := "json"
+	verbose := false
+	trustPlugins := false
+	loader := func() ([]byte, error) { return nil, nil }
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trustPlugins, nil, 30*time.Second)
+
+	flag := cmd.Commands()[0].Flags().Lookup("timeout")
+	if flag == nil {
+		t.Fatal("expected --timeout flag on operation command")
+	}
+	if flag.DefValue != "30s" {
+		t.Errorf("expected default 30s from config, got %q", flag.DefValue)
+	}
+}
+
+func TestGeneratePluginCommand_OperationDefaults(t *testing.T) {
+	manifest := abi.Manifest{
+		Name:         "aws",
+		ConfigSchema: json.RawMessage(`{"properties":{"region":{"type":"string"}}}`),
+		Services: map[string]abi.ServiceManifest{
+			"ec2": {Name: "ec2", Operations: []abi.OperationManifest{{Name: "describe_security_groups"}}},
+			"s3":  {Name: "s3", Operations: []abi.OperationManifest{{Name: "list_buckets"}}},
+		},
+	}
+	cfg := &config.Config{
+		PluginDefaults: map[string]map[string]string{"aws": {"region": "us-east-1"}},
+		OperationDefaults: map[string]map[string]map[string]map[string]string{
+			"aws": {"ec2": {"describe_security_groups": {"region": "us-west-2"}}},
+		},
+	}
+	defaults := func(service, operation string) map[string]string {
+		return cfg.DefaultsFor("aws", service, operation)
+	}
+
+	outputFormat := "json"
+	verbose := false
+	trustPlugins := false
+	loader := func() ([]byte, error) { return nil, nil }
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trustPlugins, defaults, 0)
+
+	tests := map[string]string{
+		"ec2 describe_security_groups": "us-west-2",
+		"s3 list_buckets":              "us-east-1",
+	}
+	for path, want := range tests {
+		opCmd, _, err := cmd.Find(strings.Fields(path))
+		if err != nil {
+			t.Fatalf("Find(%s): %v", path, err)
+		}
+		if got := opCmd.Flags().Lookup("region").DefValue; got != want {
+			t.Errorf("%s: expected region default %q, got %q", path, want, got)
+		}
+	}
+}
+
 func TestInputJSONToFlags(t *testing.T) {
 	input := json.RawMessage(`{"hostname": "example.com", "record_type": "A"}`)
 	flags := inputJSONToFlags(input)
@@ -100,3 +166,26 @@ func containsStr(s, sub string) bool {
 	}
 	return false
 }
+
+func TestResultFormat(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	if got := resultFormat("table", false); got != "table" {
+		t.Errorf("outside Actions: expected table, got %q", got)
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	tests := []struct {
+		format   string
+		explicit bool
+		want     string
+	}{
+		{"table", false, "gha"},
+		{"json", true, "json"},
+		{"quiet", false, "quiet"},
+	}
+	for _, tt := range tests {
+		if got := resultFormat(tt.format, tt.explicit); got != tt.want {
+			t.Errorf("resultFormat(%q, %v) = %q, want %q", tt.format, tt.explicit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// EgressReportFlag prints the hosts plugins contacted once a command has run.
+const EgressReportFlag = "egress-report"
+
+// WriteEgressReport writes the network calls plugins made during this run
+// as a table.
+func WriteEgressReport(w io.Writer) error {
+	return renderEgressReport(w, "table", runtime.Egress())
+}
+
+func renderEgressReport(w io.Writer, format string, records []runtime.EgressRecord) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(records)
+	case "table", "":
+		if len(records) == 0 {
+			_, err := fmt.Fprintln(w, "Network egress: none")
+			return err
+		}
+		_, _ = fmt.Fprintln(w, "Network egress:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tFUNCTION\tHOST\tPORT\tCALLS\tBLOCKED")
+		for _, r := range records {
+			port := strconv.Itoa(r.Port)
+			if r.Port == 0 {
+				port = "-"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\n", r.Plugin, r.Function, r.Host, port, r.Calls, r.Blocked)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// errorReport is the JSON form of a failed command.
+type errorReport struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    errcode.Code `json:"code,omitempty"`
+	Message string       `json:"message"`
+}
+
+// WriteError reports a failed command on w, with registry credentials
+// removed. With json output it writes {"error": {"code", "message"}};
+// otherwise an "Error:" line, followed by the code when err carries one.
+func WriteError(w io.Writer, format string, err error) {
+	detail := errorDetail{Code: errcode.Of(err), Message: pluginpkg.RedactSecrets(err.Error())}
+	if format == "json" {
+		_ = json.NewEncoder(w).Encode(errorReport{Error: detail})
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Error: %s\n", detail.Message)
+	if detail.Code != "" {
+		_, _ = fmt.Fprintf(w, "  Code: %s\n", detail.Code)
+	}
+}
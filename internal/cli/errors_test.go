@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+)
+
+func TestWriteError(t *testing.T) {
+	err := errcode.Errorf(errcode.PluginNotFound, "plugin %q not found", "dns")
+
+	var buf bytes.Buffer
+	WriteError(&buf, "table", err)
+	if got := buf.String(); got != "Error: plugin \"dns\" not found\n  Code: TACK1001\n" {
+		t.Errorf("text = %q", got)
+	}
+
+	buf.Reset()
+	WriteError(&buf, "json", err)
+	var report errorReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Error.Code != errcode.PluginNotFound || report.Error.Message != `plugin "dns" not found` {
+		t.Errorf("json = %s", buf.String())
+	}
+
+	buf.Reset()
+	WriteError(&buf, "table", errors.New("boom"))
+	if got := buf.String(); got != "Error: boom\n" {
+		t.Errorf("uncoded text = %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// newExecCommand creates the "exec" command, which runs an operation from a
+// local .wasm file without installing it. The command tree is generated from
+// the module's manifest at run time, so flag parsing is deferred to it.
+func newExecCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "exec --plugin-path <file.wasm> [service] <operation> [flags]",
+		Short: "Run an operation from a local .wasm file",
+		Long: fmt.Sprintf(`Run an operation from a local .wasm file, bypassing discovery and the
+plugin cache. The module is re-read on every run, which makes it suited to
+plugin development loops.
+
+Commands and flags come from the module's manifest, exactly as for an
+installed plugin: single-service plugins take <operation>, multi-service
+plugins take <service> <operation>.
+
+Examples:
+  %s exec --plugin-path ./ping.wasm check --host example.com
+  %s exec --plugin-path ./aws.wasm ec2 describe_instances --output json
+  %s exec --plugin-path ./ping.wasm --help`, meta.AppName, meta.AppName, meta.AppName),
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, rest := extractPluginPath(args)
+			if path == "" {
+				if len(rest) == 0 || containsHelpFlag(rest) {
+					return cmd.Help()
+				}
+				return fmt.Errorf("--plugin-path is required")
+			}
+
+			wasm, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading plugin: %w", err)
+			}
+			manifest, err := inspectPlugin(cmd.Context(), wasm)
+			if err != nil {
+				return fmt.Errorf("reading manifest from %s: %w", path, err)
+			}
+			if len(manifest.Services) == 0 {
+				return fmt.Errorf("plugin %q in %s declares no services", manifest.Name, path)
+			}
+
+			pluginCmd := newLocalPluginCommand(cfg, manifest, path)
+			pluginCmd.SetArgs(rest)
+			pluginCmd.SetOut(cmd.OutOrStdout())
+			pluginCmd.SetErr(cmd.ErrOrStderr())
+			return pluginCmd.ExecuteContext(cmd.Context())
+		},
+	}
+}
+
+// newLocalPluginCommand generates the command tree for a plugin loaded from
+// path. The root-level flags are redeclared because exec disables cobra's
+// flag parsing for everything after it.
+func newLocalPluginCommand(cfg *config.Config, manifest abi.Manifest, path string) *cobra.Command {
+	var (
+		outputFormat string
+		verbose      bool
+		quiet        bool
+		trustPlugins bool
+		pluginPath   string
+	)
+
+	timeout, err := cfg.OperationTimeout()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; running without a timeout\n", err)
+	}
+
+	loader := func() ([]byte, error) { return os.ReadFile(pluginPath) }
+	defaults := func(service, operation string) map[string]string {
+		return cfg.DefaultsFor(manifest.Name, service, operation)
+	}
+	pluginCmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trustPlugins, defaults, timeout)
+	pluginCmd.Use = "exec"
+	pluginCmd.SilenceUsage = true
+	pluginCmd.SilenceErrors = true
+
+	flags := pluginCmd.PersistentFlags()
+	flags.StringVar(&pluginPath, "plugin-path", path, "Path to the plugin .wasm file")
+	flags.StringVar(&outputFormat, "output", cfg.Output, "Output format: table, json, yaml, gha, codequality")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging from plugins")
+	flags.BoolVar(&quiet, "quiet", cfg.Quiet, "Suppress output; exit code indicates result")
+	flags.BoolVar(&trustPlugins, "trust-plugins", false, "Trust all plugins and automatically grant requested capabilities")
+	pluginCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if quiet {
+			outputFormat = "quiet"
+		}
+	}
+	registerOutputFormatCompletion(pluginCmd)
+
+	return pluginCmd
+}
+
+// extractPluginPath removes --plugin-path (in either "--plugin-path x" or
+// "--plugin-path=x" form) from args and returns its value with the
+// remaining arguments.
+func extractPluginPath(args []string) (string, []string) {
+	var path string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			rest = append(rest, args[i:]...)
+			return path, rest
+		case arg == "--plugin-path" && i+1 < len(args):
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--plugin-path="):
+			path = strings.TrimPrefix(arg, "--plugin-path=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return path, rest
+}
+
+// containsHelpFlag reports whether args request help.
+func containsHelpFlag(args []string) bool {
+	for _, a := range args {
+		if a == "-h" || a == "--help" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestExtractPluginPath(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantPath string
+		wantRest []string
+	}{
+		{[]string{"--plugin-path", "./dev.wasm", "check", "--host", "x"}, "./dev.wasm", []string{"check", "--host", "x"}},
+		{[]string{"ec2", "describe", "--plugin-path=./aws.wasm"}, "./aws.wasm", []string{"ec2", "describe"}},
+		{[]string{"check", "--", "--plugin-path", "x"}, "", []string{"check", "--", "--plugin-path", "x"}},
+		{[]string{"check"}, "", []string{"check"}},
+	}
+
+	for _, tt := range tests {
+		path, rest := extractPluginPath(tt.args)
+		if path != tt.wantPath || !reflect.DeepEqual(rest, tt.wantRest) {
+			t.Errorf("extractPluginPath(%v) = %q, %v; want %q, %v", tt.args, path, rest, tt.wantPath, tt.wantRest)
+		}
+	}
+}
+
+func TestNewLocalPluginCommand(t *testing.T) {
+	manifest := abi.Manifest{
+		Name: "dev",
+		Services: map[string]abi.ServiceManifest{
+			"dev": {Name: "dev", Operations: []abi.OperationManifest{{Name: "check"}}},
+		},
+	}
+
+	cmd := newLocalPluginCommand(config.DefaultConfig(), manifest, "./dev.wasm")
+
+	if cmd.Name() != "exec" {
+		t.Errorf("expected command name exec, got %q", cmd.Name())
+	}
+	for _, name := range []string{"plugin-path", "output", "verbose", "quiet", "trust-plugins"} {
+		if cmd.PersistentFlags().Lookup(name) == nil {
+			t.Errorf("expected persistent flag --%s", name)
+		}
+	}
+	if got := cmd.PersistentFlags().Lookup("plugin-path").DefValue; got != "./dev.wasm" {
+		t.Errorf("expected plugin-path default ./dev.wasm, got %q", got)
+	}
+	if len(cmd.Commands()) != 1 || cmd.Commands()[0].Name() != "check" {
+		t.Errorf("expected a single check subcommand, got %v", cmd.Commands())
+	}
+}
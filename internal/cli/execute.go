@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// executeOperation loads a plugin and runs a single operation with the given config.
+// With a shared runner, the plugin runs on the process-wide runtime, whose
+// options take the place of verbose and trustPlugins, reusing the instance
+// discovery created for digest if there is one. Otherwise a runtime is
+// created for this call and closed before returning.
+//
+// With --remote, the plugin is loaded and granted capabilities here but
+// the operation runs on the remote host.
+//
+// If ctx is cancelled or its deadline passes while the plugin is running, the
+// call returns immediately and a per-call runtime is torn down in the
+// background. WASM execution is not preemptible, so the module may keep
+// running until it next yields to a host function.
+func executeOperation(ctx context.Context, shared *runtime.SharedRunner, digest string, wasmLoader func() ([]byte, error), config map[string]any, verbose, trustPlugins bool) (abi.Result, error) {
+	var (
+		runner  *runtime.PluginRunner
+		err     error
+		release = func() {}
+	)
+	if shared != nil {
+		runner, err = shared.Get(ctx)
+	} else {
+		runner, err = runtime.NewPluginRunner(ctx,
+			runtime.WithVerbose(verbose),
+			runtime.WithTrustPlugins(trustPlugins),
+		)
+		release = func() { _ = runner.Close(context.Background()) }
+	}
+	if err != nil {
+		return abi.Result{}, fmt.Errorf("creating runtime: %w", err)
+	}
+
+	type outcome struct {
+		result abi.Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		plugin, err := runner.LoadPluginFor(ctx, digest, wasmLoader)
+		if err != nil {
+			done <- outcome{err: fmt.Errorf("loading plugin: %w", err)}
+			return
+		}
+		start := time.Now()
+		var result abi.Result
+		if remote := activeRemote(); remote != nil {
+			result, err = remote.run(ctx, plugin, wasmLoader, config, verbose)
+		} else {
+			result, err = plugin.Check(ctx, config)
+		}
+		recordResult(plugin.Manifest, digest, config, start, result, err)
+		if err != nil {
+			done <- outcome{err: fmt.Errorf("executing operation: %w", err)}
+			return
+		}
+		done <- outcome{result: result}
+	}()
+
+	select {
+	case out := <-done:
+		release()
+		return out.result, out.err
+	case <-ctx.Done():
+		go func() {
+			<-done
+			release()
+		}()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return abi.Result{}, fmt.Errorf("operation timed out: %w", ctx.Err())
+		}
+		return abi.Result{}, fmt.Errorf("operation cancelled: %w", ctx.Err())
+	}
+}
+
+// withTimeout returns ctx bounded by d. A zero d leaves ctx unbounded.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// discoverPlugins runs plugin discovery with the standard loader configuration.
+func discoverPlugins(ctx context.Context, cfg *config.Config, stack *pluginpkg.PluginStack) ([]pluginpkg.DiscoveredPlugin, error) {
+	loader := pluginpkg.NewLoader(
+		pluginpkg.EmbeddedPlugins,
+		pluginpkg.DefaultPluginsDir(),
+		stack,
+		cfg.DefaultRegistry,
+	)
+	return loader.DiscoverAll(ctx)
+}
+
+// pluginExecutor runs operations of discovered plugins by name.
+// It applies plugin_defaults from config underneath the supplied config,
+// matching the behavior of generated operation commands. Instantiated
+// modules are pooled per plugin and reused across executions; call Close
+// when done.
+type pluginExecutor struct {
+	plugins map[string]pluginpkg.DiscoveredPlugin
+	cfg     *config.Config
+	timeout time.Duration
+	pool    *runtime.ModulePool
+}
+
+// newPluginExecutor creates a pluginExecutor over the discovered plugins.
+// Each execution is bounded by the config-wide timeout.
+func newPluginExecutor(discovered []pluginpkg.DiscoveredPlugin, cfg *config.Config, verbose, trustPlugins bool) *pluginExecutor {
+	var timeout time.Duration
+	if cfg != nil {
+		d, err := cfg.OperationTimeout()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; running without a timeout\n", err)
+		}
+		timeout = d
+	}
+
+	instances := goruntime.NumCPU()
+	if cfg != nil && cfg.MaxInstances > 0 {
+		instances = cfg.MaxInstances
+	}
+
+	plugins := make(map[string]pluginpkg.DiscoveredPlugin, len(discovered))
+	for _, dp := range discovered {
+		plugins[dp.Manifest.Name] = dp
+	}
+	return &pluginExecutor{
+		plugins: plugins,
+		cfg:     cfg,
+		timeout: timeout,
+		pool: runtime.NewModulePool(instances,
+			runtime.WithVerbose(verbose),
+			runtime.WithTrustPlugins(trustPlugins),
+		),
+	}
+}
+
+// Close releases the pooled plugin modules.
+func (e *pluginExecutor) Close() error {
+	return e.pool.Close(context.Background())
+}
+
+// Execute implements workflow.Executor.
+func (e *pluginExecutor) Execute(ctx context.Context, pluginName, service, operation string, input map[string]any) (abi.Result, error) {
+	dp, ok := e.plugins[pluginName]
+	if !ok {
+		return abi.Result{}, fmt.Errorf("plugin %q not found", pluginName)
+	}
+
+	service, err := resolveService(dp.Manifest, service, operation)
+	if err != nil {
+		return abi.Result{}, err
+	}
+
+	config := map[string]any{}
+	var defaults map[string]string
+	if e.cfg != nil {
+		defaults = e.cfg.DefaultsFor(pluginName, service, operation)
+	}
+	if len(defaults) > 0 {
+		schema, err := parseConfigSchema(dp.Manifest.ConfigSchema)
+		if err != nil {
+			return abi.Result{}, fmt.Errorf("failed to parse plugin config schema: %w", err)
+		}
+		for k, v := range defaults {
+			prop, _ := schema.property(k)
+			setField(config, k, coerceDefault(prop, v))
+		}
+	}
+	for k, v := range input {
+		config[k] = v
+	}
+	config["service"] = service
+	config["operation"] = operation
+
+	ctx, cancel := withTimeout(ctx, e.timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := e.pool.Check(ctx, pluginName+"@"+dp.Digest, dp.Loader, config)
+	recordResult(dp.Manifest, dp.Digest, config, start, result, err)
+	return result, err
+}
+
+// severityFunc returns the Code Quality severity hint of an operation.
+type severityFunc func(plugin, service, operation string) string
+
+// of calls f, treating a nil function as having no hints.
+func (f severityFunc) of(plugin, service, operation string) string {
+	if f == nil {
+		return ""
+	}
+	return f(plugin, service, operation)
+}
+
+// severityHints reads severity hints from the output schemas of the
+// discovered plugins' operations.
+func severityHints(discovered []pluginpkg.DiscoveredPlugin) severityFunc {
+	return func(plugin, service, operation string) string {
+		return output.SeverityHint(outputSchemaOf(discovered, plugin, service, operation))
+	}
+}
+
+// ShowSecretsFlag prints the fields plugins annotate "x-sensitive" instead
+// of masking them.
+const ShowSecretsFlag = "show-secrets"
+
+// maskFunc masks the sensitive fields of an operation's result data.
+type maskFunc func(plugin, service, operation string, data map[string]any) map[string]any
+
+// sensitiveMasks masks result data by the output schemas of the discovered
+// plugins' operations. With show, data is left as is.
+func sensitiveMasks(discovered []pluginpkg.DiscoveredPlugin, show bool) maskFunc {
+	return func(plugin, service, operation string, data map[string]any) map[string]any {
+		if show {
+			return data
+		}
+		return output.MaskSensitive(data, outputSchemaOf(discovered, plugin, service, operation))
+	}
+}
+
+// outputSchemaOf returns the output schema of a discovered plugin's
+// operation, or nil when there is none.
+func outputSchemaOf(discovered []pluginpkg.DiscoveredPlugin, plugin, service, operation string) json.RawMessage {
+	for _, dp := range discovered {
+		if dp.Manifest.Name != plugin {
+			continue
+		}
+		svcName, err := resolveService(dp.Manifest, service, operation)
+		if err != nil {
+			return nil
+		}
+		for _, op := range dp.Manifest.Services[svcName].Operations {
+			if op.Name == operation {
+				return op.OutputSchema
+			}
+		}
+	}
+	return nil
+}
+
+// resolveService returns the service name used in the plugin config for an
+// operation. For single-service plugins the service may be omitted.
+func resolveService(manifest abi.Manifest, service, operation string) (string, error) {
+	if len(manifest.Services) > 1 {
+		svc, ok := manifest.Services[service]
+		if !ok {
+			return "", fmt.Errorf("plugin %q has multiple services; a valid service is required", manifest.Name)
+		}
+		if !hasOperation(svc, operation) {
+			return "", fmt.Errorf("service %q of plugin %q has no operation %q", service, manifest.Name, operation)
+		}
+		return service, nil
+	}
+
+	for _, svc := range manifest.Services {
+		if service != "" && service != svc.Name {
+			return "", fmt.Errorf("plugin %q has no service %q", manifest.Name, service)
+		}
+		if !hasOperation(svc, operation) {
+			return "", fmt.Errorf("plugin %q has no operation %q", manifest.Name, operation)
+		}
+		return svc.Name, nil
+	}
+	return "", fmt.Errorf("plugin %q declares no services", manifest.Name)
+}
+
+func hasOperation(svc abi.ServiceManifest, operation string) bool {
+	for _, op := range svc.Operations {
+		if op.Name == operation {
+			return true
+		}
+	}
+	return false
+}
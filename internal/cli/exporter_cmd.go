@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/notify"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/schedule"
+)
+
+// newExporterCommand creates the "exporter" command.
+func newExporterCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	var (
+		checksFile string
+		listen     string
+		withHist   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "exporter",
+		Short: "Expose scheduled checks as Prometheus metrics",
+		Long: fmt.Sprintf(`Run checks on intervals and serve their outcomes as Prometheus metrics,
+like a blackbox exporter whose probes are plugin operations.
+
+Checks use the schedule file format, from --checks and the "schedule"
+section of the config file:
+
+  checks:
+    - name: example-dns
+      schedule: "@every 30s"
+      plugin: dns
+      operation: resolve
+      with:
+        hostname: example.com
+
+Every check runs once at startup and then on its schedule. Metrics are
+served at /metrics, labelled by check, plugin, service and operation:
+
+  tack_check_success                          1 if the last run succeeded
+  tack_check_status{status=...}               1 for the last run's status
+  tack_check_duration_seconds                 duration of the last run
+  tack_check_last_run_timestamp_seconds       when the check last ran
+  tack_check_last_success_timestamp_seconds   when the check last succeeded
+  tack_check_runs_total                       runs so far
+  tack_check_failures_total                   runs that did not succeed
+
+/status and /healthz are served as by "%s schedule --listen". Results are
+not written to the history store unless --history is given.
+
+Examples:
+  %s exporter --checks checks.yaml
+  %s exporter --checks checks.yaml --listen :9469 --trust-plugins`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := append([]config.ScheduledCheck(nil), cfg.Schedule...)
+			if checksFile != "" {
+				fromFile, err := schedule.LoadFile(checksFile)
+				if err != nil {
+					return err
+				}
+				checks = append(checks, fromFile...)
+			}
+
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			trustPlugins, _ := cmd.Flags().GetBool("trust-plugins")
+			exec := newPluginExecutor(discovered, cfg, verbose, trustPlugins)
+			defer func() { _ = exec.Close() }()
+
+			var store *history.Store
+			if withHist {
+				store = history.Open(history.DefaultPath())
+			}
+
+			notifier, err := notify.New(cfg.Notifications)
+			if err != nil {
+				return err
+			}
+
+			opts := []schedule.Option{schedule.WithNotifier(notifier), schedule.WithRunAtStart()}
+			if w := LogWriter(); w != nil {
+				opts = append(opts, schedule.WithRunLog(w))
+			}
+			sched, err := schedule.New(checks, exec, store, opts...)
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{Addr: listen, Handler: sched.Handler(), ReadHeaderTimeout: 10 * time.Second}
+			errCh := make(chan error, 1)
+			go func() { errCh <- srv.ListenAndServe() }()
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Exporting %d checks at http://%s/metrics (Ctrl-C to stop)\n", len(checks), listen)
+
+			runCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go func() { _ = sched.Run(runCtx) }()
+
+			select {
+			case err := <-errCh:
+				return fmt.Errorf("serving metrics: %w", err)
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+				return nil
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&checksFile, "checks", "", "Schedule file with the checks to run")
+	cmd.Flags().StringVar(&listen, "listen", ":9469", "Address to serve metrics on")
+	cmd.Flags().BoolVar(&withHist, "history", false, "Also record results in the history store")
+	return cmd
+}
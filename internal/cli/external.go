@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// ExternalPluginPrefix prefixes the executables run for unknown commands:
+// "tack foo" runs "tack-foo".
+const ExternalPluginPrefix = meta.AppName + "-"
+
+// ExternalCommand returns the external plugin an unknown top-level command
+// on the command line names, and the arguments that follow it. It reports
+// false when the command line reaches a built-in, alias, group, or WASM
+// plugin command, or names no executable.
+//
+// Executables are looked up in cfg.ExternalPluginDirs, in order, then on
+// PATH.
+func ExternalCommand(root *cobra.Command, cfg *config.Config, args []string) (path string, rest []string, ok bool) {
+	name, rest := splitCommandWord(args)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", nil, false
+	}
+	if cmd, _, err := root.Find(args); err == nil || cmd != root {
+		return "", nil, false
+	}
+	path, ok = findExternalPlugin(ExternalPluginPrefix+name, cfg.ExternalPluginDirs)
+	return path, rest, ok
+}
+
+// splitCommandWord returns the first word of args that is not a flag, and
+// the arguments after it.
+func splitCommandWord(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return "", nil
+		}
+		if strings.HasPrefix(arg, "-") {
+			// Persistent flags that take a separate value
+			if arg == "--output" || arg == "--insecure-skip-tls-verify" || arg == "--"+LogFileFlag || arg == "--"+RemoteFlag {
+				i++
+			}
+			continue
+		}
+		return arg, args[i+1:]
+	}
+	return "", nil
+}
+
+func findExternalPlugin(file string, dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		path, err := exec.LookPath(filepath.Join(dir, file))
+		if err == nil {
+			return path, true
+		}
+	}
+	path, err := exec.LookPath(file)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// RunExternalPlugin runs the external plugin at path with args, connected
+// to this process's standard streams, and returns its exit code. The output
+// format is passed in TACK_OUTPUT. External plugins are not sandboxed, so
+// read-only mode refuses them.
+func RunExternalPlugin(ctx context.Context, cfg *config.Config, path string, args []string, outputFormat string) (int, error) {
+	if cfg.ReadOnly {
+		return 1, fmt.Errorf("external plugin %s: %w", filepath.Base(path), config.ErrReadOnly)
+	}
+	prefix := strings.ToUpper(meta.AppName) + "_"
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), prefix+"OUTPUT="+outputFormat)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code > 0 {
+			return code, nil
+		}
+		return 1, nil
+	}
+	if err != nil {
+		return 1, fmt.Errorf("running external plugin %s: %w", filepath.Base(path), err)
+	}
+	return 0, nil
+}
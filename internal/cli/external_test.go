@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestExternalCommand(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\necho \"$TACK_OUTPUT $*\" > " + out + "\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "tack-hello"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", "")
+
+	root := &cobra.Command{Use: "tack"}
+	root.PersistentFlags().String("output", "table", "")
+	root.AddCommand(&cobra.Command{Use: "version", Run: func(*cobra.Command, []string) {}})
+	cfg := &config.Config{ExternalPluginDirs: []string{dir}}
+
+	for _, args := range [][]string{{"version"}, {"missing"}, {"--output", "json"}, {"../tack-hello"}} {
+		if _, _, ok := ExternalCommand(root, cfg, args); ok {
+			t.Errorf("ExternalCommand(%q) found an external plugin", args)
+		}
+	}
+
+	path, rest, ok := ExternalCommand(root, cfg, []string{"--output", "json", "hello", "world", "--loud"})
+	if !ok || filepath.Base(path) != "tack-hello" {
+		t.Fatalf("ExternalCommand = %q, %v", path, ok)
+	}
+	if strings.Join(rest, " ") != "world --loud" {
+		t.Fatalf("rest = %q", rest)
+	}
+
+	code, err := RunExternalPlugin(context.Background(), cfg, path, rest, "json")
+	if err != nil || code != 3 {
+		t.Fatalf("RunExternalPlugin = %d, %v", code, err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(got)) != "json world --loud" {
+		t.Fatalf("plugin saw %q", got)
+	}
+
+	cfg.ReadOnly = true
+	if _, err := RunExternalPlugin(context.Background(), cfg, path, rest, "json"); !errors.Is(err, config.ErrReadOnly) {
+		t.Fatalf("expected read-only refusal, got %v", err)
+	}
+}
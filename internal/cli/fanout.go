@@ -0,0 +1,375 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	"github.com/whiskeyjimb/tack-cli/internal/workers"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags that run an operation once per target.
+const (
+	targetFlag      = "target"
+	targetsFlag     = "targets"
+	targetFieldFlag = "target-field"
+	parallelFlag    = "parallel"
+)
+
+// fanOutResult is one target's outcome in a fan-out report.
+type fanOutResult struct {
+	Target   string         `json:"target" yaml:"target"`
+	Status   string         `json:"status" yaml:"status"`
+	Duration time.Duration  `json:"duration_ns" yaml:"duration"`
+	Message  string         `json:"message,omitempty" yaml:"message,omitempty"`
+	Data     map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+
+	result abi.Result
+}
+
+// fanOutReport aggregates an operation run once per target.
+type fanOutReport struct {
+	Plugin    string         `json:"plugin" yaml:"plugin"`
+	Service   string         `json:"service,omitempty" yaml:"service,omitempty"`
+	Operation string         `json:"operation" yaml:"operation"`
+	Field     string         `json:"field" yaml:"field"`
+	Results   []fanOutResult `json:"results" yaml:"results"`
+	Duration  time.Duration  `json:"duration_ns" yaml:"duration"`
+}
+
+// failed returns the number of targets that did not succeed.
+func (r *fanOutReport) failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status != string(abi.ResultStatusSuccess) {
+			n++
+		}
+	}
+	return n
+}
+
+// addFanOutFlags adds the flags that run an operation once per target,
+// except any the plugin declares as a field of its own. The target field
+// defaults to the operation's only required scalar field, if it has one.
+func addFanOutFlags(cmd *cobra.Command, schema *parsedSchema, inputFields []string) {
+	add := func(name string, define func()) {
+		if cmd.Flags().Lookup(name) != nil {
+			return
+		}
+		define()
+		_ = cmd.Flags().SetAnnotation(name, fanOutAnnotation, []string{"true"})
+	}
+	add(targetFlag, func() {
+		cmd.Flags().StringArray(targetFlag, nil, "Run the operation once for this target (repeatable); see --target-field")
+	})
+	add(targetsFlag, func() {
+		cmd.Flags().String(targetsFlag, "", "Run the operation once per line of this file (- for stdin); blank lines and # comments are skipped")
+	})
+	add(targetFieldFlag, func() {
+		cmd.Flags().String(targetFieldFlag, defaultTargetField(schema, inputFields), "Flag each target is substituted into")
+	})
+	add(parallelFlag, func() {
+		cmd.Flags().Int(parallelFlag, 1, "Maximum targets to run at once")
+	})
+}
+
+// fanOutAnnotation marks the fan-out flags, which are not plugin config.
+const fanOutAnnotation = "tack_fan_out"
+
+// fanOutFlag returns the value of a fan-out flag, and false when the
+// plugin's own field of that name took its place.
+func fanOutFlag(cmd *cobra.Command, name string) (string, bool) {
+	f := cmd.Flags().Lookup(name)
+	if f == nil || len(f.Annotations[fanOutAnnotation]) == 0 {
+		return "", false
+	}
+	return f.Value.String(), true
+}
+
+// defaultTargetField returns the flag of the operation's only required
+// scalar field, or "" when it has none or several.
+func defaultTargetField(schema *parsedSchema, inputFields []string) string {
+	if schema == nil {
+		return ""
+	}
+	var found []string
+	for _, name := range schema.requiredFields() {
+		if name == "service" || name == "operation" {
+			continue
+		}
+		if len(inputFields) > 0 && !slices.Contains(inputFields, name) {
+			continue
+		}
+		prop := schema.flagProperties()[name]
+		if prop.Type == "string" || prop.Type == "integer" || prop.Type == "number" {
+			found = append(found, strings.ReplaceAll(name, "_", "-"))
+		}
+	}
+	if len(found) != 1 {
+		return ""
+	}
+	return found[0]
+}
+
+// fanOutTargets returns the targets given with --target and --targets, in
+// that order, or nil when there are none.
+func fanOutTargets(cmd *cobra.Command) ([]string, error) {
+	var targets []string
+	if _, ok := fanOutFlag(cmd, targetFlag); ok {
+		targets, _ = cmd.Flags().GetStringArray(targetFlag)
+	}
+	if path, ok := fanOutFlag(cmd, targetsFlag); ok && path != "" {
+		fromFile, err := readTargets(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(fromFile) == 0 {
+			return nil, fmt.Errorf("--%s %s lists no targets", targetsFlag, path)
+		}
+		targets = append(targets, fromFile...)
+	}
+	return targets, nil
+}
+
+// readTargets reads one target per line from path, or stdin for "-".
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading targets: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading targets: %w", err)
+	}
+	return targets, nil
+}
+
+// prepareFanOut checks the targets against the target field and, so the
+// required-flag and schema checks pass, sets that flag to the first target.
+// It returns the field's flag name, or "" when no targets were given.
+func prepareFanOut(cmd *cobra.Command, schema *parsedSchema, targets []string) (string, error) {
+	if len(targets) == 0 {
+		return "", nil
+	}
+	field, _ := fanOutFlag(cmd, targetFieldFlag)
+	if field == "" {
+		return "", fmt.Errorf("--%s is required: %s has no single required field to put targets in", targetFieldFlag, cmd.CommandPath())
+	}
+	if err := prepareSubstitution(cmd, schema, field, targets, "--"+targetFlag+" or --"+targetsFlag); err != nil {
+		return "", err
+	}
+	return field, nil
+}
+
+// prepareSubstitution checks values that will be substituted into the
+// field a flag sets, one run each, and sets the flag to the first of them so
+// the required-flag and schema checks pass. via names the flags the values
+// came from, for errors.
+func prepareSubstitution(cmd *cobra.Command, schema *parsedSchema, field string, values []string, via string) error {
+	f := cmd.Flags().Lookup(field)
+	if f == nil || !fanOutScalar(f.Value.Type()) {
+		return fmt.Errorf("invalid field %q: not a string or number flag of %s", field, cmd.CommandPath())
+	}
+	if f.Changed {
+		return fmt.Errorf("--%s cannot be combined with %s", field, via)
+	}
+	if schema != nil {
+		if prop, ok := schema.property(field); ok {
+			for _, v := range values {
+				if err := validateValue(field, prop, coerceDefault(prop, v)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := cmd.Flags().Set(field, values[0]); err != nil {
+		return fmt.Errorf("invalid value %q for --%s: %w", values[0], field, err)
+	}
+	return nil
+}
+
+// fanOutScalar reports whether a flag of this type can take a target.
+func fanOutScalar(flagType string) bool {
+	return flagType == "string" || flagType == "int" || flagType == "float64"
+}
+
+// runFanOut runs an operation once per target, with the target substituted
+// into the field a flag sets, at most parallel at a time. Results keep the
+// order of targets. Capability reviews still happen one at a time: the
+// runtime serializes them across runners.
+func runFanOut(
+	ctx context.Context,
+	config map[string]any,
+	field string,
+	prop schemaProperty,
+	targets []string,
+	parallel int,
+	execute func(ctx context.Context, config map[string]any) (abi.Result, error),
+) []fanOutResult {
+	results := make([]fanOutResult, len(targets))
+	workers.ForEach(len(targets), parallel, func(i int) {
+		cfg := copyConfig(config)
+		setField(cfg, field, coerceDefault(prop, targets[i]))
+		results[i] = fanOutRun(ctx, targets[i], cfg, execute)
+	})
+	return results
+}
+
+// fanOutRun runs execute with config and returns its outcome, labelled.
+func fanOutRun(ctx context.Context, label string, config map[string]any, execute func(ctx context.Context, config map[string]any) (abi.Result, error)) fanOutResult {
+	start := time.Now()
+	result, err := execute(ctx, config)
+	r := fanOutResult{Target: label, Duration: time.Since(start)}
+	if err != nil {
+		result = abi.Result{Status: abi.ResultStatusError, Message: err.Error()}
+	}
+	r.Status, r.Message, r.Data, r.result = string(result.Status), result.Message, result.Data, result
+	if result.Error != nil && result.Error.Message != "" {
+		r.Message = result.Error.Message
+	}
+	return r
+}
+
+// copyConfig copies config deeply enough that setting a field in the copy,
+// nested or not, leaves the original untouched.
+func copyConfig(config map[string]any) map[string]any {
+	out := make(map[string]any, len(config))
+	for k, v := range config {
+		if nested, ok := v.(map[string]any); ok {
+			v = copyConfig(nested)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// runOperationTargets runs an operation command once per target and
+// reports the results together, exiting non-zero if any target did not
+// succeed.
+func runOperationTargets(
+	cmd *cobra.Command,
+	pluginName, serviceName string,
+	op abi.OperationManifest,
+	isMulti bool,
+	outputFormat string,
+	schema *parsedSchema,
+	config map[string]any,
+	field string,
+	targets []string,
+	execute func(ctx context.Context, config map[string]any) (abi.Result, error),
+) error {
+	prop, _ := schema.property(field)
+	parallel := 1
+	if v, ok := fanOutFlag(cmd, parallelFlag); ok {
+		parallel, _ = strconv.Atoi(v)
+	}
+	show, _ := cmd.Flags().GetBool(ShowSecretsFlag)
+
+	start := time.Now()
+	results := runFanOut(cmd.Context(), config, field, prop, targets, parallel, func(ctx context.Context, config map[string]any) (abi.Result, error) {
+		result, err := execute(ctx, config)
+		if !show {
+			result.Data = output.MaskSensitive(result.Data, op.OutputSchema)
+		}
+		return result, err
+	})
+	report := &fanOutReport{Plugin: pluginName, Operation: op.Name, Field: field, Results: results, Duration: time.Since(start)}
+	if isMulti {
+		report.Service = serviceName
+	}
+
+	format := resultFormat(outputFormat, cmd.Flags().Changed("output"))
+	if err := renderFanOutReport(os.Stdout, format, report, op.OutputSchema, output.SeverityHint(op.OutputSchema)); err != nil {
+		return err
+	}
+	if report.failed() > 0 {
+		Exit(1)
+	}
+	return nil
+}
+
+// renderFanOutReport writes a fan-out report in the given output format.
+// schema is the operation's output schema, which orders the table columns.
+func renderFanOutReport(w io.Writer, format string, report *fanOutReport, schema json.RawMessage, severity string) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		targets := make([]string, len(report.Results))
+		results := make([]abi.Result, len(report.Results))
+		for i, r := range report.Results {
+			targets[i], results[i] = r.Target, r.result
+		}
+		if err := (&output.TableFormatter{}).FormatTargets(w, "Target", targets, results, schema); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "\nRan %s on %d targets in %s (%d failed)\n",
+			report.Operation, len(report.Results), report.Duration.Round(time.Millisecond), report.failed())
+		return nil
+	case "gha":
+		if err := renderFanOutReport(w, "table", report, schema, severity); err != nil {
+			return err
+		}
+		checks := fanOutChecks(report, severity)
+		if err := output.WriteAnnotations(w, checks); err != nil {
+			return err
+		}
+		return output.WriteStepSummary(fmt.Sprintf("%s %s", report.Plugin, report.Operation), checks)
+	case "codequality":
+		return output.WriteCodeQuality(w, fanOutChecks(report, severity))
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, gha, codequality, quiet)", format)
+	}
+}
+
+// fanOutChecks converts a fan-out report into one check per target.
+func fanOutChecks(report *fanOutReport, severity string) []output.Check {
+	checks := make([]output.Check, 0, len(report.Results))
+	for _, r := range report.Results {
+		checks = append(checks, output.Check{
+			Name:      fmt.Sprintf("%s %s %s", report.Plugin, report.Operation, r.Target),
+			Status:    r.Status,
+			Message:   r.Message,
+			Duration:  r.Duration,
+			Plugin:    report.Plugin,
+			Service:   report.Service,
+			Operation: report.Operation,
+			Severity:  severity,
+		})
+	}
+	return checks
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+func mustParseSchema(t *testing.T, schema string) *parsedSchema {
+	t.Helper()
+	s, err := parseConfigSchema(json.RawMessage(schema))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+	return s
+}
+
+func TestDefaultTargetField(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "single required",
+			schema: `{"properties":{"host_name":{"type":"string"},"type":{"type":"string"}},"required":["host_name"]}`,
+			want:   "host-name",
+		},
+		{
+			name:   "several required",
+			schema: `{"properties":{"host":{"type":"string"},"port":{"type":"integer"}},"required":["host","port"]}`,
+		},
+		{
+			name:   "required outside the operation",
+			schema: `{"properties":{"host":{"type":"string"},"url":{"type":"string"}},"required":["host","url"]}`,
+			fields: []string{"url"},
+			want:   "url",
+		},
+		{
+			name:   "none required",
+			schema: `{"properties":{"host":{"type":"string"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultTargetField(mustParseSchema(t, tt.schema), tt.fields); got != tt.want {
+				t.Errorf("defaultTargetField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFanOutTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte("# hosts\nexample.com\n\n  example.org  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := mustParseSchema(t, `{"properties":{"hostname":{"type":"string","pattern":"^[a-z.]+$"}},"required":["hostname"]}`)
+	manifest := abi.Manifest{
+		Name:         "dns",
+		ConfigSchema: schema.raw,
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{Name: "resolve"}}},
+		},
+	}
+	outputFormat, verbose, trust := "json", false, false
+	loader := func() ([]byte, error) { return nil, nil }
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trust, nil, 0).Commands()[0]
+
+	if err := cmd.ParseFlags([]string{"--target", "a.example", "--targets", path}); err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	targets, err := fanOutTargets(cmd)
+	if err != nil {
+		t.Fatalf("fanOutTargets: %v", err)
+	}
+	if strings.Join(targets, ",") != "a.example,example.com,example.org" {
+		t.Fatalf("unexpected targets %q", targets)
+	}
+
+	field, err := prepareFanOut(cmd, schema, targets)
+	if err != nil {
+		t.Fatalf("prepareFanOut: %v", err)
+	}
+	if field != "hostname" {
+		t.Errorf("expected targets to go in hostname, got %q", field)
+	}
+	if err := cmd.ValidateRequiredFlags(); err != nil {
+		t.Errorf("expected the required flag satisfied, got %v", err)
+	}
+	config := buildConfigFromFlags(cmd, "dns", "resolve")
+	for _, key := range []string{"target", "targets", "target_field", "parallel"} {
+		if _, ok := config[key]; ok {
+			t.Errorf("expected %q kept out of the plugin config, got %v", key, config)
+		}
+	}
+}
+
+func TestPrepareFanOut_Refused(t *testing.T) {
+	schema := mustParseSchema(t, `{"properties":{"hostname":{"type":"string","pattern":"^[a-z.]+$"}},"required":["hostname"]}`)
+	manifest := abi.Manifest{
+		Name:         "dns",
+		ConfigSchema: schema.raw,
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{Name: "resolve"}}},
+		},
+	}
+	outputFormat, verbose, trust := "json", false, false
+	loader := func() ([]byte, error) { return nil, nil }
+	cmd := generatePluginCommand(manifest, "", loader, nil, &outputFormat, &verbose, &trust, nil, 0).Commands()[0]
+
+	if _, err := prepareFanOut(cmd, schema, []string{"ok.example", "Not Valid"}); err == nil {
+		t.Error("expected a target failing the field's pattern to be refused")
+	}
+
+	if err := cmd.ParseFlags([]string{"--hostname", "ok.example"}); err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	if _, err := prepareFanOut(cmd, schema, []string{"other.example"}); err == nil {
+		t.Error("expected the target field given alongside targets to be refused")
+	}
+}
+
+func TestRunFanOut(t *testing.T) {
+	targets := []string{"a", "b", "c", "d"}
+	config := map[string]any{"service": "dns", "operation": "resolve", "opts": map[string]any{"host": "unset"}}
+	results := runFanOut(context.Background(), config, "opts.host", schemaProperty{Type: "string"}, targets, 3,
+		func(ctx context.Context, config map[string]any) (abi.Result, error) {
+			host := config["opts"].(map[string]any)["host"].(string)
+			switch host {
+			case "b":
+				return abi.ResultFailure("no answer", nil), nil
+			case "c":
+				return abi.Result{}, errors.New("operation timed out")
+			}
+			return abi.ResultSuccess("ok", map[string]any{"address": host + ".example"}), nil
+		})
+
+	if len(results) != len(targets) {
+		t.Fatalf("expected %d results, got %d", len(targets), len(results))
+	}
+	for i, r := range results {
+		if r.Target != targets[i] {
+			t.Errorf("result %d is for %q, want %q", i, r.Target, targets[i])
+		}
+	}
+	if results[0].Data["address"] != "a.example" || results[1].Status != "failure" || results[2].Status != "error" || results[2].Message != "operation timed out" {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if config["opts"].(map[string]any)["host"] != "unset" {
+		t.Error("expected the shared config left untouched")
+	}
+
+	report := &fanOutReport{Plugin: "dns", Operation: "resolve", Field: "host", Results: results}
+	if report.failed() != 2 {
+		t.Errorf("expected 2 failed targets, got %d", report.failed())
+	}
+	var buf bytes.Buffer
+	if err := renderFanOutReport(&buf, "table", report, nil, ""); err != nil {
+		t.Fatalf("renderFanOutReport: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Target", "Address", "a.example", "no answer", "Ran resolve on 4 targets"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+}
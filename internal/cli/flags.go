@@ -2,25 +2,78 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
 )
 
 // schemaProperty represents a single property from a JSON Schema.
 type schemaProperty struct {
-	Type        string `json:"type"`
-	Enum        []any  `json:"enum"`
-	Default     any    `json:"default"`
-	Description string `json:"description"`
+	Type        string          `json:"type"`
+	Enum        []any           `json:"enum"`
+	Default     any             `json:"default"`
+	Const       any             `json:"const"`
+	Description string          `json:"description"`
+	Pattern     string          `json:"pattern"`
+	Format      string          `json:"format"`
+	Env         string          `json:"x-env"`
+	Sensitive   bool            `json:"x-sensitive"`
+	Minimum     *float64        `json:"minimum"`
+	Maximum     *float64        `json:"maximum"`
+	MultipleOf  *float64        `json:"multipleOf"`
+	Items       *schemaProperty `json:"items"`
+
+	// Properties and Required describe the fields of an object property
+	Properties map[string]schemaProperty `json:"properties"`
+	Required   []string                  `json:"required"`
 }
 
 // parsedSchema holds the parsed config schema.
 type parsedSchema struct {
 	Properties map[string]schemaProperty `json:"properties"`
 	Required   []string                  `json:"required"`
+
+	// Subschemas of a polymorphic config. Their properties become flags
+	// alongside the top-level ones; see flagProperties.
+	OneOf []parsedSchema `json:"oneOf"`
+	AnyOf []parsedSchema `json:"anyOf"`
+	AllOf []parsedSchema `json:"allOf"`
+	Then  *parsedSchema  `json:"then"`
+	Else  *parsedSchema  `json:"else"`
+
+	// Flag combination hints. Each x- entry is a group of fields;
+	// dependentRequired maps a field to the fields it needs.
+	MutuallyExclusive [][]string          `json:"x-mutually-exclusive"`
+	RequiredTogether  [][]string          `json:"x-required-together"`
+	DependentRequired map[string][]string `json:"dependentRequired"`
+
+	raw      json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// property returns the schema property a flag sets. Dotted flag names
+// such as "tls.min-version" resolve through nested object properties.
+func (s *parsedSchema) property(flagName string) (schemaProperty, bool) {
+	var prop schemaProperty
+	props := s.flagProperties()
+	for _, part := range strings.Split(flagName, ".") {
+		p, ok := props[flagToField(part)]
+		if !ok {
+			return schemaProperty{}, false
+		}
+		prop, props = p, p.Properties
+	}
+	return prop, true
 }
 
 // parseConfigSchema parses a JSON Schema from raw bytes.
@@ -32,6 +85,7 @@ func parseConfigSchema(raw json.RawMessage) (*parsedSchema, error) {
 	if err := json.Unmarshal(raw, &s); err != nil {
 		return nil, fmt.Errorf("parsing config schema: %w", err)
 	}
+	s.raw = raw
 	return &s, nil
 }
 
@@ -45,6 +99,10 @@ func parseConfigSchema(raw json.RawMessage) (*parsedSchema, error) {
 // determined by the command path (e.g., "cli aws ec2 describe_security_groups"
 // implies service=ec2 and operation=describe_security_groups).
 //
+// Polymorphic schemas get the union of their variants' flags. When the
+// oneOf/anyOf variants share a discriminator field, it is set with a
+// --variant selector rather than its own flag.
+//
 // defaults: optional map of flag defaults (e.g., from config file).
 func addFlagsForOperation(cmd *cobra.Command, schema *parsedSchema, inputFields []string, defaults map[string]string) {
 	if schema == nil {
@@ -58,12 +116,15 @@ func addFlagsForOperation(cmd *cobra.Command, schema *parsedSchema, inputFields
 	}
 
 	// Track which fields are required
-	requiredSet := make(map[string]bool, len(schema.Required))
-	for _, r := range schema.Required {
+	required := schema.requiredFields()
+	requiredSet := make(map[string]bool, len(required))
+	for _, r := range required {
 		requiredSet[r] = true
 	}
 
-	for name, prop := range schema.Properties {
+	props := schema.flagProperties()
+	discriminator, variants := schema.discriminator()
+	for name, prop := range props {
 		// Skip service/operation - determined by command path
 		if name == "service" || name == "operation" {
 			continue
@@ -74,108 +135,490 @@ func addFlagsForOperation(cmd *cobra.Command, schema *parsedSchema, inputFields
 			continue
 		}
 
+		if name == discriminator {
+			addVariantFlag(cmd, name, prop, variants, requiredSet[name], defaults, props)
+			continue
+		}
+
 		// Convert snake_case to kebab-case for CLI flags
 		flagName := strings.ReplaceAll(name, "_", "-")
+		addPropertyFlags(cmd, flagName, prop, requiredSet[name], defaults)
+	}
 
-		// Check for user-defined default
-		userDefault, hasUserDefault := defaults[flagName]
+	for _, group := range schema.MutuallyExclusive {
+		if flags := groupFlags(cmd, group); len(flags) > 1 {
+			cmd.MarkFlagsMutuallyExclusive(flags...)
+		}
+	}
+	for _, group := range schema.RequiredTogether {
+		if flags := groupFlags(cmd, group); len(flags) > 1 {
+			cmd.MarkFlagsRequiredTogether(flags...)
+		}
+	}
+}
 
-		switch prop.Type {
-		case "string":
-			defaultVal := ""
-			if hasUserDefault {
-				defaultVal = userDefault
-			} else if prop.Default != nil {
-				defaultVal = fmt.Sprintf("%v", prop.Default)
+// groupFlags returns the flags for a group of fields, dropping fields this
+// operation has no flag for so a group spanning operations still applies
+// to the fields each one takes.
+func groupFlags(cmd *cobra.Command, fields []string) []string {
+	var flags []string
+	for _, field := range fields {
+		flagName := strings.ReplaceAll(field, "_", "-")
+		if cmd.Flags().Lookup(flagName) != nil {
+			flags = append(flags, flagName)
+		}
+	}
+	return flags
+}
+
+// addPropertyFlags adds the flag for one schema property. An object that
+// declares its own properties gets a dotted flag per nested property, such
+// as --tls.min-version, instead of a single key=value flag. Nested flags
+// are required only when the object itself is.
+func addPropertyFlags(cmd *cobra.Command, flagName string, prop schemaProperty, required bool, defaults map[string]string) {
+	if prop.Type == "object" && len(prop.Properties) > 0 {
+		nestedRequired := make(map[string]bool, len(prop.Required))
+		for _, r := range prop.Required {
+			nestedRequired[r] = true
+		}
+		for name, nested := range prop.Properties {
+			addPropertyFlags(cmd, flagName+"."+strings.ReplaceAll(name, "_", "-"), nested, required && nestedRequired[name], defaults)
+		}
+		return
+	}
+
+	// Check for user-defined default; a set x-env variable overrides it
+	userDefault, hasUserDefault := defaults[flagName]
+	source := ""
+	if hasUserDefault {
+		source = defaultFromConfig
+	}
+	if prop.Env != "" {
+		if v := os.Getenv(prop.Env); v != "" {
+			userDefault, hasUserDefault, source = v, true, defaultFromEnv
+		}
+		prop.Description = strings.TrimSpace(prop.Description + " (env " + prop.Env + ")")
+	}
+
+	switch prop.Type {
+	case "string":
+		defaultVal := ""
+		if hasUserDefault {
+			defaultVal = userDefault
+		} else if prop.Default != nil {
+			defaultVal = fmt.Sprintf("%v", prop.Default)
+		}
+		cmd.Flags().String(flagName, defaultVal, prop.Description)
+
+		// Register completion for enum values
+		if len(prop.Enum) > 0 {
+			enumStrs := make([]string, len(prop.Enum))
+			for i, e := range prop.Enum {
+				enumStrs[i] = fmt.Sprintf("%v", e)
+			}
+			_ = cmd.Flags().SetAnnotation(flagName, enumAnnotation, enumStrs)
+			_ = cmd.RegisterFlagCompletionFunc(flagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return enumStrs, cobra.ShellCompDirectiveNoFileComp
+			})
+		} else if prop.Format != "" {
+			registerFormatCompletion(cmd, flagName, prop.Format)
+		}
+
+	case "integer":
+		defaultVal := 0
+		if hasUserDefault {
+			_, _ = fmt.Sscanf(userDefault, "%d", &defaultVal)
+		} else if prop.Default != nil {
+			if f, ok := prop.Default.(float64); ok {
+				defaultVal = int(f)
+			}
+		}
+		cmd.Flags().Int(flagName, defaultVal, prop.Description)
+
+	case "number":
+		defaultVal := 0.0
+		if hasUserDefault {
+			defaultVal, _ = strconv.ParseFloat(userDefault, 64)
+		} else if prop.Default != nil {
+			if f, ok := prop.Default.(float64); ok {
+				defaultVal = f
 			}
-			cmd.Flags().String(flagName, defaultVal, prop.Description)
-
-			// Register completion for enum values
-			if len(prop.Enum) > 0 {
-				enumStrs := make([]string, len(prop.Enum))
-				for i, e := range prop.Enum {
-					enumStrs[i] = fmt.Sprintf("%v", e)
-				}
-				_ = cmd.RegisterFlagCompletionFunc(flagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-					return enumStrs, cobra.ShellCompDirectiveNoFileComp
-				})
+		}
+		cmd.Flags().Float64(flagName, defaultVal, prop.Description)
+
+	case "boolean":
+		defaultVal := false
+		if hasUserDefault {
+			defaultVal = strings.ToLower(userDefault) == "true"
+		} else if prop.Default != nil {
+			if b, ok := prop.Default.(bool); ok {
+				defaultVal = b
 			}
+		}
+		cmd.Flags().Bool(flagName, defaultVal, prop.Description)
 
-		case "integer":
-			defaultVal := 0
-			if hasUserDefault {
-				_, _ = fmt.Sscanf(userDefault, "%d", &defaultVal)
-			} else if prop.Default != nil {
-				if f, ok := prop.Default.(float64); ok {
-					defaultVal = int(f)
-				}
+	case "array":
+		// Note: User defaults for arrays/objects not currently supported via config map[string]string
+		if prop.Items != nil && prop.Items.Type == "object" {
+			addObjectArrayFlags(cmd, flagName, prop, required)
+			return
+		}
+		cmd.Flags().StringSlice(flagName, nil, prop.Description)
+
+	case "object":
+		cmd.Flags().StringToString(flagName, nil, prop.Description)
+	}
+
+	// Help shows that a sensitive flag has a default, not what it is
+	if f := cmd.Flags().Lookup(flagName); f != nil && prop.Sensitive && (hasUserDefault || prop.Default != nil) {
+		f.DefValue = output.Masked
+	}
+
+	// A default from config or the environment is sent even when the flag
+	// is not given, and satisfies a required flag
+	scalar := prop.Type == "string" || prop.Type == "integer" || prop.Type == "number" || prop.Type == "boolean"
+	if source != "" && scalar {
+		_ = cmd.Flags().SetAnnotation(flagName, defaultSourceAnnotation, []string{source})
+		return
+	}
+
+	// Mark required flags
+	if required {
+		_ = cmd.MarkFlagRequired(flagName)
+	}
+}
+
+// defaultSourceAnnotation records where a flag's default came from when it
+// was not the schema. Such defaults are part of the config.
+const defaultSourceAnnotation = "tack_default_source"
+
+const (
+	defaultFromConfig = "config"
+	defaultFromEnv    = "env"
+)
+
+// flagInConfig reports whether a flag's value belongs in the plugin config:
+// it was given on the command line, or defaulted from config or the
+// environment. Precedence is flag, then env, then plugin_defaults, then the
+// schema default, which is left to the plugin.
+func flagInConfig(f *pflag.Flag) bool {
+	return f.Changed || len(f.Annotations[defaultSourceAnnotation]) > 0
+}
+
+// addVariantFlag adds the selector for a discriminator field. It is named
+// --variant unless the schema has its own "variant" property, in which case
+// the discriminator keeps its field's flag name.
+func addVariantFlag(cmd *cobra.Command, field string, prop schemaProperty, variants []string, required bool, defaults map[string]string, props map[string]schemaProperty) {
+	flagName := variantFlag
+	if _, taken := props[variantFlag]; taken && field != variantFlag {
+		flagName = strings.ReplaceAll(field, "_", "-")
+	}
+
+	usage := prop.Description
+	if usage == "" {
+		usage = "Config variant"
+	}
+	usage += " (" + strings.Join(variants, ", ") + ")"
+
+	def, hasDefault := defaults[flagName]
+	cmd.Flags().String(flagName, def, usage)
+	_ = cmd.Flags().SetAnnotation(flagName, variantFieldAnnotation, []string{field})
+	_ = cmd.Flags().SetAnnotation(flagName, enumAnnotation, variants)
+	_ = cmd.RegisterFlagCompletionFunc(flagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return variants, cobra.ShellCompDirectiveNoFileComp
+	})
+	if hasDefault {
+		_ = cmd.Flags().SetAnnotation(flagName, defaultSourceAnnotation, []string{defaultFromConfig})
+	} else if required {
+		_ = cmd.MarkFlagRequired(flagName)
+	}
+}
+
+// validateFlags checks every flag bound for the config (see flagInConfig)
+// against the enum, pattern, format, minimum, and maximum of its schema
+// property, so bad values are reported before the plugin runs. Array flags
+// are checked item by item against the items schema, and dependentRequired
+// fields are enforced.
+func validateFlags(cmd *cobra.Command, schema *parsedSchema) error {
+	if schema == nil {
+		return nil
+	}
+	var errs []error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if !flagInConfig(f) {
+			return
+		}
+		if _, ok := f.Annotations[variantFieldAnnotation]; ok {
+			val, _ := cmd.Flags().GetString(f.Name)
+			errs = append(errs, validateValue(f.Name, schemaProperty{Enum: toAny(f.Annotations[enumAnnotation])}, val))
+			return
+		}
+		prop, ok := schema.property(f.Name)
+		if !ok {
+			return
+		}
+		switch f.Value.Type() {
+		case "string":
+			val, _ := cmd.Flags().GetString(f.Name)
+			errs = append(errs, validateValue(f.Name, prop, val))
+		case "int":
+			val, _ := cmd.Flags().GetInt(f.Name)
+			errs = append(errs, validateValue(f.Name, prop, val))
+		case "float64":
+			val, _ := cmd.Flags().GetFloat64(f.Name)
+			errs = append(errs, validateValue(f.Name, prop, val))
+		case "stringSlice":
+			if prop.Items == nil {
+				return
 			}
-			cmd.Flags().Int(flagName, defaultVal, prop.Description)
-
-		case "boolean":
-			defaultVal := false
-			if hasUserDefault {
-				defaultVal = strings.ToLower(userDefault) == "true"
-			} else if prop.Default != nil {
-				if b, ok := prop.Default.(bool); ok {
-					defaultVal = b
-				}
+			vals, _ := cmd.Flags().GetStringSlice(f.Name)
+			for _, v := range vals {
+				errs = append(errs, validateValue(f.Name, *prop.Items, v))
 			}
-			cmd.Flags().Bool(flagName, defaultVal, prop.Description)
+		}
+	})
+	return errors.Join(append(errs, validateDependencies(cmd, schema.DependentRequired))...)
+}
+
+// validateDependencies enforces dependentRequired: setting a field's flag
+// requires the flags of the fields it depends on. Cobra's flag groups are
+// symmetric, so this one-way rule is checked here instead.
+func validateDependencies(cmd *cobra.Command, dependencies map[string][]string) error {
+	fields := make([]string, 0, len(dependencies))
+	for field := range dependencies {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var errs []error
+	for _, field := range fields {
+		flagName := strings.ReplaceAll(field, "_", "-")
+		if !cmd.Flags().Changed(flagName) {
+			continue
+		}
+		var missing []string
+		for _, dep := range groupFlags(cmd, dependencies[field]) {
+			if !cmd.Flags().Changed(dep) {
+				missing = append(missing, "--"+dep)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("--%s requires %s", flagName, strings.Join(missing, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateValue checks one flag value against a schema property.
+func validateValue(flagName string, prop schemaProperty, value any) error {
+	if len(prop.Enum) > 0 {
+		allowed := make([]string, len(prop.Enum))
+		match := false
+		for i, e := range prop.Enum {
+			allowed[i] = fmt.Sprintf("%v", e)
+			match = match || allowed[i] == fmt.Sprintf("%v", value)
+		}
+		if !match {
+			return fmt.Errorf("invalid value %q for --%s: must be one of %s", fmt.Sprint(value), flagName, strings.Join(allowed, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if expected, ok := checkFormat(prop.Format, v); !ok {
+			return fmt.Errorf("invalid value %q for --%s: must be %s", v, flagName, expected)
+		}
+		if prop.Pattern == "" {
+			return nil
+		}
+		// A pattern Go cannot compile is the plugin's problem, not the user's
+		re, err := regexp.Compile(prop.Pattern)
+		if err == nil && !re.MatchString(v) {
+			return fmt.Errorf("invalid value %q for --%s: must match %s", v, flagName, prop.Pattern)
+		}
+	case int:
+		return validateNumber(flagName, prop, float64(v))
+	case float64:
+		return validateNumber(flagName, prop, v)
+	}
+	return nil
+}
+
+// validateNumber checks a numeric flag value against minimum, maximum, and
+// multipleOf.
+func validateNumber(flagName string, prop schemaProperty, v float64) error {
+	if prop.Minimum != nil && v < *prop.Minimum {
+		return fmt.Errorf("invalid value %v for --%s: must be at least %v", v, flagName, *prop.Minimum)
+	}
+	if prop.Maximum != nil && v > *prop.Maximum {
+		return fmt.Errorf("invalid value %v for --%s: must be at most %v", v, flagName, *prop.Maximum)
+	}
+	if m := prop.MultipleOf; m != nil && *m > 0 {
+		// Allow for binary rounding, e.g. 0.3 as a multiple of 0.1
+		q := v / *m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			return fmt.Errorf("invalid value %v for --%s: must be a multiple of %v", v, flagName, *m)
+		}
+	}
+	return nil
+}
 
-		case "array":
-			// Note: User defaults for arrays/objects not currently supported via config map[string]string
-			cmd.Flags().StringSlice(flagName, nil, prop.Description)
+func toAny(strs []string) []any {
+	out := make([]any, len(strs))
+	for i, s := range strs {
+		out[i] = s
+	}
+	return out
+}
 
-		case "object":
-			cmd.Flags().StringToString(flagName, nil, prop.Description)
+// flagToField converts a kebab-case flag name to its snake_case config field.
+func flagToField(flagName string) string {
+	return strings.ReplaceAll(flagName, "-", "_")
+}
+
+// setField stores value in config under the field a flag sets. A dotted
+// flag name sets a field of a nested object, creating it as needed.
+func setField(config map[string]any, flagName string, value any) {
+	parts := strings.Split(flagName, ".")
+	for _, part := range parts[:len(parts)-1] {
+		field := flagToField(part)
+		nested, ok := config[field].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			config[field] = nested
 		}
+		config = nested
+	}
+	config[flagToField(parts[len(parts)-1])] = value
+}
+
+// setFlag is the repeatable flag that sets arbitrary config fields.
+const setFlag = "set"
 
-		// Mark required flags
-		if requiredSet[name] {
-			_ = cmd.MarkFlagRequired(flagName)
+// addSetFlag adds --set to an operation command, unless the plugin has a
+// "set" field of its own.
+func addSetFlag(cmd *cobra.Command) {
+	if cmd.Flags().Lookup(setFlag) != nil {
+		return
+	}
+	cmd.Flags().StringArray(setFlag, nil, "Set a config field as path=value; dotted paths nest and values are parsed as JSON when they can be (repeatable)")
+}
+
+// applySetValues applies path=value assignments to config, overriding
+// fields set by generated flags. A value that parses as JSON (a number,
+// boolean, null, quoted string, array, or object) is used as parsed;
+// anything else is taken as a string.
+func applySetValues(config map[string]any, assignments []string) error {
+	for _, a := range assignments {
+		path, raw, ok := strings.Cut(a, "=")
+		if !ok || path == "" {
+			return fmt.Errorf("invalid --%s %q: expected path=value", setFlag, a)
+		}
+		if path == "service" || path == "operation" {
+			return fmt.Errorf("invalid --%s %q: %s is set by the command path", setFlag, a, path)
 		}
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		setField(config, path, value)
 	}
+	return nil
+}
+
+// operationConfig builds an operation's config from its flags, including
+// array-of-objects flags, applies any --set assignments on top, and expands
+// templates in the values when --expand-templates is on.
+func operationConfig(cmd *cobra.Command, schema *parsedSchema, serviceName, operationName string) (map[string]any, error) {
+	config := buildConfigFromFlags(cmd, serviceName, operationName)
+	if err := applyObjectArrays(cmd, schema, config); err != nil {
+		return nil, err
+	}
+	if f := cmd.Flags().Lookup(setFlag); f != nil && f.Value.Type() == "stringArray" {
+		assignments, _ := cmd.Flags().GetStringArray(setFlag)
+		if err := applySetValues(config, assignments); err != nil {
+			return nil, err
+		}
+	}
+	if err := expandConfigTemplates(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// coerceDefault converts a string default from config into the JSON type
+// declared by the schema property. Unparseable values are returned as-is
+// so the plugin can report them.
+func coerceDefault(prop schemaProperty, value string) any {
+	switch prop.Type {
+	case "integer":
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
 }
 
 // buildConfigFromFlags constructs the plugin config map from cobra flags.
 // It sets "service" and "operation" from the command path, then adds all
 // user-provided flag values (converting kebab-case back to snake_case).
+// Dotted flags assemble into nested objects. Flags inherited from parent
+// commands, such as --output or --remote, belong to the CLI and are left
+// out.
 func buildConfigFromFlags(cmd *cobra.Command, serviceName, operationName string) map[string]any {
 	config := map[string]any{
 		"service":   serviceName,
 		"operation": operationName,
 	}
 
-	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		if !f.Changed {
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if !flagInConfig(f) {
 			return
 		}
 
-		// Convert kebab-case flag name back to snake_case for JSON
-		jsonName := strings.ReplaceAll(f.Name, "-", "_")
+		// Fan-out flags select targets rather than set config
+		if _, ok := f.Annotations[fanOutAnnotation]; ok {
+			return
+		}
 
-		// Skip internal flags
-		if jsonName == "output" || jsonName == "plugin_path" || jsonName == "quiet" {
+		// Array-of-objects flags are parsed by applyObjectArrays
+		if _, ok := f.Annotations[objectArrayAnnotation]; ok {
+			return
+		}
+
+		if field, ok := f.Annotations[variantFieldAnnotation]; ok && len(field) > 0 {
+			val, _ := cmd.Flags().GetString(f.Name)
+			config[field[0]] = val
 			return
 		}
 
 		switch f.Value.Type() {
 		case "string":
 			val, _ := cmd.Flags().GetString(f.Name)
-			config[jsonName] = val
+			setField(config, f.Name, val)
 		case "int":
 			val, _ := cmd.Flags().GetInt(f.Name)
-			config[jsonName] = val
+			setField(config, f.Name, val)
+		case "float64":
+			val, _ := cmd.Flags().GetFloat64(f.Name)
+			setField(config, f.Name, val)
 		case "bool":
 			val, _ := cmd.Flags().GetBool(f.Name)
-			config[jsonName] = val
+			setField(config, f.Name, val)
 		case "stringSlice":
 			val, _ := cmd.Flags().GetStringSlice(f.Name)
-			config[jsonName] = val
+			setField(config, f.Name, val)
 		case "stringToString":
 			val, _ := cmd.Flags().GetStringToString(f.Name)
-			config[jsonName] = val
+			setField(config, f.Name, val)
 		}
 	})
 
@@ -2,10 +2,14 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
 )
 
 func TestAddFlagsForOperation(t *testing.T) {
@@ -132,3 +136,413 @@ func TestBuildConfigFromFlags(t *testing.T) {
 		t.Errorf("expected record_type='MX', got %v", config["record_type"])
 	}
 }
+
+func TestBuildConfigFromFlags_LeavesOutGlobalFlags(t *testing.T) {
+	root := NewRootCommand(config.DefaultConfig(), nil, "")
+	op := &cobra.Command{Use: "resolve"}
+	op.Flags().String("hostname", "", "")
+	root.AddCommand(op)
+
+	err := op.ParseFlags([]string{
+		"--hostname", "example.com",
+		"--output", "json", "--quiet", "--verbose", "--trust-plugins",
+		"--insecure-skip-tls-verify", "registry.local", "--egress-report", "--stats",
+		"--show-secrets", "--expand-templates", "--no-cache", "--log-file", "tack.log",
+		"--read-only", "--remote", "me@host",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := buildConfigFromFlags(op, "dns", "resolve")
+	if len(config) != 3 || config["hostname"] != "example.com" {
+		t.Errorf("expected only the operation's flags in the config, got %v", config)
+	}
+}
+
+func TestValidateFlags(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"record_type": {"type": "string", "enum": ["A", "AAAA", "MX"]},
+			"hostname": {"type": "string", "pattern": "^[a-z0-9.-]+$"},
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535, "multipleOf": 2},
+			"ratio": {"type": "number", "minimum": 0, "maximum": 1, "multipleOf": 0.1},
+			"regions": {"type": "array", "items": {"type": "string", "enum": ["us-east-1", "eu-west-1"]}}
+		}
+	}`
+	schema, err := parseConfigSchema(json.RawMessage(schemaJSON))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"--record-type", "MX", "--hostname", "example.com", "--port", "444", "--ratio", "0.3", "--regions", "us-east-1"}, ""},
+		{nil, ""},
+		{[]string{"--record-type", "TXT"}, `invalid value "TXT" for --record-type: must be one of A, AAAA, MX`},
+		{[]string{"--hostname", "Example.com"}, `must match ^[a-z0-9.-]+$`},
+		{[]string{"--port", "0"}, "must be at least 1"},
+		{[]string{"--port", "70000"}, "must be at most 65535"},
+		{[]string{"--port", "443"}, "must be a multiple of 2"},
+		{[]string{"--ratio", "1.5"}, "invalid value 1.5 for --ratio: must be at most 1"},
+		{[]string{"--ratio", "0.25"}, "must be a multiple of 0.1"},
+		{[]string{"--regions", "us-east-1,ap-south-1"}, `invalid value "ap-south-1" for --regions`},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "test"}
+		addFlagsForOperation(cmd, schema, nil, nil)
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("ParseFlags(%v): %v", tt.args, err)
+		}
+		err := validateFlags(cmd, schema)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("validateFlags(%v) = %v, want nil", tt.args, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("validateFlags(%v) = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNumberFlags(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {"threshold": {"type": "number", "default": 0.5}}
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, nil)
+	f := cmd.Flags().Lookup("threshold")
+	if f == nil || f.Value.Type() != "float64" || f.DefValue != "0.5" {
+		t.Fatalf("expected a float64 --threshold flag defaulting to 0.5, got %+v", f)
+	}
+	if err := cmd.ParseFlags([]string{"--threshold", "0.75"}); err != nil {
+		t.Fatal(err)
+	}
+	config := buildConfigFromFlags(cmd, "svc", "op")
+	if config["threshold"] != 0.75 {
+		t.Errorf("threshold = %#v, want 0.75", config["threshold"])
+	}
+
+	cmd = &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, map[string]string{"threshold": "0.9"})
+	if f := cmd.Flags().Lookup("threshold"); f.DefValue != "0.9" {
+		t.Errorf("expected the config default 0.9, got %q", f.DefValue)
+	}
+	if got := coerceDefault(schema.Properties["threshold"], "0.9"); got != 0.9 {
+		t.Errorf("coerceDefault = %#v, want 0.9", got)
+	}
+}
+
+func TestNestedObjectFlags(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {
+			"url": {"type": "string"},
+			"tls": {
+				"type": "object",
+				"properties": {
+					"min_version": {"type": "string", "enum": ["1.2", "1.3"]},
+					"verify": {"type": "boolean", "default": true}
+				},
+				"required": ["min_version"]
+			},
+			"headers": {"type": "object"}
+		},
+		"required": ["tls"]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, map[string]string{"tls.verify": "false"})
+	for _, name := range []string{"url", "tls.min-version", "tls.verify", "headers"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s", name)
+		}
+	}
+	if cmd.Flags().Lookup("tls") != nil {
+		t.Error("expected no --tls flag for an object with properties")
+	}
+	if f := cmd.Flags().Lookup("tls.verify"); f.DefValue != "false" {
+		t.Errorf("expected the config default for tls.verify, got %q", f.DefValue)
+	}
+	if ann := cmd.Flags().Lookup("tls.min-version").Annotations[cobra.BashCompOneRequiredFlag]; len(ann) == 0 {
+		t.Error("expected --tls.min-version to be required")
+	}
+
+	if err := cmd.ParseFlags([]string{"--tls.min-version", "1.3", "--tls.verify", "--headers", "a=b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateFlags(cmd, schema); err != nil {
+		t.Errorf("validateFlags: %v", err)
+	}
+	config := buildConfigFromFlags(cmd, "svc", "op")
+	tls, ok := config["tls"].(map[string]any)
+	if !ok || tls["min_version"] != "1.3" || tls["verify"] != true {
+		t.Errorf("expected nested tls config, got %#v", config["tls"])
+	}
+	if headers, ok := config["headers"].(map[string]string); !ok || headers["a"] != "b" {
+		t.Errorf("expected key=value headers, got %#v", config["headers"])
+	}
+
+	_ = cmd.ParseFlags([]string{"--tls.min-version", "1.0"})
+	if err := validateFlags(cmd, schema); err == nil || !strings.Contains(err.Error(), "--tls.min-version") {
+		t.Errorf("expected an enum error for --tls.min-version, got %v", err)
+	}
+}
+
+func TestPolymorphicSchemaFlags(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {"timeout_ms": {"type": "integer"}},
+		"oneOf": [
+			{
+				"properties": {"kind": {"const": "http"}, "url": {"type": "string"}},
+				"required": ["kind", "url"]
+			},
+			{
+				"properties": {"kind": {"const": "tcp"}, "host": {"type": "string"}, "port": {"type": "integer"}},
+				"required": ["kind", "host", "port"]
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		addFlagsForOperation(cmd, schema, nil, nil)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	cmd := newCmd()
+	for _, name := range []string{"timeout-ms", "url", "host", "port", "variant"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s", name)
+		}
+	}
+	if cmd.Flags().Lookup("kind") != nil {
+		t.Error("expected the discriminator to be set through --variant")
+	}
+	if ann := cmd.Flags().Lookup("url").Annotations[cobra.BashCompOneRequiredFlag]; len(ann) != 0 {
+		t.Error("expected variant-only fields not to be required flags")
+	}
+
+	cmd = newCmd("--variant", "tcp", "--host", "db", "--port", "5432")
+	if err := validateFlags(cmd, schema); err != nil {
+		t.Errorf("validateFlags: %v", err)
+	}
+	config := buildConfigFromFlags(cmd, "svc", "op")
+	if config["kind"] != "tcp" {
+		t.Errorf("expected --variant to set kind, got %#v", config)
+	}
+	if err := schema.validateConfig(config); err != nil {
+		t.Errorf("validateConfig: %v", err)
+	}
+
+	cmd = newCmd("--variant", "udp")
+	if err := validateFlags(cmd, schema); err == nil || !strings.Contains(err.Error(), "must be one of http, tcp") {
+		t.Errorf("expected an unknown variant error, got %v", err)
+	}
+
+	cmd = newCmd("--variant", "http", "--host", "db")
+	if err := schema.validateConfig(buildConfigFromFlags(cmd, "svc", "op")); err == nil || !strings.Contains(err.Error(), "url") {
+		t.Errorf("expected the http variant to require url, got %v", err)
+	}
+}
+
+func TestVariantFlagNameCollision(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {"variant": {"type": "string"}},
+		"anyOf": [
+			{"properties": {"mode": {"enum": ["fast"]}}},
+			{"properties": {"mode": {"enum": ["safe"]}}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, nil)
+	if err := cmd.ParseFlags([]string{"--mode", "safe", "--variant", "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	config := buildConfigFromFlags(cmd, "svc", "op")
+	if config["mode"] != "safe" || config["variant"] != "blue" {
+		t.Errorf("expected --mode to select the variant and --variant to stay a field, got %#v", config)
+	}
+}
+
+func TestFlagGroupHints(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {
+			"token": {"type": "string"},
+			"username": {"type": "string"},
+			"password": {"type": "string"},
+			"client_cert": {"type": "string"},
+			"client_key": {"type": "string"},
+			"ca_file": {"type": "string"}
+		},
+		"x-mutually-exclusive": [["token", "username"], ["token", "not_an_input"]],
+		"x-required-together": [["username", "password"]],
+		"dependentRequired": {"client_cert": ["client_key"]}
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"valid", []string{"--username", "u", "--password", "p", "--client-key", "k"}, ""},
+		{"exclusive", []string{"--token", "t", "--username", "u", "--password", "p"}, "none of the others can be"},
+		{"together", []string{"--username", "u"}, "they must all be set"},
+		{"dependent", []string{"--client-cert", "c"}, "--client-cert requires --client-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			addFlagsForOperation(cmd, schema, nil, nil)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := errors.Join(cmd.ValidateFlagGroups(), validateFlags(cmd, schema))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{"properties": {"hostname": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, nil)
+	addSetFlag(cmd)
+
+	args := []string{
+		"--hostname", "a.example.com",
+		"--set", "hostname=b.example.com",
+		"--set", "tls.verify=false",
+		"--set", "retries=3",
+		"--set", `tags=["x","y"]`,
+		"--set", "note=a=b",
+	}
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	config, err := operationConfig(cmd, schema, "svc", "op")
+	if err != nil {
+		t.Fatalf("operationConfig: %v", err)
+	}
+	if config["hostname"] != "b.example.com" {
+		t.Errorf("expected --set to override --hostname, got %v", config["hostname"])
+	}
+	if tls, ok := config["tls"].(map[string]any); !ok || tls["verify"] != false {
+		t.Errorf("expected nested boolean, got %#v", config["tls"])
+	}
+	if config["retries"] != float64(3) || config["note"] != "a=b" {
+		t.Errorf("expected JSON number and raw string, got %#v", config)
+	}
+	if tags, ok := config["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("expected JSON array, got %#v", config["tags"])
+	}
+
+	for _, bad := range []string{"novalue", "=1", "service=other"} {
+		if err := applySetValues(map[string]any{}, []string{bad}); err == nil {
+			t.Errorf("expected an error for --set %q", bad)
+		}
+	}
+}
+
+func TestEnvBoundFlags(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {
+			"region": {"type": "string", "x-env": "TACK_TEST_REGION", "default": "us-east-1"},
+			"profile": {"type": "string", "x-env": "TACK_TEST_PROFILE"},
+			"retries": {"type": "integer", "default": 2}
+		},
+		"required": ["region"]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+	defaults := map[string]string{"region": "eu-west-1", "profile": "ops"}
+
+	build := func(args ...string) map[string]any {
+		cmd := &cobra.Command{Use: "test"}
+		addFlagsForOperation(cmd, schema, nil, defaults)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			t.Fatalf("ValidateRequiredFlags: %v", err)
+		}
+		return buildConfigFromFlags(cmd, "svc", "op")
+	}
+
+	t.Setenv("TACK_TEST_REGION", "ap-south-1")
+	t.Setenv("TACK_TEST_PROFILE", "")
+	config := build()
+	if config["region"] != "ap-south-1" {
+		t.Errorf("expected env to beat plugin_defaults, got %v", config["region"])
+	}
+	if config["profile"] != "ops" {
+		t.Errorf("expected plugin_defaults when the env var is empty, got %v", config["profile"])
+	}
+	if _, ok := config["retries"]; ok {
+		t.Error("expected the schema default to be left to the plugin")
+	}
+
+	if config := build("--region", "us-west-2"); config["region"] != "us-west-2" {
+		t.Errorf("expected the flag to beat env, got %v", config["region"])
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, nil)
+	if usage := cmd.Flags().Lookup("region").Usage; !strings.Contains(usage, "env TACK_TEST_REGION") {
+		t.Errorf("expected the env var in the usage, got %q", usage)
+	}
+}
+
+func TestSensitiveFlagDefault(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {
+			"api_key": {"type": "string", "x-sensitive": true},
+			"verbose": {"type": "boolean", "x-sensitive": true}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	addFlagsForOperation(cmd, schema, nil, map[string]string{"api-key": "hunter2"})
+	f := cmd.Flags().Lookup("api-key")
+	if f.DefValue != output.Masked || f.Value.String() != "hunter2" {
+		t.Errorf("expected a masked default that still applies, got DefValue %q, value %q", f.DefValue, f.Value.String())
+	}
+	if f := cmd.Flags().Lookup("verbose"); f.DefValue != "false" {
+		t.Errorf("expected a flag without a default to be left alone, got %q", f.DefValue)
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bufio"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostnameLabelRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+	isoDurationRe   = regexp.MustCompile(`^P(\d+W|(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?)$`)
+	uuidRe          = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// checkFormat reports whether value satisfies a JSON Schema string format,
+// returning a description of what was expected when it does not. Formats
+// this CLI does not know are left to the plugin.
+func checkFormat(format, value string) (string, bool) {
+	switch format {
+	case "hostname":
+		return "a valid hostname", validHostname(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return "an IPv4 address", ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return "an IPv6 address", ip != nil && strings.Contains(value, ":")
+	case "uri":
+		u, err := url.Parse(value)
+		return "an absolute URI", err == nil && u.Scheme != ""
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return "an email address", err == nil && addr.Address == value
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return "an RFC 3339 date-time", err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return "a date (YYYY-MM-DD)", err == nil
+	case "duration":
+		// ISO 8601 per the spec, plus Go-style durations such as 30s
+		_, err := time.ParseDuration(value)
+		return "a duration such as 30s or PT30S", err == nil || (value != "P" && !strings.HasSuffix(value, "T") && isoDurationRe.MatchString(value))
+	case "uuid":
+		return "a UUID", uuidRe.MatchString(value)
+	}
+	return "", true
+}
+
+// validHostname reports whether s is an RFC 1123 hostname.
+func validHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !hostnameLabelRe.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// registerFormatCompletion offers completions suited to a string format:
+// known hosts for hostnames and addresses, sample values for durations, and
+// schemes for URIs. Format flags never complete to file names.
+func registerFormatCompletion(cmd *cobra.Command, flagName, format string) {
+	var complete func() ([]string, cobra.ShellCompDirective)
+	switch format {
+	case "hostname", "ipv4", "ipv6":
+		complete = func() ([]string, cobra.ShellCompDirective) {
+			return knownHosts("/etc/hosts", format), cobra.ShellCompDirectiveNoFileComp
+		}
+	case "duration":
+		complete = func() ([]string, cobra.ShellCompDirective) {
+			return []string{"30s", "1m", "5m", "1h"}, cobra.ShellCompDirectiveNoFileComp
+		}
+	case "uri":
+		complete = func() ([]string, cobra.ShellCompDirective) {
+			return []string{"https://", "http://"}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		}
+	default:
+		complete = func() ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	_ = cmd.RegisterFlagCompletionFunc(flagName, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return complete()
+	})
+}
+
+// knownHosts reads a hosts file and returns its names, or its addresses of
+// the given IP family.
+func knownHosts(path, format string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if format == "hostname" {
+			for _, name := range fields[1:] {
+				seen[name] = true
+			}
+			continue
+		}
+		if _, ok := checkFormat(format, fields[0]); ok {
+			seen[fields[0]] = true
+		}
+	}
+
+	hosts := make([]string, 0, len(seen))
+	for h := range seen {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		format, value string
+		ok            bool
+	}{
+		{"hostname", "api.example.com", true},
+		{"hostname", "bad_host.example.com", false},
+		{"hostname", "-leading.example.com", false},
+		{"ipv4", "10.0.0.1", true},
+		{"ipv4", "10.0.0.256", false},
+		{"ipv4", "::1", false},
+		{"ipv6", "2001:db8::1", true},
+		{"ipv6", "10.0.0.1", false},
+		{"uri", "https://example.com/path", true},
+		{"uri", "example.com", false},
+		{"email", "ops@example.com", true},
+		{"email", "ops", false},
+		{"date-time", "2026-01-02T15:04:05Z", true},
+		{"date", "2026-13-01", false},
+		{"duration", "30s", true},
+		{"duration", "PT1H30M", true},
+		{"duration", "P", false},
+		{"duration", "soon", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567", false},
+		{"x-custom", "anything", true},
+	}
+	for _, tt := range tests {
+		if _, ok := checkFormat(tt.format, tt.value); ok != tt.ok {
+			t.Errorf("checkFormat(%q, %q) = %v, want %v", tt.format, tt.value, ok, tt.ok)
+		}
+	}
+
+	prop := schemaProperty{Type: "string", Format: "ipv4"}
+	if err := validateValue("address", prop, "10.0.0.300"); err == nil {
+		t.Error("expected an invalid IPv4 address to fail validation")
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	content := "127.0.0.1 localhost\n::1 localhost ip6-localhost # loopback\n# 10.0.0.9 hidden\n10.0.0.5 db db.internal\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := knownHosts(path, "hostname"), []string{"db", "db.internal", "ip6-localhost", "localhost"}; !slices.Equal(got, want) {
+		t.Errorf("hostnames: got %v, want %v", got, want)
+	}
+	if got, want := knownHosts(path, "ipv4"), []string{"10.0.0.5", "127.0.0.1"}; !slices.Equal(got, want) {
+		t.Errorf("ipv4: got %v, want %v", got, want)
+	}
+}
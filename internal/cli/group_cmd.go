@@ -1,16 +1,23 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
 )
 
 // newGroupCommand creates the "group" management command.
-func newGroupCommand(cfg *config.Config, configPath string) *cobra.Command {
+func newGroupCommand(cfg *config.Config, stack *pluginpkg.PluginStack, configPath string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "group",
 		Short: "Manage plugin groups",
@@ -18,8 +25,14 @@ func newGroupCommand(cfg *config.Config, configPath string) *cobra.Command {
 
 	cmd.AddCommand(
 		newGroupListCommand(cfg),
+		newGroupShowCommand(cfg, stack),
 		newGroupCreateCommand(cfg, configPath),
 		newGroupDeleteCommand(cfg, configPath),
+		newGroupRenameCommand(cfg, configPath),
+		newGroupExportCommand(cfg),
+		newGroupImportCommand(cfg, configPath),
+		newGroupAutoCommand(cfg, stack, configPath),
+		newGroupRunCommand(cfg, stack),
 		newGroupAddCommand(cfg, configPath),
 		newGroupRemoveCommand(cfg, configPath),
 	)
@@ -51,9 +64,160 @@ func newGroupListCommand(cfg *config.Config) *cobra.Command {
 	}
 }
 
+// groupDetail describes a group for "group show".
+type groupDetail struct {
+	Name        string             `json:"name" yaml:"name"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Hidden      bool               `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Plugins     []groupPluginEntry `json:"plugins" yaml:"plugins"`
+}
+
+// groupPluginEntry is one entry of a group: an installed or missing plugin,
+// or a nested group.
+type groupPluginEntry struct {
+	Name        string   `json:"name" yaml:"name"`
+	Status      string   `json:"status" yaml:"status"` // installed, missing, or group
+	Hidden      bool     `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Version     string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Source      string   `json:"source,omitempty" yaml:"source,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Operations  []string `json:"operations,omitempty" yaml:"operations,omitempty"`
+}
+
+// newGroupShowCommand creates the "group show" command.
+func newGroupShowCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	return &cobra.Command{
+		Use:               "show <name>",
+		ValidArgsFunction: completeGroupNames(cfg),
+		Short:             "Show a group's plugins and what they provide",
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, ok := cfg.Groups[args[0]]; !ok {
+				return fmt.Errorf("group %q not found", args[0])
+			}
+
+			discovered, err := discoverPlugins(cmd.Context(), cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderGroupDetail(cmd.OutOrStdout(), format, describeGroup(cfg, args[0], discovered))
+		},
+	}
+}
+
+// describeGroup builds the detail view of a group from discovered plugins.
+func describeGroup(cfg *config.Config, name string, discovered []pluginpkg.DiscoveredPlugin) groupDetail {
+	byName := make(map[string]pluginpkg.DiscoveredPlugin, len(discovered))
+	for _, dp := range discovered {
+		byName[dp.Manifest.Name] = dp
+	}
+
+	group := cfg.Groups[name]
+	detail := groupDetail{Name: name, Description: group.Description, Hidden: group.Hidden, Plugins: []groupPluginEntry{}}
+	for _, entry := range group.Plugins {
+		if cfg.IsNestedGroup(entry) {
+			detail.Plugins = append(detail.Plugins, groupPluginEntry{
+				Name:        entry,
+				Status:      "group",
+				Hidden:      cfg.Groups[entry].Hidden,
+				Description: cfg.Groups[entry].Description,
+			})
+			continue
+		}
+
+		dp, ok := byName[entry]
+		if !ok {
+			detail.Plugins = append(detail.Plugins, groupPluginEntry{Name: entry, Status: "missing", Hidden: cfg.IsHiddenPlugin(entry)})
+			continue
+		}
+
+		m := dp.Manifest
+		isMulti := len(m.Services) > 1
+		var ops []string
+		for svcName, svc := range m.Services {
+			for _, op := range svc.Operations {
+				path := op.Name
+				if isMulti {
+					path = svcName + " " + op.Name
+				}
+				if op.Description != "" {
+					path += ": " + op.Description
+				}
+				ops = append(ops, path)
+			}
+		}
+		sort.Strings(ops)
+
+		detail.Plugins = append(detail.Plugins, groupPluginEntry{
+			Name:        entry,
+			Status:      "installed",
+			Hidden:      cfg.IsHiddenPlugin(entry),
+			Version:     m.Version,
+			Source:      dp.Source,
+			Description: m.Description,
+			Operations:  ops,
+		})
+	}
+	return detail
+}
+
+// renderGroupDetail writes a group's detail view in the given format.
+func renderGroupDetail(w io.Writer, format string, detail groupDetail) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(detail)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(detail)
+	case "table", "":
+		_, _ = fmt.Fprintf(w, "Group: %s\n", detail.Name)
+		if detail.Description != "" {
+			_, _ = fmt.Fprintf(w, "Description: %s\n", detail.Description)
+		}
+		if detail.Hidden {
+			_, _ = fmt.Fprintln(w, "Hidden: yes")
+		}
+		_, _ = fmt.Fprintln(w)
+		if len(detail.Plugins) == 0 {
+			_, _ = fmt.Fprintln(w, "No plugins in this group.")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tSTATUS\tVERSION\tSOURCE\tDESCRIPTION")
+		for _, p := range detail.Plugins {
+			status := p.Status
+			if p.Hidden {
+				status += " (hidden)"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.Name, status, p.Version, p.Source, p.Description)
+			for _, op := range p.Operations {
+				_, _ = fmt.Fprintf(tw, "\t\t\t\t- %s\n", op)
+			}
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
 // newGroupCreateCommand creates the "group create" command.
 func newGroupCreateCommand(cfg *config.Config, configPath string) *cobra.Command {
-	var description string
+	var (
+		description string
+		hidden      bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "create <name>",
@@ -70,24 +234,14 @@ func newGroupCreateCommand(cfg *config.Config, configPath string) *cobra.Command
 				return fmt.Errorf("group %q already exists", name)
 			}
 
-			// Check for reserved names (from config package)
-			if name == "" {
-				return fmt.Errorf("group name cannot be empty")
-			}
-			reservedCommands := map[string]bool{
-				"completion": true,
-				"version":    true,
-				"plugin":     true,
-				"group":      true,
-				"help":       true,
-			}
-			if reservedCommands[name] {
-				return fmt.Errorf("group name %q conflicts with built-in command", name)
+			if err := config.ValidateGroupName(name); err != nil {
+				return err
 			}
 
 			cfg.Groups[name] = config.GroupConfig{
 				Description: description,
 				Plugins:     []string{},
+				Hidden:      hidden,
 			}
 
 			if err := cfg.Save(configPath); err != nil {
@@ -101,16 +255,18 @@ func newGroupCreateCommand(cfg *config.Config, configPath string) *cobra.Command
 	}
 
 	cmd.Flags().StringVar(&description, "description", "", "Description for the group")
+	cmd.Flags().BoolVar(&hidden, "hidden", false, "Leave the group out of help output")
 	return cmd
 }
 
 // newGroupDeleteCommand creates the "group delete" command.
 func newGroupDeleteCommand(cfg *config.Config, configPath string) *cobra.Command {
 	return &cobra.Command{
-		Use:     "delete <name>",
-		Aliases: []string{"rm"},
-		Short:   "Delete a plugin group",
-		Args:    cobra.ExactArgs(1),
+		Use:               "delete <name>",
+		ValidArgsFunction: completeGroupNames(cfg),
+		Aliases:           []string{"rm"},
+		Short:             "Delete a plugin group",
+		Args:              cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 
@@ -137,12 +293,265 @@ func newGroupDeleteCommand(cfg *config.Config, configPath string) *cobra.Command
 	}
 }
 
+// newGroupRenameCommand creates the "group rename" command.
+func newGroupRenameCommand(cfg *config.Config, configPath string) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rename <old> <new>",
+		ValidArgsFunction: completeGroupNames(cfg),
+		Short:             "Rename a plugin group",
+		Args:              cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName, newName := args[0], args[1]
+
+			if oldName == "top" {
+				return fmt.Errorf("cannot rename the 'top' group - it controls which plugins appear at the root level")
+			}
+
+			group, exists := cfg.Groups[oldName]
+			if !exists {
+				return fmt.Errorf("group %q not found", oldName)
+			}
+			if _, exists := cfg.Groups[newName]; exists {
+				return fmt.Errorf("group %q already exists", newName)
+			}
+			if err := config.ValidateGroupName(newName); err != nil {
+				return err
+			}
+
+			delete(cfg.Groups, oldName)
+			cfg.Groups[newName] = group
+
+			if err := cfg.Save(configPath); err != nil {
+				delete(cfg.Groups, newName)
+				cfg.Groups[oldName] = group
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Renamed group %q to %q\n", oldName, newName)
+			return nil
+		},
+	}
+}
+
+// newGroupExportCommand creates the "group export" command.
+func newGroupExportCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "export <name>",
+		ValidArgsFunction: completeGroupNames(cfg),
+		Short:             "Print a group and its plugin defaults as YAML",
+		Long: fmt.Sprintf(`Print a group as YAML, including its description, plugins, and the
+plugin defaults that apply to them. The output can be shared or committed
+and loaded elsewhere with "group import".
+
+Example:
+  %s group export network > network.yaml`, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exp, err := cfg.ExportGroup(args[0])
+			if err != nil {
+				return err
+			}
+			enc := yaml.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent(2)
+			if err := enc.Encode(exp); err != nil {
+				return fmt.Errorf("encoding group: %w", err)
+			}
+			return enc.Close()
+		},
+	}
+}
+
+// newGroupImportCommand creates the "group import" command.
+func newGroupImportCommand(cfg *config.Config, configPath string) *cobra.Command {
+	var (
+		name  string
+		force bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Import a group exported with \"group export\"",
+		Long: fmt.Sprintf(`Import a group from a YAML file (or "-" for stdin) written by "group export".
+
+Plugin defaults in the file are merged into the config. Where a default is
+already set to a different value the existing value is kept, unless --force
+is given; --force also replaces an existing group of the same name.
+
+Example:
+  %s group import network.yaml --name net`, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if args[0] == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("reading group file: %w", err)
+			}
+
+			var exp config.GroupExport
+			if err := yaml.Unmarshal(data, &exp); err != nil {
+				return fmt.Errorf("parsing group file: %w", err)
+			}
+			if name != "" {
+				exp.Name = name
+			}
+
+			kept, err := cfg.ImportGroup(exp, force)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Save(configPath); err != nil {
+				return err
+			}
+
+			for _, k := range kept {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: keeping existing default %s (use --force to overwrite)\n", k)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Imported group %q (%d plugins)\n", exp.Name, len(exp.Plugins))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Import under a different group name")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace an existing group and overwrite conflicting defaults")
+	return cmd
+}
+
+// newGroupAutoCommand creates the "group auto" command.
+func newGroupAutoCommand(cfg *config.Config, stack *pluginpkg.PluginStack, configPath string) *cobra.Command {
+	var (
+		fromIndex    bool
+		indexFilter  string
+		only         []string
+		forceRefresh bool
+		dryRun       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "auto --from-index",
+		Short: "Group installed plugins by their index categories",
+		Long: fmt.Sprintf(`Create or update one group per category (network, cloud, security, ...)
+containing the installed plugins that plugin indexes list under it.
+
+Groups created this way are marked auto and kept in sync on later runs.
+Groups you created yourself are never modified, even if their name matches
+a category.
+
+Examples:
+  %s group auto --from-index
+  %s group auto --from-index --category network,security --dry-run`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !fromIndex {
+				return fmt.Errorf("no category source given; use --from-index")
+			}
+			ctx := cmd.Context()
+			out := cmd.OutOrStdout()
+
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+			installed := make(map[string]bool, len(discovered))
+			for _, dp := range discovered {
+				installed[dp.Manifest.Name] = true
+			}
+
+			sources := buildIndexSources(cfg)
+			if indexFilter != "" {
+				sources = filterSources(sources, indexFilter)
+			}
+			entries, err := pluginpkg.SearchAll(ctx, sources, "", forceRefresh)
+			if err != nil {
+				return err
+			}
+
+			categories := categorizePlugins(entries, installed, only)
+			if len(categories) == 0 {
+				_, _ = fmt.Fprintln(out, "No categorized plugins installed.")
+				return nil
+			}
+
+			// Work on a copy so --dry-run and failed saves leave cfg untouched
+			groups := make(map[string]config.GroupConfig, len(cfg.Groups))
+			for k, v := range cfg.Groups {
+				groups[k] = v
+			}
+			preview := *cfg
+			preview.Groups = groups
+			res := preview.ApplyAutoGroups(categories)
+
+			for _, name := range res.Created {
+				_, _ = fmt.Fprintf(out, "Created group %q (%s)\n", name, strings.Join(groups[name].Plugins, ", "))
+			}
+			for _, name := range res.Updated {
+				_, _ = fmt.Fprintf(out, "Updated group %q (%s)\n", name, strings.Join(groups[name].Plugins, ", "))
+			}
+			for _, name := range res.Skipped {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: skipping category %q: name is reserved or used by a user-created group\n", name)
+			}
+			if len(res.Created)+len(res.Updated) == 0 {
+				_, _ = fmt.Fprintln(out, "Groups are up to date.")
+				return nil
+			}
+			if dryRun {
+				return nil
+			}
+
+			if err := preview.Save(configPath); err != nil {
+				return err
+			}
+			cfg.Groups = groups
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&fromIndex, "from-index", false, "Use categories from plugin indexes")
+	cmd.Flags().StringVar(&indexFilter, "index", "", "Use a specific index only")
+	cmd.Flags().StringSliceVar(&only, "category", nil, "Only create groups for these categories")
+	cmd.Flags().BoolVar(&forceRefresh, "refresh", false, "Force refresh of plugin indexes")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show changes without saving them")
+	return cmd
+}
+
+// categorizePlugins maps each lowercase category to the installed plugins
+// listed under it. A plugin listed by several indexes is counted once per
+// category. A non-empty only restricts the categories returned.
+func categorizePlugins(entries []pluginpkg.SearchResult, installed map[string]bool, only []string) map[string][]string {
+	allowed := make(map[string]bool, len(only))
+	for _, c := range only {
+		allowed[strings.ToLower(c)] = true
+	}
+
+	seen := make(map[string]bool)
+	categories := make(map[string][]string)
+	for _, e := range entries {
+		if !installed[e.Name] {
+			continue
+		}
+		for _, c := range e.Categories {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c == "" || (len(allowed) > 0 && !allowed[c]) || seen[c+"/"+e.Name] {
+				continue
+			}
+			seen[c+"/"+e.Name] = true
+			categories[c] = append(categories[c], e.Name)
+		}
+	}
+	return categories
+}
+
 // newGroupAddCommand creates the "group add" command.
 func newGroupAddCommand(cfg *config.Config, configPath string) *cobra.Command {
 	return &cobra.Command{
-		Use:   "add <group> <plugin>...",
-		Short: "Add plugins to a group",
-		Args:  cobra.MinimumNArgs(2),
+		Use:               "add <group> <plugin|group>...",
+		ValidArgsFunction: completeGroupAdd(cfg, cachedPlugins),
+		Short:             "Add plugins or nested groups to a group",
+		Args:              cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			groupName := args[0]
 			pluginNames := args[1:]
@@ -173,8 +582,15 @@ func newGroupAddCommand(cfg *config.Config, configPath string) *cobra.Command {
 				return nil
 			}
 
+			previous := cfg.Groups[groupName]
 			cfg.Groups[groupName] = group
 
+			// Entries may name other groups; reject additions that nest a group in itself
+			if err := cfg.ValidateGroups(); err != nil {
+				cfg.Groups[groupName] = previous
+				return err
+			}
+
 			if err := cfg.Save(configPath); err != nil {
 				return err
 			}
@@ -188,9 +604,10 @@ func newGroupAddCommand(cfg *config.Config, configPath string) *cobra.Command {
 // newGroupRemoveCommand creates the "group remove" command.
 func newGroupRemoveCommand(cfg *config.Config, configPath string) *cobra.Command {
 	return &cobra.Command{
-		Use:   "remove <group> <plugin>...",
-		Short: "Remove plugins from a group",
-		Args:  cobra.MinimumNArgs(2),
+		Use:               "remove <group> <plugin>...",
+		ValidArgsFunction: completeGroupRemove(cfg),
+		Short:             "Remove plugins from a group",
+		Args:              cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			groupName := args[0]
 			pluginNames := args[1:]
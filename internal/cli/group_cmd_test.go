@@ -8,11 +8,12 @@ import (
 	"testing"
 
 	"github.com/whiskeyjimb/tack-cli/internal/config"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
 )
 
 func TestGroupList_Empty(t *testing.T) {
 	cfg := config.DefaultConfig()
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
@@ -32,7 +33,7 @@ func TestGroupList_WithGroups(t *testing.T) {
 	cfg.Groups = map[string]config.GroupConfig{
 		"network": {Description: "Network tools", Plugins: []string{"dns", "http"}},
 	}
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
@@ -56,7 +57,7 @@ func TestGroupCreate(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
@@ -86,7 +87,7 @@ func TestGroupCreate_Duplicate(t *testing.T) {
 		"network": {Description: "Network tools", Plugins: []string{"dns"}},
 	}
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"create", "network"})
 
 	if err := cmd.Execute(); err == nil {
@@ -99,7 +100,7 @@ func TestGroupCreate_ReservedName(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	cmd.SetArgs([]string{"create", "plugin"})
 
 	if err := cmd.Execute(); err == nil {
@@ -119,7 +120,7 @@ func TestGroupDelete(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
 	cmd.SetArgs([]string{"delete", "network"})
@@ -136,7 +137,7 @@ func TestGroupDelete(t *testing.T) {
 func TestGroupDelete_NotFound(t *testing.T) {
 	cfg := config.DefaultConfig()
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"delete", "nonexistent"})
 
 	if err := cmd.Execute(); err == nil {
@@ -150,7 +151,7 @@ func TestGroupDelete_TopGroup(t *testing.T) {
 		"top": {Description: "Top-level plugins", Plugins: []string{"dns"}},
 	}
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"delete", "top"})
 
 	if err := cmd.Execute(); err == nil {
@@ -160,6 +161,73 @@ func TestGroupDelete_TopGroup(t *testing.T) {
 	}
 }
 
+func TestGroupRename(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"network": {Description: "Network tools", Plugins: []string{"dns", "http"}},
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	cmd := newGroupCommand(cfg, nil, path)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"rename", "network", "net"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, exists := cfg.Groups["network"]; exists {
+		t.Error("expected old group name to be removed")
+	}
+	group, exists := cfg.Groups["net"]
+	if !exists {
+		t.Fatal("expected group 'net' to exist")
+	}
+	if group.Description != "Network tools" || len(group.Plugins) != 2 {
+		t.Errorf("expected description and plugins to be preserved, got %+v", group)
+	}
+
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading saved config: %v", err)
+	}
+	if _, exists := saved.Groups["net"]; !exists {
+		t.Error("expected rename to be persisted")
+	}
+}
+
+func TestGroupRename_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"not found", []string{"rename", "missing", "x"}},
+		{"target exists", []string{"rename", "network", "cloud"}},
+		{"reserved", []string{"rename", "network", "plugin"}},
+		{"top", []string{"rename", "top", "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Groups = map[string]config.GroupConfig{
+				"top":     {Plugins: []string{"dns"}},
+				"network": {Plugins: []string{"dns"}},
+				"cloud":   {Plugins: []string{"aws"}},
+			}
+			cmd := newGroupCommand(cfg, nil, filepath.Join(t.TempDir(), "config.yaml"))
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err == nil {
+				t.Error("expected error")
+			}
+			if len(cfg.Groups) != 3 {
+				t.Errorf("expected groups to be unchanged, got %v", cfg.Groups)
+			}
+		})
+	}
+}
+
 func TestGroupAdd(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Groups = map[string]config.GroupConfig{
@@ -168,7 +236,7 @@ func TestGroupAdd(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
 	cmd.SetArgs([]string{"add", "network", "http", "tcp"})
@@ -191,7 +259,7 @@ func TestGroupAdd_Duplicate(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
 	cmd.SetErr(new(bytes.Buffer))
@@ -210,7 +278,7 @@ func TestGroupAdd_Duplicate(t *testing.T) {
 func TestGroupAdd_GroupNotFound(t *testing.T) {
 	cfg := config.DefaultConfig()
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"add", "nonexistent", "dns"})
 
 	if err := cmd.Execute(); err == nil {
@@ -226,7 +294,7 @@ func TestGroupRemove(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
 	cmd.SetArgs([]string{"remove", "network", "http"})
@@ -252,7 +320,7 @@ func TestGroupRemove_PluginNotInGroup(t *testing.T) {
 		"network": {Description: "Network tools", Plugins: []string{"dns"}},
 	}
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"remove", "network", "http"})
 
 	if err := cmd.Execute(); err == nil {
@@ -266,7 +334,7 @@ func TestGroupRemove_FromTopWithoutOtherGroup(t *testing.T) {
 		"top": {Description: "Top-level", Plugins: []string{"aws", "dns"}},
 	}
 
-	cmd := newGroupCommand(cfg, "")
+	cmd := newGroupCommand(cfg, nil, "")
 	cmd.SetArgs([]string{"remove", "top", "aws"})
 
 	if err := cmd.Execute(); err == nil {
@@ -285,7 +353,7 @@ func TestGroupRemove_FromTopWithOtherGroup(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
 
-	cmd := newGroupCommand(cfg, path)
+	cmd := newGroupCommand(cfg, nil, path)
 	buf := new(bytes.Buffer)
 	cmd.SetOut(buf)
 	cmd.SetArgs([]string{"remove", "top", "dns"})
@@ -300,3 +368,130 @@ func TestGroupRemove_FromTopWithOtherGroup(t *testing.T) {
 		t.Errorf("expected only 'aws' in top group, got: %v", group.Plugins)
 	}
 }
+
+func TestGroupExportImport(t *testing.T) {
+	src := config.DefaultConfig()
+	src.Groups = map[string]config.GroupConfig{
+		"network": {Description: "Network tools", Plugins: []string{"dns", "http"}},
+	}
+	src.PluginDefaults = map[string]map[string]string{"dns": {"server": "1.1.1.1"}}
+
+	exported := new(bytes.Buffer)
+	cmd := newGroupCommand(src, nil, "")
+	cmd.SetOut(exported)
+	cmd.SetArgs([]string{"export", "network"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !strings.Contains(exported.String(), "server: 1.1.1.1") {
+		t.Errorf("expected defaults in export, got %q", exported.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "network.yaml")
+	if err := os.WriteFile(file, exported.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := config.DefaultConfig()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cmd = newGroupCommand(dst, nil, path)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"import", file, "--name", "net"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("loading saved config: %v", err)
+	}
+	if saved.Groups["net"].Description != "Network tools" || len(saved.Groups["net"].Plugins) != 2 {
+		t.Errorf("unexpected imported group: %+v", saved.Groups["net"])
+	}
+	if saved.PluginDefaults["dns"]["server"] != "1.1.1.1" {
+		t.Errorf("expected imported defaults, got %v", saved.PluginDefaults)
+	}
+}
+
+func TestGroupAdd_NestedCycle(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"ops":     {Plugins: []string{"network"}},
+		"network": {Plugins: []string{"dns"}},
+	}
+
+	cmd := newGroupCommand(cfg, nil, filepath.Join(t.TempDir(), "config.yaml"))
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"add", "network", "ops"})
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if len(cfg.Groups["network"].Plugins) != 1 {
+		t.Errorf("expected network to be unchanged, got %v", cfg.Groups["network"].Plugins)
+	}
+}
+
+func TestDescribeGroup(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"ops":   {Description: "Operations", Plugins: []string{"dns", "ghost", "cloud"}},
+		"cloud": {Description: "Cloud tools", Plugins: []string{"aws"}, Hidden: true},
+	}
+	cfg.HiddenPlugins = []string{"dns"}
+	dns := fakeDiscoveredPlugin("dns")
+	dns.Manifest.Version = "1.2.0"
+	dns.Source = "oci"
+
+	detail := describeGroup(cfg, "ops", []pluginpkg.DiscoveredPlugin{dns})
+
+	if len(detail.Plugins) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", detail.Plugins)
+	}
+	got := detail.Plugins[0]
+	if got.Status != "installed" || got.Version != "1.2.0" || got.Source != "oci" {
+		t.Errorf("unexpected dns entry: %+v", got)
+	}
+	if len(got.Operations) != 1 || got.Operations[0] != "check: Run check" {
+		t.Errorf("unexpected dns operations: %v", got.Operations)
+	}
+	if detail.Plugins[1].Status != "missing" || detail.Plugins[2].Status != "group" {
+		t.Errorf("expected missing and group entries, got %+v", detail.Plugins[1:])
+	}
+	if !got.Hidden || detail.Plugins[1].Hidden || !detail.Plugins[2].Hidden {
+		t.Errorf("expected dns and cloud to be marked hidden, got %+v", detail.Plugins)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := renderGroupDetail(buf, "table", detail); err != nil {
+		t.Fatalf("renderGroupDetail: %v", err)
+	}
+	for _, want := range []string{"Group: ops", "installed (hidden)", "- check: Run check", "missing"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestCategorizePlugins(t *testing.T) {
+	entry := func(name string, categories ...string) pluginpkg.SearchResult {
+		return pluginpkg.SearchResult{PluginEntry: pluginpkg.PluginEntry{Name: name, Categories: categories}}
+	}
+	entries := []pluginpkg.SearchResult{
+		entry("dns", "Network"),
+		entry("dns", "network"),
+		entry("aws", "cloud", "security"),
+		entry("gcp", "cloud"),
+	}
+	installed := map[string]bool{"dns": true, "aws": true}
+
+	got := categorizePlugins(entries, installed, nil)
+	if len(got) != 3 || len(got["network"]) != 1 || len(got["cloud"]) != 1 {
+		t.Errorf("unexpected categories: %v", got)
+	}
+
+	got = categorizePlugins(entries, installed, []string{"security"})
+	if len(got) != 1 || got["security"][0] != "aws" {
+		t.Errorf("expected only security, got %v", got)
+	}
+}
@@ -0,0 +1,496 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// statusSkipped marks group members that do not provide the operation.
+const statusSkipped = "skipped"
+
+// groupRunResult is one plugin's outcome in a "group run" report.
+type groupRunResult struct {
+	Plugin   string         `json:"plugin" yaml:"plugin"`
+	Service  string         `json:"service,omitempty" yaml:"service,omitempty"`
+	Status   string         `json:"status" yaml:"status"`
+	Duration time.Duration  `json:"duration_ns" yaml:"duration"`
+	Message  string         `json:"message,omitempty" yaml:"message,omitempty"`
+	Data     map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// groupRunReport aggregates a broadcast operation across a group.
+type groupRunReport struct {
+	Group     string           `json:"group" yaml:"group"`
+	Operation string           `json:"operation" yaml:"operation"`
+	Results   []groupRunResult `json:"results" yaml:"results"`
+	Duration  time.Duration    `json:"duration_ns" yaml:"duration"`
+}
+
+// failed returns the number of plugins that ran and did not succeed.
+func (r *groupRunReport) failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status != string(abi.ResultStatusSuccess) && res.Status != statusSkipped {
+			n++
+		}
+	}
+	return n
+}
+
+// groupRunArgs holds the parsed command line of "group run".
+type groupRunArgs struct {
+	group       string
+	operation   string
+	input       map[string]string
+	output      string
+	outputSet   bool
+	quiet       bool
+	verbose     bool
+	trust       bool
+	showSecrets bool
+	concurrency int
+	failFast    bool
+	stepTimeout time.Duration
+	help        bool
+}
+
+// newGroupRunCommand creates the "group run" command.
+func newGroupRunCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run <group> <operation> [--flag value]...",
+		Short: "Run an operation on every plugin in a group that provides it",
+		Long: fmt.Sprintf(`Run the named operation on every plugin in a group (including nested
+groups) that provides it, and report all results together.
+
+Remaining flags are passed to each plugin as operation input, on top of the
+group's defaults. A flag is only sent to plugins whose config schema
+declares it, and its value is converted to the type the schema expects.
+Plugins without the operation are reported as skipped.
+
+A summary of passed, failed, errored and skipped plugins is printed to
+stderr, and the command exits non-zero if any plugin that ran did not
+succeed.
+
+Flags:
+      --parallel int            Maximum plugins to run at once (default 4)
+      --fail-fast               Stop starting plugins once one does not succeed
+      --step-timeout duration   Maximum run time of each plugin (0 for none)
+
+Examples:
+  %s group run network healthcheck
+  %s group run network healthcheck --host example.com --output json
+  %s group run network healthcheck --parallel 8 --fail-fast --step-timeout 30s`, meta.AppName, meta.AppName, meta.AppName),
+		// Plugin flags differ per group member, so they are parsed by hand.
+		DisableFlagParsing: true,
+		ValidArgsFunction:  completeGroupNames(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			parsed, err := parseGroupRunArgs(args, output)
+			if err != nil {
+				return err
+			}
+			if parsed.help {
+				return cmd.Help()
+			}
+			if parsed.group == "" || parsed.operation == "" {
+				return fmt.Errorf("usage: %s group run <group> <operation> [--flag value]...", meta.AppName)
+			}
+			if _, ok := cfg.Groups[parsed.group]; !ok {
+				return fmt.Errorf("group %q not found", parsed.group)
+			}
+
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+			exec := newPluginExecutor(discovered, cfg, parsed.verbose, parsed.trust)
+			defer func() { _ = exec.Close() }()
+
+			mask := sensitiveMasks(discovered, parsed.showSecrets)
+			report := runGroupOperation(ctx, cfg, parsed, discovered, func(ctx context.Context, plugin, service, operation string, input map[string]any) (abi.Result, error) {
+				result, err := exec.Execute(ctx, plugin, service, operation, input)
+				result.Data = mask(plugin, service, operation, result.Data)
+				return result, err
+			})
+
+			format := resultFormat(parsed.output, parsed.outputSet)
+			if parsed.quiet {
+				format = "quiet"
+			}
+			if err := renderGroupRunReport(cmd.OutOrStdout(), format, report, severityHints(discovered)); err != nil {
+				return err
+			}
+			if format != "quiet" {
+				statuses := make([]string, len(report.Results))
+				for i, r := range report.Results {
+					statuses[i] = r.Status
+				}
+				_ = summarize(statuses, report.Duration).write(cmd.ErrOrStderr())
+			}
+
+			ran := 0
+			for _, r := range report.Results {
+				if r.Status != statusSkipped {
+					ran++
+				}
+			}
+			if ran == 0 {
+				return fmt.Errorf("no plugin in group %q provides operation %q", parsed.group, parsed.operation)
+			}
+			if n := report.failed(); n > 0 {
+				return fmt.Errorf("%d of %d plugins did not succeed", n, ran)
+			}
+			return nil
+		},
+	}
+}
+
+// parseGroupRunArgs splits "group run" arguments into positionals, the
+// command's own flags, and plugin input flags.
+func parseGroupRunArgs(args []string, defaultOutput string) (groupRunArgs, error) {
+	parsed := groupRunArgs{input: map[string]string{}, output: defaultOutput, concurrency: 4}
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if k, v, ok := strings.Cut(name, "="); ok {
+			name, value, hasValue = k, v, true
+		}
+		// takeValue consumes the next argument unless it is another flag.
+		takeValue := func() (string, bool) {
+			if hasValue {
+				return value, true
+			}
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+				return args[i], true
+			}
+			return "", false
+		}
+
+		switch name {
+		case "h", "help":
+			parsed.help = true
+		case "v", "verbose":
+			parsed.verbose = true
+		case "quiet":
+			parsed.quiet = true
+		case "trust-plugins":
+			parsed.trust = true
+		case ShowSecretsFlag:
+			parsed.showSecrets = true
+		case "read-only", EgressReportFlag, StatsFlag, NoCacheFlag:
+			// Applied process-wide before parsing
+		case LogFileFlag:
+			// Applied process-wide before parsing
+			takeValue()
+		case "output":
+			v, ok := takeValue()
+			if !ok {
+				return parsed, fmt.Errorf("flag --output needs a value")
+			}
+			parsed.output = v
+			parsed.outputSet = true
+		case parallelFlag, "concurrency":
+			v, ok := takeValue()
+			n, err := strconv.Atoi(v)
+			if !ok || err != nil || n < 1 {
+				return parsed, fmt.Errorf("flag --%s needs a positive integer", name)
+			}
+			parsed.concurrency = n
+		case failFastFlag:
+			parsed.failFast = true
+		case stepTimeoutFlag:
+			v, ok := takeValue()
+			d, err := time.ParseDuration(v)
+			if !ok || err != nil || d < 0 {
+				return parsed, fmt.Errorf("flag --%s needs a duration such as 30s", stepTimeoutFlag)
+			}
+			parsed.stepTimeout = d
+		default:
+			v, ok := takeValue()
+			if !ok {
+				v = "true"
+			}
+			parsed.input[name] = v
+		}
+	}
+
+	if len(positional) > 0 {
+		parsed.group = positional[0]
+	}
+	if len(positional) > 1 {
+		parsed.operation = positional[1]
+	}
+	if len(positional) > 2 {
+		return parsed, fmt.Errorf("unexpected arguments: %s", strings.Join(positional[2:], " "))
+	}
+	return parsed, nil
+}
+
+// groupMember is a plugin reached through a group, with the group defaults
+// that apply to it.
+type groupMember struct {
+	Name     string
+	Defaults map[string]string
+}
+
+// groupMembers returns the plugins of a group, expanding nested groups,
+// in first-seen order without duplicates.
+func groupMembers(cfg *config.Config, group string) []groupMember {
+	var members []groupMember
+	seen := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var walk func(name string, inherited map[string]string)
+	walk = func(name string, inherited map[string]string) {
+		if visiting[name] {
+			return
+		}
+		visiting[name] = true
+		defaults := config.MergeDefaults(inherited, cfg.Groups[name].Defaults)
+		for _, entry := range cfg.Groups[name].Plugins {
+			if cfg.IsNestedGroup(entry) {
+				walk(entry, defaults)
+				continue
+			}
+			if !seen[entry] {
+				seen[entry] = true
+				members = append(members, groupMember{Name: entry, Defaults: defaults})
+			}
+		}
+	}
+	walk(group, nil)
+	return members
+}
+
+// runGroupOperation executes the operation on each group member that
+// provides it, at most args.concurrency at a time. With args.failFast, no
+// further plugins start once one does not succeed.
+func runGroupOperation(
+	ctx context.Context,
+	cfg *config.Config,
+	args groupRunArgs,
+	discovered []pluginpkg.DiscoveredPlugin,
+	execute func(ctx context.Context, plugin, service, operation string, input map[string]any) (abi.Result, error),
+) *groupRunReport {
+	byName := make(map[string]pluginpkg.DiscoveredPlugin, len(discovered))
+	for _, dp := range discovered {
+		byName[dp.Manifest.Name] = dp
+	}
+
+	report := &groupRunReport{Group: args.group, Operation: args.operation}
+	start := time.Now()
+
+	type job struct {
+		index   int
+		plugin  string
+		service string
+		input   map[string]any
+	}
+	var jobs []job
+
+	for _, member := range groupMembers(cfg, args.group) {
+		name := member.Name
+		dp, ok := byName[name]
+		if !ok {
+			report.Results = append(report.Results, groupRunResult{Plugin: name, Status: statusSkipped, Message: "not installed"})
+			continue
+		}
+		services := servicesWithOperation(dp.Manifest, args.operation)
+		if len(services) == 0 {
+			report.Results = append(report.Results, groupRunResult{Plugin: name, Status: statusSkipped, Message: fmt.Sprintf("no %s operation", args.operation)})
+			continue
+		}
+		// Group defaults apply under the flags given on the command line
+		input := inputForPlugin(dp.Manifest, config.MergeDefaults(member.Defaults, args.input))
+		for _, svc := range services {
+			report.Results = append(report.Results, groupRunResult{Plugin: name, Service: svc})
+			jobs = append(jobs, job{index: len(report.Results) - 1, plugin: name, service: svc, input: input})
+		}
+	}
+
+	sem := make(chan struct{}, args.concurrency)
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+	)
+	for _, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		if stopped.Load() {
+			r := &report.Results[j.index]
+			r.Status, r.Message = statusSkipped, failFastMessage
+			<-sem
+			wg.Done()
+			continue
+		}
+		go func(j job) {
+			defer func() { <-sem; wg.Done() }()
+
+			jobCtx := ctx
+			if args.stepTimeout > 0 {
+				var cancel context.CancelFunc
+				jobCtx, cancel = context.WithTimeout(ctx, args.stepTimeout)
+				defer cancel()
+			}
+
+			jobStart := time.Now()
+			result, err := execute(jobCtx, j.plugin, j.service, args.operation, j.input)
+			r := &report.Results[j.index]
+			r.Duration = time.Since(jobStart)
+			switch {
+			case ctx.Err() == nil && errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+				r.Status = string(abi.ResultStatusError)
+				r.Message = fmt.Sprintf("timed out after %s", args.stepTimeout)
+			case err != nil:
+				r.Status = string(abi.ResultStatusError)
+				r.Message = err.Error()
+			default:
+				r.Status = string(result.Status)
+				r.Message = result.Message
+				if result.Error != nil && result.Error.Message != "" {
+					r.Message = result.Error.Message
+				}
+				r.Data = result.Data
+			}
+			if args.failFast && r.Status != string(abi.ResultStatusSuccess) {
+				stopped.Store(true)
+			}
+		}(j)
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// servicesWithOperation returns, in name order, the services of a plugin
+// that provide the operation.
+func servicesWithOperation(m abi.Manifest, operation string) []string {
+	var services []string
+	for name, svc := range m.Services {
+		if hasOperation(svc, operation) {
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// inputForPlugin converts kebab-case flag values into the plugin's config
+// fields, typed by its schema. Fields the schema does not declare are
+// dropped; a plugin without a schema receives every field as a string.
+func inputForPlugin(m abi.Manifest, flags map[string]string) map[string]any {
+	schema, err := parseConfigSchema(m.ConfigSchema)
+	if err != nil {
+		schema = &parsedSchema{}
+	}
+
+	input := make(map[string]any, len(flags))
+	for flag, value := range flags {
+		prop, declared := schema.property(flag)
+		if len(schema.flagProperties()) > 0 && !declared {
+			continue
+		}
+		setField(input, flag, coerceDefault(prop, value))
+	}
+	return input
+}
+
+// renderGroupRunReport writes a group run report in the given output format.
+// severity supplies Code Quality severity hints and may be nil.
+func renderGroupRunReport(w io.Writer, format string, report *groupRunReport, severity severityFunc) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tSERVICE\tSTATUS\tDURATION\tMESSAGE")
+		for _, r := range report.Results {
+			duration := ""
+			if r.Status != statusSkipped {
+				duration = r.Duration.Round(time.Millisecond).String()
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Plugin, r.Service, r.Status, duration, r.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "\nRan %q across group %q in %s\n", report.Operation, report.Group, report.Duration.Round(time.Millisecond))
+		return nil
+	case "gha":
+		if err := renderGroupRunReport(w, "table", report, severity); err != nil {
+			return err
+		}
+		checks := groupRunChecks(report, severity)
+		if err := output.WriteAnnotations(w, checks); err != nil {
+			return err
+		}
+		return output.WriteStepSummary(fmt.Sprintf("Group %s: %s", report.Group, report.Operation), checks)
+	case "codequality":
+		return output.WriteCodeQuality(w, groupRunChecks(report, severity))
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, gha, codequality, quiet)", format)
+	}
+}
+
+// groupRunChecks lists the plugins that ran as CI checks.
+func groupRunChecks(report *groupRunReport, severity severityFunc) []output.Check {
+	var checks []output.Check
+	for _, r := range report.Results {
+		if r.Status == statusSkipped {
+			continue
+		}
+		name := r.Plugin
+		if r.Service != "" && r.Service != r.Plugin {
+			name += " " + r.Service
+		}
+		checks = append(checks, output.Check{
+			Name:      name + " " + report.Operation,
+			Status:    r.Status,
+			Message:   r.Message,
+			Duration:  r.Duration,
+			Plugin:    r.Plugin,
+			Service:   r.Service,
+			Operation: report.Operation,
+			Severity:  severity.of(r.Plugin, r.Service, report.Operation),
+		})
+	}
+	return checks
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func TestParseGroupRunArgs(t *testing.T) {
+	got, err := parseGroupRunArgs([]string{"network", "healthcheck", "--host", "example.com", "--ipv6", "--port=443", "--output", "json", "-v", "--concurrency", "2"}, "table")
+	if err != nil {
+		t.Fatalf("parseGroupRunArgs: %v", err)
+	}
+	if got.group != "network" || got.operation != "healthcheck" {
+		t.Errorf("unexpected positionals: %+v", got)
+	}
+	if got.output != "json" || !got.verbose || got.concurrency != 2 {
+		t.Errorf("unexpected command flags: %+v", got)
+	}
+	want := map[string]string{"host": "example.com", "ipv6": "true", "port": "443"}
+	if len(got.input) != len(want) {
+		t.Fatalf("unexpected input: %v", got.input)
+	}
+	for k, v := range want {
+		if got.input[k] != v {
+			t.Errorf("input[%s] = %q, want %q", k, got.input[k], v)
+		}
+	}
+
+	if _, err := parseGroupRunArgs([]string{"a", "b", "c"}, "table"); err == nil {
+		t.Error("expected error for extra positional arguments")
+	}
+	if _, err := parseGroupRunArgs([]string{"a", "b", "--concurrency", "0"}, "table"); err == nil {
+		t.Error("expected error for invalid concurrency")
+	}
+
+	got, err = parseGroupRunArgs([]string{"a", "b", "--parallel", "8", "--fail-fast", "--step-timeout", "30s"}, "table")
+	if err != nil {
+		t.Fatalf("parseGroupRunArgs: %v", err)
+	}
+	if got.concurrency != 8 || !got.failFast || got.stepTimeout != 30*time.Second || len(got.input) != 0 {
+		t.Errorf("unexpected batch flags: %+v", got)
+	}
+	if _, err := parseGroupRunArgs([]string{"a", "b", "--step-timeout", "soon"}, "table"); err == nil {
+		t.Error("expected error for invalid step timeout")
+	}
+}
+
+func TestRunGroupOperationFailFastAndTimeout(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{"ops": {Plugins: []string{"slow", "broken", "http"}}}
+	discovered := []pluginpkg.DiscoveredPlugin{fakeDiscoveredPlugin("slow"), fakeDiscoveredPlugin("broken"), fakeDiscoveredPlugin("http")}
+
+	exec := func(ctx context.Context, plugin, _, _ string, _ map[string]any) (abi.Result, error) {
+		switch plugin {
+		case "slow":
+			<-ctx.Done()
+			return abi.Result{}, ctx.Err()
+		case "broken":
+			return abi.ResultFailure("down", nil), nil
+		}
+		return abi.ResultSuccess("ok", nil), nil
+	}
+
+	args := groupRunArgs{group: "ops", operation: "check", concurrency: 1, stepTimeout: 10 * time.Millisecond}
+	report := runGroupOperation(context.Background(), cfg, args, discovered, exec)
+	want := []string{"error", "failure", "success"}
+	for i, r := range report.Results {
+		if r.Status != want[i] {
+			t.Errorf("%s: status %s, want %s", r.Plugin, r.Status, want[i])
+		}
+	}
+	if msg := report.Results[0].Message; msg != "timed out after 10ms" {
+		t.Errorf("unexpected timeout message: %q", msg)
+	}
+
+	args.failFast = true
+	report = runGroupOperation(context.Background(), cfg, args, discovered, exec)
+	for i, r := range report.Results[1:] {
+		if r.Status != statusSkipped || r.Message != failFastMessage {
+			t.Errorf("result %d: expected a fail-fast skip, got %+v", i+1, r)
+		}
+	}
+}
+
+func TestRunGroupOperation(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Groups = map[string]config.GroupConfig{
+		"ops":     {Plugins: []string{"network", "dns", "ghost"}, Defaults: map[string]string{"port": "80", "retries": "3"}},
+		"network": {Plugins: []string{"http", "dns"}},
+	}
+
+	http := fakeDiscoveredPlugin("http")
+	http.Manifest.ConfigSchema = json.RawMessage(`{"properties":{"port":{"type":"integer"},"retries":{"type":"integer"}}}`)
+	dns := fakeDiscoveredPlugin("dns")
+	dns.Manifest.Services["dns"].Operations[0].Name = "resolve"
+	discovered := []pluginpkg.DiscoveredPlugin{http, dns}
+
+	var gotInput map[string]any
+	exec := func(_ context.Context, plugin, service, operation string, input map[string]any) (abi.Result, error) {
+		if plugin != "http" || operation != "check" {
+			return abi.Result{}, errors.New("unexpected call")
+		}
+		gotInput = input
+		return abi.ResultSuccess("ok", map[string]any{"up": true}), nil
+	}
+
+	args := groupRunArgs{group: "ops", operation: "check", input: map[string]string{"port": "443", "host": "x"}, concurrency: 2}
+	report := runGroupOperation(context.Background(), cfg, args, discovered, exec)
+
+	if len(report.Results) != 3 {
+		t.Fatalf("expected http, dns, and ghost rows, got %+v", report.Results)
+	}
+	if r := report.Results[0]; r.Plugin != "http" || r.Status != "success" || r.Message != "ok" {
+		t.Errorf("unexpected http result: %+v", r)
+	}
+	if report.Results[1].Status != statusSkipped || report.Results[2].Status != statusSkipped {
+		t.Errorf("expected dns and ghost to be skipped: %+v", report.Results[1:])
+	}
+	if report.failed() != 0 {
+		t.Errorf("expected no failures, got %d", report.failed())
+	}
+	if len(gotInput) != 2 || gotInput["port"] != 443 || gotInput["retries"] != 3 {
+		t.Errorf("expected typed declared fields with flags over group defaults, got %v", gotInput)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := renderGroupRunReport(buf, "table", report, nil); err != nil {
+		t.Fatalf("renderGroupRunReport: %v", err)
+	}
+	if !strings.Contains(buf.String(), "no check operation") {
+		t.Errorf("expected skip reason in table output:\n%s", buf.String())
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	buf.Reset()
+	if err := renderGroupRunReport(buf, "gha", report, nil); err != nil {
+		t.Fatalf("renderGroupRunReport: %v", err)
+	}
+	if !strings.Contains(buf.String(), "::notice title=http check::") || strings.Contains(buf.String(), "title=dns") {
+		t.Errorf("expected an annotation for http only:\n%s", buf.String())
+	}
+}
@@ -13,11 +13,19 @@ import (
 // registerGroups creates group commands and nests plugin commands under them.
 // Returns the set of plugin names that are in the "top" group (for root-level registration).
 // The "top" group is special - its plugins appear at root level, not under a "top" command.
+//
+// A group entry naming another group nests that group's command tree, so
+// "ops: [network, cloud]" yields "tack ops network dns ...". Nested groups
+// are also registered at the root like any other group. Cycles are rejected
+// by config.ValidateGroups; registration stops at any that remain.
+//
+// generateFn receives the group defaults that apply to the plugin: those of
+// the group and every group enclosing it, innermost first in precedence.
 func registerGroups(
 	root *cobra.Command,
 	groups map[string]config.GroupConfig,
 	discovered []pluginpkg.DiscoveredPlugin,
-	generateFn func(pluginpkg.DiscoveredPlugin) *cobra.Command,
+	generateFn func(dp pluginpkg.DiscoveredPlugin, groupDefaults map[string]string) *cobra.Command,
 ) map[string]bool {
 	// Build lookup: plugin name -> DiscoveredPlugin
 	pluginMap := make(map[string]pluginpkg.DiscoveredPlugin)
@@ -28,39 +36,81 @@ func registerGroups(
 	// Track which plugins are in the "top" group
 	topGroupPlugins := make(map[string]bool)
 
-	for groupName, groupCfg := range groups {
-		// Handle "top" group specially - just track its plugins, don't create a command
-		if groupName == "top" {
-			for _, pluginName := range groupCfg.Plugins {
-				if _, ok := pluginMap[pluginName]; ok {
-					topGroupPlugins[pluginName] = true
-				}
-			}
-			continue
-		}
+	// Warn once per missing plugin reference, however often a group is nested.
+	warned := make(map[string]bool)
+
+	// buildGroup returns the command tree for a group, or nil if it contains
+	// no installed plugins. Each call creates fresh commands because a cobra
+	// command can only have one parent.
+	var buildGroup func(groupName string, visiting map[string]bool, inherited map[string]string) *cobra.Command
+	buildGroup = func(groupName string, visiting map[string]bool, inherited map[string]string) *cobra.Command {
+		groupCfg := groups[groupName]
+		defaults := config.MergeDefaults(inherited, groupCfg.Defaults)
+		visiting[groupName] = true
+		defer delete(visiting, groupName)
 
-		// Regular group - create a command for it
 		groupCmd := &cobra.Command{
-			Use:   groupName,
-			Short: groupCfg.Description,
+			Use:    groupName,
+			Short:  groupCfg.Description,
+			Hidden: groupCfg.Hidden,
 		}
 
-		var pluginNames []string
-		for _, pluginName := range groupCfg.Plugins {
-			dp, ok := pluginMap[pluginName]
+		var pluginNames, subgroupNames []string
+		for _, entry := range groupCfg.Plugins {
+			if _, isGroup := groups[entry]; isGroup && entry != "top" {
+				if visiting[entry] {
+					continue
+				}
+				if sub := buildGroup(entry, visiting, defaults); sub != nil {
+					groupCmd.AddCommand(sub)
+					subgroupNames = append(subgroupNames, entry)
+				}
+				continue
+			}
+
+			dp, ok := pluginMap[entry]
 			if !ok {
-				fmt.Fprintf(os.Stderr, "Warning: group %q references plugin %q which is not installed\n", groupName, pluginName)
+				key := groupName + "/" + entry
+				if !warned[key] {
+					fmt.Fprintf(os.Stderr, "Warning: group %q references plugin %q which is not installed\n", groupName, entry)
+					warned[key] = true
+				}
 				continue
 			}
 
-			pluginCmd := generateFn(dp)
+			pluginCmd := generateFn(dp, defaults)
 			groupCmd.AddCommand(pluginCmd)
-			pluginNames = append(pluginNames, pluginName)
+			pluginNames = append(pluginNames, entry)
 		}
 
-		// Only add the group if it has at least one valid plugin
+		// Only keep the group if it has at least one valid plugin
+		if len(pluginNames) == 0 && len(subgroupNames) == 0 {
+			return nil
+		}
+
+		long := groupCfg.Description
 		if len(pluginNames) > 0 {
-			groupCmd.Long = fmt.Sprintf("%s\n\nPlugins: %s", groupCfg.Description, strings.Join(pluginNames, ", "))
+			long += "\n\nPlugins: " + strings.Join(pluginNames, ", ")
+		}
+		if len(subgroupNames) > 0 {
+			long += "\n\nGroups: " + strings.Join(subgroupNames, ", ")
+		}
+		groupCmd.Long = long
+		return groupCmd
+	}
+
+	for groupName, groupCfg := range groups {
+		// Handle "top" group specially - just track its plugins, don't create a command
+		if groupName == "top" {
+			for _, pluginName := range groupCfg.Plugins {
+				if _, ok := pluginMap[pluginName]; ok {
+					topGroupPlugins[pluginName] = true
+				}
+			}
+			continue
+		}
+
+		if groupCmd := buildGroup(groupName, map[string]bool{}, nil); groupCmd != nil {
 			root.AddCommand(groupCmd)
 		}
 	}
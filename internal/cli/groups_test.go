@@ -29,7 +29,7 @@ func fakeDiscoveredPlugin(name string) pluginpkg.DiscoveredPlugin {
 }
 
 // fakeGenerateFn creates a simple cobra command from a DiscoveredPlugin for testing.
-func fakeGenerateFn(dp pluginpkg.DiscoveredPlugin) *cobra.Command {
+func fakeGenerateFn(dp pluginpkg.DiscoveredPlugin, _ map[string]string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   dp.Manifest.Name,
 		Short: dp.Manifest.Description,
@@ -187,3 +187,99 @@ func TestRegisterGroups_TopGroup(t *testing.T) {
 		t.Fatalf("expected 1 group command (not including top), got %d", len(root.Commands()))
 	}
 }
+
+func TestRegisterGroups_Nested(t *testing.T) {
+	root := &cobra.Command{Use: "tack"}
+
+	discovered := []pluginpkg.DiscoveredPlugin{
+		fakeDiscoveredPlugin("dns"),
+		fakeDiscoveredPlugin("aws"),
+		fakeDiscoveredPlugin("shell"),
+	}
+
+	groups := map[string]config.GroupConfig{
+		"ops":     {Description: "Operations", Plugins: []string{"network", "cloud", "shell"}},
+		"network": {Description: "Network tools", Plugins: []string{"dns"}},
+		"cloud":   {Description: "Cloud tools", Plugins: []string{"aws"}},
+	}
+
+	registerGroups(root, groups, discovered, fakeGenerateFn)
+
+	if len(root.Commands()) != 3 {
+		t.Fatalf("expected 3 root group commands, got %d", len(root.Commands()))
+	}
+
+	cmd, _, err := root.Find([]string{"ops", "network", "dns", "check"})
+	if err != nil || cmd.Name() != "check" {
+		t.Fatalf("expected ops network dns check to resolve, got %v (%v)", cmd, err)
+	}
+
+	cmd, _, err = root.Find([]string{"ops", "shell"})
+	if err != nil || cmd.Name() != "shell" {
+		t.Errorf("expected ops shell to resolve, got %v (%v)", cmd, err)
+	}
+}
+
+func TestRegisterGroups_CycleStops(t *testing.T) {
+	root := &cobra.Command{Use: "tack"}
+
+	groups := map[string]config.GroupConfig{
+		"a": {Plugins: []string{"b", "dns"}},
+		"b": {Plugins: []string{"a"}},
+	}
+
+	// Must terminate even if validation was skipped.
+	registerGroups(root, groups, []pluginpkg.DiscoveredPlugin{fakeDiscoveredPlugin("dns")}, fakeGenerateFn)
+
+	for _, c := range root.Commands() {
+		if c.Name() != "a" {
+			continue
+		}
+		if len(c.Commands()) != 1 || c.Commands()[0].Name() != "dns" {
+			t.Errorf("expected a to contain only dns once b's cycle is cut, got %v", c.Commands())
+		}
+	}
+}
+
+func TestRegisterGroups_Defaults(t *testing.T) {
+	root := &cobra.Command{Use: "tack"}
+
+	groups := map[string]config.GroupConfig{
+		"prod":     {Plugins: []string{"prod-aws"}, Defaults: map[string]string{"region": "eu-west-1", "profile": "prod"}},
+		"prod-aws": {Plugins: []string{"aws"}, Defaults: map[string]string{"region": "us-east-1"}},
+	}
+
+	got := map[string]map[string]string{}
+	generate := func(dp pluginpkg.DiscoveredPlugin, defaults map[string]string) *cobra.Command {
+		cmd := fakeGenerateFn(dp, defaults)
+		got[dp.Manifest.Name+":"+defaults["profile"]] = defaults
+		return cmd
+	}
+	registerGroups(root, groups, []pluginpkg.DiscoveredPlugin{fakeDiscoveredPlugin("aws")}, generate)
+
+	direct := got["aws:"]
+	if direct["region"] != "us-east-1" {
+		t.Errorf("expected prod-aws defaults for aws, got %v", direct)
+	}
+	nested := got["aws:prod"]
+	if nested["region"] != "us-east-1" || nested["profile"] != "prod" {
+		t.Errorf("expected nested group defaults to override enclosing ones, got %v", nested)
+	}
+}
+
+func TestRegisterGroups_Hidden(t *testing.T) {
+	root := &cobra.Command{Use: "tack"}
+
+	groups := map[string]config.GroupConfig{
+		"danger": {Plugins: []string{"dns"}, Hidden: true},
+	}
+	registerGroups(root, groups, []pluginpkg.DiscoveredPlugin{fakeDiscoveredPlugin("dns")}, fakeGenerateFn)
+
+	cmd, _, err := root.Find([]string{"danger", "dns", "check"})
+	if err != nil || cmd.Use != "check" {
+		t.Fatalf("expected hidden group to remain invocable, got %v, %v", cmd, err)
+	}
+	if !cmd.Parent().Parent().Hidden {
+		t.Error("expected group command to be hidden")
+	}
+}
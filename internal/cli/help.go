@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// enumAnnotation is the flag annotation holding a flag's allowed values.
+const enumAnnotation = "tack_enum"
+
+// commandSpec is the machine-readable description of a command.
+type commandSpec struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Short    string        `json:"short,omitempty"`
+	Long     string        `json:"long,omitempty"`
+	Usage    string        `json:"usage"`
+	Aliases  []string      `json:"aliases,omitempty"`
+	Example  string        `json:"example,omitempty"`
+	Runnable bool          `json:"runnable"`
+	Flags    []flagSpec    `json:"flags,omitempty"`
+	Commands []commandSpec `json:"commands,omitempty"`
+}
+
+// flagSpec is the machine-readable description of a flag.
+type flagSpec struct {
+	Name       string   `json:"name"`
+	Shorthand  string   `json:"shorthand,omitempty"`
+	Type       string   `json:"type"`
+	Default    string   `json:"default,omitempty"`
+	Usage      string   `json:"usage,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+	Persistent bool     `json:"persistent,omitempty"`
+	Enum       []string `json:"enum,omitempty"`
+}
+
+// installJSONHelp makes "--help --output json" print the command tree below
+// the requested command as JSON instead of help text.
+func installJSONHelp(root *cobra.Command) {
+	defaultHelp := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if f := cmd.Flag("output"); f != nil && f.Value.String() == "json" {
+			if err := writeJSONHelp(cmd.OutOrStdout(), cmd); err == nil {
+				return
+			}
+		}
+		defaultHelp(cmd, args)
+	})
+}
+
+// writeJSONHelp writes the description of cmd and its subcommands.
+func writeJSONHelp(w io.Writer, cmd *cobra.Command) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(describeCommand(cmd))
+}
+
+// describeCommand builds the spec for cmd, recursing into visible subcommands.
+func describeCommand(cmd *cobra.Command) commandSpec {
+	spec := commandSpec{
+		Name:     cmd.Name(),
+		Path:     cmd.CommandPath(),
+		Short:    cmd.Short,
+		Long:     cmd.Long,
+		Usage:    cmd.UseLine(),
+		Aliases:  cmd.Aliases,
+		Example:  cmd.Example,
+		Runnable: cmd.Runnable(),
+	}
+
+	persistent := map[string]bool{}
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) { persistent[f.Name] = true })
+	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { persistent[f.Name] = true })
+
+	addFlag := func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		spec.Flags = append(spec.Flags, flagSpec{
+			Name:       f.Name,
+			Shorthand:  f.Shorthand,
+			Type:       f.Value.Type(),
+			Default:    f.DefValue,
+			Usage:      f.Usage,
+			Required:   len(f.Annotations[cobra.BashCompOneRequiredFlag]) > 0,
+			Persistent: persistent[f.Name],
+			Enum:       f.Annotations[enumAnnotation],
+		})
+	}
+	cmd.LocalFlags().VisitAll(addFlag)
+	cmd.InheritedFlags().VisitAll(addFlag)
+	sort.Slice(spec.Flags, func(i, j int) bool { return spec.Flags[i].Name < spec.Flags[j].Name })
+
+	for _, sub := range cmd.Commands() {
+		if sub.Hidden || !sub.IsAvailableCommand() {
+			continue
+		}
+		spec.Commands = append(spec.Commands, describeCommand(sub))
+	}
+	return spec
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestJSONHelp(t *testing.T) {
+	root := &cobra.Command{Use: "tack"}
+	root.PersistentFlags().String("output", "table", "Output format")
+
+	op := &cobra.Command{Use: "resolve", Short: "Resolve hostname", Example: "  tack dns resolve", RunE: func(*cobra.Command, []string) error { return nil }}
+	op.Flags().String("record-type", "A", "Record type")
+	_ = op.Flags().SetAnnotation("record-type", enumAnnotation, []string{"A", "AAAA"})
+	op.Flags().String("hostname", "", "Hostname")
+	_ = op.MarkFlagRequired("hostname")
+
+	dns := &cobra.Command{Use: "dns"}
+	dns.AddCommand(op)
+	root.AddCommand(dns)
+	root.InitDefaultHelpFlag()
+	installJSONHelp(root)
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"dns", "--help", "--output", "json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	var spec commandSpec
+	if err := json.Unmarshal(buf.Bytes(), &spec); err != nil {
+		t.Fatalf("expected JSON help, got %q: %v", buf.String(), err)
+	}
+	if spec.Path != "tack dns" || len(spec.Commands) != 1 {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+
+	resolve := spec.Commands[0]
+	if !resolve.Runnable || resolve.Example == "" {
+		t.Errorf("expected runnable command with example: %+v", resolve)
+	}
+	flags := map[string]flagSpec{}
+	for _, f := range resolve.Flags {
+		flags[f.Name] = f
+	}
+	if !flags["hostname"].Required {
+		t.Error("expected hostname to be required")
+	}
+	if rt := flags["record-type"]; rt.Default != "A" || len(rt.Enum) != 2 {
+		t.Errorf("unexpected record-type flag: %+v", rt)
+	}
+	if !flags["output"].Persistent {
+		t.Error("expected output to be marked persistent")
+	}
+}
+
+func TestJSONHelp_TextByDefault(t *testing.T) {
+	root := &cobra.Command{Use: "tack", Short: "One CLI"}
+	root.PersistentFlags().String("output", "table", "Output format")
+	installJSONHelp(root)
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"--help"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if json.Valid(buf.Bytes()) {
+		t.Errorf("expected text help, got JSON")
+	}
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// newIndexCommand creates the "index" command group.
+func newIndexCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Build plugin indexes",
+	}
+	cmd.AddCommand(newIndexGenerateCommand(cfg))
+	return cmd
+}
+
+// newIndexGenerateCommand creates the "index generate" command.
+func newIndexGenerateCommand(cfg *config.Config) *cobra.Command {
+	var (
+		out        string
+		registry   string
+		repository string
+		plainHTTP  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate <registry/namespace|dir>",
+		Short: "Generate an index.json from a registry namespace or a directory of plugins",
+		Long: fmt.Sprintf(`Generate a plugin index from every plugin published under a registry
+namespace, or from the .wasm files in a directory.
+
+For a registry, each repository's highest version tag is read; the plugin
+manifest is taken from the artifact without downloading the module. The
+registry must support listing repositories. For a directory, each module is
+loaded to read its manifest, and the newest version of each plugin is kept.
+
+When --out names an existing index, categories and tags already recorded
+for a plugin are kept, so they can be maintained by hand.
+
+Examples:
+  %s index generate ghcr.io/my-org/plugins -o index.json
+  %s index generate ./dist --registry ghcr.io/my-org/plugins -o index.json`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			source := args[0]
+
+			var manifests []abi.Manifest
+			if info, err := os.Stat(source); err == nil && info.IsDir() {
+				if registry == "" {
+					registry = cfg.DefaultRegistry
+				}
+				manifests, err = scanPluginDir(ctx, source, inspectPlugin)
+				if err != nil {
+					return err
+				}
+			} else {
+				if registry == "" {
+					registry = strings.TrimSuffix(source, "/")
+				}
+				scan, err := internalplugin.ScanRegistry(ctx, source, internalplugin.NewCredentialProvider(), plainHTTP || cfg.IsPlainHTTPRegistry(source))
+				if err != nil {
+					return err
+				}
+				for _, repo := range sortedKeys(scan.Skipped) {
+					fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", repo, scan.Skipped[repo])
+				}
+				manifests = scan.Manifests
+			}
+
+			var previous *internalplugin.PluginIndex
+			if out != "" {
+				if data, err := os.ReadFile(out); err == nil {
+					previous = &internalplugin.PluginIndex{}
+					if err := json.Unmarshal(data, previous); err != nil {
+						return fmt.Errorf("parsing existing index %s: %w", out, err)
+					}
+				}
+			}
+
+			idx := buildIndex(manifests, previous)
+			idx.Registry = registry
+			idx.Repository = repository
+			idx.Updated = time.Now().UTC().Format(time.RFC3339)
+
+			data, err := json.MarshalIndent(idx, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encoding index: %w", err)
+			}
+			data = append(data, '\n')
+
+			if out == "" {
+				_, err := cmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err := os.WriteFile(out, data, 0o644); err != nil {
+				return fmt.Errorf("writing index: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d plugins to %s\n", len(idx.Plugins), out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Write the index to this file instead of stdout")
+	cmd.Flags().StringVar(&registry, "registry", "", "Registry prefix recorded in the index (default: the scanned namespace, or default_registry for directories)")
+	cmd.Flags().StringVar(&repository, "repository", "", "Source repository URL recorded in the index")
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "Use HTTP instead of HTTPS (local registries)")
+	return cmd
+}
+
+// scanPluginDir reads the manifest of every .wasm file under dir, keeping
+// the newest version of each plugin. Files that fail to load are skipped
+// with a warning.
+func scanPluginDir(ctx context.Context, dir string, inspect func(context.Context, []byte) (abi.Manifest, error)) ([]abi.Manifest, error) {
+	newest := map[string]abi.Manifest{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".wasm" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		m, err := inspect(ctx, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+			return nil
+		}
+		if m.Name == "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: manifest has no name\n", path)
+			return nil
+		}
+		if prev, ok := newest[m.Name]; !ok || internalplugin.NewerVersion(m.Version, prev.Version) {
+			newest[m.Name] = m
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+	if len(newest) == 0 {
+		return nil, errors.New("no plugins found in " + dir)
+	}
+
+	manifests := make([]abi.Manifest, 0, len(newest))
+	for _, name := range sortedKeys(newest) {
+		manifests = append(manifests, newest[name])
+	}
+	return manifests, nil
+}
+
+// buildIndex creates index entries for manifests, sorted by name. Categories
+// and tags are carried over from previous when it lists the same plugin, as
+// are its advisories.
+func buildIndex(manifests []abi.Manifest, previous *internalplugin.PluginIndex) *internalplugin.PluginIndex {
+	known := map[string]internalplugin.PluginEntry{}
+	if previous != nil {
+		for _, p := range previous.Plugins {
+			known[p.Name] = p
+		}
+	}
+
+	idx := &internalplugin.PluginIndex{Plugins: []internalplugin.PluginEntry{}}
+	if previous != nil {
+		idx.Advisories = previous.Advisories
+		idx.AdvisoriesURL = previous.AdvisoriesURL
+	}
+	for _, m := range manifests {
+		entry := internalplugin.NewIndexEntry(m)
+		if p, ok := known[m.Name]; ok {
+			entry.Categories = p.Categories
+			entry.Tags = p.Tags
+		}
+		idx.Plugins = append(idx.Plugins, entry)
+	}
+	sort.Slice(idx.Plugins, func(i, j int) bool { return idx.Plugins[i].Name < idx.Plugins[j].Name })
+	return idx
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func TestScanPluginDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dns@1.2.0.wasm":  `{"name":"dns","version":"1.2.0"}`,
+		"dns@1.10.0.wasm": `{"name":"dns","version":"1.10.0"}`,
+		"sub/http.wasm":   `{"name":"http","version":"0.1.0"}`,
+		"broken.wasm":     `not a plugin`,
+		"README.md":       `{"name":"ignored"}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The fake inspector treats each file's contents as its manifest.
+	inspect := func(_ context.Context, data []byte) (abi.Manifest, error) {
+		var m abi.Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return m, errors.New("invalid module")
+		}
+		return m, nil
+	}
+
+	manifests, err := scanPluginDir(context.Background(), dir, inspect)
+	if err != nil {
+		t.Fatalf("scanPluginDir: %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 plugins, got %+v", manifests)
+	}
+	if manifests[0].Name != "dns" || manifests[0].Version != "1.10.0" {
+		t.Errorf("expected newest dns, got %+v", manifests[0])
+	}
+	if manifests[1].Name != "http" {
+		t.Errorf("expected http from a subdirectory, got %+v", manifests[1])
+	}
+
+	if _, err := scanPluginDir(context.Background(), t.TempDir(), inspect); err == nil {
+		t.Error("expected error for a directory without plugins")
+	}
+}
+
+func TestBuildIndexKeepsCategories(t *testing.T) {
+	previous := &internalplugin.PluginIndex{Plugins: []internalplugin.PluginEntry{
+		{Name: "dns", Latest: "1.0.0", Categories: []string{"network"}, Tags: []string{"resolver"}},
+		{Name: "gone", Latest: "0.1.0"},
+	}}
+	manifests := []abi.Manifest{
+		{Name: "tcp", Version: "0.2.0", Description: "TCP checks"},
+		{Name: "dns", Version: "1.1.0", Description: "DNS checks"},
+	}
+
+	idx := buildIndex(manifests, previous)
+	if len(idx.Plugins) != 2 || idx.Plugins[0].Name != "dns" || idx.Plugins[1].Name != "tcp" {
+		t.Fatalf("expected dns and tcp in name order, got %+v", idx.Plugins)
+	}
+	dns := idx.Plugins[0]
+	if dns.Latest != "1.1.0" || dns.Description != "DNS checks" {
+		t.Errorf("expected dns from its manifest, got %+v", dns)
+	}
+	if len(dns.Categories) != 1 || dns.Categories[0] != "network" || len(dns.Tags) != 1 {
+		t.Errorf("expected categories and tags carried over, got %+v", dns)
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/logfile"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// LogFileFlag names the persistent flag that writes logs to a file.
+const LogFileFlag = "log-file"
+
+// logging is the log file of this process, while one is open.
+var logging struct {
+	mu   sync.Mutex
+	file *logfile.Writer
+	stop func()
+}
+
+// LogPath returns the log file the config or the --log-file flag value
+// selects, or "" when logging to a file is off.
+func LogPath(cfg *config.Config, flagPath string) string {
+	switch {
+	case flagPath != "":
+		return flagPath
+	case cfg.Log.Path != "":
+		return cfg.Log.Path
+	case cfg.Log.Enabled:
+		return logfile.DefaultPath()
+	}
+	return ""
+}
+
+// StartLogging tees everything written to stderr, and a line per plugin
+// host function call, to the rotating log file at path, after a line naming
+// the command being run. Call StopLogging, or exit through Exit, so the
+// last lines reach the file.
+func StartLogging(cfg *config.Config, path string, args []string) error {
+	maxSize, err := cfg.LogMaxSize()
+	if err != nil {
+		return err
+	}
+	w, err := logfile.Open(path, maxSize, cfg.Log.Keep)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "--- %s (pid %d)\n", pluginpkg.RedactSecrets(strings.Join(args, " ")), os.Getpid())
+	stop, err := teeStderr(w)
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	runtime.SetHostCallLog(w)
+
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	logging.file, logging.stop = w, stop
+	return nil
+}
+
+// LogWriter returns the open log file, or nil when there is none. What is
+// written to it does not appear on stderr.
+func LogWriter() io.Writer {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	if logging.file == nil {
+		return nil
+	}
+	return logging.file
+}
+
+// StopLogging flushes and closes the log file, if one is open.
+func StopLogging() {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	if logging.file == nil {
+		return
+	}
+	runtime.SetHostCallLog(nil)
+	logging.stop()
+	_ = logging.file.Close()
+	logging.file, logging.stop = nil, nil
+}
+
+// Exit stops logging and exits with code.
+func Exit(code int) {
+	StopLogging()
+	os.Exit(code)
+}
+
+// teeStderr replaces os.Stderr with a pipe copied to both the original
+// stderr and w, so output written straight to os.Stderr, such as plugin
+// logs from the WASM host, is captured too. The returned func restores
+// os.Stderr and waits for the copy to finish.
+func teeStderr(w io.Writer) (func(), error) {
+	orig := os.Stderr
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("capturing stderr: %w", err)
+	}
+	os.Stderr = pw
+	log.SetOutput(pw)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The log file must not hold up the terminal, so its errors are
+		// ignored
+		_, _ = io.Copy(io.MultiWriter(orig, ignoreErrors{w}), r)
+		_ = r.Close()
+	}()
+	return func() {
+		os.Stderr = orig
+		log.SetOutput(orig)
+		_ = pw.Close()
+		<-done
+	}, nil
+}
+
+// ignoreErrors is a writer that reports every write as successful.
+type ignoreErrors struct{ w io.Writer }
+
+func (i ignoreErrors) Write(p []byte) (int, error) {
+	_, _ = i.w.Write(p)
+	return len(p), nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestLogPath(t *testing.T) {
+	cfg := &config.Config{}
+	if got := LogPath(cfg, ""); got != "" {
+		t.Errorf("expected logging off, got %q", got)
+	}
+	if got := LogPath(cfg, "/tmp/a.log"); got != "/tmp/a.log" {
+		t.Errorf("flag path = %q", got)
+	}
+	cfg.Log.Enabled = true
+	if got := LogPath(cfg, ""); got == "" {
+		t.Error("expected the default path when enabled")
+	}
+	cfg.Log.Path = "/var/log/tack.log"
+	if got := LogPath(cfg, ""); got != "/var/log/tack.log" {
+		t.Errorf("config path = %q", got)
+	}
+}
+
+func TestTeeStderr(t *testing.T) {
+	var log bytes.Buffer
+	stop, err := teeStderr(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(os.Stderr, "Warning: captured")
+	stop()
+
+	if log.String() != "Warning: captured\n" {
+		t.Errorf("log = %q", log.String())
+	}
+}
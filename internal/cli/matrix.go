@@ -0,0 +1,346 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	"github.com/whiskeyjimb/tack-cli/internal/workers"
+	"gopkg.in/yaml.v3"
+)
+
+// matrixFlag runs an operation once per combination of flag values.
+const matrixFlag = "matrix"
+
+// matrixDimension is a flag and the values a matrix run gives it.
+type matrixDimension struct {
+	Field  string   `json:"field" yaml:"field"`
+	Values []string `json:"values" yaml:"values"`
+}
+
+// matrixResult is one combination's outcome in a matrix report.
+type matrixResult struct {
+	Values   map[string]string `json:"values" yaml:"values"`
+	Status   string            `json:"status" yaml:"status"`
+	Duration time.Duration     `json:"duration_ns" yaml:"duration"`
+	Message  string            `json:"message,omitempty" yaml:"message,omitempty"`
+	Data     map[string]any    `json:"data,omitempty" yaml:"data,omitempty"`
+
+	label  string
+	result abi.Result
+}
+
+// matrixReport aggregates an operation run once per combination.
+type matrixReport struct {
+	Plugin     string            `json:"plugin" yaml:"plugin"`
+	Service    string            `json:"service,omitempty" yaml:"service,omitempty"`
+	Operation  string            `json:"operation" yaml:"operation"`
+	Dimensions []matrixDimension `json:"dimensions" yaml:"dimensions"`
+	Results    []matrixResult    `json:"results" yaml:"results"`
+	Duration   time.Duration     `json:"duration_ns" yaml:"duration"`
+}
+
+// failed returns the number of combinations that did not succeed.
+func (r *matrixReport) failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status != string(abi.ResultStatusSuccess) {
+			n++
+		}
+	}
+	return n
+}
+
+// addMatrixFlag adds --matrix to an operation command, unless the plugin
+// has a "matrix" field of its own.
+func addMatrixFlag(cmd *cobra.Command) {
+	if cmd.Flags().Lookup(matrixFlag) != nil {
+		return
+	}
+	cmd.Flags().StringArray(matrixFlag, nil, "Run once per combination of flag values, as field=[a,b] other=[c,d] (repeatable)")
+	_ = cmd.Flags().SetAnnotation(matrixFlag, fanOutAnnotation, []string{"true"})
+}
+
+// matrixDimensions returns the dimensions given with --matrix, or nil.
+func matrixDimensions(cmd *cobra.Command) ([]matrixDimension, error) {
+	if _, ok := fanOutFlag(cmd, matrixFlag); !ok {
+		return nil, nil
+	}
+	specs, _ := cmd.Flags().GetStringArray(matrixFlag)
+	return parseMatrix(specs)
+}
+
+// parseMatrix parses matrix specs such as "region=[us-east-1,eu-west-1]
+// record_type=[A,AAAA]". Dimensions are separated by whitespace outside
+// brackets; the brackets may be left off a value list without spaces.
+// Fields are given as config fields or flag names.
+func parseMatrix(specs []string) ([]matrixDimension, error) {
+	var dims []matrixDimension
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		for _, token := range splitMatrixSpec(spec) {
+			key, list, ok := strings.Cut(token, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid --%s %q: expected field=[value,...]", matrixFlag, token)
+			}
+			field := strings.ReplaceAll(key, "_", "-")
+			if seen[field] {
+				return nil, fmt.Errorf("invalid --%s: %s is given more than once", matrixFlag, key)
+			}
+			seen[field] = true
+
+			list = strings.TrimSuffix(strings.TrimPrefix(list, "["), "]")
+			var values []string
+			for _, v := range strings.Split(list, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 {
+				return nil, fmt.Errorf("invalid --%s %q: no values for %s", matrixFlag, token, key)
+			}
+			dims = append(dims, matrixDimension{Field: field, Values: values})
+		}
+	}
+	return dims, nil
+}
+
+// splitMatrixSpec splits a spec on whitespace outside brackets.
+func splitMatrixSpec(spec string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		depth   int
+	)
+	for _, r := range spec {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// prepareMatrix checks the values of each dimension against its flag and
+// sets each flag to its first value, as prepareFanOut does for targets.
+func prepareMatrix(cmd *cobra.Command, schema *parsedSchema, dims []matrixDimension) error {
+	if len(dims) == 0 {
+		return nil
+	}
+	if targets, _ := fanOutFlag(cmd, targetsFlag); targets != "" || cmd.Flags().Changed(targetFlag) {
+		return fmt.Errorf("--%s cannot be combined with --%s or --%s", matrixFlag, targetFlag, targetsFlag)
+	}
+	for _, d := range dims {
+		if err := prepareSubstitution(cmd, schema, d.Field, d.Values, "--"+matrixFlag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrixCombinations returns every combination of the dimensions' values,
+// one value per dimension in dimension order, the last varying fastest.
+func matrixCombinations(dims []matrixDimension) [][]string {
+	combos := [][]string{nil}
+	for _, d := range dims {
+		next := make([][]string, 0, len(combos)*len(d.Values))
+		for _, combo := range combos {
+			for _, v := range d.Values {
+				next = append(next, append(append([]string(nil), combo...), v))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// runMatrix runs an operation once per combination of the dimensions'
+// values, at most parallel at a time. Results keep combination order.
+// Combinations usually request the same capabilities; the runtime reviews
+// them once, and the rest reuse what that review remembered.
+func runMatrix(
+	ctx context.Context,
+	config map[string]any,
+	schema *parsedSchema,
+	dims []matrixDimension,
+	parallel int,
+	execute func(ctx context.Context, config map[string]any) (abi.Result, error),
+) []matrixResult {
+	combos := matrixCombinations(dims)
+	results := make([]matrixResult, len(combos))
+	workers.ForEach(len(combos), parallel, func(i int) {
+		cfg := copyConfig(config)
+		values := make(map[string]string, len(dims))
+		labels := make([]string, len(dims))
+		for j, d := range dims {
+			prop, _ := schema.property(d.Field)
+			setField(cfg, d.Field, coerceDefault(prop, combos[i][j]))
+			values[d.Field] = combos[i][j]
+			labels[j] = d.Field + "=" + combos[i][j]
+		}
+		r := fanOutRun(ctx, strings.Join(labels, " "), cfg, execute)
+		results[i] = matrixResult{
+			Values: values, Status: r.Status, Duration: r.Duration, Message: r.Message, Data: r.Data,
+			label: r.Target, result: r.result,
+		}
+	})
+	return results
+}
+
+// runOperationMatrix runs an operation command once per combination and
+// reports the results together, exiting non-zero if any combination did
+// not succeed.
+func runOperationMatrix(
+	cmd *cobra.Command,
+	pluginName, serviceName string,
+	op abi.OperationManifest,
+	isMulti bool,
+	outputFormat string,
+	schema *parsedSchema,
+	config map[string]any,
+	dims []matrixDimension,
+	execute func(ctx context.Context, config map[string]any) (abi.Result, error),
+) error {
+	parallel := 1
+	if v, ok := fanOutFlag(cmd, parallelFlag); ok {
+		parallel, _ = strconv.Atoi(v)
+	}
+	show, _ := cmd.Flags().GetBool(ShowSecretsFlag)
+
+	start := time.Now()
+	results := runMatrix(cmd.Context(), config, schema, dims, parallel, func(ctx context.Context, config map[string]any) (abi.Result, error) {
+		result, err := execute(ctx, config)
+		if !show {
+			result.Data = output.MaskSensitive(result.Data, op.OutputSchema)
+		}
+		return result, err
+	})
+	report := &matrixReport{Plugin: pluginName, Operation: op.Name, Dimensions: dims, Results: results, Duration: time.Since(start)}
+	if isMulti {
+		report.Service = serviceName
+	}
+
+	format := resultFormat(outputFormat, cmd.Flags().Changed("output"))
+	if err := renderMatrixReport(os.Stdout, format, report, op.OutputSchema, output.SeverityHint(op.OutputSchema)); err != nil {
+		return err
+	}
+	if report.failed() > 0 {
+		Exit(1)
+	}
+	return nil
+}
+
+// renderMatrixReport writes a matrix report in the given output format.
+// The table lists each combination; with two dimensions it is followed by
+// a grid of statuses, the first dimension down and the second across.
+func renderMatrixReport(w io.Writer, format string, report *matrixReport, schema json.RawMessage, severity string) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		labels := make([]string, len(report.Results))
+		results := make([]abi.Result, len(report.Results))
+		for i, r := range report.Results {
+			labels[i], results[i] = r.label, r.result
+		}
+		if err := (&output.TableFormatter{}).FormatTargets(w, "Combination", labels, results, schema); err != nil {
+			return err
+		}
+		if len(report.Dimensions) == 2 {
+			_, _ = fmt.Fprintln(w)
+			if err := writeMatrixGrid(w, report); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintf(w, "\nRan %s for %d combinations in %s (%d failed)\n",
+			report.Operation, len(report.Results), report.Duration.Round(time.Millisecond), report.failed())
+		return nil
+	case "gha":
+		if err := renderMatrixReport(w, "table", report, schema, severity); err != nil {
+			return err
+		}
+		checks := matrixChecks(report, severity)
+		if err := output.WriteAnnotations(w, checks); err != nil {
+			return err
+		}
+		return output.WriteStepSummary(fmt.Sprintf("%s %s matrix", report.Plugin, report.Operation), checks)
+	case "codequality":
+		return output.WriteCodeQuality(w, matrixChecks(report, severity))
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, gha, codequality, quiet)", format)
+	}
+}
+
+// writeMatrixGrid writes the status of each combination of a two-dimension
+// matrix as a grid.
+func writeMatrixGrid(w io.Writer, report *matrixReport) error {
+	rows, cols := report.Dimensions[0], report.Dimensions[1]
+	status := make(map[[2]string]string, len(report.Results))
+	for _, r := range report.Results {
+		status[[2]string{r.Values[rows.Field], r.Values[cols.Field]}] = r.Status
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := strings.ToUpper(rows.Field + " \\ " + cols.Field)
+	_, _ = fmt.Fprint(tw, header)
+	for _, c := range cols.Values {
+		_, _ = fmt.Fprintf(tw, "\t%s", c)
+	}
+	_, _ = fmt.Fprintln(tw)
+	for _, r := range rows.Values {
+		_, _ = fmt.Fprint(tw, r)
+		for _, c := range cols.Values {
+			_, _ = fmt.Fprintf(tw, "\t%s", status[[2]string{r, c}])
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// matrixChecks converts a matrix report into one check per combination.
+func matrixChecks(report *matrixReport, severity string) []output.Check {
+	checks := make([]output.Check, 0, len(report.Results))
+	for _, r := range report.Results {
+		checks = append(checks, output.Check{
+			Name:      fmt.Sprintf("%s %s %s", report.Plugin, report.Operation, r.label),
+			Status:    r.Status,
+			Message:   r.Message,
+			Duration:  r.Duration,
+			Plugin:    report.Plugin,
+			Service:   report.Service,
+			Operation: report.Operation,
+			Severity:  severity,
+		})
+	}
+	return checks
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+)
+
+func TestParseMatrix(t *testing.T) {
+	dims, err := parseMatrix([]string{"region=[us-east-1, eu-west-1] record_type=[A,AAAA]", "port=80,443"})
+	if err != nil {
+		t.Fatalf("parseMatrix: %v", err)
+	}
+	want := []matrixDimension{
+		{Field: "region", Values: []string{"us-east-1", "eu-west-1"}},
+		{Field: "record-type", Values: []string{"A", "AAAA"}},
+		{Field: "port", Values: []string{"80", "443"}},
+	}
+	if len(dims) != len(want) {
+		t.Fatalf("got %+v, want %+v", dims, want)
+	}
+	for i := range want {
+		if dims[i].Field != want[i].Field || strings.Join(dims[i].Values, ",") != strings.Join(want[i].Values, ",") {
+			t.Errorf("dimension %d = %+v, want %+v", i, dims[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"region", "=[a]", "region=[]", "region=[a] region=[b]"} {
+		if _, err := parseMatrix([]string{bad}); err == nil {
+			t.Errorf("parseMatrix(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	combos := matrixCombinations([]matrixDimension{
+		{Field: "region", Values: []string{"us", "eu"}},
+		{Field: "type", Values: []string{"A", "AAAA", "MX"}},
+	})
+	var got []string
+	for _, c := range combos {
+		got = append(got, strings.Join(c, "/"))
+	}
+	want := "us/A us/AAAA us/MX eu/A eu/AAAA eu/MX"
+	if strings.Join(got, " ") != want {
+		t.Errorf("combinations = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestRunMatrix(t *testing.T) {
+	schema := mustParseSchema(t, `{"properties":{"region":{"type":"string"},"port":{"type":"integer"}}}`)
+	dims := []matrixDimension{
+		{Field: "region", Values: []string{"us", "eu"}},
+		{Field: "port", Values: []string{"80", "443"}},
+	}
+	results := runMatrix(context.Background(), map[string]any{"operation": "connect"}, schema, dims, 2,
+		func(ctx context.Context, config map[string]any) (abi.Result, error) {
+			if config["region"] == "eu" && config["port"] == 443 {
+				return abi.ResultFailure("refused", nil), nil
+			}
+			return abi.ResultSuccess("ok", nil), nil
+		})
+
+	report := &matrixReport{Plugin: "tcp", Operation: "connect", Dimensions: dims, Results: results}
+	if len(results) != 4 || report.failed() != 1 || results[3].Values["region"] != "eu" || results[3].Status != "failure" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	var buf bytes.Buffer
+	if err := renderMatrixReport(&buf, "table", report, nil, ""); err != nil {
+		t.Fatalf("renderMatrixReport: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"region=eu port=443", "REGION \\ PORT", "Ran connect for 4 combinations"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCommand_ForwardsMatrix(t *testing.T) {
+	root := &cobra.Command{Use: "tack", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(newRunCommand())
+	plugin := &cobra.Command{Use: "dns"}
+	var got []string
+	op := &cobra.Command{
+		Use: "resolve",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			got, _ = cmd.Flags().GetStringArray(matrixFlag)
+			host, _ := cmd.Flags().GetString("hostname")
+			got = append(got, host)
+			return nil
+		},
+	}
+	op.Flags().String("hostname", "", "")
+	addMatrixFlag(op)
+	plugin.AddCommand(op)
+	root.AddCommand(plugin)
+
+	root.SetArgs([]string{"run", "--matrix", "record_type=[A,AAAA]", "dns", "resolve", "--hostname", "example.com"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if strings.Join(got, " ") != "record_type=[A,AAAA] example.com" {
+		t.Errorf("operation got %q", got)
+	}
+
+	root.SetArgs([]string{"run", "dns", "resolve"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "--matrix is required") {
+		t.Errorf("expected a missing --matrix error, got %v", err)
+	}
+	root.SetArgs([]string{"run", "--matrix", "a=[1]", "dns"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "not a plugin operation") {
+		t.Errorf("expected a not-an-operation error, got %v", err)
+	}
+}
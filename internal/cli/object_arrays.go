@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// objectArrayAnnotation marks the flags that collect an array-of-objects
+// field. It holds the field's flag name.
+const objectArrayAnnotation = "tack_object_array"
+
+// addObjectArrayFlags adds the flags for an array whose items are objects:
+// a repeatable flag taking one JSON object per use, such as
+// --rules '{"host":"*","port":80}', and a -file flag naming a YAML or JSON
+// list, such as --rules-file rules.yaml. File items come first.
+func addObjectArrayFlags(cmd *cobra.Command, flagName string, prop schemaProperty, required bool) {
+	usage := prop.Description
+	if usage != "" {
+		usage += " "
+	}
+	cmd.Flags().StringArray(flagName, nil, usage+"(JSON object, repeatable)")
+	_ = cmd.Flags().SetAnnotation(flagName, objectArrayAnnotation, []string{flagName})
+
+	fileFlag := flagName + "-file"
+	if cmd.Flags().Lookup(fileFlag) != nil {
+		if required {
+			_ = cmd.MarkFlagRequired(flagName)
+		}
+		return
+	}
+	cmd.Flags().String(fileFlag, "", fmt.Sprintf("YAML or JSON file with a list of --%s objects", flagName))
+	_ = cmd.Flags().SetAnnotation(fileFlag, objectArrayAnnotation, []string{flagName})
+	_ = cmd.MarkFlagFilename(fileFlag, "yaml", "yml", "json")
+	if required {
+		cmd.MarkFlagsOneRequired(flagName, fileFlag)
+	}
+}
+
+// applyObjectArrays parses the array-of-objects flags set on the command,
+// validates each item against the field's items schema, and stores the
+// items in config.
+func applyObjectArrays(cmd *cobra.Command, schema *parsedSchema, config map[string]any) error {
+	items := map[string][]any{}
+	var errs []error
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		field, ok := f.Annotations[objectArrayAnnotation]
+		if !ok || len(field) == 0 {
+			return
+		}
+		flagName := field[0]
+		var parsed []any
+		var err error
+		if f.Name == flagName {
+			values, _ := cmd.Flags().GetStringArray(f.Name)
+			parsed, err = parseObjectFlags(flagName, values)
+		} else {
+			path, _ := cmd.Flags().GetString(f.Name)
+			parsed, err = readObjectFile(f.Name, path)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if f.Name == flagName {
+			items[flagName] = append(items[flagName], parsed...)
+		} else {
+			items[flagName] = append(parsed, items[flagName]...)
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	flagNames := make([]string, 0, len(items))
+	for name := range items {
+		flagNames = append(flagNames, name)
+	}
+	sort.Strings(flagNames)
+	for _, flagName := range flagNames {
+		if schema != nil {
+			if prop, ok := schema.property(flagName); ok && prop.Items != nil {
+				for i, item := range items[flagName] {
+					errs = append(errs, validateObject(fmt.Sprintf("%s[%d]", flagName, i), *prop.Items, item.(map[string]any)))
+				}
+			}
+		}
+		setField(config, flagName, items[flagName])
+	}
+	return errors.Join(errs...)
+}
+
+// parseObjectFlags decodes the JSON object passed to each use of a flag.
+func parseObjectFlags(flagName string, values []string) ([]any, error) {
+	items := make([]any, 0, len(values))
+	for _, v := range values {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(v), &obj); err != nil || obj == nil {
+			return nil, fmt.Errorf("invalid value %q for --%s: must be a JSON object", v, flagName)
+		}
+		items = append(items, obj)
+	}
+	return items, nil
+}
+
+// readObjectFile reads a YAML or JSON list of objects.
+func readObjectFile(flagName, path string) ([]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading --%s: %w", flagName, err)
+	}
+	var list []map[string]any
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing --%s %s: must be a list of objects: %w", flagName, path, err)
+	}
+	items := make([]any, len(list))
+	for i, obj := range list {
+		items[i] = obj
+	}
+	return items, nil
+}
+
+// validateObject checks an object against an object schema: its required
+// fields must be present and each declared field must satisfy its
+// property. Errors name the field as flag[index].field.
+func validateObject(path string, prop schemaProperty, obj map[string]any) error {
+	var errs []error
+	for _, r := range prop.Required {
+		if _, ok := obj[r]; !ok {
+			errs = append(errs, fmt.Errorf("invalid value for --%s: missing required field %q", path, r))
+		}
+	}
+	fields := make([]string, 0, len(obj))
+	for name := range obj {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	for _, name := range fields {
+		fieldProp, ok := prop.Properties[name]
+		if !ok {
+			continue
+		}
+		fieldPath := path + "." + name
+		switch v := obj[name].(type) {
+		case map[string]any:
+			errs = append(errs, validateObject(fieldPath, fieldProp, v))
+		default:
+			errs = append(errs, validateValue(fieldPath, fieldProp, v))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestObjectArrayFlags(t *testing.T) {
+	schema, err := parseConfigSchema(json.RawMessage(`{
+		"properties": {
+			"rules": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"host": {"type": "string"},
+						"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+					},
+					"required": ["host"]
+				}
+			}
+		},
+		"required": ["rules"]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfigSchema: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(file, []byte("- host: db.internal\n  port: 5432\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		addFlagsForOperation(cmd, schema, nil, nil)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	cmd := newCmd("--rules", `{"host":"*","port":80}`, "--rules-file", file)
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		t.Errorf("ValidateFlagGroups: %v", err)
+	}
+	config, err := operationConfig(cmd, schema, "svc", "op")
+	if err != nil {
+		t.Fatalf("operationConfig: %v", err)
+	}
+	rules, ok := config["rules"].([]any)
+	if !ok || len(rules) != 2 {
+		t.Fatalf("expected two rules, got %#v", config["rules"])
+	}
+	if first := rules[0].(map[string]any); first["host"] != "db.internal" {
+		t.Errorf("expected file rules first, got %#v", rules)
+	}
+	if _, ok := config["rules_file"]; ok {
+		t.Error("expected --rules-file not to become a config field")
+	}
+
+	if err := newCmd().ValidateFlagGroups(); err == nil {
+		t.Error("expected --rules or --rules-file to be required")
+	}
+
+	tests := map[string]string{
+		`not json`:              "must be a JSON object",
+		`{"port":80}`:           `missing required field "host"`,
+		`{"host":"*","port":0}`: "--rules[0].port: must be at least 1",
+	}
+	for value, want := range tests {
+		_, err := operationConfig(newCmd("--rules", value), schema, "svc", "op")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("--rules %s: expected error containing %q, got %v", value, want, err)
+		}
+	}
+}
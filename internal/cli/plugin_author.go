@@ -0,0 +1,644 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/pluginlint"
+	"github.com/whiskeyjimb/tack-cli/internal/plugintest"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"github.com/whiskeyjimb/tack-cli/internal/scaffold"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/v2/registry"
+)
+
+// newPluginNewCommand creates the "plugin new" command.
+func newPluginNewCommand() *cobra.Command {
+	var (
+		dir         string
+		module      string
+		description string
+		operations  []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Create a new plugin project",
+		Long: fmt.Sprintf(`Create a new plugin project with a manifest stub, config schema,
+example-driven tests, and a Makefile with wasm build targets.
+
+Operations are given as "operation" or "service/operation". Operations
+without a service belong to a service named after the plugin.
+
+Examples:
+  %s plugin new ping
+  %s plugin new ping --module github.com/me/ping --operation check --operation icmp/echo`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if dir == "" {
+				dir = name
+			}
+
+			files, err := scaffold.Generate(dir, scaffold.Options{
+				Name:        name,
+				Module:      module,
+				Description: description,
+				Operations:  operations,
+			})
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "Created plugin %q in %s\n", name, dir)
+			for _, f := range files {
+				_, _ = fmt.Fprintf(out, "  %s\n", filepath.Join(dir, f))
+			}
+			_, _ = fmt.Fprintf(out, "\nNext steps:\n  cd %s\n  go mod tidy\n  make test build install\n", dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "", "Directory to create (default: ./<name>)")
+	cmd.Flags().StringVar(&module, "module", "", "Go module path (default: <name>)")
+	cmd.Flags().StringVar(&description, "description", "", "Plugin description")
+	cmd.Flags().StringArrayVar(&operations, "operation", nil, "Operation to generate, as op or service/op (repeatable, default: check)")
+	return cmd
+}
+
+// newPluginBuildCommand creates the "plugin build" command. Building is
+// allowed in read-only mode; --install is not.
+func newPluginBuildCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var (
+		toolchain string
+		output    string
+		tags      []string
+		install   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "build [dir]",
+		Short: "Build a plugin project to WASM",
+		Long: fmt.Sprintf(`Build a plugin project for wasip1, then load the result to verify that
+its manifest can be read. The manifest is then stored in the binary's %s
+custom section, so discovery reads it without instantiating the plugin.
+
+The go toolchain builds with GOOS=wasip1 GOARCH=wasm -buildmode=c-shared.
+The tinygo toolchain builds with -target=wasip1 -buildmode=c-shared and
+produces smaller binaries.
+
+Examples:
+  %s plugin build
+  %s plugin build ./ping --toolchain tinygo --install`, internalplugin.ManifestSection, meta.AppName, meta.AppName),
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !install {
+				return nil
+			}
+			return denyInReadOnly(cfg)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(abs, filepath.Base(abs)+".wasm")
+			}
+
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "Building %s with %s ...\n", dir, toolchain)
+			if err := buildWASM(cmd.Context(), abs, toolchain, output, tags, cmd.ErrOrStderr()); err != nil {
+				return err
+			}
+
+			wasmBytes, err := os.ReadFile(output)
+			if err != nil {
+				return fmt.Errorf("reading build output: %w", err)
+			}
+			manifest, err := inspectPlugin(cmd.Context(), wasmBytes)
+			if err != nil {
+				return fmt.Errorf("built plugin is not loadable: %w", err)
+			}
+			if manifest.Name == "" {
+				return fmt.Errorf("built plugin manifest has no name")
+			}
+			wasmBytes, err = internalplugin.EmbedManifestSection(wasmBytes, manifest)
+			if err != nil {
+				return fmt.Errorf("embedding manifest: %w", err)
+			}
+			if err := os.WriteFile(output, wasmBytes, 0o644); err != nil {
+				return fmt.Errorf("writing build output: %w", err)
+			}
+
+			ops := 0
+			for _, svc := range manifest.Services {
+				ops += len(svc.Operations)
+			}
+			_, _ = fmt.Fprintf(out, "Built %s (%s %s, %d services, %d operations)\n",
+				output, manifest.Name, manifest.Version, len(manifest.Services), ops)
+
+			if install {
+				if stack == nil {
+					return fmt.Errorf("plugin cache is unavailable; cannot install")
+				}
+				return installFromLocalFile(cmd.Context(), stack, cfg, output, out)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&toolchain, "toolchain", "go", "Compiler toolchain: go or tinygo")
+	cmd.Flags().StringVarP(&output, "out", "o", "", "Output .wasm path (default: <dir>/<dir name>.wasm)")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Build tags")
+	cmd.Flags().BoolVar(&install, "install", false, "Install the built plugin into the local plugin cache")
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"go", "tinygo"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// buildWASM compiles the plugin project in dir to output, streaming compiler
+// output to log.
+func buildWASM(ctx context.Context, dir, toolchain, output string, tags []string, log io.Writer) error {
+	build, err := toolchainCommand(ctx, toolchain, output, tags)
+	if err != nil {
+		return err
+	}
+	build.Dir = dir
+	build.Stdout = log
+	build.Stderr = log
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("%s build failed: %w", toolchain, err)
+	}
+	return nil
+}
+
+// toolchainCommand returns the compiler invocation that builds the package in
+// the working directory into a wasip1 plugin at output.
+func toolchainCommand(ctx context.Context, toolchain, output string, tags []string) (*exec.Cmd, error) {
+	var args []string
+	var env []string
+	switch toolchain {
+	case "go":
+		args = []string{"build", "-buildmode=c-shared"}
+		env = []string{"GOOS=wasip1", "GOARCH=wasm"}
+	case "tinygo":
+		args = []string{"build", "-target=wasip1", "-buildmode=c-shared"}
+	default:
+		return nil, fmt.Errorf("unsupported toolchain %q (supported: go, tinygo)", toolchain)
+	}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+	args = append(args, "-o", output, ".")
+
+	if _, err := exec.LookPath(toolchain); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH: %w", toolchain, err)
+	}
+
+	c := exec.CommandContext(ctx, toolchain, args...)
+	c.Env = append(os.Environ(), env...)
+	return c, nil
+}
+
+// inspectPlugin reads a plugin's manifest in a throwaway runtime.
+func inspectPlugin(ctx context.Context, wasmBytes []byte) (abi.Manifest, error) {
+	runner, err := runtime.NewPluginRunner(ctx)
+	if err != nil {
+		return abi.Manifest{}, fmt.Errorf("creating runtime: %w", err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+
+	return runner.ReadManifest(ctx, wasmBytes)
+}
+
+// newPluginTestCommand creates the "plugin test" command.
+func newPluginTestCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var (
+		golden        bool
+		updateGolden  bool
+		goldenDir     string
+		goldenFormats []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test <path|name>",
+		Short: "Run a plugin's manifest examples as tests",
+		Long: fmt.Sprintf(`Run every example declared in a plugin's operation manifests against the
+real runtime.
+
+An example passes when its expected_error appears in the returned error,
+when every field of its expected_output matches the result data, or, if it
+declares neither, when the operation succeeds.
+
+The target is a .wasm file, a plugin project directory containing
+<dir name>.wasm, or the name of an installed plugin.
+
+--golden also compares each passing example's formatted output (json, yaml,
+and table by default) with golden files under testdata/golden next to the
+plugin; --update-golden records them.
+
+Examples:
+  %s plugin test ./ping.wasm
+  %s plugin test ./ping --update-golden
+  %s plugin test dns --output junit > report.xml`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			wasmBytes, err := loadPluginBytes(ctx, cfg, stack, args[0])
+			if err != nil {
+				return err
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			trustPlugins, _ := cmd.Flags().GetBool("trust-plugins")
+			runner, err := runtime.NewPluginRunner(ctx,
+				runtime.WithVerbose(verbose),
+				runtime.WithTrustPlugins(trustPlugins),
+			)
+			if err != nil {
+				return fmt.Errorf("creating runtime: %w", err)
+			}
+			defer func() { _ = runner.Close(ctx) }()
+
+			plugin, err := runner.LoadPlugin(ctx, wasmBytes)
+			if err != nil {
+				return err
+			}
+
+			timeout, err := cfg.OperationTimeout()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; running without a timeout\n", err)
+			}
+			var opts []plugintest.Option
+			if golden || updateGolden {
+				dir := goldenDir
+				if dir == "" {
+					if dir, err = defaultGoldenDir(args[0]); err != nil {
+						return err
+					}
+				}
+				opts = append(opts, plugintest.WithGolden(plugintest.Golden{
+					Dir:     dir,
+					Formats: goldenFormats,
+					Update:  updateGolden,
+				}))
+			}
+			report := plugintest.Run(ctx, plugin.Manifest, func(ctx context.Context, config map[string]any) (abi.Result, error) {
+				ctx, cancel := withTimeout(ctx, timeout)
+				defer cancel()
+				return plugin.Check(ctx, config)
+			}, opts...)
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			if err := renderTestReport(cmd.OutOrStdout(), format, report); err != nil {
+				return err
+			}
+
+			if report.GoldenUpdated > 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Updated %d golden files\n", report.GoldenUpdated)
+			}
+			if len(report.Cases) == 0 {
+				return fmt.Errorf("plugin %q declares no examples", report.Plugin)
+			}
+			if report.Failed > 0 {
+				return fmt.Errorf("%d of %d examples failed", report.Failed, len(report.Cases))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&golden, "golden", false, "Compare formatted outputs against golden files")
+	cmd.Flags().BoolVar(&updateGolden, "update-golden", false, "Write formatted outputs as golden files")
+	cmd.Flags().StringVar(&goldenDir, "golden-dir", "", "Golden file directory (default: testdata/golden next to the plugin)")
+	cmd.Flags().StringSliceVar(&goldenFormats, "golden-format", plugintest.DefaultGoldenFormats, "Output formats to record: json, yaml, table")
+	return cmd
+}
+
+// defaultGoldenDir returns testdata/golden inside a project directory or
+// next to a .wasm file. Installed plugins have no natural location.
+func defaultGoldenDir(target string) (string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", fmt.Errorf("--golden-dir is required for installed plugins")
+	}
+	dir := target
+	if !info.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	return filepath.Join(dir, "testdata", "golden"), nil
+}
+
+// loadPluginBytes reads a plugin from a .wasm path, a project directory, or
+// the name of an installed plugin.
+func loadPluginBytes(ctx context.Context, cfg *config.Config, stack *internalplugin.PluginStack, target string) ([]byte, error) {
+	if info, err := os.Stat(target); err == nil {
+		path := target
+		if info.IsDir() {
+			abs, err := filepath.Abs(target)
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(abs, filepath.Base(abs)+".wasm")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading plugin: %w", err)
+		}
+		return internalplugin.DecompressWASM(data)
+	}
+
+	discovered, err := discoverPlugins(ctx, cfg, stack)
+	if err != nil {
+		return nil, fmt.Errorf("discovering plugins: %w", err)
+	}
+	for _, dp := range discovered {
+		if dp.Manifest.Name == target {
+			return dp.Loader()
+		}
+	}
+	return nil, fmt.Errorf("plugin %q not found (not a file and not installed)", target)
+}
+
+// renderTestReport writes a plugin test report in the given output format.
+func renderTestReport(w io.Writer, format string, report plugintest.Report) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "junit":
+		return plugintest.WriteJUnit(w, report)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "RESULT\tSERVICE\tOPERATION\tEXAMPLE\tDURATION\tMESSAGE")
+		for _, c := range report.Cases {
+			result := "PASS"
+			if !c.Passed {
+				result = "FAIL"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				result, c.Service, c.Operation, c.Example, c.Duration.Round(time.Millisecond), c.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "\n%s: %d passed, %d failed in %s\n",
+			report.Plugin, report.Passed, report.Failed, report.Duration.Round(time.Millisecond))
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, junit, quiet)", format)
+	}
+}
+
+// newPluginLintCommand creates the "plugin lint" command.
+func newPluginLintCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:   "lint <path|name>",
+		Short: "Check a plugin's manifest for problems",
+		Long: fmt.Sprintf(`Check a plugin's manifest: the config schema is valid JSON Schema,
+input_fields and example inputs reference declared properties, operations
+have descriptions and examples, capability rules are well-formed, and names
+follow a consistent style.
+
+Exits non-zero if any errors are found, or any findings at all with --strict.
+
+Examples:
+  %s plugin lint ./ping.wasm
+  %s plugin lint dns --strict --output json`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			wasmBytes, err := loadPluginBytes(ctx, cfg, stack, args[0])
+			if err != nil {
+				return err
+			}
+			manifest, err := inspectPlugin(ctx, wasmBytes)
+			if err != nil {
+				return err
+			}
+
+			findings := pluginlint.Lint(manifest)
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			if err := renderLintFindings(cmd.OutOrStdout(), format, manifest.Name, findings); err != nil {
+				return err
+			}
+
+			if pluginlint.HasErrors(findings, strict) {
+				return fmt.Errorf("plugin %q failed lint with %d findings", manifest.Name, len(findings))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
+	return cmd
+}
+
+// renderLintFindings writes lint findings in the given output format.
+func renderLintFindings(w io.Writer, format, plugin string, findings []pluginlint.Finding) error {
+	if findings == nil {
+		findings = []pluginlint.Finding{}
+	}
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(findings)
+	case "table", "":
+		if len(findings) == 0 {
+			_, _ = fmt.Fprintf(w, "%s: no problems found\n", plugin)
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "SEVERITY\tPATH\tMESSAGE")
+		for _, f := range findings {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Severity, f.Path, f.Message)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+// newPluginPublishCommand creates the "plugin publish" command.
+func newPluginPublishCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var (
+		sign      bool
+		keyPath   string
+		plainHTTP bool
+		skipLint  bool
+		compress  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "publish <path> [reference]",
+		Short: "Push a plugin to an OCI registry",
+		Long: fmt.Sprintf(`Package a plugin and push it to an OCI registry.
+
+The artifact holds the WASM binary, a metadata config blob, and the full
+manifest, annotated with the plugin's name, version, description, and
+requested capabilities. The reference defaults to
+<default_registry>/<name>:<version>; a bare name or name@version is
+expanded against the default registry.
+
+Credentials are read from REGISTRY_USERNAME and REGISTRY_PASSWORD, or
+else from those stored by "registry login". With
+--sign, a cosign signature is pushed alongside using --key (password from
+COSIGN_PASSWORD).
+
+With --compress, the WASM layer is pushed zstd-compressed, which cuts
+download size for large plugins; it is decompressed when loaded.
+
+Examples:
+  %s plugin publish ./ping.wasm
+  %s plugin publish ./ping ghcr.io/me/plugins/ping:1.0.0 --sign
+  %s plugin publish ./big.wasm --compress`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			out := cmd.OutOrStdout()
+
+			wasmBytes, err := loadPluginBytes(ctx, cfg, stack, args[0])
+			if err != nil {
+				return err
+			}
+			manifest, err := inspectPlugin(ctx, wasmBytes)
+			if err != nil {
+				return err
+			}
+
+			if !skipLint {
+				if findings := pluginlint.Lint(manifest); pluginlint.HasErrors(findings, false) {
+					_ = renderLintFindings(cmd.ErrOrStderr(), "table", manifest.Name, findings)
+					return fmt.Errorf("plugin %q has lint errors; fix them or pass --skip-lint", manifest.Name)
+				}
+			}
+
+			ref := publishReference(args[1:], manifest, cfg.DefaultRegistry)
+			if compress {
+				if wasmBytes, err = internalplugin.CompressWASM(wasmBytes); err != nil {
+					return err
+				}
+			}
+
+			// Load the key before pushing so a bad key does not leave an
+			// unsigned artifact behind.
+			var signer signature.Signer
+			if sign {
+				if signer, err = internalplugin.LoadSigner(keyPath); err != nil {
+					return err
+				}
+			}
+
+			authProvider := internalplugin.NewCredentialProvider()
+			plainHTTP := plainHTTP || cfg.IsPlainHTTPRegistry(ref)
+			_, _ = fmt.Fprintf(out, "Pushing %s ...\n", ref)
+			result, err := internalplugin.Publish(ctx, ref, wasmBytes, manifest, authProvider, plainHTTP)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "Published %s@%s\n", result.Reference, result.Digest)
+
+			if sign {
+				if err := signRemote(ctx, result.Reference, result.Digest, signer, authProvider, plainHTTP); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(out, "Signed %s\n", result.Digest)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&sign, "sign", false, "Sign the pushed artifact with cosign")
+	cmd.Flags().StringVar(&keyPath, "key", internalplugin.DefaultSigningKeyPath(), "Cosign private key used with --sign")
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "Use HTTP instead of HTTPS (local registries)")
+	cmd.Flags().BoolVar(&skipLint, "skip-lint", false, "Publish even if the manifest has lint errors")
+	cmd.Flags().BoolVar(&compress, "compress", false, "Push the WASM layer zstd-compressed")
+	return cmd
+}
+
+// publishReference builds the push reference from an optional argument and
+// the plugin manifest.
+func publishReference(args []string, manifest abi.Manifest, defaultRegistry string) string {
+	version := manifest.Version
+	if version == "" {
+		version = "latest"
+	}
+	if len(args) == 0 {
+		return fmt.Sprintf("%s/%s:%s", defaultRegistry, manifest.Name, version)
+	}
+
+	target := args[0]
+	if !strings.Contains(target, "/") {
+		name, v := parseNameVersion(target)
+		if v == "" {
+			v = version
+		}
+		return fmt.Sprintf("%s/%s:%s", defaultRegistry, name, v)
+	}
+	if !strings.Contains(target[strings.LastIndex(target, "/"):], ":") {
+		return target + ":" + version
+	}
+	return target
+}
+
+// signRemote signs digest and pushes the signature to the repository of ref.
+func signRemote(ctx context.Context, ref, digest string, signer signature.Signer, authProvider ports.AuthProvider, plainHTTP bool) error {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	repo, err := internalplugin.NewRemoteRepository(ctx, parsed, authProvider, plainHTTP)
+	if err != nil {
+		return err
+	}
+	if _, err := internalplugin.Sign(ctx, repo, parsed.Registry+"/"+parsed.Repository, digest, signer); err != nil {
+		return fmt.Errorf("pushing signature: %w", err)
+	}
+	return nil
+}
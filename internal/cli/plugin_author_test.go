@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/pluginlint"
+	"github.com/whiskeyjimb/tack-cli/internal/plugintest"
+)
+
+func TestPluginNewCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ping")
+
+	cmd := newPluginNewCommand()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"ping", "--dir", dir, "--operation", "icmp/echo"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(buf.String(), `Created plugin "ping"`) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "plugin.go")); err != nil {
+		t.Errorf("expected plugin.go to be created: %v", err)
+	}
+}
+
+func TestToolchainCommand(t *testing.T) {
+	c, err := toolchainCommand(context.Background(), "go", "out.wasm", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("toolchainCommand: %v", err)
+	}
+	got := strings.Join(c.Args[1:], " ")
+	if got != "build -buildmode=c-shared -tags a,b -o out.wasm ." {
+		t.Errorf("unexpected args: %s", got)
+	}
+	if !containsEnv(c.Env, "GOOS=wasip1") || !containsEnv(c.Env, "GOARCH=wasm") {
+		t.Error("expected wasip1 environment")
+	}
+
+	if _, err := toolchainCommand(context.Background(), "gcc", "out.wasm", nil); err == nil {
+		t.Error("expected error for unsupported toolchain")
+	}
+}
+
+func TestPluginBuildCommand_ReadOnlyInstall(t *testing.T) {
+	stack, _ := pluginpkg.NewPluginStack(pluginpkg.PluginServiceConfig{CacheDir: t.TempDir()})
+	cfg := config.DefaultConfig()
+	cfg.ReadOnly = true
+
+	// Refused before anything is built: the project does not exist.
+	cmd := newPluginBuildCommand(stack, cfg)
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing"), "--install"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); !errors.Is(err, config.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	// Without --install, building is allowed.
+	cmd = newPluginBuildCommand(stack, cfg)
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); errors.Is(err, config.ErrReadOnly) {
+		t.Errorf("expected a build without --install to be allowed, got %v", err)
+	}
+}
+
+func containsEnv(env []string, kv string) bool {
+	for _, e := range env {
+		if e == kv {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRenderTestReport(t *testing.T) {
+	report := plugintest.Report{
+		Plugin: "dns",
+		Cases: []plugintest.Case{
+			{Service: "dns", Operation: "resolve", Example: "basic", Passed: true},
+			{Service: "dns", Operation: "resolve", Example: "bad", Message: "output mismatch at data.records"},
+		},
+		Passed: 1,
+		Failed: 1,
+	}
+
+	var buf bytes.Buffer
+	if err := renderTestReport(&buf, "table", report); err != nil {
+		t.Fatalf("renderTestReport: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "FAIL") || !strings.Contains(out, "dns: 1 passed, 1 failed") {
+		t.Errorf("unexpected table output:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := renderTestReport(&buf, "junit", report); err != nil {
+		t.Fatalf("renderTestReport junit: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Errorf("expected JUnit XML, got:\n%s", buf.String())
+	}
+
+	if err := renderTestReport(&buf, "csv", report); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestRenderLintFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderLintFindings(&buf, "table", "dns", nil); err != nil {
+		t.Fatalf("renderLintFindings: %v", err)
+	}
+	if !strings.Contains(buf.String(), "no problems found") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	buf.Reset()
+	findings := []pluginlint.Finding{{Severity: pluginlint.SeverityError, Path: "name", Message: "plugin name is required"}}
+	if err := renderLintFindings(&buf, "table", "dns", findings); err != nil {
+		t.Fatalf("renderLintFindings: %v", err)
+	}
+	if !strings.Contains(buf.String(), "plugin name is required") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestPublishReference(t *testing.T) {
+	manifest := abi.Manifest{Name: "ping", Version: "1.2.0"}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "ghcr.io/reglet-dev/plugins/ping:1.2.0"},
+		{[]string{"ping@2.0.0"}, "ghcr.io/reglet-dev/plugins/ping:2.0.0"},
+		{[]string{"localhost:5000/plugins/ping"}, "localhost:5000/plugins/ping:1.2.0"},
+		{[]string{"ghcr.io/me/ping:dev"}, "ghcr.io/me/ping:dev"},
+	}
+	for _, tt := range tests {
+		if got := publishReference(tt.args, manifest, "ghcr.io/reglet-dev/plugins"); got != tt.want {
+			t.Errorf("publishReference(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPluginSignAndVerifyLocal(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("COSIGN_PASSWORD", "pw")
+	prefix := filepath.Join(dir, "cosign")
+	wasm := filepath.Join(dir, "ping.wasm")
+	if err := os.WriteFile(wasm, []byte("wasm"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cmd *cobra.Command, args ...string) (string, error) {
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetErr(&buf)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	if _, err := run(newPluginKeyCommand(), "generate", "--out", prefix); err != nil {
+		t.Fatalf("key generate: %v", err)
+	}
+	if _, err := run(newPluginSignCommand(), wasm, "--key", prefix+".key"); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if out, err := run(newPluginVerifyCommand(), wasm, "--key", prefix+".pub"); err != nil || !strings.Contains(out, "Verified") {
+		t.Fatalf("verify: %v (%s)", err, out)
+	}
+
+	if err := os.WriteFile(wasm, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(newPluginVerifyCommand(), wasm, "--key", prefix+".pub"); err == nil {
+		t.Error("expected verification failure after tampering")
+	}
+}
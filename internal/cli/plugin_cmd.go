@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,10 +16,15 @@ import (
 	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
 	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
 	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
 )
 
+// NoCacheFlag resolves plugin tags against their registry instead of
+// trusting recently resolved digests.
+const NoCacheFlag = "no-cache"
+
 // newPluginCommand creates the "plugin" management command group.
 func newPluginCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
 	cmd := &cobra.Command{
@@ -26,11 +34,26 @@ func newPluginCommand(stack *internalplugin.PluginStack, cfg *config.Config) *co
 
 	cmd.AddCommand(
 		newPluginListCommand(stack),
-		newPluginSearchCommand(cfg),
-		newPluginInstallCommand(stack, cfg.DefaultRegistry),
-		newPluginRemoveCommand(stack),
-		newPluginPruneCommand(stack),
+		newPluginInfoCommand(stack),
+		newPluginWhichCommand(stack, cfg),
+		newPluginFreezeCommand(stack),
+		newPluginVendorCommand(stack, cfg),
+		newPluginSearchCommand(stack, cfg),
+		newPluginInstallCommand(stack, cfg),
+		newPluginRemoveCommand(stack, cfg),
+		newPluginVersionsCommand(stack, cfg),
+		newPluginPruneCommand(stack, cfg),
 		newPluginRefreshCommand(stack),
+		newPluginNewCommand(),
+		newPluginBuildCommand(stack, cfg),
+		newPluginDevCommand(),
+		newPluginTestCommand(stack, cfg),
+		newPluginLintCommand(stack, cfg),
+		newPluginPublishCommand(stack, cfg),
+		newPluginKeyCommand(),
+		newPluginSignCommand(),
+		newPluginVerifyCommand(),
+		newPluginQuarantineCommand(stack, cfg),
 	)
 
 	return cmd
@@ -38,9 +61,14 @@ func newPluginCommand(stack *internalplugin.PluginStack, cfg *config.Config) *co
 
 // newPluginListCommand creates the "plugin list" command.
 func newPluginListCommand(stack *internalplugin.PluginStack) *cobra.Command {
-	return &cobra.Command{
+	var wide bool
+
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List installed plugins",
+		Long: `List installed plugins. With --wide, the license, authors, and source
+repository their publishers annotated the OCI artifacts with are shown too;
+"plugin info" shows a single plugin in full.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			plugins, err := stack.Service.ListCachedPlugins(cmd.Context())
 			if err != nil {
@@ -53,8 +81,14 @@ func newPluginListCommand(stack *internalplugin.PluginStack) *cobra.Command {
 				return nil
 			}
 
+			var installed map[string]internalplugin.InstallRecord
 			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "NAME\tVERSION\tDIGEST\tDESCRIPTION")
+			if wide {
+				installed = internalplugin.LoadCache(internalplugin.DefaultCachePath()).Installed
+				_, _ = fmt.Fprintln(w, "NAME\tVERSION\tDIGEST\tLICENSE\tAUTHORS\tSOURCE\tDESCRIPTION")
+			} else {
+				_, _ = fmt.Fprintln(w, "NAME\tVERSION\tDIGEST\tDESCRIPTION")
+			}
 			for _, p := range plugins {
 				meta := p.Metadata()
 				digest := p.Digest().String()
@@ -62,31 +96,102 @@ func newPluginListCommand(stack *internalplugin.PluginStack) *cobra.Command {
 				if len(digest) > 19 {
 					digest = digest[:19] + "..."
 				}
+				if wide {
+					prov := installed[meta.Name()].Provenance
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+						meta.Name(), meta.Version(), digest,
+						orDash(prov.License), orDash(prov.Authors), orDash(prov.Source), meta.Description())
+					continue
+				}
 				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
 					meta.Name(), meta.Version(), digest, meta.Description())
 			}
 			return w.Flush()
 		},
 	}
+
+	cmd.Flags().BoolVar(&wide, "wide", false, "Also show license, authors, and source repository")
+	return cmd
 }
 
 // newPluginInstallCommand creates the "plugin install" command.
-func newPluginInstallCommand(stack *internalplugin.PluginStack, defaultRegistry string) *cobra.Command {
-	return &cobra.Command{
-		Use:   "install <reference>",
+func newPluginInstallCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var (
+		acceptNewCaps bool
+		requirements  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install <reference> | -f <file>",
 		Short: "Install a plugin from an OCI registry or local file",
-		Long: fmt.Sprintf(`Install a plugin from an OCI registry or a local .wasm file.
+		Long: fmt.Sprintf(`Install a plugin from an OCI registry, an OCI image-layout directory, or
+a local .wasm file.
+
+A name qualified with an index (community/dns) installs the plugin from the
+registry that index names, which disambiguates plugins published under the
+same name by several indexes. The source is remembered for later lookups.
+
+Registries listed under network.plain_http in the config are pulled over
+plain HTTP, for local development registries.
+
+A reference may name an OCI index of variants, each a manifest whose
+platform has architecture "wasm", the WASI target as os (wasip1, wasip2),
+and the WebAssembly features it needs as os.features. The variant that runs
+on this host with the most features is installed, and recorded.
+
+When a newer version of an installed plugin requests access the installed
+version did not have (new hosts, paths, commands, or environment variables),
+the new access is listed and must be confirmed, or accepted up front with
+--accept-new-capabilities.
+
+With -f, every plugin in a requirements document written by "plugin
+freeze" is installed at its recorded version, and refused if its digest
+differs from the recorded one. "-f -" reads the document from stdin.
 
 Examples:
   %s plugin install dns                                        # Install latest from default registry
   %s plugin install dns@1.2.0                                  # Install specific version
+  %s plugin install community/dns                              # Install from the "community" index
   %s plugin install ghcr.io/my-org/plugins/custom:1.0.0        # Install from custom registry
-  %s plugin install ./custom.wasm                              # Install from local file`, meta.AppName, meta.AppName, meta.AppName, meta.AppName),
-		Args: cobra.ExactArgs(1),
+  %s plugin install oci-layout:./dist:1.0.0                    # Install from an OCI layout directory
+  %s plugin install ./custom.wasm                              # Install from local file
+  %s plugin install -f plugins.yaml                            # Install what "plugin freeze" recorded`, meta.AppName, meta.AppName, meta.AppName, meta.AppName, meta.AppName, meta.AppName, meta.AppName),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if requirements != "" && len(args) > 0 {
+				return fmt.Errorf("install either a reference or -f %s, not both", requirements)
+			}
+			if requirements != "" {
+				return nil
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		PreRunE: denyInReadOnly(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			target := args[0]
 			out := cmd.OutOrStdout()
 			ctx := cmd.Context()
+			confirm := confirmNewCapabilities(cmd.InOrStdin(), cmd.ErrOrStderr())
+			if acceptNewCaps {
+				confirm = func(internalplugin.CapabilityChange) error { return nil }
+			}
+
+			if requirements != "" {
+				reqs, err := internalplugin.LoadRequirements(requirements)
+				if err != nil {
+					return err
+				}
+				for _, r := range reqs.Plugins {
+					if err := installFromRegistry(ctx, stack, cfg, r.Index, r.Reference(), r.Digest, confirm, out); err != nil {
+						return fmt.Errorf("installing %s: %w", r.Name, err)
+					}
+				}
+				return nil
+			}
+
+			target := args[0]
+
+			if dir, tag, ok := internalplugin.ParseOCILayout(target); ok {
+				return installFromOCILayout(ctx, stack, dir, tag, out)
+			}
 
 			// Determine if target is a local file or OCI reference
 			isLocal := strings.HasSuffix(target, ".wasm") ||
@@ -95,35 +200,198 @@ Examples:
 				filepath.IsAbs(target)
 
 			if isLocal {
-				return installFromLocalFile(ctx, stack, target, out)
+				return installFromLocalFile(ctx, stack, cfg, target, out)
 			}
 
-			// Build full OCI reference from short name or full reference
-			ref := resolveOCIRef(target, defaultRegistry)
+			// Build full OCI reference from a source-qualified name, short
+			// name, or full reference
+			var source, ref string
+			if src, name, ok := internalplugin.SplitQualifiedName(target); ok {
+				resolved, err := resolveIndexRef(ctx, cfg, src, name)
+				if err != nil {
+					return err
+				}
+				source, ref = src, resolved
+			} else {
+				ref = resolveOCIRef(target, cfg.DefaultRegistry)
+			}
 
-			_, _ = fmt.Fprintf(out, "Pulling %s ...\n", ref)
+			return installFromRegistry(ctx, stack, cfg, source, ref, "", confirm, out)
+		},
+	}
 
-			pluginRef, err := hostvalues.ParsePluginReference(ref)
-			if err != nil {
-				return fmt.Errorf("invalid plugin reference %q: %w", ref, err)
-			}
+	cmd.Flags().BoolVar(&acceptNewCaps, "accept-new-capabilities", false, "Install an upgrade even if it requests access the installed version did not have")
+	cmd.Flags().StringVarP(&requirements, "file", "f", "", `Install the plugins in a requirements document ("-" for stdin)`)
+	return cmd
+}
 
-			// Pull via OCI \u2014 this resolves, downloads, verifies, and caches
-			artifact, err := stack.Service.Pull(ctx, pluginRef)
-			if err != nil {
-				return fmt.Errorf("pulling plugin: %w", err)
-			}
+// installFromRegistry pulls ref, records where it was installed from, and
+// reports it on out. With want set, an artifact whose digest differs is
+// refused before it is recorded. It refuses in read-only mode.
+func installFromRegistry(ctx context.Context, stack *internalplugin.PluginStack, cfg *config.Config, source, ref, want string, confirm internalplugin.ConfirmFunc, out io.Writer) error {
+	if cfg.ReadOnly {
+		return fmt.Errorf("installing %s: %w", ref, config.ErrReadOnly)
+	}
+	if err := internalplugin.ActivePolicy().CheckReference(ref); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "Pulling %s ...\n", ref)
+
+	pluginRef, err := hostvalues.ParsePluginReference(ref)
+	if err != nil {
+		return fmt.Errorf("invalid plugin reference %q: %w", ref, err)
+	}
+
+	loader := internalplugin.NewLoader(internalplugin.EmbeddedPlugins, internalplugin.DefaultPluginsDir(), stack, cfg.DefaultRegistry)
+	guard := loader.GuardUpgrade(ctx, pluginRef.Name())
+	if err := stack.RefreshTag(ctx, pluginRef, internalplugin.DefaultCachePath()); err != nil {
+		return fmt.Errorf("refreshing %s: %w", ref, err)
+	}
+
+	// Pull via OCI \u2014 this resolves, downloads, verifies, and caches
+	var artifact *hostentities.Plugin
+	err = stack.QuarantineFailedPull(ctx, pluginRef, func() (err error) {
+		artifact, err = stack.Service.Pull(ctx, pluginRef)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("pulling plugin: %w", err)
+	}
+
+	// The pinned digest is that of the WASM layer, not of a manifest the
+	// registry could be asked for, so a mismatch is only known once pulled
+	if want != "" && artifact.Digest().String() != want {
+		err := errcode.Errorf(errcode.DigestMismatch, "%s has digest %s, not %s as required", ref, artifact.Digest(), want)
+		if delErr := stack.Repository.Delete(ctx, artifact.Reference()); delErr != nil {
+			return errors.Join(err, fmt.Errorf("removing %s: %w", ref, delErr))
+		}
+		return err
+	}
 
-			meta := artifact.Metadata()
-			_, _ = fmt.Fprintf(out, "Installed %s@%s\n", meta.Name(), meta.Version())
+	if err := guard.Check(ctx, artifact.Reference(), confirm); err != nil {
+		return err
+	}
 
+	meta := artifact.Metadata()
+	record := internalplugin.InstallRecord{Source: source, Reference: ref}
+	cachePath := internalplugin.DefaultCachePath()
+	cache := internalplugin.LoadCache(cachePath)
+	prev, reinstall := cache.Installed[meta.Name()]
+	reinstall = reinstall && prev.Reference == ref
+	if v, ok := stack.PulledVariant(artifact.Reference()); ok {
+		record.Variant = v.String()
+	} else if reinstall {
+		// Served from the local cache; the variant pulled before still applies
+		record.Variant = prev.Variant
+	}
+	if p, ok := stack.PulledProvenance(artifact.Reference()); ok {
+		record.Provenance = p
+	} else if reinstall {
+		record.Provenance = prev.Provenance
+	}
+	if record.Variant != "" {
+		_, _ = fmt.Fprintf(out, "Installed %s@%s (variant %s)\n", meta.Name(), meta.Version(), record.Variant)
+	} else {
+		_, _ = fmt.Fprintf(out, "Installed %s@%s\n", meta.Name(), meta.Version())
+	}
+
+	if prev, ok := cache.RecordInstall(meta.Name(), record); ok && prev.Source != source {
+		fmt.Fprintf(os.Stderr, "Warning: %s replaces %s\n", record.QualifiedName(meta.Name()), prev.QualifiedName(meta.Name()))
+	}
+	if err := cache.Save(cachePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: recording plugin source: %v\n", err)
+	}
+
+	return nil
+}
+
+// confirmNewCapabilities lists the access an upgrade adds and asks whether
+// to install it. Without a terminal to ask on, the upgrade is refused.
+func confirmNewCapabilities(in io.Reader, out io.Writer) internalplugin.ConfirmFunc {
+	return func(change internalplugin.CapabilityChange) error {
+		if !isTerminal(in) {
+			return fmt.Errorf("%w; rerun with --accept-new-capabilities to install it", change.Err())
+		}
+		_, _ = fmt.Fprintf(out, "%s %s requests access %s did not have:\n", change.Plugin, change.ToVersion, change.FromVersion)
+		for _, added := range change.Added {
+			_, _ = fmt.Fprintf(out, "  %s\n", added)
+		}
+		_, _ = fmt.Fprint(out, "Install anyway? [y/N] ")
+
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
 			return nil
-		},
+		}
+		return fmt.Errorf("%s %s not installed: %w", change.Plugin, change.ToVersion, internalplugin.ErrNewCapabilities)
+	}
+}
+
+// isTerminal reports whether r is an interactive terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// resolveIndexRef resolves "name[@version]" through the named index to a
+// reference in the registry that index declares. Without a version, the
+// index's latest version is used.
+func resolveIndexRef(ctx context.Context, cfg *config.Config, source, target string) (string, error) {
+	name, version := parseNameVersion(target)
+	entry, err := lookupIndexEntry(ctx, cfg, source, name)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		version = entry.Latest
+	}
+	if version == "" {
+		version = "latest"
+	}
+	return fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(entry.Registry, "/"), name, version), nil
+}
+
+// lookupIndexEntry finds a plugin in the named index, which must declare
+// the registry it is published to.
+func lookupIndexEntry(ctx context.Context, cfg *config.Config, source, name string) (internalplugin.SearchResult, error) {
+	sources := filterSources(buildIndexSources(cfg), source)
+	if len(sources) == 0 {
+		var names []string
+		for _, s := range buildIndexSources(cfg) {
+			names = append(names, s.Name)
+		}
+		return internalplugin.SearchResult{}, fmt.Errorf("unknown index %q (configured: %s)", source, strings.Join(names, ", "))
+	}
+
+	results, err := internalplugin.SearchAll(ctx, sources, name, false)
+	if err != nil {
+		return internalplugin.SearchResult{}, err
 	}
+	for _, r := range results {
+		if r.Name != name {
+			continue
+		}
+		if r.Registry == "" {
+			return internalplugin.SearchResult{}, fmt.Errorf("index %q does not declare a registry", source)
+		}
+		return r, nil
+	}
+	return internalplugin.SearchResult{}, fmt.Errorf("plugin %q not found in index %q", name, source)
 }
 
-// installFromLocalFile installs a .wasm file into the local cache.
-func installFromLocalFile(ctx context.Context, stack *internalplugin.PluginStack, path string, out io.Writer) error {
+// installFromLocalFile installs a .wasm file into the local cache, or a
+// .wasm.zst one compressed by "plugin publish --compress". With
+// compress_plugins set, the cached copy is compressed. It refuses in
+// read-only mode.
+func installFromLocalFile(ctx context.Context, stack *internalplugin.PluginStack, cfg *config.Config, path string, out io.Writer) error {
+	if cfg.ReadOnly {
+		return fmt.Errorf("installing %s: %w", path, config.ErrReadOnly)
+	}
 	_, _ = fmt.Fprintf(out, "Installing from local file: %s\n", path)
 
 	f, err := os.Open(path)
@@ -131,9 +399,19 @@ func installFromLocalFile(ctx context.Context, stack *internalplugin.PluginStack
 		return fmt.Errorf("opening plugin file: %w", err)
 	}
 	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("opening plugin file: %w", err)
+	}
+	if err := internalplugin.CheckArtifactSize(info.Size()); err != nil {
+		return err
+	}
 
 	// Extract name from filename
-	name := strings.TrimSuffix(filepath.Base(path), ".wasm")
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".zst"), ".wasm")
+	if err := internalplugin.ActivePolicy().CheckLocalInstall(name); err != nil {
+		return err
+	}
 
 	// Create a minimal plugin entity for local files
 	ref, err := hostvalues.ParsePluginReference(name)
@@ -141,20 +419,33 @@ func installFromLocalFile(ctx context.Context, stack *internalplugin.PluginStack
 		return fmt.Errorf("invalid plugin name %q: %w", name, err)
 	}
 
-	digest, err := hostvalues.ComputeDigestSHA256(f)
+	var src io.ReadSeeker = f
+	if internalplugin.CompressCache() {
+		data, err := io.ReadAll(io.LimitReader(f, info.Size()))
+		if err != nil {
+			return fmt.Errorf("reading plugin file: %w", err)
+		}
+		if data, err = internalplugin.CompressWASM(data); err != nil {
+			return err
+		}
+		src = bytes.NewReader(data)
+	}
+
+	// The digest is of the bytes stored, compressed or not
+	digest, err := hostvalues.ComputeDigestSHA256(src)
 	if err != nil {
 		return fmt.Errorf("computing digest: %w", err)
 	}
 
 	// Re-open for storage
-	if _, err := f.Seek(0, 0); err != nil {
+	if _, err := src.Seek(0, 0); err != nil {
 		return fmt.Errorf("resetting file pointer: %w", err)
 	}
 
 	metadata := hostvalues.NewPluginMetadata(name, "local", "", nil)
 	plugin := hostentities.NewPlugin(ref, digest, metadata)
 
-	storedPath, err := stack.Repository.Store(ctx, plugin, f)
+	storedPath, err := stack.Repository.Store(ctx, plugin, src)
 	if err != nil {
 		return fmt.Errorf("storing plugin: %w", err)
 	}
@@ -163,15 +454,55 @@ func installFromLocalFile(ctx context.Context, stack *internalplugin.PluginStack
 	return nil
 }
 
+// installFromOCILayout installs a plugin artifact from an OCI image-layout
+// directory, such as one written by "oras copy --to-oci-layout".
+func installFromOCILayout(ctx context.Context, stack *internalplugin.PluginStack, dir, tag string, out io.Writer) error {
+	_, _ = fmt.Fprintf(out, "Installing from OCI layout: %s\n", dir)
+
+	artifact, err := internalplugin.FetchLayoutArtifact(ctx, dir, tag)
+	if err != nil {
+		return err
+	}
+	name := artifact.Metadata.Name()
+	if name == "" {
+		return fmt.Errorf("artifact in %s does not name its plugin", dir)
+	}
+	if err := internalplugin.ActivePolicy().CheckLocalInstall(name); err != nil {
+		return err
+	}
+	ref, err := hostvalues.ParsePluginReference(name)
+	if err != nil {
+		return fmt.Errorf("invalid plugin name %q: %w", name, err)
+	}
+
+	plugin := hostentities.NewPlugin(ref, artifact.Digest, artifact.Metadata)
+	storedPath, err := stack.Repository.Store(ctx, plugin, bytes.NewReader(artifact.WASM))
+	if err != nil {
+		return fmt.Errorf("storing plugin: %w", err)
+	}
+
+	if artifact.Variant.Target != "" {
+		_, _ = fmt.Fprintf(out, "Installed %s@%s (variant %s) to %s\n", name, artifact.Metadata.Version(), artifact.Variant, storedPath)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Installed %s@%s to %s\n", name, artifact.Metadata.Version(), storedPath)
+	return nil
+}
+
 // newPluginRemoveCommand creates the "plugin remove" command.
-func newPluginRemoveCommand(stack *internalplugin.PluginStack) *cobra.Command {
+func newPluginRemoveCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:     "remove <reference>",
 		Aliases: []string{"uninstall", "rm"},
 		Short:   "Remove a plugin from local cache",
 		Args:    cobra.ExactArgs(1),
+		PreRunE: denyInReadOnly(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ref, err := hostvalues.ParsePluginReference(args[0])
+			target := args[0]
+			if _, name, ok := internalplugin.SplitQualifiedName(target); ok {
+				target = name
+			}
+			ref, err := hostvalues.ParsePluginReference(target)
 			if err != nil {
 				return fmt.Errorf("invalid plugin reference: %w", err)
 			}
@@ -180,6 +511,19 @@ func newPluginRemoveCommand(stack *internalplugin.PluginStack) *cobra.Command {
 				return err
 			}
 
+			// Forget the install source once no version is left
+			name, _ := parseNameVersion(target)
+			if versions, err := installedVersions(cmd.Context(), stack); err == nil {
+				if _, still := versions[name]; !still {
+					cachePath := internalplugin.DefaultCachePath()
+					cache := internalplugin.LoadCache(cachePath)
+					if _, ok := cache.Installed[name]; ok {
+						delete(cache.Installed, name)
+						_ = cache.Save(cachePath)
+					}
+				}
+			}
+
 			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed plugin %q\n", args[0])
 			return nil
 		},
@@ -187,12 +531,13 @@ func newPluginRemoveCommand(stack *internalplugin.PluginStack) *cobra.Command {
 }
 
 // newPluginPruneCommand creates the "plugin prune" command.
-func newPluginPruneCommand(stack *internalplugin.PluginStack) *cobra.Command {
+func newPluginPruneCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
 	var keepVersions int
 
 	cmd := &cobra.Command{
-		Use:   "prune",
-		Short: "Remove old plugin versions from cache",
+		Use:     "prune",
+		Short:   "Remove old plugin versions from cache",
+		PreRunE: denyInReadOnly(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := stack.Service.PruneCache(cmd.Context(), keepVersions); err != nil {
 				return err
@@ -211,16 +556,22 @@ func newPluginRefreshCommand(stack *internalplugin.PluginStack) *cobra.Command {
 	return &cobra.Command{
 		Use:   "refresh",
 		Short: "Rebuild the plugin discovery cache",
+		Long: `Read every plugin binary again and rebuild the discovery cache. Records of
+where plugins were installed from are kept.
+
+Set background_refresh: true in the config to have this run in a detached
+process, at most once a day, after commands served from the cache.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			out := cmd.OutOrStdout()
 			_, _ = fmt.Fprintln(out, "Refreshing plugin cache...")
 
-			// Delete manifest discovery cache to force full rebuild
-			cachePath := internalplugin.DefaultCachePath()
-			_ = os.Remove(cachePath)
-
-			// Re-run discovery (caller must reinvoke with new loader)
-			_, _ = fmt.Fprintln(out, "Discovery cache cleared. Restart the CLI to rebuild.")
+			loader := internalplugin.NewLoader(internalplugin.EmbeddedPlugins, internalplugin.DefaultPluginsDir(), stack, "")
+			discovered, err := loader.Refresh(cmd.Context())
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "Discovery cache rebuilt (%d plugins)\n", len(discovered))
 			return nil
 		},
 	}
@@ -248,14 +599,20 @@ func parseNameVersion(s string) (name, version string) {
 	return s, ""
 }
 
-func newPluginSearchCommand(cfg *config.Config) *cobra.Command {
-	var indexFilter string
-	var forceRefresh bool
+func newPluginSearchCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var (
+		indexFilter  string
+		forceRefresh bool
+		filter       searchFilter
+	)
 
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search available plugins",
-		Args:  cobra.MaximumNArgs(1),
+		Example: fmt.Sprintf(`  %s plugin search dns
+  %s plugin search --capability network --source official
+  %s plugin search --not-installed`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			query := ""
 			if len(args) > 0 {
@@ -264,7 +621,10 @@ func newPluginSearchCommand(cfg *config.Config) *cobra.Command {
 
 			sources := buildIndexSources(cfg)
 			if indexFilter != "" {
-				sources = filterSources(sources, indexFilter)
+				filter.sources = append(filter.sources, indexFilter)
+			}
+			if len(filter.sources) > 0 {
+				sources = filterSources(sources, filter.sources...)
 			}
 
 			results, err := internalplugin.SearchAll(cmd.Context(), sources, query, forceRefresh)
@@ -272,48 +632,152 @@ func newPluginSearchCommand(cfg *config.Config) *cobra.Command {
 				return err
 			}
 
+			installed, err := installedVersions(cmd.Context(), stack)
+			if err != nil {
+				return err
+			}
+			filter.installed = installed
+			results = filter.apply(results)
+
 			if len(results) == 0 {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No plugins found.")
 				return nil
 			}
-
-			// Print as table
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "PLUGIN\tVERSION\tCAPABILITIES\tSOURCE\tDESCRIPTION")
-			for _, r := range results {
-				caps := ""
-				if len(r.Capabilities) > 0 {
-					caps = strings.Join(r.Capabilities, ",")
-				}
-				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-					r.Name, r.Latest,
-					caps,
-					r.Source, r.Description)
-			}
-			return w.Flush()
+			return renderSearchResults(cmd.OutOrStdout(), results, installed)
 		},
 	}
 
 	cmd.Flags().StringVar(&indexFilter, "index", "", "Search a specific index only")
+	_ = cmd.Flags().MarkDeprecated("index", "use --source instead")
+	cmd.Flags().StringSliceVar(&filter.sources, "source", nil, "Search only these indexes (repeatable)")
+	cmd.Flags().StringSliceVar(&filter.capabilities, "capability", nil, "Only plugins requesting all of these capabilities: network, fs, env, exec, kv (repeatable)")
+	cmd.Flags().BoolVar(&filter.installedOnly, "installed-only", false, "Only plugins that are installed")
+	cmd.Flags().BoolVar(&filter.notInstalled, "not-installed", false, "Only plugins that are not installed")
+	cmd.MarkFlagsMutuallyExclusive("installed-only", "not-installed")
 	cmd.Flags().BoolVar(&forceRefresh, "refresh", false, "Force refresh of plugin indexes")
 	return cmd
 }
 
+// searchFilter narrows "plugin search" results.
+type searchFilter struct {
+	sources       []string
+	capabilities  []string
+	installedOnly bool
+	notInstalled  bool
+	installed     map[string]string // installed plugin name -> version
+}
+
+// apply returns the results that pass every filter.
+func (f searchFilter) apply(results []internalplugin.SearchResult) []internalplugin.SearchResult {
+	var kept []internalplugin.SearchResult
+	for _, r := range results {
+		if !hasAll(r.Capabilities, f.capabilities) {
+			continue
+		}
+		_, isInstalled := f.installed[r.Name]
+		if f.installedOnly && !isInstalled {
+			continue
+		}
+		if f.notInstalled && isInstalled {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// installedVersions returns the newest cached version of each installed plugin.
+func installedVersions(ctx context.Context, stack *internalplugin.PluginStack) (map[string]string, error) {
+	plugins, err := stack.Service.ListCachedPlugins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing installed plugins: %w", err)
+	}
+	versions := make(map[string]string, len(plugins))
+	for _, p := range plugins {
+		meta := p.Metadata()
+		if v, ok := versions[meta.Name()]; !ok || internalplugin.NewerVersion(meta.Version(), v) {
+			versions[meta.Name()] = meta.Version()
+		}
+	}
+	return versions, nil
+}
+
+// renderSearchResults prints search results as a table. The INSTALLED
+// column shows the installed version and whether the index has a newer one.
+//
+// Plugins listed by more than one index are shown source-qualified
+// (community/dns), which is also how they are installed.
+func renderSearchResults(w io.Writer, results []internalplugin.SearchResult, installed map[string]string) error {
+	count := make(map[string]int, len(results))
+	for _, r := range results {
+		count[r.Name]++
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PLUGIN\tVERSION\tINSTALLED\tCAPABILITIES\tSOURCE\tDESCRIPTION")
+	for _, r := range results {
+		status := installed[r.Name]
+		if status != "" && r.Latest != "" && internalplugin.NewerVersion(r.Latest, status) {
+			status += " (update available)"
+		}
+		name := r.Name
+		if count[r.Name] > 1 {
+			name = r.Source + "/" + r.Name
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			name, r.Latest, status,
+			strings.Join(r.Capabilities, ","),
+			r.Source, r.Description)
+	}
+	return tw.Flush()
+}
+
+// hasAll reports whether have contains every entry of want, ignoring case.
+func hasAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// buildIndexSources returns the official index followed by configured ones.
+// Each is verified against index_public_keys plus its own public_key.
 func buildIndexSources(cfg *config.Config) []internalplugin.IndexSource {
 	sources := []internalplugin.IndexSource{
-		{URL: internalplugin.DefaultIndexURL, Name: "official"},
+		{URL: internalplugin.DefaultIndexURL, Name: "official", PublicKeys: cfg.IndexPublicKeys, RequireSigned: cfg.RequireSignedIndexes},
 	}
 	for _, idx := range cfg.Indexes {
-		sources = append(sources, internalplugin.IndexSource{URL: idx.URL, Name: idx.Name})
+		keys := append([]string{}, cfg.IndexPublicKeys...)
+		if idx.PublicKey != "" {
+			keys = append(keys, idx.PublicKey)
+		}
+		sources = append(sources, internalplugin.IndexSource{
+			URL:           idx.URL,
+			Name:          idx.Name,
+			PublicKeys:    keys,
+			RequireSigned: cfg.RequireSignedIndexes,
+		})
 	}
 	return sources
 }
 
-func filterSources(sources []internalplugin.IndexSource, name string) []internalplugin.IndexSource {
+func filterSources(sources []internalplugin.IndexSource, names ...string) []internalplugin.IndexSource {
 	var filtered []internalplugin.IndexSource
 	for _, s := range sources {
-		if s.Name == name {
-			filtered = append(filtered, s)
+		for _, name := range names {
+			if s.Name == name {
+				filtered = append(filtered, s)
+				break
+			}
 		}
 	}
 	return filtered
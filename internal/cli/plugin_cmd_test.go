@@ -2,11 +2,14 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
 	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
 )
@@ -34,6 +37,7 @@ func TestPluginCommand_ListEmpty(t *testing.T) {
 }
 
 func TestPluginCommand_InstallLocal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	pluginsDir := t.TempDir()
 	stack, _ := pluginpkg.NewPluginStack(pluginpkg.PluginServiceConfig{CacheDir: pluginsDir})
 
@@ -42,7 +46,7 @@ func TestPluginCommand_InstallLocal(t *testing.T) {
 	srcPath := filepath.Join(srcDir, "testplugin.wasm")
 	_ = os.WriteFile(srcPath, []byte("fake wasm"), 0o644)
 
-	cmd := newPluginInstallCommand(stack, "ghcr.io/reglet-dev")
+	cmd := newPluginInstallCommand(stack, &config.Config{DefaultRegistry: "ghcr.io/reglet-dev"})
 	var buf bytes.Buffer
 	cmd.SetOut(&buf)
 	cmd.SetArgs([]string{srcPath})
@@ -58,6 +62,7 @@ func TestPluginCommand_InstallLocal(t *testing.T) {
 }
 
 func TestPluginCommand_Remove(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	pluginsDir := t.TempDir()
 	stack, _ := pluginpkg.NewPluginStack(pluginpkg.PluginServiceConfig{CacheDir: pluginsDir})
 
@@ -67,13 +72,13 @@ func TestPluginCommand_Remove(t *testing.T) {
 	_ = os.WriteFile(srcPath, []byte("fake wasm"), 0o644)
 
 	// Install it first so we can remove it
-	installCmd := newPluginInstallCommand(stack, "")
+	installCmd := newPluginInstallCommand(stack, config.DefaultConfig())
 	installCmd.SetArgs([]string{srcPath})
 	if err := installCmd.Execute(); err != nil {
 		t.Fatalf("failed to install for remove test: %v", err)
 	}
 
-	cmd := newPluginRemoveCommand(stack)
+	cmd := newPluginRemoveCommand(stack, config.DefaultConfig())
 	var buf bytes.Buffer
 	cmd.SetOut(&buf)
 	cmd.SetArgs([]string{"testplugin"})
@@ -86,4 +91,142 @@ func TestPluginCommand_Remove(t *testing.T) {
 	if _, err := os.Stat(filepath.Join(pluginsDir, "testplugin", "plugin.wasm")); !os.IsNotExist(err) {
 		t.Error("plugin not removed")
 	}
+	if _, ok := pluginpkg.LoadCache(pluginpkg.DefaultCachePath()).Installed["testplugin"]; ok {
+		t.Error("install record not removed")
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	results := []pluginpkg.SearchResult{
+		{PluginEntry: pluginpkg.PluginEntry{Name: "dns", Capabilities: []string{"network"}}, Source: "official"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "file", Capabilities: []string{"fs"}}, Source: "official"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "aws", Capabilities: []string{"network", "env"}}, Source: "internal"},
+	}
+	names := func(rs []pluginpkg.SearchResult) string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	installed := map[string]string{"dns": "1.0.0"}
+
+	tests := []struct {
+		filter searchFilter
+		want   string
+	}{
+		{searchFilter{}, "dns,file,aws"},
+		{searchFilter{capabilities: []string{"Network"}}, "dns,aws"},
+		{searchFilter{capabilities: []string{"network", "env"}}, "aws"},
+		{searchFilter{installedOnly: true, installed: installed}, "dns"},
+		{searchFilter{notInstalled: true, installed: installed, capabilities: []string{"network"}}, "aws"},
+	}
+	for _, tt := range tests {
+		if got := names(tt.filter.apply(results)); got != tt.want {
+			t.Errorf("%+v: expected %q, got %q", tt.filter, tt.want, got)
+		}
+	}
+
+	sources := filterSources(buildIndexSources(&config.Config{Indexes: []config.IndexSource{{Name: "internal", URL: "file:///x"}}}), "internal", "official")
+	if len(sources) != 2 {
+		t.Errorf("expected both named sources, got %+v", sources)
+	}
+}
+
+func TestRenderSearchResults(t *testing.T) {
+	results := []pluginpkg.SearchResult{
+		{PluginEntry: pluginpkg.PluginEntry{Name: "dns", Latest: "1.2.0"}, Source: "official"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "tcp", Latest: "0.3.0"}, Source: "official"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "http", Latest: "2.0.0"}, Source: "official"},
+	}
+	installed := map[string]string{"dns": "1.0.0", "tcp": "0.3.0"}
+
+	var buf bytes.Buffer
+	if err := renderSearchResults(&buf, results, installed); err != nil {
+		t.Fatalf("renderSearchResults: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[0], "INSTALLED") {
+		t.Errorf("expected INSTALLED column, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "1.0.0 (update available)") {
+		t.Errorf("expected dns update, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "0.3.0") || strings.Contains(lines[2], "update") {
+		t.Errorf("expected tcp up to date, got %q", lines[2])
+	}
+	if strings.Count(lines[3], "2.0.0") != 1 {
+		t.Errorf("expected http not installed, got %q", lines[3])
+	}
+}
+
+func TestRenderSearchResults_QualifiesDuplicates(t *testing.T) {
+	results := []pluginpkg.SearchResult{
+		{PluginEntry: pluginpkg.PluginEntry{Name: "dns", Latest: "1.2.0"}, Source: "official"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "dns", Latest: "0.9.0"}, Source: "community"},
+		{PluginEntry: pluginpkg.PluginEntry{Name: "tcp", Latest: "0.3.0"}, Source: "official"},
+	}
+
+	var buf bytes.Buffer
+	if err := renderSearchResults(&buf, results, nil); err != nil {
+		t.Fatalf("renderSearchResults: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[1], "official/dns ") || !strings.HasPrefix(lines[2], "community/dns ") {
+		t.Errorf("expected qualified dns rows, got %q and %q", lines[1], lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "tcp ") {
+		t.Errorf("expected unqualified tcp row, got %q", lines[3])
+	}
+}
+
+func TestPluginCommand_ReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stack, _ := pluginpkg.NewPluginStack(pluginpkg.PluginServiceConfig{CacheDir: t.TempDir()})
+	cfg := config.DefaultConfig()
+	cfg.ReadOnly = true
+
+	srcPath := filepath.Join(t.TempDir(), "testplugin.wasm")
+	_ = os.WriteFile(srcPath, []byte("fake wasm"), 0o644)
+
+	for _, cmd := range []*cobra.Command{
+		newPluginInstallCommand(stack, cfg),
+		newPluginRemoveCommand(stack, cfg),
+		newPluginPruneCommand(stack, cfg),
+		newPluginVendorCommand(stack, cfg),
+		newPluginQuarantineRestoreCommand(stack, cfg),
+		newPluginQuarantinePurgeCommand(stack, cfg),
+	} {
+		cmd.SetArgs([]string{srcPath})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		if err := cmd.Execute(); !errors.Is(err, config.ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", cmd.Name(), err)
+		}
+	}
+
+	// The shared install helpers refuse too, whichever command calls them.
+	if err := installFromLocalFile(context.Background(), stack, cfg, srcPath, &bytes.Buffer{}); !errors.Is(err, config.ErrReadOnly) {
+		t.Errorf("installFromLocalFile: expected ErrReadOnly, got %v", err)
+	}
+	if err := installFromRegistry(context.Background(), stack, cfg, "", "ghcr.io/example/dns:1.0.0", "", nil, &bytes.Buffer{}); !errors.Is(err, config.ErrReadOnly) {
+		t.Errorf("installFromRegistry: expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestConfirmNewCapabilities_NonInteractive(t *testing.T) {
+	var out bytes.Buffer
+	confirm := confirmNewCapabilities(strings.NewReader("y\n"), &out)
+	err := confirm(pluginpkg.CapabilityChange{
+		Plugin:      "dns",
+		FromVersion: "1.0.0",
+		ToVersion:   "2.0.0",
+		Added:       []string{`command "sh"`},
+	})
+	if !errors.Is(err, pluginpkg.ErrNewCapabilities) {
+		t.Fatalf("expected ErrNewCapabilities without a terminal, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "--accept-new-capabilities") || out.Len() != 0 {
+		t.Errorf("expected a hint and no prompt, got %q and %q", err, out.String())
+	}
 }
@@ -0,0 +1,533 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// newPluginDevCommand creates the "plugin dev" command.
+func newPluginDevCommand() *cobra.Command {
+	var (
+		toolchain string
+		wasmOut   string
+		tags      []string
+		interval  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "dev [dir|file.wasm]",
+		Short: "Watch, rebuild, and run a plugin interactively",
+		Long: fmt.Sprintf(`Start an interactive session for a plugin under development.
+
+The project is built (as with "plugin build") and loaded into a runtime
+that stays alive for the session. Whenever a .go, go.mod, or go.sum file
+changes, the plugin is rebuilt and reloaded, and its commands are
+regenerated from the new manifest. Pointing at a .wasm file skips the
+build and reloads whenever the file changes.
+
+At the prompt, type an operation as you would after "%s <plugin>":
+
+  check --host example.com
+  ec2 describe_instances --output json
+
+Built-in commands: help, reload, exit.
+
+Capabilities requested by the plugin are granted for the session without
+prompting.
+
+Examples:
+  %s plugin dev
+  %s plugin dev ./ping --toolchain tinygo
+  %s plugin dev ./ping.wasm`, meta.AppName, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := "."
+			if len(args) > 0 {
+				target = args[0]
+			}
+			abs, err := filepath.Abs(target)
+			if err != nil {
+				return err
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+
+			w := &devWatcher{interval: interval}
+			var rebuild func(ctx context.Context) error
+			if strings.HasSuffix(abs, ".wasm") {
+				w.files = func() ([]string, error) { return []string{abs}, nil }
+				wasmOut = abs
+				rebuild = func(context.Context) error { return nil }
+			} else {
+				if wasmOut == "" {
+					wasmOut = filepath.Join(abs, filepath.Base(abs)+".wasm")
+				}
+				w.files = func() ([]string, error) { return pluginSourceFiles(abs) }
+				rebuild = func(ctx context.Context) error {
+					return buildWASM(ctx, abs, toolchain, wasmOut, tags, cmd.ErrOrStderr())
+				}
+			}
+
+			session := &devSession{
+				verbose: verbose,
+				format:  format,
+				out:     cmd.OutOrStdout(),
+				errOut:  cmd.ErrOrStderr(),
+			}
+			defer session.close()
+
+			ctx := cmd.Context()
+			session.reload(ctx, rebuild, wasmOut)
+			if err := w.snapshot(); err != nil {
+				return err
+			}
+
+			go w.watch(ctx, func() {
+				_, _ = fmt.Fprintln(session.errOut, "\nChange detected, reloading ...")
+				session.reload(ctx, rebuild, wasmOut)
+				session.prompt()
+			})
+
+			return session.repl(ctx, cmd.InOrStdin(), func() { session.reload(ctx, rebuild, wasmOut) })
+		},
+	}
+
+	cmd.Flags().StringVar(&toolchain, "toolchain", "go", "Compiler toolchain: go or tinygo")
+	cmd.Flags().StringVarP(&wasmOut, "out", "o", "", "Output .wasm path (default: <dir>/<dir name>.wasm)")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Build tags")
+	cmd.Flags().DurationVar(&interval, "interval", 500*time.Millisecond, "How often to check for changes")
+	_ = cmd.RegisterFlagCompletionFunc("toolchain", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"go", "tinygo"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// devSession holds the plugin loaded for a dev session. The runtime is kept
+// between commands and replaced when the plugin is reloaded.
+type devSession struct {
+	mu       sync.RWMutex
+	runner   *runtime.PluginRunner
+	plugin   *runtime.LoadedPlugin
+	manifest abi.Manifest
+
+	verbose bool
+	format  string
+	out     io.Writer
+	errOut  io.Writer
+}
+
+// reload rebuilds the plugin and swaps it into the session. Failures are
+// reported and the previously loaded plugin, if any, stays active.
+func (s *devSession) reload(ctx context.Context, rebuild func(context.Context) error, wasmPath string) {
+	if err := s.load(ctx, rebuild, wasmPath); err != nil {
+		_, _ = fmt.Fprintf(s.errOut, "Error: %v\n", err)
+		return
+	}
+	s.mu.RLock()
+	m := s.manifest
+	s.mu.RUnlock()
+	_, _ = fmt.Fprintf(s.errOut, "Loaded %s %s (%s)\n", m.Name, m.Version, strings.Join(operationPaths(m), ", "))
+}
+
+func (s *devSession) load(ctx context.Context, rebuild func(context.Context) error, wasmPath string) error {
+	if err := rebuild(ctx); err != nil {
+		return err
+	}
+	wasm, err := os.ReadFile(wasmPath)
+	if err != nil {
+		return fmt.Errorf("reading plugin: %w", err)
+	}
+
+	runner, err := runtime.NewPluginRunner(ctx,
+		runtime.WithVerbose(s.verbose),
+		runtime.WithTrustPlugins(true),
+	)
+	if err != nil {
+		return fmt.Errorf("creating runtime: %w", err)
+	}
+	plugin, err := runner.LoadPlugin(ctx, wasm)
+	if err != nil {
+		_ = runner.Close(context.Background())
+		return err
+	}
+	if len(plugin.Manifest.Services) == 0 {
+		_ = runner.Close(context.Background())
+		return fmt.Errorf("plugin %q declares no services", plugin.Manifest.Name)
+	}
+
+	s.mu.Lock()
+	old := s.runner
+	s.runner, s.plugin, s.manifest = runner, plugin, plugin.Manifest
+	s.mu.Unlock()
+	if old != nil {
+		_ = old.Close(context.Background())
+	}
+	return nil
+}
+
+func (s *devSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runner != nil {
+		_ = s.runner.Close(context.Background())
+		s.runner, s.plugin = nil, nil
+	}
+}
+
+// prompt prints the session prompt.
+func (s *devSession) prompt() {
+	s.mu.RLock()
+	name := s.manifest.Name
+	s.mu.RUnlock()
+	if name == "" {
+		name = "plugin"
+	}
+	_, _ = fmt.Fprintf(s.out, "%s> ", name)
+}
+
+// repl reads command lines from in until EOF, "exit", or cancellation.
+func (s *devSession) repl(ctx context.Context, in io.Reader, reload func()) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		s.prompt()
+		var line string
+		select {
+		case <-ctx.Done():
+			_, _ = fmt.Fprintln(s.out)
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				_, _ = fmt.Fprintln(s.out)
+				return nil
+			}
+			line = l
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			_, _ = fmt.Fprintf(s.errOut, "Error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "reload":
+			reload()
+			continue
+		case "help":
+			args = []string{"--help"}
+		}
+
+		if err := s.run(ctx, args); err != nil {
+			_, _ = fmt.Fprintf(s.errOut, "Error: %v\n", err)
+		}
+	}
+}
+
+// run executes one command line against the currently loaded plugin. The
+// command tree is regenerated each time so flag state never leaks between
+// runs and always reflects the latest manifest.
+func (s *devSession) run(ctx context.Context, args []string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.plugin == nil {
+		return errors.New("no plugin loaded; fix the build and save again, or type reload")
+	}
+
+	cmd := s.commandTree()
+	cmd.SetArgs(args)
+	cmd.SetOut(s.out)
+	cmd.SetErr(s.errOut)
+	return cmd.ExecuteContext(ctx)
+}
+
+// commandTree builds the plugin's command tree with operations bound to the
+// session's loaded plugin. Callers must hold s.mu.
+func (s *devSession) commandTree() *cobra.Command {
+	manifest, plugin := s.manifest, s.plugin
+	format := s.format
+
+	loader := func() ([]byte, error) { return nil, errors.New("not used in dev sessions") }
+	verbose, trust := s.verbose, true
+	root := generatePluginCommand(manifest, "", loader, nil, &format, &verbose, &trust, nil, 0)
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+	root.PersistentFlags().StringVar(&format, "output", s.format, "Output format: table, json, yaml")
+	registerOutputFormatCompletion(root)
+
+	schema, _ := parseConfigSchema(manifest.ConfigSchema)
+	isMulti := len(manifest.Services) > 1
+	var bind func(c *cobra.Command)
+	bind = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			bind(sub)
+		}
+		if c.RunE == nil || c == root {
+			return
+		}
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			service := devServiceName(manifest, cmd, isMulti)
+			op, _ := findOperation(manifest, service, cmd.Name())
+			config, err := operationConfig(cmd, schema, service, op.Name)
+			if err != nil {
+				return err
+			}
+
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			runCtx, cancel := withTimeout(cmd.Context(), timeout)
+			defer cancel()
+			result, err := plugin.Check(runCtx, config)
+			if err != nil {
+				return fmt.Errorf("executing operation: %w", err)
+			}
+			return writeDevResult(cmd.OutOrStdout(), format, result, op)
+		}
+	}
+	bind(root)
+	return root
+}
+
+// devServiceName returns the service an operation command belongs to.
+func devServiceName(manifest abi.Manifest, cmd *cobra.Command, isMulti bool) string {
+	if isMulti {
+		return cmd.Parent().Name()
+	}
+	for _, svc := range manifest.Services {
+		return svc.Name
+	}
+	return ""
+}
+
+// findOperation looks up an operation by service and name. Services are
+// matched by map key or declared name, mirroring generatePluginCommand.
+func findOperation(manifest abi.Manifest, service, name string) (abi.OperationManifest, bool) {
+	for key, svc := range manifest.Services {
+		if key != service && svc.Name != service {
+			continue
+		}
+		for _, op := range svc.Operations {
+			if op.Name == name {
+				return op, true
+			}
+		}
+	}
+	return abi.OperationManifest{Name: name}, false
+}
+
+// writeDevResult formats a result like a plugin command would, but reports
+// errors and failures as errors rather than exiting the session.
+func writeDevResult(w io.Writer, format string, result abi.Result, op abi.OperationManifest) error {
+	if result.IsError() && result.Error != nil {
+		msg := result.Error.Message
+		if result.Error.Type != "" {
+			msg += " (type: " + result.Error.Type + ")"
+		}
+		if result.Error.Code != "" {
+			msg += " (code: " + result.Error.Code + ")"
+		}
+		return errors.New(msg)
+	}
+
+	formatter, err := output.NewFormatter(format)
+	if err != nil {
+		return err
+	}
+	if err := formatter.Format(w, result, op.OutputSchema); err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	if !result.IsSuccess() {
+		return fmt.Errorf("operation returned status %q", result.Status)
+	}
+	return nil
+}
+
+// operationPaths lists a manifest's operations as "op" or "service op",
+// matching how they are typed at the dev prompt.
+func operationPaths(m abi.Manifest) []string {
+	isMulti := len(m.Services) > 1
+	var paths []string
+	for key, svc := range m.Services {
+		for _, op := range svc.Operations {
+			if isMulti {
+				paths = append(paths, key+" "+op.Name)
+			} else {
+				paths = append(paths, op.Name)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// devWatcher polls a set of files for modifications.
+type devWatcher struct {
+	interval time.Duration
+	files    func() ([]string, error)
+	state    map[string]time.Time
+}
+
+// snapshot records the current modification times.
+func (w *devWatcher) snapshot() error {
+	state, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.state = state
+	return nil
+}
+
+// changed rescans the files and reports whether any were added, removed, or
+// modified since the last scan.
+func (w *devWatcher) changed() bool {
+	state, err := w.scan()
+	if err != nil {
+		return false
+	}
+	differs := len(state) != len(w.state)
+	for path, mod := range state {
+		if prev, ok := w.state[path]; !ok || !prev.Equal(mod) {
+			differs = true
+		}
+	}
+	w.state = state
+	return differs
+}
+
+func (w *devWatcher) scan() (map[string]time.Time, error) {
+	files, err := w.files()
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		state[f] = info.ModTime()
+	}
+	return state, nil
+}
+
+// watch calls onChange after each detected change until ctx is cancelled.
+func (w *devWatcher) watch(ctx context.Context, onChange func()) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.changed() {
+				onChange()
+			}
+		}
+	}
+}
+
+// pluginSourceFiles lists the files that trigger a rebuild: Go sources and
+// module files, skipping hidden and vendor directories.
+func pluginSourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") || name == "go.mod" || name == "go.sum" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// splitArgs splits a command line into arguments, honouring single and
+// double quotes and backslash escapes.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
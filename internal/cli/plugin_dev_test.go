@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"check --host example.com", []string{"check", "--host", "example.com"}},
+		{`run --name "a b" --x 'c "d"'`, []string{"run", "--name", "a b", "--x", `c "d"`}},
+		{`echo a\ b ""`, []string{"echo", "a b", ""}},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.line)
+		if err != nil {
+			t.Errorf("splitArgs(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	if _, err := splitArgs(`check "open`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestDevWatcher(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plugin.go")
+	if err := os.WriteFile(src, []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &devWatcher{files: func() ([]string, error) { return pluginSourceFiles(dir) }}
+	if err := w.snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(w.state) != 1 {
+		t.Fatalf("expected only plugin.go to be watched, got %v", w.state)
+	}
+	if w.changed() {
+		t.Error("expected no change before modification")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(src, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Error("expected modification to be detected")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Error("expected new file to be detected")
+	}
+}
+
+func TestOperationPaths(t *testing.T) {
+	multi := abi.Manifest{Services: map[string]abi.ServiceManifest{
+		"iam": {Name: "iam", Operations: []abi.OperationManifest{{Name: "summary"}}},
+		"ec2": {Name: "ec2", Operations: []abi.OperationManifest{{Name: "describe"}}},
+	}}
+	if got := operationPaths(multi); !reflect.DeepEqual(got, []string{"ec2 describe", "iam summary"}) {
+		t.Errorf("unexpected multi-service paths: %v", got)
+	}
+
+	single := abi.Manifest{Services: map[string]abi.ServiceManifest{
+		"dns": {Name: "dns", Operations: []abi.OperationManifest{{Name: "resolve"}}},
+	}}
+	if got := operationPaths(single); !reflect.DeepEqual(got, []string{"resolve"}) {
+		t.Errorf("unexpected single-service paths: %v", got)
+	}
+	if _, ok := findOperation(single, "dns", "resolve"); !ok {
+		t.Error("expected findOperation to find dns/resolve")
+	}
+}
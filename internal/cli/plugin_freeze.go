@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// newPluginFreezeCommand creates the "plugin freeze" command.
+func newPluginFreezeCommand(stack *internalplugin.PluginStack) *cobra.Command {
+	return &cobra.Command{
+		Use:   "freeze",
+		Short: "Print the installed plugins as a requirements document",
+		Long: fmt.Sprintf(`Print the installed plugins as a requirements document: each plugin's
+name, version, digest, the registry it was pulled from, and the index it
+was chosen from. "plugin install -f" installs the same set elsewhere and
+refuses any artifact whose digest has changed since.
+
+The newest installed version of each plugin is listed. Plugins installed
+from local files cannot be installed from a registry and are left out with
+a warning. The document is YAML, or JSON with --output json.
+
+Examples:
+  %s plugin freeze > plugins.yaml
+  %s plugin install -f plugins.yaml`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins, err := stack.Service.ListCachedPlugins(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("listing installed plugins: %w", err)
+			}
+			installed := internalplugin.LoadCache(internalplugin.DefaultCachePath()).Installed
+			reqs, local := buildRequirements(plugins, installed)
+			for _, name := range local {
+				fmt.Fprintf(os.Stderr, "Warning: %s was installed from a local file and is left out\n", name)
+			}
+			format, _ := cmd.Flags().GetString("output")
+			return renderRequirements(cmd.OutOrStdout(), format, reqs)
+		},
+	}
+}
+
+// buildRequirements lists the newest installed version of each plugin
+// pulled from a registry, and returns the names of those installed from
+// local files separately.
+func buildRequirements(plugins []*hostentities.Plugin, installed map[string]internalplugin.InstallRecord) (internalplugin.Requirements, []string) {
+	newest := make(map[string]*hostentities.Plugin)
+	var local []string
+	for _, p := range plugins {
+		name := p.Metadata().Name()
+		if p.Reference().IsEmbedded() {
+			if !slices.Contains(local, name) {
+				local = append(local, name)
+			}
+			continue
+		}
+		if cur, ok := newest[name]; !ok || internalplugin.NewerVersion(p.Metadata().Version(), cur.Metadata().Version()) {
+			newest[name] = p
+		}
+	}
+
+	reqs := internalplugin.Requirements{Plugins: []internalplugin.Requirement{}}
+	for name, p := range newest {
+		ref := p.Reference()
+		s := ref.String()
+		r := internalplugin.Requirement{
+			Name:     name,
+			Version:  ref.Version(),
+			Digest:   p.Digest().String(),
+			Registry: s[:strings.LastIndex(s, "/")],
+		}
+		// A floating tag is recorded as the version it pulled
+		if r.Version == "latest" && p.Metadata().Version() != "" {
+			r.Version = p.Metadata().Version()
+		}
+		if record, ok := installed[name]; ok && strings.HasPrefix(record.Reference, r.Registry+"/") {
+			r.Index = record.Source
+		}
+		reqs.Plugins = append(reqs.Plugins, r)
+	}
+	slices.SortFunc(reqs.Plugins, func(a, b internalplugin.Requirement) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	slices.Sort(local)
+	return reqs, local
+}
+
+// renderRequirements writes a requirements document in the given format;
+// table output is the YAML document.
+func renderRequirements(w io.Writer, format string, reqs internalplugin.Requirements) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reqs)
+	case "yaml", "table", "":
+		_, _ = fmt.Fprintf(w, "# Installed plugins, written by \"%s plugin freeze\".\n", meta.AppName)
+		_, _ = fmt.Fprintf(w, "# Install them with \"%s plugin install -f <file>\".\n", meta.AppName)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(reqs)
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	abi "github.com/reglet-dev/reglet-abi"
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestBuildRequirements(t *testing.T) {
+	cached := func(ref, version string, b byte) *hostentities.Plugin {
+		r, err := hostvalues.ParsePluginReference(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := hostvalues.NewDigest("sha256", strings.Repeat(string('a'+b), 64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hostentities.NewPlugin(r, d, hostvalues.NewPluginMetadata(r.Name(), version, "", nil))
+	}
+	plugins := []*hostentities.Plugin{
+		cached("ghcr.io/acme/plugins/dns:1.0.0", "1.0.0", 0),
+		cached("ghcr.io/acme/plugins/dns:1.2.0", "1.2.0", 1),
+		cached("ghcr.io/acme/plugins/http:latest", "2.0.0", 2),
+		cached("custom", "local", 3),
+	}
+	installed := map[string]internalplugin.InstallRecord{
+		"dns": {Source: "community", Reference: "ghcr.io/acme/plugins/dns:1.2.0"},
+	}
+
+	reqs, local := buildRequirements(plugins, installed)
+	if len(local) != 1 || local[0] != "custom" {
+		t.Errorf("local = %v, want [custom]", local)
+	}
+	if len(reqs.Plugins) != 2 {
+		t.Fatalf("requirements = %+v", reqs.Plugins)
+	}
+	dns, http := reqs.Plugins[0], reqs.Plugins[1]
+	if dns.Version != "1.2.0" || dns.Index != "community" || dns.Digest != "sha256:"+strings.Repeat("b", 64) {
+		t.Errorf("dns = %+v, want the newest version from the community index", dns)
+	}
+	if http.Version != "2.0.0" || http.Reference() != "ghcr.io/acme/plugins/http:2.0.0" {
+		t.Errorf("http = %+v, want latest recorded as the version it pulled", http)
+	}
+
+	// The document "plugin install -f" reads back
+	var buf bytes.Buffer
+	if err := renderRequirements(&buf, "table", reqs); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := internalplugin.ParseRequirements(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Plugins) != 2 || parsed.Plugins[0] != dns {
+		t.Errorf("round trip = %+v", parsed.Plugins)
+	}
+
+	if err := renderRequirements(&buf, "csv", reqs); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestInstallFromRegistry_DigestMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+	host := serveTestPlugin(t, "ping", "1.0.0", []byte("wasm"))
+	ref := host + "/acme/plugins/ping:1.0.0"
+	stack, err := internalplugin.NewPluginStack(internalplugin.PluginServiceConfig{
+		CacheDir:            t.TempDir(),
+		PlainHTTPRegistries: []string{host},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginRef, err := hostvalues.ParsePluginReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "sha256:" + strings.Repeat("0", 64)
+	err = installFromRegistry(ctx, stack, config.DefaultConfig(), "", ref, want, nil, io.Discard)
+	if errcode.Of(err) != errcode.DigestMismatch {
+		t.Fatalf("expected %s, got %v", errcode.DigestMismatch, err)
+	}
+	if _, _, err := stack.Repository.Find(ctx, pluginRef); err == nil {
+		t.Error("expected the mismatched artifact to be removed")
+	}
+	if _, ok := internalplugin.LoadCache(internalplugin.DefaultCachePath()).Installed["ping"]; ok {
+		t.Error("expected no install record for the mismatched artifact")
+	}
+
+	// The digest the artifact does have installs
+	want = internalplugin.ContentDigest([]byte("wasm"))
+	if err := installFromRegistry(ctx, stack, config.DefaultConfig(), "", ref, want, nil, io.Discard); err != nil {
+		t.Fatalf("installFromRegistry: %v", err)
+	}
+	if _, _, err := stack.Repository.Find(ctx, pluginRef); err != nil {
+		t.Errorf("expected the matching artifact to be installed: %v", err)
+	}
+}
+
+// serveTestPlugin serves a plugin artifact from a minimal read-only
+// registry over plain HTTP and returns the registry host.
+func serveTestPlugin(t *testing.T, name, version string, wasm []byte) string {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.New()
+	desc, err := internalplugin.Pack(ctx, store, version, wasm, abi.Manifest{Name: name, Version: version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed ocispec.Manifest
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	blobs := make(map[string][]byte)
+	for _, d := range append([]ocispec.Descriptor{parsed.Config}, parsed.Layers...) {
+		data, err := content.FetchAll(ctx, store, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[d.Digest.String()] = data
+	}
+
+	prefix := "/v2/acme/plugins/" + name + "/"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix+"manifests/"+version || r.URL.Path == prefix+"manifests/"+desc.Digest.String():
+			w.Header().Set("Content-Type", desc.MediaType)
+			w.Header().Set("Docker-Content-Digest", desc.Digest.String())
+			if r.Method != http.MethodHead {
+				_, _ = w.Write(manifest)
+			}
+		case strings.HasPrefix(r.URL.Path, prefix+"blobs/"):
+			data, ok := blobs[strings.TrimPrefix(r.URL.Path, prefix+"blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// pluginInfo is the detail view of an installed plugin.
+type pluginInfo struct {
+	Name         string                    `json:"name" yaml:"name"`
+	Version      string                    `json:"version" yaml:"version"`
+	Description  string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	Digest       string                    `json:"digest" yaml:"digest"`
+	Capabilities []string                  `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	Index        string                    `json:"index,omitempty" yaml:"index,omitempty"`
+	Reference    string                    `json:"reference,omitempty" yaml:"reference,omitempty"`
+	Variant      string                    `json:"variant,omitempty" yaml:"variant,omitempty"`
+	Provenance   internalplugin.Provenance `json:"provenance" yaml:"provenance"`
+}
+
+// newPluginInfoCommand creates the "plugin info" command.
+func newPluginInfoCommand(stack *internalplugin.PluginStack) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <name>[@version]",
+		Short: "Show an installed plugin's details and provenance",
+		Long: fmt.Sprintf(`Show an installed plugin's version, digest, and capabilities, where it
+was installed from, and the provenance its publisher annotated the OCI
+artifact with: license, homepage, source repository, authors, and
+documentation (the org.opencontainers.image.* annotations).
+
+Without a version, the newest installed version is shown.
+
+Examples:
+  %s plugin info dns
+  %s plugin info dns@1.2.0 --output json`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, version := parseNameVersion(args[0])
+			plugins, err := stack.Service.ListCachedPlugins(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("listing installed plugins: %w", err)
+			}
+			var found *hostentities.Plugin
+			for _, p := range plugins {
+				m := p.Metadata()
+				if m.Name() != name || (version != "" && m.Version() != version) {
+					continue
+				}
+				if found == nil || internalplugin.NewerVersion(m.Version(), found.Metadata().Version()) {
+					found = p
+				}
+			}
+			if found == nil {
+				return fmt.Errorf("plugin %q is not installed", args[0])
+			}
+
+			record := internalplugin.LoadCache(internalplugin.DefaultCachePath()).Installed[name]
+			format, _ := cmd.Flags().GetString("output")
+			return renderPluginInfo(cmd.OutOrStdout(), format, buildPluginInfo(found, record))
+		},
+	}
+}
+
+// buildPluginInfo combines a cached plugin with its install record.
+func buildPluginInfo(p *hostentities.Plugin, record internalplugin.InstallRecord) pluginInfo {
+	m := p.Metadata()
+	return pluginInfo{
+		Name:         m.Name(),
+		Version:      m.Version(),
+		Description:  m.Description(),
+		Digest:       p.Digest().String(),
+		Capabilities: m.Capabilities(),
+		Index:        record.Source,
+		Reference:    record.Reference,
+		Variant:      record.Variant,
+		Provenance:   record.Provenance,
+	}
+}
+
+// renderPluginInfo writes a plugin's detail view in the given format.
+func renderPluginInfo(w io.Writer, format string, info pluginInfo) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(info)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		row := func(label, value string) {
+			_, _ = fmt.Fprintf(tw, "%s:\t%s\n", label, orDash(value))
+		}
+		row("Name", info.Name)
+		row("Version", info.Version)
+		row("Description", info.Description)
+		row("Digest", info.Digest)
+		row("Capabilities", strings.Join(info.Capabilities, ", "))
+		row("Index", info.Index)
+		row("Reference", info.Reference)
+		if info.Variant != "" {
+			row("Variant", info.Variant)
+		}
+		row("License", info.Provenance.License)
+		row("Homepage", info.Provenance.Homepage)
+		row("Source", info.Provenance.Source)
+		row("Authors", info.Provenance.Authors)
+		row("Documentation", info.Provenance.Documentation)
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func TestRenderPluginInfo(t *testing.T) {
+	info := pluginInfo{
+		Name:       "dns",
+		Version:    "1.2.0",
+		Digest:     "sha256:abc",
+		Reference:  "ghcr.io/reglet-dev/plugins/dns:1.2.0",
+		Provenance: internalplugin.Provenance{License: "Apache-2.0", Source: "https://github.com/reglet-dev/dns"},
+	}
+
+	var buf bytes.Buffer
+	if err := renderPluginInfo(&buf, "table", info); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"License:        Apache-2.0", "Source:         https://github.com/reglet-dev/dns", "Authors:        -"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := renderPluginInfo(&buf, "json", info); err != nil {
+		t.Fatal(err)
+	}
+	var decoded pluginInfo
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Provenance != info.Provenance {
+		t.Errorf("json round trip = %+v (%v)", decoded, err)
+	}
+
+	if err := renderPluginInfo(&buf, "csv", info); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// newPluginQuarantineCommand creates the "plugin quarantine" command group.
+func newPluginQuarantineCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quarantine",
+		Short: "Inspect plugins that failed verification",
+		Long: `Plugins whose binary no longer matches its recorded digest, or whose
+signature does not verify when pulled, are moved to a quarantine directory
+with a record of why, and are left out of discovery.
+
+Restore one to put it back where it was installed, or purge them once
+investigated.`,
+	}
+	cmd.AddCommand(
+		newPluginQuarantineListCommand(stack),
+		newPluginQuarantineRestoreCommand(stack, cfg),
+		newPluginQuarantinePurgeCommand(stack, cfg),
+	)
+	return cmd
+}
+
+// newPluginQuarantineListCommand creates the "plugin quarantine list" command.
+func newPluginQuarantineListCommand(stack *internalplugin.PluginStack) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List quarantined plugins",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := internalplugin.ListQuarantine(stack.QuarantineDir)
+			if err != nil {
+				return fmt.Errorf("listing quarantine: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(records) == 0 {
+				_, _ = fmt.Fprintln(out, "No plugins in quarantine.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tPLUGIN\tQUARANTINED\tREASON")
+			for _, r := range records {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					r.ID, r.Name, r.QuarantinedAt.Local().Format(time.DateTime), r.Reason)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// newPluginQuarantineRestoreCommand creates the "plugin quarantine restore"
+// command.
+func newPluginQuarantineRestoreCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <id>",
+		Short: "Move a quarantined plugin back to where it was installed",
+		Long: `Move a quarantined plugin back to where it was installed.
+
+The plugin is checked again when it is next discovered or loaded, so one
+that still fails verification returns to quarantine.`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: denyInReadOnly(cfg),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return quarantineIDs(stack), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r, err := internalplugin.RestoreQuarantined(stack.QuarantineDir, args[0])
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Restored %s to %s\n", r.Name, r.Path)
+			return nil
+		},
+	}
+}
+
+// newPluginQuarantinePurgeCommand creates the "plugin quarantine purge"
+// command.
+func newPluginQuarantinePurgeCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "purge [id...]",
+		Short:   "Delete quarantined plugins (all of them without arguments)",
+		PreRunE: denyInReadOnly(cfg),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return quarantineIDs(stack), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := internalplugin.PurgeQuarantine(stack.QuarantineDir, args...)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Purged %d quarantined plugin(s)\n", len(records))
+			return nil
+		},
+	}
+}
+
+// quarantineIDs completes quarantine entry IDs.
+func quarantineIDs(stack *internalplugin.PluginStack) []string {
+	records, _ := internalplugin.ListQuarantine(stack.QuarantineDir)
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.ID+"\t"+r.Name)
+	}
+	return ids
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// newPluginKeyCommand creates the "plugin key" command group.
+func newPluginKeyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "Manage plugin signing keys",
+		Long: `Manage cosign key pairs used to sign plugins.
+
+Private keys are encrypted with the password in COSIGN_PASSWORD.`,
+	}
+	cmd.AddCommand(newPluginKeyGenerateCommand(), newPluginKeyImportCommand())
+	return cmd
+}
+
+// newPluginKeyGenerateCommand creates the "plugin key generate" command.
+func newPluginKeyGenerateCommand() *cobra.Command {
+	var (
+		prefix string
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a cosign key pair",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyPath, pubPath, err := internalplugin.GenerateKeys(prefix, keyPassword(), force)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Private key written to %s\nPublic key written to %s\n", keyPath, pubPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&prefix, "out", internalplugin.DefaultKeyPrefix(), "Key path prefix; writes <out>.key and <out>.pub")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing keys")
+	return cmd
+}
+
+// newPluginKeyImportCommand creates the "plugin key import" command.
+func newPluginKeyImportCommand() *cobra.Command {
+	var (
+		prefix string
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <private-key.pem>",
+		Short: "Import a PEM private key as a cosign key pair",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyPath, pubPath, err := internalplugin.ImportKeys(args[0], prefix, keyPassword(), force)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Private key written to %s\nPublic key written to %s\n", keyPath, pubPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&prefix, "out", internalplugin.DefaultKeyPrefix(), "Key path prefix; writes <out>.key and <out>.pub")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing keys")
+	return cmd
+}
+
+// keyPassword returns the key password from the environment, warning when
+// the key would be stored without one.
+func keyPassword() []byte {
+	pw := os.Getenv(internalplugin.PasswordEnv)
+	if pw == "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s is empty; the private key will not be password protected\n", internalplugin.PasswordEnv)
+	}
+	return []byte(pw)
+}
+
+// newPluginSignCommand creates the "plugin sign" command.
+func newPluginSignCommand() *cobra.Command {
+	var (
+		keyPath   string
+		keyless   bool
+		plainHTTP bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sign <file|reference>",
+		Short: "Sign a local plugin file or a pushed plugin",
+		Long: fmt.Sprintf(`Sign a plugin with a cosign key.
+
+For a local .wasm file the signature is written next to it as <file>.sig,
+compatible with "cosign verify-blob". For a registry reference the
+signature is pushed to the registry alongside the artifact.
+
+--keyless uses Sigstore's OIDC flow through the cosign CLI, which must be
+installed.
+
+Examples:
+  %s plugin sign ./ping.wasm
+  %s plugin sign ghcr.io/me/plugins/ping:1.0.0 --key release.key
+  %s plugin sign ghcr.io/me/plugins/ping:1.0.0 --keyless`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			out := cmd.OutOrStdout()
+			target := args[0]
+			_, statErr := os.Stat(target)
+			isLocal := statErr == nil
+
+			if keyless {
+				return cosignKeyless(ctx, cmd, target, isLocal, plainHTTP)
+			}
+
+			signer, err := internalplugin.LoadSigner(keyPath)
+			if err != nil {
+				return err
+			}
+
+			if isLocal {
+				data, err := os.ReadFile(target)
+				if err != nil {
+					return fmt.Errorf("reading plugin: %w", err)
+				}
+				sig, err := internalplugin.SignBlob(data, signer)
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(target+".sig", []byte(sig+"\n"), 0o644); err != nil {
+					return fmt.Errorf("writing signature: %w", err)
+				}
+				_, _ = fmt.Fprintf(out, "Signature written to %s.sig\n", target)
+				return nil
+			}
+
+			ref, digest, err := resolveRemoteDigest(ctx, target, plainHTTP)
+			if err != nil {
+				return err
+			}
+			if err := signRemote(ctx, ref, digest, signer, internalplugin.NewCredentialProvider(), plainHTTP); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "Signed %s@%s\n", ref, digest)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&keyPath, "key", internalplugin.DefaultSigningKeyPath(), "Cosign private key")
+	cmd.Flags().BoolVar(&keyless, "keyless", false, "Sign with Sigstore keyless OIDC (requires the cosign CLI)")
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "Use HTTP instead of HTTPS (local registries)")
+	return cmd
+}
+
+// newPluginVerifyCommand creates the "plugin verify" command.
+func newPluginVerifyCommand() *cobra.Command {
+	var (
+		pubPath   string
+		sigPath   string
+		plainHTTP bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify <file|reference>",
+		Short: "Verify a plugin signature with a public key",
+		Long: fmt.Sprintf(`Verify a plugin's cosign signature with a public key.
+
+Local files are checked against <file>.sig (or --signature). Registry
+references are checked against the signatures stored in the registry,
+found through the referrers API or cosign's sha256-<digest>.sig tag.
+
+Examples:
+  %s plugin verify ./ping.wasm --key cosign.pub
+  %s plugin verify ghcr.io/me/plugins/ping:1.0.0 --key cosign.pub`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			target := args[0]
+
+			verifier, err := internalplugin.LoadVerifier(pubPath)
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(target); err == nil {
+				if sigPath == "" {
+					sigPath = target + ".sig"
+				}
+				data, err := os.ReadFile(target)
+				if err != nil {
+					return fmt.Errorf("reading plugin: %w", err)
+				}
+				sig, err := os.ReadFile(sigPath)
+				if err != nil {
+					return fmt.Errorf("reading signature: %w", err)
+				}
+				if err := internalplugin.VerifyBlob(data, string(sig), verifier); err != nil {
+					return fmt.Errorf("verification failed for %s: %w", target, err)
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Verified %s\n", target)
+				return nil
+			}
+
+			ref, digest, err := resolveRemoteDigest(ctx, target, plainHTTP)
+			if err != nil {
+				return err
+			}
+			repo, err := remoteRepository(ctx, ref, plainHTTP)
+			if err != nil {
+				return err
+			}
+			if err := internalplugin.Verify(ctx, repo, digest, verifier); err != nil {
+				return fmt.Errorf("verification failed for %s: %w", ref, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Verified %s@%s\n", ref, digest)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&pubPath, "key", internalplugin.DefaultPublicKeyPath(), "Cosign public key")
+	cmd.Flags().StringVar(&sigPath, "signature", "", "Signature file for local plugins (default: <file>.sig)")
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "Use HTTP instead of HTTPS (local registries)")
+	return cmd
+}
+
+// remoteRepository returns a registry client for ref using stored or environment credentials.
+func remoteRepository(ctx context.Context, ref string, plainHTTP bool) (*remote.Repository, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	return internalplugin.NewRemoteRepository(ctx, parsed, internalplugin.NewCredentialProvider(), plainHTTP)
+}
+
+// resolveRemoteDigest resolves a tag or digest reference to its manifest digest.
+func resolveRemoteDigest(ctx context.Context, ref string, plainHTTP bool) (string, string, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid reference %q (not a file or registry reference): %w", ref, err)
+	}
+	if parsed.Reference == "" {
+		return "", "", fmt.Errorf("reference %q must include a tag or digest", ref)
+	}
+	repo, err := internalplugin.NewRemoteRepository(ctx, parsed, internalplugin.NewCredentialProvider(), plainHTTP)
+	if err != nil {
+		return "", "", err
+	}
+	desc, err := repo.Resolve(ctx, parsed.Reference)
+	if err != nil {
+		return "", "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return parsed.String(), desc.Digest.String(), nil
+}
+
+// cosignKeyless signs target with the cosign CLI's keyless flow.
+func cosignKeyless(ctx context.Context, cmd *cobra.Command, target string, isLocal, plainHTTP bool) error {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return fmt.Errorf("--keyless requires the cosign CLI in PATH: %w", err)
+	}
+
+	var args []string
+	if isLocal {
+		args = []string{"sign-blob", "--yes", "--bundle", target + ".bundle", target}
+	} else {
+		ref, digest, err := resolveRemoteDigest(ctx, target, plainHTTP)
+		if err != nil {
+			return err
+		}
+		parsed, _ := registry.ParseReference(ref)
+		args = []string{"sign", "--yes", parsed.Registry + "/" + parsed.Repository + "@" + digest}
+		if plainHTTP {
+			args = append(args, "--allow-http-registry")
+		}
+	}
+
+	c := exec.CommandContext(ctx, "cosign", args...)
+	c.Stdin = os.Stdin
+	c.Stdout = cmd.OutOrStdout()
+	c.Stderr = cmd.ErrOrStderr()
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("cosign keyless signing failed: %w", err)
+	}
+	return nil
+}
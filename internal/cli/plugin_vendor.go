@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// newPluginVendorCommand creates the "plugin vendor" command.
+func newPluginVendorCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "vendor <name>[@version]...",
+		Short: "Copy installed plugins into the workspace",
+		Long: fmt.Sprintf(`Copy installed plugins into the workspace's .%[1]s/plugins directory, so
+the repository pins its tooling alongside the code. Each plugin is written
+as <name>.wasm, replacing an earlier vendored copy, from the newest
+installed version unless one is given.
+
+The workspace is the nearest .%[1]s directory at or above the working
+directory; outside one, vendoring starts a workspace in the working
+directory. Once the workspace is trusted ("workspace trust"), its plugins
+are found before the user's plugins directory, embedded plugins, and
+registries; "plugin which" shows which copy a name resolves to. Commit the directory to share the plugins with
+everyone working in the repository.
+
+Examples:
+  %[1]s plugin vendor dns http
+  %[1]s plugin vendor dns@1.2.0`, meta.AppName),
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: denyInReadOnly(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			cached, err := stack.Service.ListCachedPlugins(ctx)
+			if err != nil {
+				return fmt.Errorf("listing installed plugins: %w", err)
+			}
+
+			dir := internalplugin.ProjectPluginsDir()
+			if err := internalplugin.EnsurePluginsDir(dir); err != nil {
+				return fmt.Errorf("creating %s: %w", dir, err)
+			}
+			out := cmd.OutOrStdout()
+			for _, arg := range args {
+				name, version := parseNameVersion(arg)
+				p := findCached(cached, name, version)
+				if p == nil {
+					return errcode.Errorf(errcode.PluginNotFound, "plugin %q is not installed; run \"%s plugin install %s\" first", arg, meta.AppName, arg)
+				}
+				data, err := readCached(ctx, stack, p)
+				if err != nil {
+					return err
+				}
+				path := filepath.Join(dir, name+".wasm")
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					return fmt.Errorf("vendoring %s: %w", name, err)
+				}
+				_, _ = fmt.Fprintf(out, "Vendored %s %s to %s\n", name, p.Metadata().Version(), relToWorkingDir(path))
+			}
+			return nil
+		},
+	}
+}
+
+// relToWorkingDir returns path relative to the working directory when it
+// is inside it, and path unchanged otherwise.
+func relToWorkingDir(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path
+	}
+	return rel
+}
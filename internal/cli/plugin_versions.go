@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/v2/registry"
+)
+
+// pluginVersions is the output of "plugin versions".
+type pluginVersions struct {
+	Name       string          `json:"name" yaml:"name"`
+	Repository string          `json:"repository" yaml:"repository"`
+	Versions   []pluginVersion `json:"versions" yaml:"versions"`
+}
+
+// pluginVersion is one published or cached version of a plugin.
+type pluginVersion struct {
+	Version   string `json:"version" yaml:"version"`
+	Published bool   `json:"published" yaml:"published"`
+	Cached    bool   `json:"cached" yaml:"cached"`
+	Installed bool   `json:"installed" yaml:"installed"`
+}
+
+// newPluginVersionsCommand creates the "plugin versions" command.
+func newPluginVersionsCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	var plainHTTP bool
+
+	cmd := &cobra.Command{
+		Use:   "versions <name>",
+		Short: "List the published versions of a plugin",
+		Long: `List the versions published for a plugin in its registry, newest first.
+The installed version is marked, as are other versions in the local cache.
+
+The name may be source-qualified (community/dns) to use the registry that
+index declares, or a full repository (ghcr.io/my-org/plugins/dns). Tags
+that are not versions, such as "latest", are not listed.`,
+		Example: fmt.Sprintf(`  %s plugin versions dns
+  %s plugin versions community/dns --output json`, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			name, repository, err := resolvePluginRepository(ctx, cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			published, err := listPublishedVersions(ctx, repository, plainHTTP || cfg.IsPlainHTTPRegistry(repository))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+
+			cached, err := cachedPluginVersions(ctx, stack, name)
+			if err != nil {
+				return err
+			}
+			if len(published) == 0 && len(cached) == 0 {
+				return fmt.Errorf("no versions found for %s", repository)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderPluginVersions(cmd.OutOrStdout(), format, buildVersionList(name, repository, published, cached))
+		},
+	}
+
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "Use HTTP instead of HTTPS (local registries)")
+	return cmd
+}
+
+// resolvePluginRepository returns the plugin name and registry repository
+// for a short, source-qualified, or fully qualified plugin name. Any
+// version or tag is ignored.
+func resolvePluginRepository(ctx context.Context, cfg *config.Config, target string) (name, repository string, err error) {
+	if source, rest, ok := internalplugin.SplitQualifiedName(target); ok {
+		name, _ = parseNameVersion(rest)
+		entry, err := lookupIndexEntry(ctx, cfg, source, name)
+		if err != nil {
+			return "", "", err
+		}
+		return name, strings.TrimSuffix(entry.Registry, "/") + "/" + name, nil
+	}
+	if strings.Contains(target, "/") {
+		ref, err := registry.ParseReference(target)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid plugin repository %q: %w", target, err)
+		}
+		return path.Base(ref.Repository), ref.Registry + "/" + ref.Repository, nil
+	}
+	name, _ = parseNameVersion(target)
+	return name, strings.TrimSuffix(cfg.DefaultRegistry, "/") + "/" + name, nil
+}
+
+// listPublishedVersions lists the version tags of a registry repository.
+func listPublishedVersions(ctx context.Context, repository string, plainHTTP bool) ([]string, error) {
+	ref, err := registry.ParseReference(repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin repository %q: %w", repository, err)
+	}
+	repo, err := internalplugin.NewRemoteRepository(ctx, ref, internalplugin.NewCredentialProvider(), plainHTTP)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := internalplugin.PublishedVersions(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", repository, err)
+	}
+	return versions, nil
+}
+
+// cachedPluginVersions returns the versions of a plugin in the local cache.
+func cachedPluginVersions(ctx context.Context, stack *internalplugin.PluginStack, name string) ([]string, error) {
+	plugins, err := stack.Service.ListCachedPlugins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing installed plugins: %w", err)
+	}
+	var versions []string
+	for _, p := range plugins {
+		if p.Metadata().Name() == name {
+			versions = append(versions, p.Metadata().Version())
+		}
+	}
+	return versions, nil
+}
+
+// buildVersionList merges published and cached versions, newest first. The
+// newest cached version is the installed one, as for "plugin search".
+func buildVersionList(name, repository string, published, cached []string) pluginVersions {
+	seen := map[string]*pluginVersion{}
+	var order []string
+	add := func(v string) *pluginVersion {
+		if pv, ok := seen[v]; ok {
+			return pv
+		}
+		seen[v] = &pluginVersion{Version: v}
+		order = append(order, v)
+		return seen[v]
+	}
+	for _, v := range published {
+		add(v).Published = true
+	}
+	installed := ""
+	for _, v := range cached {
+		add(v).Cached = true
+		if installed == "" || internalplugin.NewerVersion(v, installed) {
+			installed = v
+		}
+	}
+	if installed != "" {
+		seen[installed].Installed = true
+	}
+
+	internalplugin.SortVersions(order)
+	list := pluginVersions{Name: name, Repository: repository, Versions: []pluginVersion{}}
+	for _, v := range order {
+		list.Versions = append(list.Versions, *seen[v])
+	}
+	return list
+}
+
+// renderPluginVersions writes a version list in the given format.
+func renderPluginVersions(w io.Writer, format string, list pluginVersions) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(list)
+	case "table", "":
+		// Without any published version the registry was not read, so
+		// versions are not marked unpublished.
+		anyPublished := false
+		for _, v := range list.Versions {
+			anyPublished = anyPublished || v.Published
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "VERSION\tSTATUS")
+		for _, v := range list.Versions {
+			var status []string
+			if v.Installed {
+				status = append(status, "installed")
+			} else if v.Cached {
+				status = append(status, "cached")
+			}
+			if anyPublished && !v.Published {
+				status = append(status, "not published")
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", v.Version, strings.Join(status, ", "))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestBuildVersionList(t *testing.T) {
+	list := buildVersionList("dns", "ghcr.io/acme/dns",
+		[]string{"1.10.0", "1.2.0", "1.9.0"},
+		[]string{"1.2.0", "1.9.0", "0.1.0-dev"})
+
+	var got []string
+	for _, v := range list.Versions {
+		got = append(got, v.Version)
+	}
+	if strings.Join(got, ",") != "1.10.0,1.9.0,1.2.0,0.1.0-dev" {
+		t.Fatalf("unexpected order: %v", got)
+	}
+	want := []pluginVersion{
+		{Version: "1.10.0", Published: true},
+		{Version: "1.9.0", Published: true, Cached: true, Installed: true},
+		{Version: "1.2.0", Published: true, Cached: true},
+		{Version: "0.1.0-dev", Cached: true},
+	}
+	for i, v := range want {
+		if list.Versions[i] != v {
+			t.Errorf("version %d: expected %+v, got %+v", i, v, list.Versions[i])
+		}
+	}
+}
+
+func TestRenderPluginVersions(t *testing.T) {
+	list := buildVersionList("dns", "ghcr.io/acme/dns", []string{"1.1.0", "1.0.0"}, []string{"1.0.0", "0.9.0"})
+
+	var buf bytes.Buffer
+	if err := renderPluginVersions(&buf, "table", list); err != nil {
+		t.Fatalf("renderPluginVersions: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if strings.TrimSpace(lines[1]) != "1.1.0" || !strings.Contains(lines[2], "installed") ||
+		!strings.Contains(lines[3], "cached, not published") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := renderPluginVersions(&buf, "json", list); err != nil {
+		t.Fatalf("renderPluginVersions: %v", err)
+	}
+	var decoded pluginVersions
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Versions) != 3 || !decoded.Versions[1].Installed {
+		t.Errorf("unexpected JSON (%v):\n%s", err, buf.String())
+	}
+
+	// Cached versions alone are not marked unpublished.
+	buf.Reset()
+	_ = renderPluginVersions(&buf, "table", buildVersionList("dns", "ghcr.io/acme/dns", nil, []string{"1.0.0"}))
+	if strings.Contains(buf.String(), "not published") {
+		t.Errorf("unexpected unpublished marker:\n%s", buf.String())
+	}
+}
+
+func TestResolvePluginRepository(t *testing.T) {
+	cfg := &config.Config{DefaultRegistry: "ghcr.io/reglet-dev/plugins/"}
+	tests := []struct{ target, name, repository string }{
+		{"dns", "dns", "ghcr.io/reglet-dev/plugins/dns"},
+		{"dns@1.2.0", "dns", "ghcr.io/reglet-dev/plugins/dns"},
+		{"ghcr.io/acme/tools/ping:1.0.0", "ping", "ghcr.io/acme/tools/ping"},
+	}
+	for _, tt := range tests {
+		name, repository, err := resolvePluginRepository(context.Background(), cfg, tt.target)
+		if err != nil || name != tt.name || repository != tt.repository {
+			t.Errorf("%s: got %q, %q, %v", tt.target, name, repository, err)
+		}
+	}
+}
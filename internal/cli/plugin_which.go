@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// newPluginWhichCommand creates the "plugin which" command.
+func newPluginWhichCommand(stack *internalplugin.PluginStack, cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "which <name>[@version]",
+		Short: "Explain which plugin binary a name resolves to",
+		Long: fmt.Sprintf(`Explain how a plugin name resolves: every place it is looked up, in
+precedence order, and the one that would be loaded with its version and
+digest.
+
+Names resolve to, in order: a file in the trusted workspace's
+.%[1]s/plugins directory, then in the user's plugins directory (<name>.wasm, then
+<name>@<version>.wasm, newest first), a plugin embedded in the binary, and
+an OCI reference under default_registry. A
+source-qualified name ("community/dns") resolves to the reference it was
+installed from. Candidates after the first one found are shadowed; a
+candidate the policy blocks or that fails verification is refused, and
+loading fails rather than moving on. Quarantined copies are listed last.
+
+Nothing is pulled, loaded, or quarantined.
+
+Examples:
+  %[1]s plugin which dns
+  %[1]s plugin which community/dns --output json`, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loader := internalplugin.NewLoader(internalplugin.EmbeddedPlugins, internalplugin.DefaultPluginsDir(), stack, cfg.DefaultRegistry)
+			res := loader.Explain(cmd.Context(), args[0])
+			format, _ := cmd.Flags().GetString("output")
+			if err := renderResolution(cmd.OutOrStdout(), format, res); err != nil {
+				return err
+			}
+			switch {
+			case res.Selected != nil:
+				return nil
+			case slices.ContainsFunc(res.Candidates, func(c internalplugin.Candidate) bool {
+				return c.Status == internalplugin.CandidateRefused
+			}):
+				return errors.New(res.Error)
+			default:
+				return errcode.New(errcode.PluginNotFound, res.Error)
+			}
+		},
+	}
+}
+
+// renderResolution writes how a plugin name resolves in the given format.
+func renderResolution(w io.Writer, format string, res internalplugin.Resolution) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(res)
+	case "table", "":
+		if s := res.Selected; s != nil {
+			_, _ = fmt.Fprintf(w, "%s resolves to %s %s\n", res.Name, s.Source, s.Location)
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintf(tw, "Version:\t%s\n", orDash(s.Version))
+			_, _ = fmt.Fprintf(tw, "Digest:\t%s\n", orDash(s.Digest))
+			if s.Source == "oci" {
+				_, _ = fmt.Fprintf(tw, "Pin:\t%s\n", pinOf(s.Location))
+			}
+			if s.Path != "" {
+				_, _ = fmt.Fprintf(tw, "Path:\t%s\n", s.Path)
+			}
+			_ = tw.Flush()
+		} else {
+			_, _ = fmt.Fprintf(w, "%s does not resolve: %s\n", res.Name, res.Error)
+		}
+		_, _ = fmt.Fprintln(w)
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "#\tSOURCE\tLOCATION\tSTATUS\tREASON")
+		for i, c := range res.Candidates {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, c.Source, c.Location, c.Status, orDash(c.Reason))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+func TestRenderResolution(t *testing.T) {
+	selected := internalplugin.Candidate{
+		Source:   "oci",
+		Location: "ghcr.io/acme/plugins/dns:1.2.0",
+		Status:   internalplugin.CandidateSelected,
+		Path:     "/home/u/.tack/plugins/ghcr.io/acme/plugins/dns:1.2.0/plugin.wasm",
+		Version:  "1.2.0",
+		Digest:   "sha256:abc",
+	}
+	res := internalplugin.Resolution{
+		Name: "dns@1.2.0",
+		Candidates: []internalplugin.Candidate{
+			{Source: "local", Location: "/home/u/.tack/plugins/dns@1.2.0.wasm", Status: internalplugin.CandidateAbsent},
+			{Source: "embedded", Location: "plugins/dns@1.2.0.wasm", Status: internalplugin.CandidateAbsent},
+			selected,
+		},
+		Selected: &selected,
+	}
+
+	var buf bytes.Buffer
+	if err := renderResolution(&buf, "table", res); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"dns@1.2.0 resolves to oci ghcr.io/acme/plugins/dns:1.2.0",
+		"Digest:   sha256:abc",
+		"Pin:      version",
+		"3  oci       ghcr.io/acme/plugins/dns:1.2.0        selected  -",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	res.Selected, res.Error = nil, `plugin "dns@1.2.0" not found`
+	if err := renderResolution(&buf, "table", res); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `dns@1.2.0 does not resolve: plugin "dns@1.2.0" not found`) {
+		t.Errorf("expected the failure to be explained:\n%s", buf.String())
+	}
+
+	if err := renderResolution(&buf, "csv", res); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
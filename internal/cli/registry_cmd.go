@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"golang.org/x/term"
+)
+
+// newRegistryCommand creates the "registry" command group.
+func newRegistryCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Manage credentials for plugin registries",
+		Long: `Manage the credentials used to pull, publish, and sign plugins in
+OCI registries.
+
+Credentials are stored in the operating system keyring (Keychain, Secret
+Service, or Windows Credential Manager), never in the config file.
+REGISTRY_USERNAME and REGISTRY_PASSWORD, when set, take precedence over
+stored credentials.`,
+	}
+	cmd.AddCommand(
+		newRegistryLoginCommand(cfg),
+		newRegistryLogoutCommand(),
+	)
+	return cmd
+}
+
+// newRegistryLoginCommand creates the "registry login" command.
+func newRegistryLoginCommand(cfg *config.Config) *cobra.Command {
+	var (
+		username      string
+		passwordStdin bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login <registry>",
+		Short: "Store credentials for a registry in the OS keyring",
+		Long: `Store credentials for a registry in the OS keyring.
+
+The password is prompted for without echo, or read from standard input with
+--password-stdin so it stays out of shell history.`,
+		Example: fmt.Sprintf(`  %s registry login ghcr.io -u octocat
+  echo "$TOKEN" | %s registry login ghcr.io -u octocat --password-stdin`, meta.AppName, meta.AppName),
+		Args:    cobra.ExactArgs(1),
+		PreRunE: denyInReadOnly(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			out := cmd.OutOrStdout()
+			password, err := readRegistryLogin(in, out, &username, passwordStdin)
+			if err != nil {
+				return err
+			}
+			if err := internalplugin.SaveRegistryCredentials(args[0], username, password); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "Stored credentials for %s\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&username, "username", "u", "", "Registry username (prompted for if omitted)")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from standard input")
+	return cmd
+}
+
+// readRegistryLogin reads the username, when not given, and the password.
+// Prompts need a terminal; without one the password must come from
+// --password-stdin.
+func readRegistryLogin(in io.Reader, out io.Writer, username *string, passwordStdin bool) (string, error) {
+	if passwordStdin {
+		if *username == "" {
+			return "", errors.New("--username is required with --password-stdin")
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return "", fmt.Errorf("reading password: %w", err)
+		}
+		return nonEmptyPassword(strings.TrimRight(string(data), "\r\n"))
+	}
+
+	f, ok := in.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return "", errors.New("no terminal to prompt for a password; use --password-stdin")
+	}
+	if *username == "" {
+		_, _ = fmt.Fprint(out, "Username: ")
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading username: %w", err)
+		}
+		*username = strings.TrimSpace(line)
+		if *username == "" {
+			return "", errors.New("username is required")
+		}
+	}
+	_, _ = fmt.Fprint(out, "Password: ")
+	data, err := term.ReadPassword(int(f.Fd()))
+	_, _ = fmt.Fprintln(out)
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return nonEmptyPassword(string(data))
+}
+
+func nonEmptyPassword(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
+
+// newRegistryLogoutCommand creates the "registry logout" command. It is
+// allowed in read-only mode, as removing credentials only reduces access.
+func newRegistryLogoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout <registry>",
+		Short: "Remove the credentials stored for a registry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := internalplugin.DeleteRegistryCredentials(args[0]); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed credentials for %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/zalando/go-keyring"
+)
+
+func TestRegistryLoginLogout(t *testing.T) {
+	keyring.MockInit()
+	cfg := config.DefaultConfig()
+
+	run := func(stdin string, args ...string) (string, error) {
+		cmd := newRegistryCommand(cfg)
+		var out bytes.Buffer
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("", "login", "ghcr.io", "-u", "octocat"); err == nil || !strings.Contains(err.Error(), "--password-stdin") {
+		t.Errorf("expected login without a terminal to require --password-stdin, got %v", err)
+	}
+	if _, err := run("token\n", "login", "ghcr.io", "--password-stdin"); err == nil {
+		t.Error("expected --password-stdin without --username to fail")
+	}
+	out, err := run("token-value\n", "login", "ghcr.io", "-u", "octocat", "--password-stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Stored credentials for ghcr.io") {
+		t.Errorf("unexpected login output: %q", out)
+	}
+
+	if _, err := run("", "logout", "ghcr.io"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("", "logout", "ghcr.io"); !errors.Is(err, internalplugin.ErrNotLoggedIn) {
+		t.Errorf("expected ErrNotLoggedIn, got %v", err)
+	}
+
+	cfg.ReadOnly = true
+	if _, err := run("token-value\n", "login", "ghcr.io", "-u", "octocat", "--password-stdin"); !errors.Is(err, config.ErrReadOnly) {
+		t.Errorf("expected login to be refused in read-only mode, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// RemoteFlag runs plugin operations on another machine over SSH.
+const RemoteFlag = "remote"
+
+// remoteExecution holds the host plugin operations run on while --remote
+// is set.
+var remoteExecution struct {
+	mu   sync.Mutex
+	host *remoteHost
+}
+
+// remoteHost is a machine reached over SSH that runs operations with
+// "tack agent".
+type remoteHost struct {
+	target  string   // [user@]host
+	command string   // runs tack on the host
+	options []string // ssh options, before the host
+}
+
+// runRemotelyOn makes the plugin operations run by this process run on
+// target over SSH, reached as cfg.Remote says. An empty target runs them
+// locally.
+func runRemotelyOn(cfg *config.Config, target string) {
+	remoteExecution.mu.Lock()
+	defer remoteExecution.mu.Unlock()
+	if target == "" {
+		remoteExecution.host = nil
+		return
+	}
+	host := &remoteHost{target: target, command: cfg.Remote.Command, options: cfg.Remote.SSHOptions}
+	if host.command == "" {
+		host.command = meta.AppName
+	}
+	remoteExecution.host = host
+}
+
+// activeRemote returns the host operations run on, or nil to run them
+// locally.
+func activeRemote() *remoteHost {
+	remoteExecution.mu.Lock()
+	defer remoteExecution.mu.Unlock()
+	return remoteExecution.host
+}
+
+// agentRequest is the operation --remote sends to "tack agent".
+type agentRequest struct {
+	// Plugin is the WASM binary, and Digest its content digest.
+	Plugin []byte `json:"plugin"`
+	Digest string `json:"digest"`
+
+	Config map[string]any `json:"config"`
+
+	// Grants are the capabilities granted locally; the plugin gets
+	// exactly these on the remote host.
+	Grants *hostfunc.GrantSet `json:"grants"`
+
+	// Timeout is what is left of the local timeout, if there is one.
+	Timeout string `json:"timeout,omitempty"`
+	Verbose bool   `json:"verbose,omitempty"`
+}
+
+// agentResponse is what "tack agent" writes back: the operation's result,
+// or why it could not be run.
+type agentResponse struct {
+	Result *abi.Result  `json:"result,omitempty"`
+	Error  *errorDetail `json:"error,omitempty"`
+}
+
+// run runs the operation of a locally loaded plugin on the host, with the
+// capabilities it is granted locally, asking for them first if need be.
+// The host's own policy applies as well. Plugin logs written on the host
+// are streamed to stderr.
+func (h *remoteHost) run(ctx context.Context, plugin *runtime.LoadedPlugin, wasmLoader func() ([]byte, error), config map[string]any, verbose bool) (abi.Result, error) {
+	// ssh would read such a target as an option, such as -oProxyCommand
+	if strings.HasPrefix(h.target, "-") {
+		return abi.Result{}, errcode.Errorf(errcode.RemoteFailed, "invalid --%s target %q: must be [user@]host", RemoteFlag, h.target)
+	}
+	grants, err := plugin.Grants(config)
+	if err != nil {
+		return abi.Result{}, err
+	}
+	data, err := wasmLoader()
+	if err != nil {
+		return abi.Result{}, fmt.Errorf("reading plugin: %w", err)
+	}
+	req := agentRequest{
+		Plugin:  data,
+		Digest:  pluginpkg.ContentDigest(data),
+		Config:  config,
+		Grants:  grants,
+		Verbose: verbose,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Timeout = time.Until(deadline).Round(time.Millisecond).String()
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return abi.Result{}, fmt.Errorf("encoding request: %w", err)
+	}
+
+	args := append(slices.Clone(h.options), "--", h.target, h.command, "agent", "--quiet")
+	var stdout bytes.Buffer
+	ssh := exec.CommandContext(ctx, "ssh", args...)
+	ssh.Stdin = bytes.NewReader(body)
+	ssh.Stdout = &stdout
+	ssh.Stderr = os.Stderr
+	runErr := ssh.Run()
+
+	var resp agentResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil || (resp.Result == nil && resp.Error == nil) {
+		if runErr != nil {
+			return abi.Result{}, errcode.Errorf(errcode.RemoteFailed, "running on %s: %w", h.target, runErr)
+		}
+		return abi.Result{}, errcode.Errorf(errcode.RemoteFailed, "running on %s: no result from %s agent", h.target, h.command)
+	}
+	if resp.Error != nil {
+		code := resp.Error.Code
+		if code == "" {
+			code = errcode.RemoteFailed
+		}
+		return abi.Result{}, errcode.Errorf(code, "on %s: %s", h.target, resp.Error.Message)
+	}
+	return *resp.Result, nil
+}
+
+// newAgentCommand creates the "agent" command run on remote hosts.
+func newAgentCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "agent",
+		Short: "Run a plugin operation sent by --remote",
+		Long: fmt.Sprintf(`Run a plugin operation sent by "%[1]s --remote" over SSH. A request is
+read from stdin: the plugin binary, the operation config, and the
+capabilities granted on the machine that sent it. The plugin is run with
+exactly those capabilities, without asking, and within this machine's own
+policy; the result is written to stdout as JSON.
+
+Install %[1]s on the remote host (or set remote.command) to use --remote.
+This command is not meant to be run by hand.`, meta.AppName),
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp agentResponse
+			var req agentRequest
+			if err := json.NewDecoder(cmd.InOrStdin()).Decode(&req); err != nil {
+				resp.Error = &errorDetail{Message: fmt.Sprintf("reading request: %v", err)}
+			} else {
+				result, err := runAgentRequest(cmd.Context(), req)
+				if err != nil {
+					resp.Error = &errorDetail{Code: errcode.Of(err), Message: err.Error()}
+				} else {
+					resp.Result = &result
+				}
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(resp)
+		},
+	}
+}
+
+// runAgentRequest runs the operation a request describes.
+func runAgentRequest(ctx context.Context, req agentRequest) (abi.Result, error) {
+	if digest := pluginpkg.ContentDigest(req.Plugin); digest != req.Digest {
+		return abi.Result{}, fmt.Errorf("%w: sent %s, received %s", pluginpkg.ErrDigestMismatch, req.Digest, digest)
+	}
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil {
+			return abi.Result{}, fmt.Errorf("invalid timeout %q: %w", req.Timeout, err)
+		}
+		if d <= 0 {
+			return abi.Result{}, fmt.Errorf("operation timed out: %w", context.DeadlineExceeded)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, d)
+		defer cancel()
+	}
+
+	grants := req.Grants
+	if grants == nil {
+		grants = &hostfunc.GrantSet{}
+	}
+	runner, err := runtime.NewPluginRunner(ctx, runtime.WithVerbose(req.Verbose), runtime.WithGrants(grants))
+	if err != nil {
+		return abi.Result{}, fmt.Errorf("creating runtime: %w", err)
+	}
+	defer func() { _ = runner.Close(context.Background()) }()
+
+	plugin, err := runner.LoadPlugin(ctx, req.Plugin)
+	if err != nil {
+		return abi.Result{}, err
+	}
+	if err := pluginpkg.ActivePolicy().CheckPlugin(plugin.Manifest.Name); err != nil {
+		return abi.Result{}, err
+	}
+	result, err := plugin.Check(ctx, req.Config)
+	if err != nil {
+		return abi.Result{}, fmt.Errorf("executing operation: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+func TestRemoteRun(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	wasmData, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+
+	// ssh records its arguments and the request, and answers with the
+	// response file if there is one
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$*\" > " + filepath.Join(dir, "args") +
+		"\ncat > " + filepath.Join(dir, "request.json") +
+		"\ncat " + filepath.Join(dir, "response.json") + " 2>/dev/null\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx := context.Background()
+	runner, err := runtime.NewPluginRunner(ctx, runtime.WithTrustPlugins(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+	plugin, err := runner.LoadPlugin(ctx, wasmData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := func() ([]byte, error) { return wasmData, nil }
+	cfg := &config.Config{Remote: config.RemoteConfig{Command: "/opt/tack", SSHOptions: []string{"-p", "2222"}}}
+	config := map[string]any{"action": "echo_test", "input": "from afar"}
+	runRemotelyOn(cfg, "ops@edge-1")
+	t.Cleanup(func() { runRemotelyOn(nil, "") })
+	remote := activeRemote()
+
+	// Without an answer from the agent, the run fails with a code
+	if _, err := remote.run(ctx, plugin, load, config, false); errcode.Of(err) != errcode.RemoteFailed {
+		t.Fatalf("expected %s without a response, got %v", errcode.RemoteFailed, err)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "-p 2222 -- ops@edge-1 /opt/tack agent --quiet" {
+		t.Errorf("ssh args = %q", got)
+	}
+
+	// The agent runs the request it was sent with the grants it carries
+	data, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req agentRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Grants == nil || req.Config["input"] != "from afar" {
+		t.Fatalf("request = %+v", req)
+	}
+	result, err := runAgentRequest(ctx, req)
+	if err != nil {
+		t.Fatalf("runAgentRequest: %v", err)
+	}
+	resp, _ := json.Marshal(agentResponse{Result: &result})
+	if err := os.WriteFile(filepath.Join(dir, "response.json"), resp, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := remote.run(ctx, plugin, load, config, false)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got.Data["echo"] != "from afar" {
+		t.Errorf("remote result = %+v", got)
+	}
+
+	// A binary altered on the way is refused
+	req.Plugin = append([]byte{}, req.Plugin[:len(req.Plugin)-1]...)
+	if _, err := runAgentRequest(ctx, req); errcode.Of(err) != errcode.DigestMismatch {
+		t.Errorf("expected %s for an altered binary, got %v", errcode.DigestMismatch, err)
+	}
+
+	// A target ssh would take for an option is refused before ssh runs
+	_ = os.Remove(filepath.Join(dir, "args"))
+	runRemotelyOn(cfg, "-oProxyCommand=touch pwned")
+	if _, err := activeRemote().run(ctx, plugin, load, config, false); errcode.Of(err) != errcode.RemoteFailed {
+		t.Errorf("expected an option-like target to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "args")); err == nil {
+		t.Error("expected ssh not to run for an option-like target")
+	}
+
+	// Errors reported by the agent keep their code
+	resp, _ = json.Marshal(agentResponse{Error: &errorDetail{Code: errcode.PolicyDenied, Message: "plugin fixture is blocked"}})
+	_ = os.WriteFile(filepath.Join(dir, "response.json"), resp, 0o644)
+	if _, err := remote.run(ctx, plugin, load, config, false); errcode.Of(err) != errcode.PolicyDenied || !strings.Contains(err.Error(), "ops@edge-1") {
+		t.Errorf("expected the agent's policy error, got %v", err)
+	}
+}
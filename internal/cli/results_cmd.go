@@ -0,0 +1,491 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/results"
+	"gopkg.in/yaml.v3"
+)
+
+// ResultsPath returns the result database the config selects, or "" when
+// results are not stored.
+func ResultsPath(cfg *config.Config) string {
+	switch {
+	case cfg.Results.Path != "":
+		return cfg.Results.Path
+	case cfg.Results.Enabled:
+		return results.DefaultPath()
+	}
+	return ""
+}
+
+// resultRecording is the result store of this process. The database is
+// opened on the first result, so commands that run no plugins never touch
+// it.
+var resultRecording struct {
+	mu     sync.Mutex
+	path   string
+	store  *results.Store
+	failed bool
+}
+
+// recordResultsTo makes every plugin operation run by this process store its
+// result in the database at path. An empty path turns recording off.
+func recordResultsTo(path string) {
+	resultRecording.mu.Lock()
+	defer resultRecording.mu.Unlock()
+	if resultRecording.store != nil {
+		_ = resultRecording.store.Close()
+	}
+	resultRecording.path, resultRecording.store, resultRecording.failed = path, nil, false
+}
+
+// resultPushing is the result sink pusher of this process, nil when no
+// sinks are configured.
+var resultPushing struct {
+	mu     sync.Mutex
+	pusher *results.Pusher
+}
+
+// pushResultsTo makes every plugin operation run by this process push its
+// result to the sinks of cfgs. Invalid sinks are reported and none are
+// used.
+func pushResultsTo(cfgs []config.ResultSinkConfig) {
+	pusher, err := results.NewPusher(cfgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	resultPushing.mu.Lock()
+	defer resultPushing.mu.Unlock()
+	_ = resultPushing.pusher.Close()
+	resultPushing.pusher = pusher
+}
+
+// recordResult stores the outcome of one operation of the plugin with the
+// given manifest and digest while recording is on, and pushes it to the
+// result sinks. Sensitive fields are masked whatever --show-secrets says.
+// A store that cannot be opened or written is reported once and recording
+// stops.
+func recordResult(manifest abi.Manifest, digest string, config map[string]any, start time.Time, result abi.Result, err error) {
+	resultPushing.mu.Lock()
+	pusher := resultPushing.pusher
+	resultPushing.mu.Unlock()
+	resultRecording.mu.Lock()
+	storing := resultRecording.path != "" && !resultRecording.failed
+	resultRecording.mu.Unlock()
+	if !storing && pusher == nil {
+		return
+	}
+
+	r := results.Record{
+		Plugin:    manifest.Name,
+		Version:   manifest.Version,
+		Digest:    digest,
+		Status:    string(result.Status),
+		Message:   result.Message,
+		StartedAt: start,
+		Duration:  time.Since(start),
+	}
+	r.Service, _ = config["service"].(string)
+	r.Operation, _ = config["operation"].(string)
+	if r.Message == "" && result.Error != nil {
+		r.Message = result.Error.Message
+	}
+	if err != nil {
+		r.Status, r.Message = "error", err.Error()
+	}
+	r.Message = pluginpkg.RedactSecrets(r.Message)
+
+	schema := operationSchema(manifest, r.Service, r.Operation)
+	r.Data = output.MaskSensitive(result.Data, schema)
+	if len(schema) > 0 {
+		_ = json.Unmarshal(schema, &r.Schema)
+	}
+
+	if storing {
+		storeResult(r)
+	}
+	pusher.Push(context.Background(), results.Event{
+		Plugin:     r.Plugin,
+		Version:    r.Version,
+		Digest:     r.Digest,
+		Service:    r.Service,
+		Operation:  r.Operation,
+		ConfigHash: configHash(config),
+		Status:     r.Status,
+		Message:    r.Message,
+		Data:       r.Data,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.StartedAt.Add(r.Duration),
+		Duration:   r.Duration,
+	})
+}
+
+// storeResult adds r to the result store, opening it first if need be.
+func storeResult(r results.Record) {
+	resultRecording.mu.Lock()
+	defer resultRecording.mu.Unlock()
+	if resultRecording.path == "" || resultRecording.failed {
+		return
+	}
+	if resultRecording.store == nil {
+		store, err := results.Open(resultRecording.path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: result store: %v\n", err)
+			resultRecording.failed = true
+			return
+		}
+		resultRecording.store = store
+	}
+	if _, err := resultRecording.store.Add(context.Background(), r); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: result store: %v\n", err)
+		resultRecording.failed = true
+	}
+}
+
+// configHash returns the digest of an operation config. Map keys are
+// encoded in order, so equal configs hash alike.
+func configHash(config map[string]any) string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return pluginpkg.ContentDigest(data)
+}
+
+// operationSchema returns the output schema of an operation of manifest,
+// or nil when there is none.
+func operationSchema(manifest abi.Manifest, service, operation string) json.RawMessage {
+	for _, op := range manifest.Services[service].Operations {
+		if op.Name == operation {
+			return op.OutputSchema
+		}
+	}
+	return nil
+}
+
+// newResultsCommand creates the "results" command.
+func newResultsCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "results",
+		Short: "Query stored plugin operation results",
+		Long: `Query the results of past plugin operations: status, data, output schema,
+timing and the digest of the plugin binary that produced them.
+
+Results are stored when the results section of the config enables it.`,
+	}
+	cmd.AddCommand(newResultsListCommand(cfg))
+	cmd.AddCommand(newResultsShowCommand(cfg))
+	cmd.AddCommand(newResultsExportCommand(cfg))
+	return cmd
+}
+
+// resultFilterFlags are the flags that select results.
+type resultFilterFlags struct {
+	plugin string
+	status string
+	since  string
+}
+
+func (f *resultFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.plugin, "plugin", "", "Only results of this plugin")
+	cmd.Flags().StringVar(&f.status, "status", "", "Only results with this status: success, failure, or error")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only results from this long ago or later, such as 12h or 7d")
+}
+
+// filter returns the results.Filter the flags select.
+func (f *resultFilterFlags) filter(now time.Time) (results.Filter, error) {
+	filter := results.Filter{Plugin: f.plugin, Status: f.status}
+	if f.since != "" {
+		d, err := parseAge(f.since)
+		if err != nil {
+			return results.Filter{}, err
+		}
+		filter.Since = now.Add(-d)
+	}
+	return filter, nil
+}
+
+// parseAge parses a duration that may also be given in days or weeks, such
+// as "7d" or "2w".
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid --since %q (e.g. 12h, 7d)", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid --since %q (e.g. 12h, 7d)", s)
+	}
+	return d, nil
+}
+
+// openResults opens the configured result store, or the default one when
+// recording is off, so results kept earlier can still be read.
+func openResults(cfg *config.Config) (*results.Store, error) {
+	path := ResultsPath(cfg)
+	if path == "" {
+		path = results.DefaultPath()
+	}
+	return results.Open(path)
+}
+
+// newResultsListCommand creates the "results list" command.
+func newResultsListCommand(cfg *config.Config) *cobra.Command {
+	var (
+		flags resultFilterFlags
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List stored results, newest first",
+		Example: fmt.Sprintf(`  %s results list
+  %s results list --plugin dns --status failure --since 7d`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := flags.filter(time.Now())
+			if err != nil {
+				return err
+			}
+			filter.Limit = limit
+
+			store, err := openResults(cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = store.Close() }()
+			records, err := store.List(cmd.Context(), filter)
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderResultList(cmd.OutOrStdout(), format, records)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of results to list (0 for all)")
+	return cmd
+}
+
+// newResultsShowCommand creates the "results show" command.
+func newResultsShowCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "show <id>",
+		Short:   "Show a stored result in full",
+		Example: fmt.Sprintf(`  %s results show 42 --output json`, meta.AppName),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid result ID %q", args[0])
+			}
+
+			store, err := openResults(cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = store.Close() }()
+			r, err := store.Get(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderResult(cmd.OutOrStdout(), format, r)
+		},
+	}
+}
+
+// newResultsExportCommand creates the "results export" command.
+func newResultsExportCommand(cfg *config.Config) *cobra.Command {
+	var (
+		flags  resultFilterFlags
+		format string
+		file   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write stored results as JSON Lines or CSV, oldest first",
+		Long: `Write stored results for analysis in other tools, oldest first.
+
+JSON Lines holds one complete result per line, data and schema included.
+CSV holds one row per result with its timing and status, without data.`,
+		Example: fmt.Sprintf(`  %s results export --since 30d > results.jsonl
+  %s results export --plugin http --format csv --file http.csv`, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := flags.filter(time.Now())
+			if err != nil {
+				return err
+			}
+
+			store, err := openResults(cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = store.Close() }()
+			records, err := store.List(cmd.Context(), filter)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return fmt.Errorf("creating export file: %w", err)
+				}
+				defer func() { _ = f.Close() }()
+				w = f
+			}
+			return exportResults(w, format, records)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVar(&format, "format", "jsonl", "Export format: jsonl or csv")
+	cmd.Flags().StringVar(&file, "file", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+func renderResultList(w io.Writer, format string, records []results.Record) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(records)
+	case "table", "":
+		if len(records) == 0 {
+			_, err := fmt.Fprintln(w, "No results stored")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tSTARTED\tPLUGIN\tOPERATION\tSTATUS\tDURATION\tMESSAGE")
+		for _, r := range records {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				r.ID, r.StartedAt.Local().Format(time.DateTime), r.Plugin, resultOperation(r), r.Status,
+				r.Duration.Round(time.Millisecond), r.Message)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+func renderResult(w io.Writer, format string, r results.Record) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(r)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "ID:\t%d\n", r.ID)
+		_, _ = fmt.Fprintf(tw, "Plugin:\t%s %s\n", r.Plugin, r.Version)
+		_, _ = fmt.Fprintf(tw, "Digest:\t%s\n", r.Digest)
+		_, _ = fmt.Fprintf(tw, "Operation:\t%s\n", resultOperation(r))
+		_, _ = fmt.Fprintf(tw, "Status:\t%s\n", r.Status)
+		if r.Message != "" {
+			_, _ = fmt.Fprintf(tw, "Message:\t%s\n", r.Message)
+		}
+		_, _ = fmt.Fprintf(tw, "Started:\t%s\n", r.StartedAt.Local().Format(time.RFC3339))
+		_, _ = fmt.Fprintf(tw, "Duration:\t%s\n", r.Duration.Round(time.Millisecond))
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if len(r.Data) == 0 {
+			return nil
+		}
+		_, _ = fmt.Fprintln(w)
+		var schema json.RawMessage
+		if r.Schema != nil {
+			schema, _ = json.Marshal(r.Schema)
+		}
+		data := abi.Result{Status: abi.ResultStatusSuccess, Data: r.Data}
+		return (&output.TableFormatter{}).Format(w, data, schema)
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+// exportResults writes records oldest first in the given export format.
+func exportResults(w io.Writer, format string, records []results.Record) error {
+	switch format {
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for i := len(records) - 1; i >= 0; i-- {
+			if err := enc.Encode(records[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"id", "started_at", "plugin", "version", "digest", "service", "operation", "status", "duration_ms", "message"})
+		for i := len(records) - 1; i >= 0; i-- {
+			r := records[i]
+			_ = cw.Write([]string{
+				strconv.FormatInt(r.ID, 10), r.StartedAt.UTC().Format(time.RFC3339Nano), r.Plugin, r.Version, r.Digest,
+				r.Service, r.Operation, r.Status, strconv.FormatFloat(float64(r.Duration.Microseconds())/1000, 'f', 3, 64), r.Message,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported export format: %q (supported: jsonl, csv)", format)
+	}
+}
+
+// resultOperation names the operation of r, with its service when it has
+// one.
+func resultOperation(r results.Record) string {
+	if r.Service != "" {
+		return r.Service + " " + r.Operation
+	}
+	return r.Operation
+}
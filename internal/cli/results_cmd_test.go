@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	"github.com/whiskeyjimb/tack-cli/internal/results"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "12h", want: 12 * time.Hour},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "xd", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRecordResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	recordResultsTo(path)
+	defer recordResultsTo("")
+
+	manifest := abi.Manifest{
+		Name:    "vault",
+		Version: "1.2.0",
+		Services: map[string]abi.ServiceManifest{
+			"kv": {Name: "kv", Operations: []abi.OperationManifest{{
+				Name:         "read",
+				OutputSchema: json.RawMessage(`{"type":"object","properties":{"token":{"type":"string","x-sensitive":true}}}`),
+			}}},
+		},
+	}
+	config := map[string]any{"service": "kv", "operation": "read"}
+	start := time.Now()
+	recordResult(manifest, "sha256:abc", config, start, abi.ResultSuccess("read", map[string]any{"token": "s3cret"}), nil)
+	recordResult(manifest, "sha256:abc", config, start, abi.Result{}, errors.New("operation timed out"))
+	recordResultsTo("")
+
+	store, err := results.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	records, err := store.List(context.Background(), results.Filter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	var ok, failed results.Record
+	for _, r := range records {
+		if r.Status == "error" {
+			failed = r
+		} else {
+			ok = r
+		}
+	}
+	if ok.Plugin != "vault" || ok.Version != "1.2.0" || ok.Digest != "sha256:abc" || ok.Service != "kv" || ok.Operation != "read" {
+		t.Errorf("unexpected record %+v", ok)
+	}
+	if ok.Data["token"] != output.Masked {
+		t.Errorf("expected sensitive data masked, got %v", ok.Data)
+	}
+	if ok.Schema["type"] != "object" {
+		t.Errorf("expected the output schema stored, got %v", ok.Schema)
+	}
+	if failed.Message != "operation timed out" {
+		t.Errorf("expected the error stored as the message, got %+v", failed)
+	}
+}
+
+func TestRecordResult_Push(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	pushResultsTo([]config.ResultSinkConfig{{File: path}})
+	defer pushResultsTo(nil)
+
+	manifest := abi.Manifest{
+		Name: "vault",
+		Services: map[string]abi.ServiceManifest{
+			"kv": {Name: "kv", Operations: []abi.OperationManifest{{
+				Name:         "read",
+				OutputSchema: json.RawMessage(`{"type":"object","properties":{"token":{"type":"string","x-sensitive":true}}}`),
+			}}},
+		},
+	}
+	config := map[string]any{"service": "kv", "operation": "read", "path": "secret/app"}
+	recordResult(manifest, "sha256:abc", config, time.Now(), abi.ResultSuccess("read", map[string]any{"token": "s3cret"}), nil)
+	pushResultsTo(nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev results.Event
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatalf("decoding %q: %v", data, err)
+	}
+	if ev.Plugin != "vault" || ev.Service != "kv" || ev.Operation != "read" || ev.Status != "success" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if ev.Data["token"] != output.Masked {
+		t.Errorf("expected sensitive data masked, got %v", ev.Data)
+	}
+	if ev.ConfigHash != configHash(config) || strings.Contains(string(data), "secret/app") {
+		t.Errorf("expected the config hashed, got %q", ev.ConfigHash)
+	}
+}
+
+func TestExportResults(t *testing.T) {
+	started := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []results.Record{
+		{ID: 2, Plugin: "dns", Operation: "resolve", Status: "failure", Message: "no answer", StartedAt: started.Add(time.Minute), Duration: 1500 * time.Microsecond},
+		{ID: 1, Plugin: "dns", Operation: "resolve", Status: "success", StartedAt: started, Data: map[string]any{"ip": "192.0.2.1"}},
+	}
+
+	var jsonl bytes.Buffer
+	if err := exportResults(&jsonl, "jsonl", records); err != nil {
+		t.Fatalf("exportResults jsonl: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(jsonl.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"id":1`) || !strings.Contains(lines[0], `"ip":"192.0.2.1"`) {
+		t.Errorf("expected oldest first with data, got:\n%s", jsonl.String())
+	}
+
+	var csv bytes.Buffer
+	if err := exportResults(&csv, "csv", records); err != nil {
+		t.Fatalf("exportResults csv: %v", err)
+	}
+	want := "id,started_at,plugin,version,digest,service,operation,status,duration_ms,message\n" +
+		"1,2026-05-01T12:00:00Z,dns,,,,resolve,success,0.000,\n" +
+		"2,2026-05-01T12:01:00Z,dns,,,,resolve,failure,1.500,no answer\n"
+	if csv.String() != want {
+		t.Errorf("csv export:\n%s\nwant:\n%s", csv.String(), want)
+	}
+
+	if err := exportResults(&csv, "xml", records); err == nil {
+		t.Error("expected an error for an unknown export format")
+	}
+}
+
+func TestRenderResultList(t *testing.T) {
+	records := []results.Record{
+		{ID: 7, Plugin: "http", Service: "client", Operation: "get", Status: "success", StartedAt: time.Now(), Duration: 120 * time.Millisecond},
+	}
+	var buf bytes.Buffer
+	if err := renderResultList(&buf, "table", records); err != nil {
+		t.Fatalf("renderResultList: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "ID") || !strings.Contains(out, "client get") || !strings.Contains(out, "120ms") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := renderResultList(&buf, "table", nil); err != nil || !strings.Contains(buf.String(), "No results stored") {
+		t.Errorf("expected an empty-store message, got %q, %v", buf.String(), err)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/config"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
 	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
 )
 
 // NewRootCommand creates the top-level CLI command with dynamic plugin loading.
@@ -19,6 +23,13 @@ func NewRootCommand(cfg *config.Config, stack *pluginpkg.PluginStack, configPath
 		verbose      bool
 		quiet        bool
 		trustPlugins bool
+		insecure     []string
+		readOnly     bool
+		egressReport bool
+		stats        bool
+		expand       bool
+		noCache      bool
+		remote       string
 	)
 
 	root := &cobra.Command{
@@ -32,33 +43,100 @@ Plugins are sandboxed WASM modules, installable from OCI registries.`, "Tack"),
 	}
 
 	// Flags with defaults from config
-	root.PersistentFlags().StringVar(&outputFormat, "output", cfg.Output, "Output format: table, json, yaml")
+	root.PersistentFlags().StringVar(&outputFormat, "output", cfg.Output, "Output format: table, json, yaml, gha, codequality")
 	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging from plugins")
 	root.PersistentFlags().BoolVar(&quiet, "quiet", cfg.Quiet, "Suppress output; exit code indicates result")
 	root.PersistentFlags().BoolVar(&trustPlugins, "trust-plugins", false, "Trust all plugins and automatically grant requested capabilities")
+	root.PersistentFlags().StringSliceVar(&insecure, "insecure-skip-tls-verify", nil, "Skip TLS verification for these registry or index hosts (repeatable)")
+	root.PersistentFlags().BoolVar(&egressReport, EgressReportFlag, false, "After the command, print every host and port plugins contacted")
+	root.PersistentFlags().BoolVar(&stats, StatsFlag, false, "After the command, report each plugin operation's wall time, peak memory, and host calls, and keep them in history")
+	root.PersistentFlags().Bool(ShowSecretsFlag, false, "Print fields plugins mark sensitive instead of masking them, in output and history")
+	root.PersistentFlags().BoolVar(&expand, ExpandTemplatesFlag, false, `Expand {{ env "NAME" }} and {{ config "path.to.key" }} in operation flag values`)
+	root.PersistentFlags().BoolVar(&noCache, NoCacheFlag, false, "Resolve plugin tags such as latest against the registry instead of using recent resolutions")
+	root.PersistentFlags().String(LogFileFlag, "", "Also write stderr and each plugin host call to this log file, rotating it by size")
+	root.PersistentFlags().BoolVar(&readOnly, "read-only", cfg.ReadOnly, "Refuse config changes and plugin install/remove, and block plugins that write files or run commands")
+	root.PersistentFlags().StringVar(&remote, RemoteFlag, "", fmt.Sprintf("Run plugin operations on this [user@]host over SSH; it needs %s installed", meta.AppName))
 
 	// When quiet mode is enabled, override output format
 	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		if quiet {
 			outputFormat = "quiet"
 		}
+		// The flag can turn read-only mode on but not off
+		if readOnly {
+			cfg.ReadOnly = true
+			runtime.SetReadOnly(true)
+		}
+		if egressReport {
+			runtime.SetEgressRecording(true)
+		}
+		if stats {
+			runtime.SetUsageRecording(true)
+		}
+		if noCache {
+			pluginpkg.SetTagCacheTTL(0)
+		}
+		recordResultsTo(ResultsPath(cfg))
+		pushResultsTo(cfg.Results.Sinks)
+		runRemotelyOn(cfg, remote)
+		if expand {
+			expandTemplatesWith(cfg)
+		}
+		if len(insecure) > 0 {
+			if err := ConfigureNetwork(cfg, insecure); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: network config: %v\n", err)
+			}
+		}
 	}
 
 	// Static commands
 	root.AddCommand(newCompletionCommand())
 	root.AddCommand(newVersionCommand())
+	root.AddCommand(newDebugCommand(cfg))
 
 	// Plugin management (uses host-sdk PluginService)
 	if stack != nil {
 		root.AddCommand(newPluginCommand(stack, cfg))
+		root.AddCommand(newAuditCommand(stack, cfg))
+		root.AddCommand(newSchemaCommand(cfg, stack))
+		root.AddCommand(newBundleCommand(stack))
 	}
 
+	// Index generation for private plugin registries
+	root.AddCommand(newIndexCommand(cfg))
+	root.AddCommand(newRegistryCommand(cfg))
+
+	// Ad-hoc execution of local .wasm files
+	root.AddCommand(newExecCommand(cfg))
+	root.AddCommand(newRunCommand())
+
 	// Group management
-	root.AddCommand(newGroupCommand(cfg, configPath))
+	root.AddCommand(newGroupCommand(cfg, stack, configPath))
+
+	// Workflow orchestration
+	root.AddCommand(newWorkflowCommand(cfg, stack))
+	root.AddCommand(newScheduleCommand(cfg, stack))
+	root.AddCommand(newExporterCommand(cfg, stack))
+	root.AddCommand(newResultsCommand(cfg))
+
+	// HTTP API over installed plugins
+	root.AddCommand(newServeCommand(cfg, stack))
+
+	// Runs operations sent by --remote
+	root.AddCommand(newAgentCommand())
+
+	// Project workspace trust
+	root.AddCommand(newWorkspaceCommand(cfg))
 
 	// Register flag completions
 	registerOutputFormatCompletion(root)
 
+	// --help --output json describes the command tree for tooling.
+	// The help flag is registered up front so cobra knows it takes no value
+	// when resolving "tack --help --output json".
+	root.InitDefaultHelpFlag()
+	installJSONHelp(root)
+
 	// Register aliases from config
 	if len(cfg.Aliases) > 0 {
 		registerAliases(root, cfg.Aliases)
@@ -67,9 +145,22 @@ Plugins are sandboxed WASM modules, installable from OCI registries.`, "Tack"),
 	return root
 }
 
+// denyInReadOnly is a PreRunE for commands that change installed plugins,
+// refusing them in read-only mode.
+func denyInReadOnly(cfg *config.Config) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if cfg.ReadOnly {
+			return fmt.Errorf("%s: %w", cmd.CommandPath(), config.ErrReadOnly)
+		}
+		return nil
+	}
+}
+
 // RegisterPluginCommands discovers plugins and adds their commands to root.
-// This is called from main.go after flag parsing.
-func RegisterPluginCommands(root *cobra.Command, outputFormat *string, verbose *bool, trustPlugins *bool, cfg *config.Config, stack *pluginpkg.PluginStack) error {
+// This is called from main.go after flag parsing. Discovery and the
+// generated operation commands share runner, which the caller closes once
+// the command has run.
+func RegisterPluginCommands(root *cobra.Command, outputFormat *string, verbose *bool, trustPlugins *bool, cfg *config.Config, stack *pluginpkg.PluginStack, runner *runtime.SharedRunner) error {
 	ctx := context.Background()
 
 	loader := pluginpkg.NewLoader(
@@ -77,21 +168,35 @@ func RegisterPluginCommands(root *cobra.Command, outputFormat *string, verbose *
 		pluginpkg.DefaultPluginsDir(),
 		stack,
 		cfg.DefaultRegistry,
-	)
+	).UseRunner(runner).TraceWith(startup)
+	done := StartupPhase(phasePluginDiscovery)
 	discovered, err := loader.DiscoverAll(ctx)
+	done()
 	if err != nil {
 		// Don't fail the CLI if plugin discovery fails
 		fmt.Fprintf(os.Stderr, "Warning: plugin discovery failed: %v\n", err)
 		return nil
 	}
+	if cfg.BackgroundRefresh && loader.ClaimRefresh(backgroundRefreshInterval) {
+		startBackgroundRefresh()
+	}
+
+	defer StartupPhase("command registration")()
+
+	timeout, err := cfg.OperationTimeout()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; running without a timeout\n", err)
+	}
 
 	// Helper to generate a plugin command for a given DiscoveredPlugin.
-	makePluginCmd := func(dp pluginpkg.DiscoveredPlugin) *cobra.Command {
-		var defaults map[string]string
-		if cfg != nil && cfg.PluginDefaults != nil {
-			defaults = cfg.PluginDefaults[dp.Manifest.Name]
+	// Group defaults take precedence over plugin and operation defaults.
+	makePluginCmd := func(dp pluginpkg.DiscoveredPlugin, groupDefaults map[string]string) *cobra.Command {
+		defaults := func(service, operation string) map[string]string {
+			return config.MergeDefaults(cfg.DefaultsFor(dp.Manifest.Name, service, operation), groupDefaults)
 		}
-		return generatePluginCommand(dp.Manifest, dp.Loader, outputFormat, verbose, trustPlugins, defaults)
+		pluginCmd := generatePluginCommand(dp.Manifest, dp.Digest, dp.Loader, runner, outputFormat, verbose, trustPlugins, defaults, timeout)
+		pluginCmd.Hidden = cfg.IsHiddenPlugin(dp.Manifest.Name)
+		return pluginCmd
 	}
 
 	// Ensure "top" group exists with all plugins by default
@@ -118,10 +223,74 @@ func RegisterPluginCommands(root *cobra.Command, outputFormat *string, verbose *
 	// Register plugins at the top level if they're in the "top" group
 	for _, dp := range discovered {
 		if topGroupPlugins[dp.Manifest.Name] {
-			pluginCmd := makePluginCmd(dp)
+			pluginCmd := makePluginCmd(dp, cfg.Groups["top"].Defaults)
 			root.AddCommand(pluginCmd)
 		}
 	}
 
 	return nil
 }
+
+// backgroundRefreshInterval is the least time between background refreshes
+// of the discovery cache.
+const backgroundRefreshInterval = 24 * time.Hour
+
+// startBackgroundRefresh runs "plugin refresh" in a detached process, so
+// the command being run does not wait for it. Failures are ignored; the
+// cache is refreshed again a day later.
+func startBackgroundRefresh() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	refresh := exec.Command(exe, "plugin", "refresh")
+	if err := refresh.Start(); err != nil {
+		return
+	}
+	_ = refresh.Process.Release()
+}
+
+// NeedsPluginCommands reports whether a command line can reach a plugin
+// command. "version" and the shell completion scripts cannot, so they skip
+// plugin discovery altogether.
+func NeedsPluginCommands(args []string) bool {
+	var words []string
+	for i := 0; i < len(args) && len(words) < 2; i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			// Persistent flags that take a separate value
+			if arg == "--output" || arg == "--insecure-skip-tls-verify" || arg == "--"+LogFileFlag || arg == "--"+RemoteFlag {
+				i++
+			}
+			continue
+		}
+		words = append(words, arg)
+	}
+	if len(words) == 0 {
+		return true
+	}
+	switch words[0] {
+	case "version", "agent", "workspace":
+		return false
+	case "completion":
+		if len(words) < 2 {
+			return true
+		}
+		switch words[1] {
+		case "bash", "zsh", "fish", "powershell":
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigureNetwork applies the network section of the config, plus extra
+// hosts to skip TLS verification for, to index fetches and registry pulls.
+func ConfigureNetwork(cfg *config.Config, insecureHosts []string) error {
+	return pluginpkg.ConfigureHTTP(pluginpkg.TransportOptions{
+		Proxy:         cfg.Network.Proxy,
+		NoProxy:       cfg.Network.NoProxy,
+		CAFiles:       cfg.Network.CAFiles,
+		InsecureHosts: append(append([]string(nil), cfg.Network.InsecureSkipTLSVerify...), insecureHosts...),
+	})
+}
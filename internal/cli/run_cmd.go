@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// newRunCommand creates the "run" command.
+func newRunCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run --matrix 'field=[a,b] ...' <plugin> [service] <operation> [flags]",
+		Short: "Run a plugin operation for every combination of flag values",
+		Long: fmt.Sprintf(`Run a plugin operation once per combination of the values given with
+--matrix, and report every result together, followed by a grid of statuses
+when there are two dimensions.
+
+Each dimension is a config field or flag name with a list of values, such as
+region=[us-east-1,eu-west-1]; separate dimensions with spaces or repeat
+--matrix. The other flags apply to every run. --parallel sets how many
+combinations run at once. The command exits non-zero if any combination
+does not succeed.
+
+The same --matrix flag is accepted by every operation command directly.
+
+Examples:
+  %s run --matrix 'region=[us-east-1,eu-west-1] record_type=[A,AAAA]' dns resolve --hostname example.com
+  %s run --matrix 'port=[80,443,8443]' --parallel 3 tcp connect --host example.com`, meta.AppName, meta.AppName),
+		// The operation's flags are only known once it is found
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specs, rest, help, err := splitRunArgs(args)
+			if err != nil {
+				return err
+			}
+			if help {
+				return cmd.Help()
+			}
+			if len(specs) == 0 {
+				return fmt.Errorf("--%s is required: %s run --%s 'field=[a,b]' <plugin> <operation> [flags]", matrixFlag, meta.AppName, matrixFlag)
+			}
+
+			root := cmd.Root()
+			target, _, err := root.Find(rest)
+			if err != nil || target == root {
+				return fmt.Errorf("no plugin operation given: %s run --%s 'field=[a,b]' <plugin> <operation> [flags]", meta.AppName, matrixFlag)
+			}
+			if _, ok := fanOutFlag(target, matrixFlag); !ok {
+				return fmt.Errorf("%s is not a plugin operation that takes --%s", strings.TrimPrefix(target.CommandPath(), meta.AppName+" "), matrixFlag)
+			}
+
+			for _, spec := range specs {
+				rest = append(rest, "--"+matrixFlag, spec)
+			}
+			root.SetArgs(rest)
+			return root.ExecuteContext(cmd.Context())
+		},
+	}
+}
+
+// splitRunArgs separates the --matrix values from the rest of a "run"
+// command line, and reports whether help was asked for before the
+// operation was named.
+func splitRunArgs(args []string) (specs, rest []string, help bool, err error) {
+	named := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+matrixFlag:
+			if i+1 >= len(args) {
+				return nil, nil, false, fmt.Errorf("flag --%s needs a value", matrixFlag)
+			}
+			i++
+			specs = append(specs, args[i])
+		case strings.HasPrefix(arg, "--"+matrixFlag+"="):
+			specs = append(specs, strings.TrimPrefix(arg, "--"+matrixFlag+"="))
+		case (arg == "-h" || arg == "--help") && !named:
+			help = true
+		default:
+			if !strings.HasPrefix(arg, "-") {
+				named = true
+			}
+			rest = append(rest, arg)
+		}
+	}
+	return specs, rest, help, nil
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/notify"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/schedule"
+)
+
+// newScheduleCommand creates the "schedule" command.
+func newScheduleCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	var (
+		file      string
+		listen    string
+		once      bool
+		noHistory bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run checks on cron-like schedules",
+		Long: fmt.Sprintf(`Run checks on cron-like schedules until interrupted.
+
+Checks come from the "schedule" section of the config file and from --file.
+Each check names a plugin operation, its config, and a schedule:
+
+  schedule:
+    - name: example-dns
+      schedule: "*/5 * * * *"    # or @every 30s, @hourly, @daily
+      plugin: dns
+      operation: resolve
+      with:
+        hostname: example.com
+
+Results are appended to the history store. With --listen, check status is
+served as JSON at /status, Prometheus metrics at /metrics, and /healthz
+reports liveness.
+
+Examples:
+  %s schedule
+  %s schedule --file checks.yaml --listen :9470
+  %s schedule --once`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := append([]config.ScheduledCheck(nil), cfg.Schedule...)
+			if file != "" {
+				fromFile, err := schedule.LoadFile(file)
+				if err != nil {
+					return err
+				}
+				checks = append(checks, fromFile...)
+			}
+
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			trustPlugins, _ := cmd.Flags().GetBool("trust-plugins")
+			exec := newPluginExecutor(discovered, cfg, verbose, trustPlugins)
+			defer func() { _ = exec.Close() }()
+
+			var store *history.Store
+			if !noHistory {
+				store = history.Open(history.DefaultPath())
+			}
+
+			notifier, err := notify.New(cfg.Notifications)
+			if err != nil {
+				return err
+			}
+
+			show, _ := cmd.Flags().GetBool(ShowSecretsFlag)
+			opts := []schedule.Option{
+				schedule.WithNotifier(notifier),
+				schedule.WithDataMask(sensitiveMasks(discovered, show)),
+			}
+			if w := LogWriter(); w != nil {
+				opts = append(opts, schedule.WithRunLog(w))
+			}
+			sched, err := schedule.New(checks, exec, store, opts...)
+			if err != nil {
+				return err
+			}
+
+			if once {
+				sched.RunOnce(ctx)
+				return renderScheduleStatus(cmd, sched.Status())
+			}
+
+			if listen != "" {
+				srv := &http.Server{Addr: listen, Handler: sched.Handler(), ReadHeaderTimeout: 10 * time.Second}
+				go func() {
+					if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: status server: %v\n", err)
+					}
+				}()
+				defer func() {
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					_ = srv.Shutdown(shutdownCtx)
+				}()
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Serving status on %s\n", listen)
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Scheduling %d checks (Ctrl-C to stop)\n", len(checks))
+			if err := sched.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Schedule file with additional checks")
+	cmd.Flags().StringVar(&listen, "listen", "", "Address for the status endpoint (e.g. :9470)")
+	cmd.Flags().BoolVar(&once, "once", false, "Run every check once and exit")
+	cmd.Flags().BoolVar(&noHistory, "no-history", false, "Do not record results in the history store")
+	return cmd
+}
+
+// renderScheduleStatus prints check statuses and returns an error if any
+// check did not succeed.
+func renderScheduleStatus(cmd *cobra.Command, statuses []schedule.CheckStatus) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CHECK\tPLUGIN\tOPERATION\tSTATUS\tDURATION\tMESSAGE")
+	failed := 0
+	for _, st := range statuses {
+		if st.Status != "success" {
+			failed++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			st.Name, st.Plugin, st.Operation, st.Status, st.Duration.Round(time.Millisecond), st.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks did not succeed", failed, len(statuses))
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// jsonSchemaDialect is declared by exported schemas that name no dialect.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// newSchemaCommand creates the "schema" command.
+func newSchemaCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Work with plugin operation schemas",
+	}
+	cmd.AddCommand(newSchemaExportCommand(cfg, stack))
+	return cmd
+}
+
+// newSchemaExportCommand creates the "schema export" command.
+func newSchemaExportCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "export [plugin...]",
+		Short: "Write each operation's input and output JSON Schema to files",
+		Long: fmt.Sprintf(`Write standalone JSON Schema files for the operations of installed plugins,
+for form builders, validation layers and wrappers to consume:
+
+  <dir>/<plugin>/<service>/<operation>.input.json    config the operation reads
+  <dir>/<plugin>/<service>/<operation>.output.json   shape of the result data
+
+Input schemas are the plugin's config schema narrowed to the operation's
+input fields. Operations without an output schema get no output file.
+
+Examples:
+  %s schema export
+  %s schema export dns http --dir ./schemas`, meta.AppName, meta.AppName),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			discovered, err := discoverPlugins(cmd.Context(), cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			var manifests []abi.Manifest
+			for _, dp := range discovered {
+				if len(args) == 0 || slices.Contains(args, dp.Manifest.Name) {
+					manifests = append(manifests, dp.Manifest)
+				}
+			}
+			for _, name := range args {
+				if !slices.ContainsFunc(manifests, func(m abi.Manifest) bool { return m.Name == name }) {
+					return fmt.Errorf("plugin %q not found", name)
+				}
+			}
+
+			written := 0
+			for _, m := range manifests {
+				n, err := exportSchemas(dir, m)
+				if err != nil {
+					return err
+				}
+				written += n
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d schemas for %d plugins to %s\n", written, len(manifests), dir)
+			return nil
+		},
+		ValidArgsFunction: completePluginNames(cachedPlugins),
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "schemas", "Directory to write schemas to")
+	return cmd
+}
+
+// exportSchemas writes the schemas of a plugin's operations under dir and
+// returns how many files it wrote.
+func exportSchemas(dir string, m abi.Manifest) (int, error) {
+	services := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	written := 0
+	for _, svcName := range services {
+		for _, op := range m.Services[svcName].Operations {
+			base := filepath.Join(dir, m.Name, svcName, op.Name)
+			input, err := operationInputSchema(m, svcName, op)
+			if err != nil {
+				return written, err
+			}
+			if err := writeSchemaFile(base+".input.json", input); err != nil {
+				return written, err
+			}
+			written++
+
+			output, err := operationOutputSchema(m, svcName, op)
+			if err != nil {
+				return written, err
+			}
+			if output == nil {
+				continue
+			}
+			if err := writeSchemaFile(base+".output.json", output); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// operationInputSchema narrows a plugin's config schema to the fields one
+// operation reads. Fields set by the command path (service, operation) are
+// dropped.
+func operationInputSchema(m abi.Manifest, service string, op abi.OperationManifest) (map[string]any, error) {
+	schema := map[string]any{"type": "object"}
+	if len(m.ConfigSchema) > 0 {
+		if err := json.Unmarshal(m.ConfigSchema, &schema); err != nil {
+			return nil, fmt.Errorf("%s: parsing config schema: %w", m.Name, err)
+		}
+	}
+
+	keep := func(field string) bool {
+		if field == "service" || field == "operation" {
+			return false
+		}
+		return len(op.InputFields) == 0 || slices.Contains(op.InputFields, field)
+	}
+	if props, ok := schema["properties"].(map[string]any); ok {
+		for field := range props {
+			if !keep(field) {
+				delete(props, field)
+			}
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		kept := []any{}
+		for _, r := range required {
+			if field, ok := r.(string); ok && keep(field) {
+				kept = append(kept, field)
+			}
+		}
+		if len(kept) > 0 {
+			schema["required"] = kept
+		} else {
+			delete(schema, "required")
+		}
+	}
+
+	describeSchema(schema, m, service, op, "input")
+	return schema, nil
+}
+
+// operationOutputSchema returns an operation's output schema, or nil if
+// it declares none.
+func operationOutputSchema(m abi.Manifest, service string, op abi.OperationManifest) (map[string]any, error) {
+	if len(op.OutputSchema) == 0 {
+		return nil, nil
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(op.OutputSchema, &schema); err != nil {
+		return nil, fmt.Errorf("%s %s: parsing output schema: %w", m.Name, op.Name, err)
+	}
+	if schema == nil {
+		return nil, nil
+	}
+	describeSchema(schema, m, service, op, "output")
+	return schema, nil
+}
+
+// describeSchema fills in the dialect, title and description of an
+// exported schema where the plugin left them out.
+func describeSchema(schema map[string]any, m abi.Manifest, service string, op abi.OperationManifest, kind string) {
+	if _, ok := schema["$schema"]; !ok {
+		schema["$schema"] = jsonSchemaDialect
+	}
+	if _, ok := schema["title"]; !ok {
+		schema["title"] = fmt.Sprintf("%s %s %s %s", m.Name, service, op.Name, kind)
+	}
+	if _, ok := schema["description"]; !ok && op.Description != "" {
+		schema["description"] = op.Description
+	}
+}
+
+// writeSchemaFile writes a schema as indented JSON, creating parent
+// directories.
+func writeSchemaFile(path string, schema map[string]any) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating schema directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing schema: %w", err)
+	}
+	return nil
+}
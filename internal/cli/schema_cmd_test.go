@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+func TestExportSchemas(t *testing.T) {
+	m := abi.Manifest{
+		Name: "dns",
+		ConfigSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"hostname": {"type": "string"},
+				"record_type": {"type": "string", "enum": ["A", "AAAA"]},
+				"nameserver": {"type": "string"},
+				"operation": {"type": "string"}
+			},
+			"required": ["hostname", "nameserver", "operation"]
+		}`),
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{
+				{
+					Name:         "resolve",
+					Description:  "Resolve a hostname",
+					InputFields:  []string{"hostname", "record_type"},
+					OutputSchema: json.RawMessage(`{"type": "object", "properties": {"records": {"type": "array"}}}`),
+				},
+				{Name: "nameservers"},
+			}},
+		},
+	}
+
+	dir := t.TempDir()
+	n, err := exportSchemas(dir, m)
+	if err != nil {
+		t.Fatalf("exportSchemas: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 files (two inputs, one output), got %d", n)
+	}
+
+	read := func(name string) map[string]any {
+		data, err := os.ReadFile(filepath.Join(dir, "dns", "dns", name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("parsing %s: %v", name, err)
+		}
+		return schema
+	}
+
+	input := read("resolve.input.json")
+	props := input["properties"].(map[string]any)
+	if len(props) != 2 || props["hostname"] == nil || props["record_type"] == nil {
+		t.Errorf("expected only the operation's input fields, got %v", props)
+	}
+	if req, _ := input["required"].([]any); len(req) != 1 || req[0] != "hostname" {
+		t.Errorf("expected required narrowed to hostname, got %v", input["required"])
+	}
+	if input["$schema"] != jsonSchemaDialect || input["title"] != "dns dns resolve input" || input["description"] != "Resolve a hostname" {
+		t.Errorf("unexpected schema metadata: %v", input)
+	}
+
+	if output := read("resolve.output.json"); output["properties"] == nil {
+		t.Errorf("expected the output schema, got %v", output)
+	}
+	if props := read("nameservers.input.json")["properties"].(map[string]any); len(props) != 3 {
+		t.Errorf("expected every field but operation without input_fields, got %v", props)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dns", "dns", "nameservers.output.json")); !os.IsNotExist(err) {
+		t.Error("expected no output schema file for nameservers")
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// variantFlag is the flag that selects a oneOf/anyOf variant by setting its
+// discriminator field.
+const variantFlag = "variant"
+
+// variantFieldAnnotation is the flag annotation naming the config field a
+// variant selector sets.
+const variantFieldAnnotation = "tack_variant_field"
+
+// polymorphic reports whether the schema composes subschemas, so flag-level
+// checks alone cannot tell whether a config is valid.
+func (s *parsedSchema) polymorphic() bool {
+	return len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0 || s.Then != nil || s.Else != nil
+}
+
+// flagProperties returns the properties that become flags: the top-level
+// ones plus those of every allOf, oneOf, anyOf, then, and else subschema.
+// When a property is declared more than once, the first declaration wins.
+func (s *parsedSchema) flagProperties() map[string]schemaProperty {
+	if !s.polymorphic() {
+		return s.Properties
+	}
+	props := make(map[string]schemaProperty, len(s.Properties))
+	var collect func(*parsedSchema)
+	collect = func(sub *parsedSchema) {
+		for name, prop := range sub.Properties {
+			if _, ok := props[name]; !ok {
+				props[name] = prop
+			}
+		}
+		for _, group := range [][]parsedSchema{sub.AllOf, sub.OneOf, sub.AnyOf} {
+			for i := range group {
+				collect(&group[i])
+			}
+		}
+		for _, branch := range []*parsedSchema{sub.Then, sub.Else} {
+			if branch != nil {
+				collect(branch)
+			}
+		}
+	}
+	collect(s)
+	return props
+}
+
+// requiredFields returns the fields every config must set: the top-level
+// required list plus those of allOf subschemas. Fields required by only
+// some variants are left to full-schema validation.
+func (s *parsedSchema) requiredFields() []string {
+	required := append([]string(nil), s.Required...)
+	for i := range s.AllOf {
+		required = append(required, s.AllOf[i].requiredFields()...)
+	}
+	return required
+}
+
+// discriminator finds the field that tells oneOf (or, failing that, anyOf)
+// variants apart: one every variant pins to a distinct value with const or
+// a single-value enum. It returns the field and the variant values in
+// declaration order, or "" when there is no such field.
+func (s *parsedSchema) discriminator() (string, []string) {
+	variants := s.OneOf
+	if len(variants) == 0 {
+		variants = s.AnyOf
+	}
+	if len(variants) < 2 {
+		return "", nil
+	}
+
+	candidates := make([]string, 0, len(variants[0].Properties))
+	for name := range variants[0].Properties {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	for _, name := range candidates {
+		values := make([]string, 0, len(variants))
+		seen := make(map[string]bool, len(variants))
+		for _, v := range variants {
+			val, ok := pinnedValue(v.Properties[name])
+			if !ok || seen[val] {
+				break
+			}
+			seen[val] = true
+			values = append(values, val)
+		}
+		if len(values) == len(variants) {
+			return name, values
+		}
+	}
+	return "", nil
+}
+
+// pinnedValue returns the only value a property allows, if it allows one.
+func pinnedValue(prop schemaProperty) (string, bool) {
+	switch {
+	case prop.Const != nil:
+		return fmt.Sprintf("%v", prop.Const), true
+	case len(prop.Enum) == 1:
+		return fmt.Sprintf("%v", prop.Enum[0]), true
+	}
+	return "", false
+}
+
+// validateConfig checks an assembled config against the full schema,
+// including the oneOf/anyOf/allOf and if/then/else constraints flags cannot
+// express. Schemas without composition are fully covered by validateFlags
+// and are not compiled.
+func (s *parsedSchema) validateConfig(config map[string]any) error {
+	if !s.polymorphic() || len(s.raw) == 0 {
+		return nil
+	}
+	if s.compiled == nil {
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource("schema.json", bytes.NewReader(s.raw)); err != nil {
+			return fmt.Errorf("loading config schema: %w", err)
+		}
+		compiled, err := compiler.Compile("schema.json")
+		if err != nil {
+			return fmt.Errorf("compiling config schema: %w", err)
+		}
+		s.compiled = compiled
+	}
+
+	// Round-trip through JSON so values have the types the validator expects
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decoding config: %w", err)
+	}
+
+	err = s.compiled.Validate(doc)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	return fmt.Errorf("config does not match the plugin schema:\n  %s", strings.Join(leafErrors(ve), "\n  "))
+}
+
+// leafErrors flattens a validation error to its most specific causes.
+func leafErrors(ve *jsonschema.ValidationError) []string {
+	if len(ve.Causes) == 0 {
+		loc := ve.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		return []string{loc + ": " + ve.Message}
+	}
+	var msgs []string
+	for _, c := range ve.Causes {
+		msgs = append(msgs, leafErrors(c)...)
+	}
+	return msgs
+}
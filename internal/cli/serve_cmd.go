@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/server"
+	"google.golang.org/grpc"
+)
+
+// serveTokenEnv names the environment variable holding the API token.
+const serveTokenEnv = "TACK_SERVE_TOKEN"
+
+// newServeCommand creates the "serve" command.
+func newServeCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	var (
+		listen    string
+		grpcAddr  string
+		tokenFile string
+		noAuth    bool
+		plugins   []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve plugin operations over HTTP and gRPC",
+		Long: fmt.Sprintf(`Serve the operations of installed plugins as a REST API until interrupted.
+
+  GET  /plugins                                  list exposed operations
+  POST /plugins/{plugin}/{service}/{operation}   run an operation
+  POST /plugins/{plugin}/{operation}             same, for single-service plugins
+  GET  /healthz                                  liveness (no auth)
+
+The request body is the operation's config as a JSON object; plugin and
+operation defaults from the config file apply underneath it, and every run
+is bounded by the configured timeout. The response is the plugin result as
+JSON, or with ?format=table|yaml|json the output the CLI would print.
+
+Requests must send "Authorization: Bearer <token>", with the token read from
+%s or --token-file. Without a token the server only listens on a
+loopback address unless --no-auth is given.
+
+Plugins run without a terminal to prompt on, so their capabilities must
+already be granted (run each operation once interactively) or the server
+started with --trust-plugins.
+
+With --grpc, the same operations are also offered as the tack.v1.TackService
+gRPC service (api/tack/v1/tack.proto): ListPlugins, DescribeOperation for an
+operation's schemas, and Execute, which streams results as invocations
+finish. gRPC calls send the token as "authorization: Bearer <token>"
+metadata. Pass --listen "" to serve gRPC only.
+
+Examples:
+  %s serve
+  %s=s3cret %s serve --listen :9480 --plugin dns --plugin http
+  curl -H "Authorization: Bearer s3cret" -d '{"hostname":"example.com"}' \
+    localhost:9480/plugins/dns/resolve
+  %s serve --listen "" --grpc 127.0.0.1:9481`, serveTokenEnv, meta.AppName, serveTokenEnv, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := serveToken(tokenFile)
+			if err != nil {
+				return err
+			}
+			if listen == "" && grpcAddr == "" {
+				return errors.New("nothing to serve: --listen and --grpc are both empty")
+			}
+			for _, addr := range []string{listen, grpcAddr} {
+				if addr != "" && token == "" && !noAuth && !isLoopback(addr) {
+					return fmt.Errorf("refusing to serve %s without authentication: set %s or --token-file, or pass --no-auth", addr, serveTokenEnv)
+				}
+			}
+
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+			var manifests []abi.Manifest
+			for _, dp := range discovered {
+				if len(plugins) == 0 || slices.Contains(plugins, dp.Manifest.Name) {
+					manifests = append(manifests, dp.Manifest)
+				}
+			}
+			if len(manifests) == 0 {
+				return errors.New("no plugins to serve")
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			trustPlugins, _ := cmd.Flags().GetBool("trust-plugins")
+			exec := newPluginExecutor(discovered, cfg, verbose, trustPlugins)
+			defer func() { _ = exec.Close() }()
+
+			var opts []server.Option
+			if token != "" {
+				opts = append(opts, server.WithToken(token))
+			}
+			ops := server.Operations(manifests)
+			api := server.New(exec, ops, opts...)
+
+			errCh := make(chan error, 2)
+			var addrs []string
+			var srv *http.Server
+			if listen != "" {
+				srv = &http.Server{
+					Addr:              listen,
+					Handler:           api.Handler(),
+					ReadHeaderTimeout: 10 * time.Second,
+				}
+				go func() { errCh <- srv.ListenAndServe() }()
+				addrs = append(addrs, "http://"+listen)
+			}
+			var grpcSrv *grpc.Server
+			if grpcAddr != "" {
+				lis, err := net.Listen("tcp", grpcAddr)
+				if err != nil {
+					if srv != nil {
+						_ = srv.Close()
+					}
+					return fmt.Errorf("listening on %s: %w", grpcAddr, err)
+				}
+				grpcSrv = api.GRPCServer()
+				go func() { errCh <- grpcSrv.Serve(lis) }()
+				addrs = append(addrs, "grpc://"+grpcAddr)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Serving %d operations from %d plugins on %s (Ctrl-C to stop)\n", len(ops), len(manifests), strings.Join(addrs, ", "))
+
+			stop := func() error {
+				if grpcSrv != nil {
+					grpcSrv.GracefulStop()
+				}
+				if srv == nil {
+					return nil
+				}
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				return srv.Shutdown(shutdownCtx)
+			}
+			select {
+			case err := <-errCh:
+				_ = stop()
+				return fmt.Errorf("serving: %w", err)
+			case <-ctx.Done():
+				return stop()
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:9480", "Address to serve the REST API on (empty to disable)")
+	cmd.Flags().StringVar(&grpcAddr, "grpc", "", "Address to also serve the gRPC API on (e.g. 127.0.0.1:9481)")
+	cmd.Flags().StringVar(&tokenFile, "token-file", "", "File holding the bearer token clients must send (default: $"+serveTokenEnv+")")
+	cmd.Flags().BoolVar(&noAuth, "no-auth", false, "Allow serving without a token on a non-loopback address")
+	cmd.Flags().StringSliceVar(&plugins, "plugin", nil, "Expose only these plugins (repeatable; default: all installed)")
+	return cmd
+}
+
+// serveToken returns the API token from tokenFile, or from the environment
+// when no file is given.
+func serveToken(tokenFile string) (string, error) {
+	if tokenFile == "" {
+		return os.Getenv(serveTokenEnv), nil
+	}
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", tokenFile)
+	}
+	return token, nil
+}
+
+// isLoopback reports whether a listen address only accepts local
+// connections. An empty host (":9480") listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:9480": true,
+		"localhost:9480": true,
+		"[::1]:9480":     true,
+		":9480":          false,
+		"0.0.0.0:9480":   false,
+		"10.0.0.5:9480":  false,
+		"bad":            false,
+	}
+	for addr, want := range tests {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestServeToken(t *testing.T) {
+	t.Setenv(serveTokenEnv, "from-env")
+	if token, err := serveToken(""); err != nil || token != "from-env" {
+		t.Errorf("expected env token, got %q, %v", token, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	_ = os.WriteFile(path, []byte("from-file\n"), 0o600)
+	if token, err := serveToken(path); err != nil || token != "from-file" {
+		t.Errorf("expected file token, got %q, %v", token, err)
+	}
+
+	_ = os.WriteFile(path, []byte("\n"), 0o600)
+	if _, err := serveToken(path); err == nil {
+		t.Error("expected an error for an empty token file")
+	}
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// ExpandTemplatesFlag turns on template expansion in operation flag values.
+const ExpandTemplatesFlag = "expand-templates"
+
+// flagTemplates holds the config templates read from while expansion is on.
+var flagTemplates struct {
+	mu  sync.Mutex
+	cfg *config.Config
+}
+
+// expandTemplatesWith turns on template expansion in operation flag values,
+// reading {{ config }} lookups from cfg. A nil cfg turns it off.
+func expandTemplatesWith(cfg *config.Config) {
+	flagTemplates.mu.Lock()
+	defer flagTemplates.mu.Unlock()
+	flagTemplates.cfg = cfg
+}
+
+// expandConfigTemplates expands templates in the string values of an
+// operation config, including those nested in objects and lists, while
+// expansion is on. Values without "{{" are left alone.
+//
+//	{{ env "TARGET_HOST" }}                 an environment variable, which must be set
+//	{{ config "plugin_defaults.aws.region" }} a value from the config file
+func expandConfigTemplates(values map[string]any) error {
+	flagTemplates.mu.Lock()
+	cfg := flagTemplates.cfg
+	flagTemplates.mu.Unlock()
+	if cfg == nil {
+		return nil
+	}
+
+	funcs := template.FuncMap{
+		"env": func(name string) (string, error) {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			return v, nil
+		},
+		"config": func(path string) (string, error) {
+			return configValue(cfg, path)
+		},
+	}
+	for k, v := range values {
+		expanded, err := expandValue(v, funcs)
+		if err != nil {
+			return fmt.Errorf("expanding --%s: %w", strings.ReplaceAll(k, "_", "-"), err)
+		}
+		values[k] = expanded
+	}
+	return nil
+}
+
+func expandValue(v any, funcs template.FuncMap) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		tmpl, err := template.New("flag").Funcs(funcs).Option("missingkey=error").Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, nil); err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+	case map[string]any:
+		for k, nested := range val {
+			expanded, err := expandValue(nested, funcs)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = expanded
+		}
+		return val, nil
+	case map[string]string:
+		for k, nested := range val {
+			expanded, err := expandValue(nested, funcs)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = expanded.(string)
+		}
+		return val, nil
+	case []string:
+		for i, nested := range val {
+			expanded, err := expandValue(nested, funcs)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = expanded.(string)
+		}
+		return val, nil
+	case []any:
+		for i, nested := range val {
+			expanded, err := expandValue(nested, funcs)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = expanded
+		}
+		return val, nil
+	}
+	return v, nil
+}
+
+// configValue returns the config file value at a dotted path of YAML keys,
+// such as "plugin_defaults.aws.region". The value must be a scalar.
+func configValue(cfg *config.Config, path string) (string, error) {
+	raw, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("reading config: %w", err)
+	}
+	var node any
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return "", fmt.Errorf("reading config: %w", err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("config has no %s", path)
+		}
+		if node, ok = m[key]; !ok {
+			return "", fmt.Errorf("config has no %s", path)
+		}
+	}
+	switch node.(type) {
+	case map[string]any, []any, nil:
+		return "", fmt.Errorf("config %s is not a single value", path)
+	}
+	return fmt.Sprint(node), nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestExpandConfigTemplates(t *testing.T) {
+	t.Setenv("TARGET_HOST", "db.internal")
+	cfg := &config.Config{
+		Output:         "json",
+		PluginDefaults: map[string]map[string]string{"aws": {"region": "eu-west-1"}},
+	}
+
+	values := map[string]any{
+		"hostname": `{{ env "TARGET_HOST" }}`,
+		"region":   `{{ config "plugin_defaults.aws.region" }}`,
+		"tags":     []string{`{{ env "TARGET_HOST" }}:5432`, "plain"},
+		"headers":  map[string]any{"X-Format": `{{ config "output" }}`},
+		"port":     5432,
+	}
+
+	// Off by default
+	if err := expandConfigTemplates(values); err != nil {
+		t.Fatal(err)
+	}
+	if values["hostname"] != `{{ env "TARGET_HOST" }}` {
+		t.Fatalf("templates expanded while off: %v", values["hostname"])
+	}
+
+	expandTemplatesWith(cfg)
+	t.Cleanup(func() { expandTemplatesWith(nil) })
+	if err := expandConfigTemplates(values); err != nil {
+		t.Fatal(err)
+	}
+	if values["hostname"] != "db.internal" {
+		t.Errorf("hostname = %v", values["hostname"])
+	}
+	if values["region"] != "eu-west-1" {
+		t.Errorf("region = %v", values["region"])
+	}
+	if tags := values["tags"].([]string); tags[0] != "db.internal:5432" || tags[1] != "plain" {
+		t.Errorf("tags = %v", tags)
+	}
+	if h := values["headers"].(map[string]any); h["X-Format"] != "json" {
+		t.Errorf("headers = %v", h)
+	}
+	if values["port"] != 5432 {
+		t.Errorf("port = %v", values["port"])
+	}
+}
+
+func TestExpandConfigTemplatesErrors(t *testing.T) {
+	expandTemplatesWith(&config.Config{PluginDefaults: map[string]map[string]string{"aws": {"region": "eu-west-1"}}})
+	t.Cleanup(func() { expandTemplatesWith(nil) })
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`{{ env "TACK_TEST_UNSET_VARIABLE" }}`, "TACK_TEST_UNSET_VARIABLE is not set"},
+		{`{{ config "plugin_defaults.gcp.project" }}`, "config has no plugin_defaults.gcp.project"},
+		{`{{ config "plugin_defaults.aws" }}`, "is not a single value"},
+		{`{{ env "A"`, "unclosed action"},
+	}
+	for _, tt := range tests {
+		err := expandConfigTemplates(map[string]any{"target_host": tt.value})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.value, err, tt.want)
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), "expanding --target-host") {
+			t.Errorf("%s: error %q does not name the flag", tt.value, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+)
+
+// latestReleaseURL is queried for the newest published release.
+const latestReleaseURL = "https://api.github.com/repos/whiskeyjimbo/tack-cli/releases/latest"
+
+// updateCheckInterval is the least time between checks for a new release.
+const updateCheckInterval = 24 * time.Hour
+
+// updateCheck is the cached result of the last release check.
+type updateCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// updateCheckPath returns where the last release check is cached:
+// ~/.tack/update_check.json
+func updateCheckPath() string {
+	return filepath.Join(filepath.Dir(pluginpkg.DefaultCachePath()), "update_check.json")
+}
+
+func loadUpdateCheck(path string) updateCheck {
+	var c updateCheck
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	_ = json.Unmarshal(data, &c)
+	return c
+}
+
+func (c updateCheck) save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// NotifyNewVersion prints a one-line hint to w when the cached release check
+// found a newer version than the one running. It never waits on the
+// network: when the cache is older than a day, it claims the next check and
+// leaves it to a detached "version --check" process, whose result a later
+// command reports. Nothing is printed or checked for development builds,
+// when the check is disabled, or for json, yaml, and quiet output.
+func NotifyNewVersion(w io.Writer, cfg *config.Config, outputFormat string) {
+	if cfg.NoUpdateCheck || !releaseVersion(Version) {
+		return
+	}
+	switch outputFormat {
+	case "json", "yaml", "quiet":
+		return
+	}
+	path := updateCheckPath()
+	check := loadUpdateCheck(path)
+	if newerRelease(check.Latest, Version) {
+		_, _ = fmt.Fprintf(w, "A new release of %s is available: %s -> %s\n", meta.AppName, Version, check.Latest)
+	}
+	if time.Since(check.CheckedAt) > updateCheckInterval {
+		// Claim the check before starting it, so concurrent commands do not
+		// start one each
+		check.CheckedAt = time.Now()
+		if check.save(path) == nil {
+			startUpdateCheck()
+		}
+	}
+}
+
+// startUpdateCheck runs "version --check" in a detached process.
+func startUpdateCheck() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	check := exec.Command(exe, "version", "--check")
+	if err := check.Start(); err != nil {
+		return
+	}
+	_ = check.Process.Release()
+}
+
+// checkLatestRelease fetches the newest release version from url and caches
+// it at path.
+func checkLatestRelease(ctx context.Context, url, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("checking for a new release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checking for a new release: %s", resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return "", fmt.Errorf("checking for a new release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", errors.New("checking for a new release: no release tag")
+	}
+	if err := (updateCheck{CheckedAt: time.Now(), Latest: release.TagName}).save(path); err != nil {
+		return "", fmt.Errorf("caching the release check: %w", err)
+	}
+	return release.TagName, nil
+}
+
+// releaseVersion reports whether v is a released semantic version, not a
+// development build.
+func releaseVersion(v string) bool {
+	_, err := semver.NewVersion(v)
+	return err == nil
+}
+
+// newerRelease reports whether latest is a release newer than current.
+func newerRelease(latest, current string) bool {
+	vl, err := semver.NewVersion(latest)
+	if err != nil {
+		return false
+	}
+	vc, err := semver.NewVersion(current)
+	if err != nil {
+		return false
+	}
+	return vl.GreaterThan(vc)
+}
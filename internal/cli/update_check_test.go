@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestCheckLatestRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name": "v1.4.0"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "update_check.json")
+	latest, err := checkLatestRelease(context.Background(), srv.URL, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != "v1.4.0" {
+		t.Fatalf("latest = %q", latest)
+	}
+	if got := loadUpdateCheck(path); got.Latest != "v1.4.0" || got.CheckedAt.IsZero() {
+		t.Fatalf("cached %+v", got)
+	}
+}
+
+func TestNotifyNewVersion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	old := Version
+	defer func() { Version = old }()
+
+	fresh := updateCheck{CheckedAt: time.Now(), Latest: "v1.4.0"}
+	if err := fresh.save(updateCheckPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		format  string
+		disable bool
+		want    bool
+	}{
+		{"newer release", "1.3.0", "table", false, true},
+		{"up to date", "v1.4.0", "table", false, false},
+		{"development build", "dev", "table", false, false},
+		{"json output", "1.3.0", "json", false, false},
+		{"quiet", "1.3.0", "quiet", false, false},
+		{"disabled", "1.3.0", "table", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version = tt.version
+			var buf bytes.Buffer
+			NotifyNewVersion(&buf, &config.Config{NoUpdateCheck: tt.disable}, tt.format)
+			if got := strings.Contains(buf.String(), "v1.4.0"); got != tt.want {
+				t.Fatalf("printed %q, want hint %v", buf.String(), tt.want)
+			}
+		})
+	}
+}
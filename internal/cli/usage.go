@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// StatsFlag reports the resources each plugin operation used once a
+// command has run.
+const StatsFlag = "stats"
+
+// WriteUsageReport writes the resource usage of the operations run during
+// this command as a table.
+func WriteUsageReport(w io.Writer) error {
+	return renderUsageReport(w, "table", runtime.Usages())
+}
+
+// RecordUsage appends the resource usage of this command's operations to
+// the history store.
+func RecordUsage(store *history.Store) error {
+	for _, u := range runtime.Usages() {
+		err := store.Append(history.Record{
+			Source:    "stats",
+			Plugin:    u.Plugin,
+			Service:   u.Service,
+			Operation: u.Operation,
+			Status:    u.Status,
+			StartedAt: u.StartedAt,
+			Duration:  u.WallTime,
+			Usage: &history.Usage{
+				PluginVersion:   u.Version,
+				PeakMemoryBytes: u.PeakMemoryBytes,
+				HostCalls:       u.HostCalls,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderUsageReport(w io.Writer, format string, usages []runtime.Usage) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(usages)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(usages)
+	case "table", "":
+		if len(usages) == 0 {
+			_, err := fmt.Fprintln(w, "Resource usage: no plugin operations ran")
+			return err
+		}
+		_, _ = fmt.Fprintln(w, "Resource usage:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PLUGIN\tVERSION\tOPERATION\tSTATUS\tWALL TIME\tPEAK MEMORY\tHOST CALLS")
+		for _, u := range usages {
+			op := u.Operation
+			if u.Service != "" {
+				op = u.Service + " " + op
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				u.Plugin, u.Version, op, u.Status,
+				formatMS(float64(u.WallTime.Microseconds())/1000), formatMemory(u.PeakMemoryBytes), formatHostCalls(u.HostCalls))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
+}
+
+// formatMemory prints a byte count in KiB or MiB, or "-" when unmeasured.
+func formatMemory(n uint64) string {
+	switch {
+	case n == 0:
+		return "-"
+	case n < 1<<20:
+		return fmt.Sprintf("%.0f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	}
+}
+
+// formatHostCalls prints host call counts as "fn=n" pairs in name order.
+func formatHostCalls(calls map[string]int) string {
+	if len(calls) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, calls[name])
+	}
+	return strings.Join(parts, " ")
+}
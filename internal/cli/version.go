@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	internalplugin "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"gopkg.in/yaml.v3"
 )
 
 // Version is set at build time via ldflags.
@@ -18,17 +24,156 @@ var Commit = "unknown"
 // BuildTime is set at build time via ldflags.
 var BuildTime = "unknown"
 
+// abiModule is the module defining the plugin ABI the CLI speaks.
+const abiModule = "github.com/reglet-dev/reglet-abi"
+
+// versionInfo is the output of "version".
+type versionInfo struct {
+	Version         string `json:"version" yaml:"version"`
+	Commit          string `json:"commit" yaml:"commit"`
+	BuildTime       string `json:"build_time" yaml:"build_time"`
+	Go              string `json:"go" yaml:"go"`
+	OS              string `json:"os" yaml:"os"`
+	Arch            string `json:"arch" yaml:"arch"`
+	BuildTags       string `json:"build_tags,omitempty" yaml:"build_tags,omitempty"`
+	EmbeddedPlugins bool   `json:"embedded_plugins" yaml:"embedded_plugins"`
+
+	// ABI is the version of the plugin ABI module the CLI was built with.
+	ABI     string          `json:"abi" yaml:"abi"`
+	Modules []moduleVersion `json:"modules" yaml:"modules"`
+
+	// Latest and UpdateAvailable are set by --check.
+	Latest          string `json:"latest,omitempty" yaml:"latest,omitempty"`
+	UpdateAvailable bool   `json:"update_available,omitempty" yaml:"update_available,omitempty"`
+}
+
+// moduleVersion is a dependency compiled into the binary, with its go.sum
+// checksum.
+type moduleVersion struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version" yaml:"version"`
+	Sum     string `json:"sum,omitempty" yaml:"sum,omitempty"`
+}
+
+// buildVersionInfo collects the version and build metadata of the running
+// binary.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:         Version,
+		Commit:          Commit,
+		BuildTime:       BuildTime,
+		Go:              runtime.Version(),
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		EmbeddedPlugins: internalplugin.PluginsEmbedded,
+		ABI:             "unknown",
+		Modules:         []moduleVersion{},
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range build.Settings {
+		if s.Key == "-tags" {
+			info.BuildTags = s.Value
+		}
+	}
+	for _, dep := range build.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		info.Modules = append(info.Modules, moduleVersion{Path: dep.Path, Version: dep.Version, Sum: dep.Sum})
+		if dep.Path == abiModule {
+			info.ABI = dep.Version
+		}
+	}
+	return info
+}
+
 // newVersionCommand creates the "version" command.
 func newVersionCommand() *cobra.Command {
-	return &cobra.Command{
+	var check bool
+
+	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("%s version %s\n", meta.AppName, Version)
-			fmt.Printf("  commit:     %s\n", Commit)
-			fmt.Printf("  build time: %s\n", BuildTime)
-			fmt.Printf("  go:         %s\n", runtime.Version())
-			fmt.Printf("  os/arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		Long: `Print version information: the release, commit, and build time, whether
+plugins are embedded, the plugin ABI version supported, and the checksum of
+every module compiled in.
+
+With --check, also look up the latest release and say whether it is newer.
+The result is cached; commands print a one-line hint on stderr while a newer
+release is available.`,
+		Example: fmt.Sprintf(`  %s version
+  %s version --output json
+  %s version --check`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := buildVersionInfo()
+			if check {
+				latest, err := checkLatestRelease(cmd.Context(), latestReleaseURL, updateCheckPath())
+				if err != nil {
+					return err
+				}
+				info.Latest, info.UpdateAvailable = latest, newerRelease(latest, Version)
+			}
+			format, _ := cmd.Flags().GetString("output")
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			return renderVersion(cmd.OutOrStdout(), format, info)
 		},
 	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Look up the latest release")
+	return cmd
+}
+
+// renderVersion writes version information in the given output format.
+func renderVersion(w io.Writer, format string, info versionInfo) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(info)
+	case "table", "":
+		_, _ = fmt.Fprintf(w, "%s version %s\n", meta.AppName, info.Version)
+		_, _ = fmt.Fprintf(w, "  commit:     %s\n", info.Commit)
+		_, _ = fmt.Fprintf(w, "  build time: %s\n", info.BuildTime)
+		_, _ = fmt.Fprintf(w, "  go:         %s\n", info.Go)
+		_, _ = fmt.Fprintf(w, "  os/arch:    %s/%s\n", info.OS, info.Arch)
+		if info.BuildTags != "" {
+			_, _ = fmt.Fprintf(w, "  build tags: %s\n", info.BuildTags)
+		}
+		if info.EmbeddedPlugins {
+			_, _ = fmt.Fprintln(w, "  plugins:    embedded")
+		} else {
+			_, _ = fmt.Fprintln(w, "  plugins:    not embedded")
+		}
+		_, _ = fmt.Fprintf(w, "  abi:        %s\n", info.ABI)
+		switch {
+		case info.UpdateAvailable:
+			_, _ = fmt.Fprintf(w, "  latest:     %s (update available)\n", info.Latest)
+		case info.Latest != "":
+			_, _ = fmt.Fprintf(w, "  latest:     %s\n", info.Latest)
+		}
+		if len(info.Modules) == 0 {
+			return nil
+		}
+		_, _ = fmt.Fprintln(w, "\nModules:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, m := range info.Modules {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\n", m.Path, m.Version, m.Sum)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+	}
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderVersion(t *testing.T) {
+	info := versionInfo{
+		Version:         "1.3.0",
+		Commit:          "abc123",
+		BuildTime:       "2026-01-02T03:04:05Z",
+		Go:              "go1.25.0",
+		OS:              "linux",
+		Arch:            "amd64",
+		BuildTags:       "embed_plugins",
+		EmbeddedPlugins: true,
+		ABI:             "v0.1.1",
+		Modules:         []moduleVersion{{Path: abiModule, Version: "v0.1.1", Sum: "h1:abc="}},
+		Latest:          "v1.4.0",
+		UpdateAvailable: true,
+	}
+
+	var buf bytes.Buffer
+	if err := renderVersion(&buf, "table", info); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"tack version 1.3.0", "build tags: embed_plugins", "plugins:    embedded", "abi:        v0.1.1", "v1.4.0 (update available)", abiModule} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := renderVersion(&buf, "json", info); err != nil {
+		t.Fatal(err)
+	}
+	var decoded versionInfo
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ABI != "v0.1.1" || len(decoded.Modules) != 1 || decoded.Modules[0].Sum != "h1:abc=" || !decoded.UpdateAvailable {
+		t.Errorf("unexpected json %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := renderVersion(&buf, "quiet", info); err != nil || buf.Len() != 0 {
+		t.Errorf("quiet printed %q (%v)", buf.String(), err)
+	}
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/notify"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+	pluginpkg "github.com/whiskeyjimb/tack-cli/internal/plugin"
+	"github.com/whiskeyjimb/tack-cli/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// newWorkflowCommand creates the "workflow" command group.
+func newWorkflowCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workflow",
+		Short: "Run multi-step check workflows",
+	}
+
+	cmd.AddCommand(
+		newWorkflowRunCommand(cfg, stack),
+		newWorkflowValidateCommand(),
+	)
+
+	return cmd
+}
+
+// newWorkflowRunCommand creates the "workflow run" command.
+func newWorkflowRunCommand(cfg *config.Config, stack *pluginpkg.PluginStack) *cobra.Command {
+	var (
+		concurrency int
+		failFast    bool
+		stepTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run <file>",
+		Short: "Run a workflow definition",
+		Long: fmt.Sprintf(`Run a workflow definition.
+
+Steps run once all steps listed in depends_on have succeeded. String inputs
+are Go templates that can reference the outputs of those steps:
+
+  with:
+    host: '{{ index .steps.resolve.data.records 0 }}'
+
+--parallel bounds how many steps run at once, --fail-fast stops starting
+steps once one does not succeed, and --step-timeout bounds each step that
+does not set its own timeout. A summary of passed, failed, errored and
+skipped steps is printed to stderr; the command exits non-zero unless every
+step passed.
+
+Examples:
+  %s workflow run checks.yaml
+  %s workflow run checks.yaml --parallel 8 --output json
+  %s workflow run checks.yaml --fail-fast --step-timeout 30s`, meta.AppName, meta.AppName, meta.AppName),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := workflow.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			discovered, err := discoverPlugins(ctx, cfg, stack)
+			if err != nil {
+				return fmt.Errorf("discovering plugins: %w", err)
+			}
+
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			trustPlugins, _ := cmd.Flags().GetBool("trust-plugins")
+			exec := newPluginExecutor(discovered, cfg, verbose, trustPlugins)
+			defer func() { _ = exec.Close() }()
+
+			report, err := workflow.Run(ctx, wf, exec, workflow.RunOptions{
+				Concurrency: concurrency,
+				FailFast:    failFast,
+				StepTimeout: stepTimeout,
+			})
+			if err != nil {
+				return err
+			}
+
+			show, _ := cmd.Flags().GetBool(ShowSecretsFlag)
+			mask := sensitiveMasks(discovered, show)
+			for i := range report.Steps {
+				st := &report.Steps[i]
+				st.Data = mask(st.Plugin, st.Service, st.Operation, st.Data)
+			}
+
+			format, _ := cmd.Flags().GetString("output")
+			format = resultFormat(format, cmd.Flags().Changed("output"))
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				format = "quiet"
+			}
+			if err := renderWorkflowReport(cmd.OutOrStdout(), format, report, severityHints(discovered)); err != nil {
+				return err
+			}
+			statuses := make([]string, len(report.Steps))
+			for i, s := range report.Steps {
+				statuses[i] = s.Status
+			}
+			summary := summarize(statuses, report.Duration)
+			if format != "quiet" {
+				_ = summary.write(cmd.ErrOrStderr())
+			}
+
+			if !report.Succeeded() {
+				var failed []string
+				steps := make(map[string]any, len(report.Steps))
+				for _, s := range report.Steps {
+					steps[s.ID] = s.Status
+					if s.Status != workflow.StatusSuccess {
+						failed = append(failed, s.ID)
+					}
+				}
+
+				notifier, err := notify.New(cfg.Notifications)
+				if err != nil {
+					return err
+				}
+				notifier.Notify(ctx, notify.Event{
+					Source:  "workflow",
+					Name:    wf.Name,
+					Status:  workflow.StatusFailure,
+					Message: fmt.Sprintf("steps did not succeed: %s", strings.Join(failed, ", ")),
+					Data:    map[string]any{"steps": steps},
+				})
+
+				return fmt.Errorf("workflow %q: %d of %d steps did not succeed", wf.Name, len(failed), len(report.Steps))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&concurrency, parallelFlag, 0, "Maximum steps to run at once (overrides the workflow file)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "Maximum steps to run at once (alias for --parallel)")
+	_ = cmd.Flags().MarkHidden("concurrency")
+	cmd.Flags().BoolVar(&failFast, failFastFlag, false, "Stop starting steps once one does not succeed")
+	cmd.Flags().DurationVar(&stepTimeout, stepTimeoutFlag, 0, "Maximum run time of each step without its own timeout (0 for none)")
+	return cmd
+}
+
+// newWorkflowValidateCommand creates the "workflow validate" command.
+func newWorkflowValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check a workflow definition for errors",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := workflow.Load(args[0])
+			if err != nil {
+				return err
+			}
+			order, _ := wf.Order()
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Workflow %q is valid (%d steps)\n", wf.Name, len(wf.Steps))
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Execution order: %s\n", strings.Join(order, " -> "))
+			return nil
+		},
+	}
+}
+
+// renderWorkflowReport writes a workflow report in the given output format.
+// severity supplies Code Quality severity hints and may be nil.
+func renderWorkflowReport(w io.Writer, format string, report *workflow.Report, severity severityFunc) error {
+	switch format {
+	case "quiet":
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "STEP\tPLUGIN\tOPERATION\tDEPENDS ON\tSTATUS\tDURATION\tMESSAGE")
+		for _, s := range report.Steps {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				s.ID, s.Plugin, s.Operation, strings.Join(s.DependsOn, ","),
+				s.Status, s.Duration.Round(time.Millisecond), s.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "\nWorkflow %q finished in %s\n", report.Workflow, report.Duration.Round(time.Millisecond))
+		return nil
+	case "gha":
+		if err := renderWorkflowReport(w, "table", report, severity); err != nil {
+			return err
+		}
+		checks := workflowChecks(report, severity)
+		if err := output.WriteAnnotations(w, checks); err != nil {
+			return err
+		}
+		return output.WriteStepSummary("Workflow "+report.Workflow, checks)
+	case "codequality":
+		return output.WriteCodeQuality(w, workflowChecks(report, severity))
+	default:
+		return fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, gha, codequality, quiet)", format)
+	}
+}
+
+// workflowChecks lists workflow steps as CI checks.
+func workflowChecks(report *workflow.Report, severity severityFunc) []output.Check {
+	checks := make([]output.Check, 0, len(report.Steps))
+	for _, s := range report.Steps {
+		checks = append(checks, output.Check{
+			Name:      s.ID,
+			Status:    s.Status,
+			Message:   s.Message,
+			Duration:  s.Duration,
+			Plugin:    s.Plugin,
+			Service:   s.Service,
+			Operation: s.Operation,
+			Severity:  severity.of(s.Plugin, s.Service, s.Operation),
+		})
+	}
+	return checks
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
+)
+
+// newWorkspaceCommand creates the "workspace" command.
+func newWorkspaceCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Show and trust the project workspace",
+		Long: fmt.Sprintf(`A workspace is the nearest %[2]s directory at or above the working
+directory. Its history is always kept there. Its plugins, capability
+grants, remembered reviews, and config.yaml are used only once you
+trust it, since anyone can commit a %[2]s directory to a repository;
+until then they come from ~/%[2]s.
+
+Examples:
+  %[1]s workspace status
+  %[1]s workspace trust
+  %[1]s workspace untrust ~/src/project`, meta.AppName, workspace.DirName),
+	}
+	cmd.AddCommand(newWorkspaceStatusCommand())
+	cmd.AddCommand(newWorkspaceTrustCommand(cfg))
+	cmd.AddCommand(newWorkspaceUntrustCommand(cfg))
+	return cmd
+}
+
+func newWorkspaceStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the workspace and whether it is trusted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			dir := workspace.Dir()
+			if dir == "" {
+				_, _ = fmt.Fprintln(out, "Not in a workspace")
+				return nil
+			}
+			trusted := "no"
+			if workspace.Trusted(dir) {
+				trusted = "yes"
+			}
+			_, _ = fmt.Fprintf(out, "Workspace: %s\nTrusted:   %s\n", dir, trusted)
+			return nil
+		},
+	}
+}
+
+func newWorkspaceTrustCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "trust [dir]",
+		Short:   "Use the plugins, grants, and config of a workspace",
+		Long:    "Trust the workspace containing dir, or the working directory, so its plugins, capability\ngrants, remembered reviews, and config.yaml are used.",
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: denyInReadOnly(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := workspaceArg(args)
+			if err != nil {
+				return err
+			}
+			if err := workspace.Trust(dir); err != nil {
+				return fmt.Errorf("trusting %s: %w", dir, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Trusted %s\n", dir)
+			return nil
+		},
+	}
+}
+
+func newWorkspaceUntrustCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "untrust [dir]",
+		Short:   "Stop using the plugins, grants, and config of a workspace",
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: denyInReadOnly(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := workspaceArg(args)
+			if err != nil {
+				return err
+			}
+			if err := workspace.Untrust(dir); err != nil {
+				return fmt.Errorf("untrusting %s: %w", dir, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "No longer trusting %s\n", dir)
+			return nil
+		},
+	}
+}
+
+// workspaceArg returns the workspace containing the directory in args, or
+// the working directory.
+func workspaceArg(args []string) (string, error) {
+	start := "."
+	if len(args) > 0 {
+		start = args[0]
+	}
+	dir := workspace.Find(start)
+	if dir == "" {
+		return "", fmt.Errorf("no %s directory at or above %s", workspace.DirName, start)
+	}
+	return dir, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AutoGroupResult reports what ApplyAutoGroups changed.
+type AutoGroupResult struct {
+	Created []string
+	Updated []string
+	// Skipped lists categories whose group name is taken by a user-created
+	// group or is not a valid group name.
+	Skipped []string
+}
+
+// ApplyAutoGroups creates or refreshes one group per category, containing
+// the given plugins. Groups it creates are marked Auto; groups without the
+// mark were created by the user and are never modified.
+func (c *Config) ApplyAutoGroups(categories map[string][]string) AutoGroupResult {
+	var res AutoGroupResult
+	if c.Groups == nil {
+		c.Groups = make(map[string]GroupConfig)
+	}
+
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		plugins := append([]string{}, categories[name]...)
+		sort.Strings(plugins)
+
+		existing, exists := c.Groups[name]
+		switch {
+		case ValidateGroupName(name) != nil || name == "top":
+			res.Skipped = append(res.Skipped, name)
+		case !exists:
+			c.Groups[name] = GroupConfig{
+				Description: fmt.Sprintf("Plugins in the %s category", name),
+				Plugins:     plugins,
+				Auto:        true,
+			}
+			res.Created = append(res.Created, name)
+		case !existing.Auto:
+			res.Skipped = append(res.Skipped, name)
+		case !equalStrings(existing.Plugins, plugins):
+			existing.Plugins = plugins
+			c.Groups[name] = existing
+			res.Updated = append(res.Updated, name)
+		}
+	}
+	return res
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
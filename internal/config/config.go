@@ -3,10 +3,15 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
 	"gopkg.in/yaml.v3"
 )
@@ -19,11 +24,71 @@ type Config struct {
 	// Timeout is the default operation timeout.
 	Timeout string `yaml:"timeout"`
 
+	// MaxInstances caps the instantiated modules kept per plugin when
+	// operations run concurrently (serve, schedule, group run, workflows).
+	// Zero means the number of CPUs.
+	MaxInstances int `yaml:"max_instances,omitempty"`
+
+	// BackgroundRefresh rebuilds the plugin discovery cache in a detached
+	// process, at most once a day, after a command was served from it.
+	BackgroundRefresh bool `yaml:"background_refresh,omitempty"`
+
+	// NoUpdateCheck turns off the daily check for a newer release and the
+	// hint printed when there is one.
+	NoUpdateCheck bool `yaml:"no_update_check,omitempty"`
+
+	// MaxArtifactSize is the largest plugin binary read, installed, or
+	// pulled, such as "256MB" or "1GiB". Empty means the built-in default.
+	MaxArtifactSize string `yaml:"max_artifact_size,omitempty"`
+
+	// MaxArtifactSizeByRegistry caps plugin binaries pulled from a registry
+	// or repository prefix ("ghcr.io", "ghcr.io/acme") below
+	// MaxArtifactSize. The longest matching prefix applies.
+	MaxArtifactSizeByRegistry map[string]string `yaml:"max_artifact_size_by_registry,omitempty"`
+
+	// Licenses restricts the licenses of plugins installed from registries
+	// and OCI layouts.
+	Licenses LicensePolicy `yaml:"licenses,omitempty"`
+
+	// CompressPlugins stores plugins installed from local files
+	// zstd-compressed in the plugin cache.
+	CompressPlugins bool `yaml:"compress_plugins,omitempty"`
+
+	// AllowExecPlugins permits loading plugins whose manifest requests
+	// command execution, whatever --trust-plugins or stored grants say.
+	// Unset, it is true except in CI (CI=true in the environment).
+	AllowExecPlugins *bool `yaml:"allow_exec_plugins,omitempty"`
+
+	// ReadOnly refuses config changes and plugin install/remove, and
+	// blocks plugins that need filesystem writes or command execution.
+	ReadOnly bool `yaml:"read_only,omitempty"`
+
+	// Log tees the CLI's stderr, and each plugin host function call, to a
+	// log file that rotates by size.
+	Log LogConfig `yaml:"log,omitempty"`
+
+	// Results keeps the full result of every plugin operation in a local
+	// database, queried with "tack results".
+	Results ResultsConfig `yaml:"results,omitempty"`
+
+	// FSMounts exposes host directories to plugins at virtual paths. When
+	// set, plugin filesystem capabilities are evaluated against these
+	// paths, and access outside them is refused even if granted.
+	FSMounts []FSMount `yaml:"fs_mounts,omitempty"`
+
+	// ExternalPluginDirs are searched, before PATH, for "tack-<name>"
+	// executables run when a command is not otherwise known.
+	ExternalPluginDirs []string `yaml:"external_plugin_dirs,omitempty"`
+
 	// DefaultRegistry is the OCI registry prefix for plugin references.
 	// When a user runs "cli plugin install dns", this prefix is prepended
 	// to form the full OCI reference: "ghcr.io/reglet-dev/reglet-plugins/dns:latest"
 	DefaultRegistry string `yaml:"default_registry"`
 
+	// AutoInstall installs a plugin named by an unknown command, when one
+	// index publishes it, and runs the command, without asking first.
+	AutoInstall bool `yaml:"auto_install,omitempty"`
+
 	// RequireSigning controls whether plugins must have valid cosign signatures.
 	RequireSigning bool `yaml:"require_signing"`
 
@@ -38,18 +103,163 @@ type Config struct {
 	// Example: {"aws": {"region": "us-east-1"}}
 	PluginDefaults map[string]map[string]string `yaml:"plugin_defaults"`
 
+	// OperationDefaults holds default flag values for single operations,
+	// keyed by plugin, service, and operation. They take precedence over
+	// PluginDefaults for that operation.
+	// Example: {"aws": {"ec2": {"describe_security_groups": {"region": "us-west-2"}}}}
+	OperationDefaults map[string]map[string]map[string]map[string]string `yaml:"operation_defaults,omitempty"`
+
+	// HiddenPlugins lists plugins whose commands are left out of help
+	// output. They can still be invoked by name.
+	HiddenPlugins []string `yaml:"hidden_plugins,omitempty"`
+
 	// Indexes lists additional plugin search indexes.
 	Indexes []IndexSource `yaml:"indexes"`
 
+	// IndexPublicKeys are cosign public key files trusted to sign every
+	// index, including the official one.
+	IndexPublicKeys []string `yaml:"index_public_keys,omitempty"`
+
+	// RequireSignedIndexes refuses indexes whose signature cannot be verified.
+	RequireSignedIndexes bool `yaml:"require_signed_indexes"`
+
 	// Groups maps group names to their configuration.
 	// Plugins in a group are accessed as: tack <group> <plugin> <operation>
 	Groups map[string]GroupConfig `yaml:"groups,omitempty"`
+
+	// Schedule lists checks run periodically by "tack schedule".
+	Schedule []ScheduledCheck `yaml:"schedule,omitempty"`
+
+	// Notifications lists sinks alerted when scheduled checks or
+	// workflows transition to failure.
+	Notifications []NotificationConfig `yaml:"notifications,omitempty"`
+
+	// Network configures proxies and TLS trust for index fetches and
+	// registry pulls.
+	Network NetworkConfig `yaml:"network,omitempty"`
+
+	// Remote configures --remote, which runs plugin operations on another
+	// machine over SSH.
+	Remote RemoteConfig `yaml:"remote,omitempty"`
+
+	// overlay is the config read over this one by LoadOverlay, if any.
+	overlay string
+}
+
+// RemoteConfig configures how --remote reaches remote hosts.
+type RemoteConfig struct {
+	// Command runs tack on remote hosts. Default: tack, found on the
+	// remote PATH.
+	Command string `yaml:"command,omitempty"`
+
+	// SSHOptions are passed to ssh before the host, such as
+	// ["-p", "2222"] or ["-i", "~/.ssh/checks"].
+	SSHOptions []string `yaml:"ssh_options,omitempty"`
+}
+
+// NetworkConfig configures outbound HTTP for corporate networks.
+type NetworkConfig struct {
+	// Proxy is the proxy URL for HTTP and HTTPS. When empty, the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// NoProxy lists hosts, domains and CIDRs reached without the proxy,
+	// comma-separated like NO_PROXY.
+	NoProxy string `yaml:"no_proxy,omitempty"`
+
+	// CAFiles are PEM bundles trusted in addition to the system roots.
+	CAFiles []string `yaml:"ca_files,omitempty"`
+
+	// InsecureSkipTLSVerify lists registry and index hosts whose TLS
+	// certificates are not verified.
+	InsecureSkipTLSVerify []string `yaml:"insecure_skip_tls_verify,omitempty"`
+
+	// PlainHTTP lists registry hosts (host or host:port) reached over plain
+	// HTTP, such as localhost:5000 during development.
+	PlainHTTP []string `yaml:"plain_http,omitempty"`
+}
+
+// LogConfig configures the log file.
+type LogConfig struct {
+	// Enabled writes the log to Path. Setting Path also enables it.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Path is the log file. Default: ~/.tack/logs/tack.log.
+	Path string `yaml:"path,omitempty"`
+
+	// MaxSize is the size at which the log rotates, such as "10MB".
+	MaxSize string `yaml:"max_size,omitempty"`
+
+	// Keep is how many rotated logs are retained. Default: 5.
+	Keep int `yaml:"keep,omitempty"`
+}
+
+// ResultsConfig configures the result store.
+type ResultsConfig struct {
+	// Enabled stores results at Path. Setting Path also enables it.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Path is the database file. Default: ~/.tack/results.db.
+	Path string `yaml:"path,omitempty"`
+
+	// Sinks are sent every result as it completes, whether or not results
+	// are stored.
+	Sinks []ResultSinkConfig `yaml:"sinks,omitempty"`
+}
+
+// ResultSinkConfig defines where results are pushed: an HTTP endpoint, or a
+// file or FIFO written as JSON Lines.
+type ResultSinkConfig struct {
+	// Name identifies the sink in warnings. Optional.
+	Name string `yaml:"name,omitempty"`
+
+	// URL is the endpoint each result is POSTed to as JSON.
+	URL string `yaml:"url,omitempty"`
+
+	// Headers are added to requests. Values may reference environment
+	// variables as $VAR or ${VAR}.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// File is appended one JSON result per line. It may be a FIFO.
+	File string `yaml:"file,omitempty"`
+}
+
+// LicensePolicy lists the SPDX licenses plugins may and may not have.
+// Identifiers are compared without regard to case and may use * globs.
+type LicensePolicy struct {
+	// Allowed lists the permitted licenses. When set, plugins with other
+	// licenses, or none, are not permitted.
+	Allowed []string `yaml:"allowed,omitempty"`
+
+	// Forbidden lists licenses that are never permitted.
+	Forbidden []string `yaml:"forbidden,omitempty"`
+
+	// Mode is "enforce" (the default) to refuse such plugins or "warn" to
+	// install them with a warning.
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// FSMount maps a host directory into the plugin-visible filesystem.
+type FSMount struct {
+	// Host is the directory on disk. Relative paths are resolved against
+	// the working directory.
+	Host string `yaml:"host"`
+
+	// Path is the absolute path plugins see, such as "/work".
+	Path string `yaml:"path"`
+
+	// ReadOnly refuses plugin writes under Path.
+	ReadOnly bool `yaml:"read_only,omitempty"`
 }
 
 // IndexSource defines a plugin index location.
 type IndexSource struct {
 	URL  string `yaml:"url"`
 	Name string `yaml:"name"`
+
+	// PublicKey is a cosign public key file that must have signed the
+	// index (a detached signature at <url>.sig).
+	PublicKey string `yaml:"public_key,omitempty"`
 }
 
 // GroupConfig defines a named plugin group.
@@ -57,8 +267,155 @@ type GroupConfig struct {
 	// Description is the help text shown for the group command.
 	Description string `yaml:"description"`
 
-	// Plugins lists plugin names that belong to this group.
+	// Plugins lists plugin names that belong to this group. An entry naming
+	// another group nests that group's commands under this one.
 	Plugins []string `yaml:"plugins"`
+
+	// Defaults holds flag defaults applied to every plugin invoked through
+	// this group, e.g. {"region": "us-east-1"}. They take precedence over
+	// plugin_defaults; flags given on the command line still win. Defaults
+	// of an enclosing group apply to nested groups, which may override them.
+	Defaults map[string]string `yaml:"defaults,omitempty"`
+
+	// Hidden leaves the group's command out of help output; it can still
+	// be invoked by name.
+	Hidden bool `yaml:"hidden,omitempty"`
+
+	// Auto marks groups managed by "group auto". Only these are updated
+	// when categories change; user-created groups are left alone.
+	Auto bool `yaml:"auto,omitempty"`
+}
+
+// ScheduledCheck defines a plugin operation run on a cron-like schedule.
+type ScheduledCheck struct {
+	// Name identifies the check in status output and history.
+	Name string `yaml:"name"`
+
+	// Schedule is a 5-field cron expression or descriptor such as "@every 1m".
+	Schedule string `yaml:"schedule"`
+
+	// Plugin, Service, and Operation select what to run.
+	// Service may be omitted for single-service plugins.
+	Plugin    string `yaml:"plugin"`
+	Service   string `yaml:"service,omitempty"`
+	Operation string `yaml:"operation"`
+
+	// With holds the operation config (snake_case field names).
+	With map[string]any `yaml:"with,omitempty"`
+}
+
+// NotificationConfig defines a notification sink.
+type NotificationConfig struct {
+	// Name identifies the sink in warnings. Optional.
+	Name string `yaml:"name,omitempty"`
+
+	// Type is one of "webhook", "slack", or "email".
+	Type string `yaml:"type"`
+
+	// URL is the endpoint for webhook and slack sinks.
+	URL string `yaml:"url,omitempty"`
+
+	// Headers are added to webhook requests. Values may reference
+	// environment variables as $VAR or ${VAR}.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Template is a Go text/template for the message body, rendered
+	// against the notification event.
+	Template string `yaml:"template,omitempty"`
+
+	// On lists the statuses that trigger this sink. Default: failure, error.
+	On []string `yaml:"on,omitempty"`
+
+	// SMTP settings for email sinks. The password is read from the
+	// environment variable named by PasswordEnv.
+	SMTPHost    string   `yaml:"smtp_host,omitempty"`
+	SMTPPort    int      `yaml:"smtp_port,omitempty"`
+	Username    string   `yaml:"username,omitempty"`
+	PasswordEnv string   `yaml:"password_env,omitempty"`
+	From        string   `yaml:"from,omitempty"`
+	To          []string `yaml:"to,omitempty"`
+}
+
+// OperationTimeout parses Timeout. An empty value or "0" disables the
+// timeout and returns zero.
+func (c *Config) OperationTimeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", c.Timeout, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must not be negative", c.Timeout)
+	}
+	return d, nil
+}
+
+// ArtifactSizeLimit parses MaxArtifactSize into bytes. An empty value
+// returns zero, meaning the default.
+func (c *Config) ArtifactSizeLimit() (int64, error) {
+	return parseSize("max_artifact_size", c.MaxArtifactSize, "256MB")
+}
+
+// RegistryArtifactSizeLimits parses MaxArtifactSizeByRegistry into bytes
+// per registry prefix.
+func (c *Config) RegistryArtifactSizeLimits() (map[string]int64, error) {
+	limits := make(map[string]int64, len(c.MaxArtifactSizeByRegistry))
+	for prefix, value := range c.MaxArtifactSizeByRegistry {
+		n, err := parseSize("max_artifact_size_by_registry."+prefix, value, "50MB")
+		if err != nil {
+			return nil, err
+		}
+		limits[prefix] = n
+	}
+	return limits, nil
+}
+
+// ExecPluginsAllowed resolves AllowExecPlugins, which defaults to false
+// when the CI environment variable says the CLI runs in CI.
+func (c *Config) ExecPluginsAllowed() bool {
+	if c.AllowExecPlugins != nil {
+		return *c.AllowExecPlugins
+	}
+	ci, _ := strconv.ParseBool(os.Getenv("CI"))
+	return !ci
+}
+
+// LogMaxSize parses Log.MaxSize into bytes. An empty value returns zero,
+// meaning the default.
+func (c *Config) LogMaxSize() (int64, error) {
+	return parseSize("log.max_size", c.Log.MaxSize, "10MB")
+}
+
+// parseSize parses a size setting into bytes. An empty value returns zero.
+// Units are B, KB, MB, and GB (powers of 1024, also written KiB, MiB, GiB);
+// a bare number is bytes.
+func parseSize(name, value, example string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	s := strings.ToUpper(strings.TrimSpace(value))
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"B", 1},
+	}
+	scale := int64(1)
+	for _, u := range units {
+		if rest, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, scale = strings.TrimSpace(rest), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/scale {
+		return 0, fmt.Errorf("invalid %s %q: want a positive size such as %s", name, value, example)
+	}
+	return n * scale, nil
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -91,6 +448,25 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// LoadOverlay reads the config at path over c, as a trusted workspace's
+// config is read over the user's: the settings it has replace those of c.
+// A missing file is not an error. A config with an overlay cannot be saved,
+// as saving would copy the overlay's settings into the user's config.
+func (c *Config) LoadOverlay(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	c.overlay = path
+	return nil
+}
+
 // DefaultConfigPath returns the default config file path.
 // ~/.tack/config.yaml
 func DefaultConfigPath() string {
@@ -117,6 +493,8 @@ func DefaultConfigDir() string {
 //   - TACK_OUTPUT: default output format
 //   - TACK_TIMEOUT: default timeout
 //   - TACK_DEFAULT_REGISTRY: OCI registry prefix
+//   - TACK_READ_ONLY: turns read-only mode on (it cannot be turned off)
+//   - TACK_NO_UPDATE_CHECK: turns the new-release check off
 func (c *Config) ApplyEnvOverrides() {
 	prefix := strings.ToUpper(meta.AppName) + "_"
 	if v := os.Getenv(prefix + "OUTPUT"); v != "" {
@@ -128,35 +506,173 @@ func (c *Config) ApplyEnvOverrides() {
 	if v := os.Getenv(prefix + "DEFAULT_REGISTRY"); v != "" {
 		c.DefaultRegistry = v
 	}
+	if v, err := strconv.ParseBool(os.Getenv(prefix + "READ_ONLY")); err == nil && v {
+		c.ReadOnly = true
+	}
+	if v, err := strconv.ParseBool(os.Getenv(prefix + "NO_UPDATE_CHECK")); err == nil && v {
+		c.NoUpdateCheck = true
+	}
+	if v, err := strconv.ParseBool(os.Getenv(prefix + "ALLOW_EXEC_PLUGINS")); err == nil {
+		c.AllowExecPlugins = &v
+	}
 }
 
 // reservedCommands lists built-in command names that cannot be used as group names.
 var reservedCommands = map[string]bool{
 	"completion": true,
 	"version":    true,
+	"debug":      true,
 	"plugin":     true,
 	"group":      true,
 	"help":       true,
+	"workflow":   true,
+	"schedule":   true,
+	"exec":       true,
+	"index":      true,
+	"registry":   true,
+	"audit":      true,
+	"serve":      true,
+	"exporter":   true,
+	"schema":     true,
+	"results":    true,
+	"run":        true,
+}
+
+// IsHiddenPlugin reports whether a plugin is listed in HiddenPlugins.
+func (c *Config) IsHiddenPlugin(name string) bool {
+	for _, p := range c.HiddenPlugins {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPlainHTTPRegistry reports whether the registry of ref, a host or a
+// reference starting with one, is listed in Network.PlainHTTP.
+func (c *Config) IsPlainHTTPRegistry(ref string) bool {
+	host, _, _ := strings.Cut(ref, "/")
+	for _, h := range c.Network.PlainHTTP {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultsFor returns the flag defaults for one operation of a plugin:
+// its plugin defaults overlaid with any operation defaults.
+func (c *Config) DefaultsFor(plugin, service, operation string) map[string]string {
+	return MergeDefaults(c.PluginDefaults[plugin], c.OperationDefaults[plugin][service][operation])
+}
+
+// MergeDefaults combines flag default maps; later layers take precedence.
+// It returns nil when every layer is empty.
+func MergeDefaults(layers ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, layer := range layers {
+		for k, v := range layer {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
 }
 
 // ValidateGroups checks group configuration for errors.
-// Only checks for critical errors (empty name, reserved name).
+// Only checks for critical errors (empty name, reserved name, nesting cycles).
 // Empty plugin lists are allowed since groups may be in the process of being configured.
 func (c *Config) ValidateGroups() error {
 	for name := range c.Groups {
-		if name == "" {
-			return fmt.Errorf("group name cannot be empty")
+		if err := ValidateGroupName(name); err != nil {
+			return err
+		}
+	}
+	return c.checkGroupCycles()
+}
+
+// IsNestedGroup reports whether a group entry refers to another group
+// rather than a plugin. The "top" group cannot be nested.
+func (c *Config) IsNestedGroup(entry string) bool {
+	if entry == "top" {
+		return false
+	}
+	_, ok := c.Groups[entry]
+	return ok
+}
+
+// checkGroupCycles rejects groups that contain themselves, directly or
+// through nested groups.
+func (c *Config) checkGroupCycles() error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(c.Groups))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("group cycle: %s", strings.Join(append(path, name), " -> "))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, entry := range c.Groups[name].Plugins {
+			if c.IsNestedGroup(entry) {
+				if err := visit(entry, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		return nil
+	}
+
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "top" {
+			continue
 		}
-		if reservedCommands[name] {
-			return fmt.Errorf("group name %q conflicts with built-in command", name)
+		if err := visit(name, nil); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// ValidateGroupName checks that name can be used as a group command.
+func ValidateGroupName(name string) error {
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+	if reservedCommands[name] {
+		return fmt.Errorf("group name %q conflicts with built-in command", name)
+	}
+	return nil
+}
+
+// ErrReadOnly is returned when read-only mode refuses a change.
+var ErrReadOnly = errcode.New(errcode.ReadOnly, "refused in read-only mode")
+
 // Save writes the config to the given path as YAML.
-// Creates parent directories if they don't exist.
+// Creates parent directories if they don't exist. It refuses in read-only
+// mode.
 func (c *Config) Save(path string) error {
+	if c.ReadOnly {
+		return ErrReadOnly
+	}
+	if c.overlay != "" {
+		return fmt.Errorf("the config includes the workspace config %s; change the user's config from outside the workspace", c.overlay)
+	}
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("creating config directory: %w", err)
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_Default(t *testing.T) {
@@ -202,3 +205,312 @@ func TestSave_RoundTrip(t *testing.T) {
 		t.Errorf("expected 2 plugins, got %d", len(net.Plugins))
 	}
 }
+
+func TestOperationTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"45s", 45 * time.Second, false},
+		{"soon", 0, true},
+		{"-1s", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := (&Config{Timeout: tt.value}).OperationTimeout()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("OperationTimeout(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestArtifactSizeLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1048576", 1 << 20, false},
+		{"256MB", 256 << 20, false},
+		{"1 GiB", 1 << 30, false},
+		{"512kb", 512 << 10, false},
+		{"0", 0, true},
+		{"-5MB", 0, true},
+		{"big", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := (&Config{MaxArtifactSize: tt.value}).ArtifactSizeLimit()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ArtifactSizeLimit(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRegistryArtifactSizeLimits(t *testing.T) {
+	cfg := &Config{MaxArtifactSizeByRegistry: map[string]string{"ghcr.io/acme": "50MB", "docker.io": "1GiB"}}
+	got, err := cfg.RegistryArtifactSizeLimits()
+	if err != nil || got["ghcr.io/acme"] != 50<<20 || got["docker.io"] != 1<<30 {
+		t.Errorf("RegistryArtifactSizeLimits = %v, %v", got, err)
+	}
+
+	cfg.MaxArtifactSizeByRegistry["ghcr.io"] = "lots"
+	if _, err := cfg.RegistryArtifactSizeLimits(); err == nil || !strings.Contains(err.Error(), "max_artifact_size_by_registry.ghcr.io") {
+		t.Errorf("expected an error naming the bad entry, got %v", err)
+	}
+}
+
+func TestExportImportGroup(t *testing.T) {
+	src := DefaultConfig()
+	src.Groups = map[string]GroupConfig{
+		"cloud": {Description: "Cloud tools", Plugins: []string{"aws", "gcp"}},
+	}
+	src.PluginDefaults = map[string]map[string]string{
+		"aws": {"region": "us-east-1"},
+		"dns": {"server": "1.1.1.1"},
+	}
+
+	exp, err := src.ExportGroup("cloud")
+	if err != nil {
+		t.Fatalf("ExportGroup: %v", err)
+	}
+	if len(exp.Defaults) != 1 || exp.Defaults["aws"]["region"] != "us-east-1" {
+		t.Errorf("expected only the aws defaults to be exported, got %v", exp.Defaults)
+	}
+	if _, err := src.ExportGroup("missing"); err == nil {
+		t.Error("expected error exporting a missing group")
+	}
+
+	dst := DefaultConfig()
+	dst.PluginDefaults = map[string]map[string]string{"aws": {"region": "eu-west-1"}}
+	kept, err := dst.ImportGroup(exp, false)
+	if err != nil {
+		t.Fatalf("ImportGroup: %v", err)
+	}
+	if dst.Groups["cloud"].Description != "Cloud tools" || len(dst.Groups["cloud"].Plugins) != 2 {
+		t.Errorf("unexpected imported group: %+v", dst.Groups["cloud"])
+	}
+	if len(kept) != 1 || kept[0] != "aws.region" || dst.PluginDefaults["aws"]["region"] != "eu-west-1" {
+		t.Errorf("expected existing aws.region to be kept, got %v / %v", kept, dst.PluginDefaults)
+	}
+
+	if _, err := dst.ImportGroup(exp, false); err == nil {
+		t.Error("expected error importing over an existing group")
+	}
+	if _, err := dst.ImportGroup(exp, true); err != nil {
+		t.Fatalf("ImportGroup with force: %v", err)
+	}
+	if dst.PluginDefaults["aws"]["region"] != "us-east-1" {
+		t.Errorf("expected force to overwrite defaults, got %v", dst.PluginDefaults)
+	}
+
+	exp.Name = "plugin"
+	if _, err := dst.ImportGroup(exp, true); err == nil {
+		t.Error("expected error importing a reserved group name")
+	}
+}
+
+func TestValidateGroups_Nested(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Groups = map[string]GroupConfig{
+		"ops":     {Plugins: []string{"network", "cloud", "shell"}},
+		"network": {Plugins: []string{"dns"}},
+		"cloud":   {Plugins: []string{"aws"}},
+		"top":     {Plugins: []string{"dns", "ops"}},
+	}
+	if err := cfg.ValidateGroups(); err != nil {
+		t.Errorf("unexpected error for nested groups: %v", err)
+	}
+	if !cfg.IsNestedGroup("network") || cfg.IsNestedGroup("dns") || cfg.IsNestedGroup("top") {
+		t.Error("IsNestedGroup classified entries incorrectly")
+	}
+}
+
+func TestValidateGroups_Cycle(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Groups = map[string]GroupConfig{
+		"a": {Plugins: []string{"b"}},
+		"b": {Plugins: []string{"c"}},
+		"c": {Plugins: []string{"a"}},
+	}
+	err := cfg.ValidateGroups()
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+
+	cfg.Groups = map[string]GroupConfig{"self": {Plugins: []string{"self"}}}
+	if err := cfg.ValidateGroups(); err == nil {
+		t.Error("expected error for a group containing itself")
+	}
+}
+
+func TestApplyAutoGroups(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Groups = map[string]GroupConfig{
+		"security": {Description: "Mine", Plugins: []string{"scanner"}},
+		"cloud":    {Plugins: []string{"aws"}, Auto: true},
+	}
+
+	res := cfg.ApplyAutoGroups(map[string][]string{
+		"network":  {"http", "dns"},
+		"cloud":    {"gcp", "aws"},
+		"security": {"tls"},
+		"plugin":   {"x"},
+	})
+
+	if len(res.Created) != 1 || res.Created[0] != "network" {
+		t.Errorf("expected network to be created, got %v", res.Created)
+	}
+	if len(res.Updated) != 1 || res.Updated[0] != "cloud" {
+		t.Errorf("expected cloud to be updated, got %v", res.Updated)
+	}
+	if len(res.Skipped) != 2 {
+		t.Errorf("expected reserved and user-created categories to be skipped, got %v", res.Skipped)
+	}
+	if got := cfg.Groups["network"]; !got.Auto || got.Plugins[0] != "dns" {
+		t.Errorf("expected sorted auto group, got %+v", got)
+	}
+	if got := cfg.Groups["security"]; got.Auto || len(got.Plugins) != 1 || got.Plugins[0] != "scanner" {
+		t.Errorf("expected user group to be untouched, got %+v", got)
+	}
+
+	again := cfg.ApplyAutoGroups(map[string][]string{"network": {"dns", "http"}})
+	if len(again.Created)+len(again.Updated) != 0 {
+		t.Errorf("expected no changes on second run, got %+v", again)
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	if got := MergeDefaults(nil, map[string]string{}); got != nil {
+		t.Errorf("expected nil for empty layers, got %v", got)
+	}
+	base := map[string]string{"region": "eu-west-1", "profile": "dev"}
+	got := MergeDefaults(base, map[string]string{"region": "us-east-1"})
+	if got["region"] != "us-east-1" || got["profile"] != "dev" {
+		t.Errorf("expected later layers to win, got %v", got)
+	}
+	if base["region"] != "eu-west-1" {
+		t.Error("MergeDefaults must not modify its inputs")
+	}
+}
+
+func TestDefaultsFor(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `plugin_defaults:
+  aws:
+    region: us-east-1
+    profile: default
+operation_defaults:
+  aws:
+    ec2:
+      describe_security_groups:
+        region: us-west-2
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	got := cfg.DefaultsFor("aws", "ec2", "describe_security_groups")
+	if got["region"] != "us-west-2" || got["profile"] != "default" {
+		t.Errorf("expected operation defaults over plugin defaults, got %v", got)
+	}
+	if got := cfg.DefaultsFor("aws", "s3", "list_buckets"); got["region"] != "us-east-1" {
+		t.Errorf("expected plugin defaults for other operations, got %v", got)
+	}
+	if got := cfg.DefaultsFor("dns", "dns", "resolve"); got != nil {
+		t.Errorf("expected nil for unconfigured plugin, got %v", got)
+	}
+}
+
+func TestIsHiddenPlugin(t *testing.T) {
+	cfg := &Config{HiddenPlugins: []string{"command"}}
+	if !cfg.IsHiddenPlugin("command") {
+		t.Error("expected command to be hidden")
+	}
+	if cfg.IsHiddenPlugin("dns") {
+		t.Error("expected dns to be visible")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Setenv("TACK_READ_ONLY", "true")
+	cfg := DefaultConfig()
+	cfg.ApplyEnvOverrides()
+	if !cfg.ReadOnly {
+		t.Fatal("expected TACK_READ_ONLY to enable read-only mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := cfg.Save(path); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected Save to be refused, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected no config file to be written")
+	}
+}
+
+func TestExecPluginsAllowed(t *testing.T) {
+	t.Setenv("CI", "")
+	t.Setenv("TACK_ALLOW_EXEC_PLUGINS", "")
+	cfg := DefaultConfig()
+	if !cfg.ExecPluginsAllowed() {
+		t.Error("expected exec plugins to be allowed outside CI")
+	}
+
+	t.Setenv("CI", "true")
+	if cfg.ExecPluginsAllowed() {
+		t.Error("expected exec plugins to be disabled in CI by default")
+	}
+
+	t.Setenv("TACK_ALLOW_EXEC_PLUGINS", "1")
+	cfg.ApplyEnvOverrides()
+	if !cfg.ExecPluginsAllowed() {
+		t.Error("expected TACK_ALLOW_EXEC_PLUGINS to enable exec plugins in CI")
+	}
+
+	off := false
+	cfg.AllowExecPlugins = &off
+	t.Setenv("CI", "")
+	if cfg.ExecPluginsAllowed() {
+		t.Error("expected allow_exec_plugins: false to disable exec plugins")
+	}
+}
+
+func TestLoadOverlay(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config.yaml")
+	project := filepath.Join(dir, "project.yaml")
+	if err := os.WriteFile(user, []byte("output: json\ntimeout: 10s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(project, []byte("timeout: 1m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.LoadOverlay(filepath.Join(dir, "missing.yaml")); err != nil {
+		t.Fatalf("expected a missing overlay to be skipped, got %v", err)
+	}
+	if err := cfg.Save(user); err != nil {
+		t.Fatalf("expected a config without an overlay to save, got %v", err)
+	}
+
+	if err := cfg.LoadOverlay(project); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Output != "json" || cfg.Timeout != "1m" {
+		t.Errorf("expected the overlay over the user's config, got output %q timeout %q", cfg.Output, cfg.Timeout)
+	}
+	if err := cfg.Save(user); err == nil {
+		t.Error("expected saving a config with an overlay to fail")
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GroupExport is a self-contained, shareable description of a group: its
+// plugins plus the plugin defaults that apply to them.
+type GroupExport struct {
+	Name        string                       `yaml:"name"`
+	Description string                       `yaml:"description,omitempty"`
+	Hidden      bool                         `yaml:"hidden,omitempty"`
+	Plugins     []string                     `yaml:"plugins"`
+	Defaults    map[string]map[string]string `yaml:"defaults,omitempty"`
+
+	// GroupDefaults are the group's own defaults (GroupConfig.Defaults).
+	GroupDefaults map[string]string `yaml:"group_defaults,omitempty"`
+}
+
+// ExportGroup returns the named group together with the defaults of the
+// plugins it contains.
+func (c *Config) ExportGroup(name string) (GroupExport, error) {
+	group, ok := c.Groups[name]
+	if !ok {
+		return GroupExport{}, fmt.Errorf("group %q not found", name)
+	}
+
+	exp := GroupExport{
+		Name:        name,
+		Description: group.Description,
+		Hidden:      group.Hidden,
+		Plugins:     append([]string{}, group.Plugins...),
+	}
+	if len(group.Defaults) > 0 {
+		exp.GroupDefaults = copyStrings(group.Defaults)
+	}
+	for _, p := range group.Plugins {
+		if d := c.PluginDefaults[p]; len(d) > 0 {
+			if exp.Defaults == nil {
+				exp.Defaults = make(map[string]map[string]string)
+			}
+			exp.Defaults[p] = copyStrings(d)
+		}
+	}
+	return exp, nil
+}
+
+// ImportGroup adds an exported group to the config. An existing group with
+// the same name is replaced only when force is set. Imported defaults are
+// merged into PluginDefaults; on conflicting keys the existing value is kept
+// unless force is set. It returns the default keys that were left unchanged.
+func (c *Config) ImportGroup(exp GroupExport, force bool) ([]string, error) {
+	if err := ValidateGroupName(exp.Name); err != nil {
+		return nil, err
+	}
+	if _, exists := c.Groups[exp.Name]; exists && !force {
+		return nil, fmt.Errorf("group %q already exists (use --force to replace it)", exp.Name)
+	}
+
+	if c.Groups == nil {
+		c.Groups = make(map[string]GroupConfig)
+	}
+	plugins := exp.Plugins
+	if plugins == nil {
+		plugins = []string{}
+	}
+	c.Groups[exp.Name] = GroupConfig{Description: exp.Description, Plugins: plugins, Defaults: exp.GroupDefaults, Hidden: exp.Hidden}
+
+	var kept []string
+	for plugin, defaults := range exp.Defaults {
+		if c.PluginDefaults == nil {
+			c.PluginDefaults = make(map[string]map[string]string)
+		}
+		existing := c.PluginDefaults[plugin]
+		if existing == nil {
+			existing = make(map[string]string)
+			c.PluginDefaults[plugin] = existing
+		}
+		for k, v := range defaults {
+			if old, ok := existing[k]; ok && old != v && !force {
+				kept = append(kept, plugin+"."+k)
+				continue
+			}
+			existing[k] = v
+		}
+	}
+	sort.Strings(kept)
+	return kept, nil
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+// Package errcode gives CLI failures stable codes that scripts and support
+// docs can reference, and maps them to process exit codes.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code identifies a kind of failure. Codes are stable across releases: a
+// code is never reused for a different failure. The first digit is the
+// category: 1 plugin lookup and loading, 2 access control, 3 integrity,
+// 4 credentials, 5 execution.
+type Code string
+
+// Error codes.
+const (
+	PluginNotFound     Code = "TACK1001" // no plugin by that name is installed or published
+	ArtifactTooLarge   Code = "TACK1002" // a plugin binary exceeds max_artifact_size
+	RateLimited        Code = "TACK1003" // a registry kept rate limiting requests
+	ReadOnly           Code = "TACK2001" // refused in read-only mode
+	PolicyDenied       Code = "TACK2002" // refused by the organization policy
+	CapabilityDenied   Code = "TACK2003" // requested capabilities were not granted
+	OutsideMounts      Code = "TACK2004" // filesystem access outside fs_mounts
+	NewCapabilities    Code = "TACK2005" // an upgrade requests capabilities not yet granted
+	LicenseDenied      Code = "TACK2006" // a plugin's license is not permitted by the licenses config
+	ExecDisabled       Code = "TACK2007" // a plugin requests command execution and allow_exec_plugins is off
+	DigestMismatch     Code = "TACK3001" // a plugin binary differs from its recorded digest
+	SignatureInvalid   Code = "TACK3002" // a signature is missing or does not verify
+	ManifestMismatch   Code = "TACK3003" // a plugin's manifest section differs from the manifest its module reports
+	NotLoggedIn        Code = "TACK4001" // no registry credentials are stored
+	OperationTimeout   Code = "TACK5001" // an operation ran past its timeout
+	OperationCancelled Code = "TACK5002" // an operation was interrupted
+	RemoteFailed       Code = "TACK5003" // ssh or the agent on a --remote host failed
+)
+
+// Exit codes by category. Failures without a code exit with 1.
+const (
+	exitGeneral     = 1
+	exitPlugin      = 3
+	exitDenied      = 4
+	exitIntegrity   = 5
+	exitCredentials = 6
+	exitTimeout     = 7
+	exitInterrupted = 130
+)
+
+// Error is an error with a stable code. Its message is that of the wrapped
+// error; the code is reported alongside it.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New returns an error with code and message, for use as a sentinel.
+func New(code Code, message string) error {
+	return &Error{Code: code, Err: errors.New(message)}
+}
+
+// Errorf formats an error with code, wrapping any %w operand.
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attaches code to err. It returns nil for a nil err, and err as is
+// when it already carries a code.
+func Wrap(code Code, err error) error {
+	if err == nil || Of(err) != "" {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the code of the outermost coded error in err's chain. Timeouts
+// and interrupts are coded even when nothing attached a code. It returns ""
+// for other errors.
+func Of(err error) Code {
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return OperationTimeout
+	case errors.Is(err, context.Canceled):
+		return OperationCancelled
+	}
+	return ""
+}
+
+// ExitCode returns the process exit code for err.
+func ExitCode(err error) int {
+	code := Of(err)
+	switch {
+	case code == "":
+		return exitGeneral
+	case code == OperationTimeout:
+		return exitTimeout
+	case code == OperationCancelled:
+		return exitInterrupted
+	}
+	switch code[len("TACK")] {
+	case '1':
+		return exitPlugin
+	case '2':
+		return exitDenied
+	case '3':
+		return exitIntegrity
+	case '4':
+		return exitCredentials
+	}
+	return exitGeneral
+}
@@ -0,0 +1,51 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOfAndExitCode(t *testing.T) {
+	denied := New(CapabilityDenied, "not granted")
+	tests := []struct {
+		name string
+		err  error
+		code Code
+		exit int
+	}{
+		{"plain", errors.New("boom"), "", 1},
+		{"sentinel", denied, CapabilityDenied, 4},
+		{"wrapped sentinel", fmt.Errorf("running dns: %w", denied), CapabilityDenied, 4},
+		{"errorf", Errorf(PluginNotFound, "plugin %q not found", "dns"), PluginNotFound, 3},
+		{"signature", Errorf(SignatureInvalid, "bad: %w", errors.New("x")), SignatureInvalid, 5},
+		{"credentials", New(NotLoggedIn, "not logged in"), NotLoggedIn, 6},
+		{"timeout", fmt.Errorf("lookup: %w", context.DeadlineExceeded), OperationTimeout, 7},
+		{"interrupt", context.Canceled, OperationCancelled, 130},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.code {
+				t.Errorf("Of = %q, want %q", got, tt.code)
+			}
+			if got := ExitCode(tt.err); got != tt.exit {
+				t.Errorf("ExitCode = %d, want %d", got, tt.exit)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(PluginNotFound, nil) != nil {
+		t.Fatal("Wrap(nil) should be nil")
+	}
+	base := errors.New("boom")
+	wrapped := Wrap(DigestMismatch, base)
+	if Of(wrapped) != DigestMismatch || !errors.Is(wrapped, base) || wrapped.Error() != "boom" {
+		t.Fatalf("unexpected wrap: %v (%q)", wrapped, Of(wrapped))
+	}
+	if Of(Wrap(PluginNotFound, wrapped)) != DigestMismatch {
+		t.Fatal("Wrap should keep an existing code")
+	}
+}
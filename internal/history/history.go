@@ -0,0 +1,121 @@
+// Package history persists the outcomes of plugin executions.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
+)
+
+// Record is a single stored execution outcome.
+type Record struct {
+	ID        string         `json:"id"`
+	Source    string         `json:"source"` // "schedule", "workflow", ...
+	Check     string         `json:"check,omitempty"`
+	Plugin    string         `json:"plugin"`
+	Service   string         `json:"service,omitempty"`
+	Operation string         `json:"operation"`
+	Status    string         `json:"status"`
+	Message   string         `json:"message,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+	StartedAt time.Time      `json:"started_at"`
+	Duration  time.Duration  `json:"duration_ns"`
+	Usage     *Usage         `json:"usage,omitempty"`
+}
+
+// Usage is the resources an execution used, recorded with --stats so plugin
+// growth can be followed across versions.
+type Usage struct {
+	PluginVersion   string         `json:"plugin_version,omitempty"`
+	PeakMemoryBytes uint64         `json:"peak_memory_bytes"`
+	HostCalls       map[string]int `json:"host_calls,omitempty"`
+}
+
+// Store appends records to a JSON Lines file.
+// It is safe for concurrent use within a process.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns a Store backed by the file at path.
+// The file and its parent directory are created on first write.
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns the default history location: history.jsonl in the
+// workspace, or ~/.tack/history.jsonl outside one.
+func DefaultPath() string {
+	return workspace.Path("history.jsonl")
+}
+
+// Append writes a record, assigning an ID if it has none.
+func (s *Store) Append(r Record) error {
+	if r.ID == "" {
+		r.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding history record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit of the most recent records, oldest first.
+// A limit <= 0 returns all records. Malformed lines are skipped.
+func (s *Store) List(limit int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+		if limit > 0 && len(records) > limit {
+			records = records[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	return records, nil
+}
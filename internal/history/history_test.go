@@ -0,0 +1,55 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_AppendAndList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	s := Open(path)
+
+	for _, status := range []string{"success", "failure", "error"} {
+		if err := s.Append(Record{Plugin: "dns", Operation: "resolve", Status: status}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	all, err := s.List(0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(all))
+	}
+	if all[0].ID == "" {
+		t.Error("expected ID to be assigned")
+	}
+
+	recent, err := s.List(2)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Status != "failure" || recent[1].Status != "error" {
+		t.Errorf("expected the 2 most recent records oldest first, got %+v", recent)
+	}
+}
+
+func TestStore_ListMissingAndMalformed(t *testing.T) {
+	dir := t.TempDir()
+	records, err := Open(filepath.Join(dir, "missing.jsonl")).List(0)
+	if err != nil || records != nil {
+		t.Fatalf("expected empty list for missing file, got %v, %v", records, err)
+	}
+
+	path := filepath.Join(dir, "history.jsonl")
+	_ = os.WriteFile(path, []byte("not json\n{\"plugin\":\"dns\",\"status\":\"success\"}\n"), 0o600)
+	records, err = Open(path).List(0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || records[0].Plugin != "dns" {
+		t.Errorf("expected malformed line to be skipped, got %+v", records)
+	}
+}
@@ -0,0 +1,133 @@
+// Package logfile writes a log file that rotates by size.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// Defaults for Open when maxSize or keep is zero.
+const (
+	DefaultMaxSize = 10 << 20
+	DefaultKeep    = 5
+)
+
+// DefaultPath returns the default log location.
+// ~/.tack/logs/tack.log
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "."+meta.AppName, "logs", meta.AppName+".log")
+	}
+	return filepath.Join(home, "."+meta.AppName, "logs", meta.AppName+".log")
+}
+
+// Writer appends to a log file, rotating it once it would grow past its
+// size limit: path becomes path.1, path.1 becomes path.2, and so on, and
+// the oldest beyond the retention count is removed. Every line written is
+// prefixed with a timestamp. It is safe for concurrent use, including by
+// several processes sharing the file, though rotation is then approximate.
+type Writer struct {
+	path    string
+	maxSize int64
+	keep    int
+	now     func() time.Time
+
+	mu        sync.Mutex
+	f         *os.File
+	size      int64
+	lineStart bool
+}
+
+// Open opens the log at path for appending, creating it and its directory
+// if needed. maxSize is the size in bytes at which it rotates and keep the
+// number of rotated files retained; zero means the default.
+func Open(path string, maxSize int64, keep int) (*Writer, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	w := &Writer{path: path, maxSize: maxSize, keep: keep, now: time.Now, lineStart: true}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening log: %w", err)
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+// Path returns the path of the current log file.
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Write appends p, timestamping each line it starts.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stamp := []byte(w.now().Format(time.RFC3339) + " ")
+	out := make([]byte, 0, len(p)+len(stamp))
+	for _, b := range p {
+		if w.lineStart {
+			out = append(out, stamp...)
+		}
+		out = append(out, b)
+		w.lineStart = b == '\n'
+	}
+
+	if w.size > 0 && w.size+int64(len(out)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(out)
+	w.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rotate shifts the rotated files up by one, dropping the oldest, and
+// starts a new log.
+func (w *Writer) rotate() error {
+	_ = w.f.Close()
+	_ = os.Remove(fmt.Sprintf("%s.%d", w.path, w.keep))
+	for i := w.keep - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotating log: %w", err)
+	}
+	return w.open()
+}
+
+// Close closes the log file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
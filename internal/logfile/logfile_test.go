@@ -0,0 +1,62 @@
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "tack.log")
+	w, err := Open(path, 64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	for i := range 6 {
+		if _, err := fmt.Fprintf(w, "line %d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(current), "2026-01-02T03:04:05Z line ") || !strings.HasSuffix(string(current), "line 5\n") {
+		t.Errorf("unexpected current log %q", current)
+	}
+	for _, rotated := range []string{path + ".1", path + ".2"} {
+		if _, err := os.Stat(rotated); err != nil {
+			t.Errorf("expected %s: %v", rotated, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 rotated logs to be kept, got %v", err)
+	}
+}
+
+func TestWriterTimestampsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tack.log")
+	w, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	_, _ = w.Write([]byte("partial "))
+	_, _ = w.Write([]byte("line\nnext\n"))
+	_ = w.Close()
+
+	data, _ := os.ReadFile(path)
+	want := "2026-01-02T03:04:05Z partial line\n2026-01-02T03:04:05Z next\n"
+	if string(data) != want {
+		t.Errorf("log = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,231 @@
+// Package notify delivers failure notifications to webhooks, chat, and email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// DefaultTemplate is the message template used when a sink sets none.
+const DefaultTemplate = `[{{ .Status }}] {{ .Source }} {{ .Name }}: {{ .Plugin }} {{ .Operation }}{{ if .Message }} - {{ .Message }}{{ end }}`
+
+// Event describes a run that changed state.
+type Event struct {
+	Source         string         `json:"source"` // "schedule", "workflow", ...
+	Name           string         `json:"name"`   // check or workflow name
+	Plugin         string         `json:"plugin,omitempty"`
+	Operation      string         `json:"operation,omitempty"`
+	Status         string         `json:"status"`
+	PreviousStatus string         `json:"previous_status,omitempty"`
+	Message        string         `json:"message,omitempty"`
+	Data           map[string]any `json:"data,omitempty"`
+	Time           time.Time      `json:"time"`
+}
+
+// Sink delivers a rendered notification.
+type Sink interface {
+	Send(ctx context.Context, ev Event, text string) error
+}
+
+// sink pairs a Sink with its trigger statuses and message template.
+type sink struct {
+	name string
+	on   map[string]bool
+	tmpl *template.Template
+	Sink
+}
+
+// Notifier fans events out to the configured sinks.
+type Notifier struct {
+	sinks []sink
+}
+
+// New builds a Notifier from config. Returns a Notifier with no sinks if
+// cfgs is empty, so callers can always call Notify.
+func New(cfgs []config.NotificationConfig) (*Notifier, error) {
+	n := &Notifier{}
+	for i, c := range cfgs {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("%s#%d", c.Type, i+1)
+		}
+
+		text := c.Template
+		if text == "" {
+			text = DefaultTemplate
+		}
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("notification %q: invalid template: %w", name, err)
+		}
+
+		on := map[string]bool{"failure": true, "error": true}
+		if len(c.On) > 0 {
+			on = make(map[string]bool, len(c.On))
+			for _, s := range c.On {
+				on[s] = true
+			}
+		}
+
+		var s Sink
+		switch c.Type {
+		case "webhook":
+			if c.URL == "" {
+				return nil, fmt.Errorf("notification %q: url is required", name)
+			}
+			s = &WebhookSink{URL: c.URL, Headers: c.Headers}
+		case "slack":
+			if c.URL == "" {
+				return nil, fmt.Errorf("notification %q: url is required", name)
+			}
+			s = &SlackSink{URL: c.URL}
+		case "email":
+			if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+				return nil, fmt.Errorf("notification %q: smtp_host, from, and to are required", name)
+			}
+			s = &EmailSink{
+				Host:     c.SMTPHost,
+				Port:     c.SMTPPort,
+				Username: c.Username,
+				Password: os.Getenv(c.PasswordEnv),
+				From:     c.From,
+				To:       c.To,
+			}
+		default:
+			return nil, fmt.Errorf("notification %q: unsupported type %q (supported: webhook, slack, email)", name, c.Type)
+		}
+
+		n.sinks = append(n.sinks, sink{name: name, on: on, tmpl: tmpl, Sink: s})
+	}
+	return n, nil
+}
+
+// Notify sends ev to every sink triggered by its status. Delivery failures
+// are reported on stderr and never interrupt the caller.
+func (n *Notifier) Notify(ctx context.Context, ev Event) {
+	if n == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	for _, s := range n.sinks {
+		if !s.on[ev.Status] {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := s.tmpl.Execute(&buf, ev); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: notification %q: rendering message: %v\n", s.name, err)
+			continue
+		}
+		if err := s.Send(ctx, ev, buf.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: notification %q: %v\n", s.name, err)
+		}
+	}
+}
+
+// IsFailing reports whether a status should be treated as a failed run.
+func IsFailing(status string) bool {
+	return status == "failure" || status == "error"
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookSink POSTs the event as JSON with the rendered text in "text".
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, ev Event, text string) error {
+	payload := struct {
+		Event
+		Text string `json:"text"`
+	}{ev, text}
+	return postJSON(ctx, s.URL, s.Headers, payload)
+}
+
+// SlackSink posts a Slack-compatible incoming-webhook payload.
+type SlackSink struct {
+	URL string
+}
+
+// Send implements Sink.
+func (s *SlackSink) Send(ctx context.Context, _ Event, text string) error {
+	return postJSON(ctx, s.URL, nil, map[string]string{"text": text})
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", meta.AppName)
+	for k, v := range headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailSink sends a plain-text email through an SMTP server.
+type EmailSink struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Send implements Sink.
+func (s *EmailSink) Send(_ context.Context, ev Event, text string) error {
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := s.Host + ":" + strconv.Itoa(port)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	subject := fmt.Sprintf("%s: %s %s is %s", meta.AppName, ev.Source, ev.Name, ev.Status)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		s.From, strings.Join(s.To, ", "), subject, text)
+
+	if err := smtp.SendMail(addr, auth, s.From, s.To, []byte(msg)); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+	return nil
+}
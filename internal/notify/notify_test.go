@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+type capture struct {
+	mu      sync.Mutex
+	bodies  []map[string]any
+	headers []http.Header
+}
+
+func (c *capture) handler(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	c.mu.Lock()
+	c.bodies = append(c.bodies, body)
+	c.headers = append(c.headers, r.Header.Clone())
+	c.mu.Unlock()
+}
+
+func TestNotifier_WebhookAndSlack(t *testing.T) {
+	var hook, slack capture
+	hookSrv := httptest.NewServer(http.HandlerFunc(hook.handler))
+	defer hookSrv.Close()
+	slackSrv := httptest.NewServer(http.HandlerFunc(slack.handler))
+	defer slackSrv.Close()
+
+	t.Setenv("NOTIFY_TOKEN", "secret")
+	n, err := New([]config.NotificationConfig{
+		{Type: "webhook", URL: hookSrv.URL, Headers: map[string]string{"Authorization": "Bearer $NOTIFY_TOKEN"}},
+		{Type: "slack", URL: slackSrv.URL, Template: "{{ .Name }} is {{ .Status }}"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	n.Notify(context.Background(), Event{Source: "schedule", Name: "dns", Plugin: "dns", Operation: "resolve", Status: "failure"})
+
+	if len(hook.bodies) != 1 {
+		t.Fatalf("expected 1 webhook call, got %d", len(hook.bodies))
+	}
+	if hook.bodies[0]["name"] != "dns" || hook.bodies[0]["text"] != "[failure] schedule dns: dns resolve" {
+		t.Errorf("unexpected webhook payload: %v", hook.bodies[0])
+	}
+	if got := hook.headers[0].Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("expected expanded header, got %q", got)
+	}
+	if len(slack.bodies) != 1 || slack.bodies[0]["text"] != "dns is failure" {
+		t.Errorf("unexpected slack payload: %v", slack.bodies)
+	}
+}
+
+func TestNotifier_OnFilter(t *testing.T) {
+	var hook capture
+	srv := httptest.NewServer(http.HandlerFunc(hook.handler))
+	defer srv.Close()
+
+	n, err := New([]config.NotificationConfig{{Type: "webhook", URL: srv.URL, On: []string{"error"}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	n.Notify(context.Background(), Event{Name: "a", Status: "failure"})
+	n.Notify(context.Background(), Event{Name: "b", Status: "error"})
+
+	if len(hook.bodies) != 1 || hook.bodies[0]["name"] != "b" {
+		t.Errorf("expected only the error event, got %v", hook.bodies)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.NotificationConfig
+	}{
+		{"unknown type", config.NotificationConfig{Type: "pager"}},
+		{"webhook without url", config.NotificationConfig{Type: "webhook"}},
+		{"email without recipients", config.NotificationConfig{Type: "email", SMTPHost: "mail", From: "a@b"}},
+		{"bad template", config.NotificationConfig{Type: "slack", URL: "http://x", Template: "{{ .Name "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New([]config.NotificationConfig{tt.cfg}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestNotifier_NilSafe(t *testing.T) {
+	var n *Notifier
+	n.Notify(context.Background(), Event{Status: "failure"})
+}
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// codeQualitySeverities are the severities GitLab accepts.
+var codeQualitySeverities = map[string]bool{
+	"info": true, "minor": true, "major": true, "critical": true, "blocker": true,
+}
+
+// SeverityHint returns the "x-severity" annotation of an operation's output
+// schema, used as the Code Quality severity of its failures. It returns ""
+// when the schema has no valid hint.
+func SeverityHint(outputSchema json.RawMessage) string {
+	var schema struct {
+		Severity string `json:"x-severity"`
+	}
+	if len(outputSchema) == 0 || json.Unmarshal(outputSchema, &schema) != nil {
+		return ""
+	}
+	severity := strings.ToLower(schema.Severity)
+	if !codeQualitySeverities[severity] {
+		return ""
+	}
+	return severity
+}
+
+// CodeQualityIssue is an entry of a GitLab Code Quality report.
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+// CodeQualityLocation is where GitLab shows an issue.
+type CodeQualityLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// CodeQualityIssues converts failed and errored checks to Code Quality
+// issues. The check name is plugin/operation (plugin/service/operation
+// for multi-service plugins) and doubles as the location path. Failures
+// default to "major" and errors to "critical" unless the check carries a
+// severity hint. Fingerprints depend only on the check, so GitLab can
+// tell new failures from resolved ones across pipelines.
+func CodeQualityIssues(checks []Check) []CodeQualityIssue {
+	issues := []CodeQualityIssue{}
+	for _, c := range checks {
+		var severity string
+		switch c.Status {
+		case string(abi.ResultStatusFailure):
+			severity = "major"
+		case string(abi.ResultStatusError):
+			severity = "critical"
+		default:
+			continue
+		}
+		if c.Severity != "" {
+			severity = c.Severity
+		}
+
+		checkName := c.Plugin
+		if c.Service != "" && c.Service != c.Plugin {
+			checkName += "/" + c.Service
+		}
+		checkName += "/" + c.Operation
+
+		description := c.Name + ": " + c.Status
+		if c.Message != "" {
+			description += ": " + c.Message
+		}
+		sum := sha256.Sum256([]byte(checkName + "\x00" + c.Name))
+
+		issue := CodeQualityIssue{
+			Description: description,
+			CheckName:   checkName,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    severity,
+		}
+		issue.Location.Path = checkName
+		issue.Location.Lines.Begin = 1
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// WriteCodeQuality writes a GitLab Code Quality report for the checks.
+func WriteCodeQuality(w io.Writer, checks []Check) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(CodeQualityIssues(checks))
+}
+
+// CodeQualityFormatter writes a result as a GitLab Code Quality report:
+// an empty array on success, or a single issue otherwise.
+type CodeQualityFormatter struct {
+	Plugin    string
+	Service   string
+	Operation string
+}
+
+func (f *CodeQualityFormatter) Format(w io.Writer, result abi.Result, outputSchema json.RawMessage) error {
+	message := result.Message
+	if result.Error != nil && result.Error.Message != "" {
+		message = result.Error.Message
+	}
+	name := strings.Join(strings.Fields(f.Plugin+" "+f.Service+" "+f.Operation), " ")
+	if name == "" {
+		name = "tack"
+	}
+	return WriteCodeQuality(w, []Check{{
+		Name:      name,
+		Status:    string(result.Status),
+		Message:   message,
+		Plugin:    f.Plugin,
+		Service:   f.Service,
+		Operation: f.Operation,
+		Severity:  SeverityHint(outputSchema),
+	}})
+}
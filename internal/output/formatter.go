@@ -16,7 +16,8 @@ type Formatter interface {
 }
 
 // NewFormatter returns a Formatter for the given format name.
-// Supported formats: "json", "table", "yaml", "quiet".
+// Supported formats: "json", "table", "yaml", "gha", "codequality",
+// "quiet".
 func NewFormatter(format string) (Formatter, error) {
 	switch format {
 	case "json":
@@ -25,10 +26,14 @@ func NewFormatter(format string) (Formatter, error) {
 		return &TableFormatter{}, nil
 	case "yaml":
 		return &YAMLFormatter{}, nil
+	case "gha":
+		return &GHAFormatter{}, nil
+	case "codequality":
+		return &CodeQualityFormatter{}, nil
 	case "quiet":
 		return &QuietFormatter{}, nil
 	default:
-		return nil, fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, quiet)", format)
+		return nil, fmt.Errorf("unsupported output format: %q (supported: json, table, yaml, gha, codequality, quiet)", format)
 	}
 }
 
@@ -3,6 +3,8 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -76,3 +78,127 @@ func TestNewFormatter_Invalid(t *testing.T) {
 		t.Error("expected error for unsupported format")
 	}
 }
+
+func TestGHAFormatter(t *testing.T) {
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	var buf bytes.Buffer
+	f := &GHAFormatter{Name: "dns resolve"}
+	result := abi.ResultFailure("no records\nfor example.com", nil)
+	if err := f.Format(&buf, result, nil); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Status: failure") {
+		t.Errorf("expected table output, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "::error title=dns resolve::dns resolve: failure: no records%0Afor example.com\n") {
+		t.Errorf("expected error annotation, got: %s", buf.String())
+	}
+
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("reading summary: %v", err)
+	}
+	if !strings.Contains(string(data), "0 of 1 checks succeeded") || !strings.Contains(string(data), "| dns resolve | ❌ failure |  | no records<br>for example.com |") {
+		t.Errorf("unexpected summary:\n%s", data)
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	checks := []Check{
+		{Name: "a", Status: "success"},
+		{Name: "b, c", Status: "error", Message: "100% broken"},
+		{Name: "d", Status: "skipped"},
+	}
+	if err := WriteAnnotations(&buf, checks); err != nil {
+		t.Fatalf("WriteAnnotations: %v", err)
+	}
+	want := "::notice title=a::a: success\n" +
+		"::error title=b%2C c::b, c: error: 100%25 broken\n" +
+		"::warning title=d::d: skipped\n"
+	if buf.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestCodeQualityIssues(t *testing.T) {
+	checks := []Check{
+		{Name: "dns resolve", Plugin: "dns", Operation: "resolve", Status: "success"},
+		{Name: "aws ec2 describe", Plugin: "aws", Service: "ec2", Operation: "describe", Status: "failure", Message: "open port"},
+		{Name: "http check", Plugin: "http", Operation: "check", Status: "error", Severity: "blocker"},
+		{Name: "tcp connect", Plugin: "tcp", Operation: "connect", Status: "skipped"},
+	}
+	issues := CodeQualityIssues(checks)
+	if len(issues) != 2 {
+		t.Fatalf("expected issues for the failure and error only, got %+v", issues)
+	}
+	if i := issues[0]; i.CheckName != "aws/ec2/describe" || i.Severity != "major" || i.Description != "aws ec2 describe: failure: open port" || i.Location.Path != "aws/ec2/describe" || i.Location.Lines.Begin != 1 {
+		t.Errorf("unexpected failure issue: %+v", i)
+	}
+	if i := issues[1]; i.CheckName != "http/check" || i.Severity != "blocker" {
+		t.Errorf("expected hinted severity, got %+v", i)
+	}
+	if issues[0].Fingerprint == issues[1].Fingerprint || CodeQualityIssues(checks)[0].Fingerprint != issues[0].Fingerprint {
+		t.Error("expected distinct, stable fingerprints")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCodeQuality(&buf, checks[:1]); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected an empty report, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestSeverityHint(t *testing.T) {
+	tests := map[string]string{
+		`{"type":"object","x-severity":"Critical"}`: "critical",
+		`{"x-severity":"urgent"}`:                   "",
+		`{"type":"object"}`:                         "",
+		``:                                          "",
+	}
+	for schema, want := range tests {
+		if got := SeverityHint(json.RawMessage(schema)); got != want {
+			t.Errorf("SeverityHint(%s) = %q, want %q", schema, got, want)
+		}
+	}
+}
+
+func TestMaskSensitive(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"token": {"type": "string", "x-sensitive": true},
+			"user": {"type": "string"},
+			"auth": {"type": "object", "properties": {"password": {"x-sensitive": true}}},
+			"keys": {"type": "array", "items": {"type": "object", "properties": {"secret": {"x-sensitive": true}}}}
+		}
+	}`)
+	data := map[string]any{
+		"token": "abc",
+		"user":  "bob",
+		"auth":  map[string]any{"password": "pw", "realm": "r"},
+		"keys":  []any{map[string]any{"secret": "s1", "id": "k1"}},
+	}
+
+	got := MaskSensitive(data, schema)
+	if got["token"] != Masked || got["user"] != "bob" {
+		t.Errorf("top-level fields = %v", got)
+	}
+	if auth := got["auth"].(map[string]any); auth["password"] != Masked || auth["realm"] != "r" {
+		t.Errorf("nested fields = %v", auth)
+	}
+	if key := got["keys"].([]any)[0].(map[string]any); key["secret"] != Masked || key["id"] != "k1" {
+		t.Errorf("array item fields = %v", key)
+	}
+	if data["token"] != "abc" || data["auth"].(map[string]any)["password"] != "pw" {
+		t.Error("MaskSensitive modified its input")
+	}
+
+	if got := MaskSensitive(data, json.RawMessage(`{"x-sensitive": true}`)); got["user"] != Masked {
+		t.Errorf("sensitive root: %v", got)
+	}
+	if got := MaskSensitive(data, json.RawMessage(`{"type":"object"}`)); got["token"] != "abc" {
+		t.Errorf("unannotated schema changed data: %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// InGitHubActions reports whether tack is running in a GitHub Actions job.
+func InGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Check is one check outcome reported to a CI system.
+type Check struct {
+	Name     string
+	Status   string
+	Message  string
+	Duration time.Duration
+
+	// Plugin, Service and Operation identify what ran; Service is empty
+	// for single-service plugins.
+	Plugin    string
+	Service   string
+	Operation string
+
+	// Severity is the operation's severity hint (see SeverityHint).
+	Severity string
+}
+
+// WriteAnnotations writes a workflow command per check: ::error for failed
+// and errored checks, ::warning for other non-successful ones (such as
+// skipped steps), and ::notice for successes.
+func WriteAnnotations(w io.Writer, checks []Check) error {
+	for _, c := range checks {
+		level := annotationLevel(c.Status)
+		message := c.Name + ": " + c.Status
+		if c.Message != "" {
+			message += ": " + c.Message
+		}
+		if _, err := fmt.Fprintf(w, "::%s title=%s::%s\n", level, escapeProperty(c.Name), escapeData(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotationLevel maps a check status to a workflow command.
+func annotationLevel(status string) string {
+	switch status {
+	case string(abi.ResultStatusSuccess):
+		return "notice"
+	case string(abi.ResultStatusFailure), string(abi.ResultStatusError):
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// summaryIcons marks summary rows by annotation level.
+var summaryIcons = map[string]string{"notice": "✅", "error": "❌", "warning": "⚠️"}
+
+// WriteStepSummary appends a markdown table of checks to the file named by
+// GITHUB_STEP_SUMMARY. It does nothing outside GitHub Actions.
+func WriteStepSummary(title string, checks []Check) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening step summary: %w", err)
+	}
+	if err := writeSummary(f, title, checks); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing step summary: %w", err)
+	}
+	return f.Close()
+}
+
+func writeSummary(w io.Writer, title string, checks []Check) error {
+	passed := 0
+	for _, c := range checks {
+		if c.Status == string(abi.ResultStatusSuccess) {
+			passed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n%d of %d checks succeeded\n\n", title, passed, len(checks))
+	b.WriteString("| Check | Status | Duration | Message |\n|---|---|---|---|\n")
+	for _, c := range checks {
+		icon := summaryIcons[annotationLevel(c.Status)]
+		duration := ""
+		if c.Duration > 0 {
+			duration = c.Duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(&b, "| %s | %s %s | %s | %s |\n", escapeCell(c.Name), icon, c.Status, duration, escapeCell(c.Message))
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GHAFormatter prints a result as a table and reports it to GitHub Actions
+// as an annotation and a step summary entry.
+type GHAFormatter struct {
+	// Name labels the check, e.g. "dns resolve". Defaults to "tack".
+	Name string
+}
+
+func (f *GHAFormatter) Format(w io.Writer, result abi.Result, outputSchema json.RawMessage) error {
+	if err := (&TableFormatter{}).Format(w, result, outputSchema); err != nil {
+		return err
+	}
+
+	name := f.Name
+	if name == "" {
+		name = "tack"
+	}
+	message := result.Message
+	if result.Error != nil && result.Error.Message != "" {
+		message = result.Error.Message
+	}
+	checks := []Check{{Name: name, Status: string(result.Status), Message: message}}
+	if err := WriteAnnotations(w, checks); err != nil {
+		return err
+	}
+	return WriteStepSummary(name, checks)
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// escapeCell makes s safe inside a markdown table cell.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r", "", "\n", "<br>").Replace(s)
+}
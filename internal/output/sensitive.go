@@ -0,0 +1,84 @@
+package output
+
+import "encoding/json"
+
+// Masked replaces the values of sensitive fields.
+const Masked = "********"
+
+// sensitiveSchema is the part of a JSON Schema that marks fields with an
+// "x-sensitive" annotation.
+type sensitiveSchema struct {
+	Sensitive  bool                       `json:"x-sensitive"`
+	Properties map[string]sensitiveSchema `json:"properties"`
+	Items      *sensitiveSchema           `json:"items"`
+}
+
+// hasSensitive reports whether s or anything under it is sensitive.
+func (s *sensitiveSchema) hasSensitive() bool {
+	if s == nil {
+		return false
+	}
+	if s.Sensitive {
+		return true
+	}
+	for _, p := range s.Properties {
+		if p.hasSensitive() {
+			return true
+		}
+	}
+	return s.Items.hasSensitive()
+}
+
+// MaskSensitive returns data with the values of fields the output schema
+// annotates "x-sensitive": true replaced by Masked, including fields of
+// nested objects and of array items. A sensitive schema root masks every
+// field. data is returned as is when the schema marks nothing; otherwise it
+// is copied, never modified.
+func MaskSensitive(data map[string]any, outputSchema json.RawMessage) map[string]any {
+	if len(data) == 0 || len(outputSchema) == 0 {
+		return data
+	}
+	var schema sensitiveSchema
+	if json.Unmarshal(outputSchema, &schema) != nil || !schema.hasSensitive() {
+		return data
+	}
+	if schema.Sensitive {
+		masked := make(map[string]any, len(data))
+		for k := range data {
+			masked[k] = Masked
+		}
+		return masked
+	}
+	masked, _ := maskValue(data, &schema).(map[string]any)
+	return masked
+}
+
+func maskValue(v any, schema *sensitiveSchema) any {
+	if schema == nil || v == nil {
+		return v
+	}
+	if schema.Sensitive {
+		return Masked
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, field := range v {
+			if p, ok := schema.Properties[k]; ok {
+				field = maskValue(field, &p)
+			}
+			out[k] = field
+		}
+		return out
+	case []any:
+		if schema.Items == nil {
+			return v
+		}
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = maskValue(item, schema.Items)
+		}
+		return out
+	}
+	return v
+}
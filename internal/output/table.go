@@ -68,6 +68,66 @@ func (f *TableFormatter) Format(w io.Writer, result abi.Result, outputSchema jso
 	return table.Render()
 }
 
+// FormatTargets renders the results of one operation run against several
+// targets as a single table: a row per target, labelled in a column with
+// the given header, with its status and data columns, in schema order or
+// else sorted, and a message column when any target did not succeed.
+func (f *TableFormatter) FormatTargets(w io.Writer, header string, targets []string, results []abi.Result, outputSchema json.RawMessage) error {
+	columns := columnsFromSchema(outputSchema)
+	failed := false
+	if len(columns) == 0 {
+		seen := map[string]bool{}
+		for _, r := range results {
+			for k := range r.Data {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+	for _, r := range results {
+		failed = failed || !r.IsSuccess()
+	}
+
+	table := tablewriter.NewTable(w,
+		tablewriter.WithHeaderAutoFormat(tw.Off),
+		tablewriter.WithRowAutoWrap(tw.WrapNone),
+		tablewriter.WithRendition(tw.Rendition{
+			Borders: tw.Border{Top: tw.On, Bottom: tw.On, Left: tw.On, Right: tw.On},
+		}),
+	)
+
+	headers := []interface{}{header, "Status"}
+	for _, col := range columns {
+		headers = append(headers, snakeToTitle(col))
+	}
+	if failed {
+		headers = append(headers, "Message")
+	}
+	table.Header(headers...)
+
+	for i, r := range results {
+		row := []interface{}{targets[i], string(r.Status)}
+		for _, col := range columns {
+			row = append(row, formatValue(r.Data[col]))
+		}
+		if failed {
+			msg := r.Message
+			if r.Error != nil && r.Error.Message != "" {
+				msg = r.Error.Message
+			}
+			if r.IsSuccess() {
+				msg = ""
+			}
+			row = append(row, msg)
+		}
+		_ = table.Append(row...)
+	}
+	return table.Render()
+}
+
 // columnsFromSchema extracts property names from a JSON Schema object.
 // Returns nil if the schema is empty or unparseable.
 func columnsFromSchema(schema json.RawMessage) []string {
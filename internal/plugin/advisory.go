@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Advisory is a security notice about published plugin versions, listed in
+// an index's "advisories" section or in a separate advisories document.
+type Advisory struct {
+	// ID identifies the advisory, such as "TACK-2026-001" or a CVE ID.
+	ID string `json:"id"`
+
+	// Plugin is the name of the affected plugin.
+	Plugin string `json:"plugin"`
+
+	// Affected is a semantic version constraint matching the affected
+	// versions, such as "< 1.2.3" or ">= 1.0.0, < 1.0.4".
+	Affected string `json:"affected,omitempty"`
+
+	// Digests lists affected artifacts by digest, whatever their version.
+	Digests []string `json:"digests,omitempty"`
+
+	// Revoked marks signatures that must no longer be trusted, for example
+	// after a signing key was compromised.
+	Revoked bool `json:"revoked,omitempty"`
+
+	Severity string `json:"severity,omitempty"` // low, medium, high, or critical
+	Summary  string `json:"summary"`
+	FixedIn  string `json:"fixed_in,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Affects reports whether the advisory applies to a plugin version with
+// the given artifact digest (which may be empty). Versions that are not
+// semantic versions only match by digest.
+func (a Advisory) Affects(name, version, digest string) (bool, error) {
+	if a.Plugin != name {
+		return false, nil
+	}
+	if digest != "" && slices.Contains(a.Digests, digest) {
+		return true, nil
+	}
+	if a.Affected == "" {
+		return false, nil
+	}
+	constraint, err := semver.NewConstraint(a.Affected)
+	if err != nil {
+		return false, fmt.Errorf("advisory %s: invalid affected range %q: %w", a.ID, a.Affected, err)
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, nil
+	}
+	return constraint.Check(v), nil
+}
+
+// SourcedAdvisory is an Advisory annotated with the index it came from.
+type SourcedAdvisory struct {
+	Advisory
+	Source string
+}
+
+// FetchAdvisories collects the advisories of every index: those listed in
+// the index itself and those in the document at its advisories_url. The
+// document has the same shape as an index, is cached alongside it, and is
+// held to the same signing policy.
+//
+// Indexes are revalidated on every call, so a new advisory is seen as soon
+// as it is published; an index that cannot be fetched falls back to its
+// cached copy with a warning.
+func FetchAdvisories(ctx context.Context, sources []IndexSource) ([]SourcedAdvisory, error) {
+	cacheDir, err := indexCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var advisories []SourcedAdvisory
+	for _, src := range sources {
+		idx, err := cachedFetch(ctx, src, cacheDir, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch %s index: %v\n", src.Name, err)
+			continue
+		}
+		for _, a := range idx.Advisories {
+			advisories = append(advisories, SourcedAdvisory{Advisory: a, Source: src.Name})
+		}
+
+		if idx.AdvisoriesURL == "" {
+			continue
+		}
+		feed := src
+		feed.Name = src.Name + ".advisories"
+		feed.URL = resolveAdvisoriesURL(src.URL, idx.AdvisoriesURL)
+		doc, err := cachedFetch(ctx, feed, cacheDir, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch %s advisories: %v\n", src.Name, err)
+			continue
+		}
+		for _, a := range doc.Advisories {
+			advisories = append(advisories, SourcedAdvisory{Advisory: a, Source: src.Name})
+		}
+	}
+	return advisories, nil
+}
+
+// resolveAdvisoriesURL resolves an advisories_url relative to the index URL
+// when it is a bare file name, such as "advisories.json".
+func resolveAdvisoriesURL(indexURL, advisoriesURL string) string {
+	if strings.Contains(advisoriesURL, "://") {
+		return advisoriesURL
+	}
+	if strings.HasPrefix(indexURL, "oci://") {
+		return advisoriesURL
+	}
+	if path, ok := strings.CutPrefix(indexURL, "file://"); ok {
+		return "file://" + filepath.Join(filepath.Dir(path), advisoriesURL)
+	}
+	if i := strings.LastIndex(indexURL, "/"); i >= 0 {
+		return indexURL[:i+1] + advisoriesURL
+	}
+	return advisoriesURL
+}
@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdvisoryAffects(t *testing.T) {
+	a := Advisory{ID: "A-1", Plugin: "dns", Affected: ">= 1.0.0, < 1.2.3", Digests: []string{"sha256:bad"}}
+	tests := []struct {
+		name, version, digest string
+		want                  bool
+	}{
+		{"dns", "1.2.0", "", true},
+		{"dns", "1.2.3", "", false},
+		{"dns", "0.9.0", "", false},
+		{"dns", "dev", "sha256:bad", true},
+		{"dns", "dev", "sha256:good", false},
+		{"tcp", "1.2.0", "", false},
+	}
+	for _, tt := range tests {
+		got, err := a.Affects(tt.name, tt.version, tt.digest)
+		if err != nil || got != tt.want {
+			t.Errorf("Affects(%s, %s, %s) = %v, %v; want %v", tt.name, tt.version, tt.digest, got, err, tt.want)
+		}
+	}
+
+	if _, err := (Advisory{Plugin: "dns", Affected: "not a range"}).Affects("dns", "1.0.0", ""); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func TestResolveAdvisoriesURL(t *testing.T) {
+	tests := []struct{ index, advisories, want string }{
+		{"https://example.com/idx/index.json", "advisories.json", "https://example.com/idx/advisories.json"},
+		{"https://example.com/index.json", "https://cdn.example.com/adv.json", "https://cdn.example.com/adv.json"},
+		{"file:///opt/tack/index.json", "advisories.json", "file:///opt/tack/advisories.json"},
+	}
+	for _, tt := range tests {
+		if got := resolveAdvisoriesURL(tt.index, tt.advisories); got != tt.want {
+			t.Errorf("resolveAdvisoriesURL(%q, %q) = %q, want %q", tt.index, tt.advisories, got, tt.want)
+		}
+	}
+}
+
+func TestFetchAdvisories(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			_, _ = w.Write([]byte(`{"plugins":[],"advisories":[{"id":"A-1","plugin":"dns","affected":"< 1.0.0"}],"advisories_url":"advisories.json"}`))
+		case "/advisories.json":
+			_, _ = w.Write([]byte(`{"advisories":[{"id":"A-2","plugin":"tcp","revoked":true,"digests":["sha256:x"]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	advisories, err := FetchAdvisories(context.Background(), []IndexSource{{Name: "test", URL: srv.URL + "/index.json"}})
+	if err != nil {
+		t.Fatalf("FetchAdvisories: %v", err)
+	}
+	if len(advisories) != 2 || advisories[0].ID != "A-1" || advisories[1].ID != "A-2" || !advisories[1].Revoked {
+		t.Fatalf("unexpected advisories: %+v", advisories)
+	}
+	if advisories[1].Source != "test" {
+		t.Errorf("expected source test, got %q", advisories[1].Source)
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+)
+
+// DefaultMaxArtifactSize is the largest plugin binary read, installed, or
+// pulled unless SetMaxArtifactSize says otherwise.
+const DefaultMaxArtifactSize int64 = 256 << 20
+
+// ErrArtifactTooLarge marks a plugin binary over the size limit.
+var ErrArtifactTooLarge = errcode.New(errcode.ArtifactTooLarge, "plugin artifact exceeds the size limit")
+
+var maxArtifactSize atomic.Int64
+
+// registryArtifactSizes maps registry and repository prefixes to their
+// size limits.
+var registryArtifactSizes atomic.Pointer[map[string]int64]
+
+func init() {
+	maxArtifactSize.Store(DefaultMaxArtifactSize)
+}
+
+// SetMaxArtifactSize sets the largest plugin binary accepted, in bytes.
+// Zero or less restores the default.
+func SetMaxArtifactSize(n int64) {
+	if n <= 0 {
+		n = DefaultMaxArtifactSize
+	}
+	maxArtifactSize.Store(n)
+}
+
+// CheckArtifactSize returns ErrArtifactTooLarge when size is over the limit.
+func CheckArtifactSize(size int64) error {
+	if limit := maxArtifactSize.Load(); size > limit {
+		return fmt.Errorf("%w: %d bytes (limit %d; see max_artifact_size)", ErrArtifactTooLarge, size, limit)
+	}
+	return nil
+}
+
+// SetRegistryArtifactSizes sets size limits, in bytes, for plugins pulled
+// from registry or repository prefixes such as "ghcr.io" or "ghcr.io/acme".
+// They only lower the global limit.
+func SetRegistryArtifactSizes(limits map[string]int64) {
+	normalized := make(map[string]int64, len(limits))
+	for prefix, n := range limits {
+		normalized[strings.ToLower(strings.Trim(strings.TrimSpace(prefix), "/"))] = n
+	}
+	registryArtifactSizes.Store(&normalized)
+}
+
+// CheckRegistryArtifactSize returns ErrArtifactTooLarge when a plugin of
+// size bytes in repository (registry/path) is over the global limit or the
+// limit of the longest registry prefix that matches it.
+func CheckRegistryArtifactSize(repository string, size int64) error {
+	if err := CheckArtifactSize(size); err != nil {
+		return err
+	}
+	limits := registryArtifactSizes.Load()
+	if limits == nil {
+		return nil
+	}
+	repository = strings.ToLower(repository)
+	match := ""
+	for prefix := range *limits {
+		if (repository == prefix || strings.HasPrefix(repository, prefix+"/")) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if limit, ok := (*limits)[match]; ok && match != "" && size > limit {
+		return fmt.Errorf("%w: %d bytes (limit %d for %s; see max_artifact_size_by_registry)", ErrArtifactTooLarge, size, limit, match)
+	}
+	return nil
+}
+
+// readDigested reads a plugin binary, hashing it as it is read rather than
+// in a second pass, and returns it with its content digest.
+func readDigested(path string) ([]byte, string, error) {
+	h := sha256.New()
+	data, err := readArtifact(path, h)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readArtifact reads a plugin binary, copying it to tee, if not nil, as it
+// is read. Files over the size limit are refused before they are read.
+// zstd-compressed binaries are decompressed after the stored bytes reach
+// tee, so digests stay those of the file on disk.
+func readArtifact(path string, tee io.Writer) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckArtifactSize(info.Size()); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// The file may grow after Stat; stop as soon as it passes the limit
+	r := newArtifactReader(f)
+	if tee != nil {
+		r = io.TeeReader(r, tee)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, info.Size()))
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	data, err := DecompressWASM(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// artifactReader reads at most one byte past the size limit and fails with
+// ErrArtifactTooLarge as soon as the limit is passed.
+type artifactReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func newArtifactReader(r io.Reader) io.Reader {
+	limit := maxArtifactSize.Load()
+	return &artifactReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+func (a *artifactReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	a.n += int64(n)
+	if a.n > a.limit {
+		return n, fmt.Errorf("%w: over %d bytes (see max_artifact_size)", ErrArtifactTooLarge, a.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadDigested(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ping.wasm")
+	if err := os.WriteFile(path, []byte("wasm bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, digest, err := readDigested(path)
+	if err != nil || string(data) != "wasm bytes" || digest != ContentDigest(data) {
+		t.Fatalf("readDigested = %q, %q, %v", data, digest, err)
+	}
+
+	SetMaxArtifactSize(4)
+	defer SetMaxArtifactSize(0)
+	if _, _, err := readDigested(path); !errors.Is(err, ErrArtifactTooLarge) {
+		t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+	}
+}
+
+// endlessReader counts the bytes read from it and never ends.
+type endlessReader struct{ n int64 }
+
+func (e *endlessReader) Read(p []byte) (int, error) {
+	e.n += int64(len(p))
+	return len(p), nil
+}
+
+func TestArtifactReaderStopsAtLimit(t *testing.T) {
+	SetMaxArtifactSize(1000)
+	defer SetMaxArtifactSize(0)
+
+	src := &endlessReader{}
+	if _, err := io.Copy(io.Discard, newArtifactReader(src)); !errors.Is(err, ErrArtifactTooLarge) {
+		t.Fatalf("expected ErrArtifactTooLarge, got %v", err)
+	}
+	if src.n > 1001 {
+		t.Errorf("read %d bytes, want no more than the limit plus one", src.n)
+	}
+}
+
+func TestCheckRegistryArtifactSize(t *testing.T) {
+	SetRegistryArtifactSizes(map[string]int64{"ghcr.io": 100, "GHCR.io/acme/": 10})
+	defer SetRegistryArtifactSizes(nil)
+
+	tests := []struct {
+		repository string
+		size       int64
+		ok         bool
+	}{
+		{"ghcr.io/other/ping", 50, true},
+		{"ghcr.io/other/ping", 101, false},
+		{"ghcr.io/acme/ping", 11, false},
+		{"ghcr.io/acmecorp/ping", 50, true},
+		{"docker.io/acme/ping", 1000, true},
+		{"docker.io/acme/ping", DefaultMaxArtifactSize + 1, false},
+	}
+	for _, tt := range tests {
+		err := CheckRegistryArtifactSize(tt.repository, tt.size)
+		if tt.ok != (err == nil) || (err != nil && !errors.Is(err, ErrArtifactTooLarge)) {
+			t.Errorf("CheckRegistryArtifactSize(%s, %d) = %v, want ok=%v", tt.repository, tt.size, err, tt.ok)
+		}
+	}
+}
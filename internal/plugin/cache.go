@@ -2,9 +2,12 @@
 package plugin
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	abi "github.com/reglet-dev/reglet-abi"
@@ -13,21 +16,155 @@ import (
 
 // DiscoveryCache stores extracted manifests to speed up plugin registration.
 type DiscoveryCache struct {
-	// Files maps file paths (or embedded URLs) to cached metadata.
+	// Files maps the content digest of each plugin binary ("sha256:...")
+	// to its cached metadata, so copies and moves of a file still hit the
+	// cache and any edit misses it.
 	Files map[string]CacheEntry `json:"files"`
+
+	// Paths maps local plugin files to the digest they had when last read,
+	// so a warm start can list plugins without reading any binary. Files
+	// whose size or modification time changed are read again.
+	Paths map[string]PathEntry `json:"paths,omitempty"`
+
+	// Installed maps plugin names to where they were installed from, so a
+	// source-qualified name such as "community/dns" resolves to the same
+	// artifact later.
+	Installed map[string]InstallRecord `json:"installed,omitempty"`
+
+	// RefreshedAt is when every plugin binary was last read in full,
+	// rather than listed from Paths.
+	RefreshedAt time.Time `json:"refreshed_at,omitzero"`
+
+	// Tags maps OCI references to the manifest digest their tag last
+	// resolved to, so that tags are not resolved against the registry on
+	// every load.
+	Tags map[string]TagEntry `json:"tags,omitempty"`
+}
+
+// InstallRecord describes where an installed plugin came from.
+type InstallRecord struct {
+	// Source is the index the plugin was chosen from ("official",
+	// "community", ...), or empty for direct registry references.
+	Source string `json:"source,omitempty"`
+
+	// Reference is the OCI reference that was pulled.
+	Reference string `json:"reference"`
+
+	// Variant is the build chosen when the reference named an index of
+	// variants, such as "wasip1+simd".
+	Variant string `json:"variant,omitempty"`
+
+	// Provenance is what the artifact's OCI annotations say about its
+	// license, homepage, source repository, authors, and documentation.
+	Provenance Provenance `json:"provenance,omitzero"`
+}
+
+// QualifiedName returns "source/name" for a plugin, or just name when the
+// record has no source.
+func (r InstallRecord) QualifiedName(name string) string {
+	if r.Source == "" {
+		return name
+	}
+	return r.Source + "/" + name
 }
 
-// CacheEntry holds metadata and manifest for a single plugin file.
+// RecordInstall stores where a plugin was installed from. It returns the
+// previous record and whether one existed.
+func (c *DiscoveryCache) RecordInstall(name string, r InstallRecord) (InstallRecord, bool) {
+	prev, ok := c.Installed[name]
+	c.Installed[name] = r
+	return prev, ok
+}
+
+// SplitQualifiedName splits a source-qualified plugin name such as
+// "community/dns@1.2.0" into its source and the remainder. ok is false for
+// unqualified names and for OCI references, whose first element names a
+// registry host (it contains "." or ":" or is "localhost").
+func SplitQualifiedName(s string) (source, name string, ok bool) {
+	source, name, found := strings.Cut(s, "/")
+	if !found || source == "" || name == "" || strings.Contains(name, "/") ||
+		strings.ContainsAny(source, ".:") || source == "localhost" {
+		return "", s, false
+	}
+	return source, name, true
+}
+
+// CacheEntry holds the manifest extracted from one plugin binary.
 type CacheEntry struct {
-	ModTime  time.Time    `json:"mod_time"`
-	Size     int64        `json:"size"`
+	Digest   string       `json:"digest"`
 	Manifest abi.Manifest `json:"manifest"`
 }
 
+// PathEntry records the content digest of a plugin file as of its size and
+// modification time.
+type PathEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Digest  string    `json:"digest"`
+}
+
+// KnownDigest returns the digest recorded for path if the file still has
+// the recorded size and modification time.
+func (c *DiscoveryCache) KnownDigest(path string, info os.FileInfo) (string, bool) {
+	entry, ok := c.Paths[path]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return "", false
+	}
+	return entry.Digest, true
+}
+
+// RecordPath records the digest of path as of info.
+func (c *DiscoveryCache) RecordPath(path string, info os.FileInfo, digest string) {
+	c.Paths[path] = PathEntry{Size: info.Size(), ModTime: info.ModTime(), Digest: digest}
+}
+
+// ContentDigest returns the SHA-256 digest of a plugin binary in the
+// "sha256:<hex>" form the plugin repository records.
+func ContentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Lookup returns the cached manifest of the binary with the given digest.
+func (c *DiscoveryCache) Lookup(digest string) (abi.Manifest, bool) {
+	entry, ok := c.Files[digest]
+	if !ok {
+		return abi.Manifest{}, false
+	}
+	return entry.Manifest, true
+}
+
+// Put caches the manifest of the binary with the given digest.
+func (c *DiscoveryCache) Put(digest string, m abi.Manifest) {
+	c.Files[digest] = CacheEntry{Digest: digest, Manifest: m}
+}
+
+// Prune drops entries whose digest is not in keep, along with the paths
+// recorded for them, and reports whether any were dropped.
+func (c *DiscoveryCache) Prune(keep map[string]bool) bool {
+	pruned := false
+	for digest := range c.Files {
+		if !keep[digest] {
+			delete(c.Files, digest)
+			pruned = true
+		}
+	}
+	for path, entry := range c.Paths {
+		if !keep[entry.Digest] {
+			delete(c.Paths, path)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
 // NewDiscoveryCache creates a new, empty cache.
 func NewDiscoveryCache() *DiscoveryCache {
 	return &DiscoveryCache{
-		Files: make(map[string]CacheEntry),
+		Files:     make(map[string]CacheEntry),
+		Paths:     make(map[string]PathEntry),
+		Installed: make(map[string]InstallRecord),
+		Tags:      make(map[string]TagEntry),
 	}
 }
 
@@ -47,6 +184,22 @@ func LoadCache(path string) *DiscoveryCache {
 	if cache.Files == nil {
 		cache.Files = make(map[string]CacheEntry)
 	}
+	// Entries written before the cache was keyed by content carry no
+	// digest; drop them rather than trust them.
+	for key, entry := range cache.Files {
+		if entry.Digest != key {
+			delete(cache.Files, key)
+		}
+	}
+	if cache.Paths == nil {
+		cache.Paths = make(map[string]PathEntry)
+	}
+	if cache.Installed == nil {
+		cache.Installed = make(map[string]InstallRecord)
+	}
+	if cache.Tags == nil {
+		cache.Tags = make(map[string]TagEntry)
+	}
 
 	return &cache
 }
@@ -63,7 +216,24 @@ func (c *DiscoveryCache) Save(path string) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0o644)
+	// Write and rename, so a concurrent reader such as a background
+	// refresh never sees a partial file
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // DefaultCachePath returns the default location for the discovery cache.
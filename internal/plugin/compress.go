@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MediaTypePluginWASMZstd is the layer media type of a zstd-compressed WASM
+// binary.
+const MediaTypePluginWASMZstd = MediaTypePluginWASM + "+zstd"
+
+var compressCache atomic.Bool
+
+// SetCompressCache sets whether plugins installed from local files are
+// stored zstd-compressed in the plugin cache.
+func SetCompressCache(on bool) {
+	compressCache.Store(on)
+}
+
+// CompressCache reports whether local installs are stored compressed.
+func CompressCache() bool {
+	return compressCache.Load()
+}
+
+// zstdMagic starts every zstd frame; WASM modules start with "\0asm".
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// IsCompressed reports whether data is a zstd-compressed plugin binary.
+func IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, zstdMagic)
+}
+
+// CompressWASM compresses a plugin binary with zstd. Compressed plugins are
+// decompressed when they are loaded, so they can be published and cached
+// as they are.
+func CompressWASM(wasm []byte) ([]byte, error) {
+	if IsCompressed(wasm) {
+		return wasm, nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return nil, fmt.Errorf("compressing plugin: %w", err)
+	}
+	defer func() { _ = enc.Close() }()
+	return enc.EncodeAll(wasm, nil), nil
+}
+
+// DecompressWASM returns data as it is unless it is zstd-compressed, in
+// which case it is decompressed. Decompression stops as soon as the output
+// passes the size limit.
+func DecompressWASM(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+	limit := maxArtifactSize.Load()
+	dec, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing plugin: %w", err)
+	}
+	defer dec.Close()
+	wasm, err := io.ReadAll(newArtifactReader(dec))
+	if errors.Is(err, ErrArtifactTooLarge) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, fmt.Errorf("%w: decompressed binary is over %d bytes (see max_artifact_size)", ErrArtifactTooLarge, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompressing plugin: %w", err)
+	}
+	return wasm, nil
+}
@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestCompressWASM(t *testing.T) {
+	wasm := append([]byte("\x00asm\x01\x00\x00\x00"), bytes.Repeat([]byte("plugin code "), 1000)...)
+
+	compressed, err := CompressWASM(wasm)
+	if err != nil {
+		t.Fatalf("CompressWASM: %v", err)
+	}
+	if !IsCompressed(compressed) || IsCompressed(wasm) {
+		t.Fatal("IsCompressed did not tell compressed and plain binaries apart")
+	}
+	if len(compressed) >= len(wasm) {
+		t.Errorf("compressed %d bytes to %d", len(wasm), len(compressed))
+	}
+	if again, _ := CompressWASM(compressed); !bytes.Equal(again, compressed) {
+		t.Error("compressing a compressed binary changed it")
+	}
+
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	if err := os.WriteFile(path, compressed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, digest, err := readDigested(path)
+	if err != nil || !bytes.Equal(data, wasm) {
+		t.Fatalf("readDigested did not decompress: %d bytes, %v", len(data), err)
+	}
+	if digest != ContentDigest(compressed) {
+		t.Errorf("digest %s is not that of the stored bytes", digest)
+	}
+
+	// The limit applies to the decompressed size
+	SetMaxArtifactSize(int64(len(compressed)) + 100)
+	defer SetMaxArtifactSize(0)
+	if _, err := readArtifact(path, nil); !errors.Is(err, ErrArtifactTooLarge) {
+		t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+	}
+}
+
+func TestPackCompressed(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	compressed, err := CompressWASM([]byte("\x00asm\x01\x00\x00\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pack(ctx, store, "1.0.0", compressed, testPluginManifest()); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	artifact, layer, err := resolveArtifact(ctx, store, "1.0.0")
+	if err != nil {
+		t.Fatalf("resolveArtifact: %v", err)
+	}
+	if layer.MediaType != MediaTypePluginWASMZstd {
+		t.Errorf("layer media type = %s", layer.MediaType)
+	}
+	if artifact.Digest.String() != ContentDigest(compressed) {
+		t.Errorf("digest %s is not that of the compressed layer", artifact.Digest)
+	}
+}
@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/zalando/go-keyring"
+)
+
+// ErrNotLoggedIn is returned when no credentials are stored for a registry.
+var ErrNotLoggedIn = errcode.New(errcode.NotLoggedIn, "not logged in")
+
+// keyringService names the entries registry credentials are stored under
+// in the OS keyring.
+var keyringService = meta.AppName + "-registry"
+
+// registryCredential is the keyring entry for one registry.
+type registryCredential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// SaveRegistryCredentials stores credentials for registry in the OS keyring,
+// replacing any already stored.
+func SaveRegistryCredentials(registry, username, password string) error {
+	registry = normalizeRegistry(registry)
+	if registry == "" {
+		return errors.New("registry is required")
+	}
+	data, err := json.Marshal(registryCredential{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+	AddSecret(password)
+	if err := keyring.Set(keyringService, registry, string(data)); err != nil {
+		return fmt.Errorf("storing credentials for %s in the keyring: %w", registry, err)
+	}
+	return nil
+}
+
+// DeleteRegistryCredentials removes the credentials stored for registry.
+// It returns ErrNotLoggedIn when there are none.
+func DeleteRegistryCredentials(registry string) error {
+	registry = normalizeRegistry(registry)
+	err := keyring.Delete(keyringService, registry)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("%s: %w", registry, ErrNotLoggedIn)
+	}
+	if err != nil {
+		return fmt.Errorf("removing credentials for %s from the keyring: %w", registry, err)
+	}
+	return nil
+}
+
+// registryCredentials returns the credentials stored for registry. It
+// returns ErrNotLoggedIn when there are none.
+func registryCredentials(registry string) (string, string, error) {
+	registry = normalizeRegistry(registry)
+	data, err := keyring.Get(keyringService, registry)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", "", fmt.Errorf("%s: %w", registry, ErrNotLoggedIn)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("reading credentials for %s from the keyring: %w", registry, err)
+	}
+	var c registryCredential
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return "", "", fmt.Errorf("reading credentials for %s from the keyring: %w", registry, err)
+	}
+	AddSecret(c.Password)
+	return c.Username, c.Password, nil
+}
+
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimSpace(strings.ToLower(registry))
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	host, _, _ := strings.Cut(registry, "/")
+	return host
+}
+
+// CredentialProvider supplies registry credentials: REGISTRY_USERNAME and
+// REGISTRY_PASSWORD when set, otherwise those stored by "registry login".
+// A keyring that cannot be read is treated as holding none, so anonymous
+// pulls keep working without one.
+type CredentialProvider struct{}
+
+// NewCredentialProvider creates a CredentialProvider.
+func NewCredentialProvider() *CredentialProvider {
+	return &CredentialProvider{}
+}
+
+// GetCredentials returns the username and password for registry, or empty
+// strings when there are none.
+func (p *CredentialProvider) GetCredentials(ctx context.Context, registry string) (string, string, error) {
+	if username, password := os.Getenv("REGISTRY_USERNAME"), os.Getenv("REGISTRY_PASSWORD"); username != "" || password != "" {
+		AddSecret(password)
+		return username, password, nil
+	}
+	username, password, err := registryCredentials(registry)
+	if err != nil {
+		return "", "", nil
+	}
+	return username, password, nil
+}
+
+// secrets holds the credentials handed out in this process, so they can be
+// removed from anything printed.
+var secrets struct {
+	mu     sync.Mutex
+	values []string
+}
+
+// AddSecret registers a value RedactSecrets removes from text. Values
+// shorter than four characters are ignored, as redacting them would mangle
+// ordinary text.
+func AddSecret(value string) {
+	if len(value) < 4 {
+		return
+	}
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	for _, v := range secrets.values {
+		if v == value {
+			return
+		}
+	}
+	secrets.values = append(secrets.values, value)
+}
+
+var (
+	urlUserinfo = regexp.MustCompile(`://[^/\s@]+@`)
+	authHeader  = regexp.MustCompile(`(?i)(authorization:\s*(?:basic|bearer)\s+)[^\s"',]+`)
+)
+
+const redacted = "[REDACTED]"
+
+// RedactSecrets removes registry credentials from s: the values passed to
+// AddSecret, user information in URLs, and Authorization header values.
+func RedactSecrets(s string) string {
+	secrets.mu.Lock()
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	secrets.mu.Unlock()
+	s = urlUserinfo.ReplaceAllString(s, "://"+redacted+"@")
+	return authHeader.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// redactingHandler passes log records on with RedactSecrets applied to the
+// message and string attributes.
+type redactingHandler struct {
+	slog.Handler
+}
+
+// NewRedactingLogger returns a logger that writes through logger's handler
+// with registry credentials removed.
+func NewRedactingLogger(logger *slog.Logger) *slog.Logger {
+	return slog.New(redactingHandler{logger.Handler()})
+}
+
+func (h redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, RedactSecrets(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = redactAttr(a)
+	}
+	return redactingHandler{h.Handler.WithAttrs(redactedAttrs)}
+}
+
+func (h redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactSecrets(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redactedAttrs := make([]any, len(group))
+		for i, g := range group {
+			redactedAttrs[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, redactedAttrs...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, RedactSecrets(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestRegistryCredentials(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("REGISTRY_USERNAME", "")
+	t.Setenv("REGISTRY_PASSWORD", "")
+	provider := NewCredentialProvider()
+	ctx := context.Background()
+
+	if u, p, err := provider.GetCredentials(ctx, "ghcr.io"); err != nil || u != "" || p != "" {
+		t.Fatalf("GetCredentials before login = %q, %q, %v; want none", u, p, err)
+	}
+	if err := SaveRegistryCredentials("https://GHCR.io/acme", "octocat", "s3cret-token"); err != nil {
+		t.Fatal(err)
+	}
+	if u, p, err := provider.GetCredentials(ctx, "ghcr.io"); err != nil || u != "octocat" || p != "s3cret-token" {
+		t.Fatalf("GetCredentials = %q, %q, %v; want stored credentials", u, p, err)
+	}
+
+	t.Setenv("REGISTRY_USERNAME", "ci")
+	t.Setenv("REGISTRY_PASSWORD", "env-token")
+	if u, p, _ := provider.GetCredentials(ctx, "ghcr.io"); u != "ci" || p != "env-token" {
+		t.Errorf("GetCredentials = %q, %q; want the environment to take precedence", u, p)
+	}
+
+	if err := DeleteRegistryCredentials("ghcr.io"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteRegistryCredentials("ghcr.io"); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("second logout: expected ErrNotLoggedIn, got %v", err)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	AddSecret("hunter2-token")
+	AddSecret("pw")
+
+	got := RedactSecrets(`GET https://bob:pw@ghcr.io/v2/: 401 with hunter2-token; Authorization: Bearer abc.def; basic auth`)
+	for _, leak := range []string{"hunter2-token", "bob:pw", "abc.def"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("RedactSecrets left %q in %q", leak, got)
+		}
+	}
+	if !strings.Contains(got, "basic auth") {
+		t.Errorf("RedactSecrets changed ordinary text: %q", got)
+	}
+
+	var buf bytes.Buffer
+	logger := NewRedactingLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	logger.With("token", "hunter2-token").Info("pulling with hunter2-token", "err", errors.New("denied for hunter2-token"))
+	if strings.Contains(buf.String(), "hunter2-token") {
+		t.Errorf("log output leaked a secret: %s", buf.String())
+	}
+}
@@ -10,3 +10,7 @@ import "embed"
 //
 //go:embed plugins/*.wasm
 var EmbeddedPlugins embed.FS
+
+// PluginsEmbedded reports whether the binary was built with the
+// embed_plugins tag.
+const PluginsEmbedded = true
@@ -7,3 +7,7 @@ import "embed"
 
 // EmbeddedPlugins is empty when built without the embed_plugins tag.
 var EmbeddedPlugins embed.FS
+
+// PluginsEmbedded reports whether the binary was built with the
+// embed_plugins tag.
+const PluginsEmbedded = false
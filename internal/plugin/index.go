@@ -3,13 +3,21 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
 )
 
 const DefaultIndexURL = "https://raw.githubusercontent.com/reglet-dev/reglet-plugins/main/index.json"
@@ -20,6 +28,13 @@ type PluginIndex struct {
 	Registry   string        `json:"registry"`
 	Updated    string        `json:"updated"`
 	Plugins    []PluginEntry `json:"plugins"`
+
+	// Advisories are security notices about published plugin versions.
+	Advisories []Advisory `json:"advisories,omitempty"`
+
+	// AdvisoriesURL locates a separate advisories document, absolute or
+	// relative to the index, so advisories can be updated on their own.
+	AdvisoriesURL string `json:"advisories_url,omitempty"`
 }
 
 // PluginEntry is a single plugin in an index.
@@ -28,12 +43,75 @@ type PluginEntry struct {
 	Description  string   `json:"description"`
 	Capabilities []string `json:"capabilities"`
 	Latest       string   `json:"latest"`
+
+	// Categories are broad areas such as "network", "cloud", or "security",
+	// used by "group auto" to organize installed plugins.
+	Categories []string `json:"categories,omitempty"`
+
+	// Tags are free-form search keywords.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// matches reports whether the entry matches a lowercase search query by
+// name, description, category, or tag.
+func (p PluginEntry) matches(query string) bool {
+	if query == "" || strings.Contains(strings.ToLower(p.Name), query) ||
+		strings.Contains(strings.ToLower(p.Description), query) {
+		return true
+	}
+	for _, s := range append(append([]string{}, p.Categories...), p.Tags...) {
+		if strings.ToLower(s) == query {
+			return true
+		}
+	}
+	return false
 }
 
 // IndexSource identifies where an index came from.
 type IndexSource struct {
 	URL  string
 	Name string // "official", "community", etc.
+
+	// PublicKeys are cosign public key files. When set, the index must have
+	// a detached signature at URL + ".sig" made by one of them.
+	PublicKeys []string
+
+	// RequireSigned rejects the index unless its signature is verified.
+	RequireSigned bool
+}
+
+// checkSignature applies the source's signing policy to index data and its
+// detached base64 signature (nil if there is none).
+func (s IndexSource) checkSignature(data, sig []byte) error {
+	if len(s.PublicKeys) == 0 {
+		if s.RequireSigned {
+			return errors.New("signed indexes are required but no public key is configured for this index")
+		}
+		return nil
+	}
+	if sig == nil {
+		return errors.New("index is not signed")
+	}
+	return VerifyIndex(data, sig, s.PublicKeys)
+}
+
+// VerifyIndex checks a detached index signature against each public key in
+// turn and succeeds if any of them made it.
+func VerifyIndex(data, sig []byte, keyPaths []string) error {
+	var errs []error
+	for _, path := range keyPaths {
+		verifier, err := LoadVerifier(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := VerifyBlob(data, string(sig), verifier); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("index signature not verified: %w", errors.Join(errs...))
 }
 
 // SearchResult is a PluginEntry annotated with its source.
@@ -43,36 +121,196 @@ type SearchResult struct {
 	Registry string // OCI registry prefix for install
 }
 
+// errNotFound marks an index or signature that does not exist.
+var errNotFound = errors.New("not found")
+
+// errNotModified marks an index the server reports unchanged since it was
+// cached.
+var errNotModified = errors.New("not modified")
+
+// indexValidators are the HTTP cache validators of a fetched index, sent
+// back on the next fetch so an unchanged index is not downloaded again.
+type indexValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
 // FetchIndex downloads and parses a plugin index.
 func FetchIndex(ctx context.Context, url string) (*PluginIndex, error) {
+	body, _, err := fetchIndexData(ctx, url, false)
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(body)
+}
+
+// fetchIndexData loads an index from an http(s), file://, or oci:// URL
+// and, when withSig is set, its detached signature (nil if there is none).
+//
+// For URLs and files the signature lives at the same location plus ".sig".
+// An OCI artifact carries the index as a layer titled index.json (or as its
+// only layer) and the signature as a layer titled index.json.sig.
+func fetchIndexData(ctx context.Context, url string, withSig bool) (data, sig []byte, err error) {
+	data, sig, _, err = fetchIndexDataIfModified(ctx, url, withSig, indexValidators{})
+	return data, sig, err
+}
+
+// fetchIndexDataIfModified is fetchIndexData with a conditional request for
+// http(s) URLs: it returns errNotModified if the index still matches prev,
+// and otherwise the validators to send next time. Files and OCI artifacts
+// are always read and have no validators.
+func fetchIndexDataIfModified(ctx context.Context, url string, withSig bool, prev indexValidators) (data, sig []byte, next indexValidators, err error) {
+	if ref, ok := strings.CutPrefix(url, "oci://"); ok {
+		data, sig, err = fetchOCIIndex(ctx, ref, withSig)
+		return data, sig, indexValidators{}, err
+	}
+
+	fetch := fetchHTTP
+	if path, ok := strings.CutPrefix(url, "file://"); ok {
+		url, fetch = path, readIndexFile
+		data, err = readIndexFile(ctx, url)
+	} else {
+		data, next, err = fetchHTTPIfModified(ctx, url, prev)
+	}
+	if err != nil {
+		return nil, nil, indexValidators{}, err
+	}
+	if withSig {
+		sig, err = fetch(ctx, url+".sig")
+		if errors.Is(err, errNotFound) {
+			sig, err = nil, nil
+		}
+		if err != nil {
+			return nil, nil, indexValidators{}, fmt.Errorf("fetching signature: %w", err)
+		}
+	}
+	return data, sig, next, nil
+}
+
+// readIndexFile reads a local index file.
+func readIndexFile(_ context.Context, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+	return data, nil
+}
+
+// fetchOCIIndex pulls an index artifact. A reference without a tag uses
+// "latest"; credentials come from NewCredentialProvider.
+func fetchOCIIndex(ctx context.Context, ref string, withSig bool) ([]byte, []byte, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid index reference %q: %w", ref, err)
+	}
+	if parsed.Reference == "" {
+		parsed.Reference = "latest"
+	}
+	repo, err := NewRemoteRepository(ctx, parsed, NewCredentialProvider(), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readIndexArtifact(ctx, repo, parsed.Reference, withSig)
+}
+
+// readIndexArtifact reads the index and signature layers of an artifact.
+func readIndexArtifact(ctx context.Context, target oras.ReadOnlyTarget, reference string, withSig bool) ([]byte, []byte, error) {
+	_, raw, err := oras.FetchBytes(ctx, target, reference, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching index artifact: %w", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("parsing index artifact: %w", err)
+	}
+
+	var indexLayer, sigLayer *ocispec.Descriptor
+	for i, layer := range manifest.Layers {
+		switch layer.Annotations[ocispec.AnnotationTitle] {
+		case "index.json":
+			indexLayer = &manifest.Layers[i]
+		case "index.json.sig":
+			sigLayer = &manifest.Layers[i]
+		}
+	}
+	if indexLayer == nil && len(manifest.Layers) == 1 {
+		indexLayer = &manifest.Layers[0]
+	}
+	if indexLayer == nil {
+		return nil, nil, errors.New("index artifact has no index.json layer")
+	}
+
+	data, err := content.FetchAll(ctx, target, *indexLayer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching index: %w", err)
+	}
+	var sig []byte
+	if withSig && sigLayer != nil {
+		if sig, err = content.FetchAll(ctx, target, *sigLayer); err != nil {
+			return nil, nil, fmt.Errorf("fetching signature: %w", err)
+		}
+	}
+	return data, sig, nil
+}
+
+// fetchHTTP downloads url, returning errNotFound for a 404 response.
+func fetchHTTP(ctx context.Context, url string) ([]byte, error) {
+	body, _, err := fetchHTTPIfModified(ctx, url, indexValidators{})
+	return body, err
+}
+
+// fetchHTTPIfModified downloads url unless it is unchanged since prev was
+// recorded, in which case it returns errNotModified.
+func fetchHTTPIfModified(ctx context.Context, url string, prev indexValidators) ([]byte, indexValidators, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, indexValidators{}, fmt.Errorf("creating request: %w", err)
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("fetching index: %w", err)
+		return nil, indexValidators{}, fmt.Errorf("fetching index: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotModified && prev != (indexValidators{}) {
+		return nil, prev, errNotModified
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, indexValidators{}, fmt.Errorf("%w: index returned %d", errNotFound, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("index returned %d", resp.StatusCode)
+		return nil, indexValidators{}, fmt.Errorf("index returned %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading index: %w", err)
+		return nil, indexValidators{}, fmt.Errorf("reading index: %w", err)
+	}
+	next := indexValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
 	}
+	return body, next, nil
+}
 
+func parseIndex(data []byte) (*PluginIndex, error) {
 	var idx PluginIndex
-	if err := json.Unmarshal(body, &idx); err != nil {
+	if err := json.Unmarshal(data, &idx); err != nil {
 		return nil, fmt.Errorf("parsing index: %w", err)
 	}
-
 	return &idx, nil
 }
 
@@ -82,11 +320,10 @@ func SearchAll(ctx context.Context, sources []IndexSource, query string, forceRe
 	var results []SearchResult
 	query = strings.ToLower(query)
 
-	home, err := os.UserHomeDir()
+	cacheDir, err := indexCacheDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home dir: %w", err)
+		return nil, err
 	}
-	cacheDir := filepath.Join(home, ".tack", "cache", "indexes")
 
 	maxAge := 1 * time.Hour
 	if forceRefresh {
@@ -102,8 +339,7 @@ func SearchAll(ctx context.Context, sources []IndexSource, query string, forceRe
 		}
 
 		for _, p := range idx.Plugins {
-			if query == "" || strings.Contains(strings.ToLower(p.Name), query) ||
-				strings.Contains(strings.ToLower(p.Description), query) {
+			if p.matches(query) {
 				results = append(results, SearchResult{
 					PluginEntry: p,
 					Source:      src.Name,
@@ -116,51 +352,164 @@ func SearchAll(ctx context.Context, sources []IndexSource, query string, forceRe
 	return results, nil
 }
 
+// indexCacheDir returns the directory holding cached indexes.
+func indexCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %w", err)
+	}
+	return filepath.Join(home, ".tack", "cache", "indexes"), nil
+}
+
+// CachedIndexSources returns, in name order, the cached indexes that list
+// a plugin. It reads only the local cache and is meant for hints; the
+// indexes are not verified.
+func CachedIndexSources(name string) []string {
+	dir, err := indexCacheDir()
+	if err != nil {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	var sources []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		idx, err := parseIndex(data)
+		if err != nil {
+			continue
+		}
+		for _, p := range idx.Plugins {
+			if p.Name == name {
+				sources = append(sources, strings.TrimSuffix(filepath.Base(path), ".json"))
+				break
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// cachedFetch returns the index for src, from the cache when it is newer
+// than maxAge. The raw index and its signature are cached together, and
+// the signing policy is checked on every read, so a cached copy is only
+// trusted under the current keys.
+//
+// A stale cached copy is revalidated with the ETag and Last-Modified the
+// server sent for it; if the index is unchanged it is kept, and its age
+// restarts, without downloading it again.
 func cachedFetch(ctx context.Context, src IndexSource, cacheDir string, maxAge time.Duration) (*PluginIndex, error) {
 	cachePath := filepath.Join(cacheDir, src.Name+".json")
+	if strings.HasPrefix(src.URL, "file://") {
+		// Local files are cheap to read and should reflect edits at once.
+		maxAge = 0
+	}
 
-	cached, cacheOK := readCache(cachePath)
-	if cacheOK && maxAge > 0 {
+	cached, cacheErr := readCache(src, cachePath)
+	if cacheErr == nil && maxAge > 0 {
 		info, err := os.Stat(cachePath)
 		if err == nil && time.Since(info.ModTime()) < maxAge {
 			return cached, nil
 		}
 	}
 
-	idx, err := FetchIndex(ctx, src.URL)
+	var prev indexValidators
+	if cacheErr == nil {
+		prev = readValidators(cachePath)
+	}
+	idx, err := fetchVerified(ctx, src, cachePath, prev)
+	if errors.Is(err, errNotModified) {
+		now := time.Now()
+		_ = os.Chtimes(cachePath, now, now)
+		return cached, nil
+	}
 	if err != nil {
 		// Serve stale cache rather than failing entirely
-		if cacheOK {
+		if cacheErr == nil {
 			fmt.Fprintf(os.Stderr, "Warning: using stale %s index (fetch failed: %v)\n", src.Name, err)
 			return cached, nil
 		}
 		return nil, err
 	}
+	return idx, nil
+}
+
+// fetchVerified downloads an index and, when src has public keys, its
+// detached signature, checks them, and caches both. It returns
+// errNotModified if the index is unchanged since prev was recorded.
+func fetchVerified(ctx context.Context, src IndexSource, cachePath string, prev indexValidators) (*PluginIndex, error) {
+	data, sig, next, err := fetchIndexDataIfModified(ctx, src.URL, len(src.PublicKeys) > 0, prev)
+	if err != nil {
+		return nil, err
+	}
+	if err := src.checkSignature(data, sig); err != nil {
+		return nil, err
+	}
 
-	_ = saveCache(idx, src, cacheDir)
+	idx, err := parseIndex(data)
+	if err != nil {
+		return nil, err
+	}
+	_ = saveCache(data, sig, next, cachePath)
 	return idx, nil
 }
 
-func readCache(path string) (*PluginIndex, bool) {
+func readCache(src IndexSource, path string) (*PluginIndex, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, false
+		return nil, err
 	}
-	var idx PluginIndex
-	if err := json.Unmarshal(data, &idx); err != nil {
-		return nil, false
+	sig, err := os.ReadFile(path + ".sig")
+	if err != nil {
+		sig = nil
 	}
-	return &idx, true
+	if err := src.checkSignature(data, sig); err != nil {
+		return nil, err
+	}
+	return parseIndex(data)
 }
 
-func saveCache(idx *PluginIndex, src IndexSource, cacheDir string) error {
-	cachePath := filepath.Join(cacheDir, src.Name+".json")
-	data, err := json.Marshal(idx)
-	if err != nil {
-		return err
+// readValidators returns the validators saved with a cached index, or none.
+func readValidators(cachePath string) indexValidators {
+	var v indexValidators
+	if data, err := os.ReadFile(cachePath + ".meta"); err == nil {
+		_ = json.Unmarshal(data, &v)
 	}
+	return v
+}
+
+func saveCache(data, sig []byte, validators indexValidators, cachePath string) error {
 	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(cachePath, data, 0o644)
+	if err := os.WriteFile(cachePath, data, 0o644); err != nil {
+		return err
+	}
+	if validators == (indexValidators{}) {
+		if err := removeIfExists(cachePath + ".meta"); err != nil {
+			return err
+		}
+	} else {
+		meta, err := json.Marshal(validators)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(cachePath+".meta", meta, 0o644); err != nil {
+			return err
+		}
+	}
+	if sig == nil {
+		return removeIfExists(cachePath + ".sig")
+	}
+	return os.WriteFile(cachePath+".sig", sig, 0o644)
+}
+
+// removeIfExists removes path, ignoring a file that is already gone.
+func removeIfExists(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
 }
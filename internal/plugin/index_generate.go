@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// NewIndexEntry builds an index entry from a plugin manifest.
+func NewIndexEntry(m abi.Manifest) PluginEntry {
+	caps := CapabilityKinds(m)
+	if caps == nil {
+		caps = []string{}
+	}
+	return PluginEntry{
+		Name:         m.Name,
+		Description:  m.Description,
+		Capabilities: caps,
+		Latest:       m.Version,
+	}
+}
+
+// NewerVersion reports whether version a is newer than b. Versions that
+// are not semantic versions sort before those that are, then by name.
+func NewerVersion(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.GreaterThan(vb)
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	default:
+		return a > b
+	}
+}
+
+// LatestVersion returns the highest semantic version among tags. Tags that
+// are not versions (such as "latest" or signature tags) are ignored. It
+// returns "" if no tag is a version.
+func LatestVersion(tags []string) string {
+	var latest *semver.Version
+	var tag string
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest, tag = v, t
+		}
+	}
+	return tag
+}
+
+// errNotPlugin marks artifacts that are not plugins published by Pack.
+var errNotPlugin = errors.New("not a plugin artifact")
+
+// FetchArtifactManifest reads the plugin manifest stored in a published
+// artifact, without downloading its WASM layer.
+func FetchArtifactManifest(ctx context.Context, target oras.ReadOnlyTarget, reference string) (abi.Manifest, error) {
+	_, data, err := oras.FetchBytes(ctx, target, reference, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return abi.Manifest{}, fmt.Errorf("fetching %s: %w", reference, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return abi.Manifest{}, fmt.Errorf("parsing OCI manifest: %w", err)
+	}
+	if manifest.ArtifactType != ArtifactType && manifest.Config.MediaType != MediaTypePluginConfig {
+		return abi.Manifest{}, errNotPlugin
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeManifestJSON {
+			continue
+		}
+		raw, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return abi.Manifest{}, fmt.Errorf("fetching plugin manifest: %w", err)
+		}
+		var m abi.Manifest
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return abi.Manifest{}, fmt.Errorf("parsing plugin manifest: %w", err)
+		}
+		return m, nil
+	}
+	return abi.Manifest{}, fmt.Errorf("artifact has no %s layer", MediaTypeManifestJSON)
+}
+
+// RegistryScan is the result of ScanRegistry.
+type RegistryScan struct {
+	// Manifests holds the latest manifest of each plugin repository.
+	Manifests []abi.Manifest
+	// Skipped maps repositories that were not read to the reason why.
+	Skipped map[string]error
+}
+
+// ScanRegistry reads the latest published manifest of every repository
+// under namespace ("registry/path"). The registry must support listing
+// repositories (the catalog API).
+func ScanRegistry(ctx context.Context, namespace string, authProvider ports.AuthProvider, plainHTTP bool) (RegistryScan, error) {
+	host, prefix, _ := strings.Cut(strings.TrimSuffix(namespace, "/"), "/")
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return RegistryScan{}, fmt.Errorf("invalid registry %q: %w", host, err)
+	}
+	reg.PlainHTTP = plainHTTP
+	reg.Client = registryClient(ctx, host, authProvider)
+
+	var repos []string
+	err = reg.Repositories(ctx, "", func(names []string) error {
+		for _, name := range names {
+			if prefix == "" || strings.HasPrefix(name, prefix+"/") {
+				repos = append(repos, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RegistryScan{}, fmt.Errorf("listing repositories in %s: %w", host, err)
+	}
+	sort.Strings(repos)
+
+	scan := RegistryScan{Skipped: map[string]error{}}
+	for _, name := range repos {
+		repo, err := reg.Repository(ctx, name)
+		if err != nil {
+			scan.Skipped[name] = err
+			continue
+		}
+		tags, err := registry.Tags(ctx, repo)
+		if err != nil {
+			scan.Skipped[name] = fmt.Errorf("listing tags: %w", err)
+			continue
+		}
+		tag := LatestVersion(tags)
+		if tag == "" {
+			scan.Skipped[name] = errors.New("no version tags")
+			continue
+		}
+		m, err := FetchArtifactManifest(ctx, repo, tag)
+		if err != nil {
+			scan.Skipped[name] = err
+			continue
+		}
+		// The tag is what users install, whatever the manifest claims.
+		m.Version = tag
+		scan.Manifests = append(scan.Manifests, m)
+	}
+	return scan, nil
+}
+
+// SortVersions orders versions newest first, as NewerVersion compares them.
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool { return NewerVersion(versions[i], versions[j]) })
+}
+
+// PublishedVersions returns the version tags of a plugin repository, newest
+// first. Tags that are not versions are ignored.
+func PublishedVersions(ctx context.Context, repo registry.TagLister) ([]string, error) {
+	tags, err := registry.Tags(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	var versions []string
+	for _, t := range tags {
+		if _, err := semver.NewVersion(t); err == nil {
+			versions = append(versions, t)
+		}
+	}
+	SortVersions(versions)
+	return versions, nil
+}
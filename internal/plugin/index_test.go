@@ -0,0 +1,279 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestPluginEntryMatches(t *testing.T) {
+	entry := PluginEntry{
+		Name:        "dns",
+		Description: "DNS resolution",
+		Categories:  []string{"Network"},
+		Tags:        []string{"resolver"},
+	}
+
+	for _, q := range []string{"", "dns", "resolution", "network", "resolver"} {
+		if !entry.matches(q) {
+			t.Errorf("expected %q to match", q)
+		}
+	}
+	if entry.matches("cloud") {
+		t.Error("expected cloud not to match")
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tags := []string{"latest", "1.2.0", "v1.10.0", "sha256-abc.sig", "1.9.3"}
+	if got := LatestVersion(tags); got != "v1.10.0" {
+		t.Errorf("expected v1.10.0, got %q", got)
+	}
+	if got := LatestVersion([]string{"latest"}); got != "" {
+		t.Errorf("expected no version, got %q", got)
+	}
+	if !NewerVersion("1.10.0", "1.9.0") || NewerVersion("dev", "0.1.0") {
+		t.Error("expected semantic ordering, with non-versions oldest")
+	}
+}
+
+func TestFetchArtifactManifest(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	if _, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest()); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	m, err := FetchArtifactManifest(ctx, store, "1.0.0")
+	if err != nil {
+		t.Fatalf("FetchArtifactManifest: %v", err)
+	}
+	entry := NewIndexEntry(m)
+	if entry.Name != "ping" || entry.Latest != "1.0.0" || entry.Description != "Ping checks" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if len(entry.Capabilities) != 1 || entry.Capabilities[0] != "network" {
+		t.Errorf("expected network capability, got %v", entry.Capabilities)
+	}
+
+	if _, err := FetchArtifactManifest(ctx, store, "2.0.0"); err == nil {
+		t.Error("expected error for missing tag")
+	} else if errors.Is(err, errNotPlugin) {
+		t.Errorf("expected fetch error, got %v", err)
+	}
+}
+
+func TestCachedFetchSignedIndex(t *testing.T) {
+	t.Setenv(PasswordEnv, "pw")
+	dir := t.TempDir()
+	keyPath, pubPath, err := GenerateKeys(filepath.Join(dir, "cosign"), []byte("pw"), false)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	_, otherPub, err := GenerateKeys(filepath.Join(dir, "other"), []byte("pw"), false)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	signer, err := LoadSigner(keyPath)
+	if err != nil {
+		t.Fatalf("LoadSigner: %v", err)
+	}
+
+	index := []byte(`{"registry":"ghcr.io/acme","plugins":[{"name":"dns","latest":"1.0.0"}]}`)
+	sig, err := SignBlob(index, signer)
+	if err != nil {
+		t.Fatalf("SignBlob: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/signed.json", "/unsigned.json":
+			_, _ = w.Write(index)
+		case "/signed.json.sig":
+			_, _ = w.Write([]byte(sig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		src     IndexSource
+		wantErr string
+	}{
+		{"signed", IndexSource{URL: srv.URL + "/signed.json", PublicKeys: []string{otherPub, pubPath}}, ""},
+		{"unsigned allowed", IndexSource{URL: srv.URL + "/unsigned.json"}, ""},
+		{"unsigned with key", IndexSource{URL: srv.URL + "/unsigned.json", PublicKeys: []string{pubPath}}, "not signed"},
+		{"wrong key", IndexSource{URL: srv.URL + "/signed.json", PublicKeys: []string{otherPub}}, "not verified"},
+		{"required without key", IndexSource{URL: srv.URL + "/signed.json", RequireSigned: true}, "no public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.src.Name = "test"
+			idx, err := cachedFetch(context.Background(), tt.src, t.TempDir(), time.Hour)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cachedFetch: %v", err)
+			}
+			if len(idx.Plugins) != 1 || idx.Plugins[0].Name != "dns" {
+				t.Errorf("unexpected index: %+v", idx)
+			}
+		})
+	}
+
+	// A cached index is checked again under the current keys.
+	cacheDir := t.TempDir()
+	src := IndexSource{Name: "test", URL: srv.URL + "/unsigned.json"}
+	if _, err := cachedFetch(context.Background(), src, cacheDir, time.Hour); err != nil {
+		t.Fatalf("cachedFetch: %v", err)
+	}
+	src.URL = srv.URL + "/missing.json"
+	src.PublicKeys = []string{pubPath}
+	if _, err := cachedFetch(context.Background(), src, cacheDir, time.Hour); err == nil {
+		t.Error("expected unsigned cached index to be refused once keys are configured")
+	}
+}
+
+func TestFetchIndexFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(path, []byte(`{"plugins":[{"name":"dns"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := FetchIndex(context.Background(), "file://"+path)
+	if err != nil {
+		t.Fatalf("FetchIndex: %v", err)
+	}
+	if len(idx.Plugins) != 1 || idx.Plugins[0].Name != "dns" {
+		t.Errorf("unexpected index: %+v", idx)
+	}
+
+	src := IndexSource{Name: "local", URL: "file://" + path, PublicKeys: []string{filepath.Join(dir, "missing.pub")}}
+	if _, err := cachedFetch(context.Background(), src, t.TempDir(), time.Hour); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("expected unsigned file index to be refused, got %v", err)
+	}
+	if _, err := FetchIndex(context.Background(), "file://"+filepath.Join(dir, "nope.json")); !errors.Is(err, errNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestReadIndexArtifact(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	index := []byte(`{"plugins":[{"name":"dns"}]}`)
+	indexDesc, err := pushBlob(ctx, store, "application/json", index, map[string]string{ocispec.AnnotationTitle: "index.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigDesc, err := pushBlob(ctx, store, "text/plain", []byte("c2ln"), map[string]string{ocispec.AnnotationTitle: "index.json.sig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.tack.index.v1", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{sigDesc, indexDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, desc, "latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, sig, err := readIndexArtifact(ctx, store, "latest", true)
+	if err != nil {
+		t.Fatalf("readIndexArtifact: %v", err)
+	}
+	if string(data) != string(index) || string(sig) != "c2ln" {
+		t.Errorf("unexpected layers: %q, %q", data, sig)
+	}
+	if _, sig, _ := readIndexArtifact(ctx, store, "latest", false); sig != nil {
+		t.Error("expected no signature when not requested")
+	}
+}
+
+func TestCachedFetchConditional(t *testing.T) {
+	index := []byte(`{"plugins":[{"name":"dns","latest":"1.0.0"}]}`)
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(index)
+	}))
+	defer srv.Close()
+
+	src := IndexSource{Name: "test", URL: srv.URL + "/index.json"}
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "test.json")
+
+	if _, err := cachedFetch(context.Background(), src, dir, 0); err != nil {
+		t.Fatalf("cachedFetch: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cachePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := cachedFetch(context.Background(), src, dir, time.Hour)
+	if err != nil {
+		t.Fatalf("cachedFetch: %v", err)
+	}
+	if len(idx.Plugins) != 1 || idx.Plugins[0].Name != "dns" {
+		t.Errorf("unexpected index: %+v", idx)
+	}
+	if full != 1 || notModified != 1 {
+		t.Errorf("expected 1 full and 1 conditional fetch, got %d and %d", full, notModified)
+	}
+	if info, err := os.Stat(cachePath); err != nil || time.Since(info.ModTime()) > time.Minute {
+		t.Errorf("expected revalidated cache to be fresh, got %v", err)
+	}
+
+	// Without saved validators the index is fetched in full.
+	if err := os.Remove(cachePath + ".meta"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cachedFetch(context.Background(), src, dir, 0); err != nil {
+		t.Fatalf("cachedFetch: %v", err)
+	}
+	if full != 2 {
+		t.Errorf("expected a full fetch without validators, got %d", full)
+	}
+}
+
+// tagList is a registry.TagLister over a fixed set of tags.
+type tagList []string
+
+func (l tagList) Tags(_ context.Context, _ string, fn func([]string) error) error {
+	return fn(l)
+}
+
+func TestPublishedVersions(t *testing.T) {
+	versions, err := PublishedVersions(context.Background(), tagList{"latest", "1.2.0", "v1.10.0", "sha256-abc.sig", "1.9.3", "2.0.0-rc.1"})
+	if err != nil {
+		t.Fatalf("PublishedVersions: %v", err)
+	}
+	if got := strings.Join(versions, ","); got != "2.0.0-rc.1,v1.10.0,1.9.3,1.2.0" {
+		t.Errorf("unexpected versions: %s", got)
+	}
+}
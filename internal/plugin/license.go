@@ -0,0 +1,234 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ErrLicenseDenied is returned when a plugin's license is not permitted by
+// the license policy.
+var ErrLicenseDenied = errcode.New(errcode.LicenseDenied, "plugin license not permitted")
+
+// SBOM artifact types whose license is read when an artifact has no
+// license annotation.
+const (
+	ArtifactTypeSPDX      = "application/spdx+json"
+	ArtifactTypeCycloneDX = "application/vnd.cyclonedx+json"
+)
+
+// maxSBOMSize bounds the SBOM documents read for a license.
+const maxSBOMSize = 4 << 20
+
+// LicensePolicy restricts the licenses of plugins pulled from registries
+// and OCI layouts. Identifiers are SPDX license IDs, compared without
+// regard to case, and may use * globs ("BSD-*").
+type LicensePolicy struct {
+	// Allowed lists the permitted licenses. When set, a plugin with any
+	// other license, or none, is not permitted.
+	Allowed []string
+
+	// Forbidden lists licenses that are never permitted.
+	Forbidden []string
+
+	// WarnOnly reports licenses that are not permitted instead of
+	// refusing the plugin.
+	WarnOnly bool
+}
+
+var licensePolicy atomic.Pointer[LicensePolicy]
+
+// SetLicensePolicy makes p the license policy applied to pulls. A nil p
+// removes it.
+func SetLicensePolicy(p *LicensePolicy) {
+	licensePolicy.Store(p)
+}
+
+// Check returns ErrLicenseDenied when license, an SPDX license expression,
+// is not permitted for the named plugin. Of the alternatives joined by OR,
+// one must be permitted; licenses joined by AND must all be. A nil policy
+// permits everything.
+func (p *LicensePolicy) Check(name, license string) error {
+	if p == nil {
+		return nil
+	}
+	license = strings.TrimSpace(license)
+	if license == "" {
+		if len(p.Allowed) > 0 {
+			return fmt.Errorf("%w: %s declares no license", ErrLicenseDenied, name)
+		}
+		return nil
+	}
+
+	expr := strings.NewReplacer("(", " ", ")", " ").Replace(license)
+	var reason string
+	for _, alternative := range splitOperator(expr, "OR") {
+		denied := ""
+		for _, id := range splitOperator(alternative, "AND") {
+			// "GPL-2.0-only WITH Classpath-exception-2.0" is judged by its license
+			id, _, _ = strings.Cut(id, " WITH ")
+			if why := p.deny(strings.TrimSpace(id)); why != "" {
+				denied = why
+				break
+			}
+		}
+		if denied == "" {
+			return nil
+		}
+		reason = denied
+	}
+	return fmt.Errorf("%w: %s is licensed %s (%s)", ErrLicenseDenied, name, license, reason)
+}
+
+// deny returns why a single license identifier is not permitted, or "".
+func (p *LicensePolicy) deny(id string) string {
+	if matchLicense(p.Forbidden, id) {
+		return id + " is forbidden"
+	}
+	if len(p.Allowed) > 0 && !matchLicense(p.Allowed, id) {
+		return id + " is not allowed"
+	}
+	return ""
+}
+
+func matchLicense(patterns []string, id string) bool {
+	id = strings.ToLower(id)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitOperator splits an SPDX expression on a binary operator.
+func splitOperator(expr, op string) []string {
+	fields := strings.Fields(expr)
+	var parts []string
+	start := 0
+	for i, f := range fields {
+		if strings.EqualFold(f, op) {
+			parts = append(parts, strings.Join(fields[start:i], " "))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.Join(fields[start:], " "))
+}
+
+// enforceLicense applies the license policy to an artifact before its
+// binary is fetched. Without a license annotation, the license of an SPDX
+// or CycloneDX SBOM attached to reference through the referrers API is
+// used, and recorded in the artifact's provenance. In warn mode a license
+// that is not permitted is reported and the pull goes on.
+func enforceLicense(ctx context.Context, target oras.ReadOnlyTarget, reference string, artifact *Artifact) error {
+	p := licensePolicy.Load()
+	if p == nil {
+		return nil
+	}
+	if artifact.Provenance.License == "" {
+		artifact.Provenance.License = sbomLicense(ctx, target, reference)
+	}
+	err := p.Check(artifact.Metadata.Name(), artifact.Provenance.License)
+	if err != nil && p.WarnOnly {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// sbomLicense returns the license an SBOM attached to reference declares
+// for the artifact, or "" when there is none or it cannot be read.
+func sbomLicense(ctx context.Context, target oras.ReadOnlyTarget, reference string) string {
+	store, ok := target.(content.ReadOnlyGraphStorage)
+	if !ok {
+		return ""
+	}
+	subject, err := target.Resolve(ctx, reference)
+	if err != nil {
+		return ""
+	}
+	referrers, err := registry.Referrers(ctx, store, subject, "")
+	if err != nil {
+		return ""
+	}
+	for _, desc := range referrers {
+		if desc.ArtifactType != ArtifactTypeSPDX && desc.ArtifactType != ArtifactTypeCycloneDX {
+			continue
+		}
+		raw, err := content.FetchAll(ctx, target, desc)
+		if err != nil {
+			continue
+		}
+		var manifest ocispec.Manifest
+		if json.Unmarshal(raw, &manifest) != nil || len(manifest.Layers) == 0 || manifest.Layers[0].Size > maxSBOMSize {
+			continue
+		}
+		doc, err := content.FetchAll(ctx, target, manifest.Layers[0])
+		if err != nil {
+			continue
+		}
+		if license := documentLicense(desc.ArtifactType, doc); license != "" {
+			return license
+		}
+	}
+	return ""
+}
+
+// documentLicense reads the license of an SBOM's subject: the first SPDX
+// package's declared (or else concluded) license, or the CycloneDX
+// metadata component's license expression or IDs.
+func documentLicense(artifactType string, doc []byte) string {
+	if artifactType == ArtifactTypeSPDX {
+		var spdx struct {
+			Packages []struct {
+				LicenseDeclared  string `json:"licenseDeclared"`
+				LicenseConcluded string `json:"licenseConcluded"`
+			} `json:"packages"`
+		}
+		if json.Unmarshal(doc, &spdx) != nil || len(spdx.Packages) == 0 {
+			return ""
+		}
+		for _, l := range []string{spdx.Packages[0].LicenseDeclared, spdx.Packages[0].LicenseConcluded} {
+			if l != "" && l != "NOASSERTION" && l != "NONE" {
+				return l
+			}
+		}
+		return ""
+	}
+
+	var cdx struct {
+		Metadata struct {
+			Component struct {
+				Licenses []struct {
+					Expression string `json:"expression"`
+					License    struct {
+						ID string `json:"id"`
+					} `json:"license"`
+				} `json:"licenses"`
+			} `json:"component"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(doc, &cdx) != nil {
+		return ""
+	}
+	var ids []string
+	for _, l := range cdx.Metadata.Component.Licenses {
+		if l.Expression != "" {
+			return l.Expression
+		}
+		if l.License.ID != "" {
+			ids = append(ids, l.License.ID)
+		}
+	}
+	return strings.Join(ids, " AND ")
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestLicensePolicyCheck(t *testing.T) {
+	p := &LicensePolicy{Allowed: []string{"MIT", "Apache-2.0", "BSD-*"}, Forbidden: []string{"BSD-4-Clause"}}
+	tests := []struct {
+		license string
+		ok      bool
+	}{
+		{"MIT", true},
+		{"apache-2.0", true},
+		{"BSD-3-Clause", true},
+		{"BSD-4-Clause", false},
+		{"GPL-3.0-only", false},
+		{"", false},
+		{"GPL-3.0-only OR MIT", true},
+		{"(MIT AND GPL-3.0-only)", false},
+		{"MIT AND Apache-2.0", true},
+		{"Apache-2.0 WITH LLVM-exception", true},
+	}
+	for _, tt := range tests {
+		err := p.Check("ping", tt.license)
+		if tt.ok != (err == nil) {
+			t.Errorf("Check(%q) = %v, want ok=%v", tt.license, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrLicenseDenied) {
+			t.Errorf("Check(%q) = %v, want ErrLicenseDenied", tt.license, err)
+		}
+	}
+
+	denyOnly := &LicensePolicy{Forbidden: []string{"AGPL-*"}}
+	if err := denyOnly.Check("ping", ""); err != nil {
+		t.Errorf("a forbidden list alone should permit unlicensed plugins: %v", err)
+	}
+	if err := denyOnly.Check("ping", "AGPL-3.0-only"); err == nil {
+		t.Error("expected AGPL-3.0-only to be refused")
+	}
+	if err := (*LicensePolicy)(nil).Check("ping", "anything"); err != nil {
+		t.Errorf("nil policy: %v", err)
+	}
+}
+
+func TestFetchArtifactLicense(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	// An SPDX SBOM attached through the referrers API names the license
+	sbom, err := pushBlob(ctx, store, ArtifactTypeSPDX, []byte(`{"packages":[{"licenseDeclared":"GPL-3.0-only"}]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, ArtifactTypeSPDX, oras.PackManifestOptions{
+		Layers:  []ocispec.Descriptor{sbom},
+		Subject: &desc,
+	}); err != nil {
+		t.Fatalf("packing SBOM: %v", err)
+	}
+
+	SetLicensePolicy(&LicensePolicy{Forbidden: []string{"GPL-*"}})
+	defer SetLicensePolicy(nil)
+	if _, err := FetchArtifact(ctx, store, "1.0.0"); !errors.Is(err, ErrLicenseDenied) {
+		t.Fatalf("expected ErrLicenseDenied, got %v", err)
+	}
+
+	SetLicensePolicy(&LicensePolicy{Forbidden: []string{"GPL-*"}, WarnOnly: true})
+	artifact, err := FetchArtifact(ctx, store, "1.0.0")
+	if err != nil {
+		t.Fatalf("warn mode: %v", err)
+	}
+	if artifact.Provenance.License != "GPL-3.0-only" {
+		t.Errorf("expected the SBOM license in the provenance, got %q", artifact.Provenance.License)
+	}
+}
+
+func TestDocumentLicense(t *testing.T) {
+	cdx := `{"metadata":{"component":{"licenses":[{"license":{"id":"MIT"}},{"license":{"id":"ISC"}}]}}}`
+	if got := documentLicense(ArtifactTypeCycloneDX, []byte(cdx)); got != "MIT AND ISC" {
+		t.Errorf("CycloneDX IDs = %q", got)
+	}
+	cdx = `{"metadata":{"component":{"licenses":[{"expression":"MIT OR Apache-2.0"}]}}}`
+	if got := documentLicense(ArtifactTypeCycloneDX, []byte(cdx)); got != "MIT OR Apache-2.0" {
+		t.Errorf("CycloneDX expression = %q", got)
+	}
+	spdx := `{"packages":[{"licenseDeclared":"NOASSERTION","licenseConcluded":"BSD-2-Clause"}]}`
+	if got := documentLicense(ArtifactTypeSPDX, []byte(spdx)); got != "BSD-2-Clause" {
+		t.Errorf("SPDX = %q", got)
+	}
+}
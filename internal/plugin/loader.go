@@ -2,50 +2,123 @@
 package plugin
 
 import (
+	"cmp"
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	goruntime "runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	abi "github.com/reglet-dev/reglet-abi"
 	hostdto "github.com/reglet-dev/reglet-host-sdk/plugin/dto"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
 	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"github.com/whiskeyjimb/tack-cli/internal/workers"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
 )
 
 // DiscoveredPlugin holds a plugin's WASM loader and manifest.
 type DiscoveredPlugin struct {
 	Manifest abi.Manifest
 	Loader   func() ([]byte, error)
-	Source   string // "embedded", "local", or "oci"
+	Source   string // "embedded", "local", "workspace", or "oci"
 	Path     string // file path (for local/oci plugins)
+	Digest   string // content digest of the WASM binary ("sha256:...")
 }
 
 // Loader discovers and loads plugins from multiple sources.
 type Loader struct {
-	embeddedFS embed.FS     // Embedded WASM files
-	pluginsDir string       // Local plugins directory (~/.cli/plugins/)
-	cachePath  string       // Path to discovery cache
-	stack      *PluginStack // Host-sdk plugin service (for OCI fallback)
-	defaultReg string       // Default OCI registry prefix
+	embeddedFS   embed.FS     // Embedded WASM files
+	pluginsDir   string       // Local plugins directory (~/.tack/plugins/)
+	workspaceDir string       // Trusted workspace plugins directory (.tack/plugins/); may be empty
+	quarantine   string       // Receives plugins that fail verification
+	cachePath    string       // Path to discovery cache
+	stack        *PluginStack // Host-sdk plugin service (for OCI fallback)
+	defaultReg   string       // Default OCI registry prefix
+
+	runner  *runtime.SharedRunner // Reads manifests; nil for a runner per plugin
+	tracer  DiscoveryTracer       // Receives discovery timings; may be nil
+	confirm ConfirmFunc           // Confirms upgrades that request new capabilities; may be nil
+
+	servedWarm  bool      // DiscoverAll listed plugins without reading them
+	refreshedAt time.Time // When the cache was last refreshed
+}
+
+// DiscoveryTracer receives timings from plugin discovery. ManifestRead may
+// be called from several goroutines at once.
+type DiscoveryTracer interface {
+	// CacheLoaded reports how long reading the discovery cache took.
+	CacheLoaded(d time.Duration)
+	// ManifestRead reports how long a plugin's manifest took to obtain,
+	// and whether it came from the cache.
+	ManifestRead(name string, d time.Duration, cached bool)
 }
 
 // NewLoader creates a plugin Loader.
-// stack may be nil to disable OCI fallback.
+// stack may be nil to disable OCI fallback. The workspace's plugins are
+// only loaded once the workspace is trusted, since they would otherwise
+// run with the grants of the plugins they shadow.
 func NewLoader(embeddedFS embed.FS, pluginsDir string, stack *PluginStack, defaultRegistry string) *Loader {
-	return &Loader{
+	l := &Loader{
 		embeddedFS: embeddedFS,
 		pluginsDir: pluginsDir,
+		quarantine: QuarantineDir(pluginsDir),
 		cachePath:  DefaultCachePath(),
 		stack:      stack,
 		defaultReg: defaultRegistry,
 	}
+	if dir := workspace.Dir(); dir != "" && workspace.Trusted(dir) {
+		l.workspaceDir = filepath.Join(dir, "plugins")
+	}
+	return l
+}
+
+// localDir is a directory plugin files are loaded from, with the source
+// its plugins are reported as.
+type localDir struct {
+	source string
+	path   string
+}
+
+// localDirs returns the directories plugin files are looked up in, in
+// precedence order: the trusted workspace's, then the user's. The
+// workspace's is left out when the policy does not allow its plugins.
+func (l *Loader) localDirs() []localDir {
+	dirs := make([]localDir, 0, 2)
+	if l.workspaceDir != "" && ActivePolicy().CheckWorkspacePlugins() == nil {
+		dirs = append(dirs, localDir{source: "workspace", path: l.workspaceDir})
+	}
+	return append(dirs, localDir{source: "local", path: l.pluginsDir})
+}
+
+// UseRunner makes the loader read manifests with a shared runner rather
+// than creating one per plugin.
+func (l *Loader) UseRunner(r *runtime.SharedRunner) *Loader {
+	l.runner = r
+	return l
+}
+
+// TraceWith makes the loader report discovery timings to t.
+func (l *Loader) TraceWith(t DiscoveryTracer) *Loader {
+	l.tracer = t
+	return l
 }
 
 // DiscoverAll finds and loads all available plugins.
 func (l *Loader) DiscoverAll(ctx context.Context) ([]DiscoveredPlugin, error) {
+	start := time.Now()
 	cache := LoadCache(l.cachePath)
+	l.servedWarm = false
+	if l.tracer != nil {
+		l.tracer.CacheLoaded(time.Since(start))
+	}
 	plugins := make(map[string]DiscoveredPlugin)
 	cacheUpdated := false
 
@@ -61,7 +134,8 @@ func (l *Loader) DiscoverAll(ctx context.Context) ([]DiscoveredPlugin, error) {
 		cacheUpdated = true
 	}
 
-	// 2. Load local plugins (override embedded if same name)
+	// 2. Load local plugins (override embedded if same name, and workspace
+	// plugins override the user's)
 	local, updatedL, err := l.loadLocalPlugins(ctx, cache)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -69,47 +143,125 @@ func (l *Loader) DiscoverAll(ctx context.Context) ([]DiscoveredPlugin, error) {
 		}
 	}
 	for _, p := range local {
+		if prev, ok := plugins[p.Manifest.Name]; ok && p.Source == "workspace" && prev.Source != "workspace" {
+			warnShadowed(p.Manifest.Name, p.Path, prev.Source)
+		}
 		plugins[p.Manifest.Name] = p
 	}
 	if updatedL {
 		cacheUpdated = true
 	}
 
+	// A run that listed nothing from Paths read every binary
+	if cache.RefreshedAt.IsZero() && !l.servedWarm {
+		cache.RefreshedAt = time.Now()
+		cacheUpdated = true
+	}
+	l.refreshedAt = cache.RefreshedAt
+
+	// Drop entries for binaries that are gone
+	seen := make(map[string]bool, len(embedded)+len(local))
+	for _, p := range append(embedded, local...) {
+		seen[p.Digest] = true
+	}
+	if cache.Prune(seen) {
+		cacheUpdated = true
+	}
+
 	// Save cache if updated
 	if cacheUpdated {
 		_ = cache.Save(l.cachePath)
 	}
 
-	// Convert map to slice
+	// Convert map to slice, leaving out plugins the policy blocks
+	policy := ActivePolicy()
 	result := make([]DiscoveredPlugin, 0, len(plugins))
 	for _, p := range plugins {
+		if policy.CheckPlugin(p.Manifest.Name) != nil {
+			continue
+		}
 		result = append(result, p)
 	}
 
 	return result, nil
 }
 
-// LoadByName loads a specific plugin by name or OCI reference.
+// Refresh rebuilds the discovery cache by reading every plugin binary again,
+// keeping install records, and returns the plugins found.
+func (l *Loader) Refresh(ctx context.Context) ([]DiscoveredPlugin, error) {
+	cache := LoadCache(l.cachePath)
+	cache.Files = make(map[string]CacheEntry)
+	cache.Paths = make(map[string]PathEntry)
+	cache.RefreshedAt = time.Now()
+	if err := cache.Save(l.cachePath); err != nil {
+		return nil, fmt.Errorf("saving discovery cache: %w", err)
+	}
+	return l.DiscoverAll(ctx)
+}
+
+// ClaimRefresh reports whether the last DiscoverAll listed plugins from the
+// cache and the cache was last refreshed more than maxAge ago. If so, it
+// marks the cache refreshed now, so other processes do not also start a
+// refresh; the caller is expected to run Refresh.
+func (l *Loader) ClaimRefresh(maxAge time.Duration) bool {
+	if !l.servedWarm || time.Since(l.refreshedAt) <= maxAge {
+		return false
+	}
+	cache := LoadCache(l.cachePath)
+	if time.Since(cache.RefreshedAt) <= maxAge {
+		return false
+	}
+	cache.RefreshedAt = time.Now()
+	return cache.Save(l.cachePath) == nil
+}
+
+// LoadByName loads a specific plugin by name, source-qualified name
+// ("community/dns"), or OCI reference.
+//
+// A source-qualified name loads the reference recorded when the plugin was
+// installed from that index. Other names resolve in order:
+//  1. Workspace, once trusted: .tack/plugins/<name>.wasm or <name>@*.wasm
+//  2. Local cache: ~/.tack/plugins/<name>.wasm or <name>@*.wasm
+//  3. Embedded: plugins/<name>.wasm
+//  4. OCI registry: <default_registry>/<name>:latest (if stack is configured)
 //
-// Resolution order:
-//  1. Local cache: ~/.cli/plugins/<name>.wasm or <name>@*.wasm
-//  2. Embedded: plugins/<name>.wasm
-//  3. OCI registry: <default_registry>/<name>:latest (if stack is configured)
+// Tags fetched from a registry are re-resolved at most once per tag cache
+// TTL; see RefreshTag.
 func (l *Loader) LoadByName(ctx context.Context, name string) (*DiscoveredPlugin, error) {
-	// 1. Check local cache (unversioned)
-	localPath := filepath.Join(l.pluginsDir, name+".wasm")
-	if _, err := os.Stat(localPath); err == nil {
-		return l.loadLocalFile(ctx, localPath)
+	dp, err := l.loadByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := ActivePolicy().CheckPlugin(dp.Manifest.Name); err != nil {
+		return nil, err
 	}
+	return dp, nil
+}
 
-	// Check local cache (versioned, pick latest alphabetically)
-	matches, _ := filepath.Glob(filepath.Join(l.pluginsDir, name+"@*.wasm"))
-	if len(matches) > 0 {
-		return l.loadLocalFile(ctx, matches[len(matches)-1])
+func (l *Loader) loadByName(ctx context.Context, name string) (*DiscoveredPlugin, error) {
+	if source, rest, ok := SplitQualifiedName(name); ok {
+		return l.loadQualified(ctx, source, rest)
 	}
 
-	// 2. Check embedded plugins
+	// 1. Check the workspace, then the local cache (unversioned, then
+	// versioned, picking the latest alphabetically)
 	embeddedPath := "plugins/" + name + ".wasm"
+	for _, dir := range l.localDirs() {
+		localPath := findLocalFile(dir.path, name)
+		if localPath == "" {
+			continue
+		}
+		if dir.source == "workspace" {
+			if findLocalFile(l.pluginsDir, name) != "" {
+				warnShadowed(name, localPath, "local")
+			} else if _, err := l.embeddedFS.Open(embeddedPath); err == nil {
+				warnShadowed(name, localPath, "embedded")
+			}
+		}
+		return l.loadLocalFile(ctx, dir.source, localPath)
+	}
+
+	// 2. Check embedded plugins
 	if _, err := l.embeddedFS.Open(embeddedPath); err == nil {
 		return l.loadEmbeddedFile(ctx, embeddedPath)
 	}
@@ -119,21 +271,79 @@ func (l *Loader) LoadByName(ctx context.Context, name string) (*DiscoveredPlugin
 		return l.loadFromOCI(ctx, name)
 	}
 
-	return nil, fmt.Errorf("plugin %q not found", name)
+	return nil, errcode.Errorf(errcode.PluginNotFound, "plugin %q not found", name)
+}
+
+// findLocalFile returns the file of the plugin name in dir: name.wasm, or
+// else the last name@<version>.wasm alphabetically. It returns "" when
+// there is none.
+func findLocalFile(dir, name string) string {
+	path := filepath.Join(dir, name+".wasm")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, name+"@*.wasm"))
+	if len(matches) > 0 {
+		return matches[len(matches)-1]
+	}
+	return ""
+}
+
+// warnShadowed reports a workspace plugin used instead of an installed or
+// embedded one of the same name, so a checkout cannot swap a binary
+// unnoticed.
+func warnShadowed(name, path, source string) {
+	fmt.Fprintf(os.Stderr, "Warning: using workspace plugin %s from %s instead of the %s one\n", name, path, source)
+}
+
+// loadQualified loads a plugin installed from the named index source.
+func (l *Loader) loadQualified(ctx context.Context, source, name string) (*DiscoveredPlugin, error) {
+	pluginName, _ := parseNameVersion(name)
+	record, ok := LoadCache(l.cachePath).Installed[pluginName]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("plugin %q is not installed from %q", pluginName, source)
+	case record.Source != source:
+		return nil, fmt.Errorf("plugin %q is installed as %q, not from %q", pluginName, record.QualifiedName(pluginName), source)
+	case l.stack == nil:
+		return nil, fmt.Errorf("plugin %q from %q requires the plugin service", pluginName, source)
+	}
+	return l.loadFromOCI(ctx, record.Reference)
 }
 
 // loadFromOCI resolves a plugin name to an OCI reference and loads it
 // via the host-sdk PluginService.
 func (l *Loader) loadFromOCI(ctx context.Context, name string) (*DiscoveredPlugin, error) {
 	ref := l.resolveOCIReference(name)
+	if err := ActivePolicy().CheckReference(ref); err != nil {
+		return nil, err
+	}
 
+	pluginRef, err := hostvalues.ParsePluginReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin reference %q: %w", ref, err)
+	}
+
+	// An auto-resolved newer version may not quietly widen what the
+	// plugin can reach
+	guard := l.GuardUpgrade(ctx, referenceName(ref))
+	if err := l.stack.RefreshTag(ctx, pluginRef, l.cachePath); err != nil {
+		return nil, fmt.Errorf("refreshing %s: %w", ref, err)
+	}
 	dto := &hostdto.PluginSpecDTO{Name: ref}
-	wasmPath, err := l.stack.Service.LoadPlugin(ctx, dto)
+	var wasmPath string
+	err = l.stack.QuarantineFailedPull(ctx, pluginRef, func() (err error) {
+		wasmPath, err = l.stack.Service.LoadPlugin(ctx, dto)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("loading plugin %q from OCI: %w", name, err)
 	}
+	if err := guard.Check(ctx, pluginRef, l.confirm); err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(wasmPath)
+	data, _, err := l.readVerified(wasmPath)
 	if err != nil {
 		return nil, fmt.Errorf("reading cached plugin: %w", err)
 	}
@@ -165,12 +375,12 @@ func parseNameVersion(s string) (string, string) {
 	return s, ""
 }
 
-func (l *Loader) loadLocalFile(ctx context.Context, path string) (*DiscoveredPlugin, error) {
-	data, err := os.ReadFile(path)
+func (l *Loader) loadLocalFile(ctx context.Context, source, path string) (*DiscoveredPlugin, error) {
+	data, _, err := l.readVerified(path)
 	if err != nil {
 		return nil, err
 	}
-	return l.loadPluginBytes(ctx, data, "local", path)
+	return l.loadPluginBytes(ctx, data, source, path)
 }
 
 func (l *Loader) loadEmbeddedFile(ctx context.Context, path string) (*DiscoveredPlugin, error) {
@@ -187,115 +397,204 @@ func (l *Loader) loadEmbeddedPlugins(ctx context.Context, cache *DiscoveryCache)
 		return nil, false, nil
 	}
 
-	var plugins []DiscoveredPlugin
-	updated := false
+	var paths []string
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".wasm") {
 			continue
 		}
+		paths = append(paths, "plugins/"+entry.Name())
+	}
 
-		path := "plugins/" + entry.Name()
-		info, err := entry.Info()
+	plugins, updated := l.discoverParallel(ctx, cache, paths, func(path string) (*DiscoveredPlugin, []byte, error) {
+		data, err := l.embeddedFS.ReadFile(path)
 		if err != nil {
-			continue
+			return nil, nil, err
 		}
-
 		cacheKey := "embedded://" + path
-		if cached, ok := cache.Files[cacheKey]; ok && cached.Size == info.Size() {
-			plugins = append(plugins, DiscoveredPlugin{
-				Manifest: cached.Manifest,
-				Loader:   func() ([]byte, error) { return l.embeddedFS.ReadFile(path) },
-				Source:   "embedded",
-				Path:     cacheKey,
-			})
-			continue
+		return &DiscoveredPlugin{
+			Loader: l.createOnDemandLoader("embedded", cacheKey),
+			Source: "embedded",
+			Path:   cacheKey,
+			Digest: ContentDigest(data),
+		}, data, nil
+	})
+	return plugins, updated, nil
+}
+
+// loadLocalPlugins discovers the plugin files in the user's plugins
+// directory and then the workspace's, so that, by name, the workspace's are
+// returned after (and override) the user's.
+func (l *Loader) loadLocalPlugins(ctx context.Context, cache *DiscoveryCache) ([]DiscoveredPlugin, bool, error) {
+	infos := make(map[string]os.FileInfo)
+	sources := make(map[string]string)
+	var paths []string
+	dirs := l.localDirs()
+	slices.Reverse(dirs)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir.path, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip directories we can't read
+			}
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".wasm") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			infos[path] = info
+			sources[path] = dir.source
+			paths = append(paths, path)
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, false, err
 		}
+	}
 
-		// Cache miss
-		data, err := l.embeddedFS.ReadFile(path)
-		if err != nil {
-			continue
+	// Files unchanged since the last run are listed from the cache without
+	// being read; their content is checked when they are first loaded.
+	var (
+		known   []DiscoveredPlugin
+		pending []string
+	)
+	for _, path := range paths {
+		start := time.Now()
+		if digest, ok := cache.KnownDigest(path, infos[path]); ok {
+			if manifest, ok := cache.Lookup(digest); ok {
+				l.traceManifest(manifest.Name, start, true)
+				known = append(known, DiscoveredPlugin{
+					Manifest: manifest,
+					Loader:   l.verifiedLoader(path, digest),
+					Source:   sources[path],
+					Path:     path,
+					Digest:   digest,
+				})
+				continue
+			}
 		}
-		p, err := l.loadPluginBytes(ctx, data, "embedded", cacheKey)
+		pending = append(pending, path)
+	}
+
+	read, updated := l.discoverParallel(ctx, cache, pending, func(path string) (*DiscoveredPlugin, []byte, error) {
+		data, digest, err := l.readVerified(path)
 		if err != nil {
-			continue
+			return nil, nil, err
 		}
+		return &DiscoveredPlugin{
+			Loader: l.createOnDemandLoader(sources[path], path),
+			Source: sources[path],
+			Path:   path,
+			Digest: digest,
+		}, data, nil
+	})
 
-		cache.Files[cacheKey] = CacheEntry{
-			Size:     info.Size(),
-			Manifest: p.Manifest,
-		}
+	for _, p := range read {
+		cache.RecordPath(p.Path, infos[p.Path], p.Digest)
 		updated = true
-		plugins = append(plugins, *p)
 	}
+	for path := range cache.Paths {
+		if _, ok := infos[path]; !ok {
+			delete(cache.Paths, path)
+			updated = true
+		}
+	}
+	l.servedWarm = len(known) > 0
 
+	// Listing and reading reorder files; put the workspace's back last
+	rank := func(p DiscoveredPlugin) int {
+		if p.Source == "workspace" {
+			return 1
+		}
+		return 0
+	}
+	plugins := append(known, read...)
+	slices.SortStableFunc(plugins, func(a, b DiscoveredPlugin) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
 	return plugins, updated, nil
 }
 
-func (l *Loader) loadLocalPlugins(ctx context.Context, cache *DiscoveryCache) ([]DiscoveredPlugin, bool, error) {
-	var plugins []DiscoveredPlugin
-	updated := false
-
-	err := filepath.WalkDir(l.pluginsDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip directories we can't read
+// verifiedLoader reads a plugin that was listed from the cache without
+// being read, refusing it if its content no longer has the cached digest.
+// The stale entry is dropped so the next run reads the file again.
+func (l *Loader) verifiedLoader(path, digest string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		data, got, err := l.readVerified(path)
+		if err == nil && got == digest {
+			return data, nil
 		}
-		if d.IsDir() || !strings.HasSuffix(d.Name(), ".wasm") {
-			return nil
+		cache := LoadCache(l.cachePath)
+		delete(cache.Paths, path)
+		_ = cache.Save(l.cachePath)
+		if err != nil {
+			return nil, err
 		}
+		return nil, fmt.Errorf("plugin %s changed since it was discovered; run the command again", path)
+	}
+}
 
-		info, err := d.Info()
+// discoverParallel reads each path with read and fills in its manifest,
+// from the cache when the digest is known and otherwise by loading the
+// binary. Paths are processed on up to NumCPU goroutines; a path that
+// fails to read or load is skipped without affecting the others. Plugins
+// are returned in path order, with whether the cache was updated.
+func (l *Loader) discoverParallel(ctx context.Context, cache *DiscoveryCache, paths []string, read func(path string) (*DiscoveredPlugin, []byte, error)) ([]DiscoveredPlugin, bool) {
+	results := make([]*DiscoveredPlugin, len(paths))
+	var (
+		mu      sync.Mutex
+		updated bool
+	)
+
+	workers.ForEach(len(paths), goruntime.NumCPU(), func(i int) {
+		start := time.Now()
+		p, data, err := read(paths[i])
 		if err != nil {
-			return nil
+			return
 		}
 
-		if cached, ok := cache.Files[path]; ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
-			plugins = append(plugins, DiscoveredPlugin{
-				Manifest: cached.Manifest,
-				Loader:   func() ([]byte, error) { return os.ReadFile(path) },
-				Source:   "local",
-				Path:     path,
-			})
-			return nil
+		mu.Lock()
+		manifest, ok := cache.Lookup(p.Digest)
+		mu.Unlock()
+		if ok {
+			p.Manifest = manifest
+			results[i] = p
+			l.traceManifest(manifest.Name, start, true)
+			return
 		}
 
 		// Cache miss
-		data, err := os.ReadFile(path)
+		loaded, err := l.loadPluginBytes(ctx, data, p.Source, p.Path)
 		if err != nil {
-			return nil
-		}
-		p, err := l.loadPluginBytes(ctx, data, "local", path)
-		if err != nil {
-			return nil
-		}
-
-		cache.Files[path] = CacheEntry{
-			ModTime:  info.ModTime(),
-			Size:     info.Size(),
-			Manifest: p.Manifest,
+			return
 		}
+		mu.Lock()
+		cache.Put(loaded.Digest, loaded.Manifest)
 		updated = true
-		plugins = append(plugins, *p)
-		return nil
+		mu.Unlock()
+		results[i] = loaded
+		l.traceManifest(loaded.Manifest.Name, start, false)
 	})
 
-	if err != nil && !os.IsNotExist(err) {
-		return nil, false, err
+	var plugins []DiscoveredPlugin
+	for _, p := range results {
+		if p != nil {
+			plugins = append(plugins, *p)
+		}
 	}
-
-	return plugins, updated, nil
+	return plugins, updated
 }
 
-func (l *Loader) loadPluginBytes(ctx context.Context, data []byte, source, path string) (*DiscoveredPlugin, error) {
-	// We trust plugins during discovery because we only read the manifest
-	// and do not execute any operations.
-	runner, err := runtime.NewPluginRunner(ctx, runtime.WithTrustPlugins(true))
-	if err != nil {
-		return nil, err
+// traceManifest reports the time since start spent obtaining a manifest.
+func (l *Loader) traceManifest(name string, start time.Time, cached bool) {
+	if l.tracer != nil {
+		l.tracer.ManifestRead(name, time.Since(start), cached)
 	}
-	defer func() { _ = runner.Close(ctx) }()
+}
 
-	loaded, err := runner.LoadPlugin(ctx, data)
+func (l *Loader) loadPluginBytes(ctx context.Context, data []byte, source, path string) (*DiscoveredPlugin, error) {
+	digest := ContentDigest(data)
+	manifest, err := l.readManifest(ctx, digest, data)
 	if err != nil {
 		return nil, err
 	}
@@ -306,13 +605,94 @@ func (l *Loader) loadPluginBytes(ctx context.Context, data []byte, source, path
 	loader := l.createOnDemandLoader(source, path)
 
 	return &DiscoveredPlugin{
-		Manifest: loaded.Manifest,
+		Manifest: manifest,
 		Loader:   loader,
 		Source:   source,
 		Path:     path,
+		Digest:   digest,
 	}, nil
 }
 
+// readManifest returns a plugin binary's manifest, from its manifest
+// section when it has one and otherwise by instantiating it. No
+// capabilities are granted and no operation runs. With a shared runner the
+// instance is kept, so running the plugin later in this process does not
+// read and instantiate the binary again. A section's capabilities are only
+// a listing; see ModuleManifest.
+func (l *Loader) readManifest(ctx context.Context, digest string, data []byte) (abi.Manifest, error) {
+	// A malformed section is ignored; the module itself has the final say
+	if manifest, ok, err := ReadManifestSection(data); err == nil && ok {
+		return manifest, nil
+	}
+
+	if l.runner != nil {
+		runner, err := l.runner.Get(ctx)
+		if err != nil {
+			return abi.Manifest{}, err
+		}
+		return runner.Preload(ctx, digest, data)
+	}
+
+	runner, err := runtime.NewPluginRunner(ctx)
+	if err != nil {
+		return abi.Manifest{}, err
+	}
+	defer func() { _ = runner.Close(ctx) }()
+	return runner.ReadManifest(ctx, data)
+}
+
+// ErrDigestMismatch marks an installed plugin binary whose content no longer
+// matches the digest recorded when it was installed.
+var ErrDigestMismatch = errcode.New(errcode.DigestMismatch, "plugin binary does not match its recorded digest")
+
+// readPluginFile reads a plugin binary and returns it with its content
+// digest. Binaries installed into the plugin repository (plugin.wasm next
+// to digest.txt) are checked against the digest recorded at install time,
+// so a binary modified afterwards is refused rather than trusted.
+func readPluginFile(path string) ([]byte, string, error) {
+	data, digest, err := readDigested(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if filepath.Base(path) != "plugin.wasm" {
+		return data, digest, nil
+	}
+	recorded, err := os.ReadFile(filepath.Join(filepath.Dir(path), "digest.txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, digest, nil
+		}
+		return nil, "", err
+	}
+	if want := strings.TrimSpace(string(recorded)); want != digest {
+		return nil, "", fmt.Errorf("%w: recorded %s, found %s", ErrDigestMismatch, want, digest)
+	}
+	return data, digest, nil
+}
+
+// ReadInstalled reads an installed plugin binary, decompressed, with the
+// digest of the file on disk. A binary that no longer matches the digest
+// recorded at install time is refused with ErrDigestMismatch.
+func ReadInstalled(path string) ([]byte, string, error) {
+	return readPluginFile(path)
+}
+
+// readVerified is readPluginFile, quarantining a binary that no longer
+// matches its recorded digest so it is left out of discovery from then on.
+func (l *Loader) readVerified(path string) ([]byte, string, error) {
+	data, digest, err := readPluginFile(path)
+	if !errors.Is(err, ErrDigestMismatch) {
+		return data, digest, err
+	}
+	record, qErr := Quarantine(l.quarantine, path, err.Error())
+	if qErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+		return nil, "", errors.Join(err, qErr)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: quarantined %s as %s: %v\n", path, record.ID, err)
+	return nil, "", fmt.Errorf("%w (quarantined as %s)", err, record.ID)
+}
+
 // createOnDemandLoader creates a loader function that reads WASM bytes from
 // the source (disk/FS) on demand, rather than caching them in memory.
 func (l *Loader) createOnDemandLoader(source, path string) func() ([]byte, error) {
@@ -323,9 +703,9 @@ func (l *Loader) createOnDemandLoader(source, path string) func() ([]byte, error
 		return func() ([]byte, error) {
 			return l.embeddedFS.ReadFile(fsPath)
 		}
-	case "local", "oci":
+	case "local", "workspace", "oci":
 		return func() ([]byte, error) {
-			return os.ReadFile(path)
+			return readArtifact(path, nil)
 		}
 	default:
 		return func() ([]byte, error) {
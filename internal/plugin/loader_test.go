@@ -3,9 +3,15 @@ package plugin
 import (
 	"context"
 	"embed"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
 )
 
 func TestLoader_LoadLocalPlugins(t *testing.T) {
@@ -55,6 +61,82 @@ func TestLoader_LoadLocalPlugins(t *testing.T) {
 	}
 }
 
+func TestLoader_WorkspacePluginsOverride(t *testing.T) {
+	wasmData, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+
+	dir := t.TempDir()
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "user"), nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	loader.workspaceDir = filepath.Join(dir, "workspace")
+	for _, d := range []string{loader.pluginsDir, loader.workspaceDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(d, "fixture.wasm"), wasmData, 0o644)
+	}
+	workspacePath := filepath.Join(loader.workspaceDir, "fixture.wasm")
+
+	// Both a cold and a warm discovery prefer the workspace's copy
+	for range 2 {
+		plugins, err := loader.DiscoverAll(context.Background())
+		if err != nil {
+			t.Fatalf("DiscoverAll: %v", err)
+		}
+		if len(plugins) != 1 || plugins[0].Source != "workspace" || plugins[0].Path != workspacePath {
+			t.Fatalf("expected the workspace's fixture, got %+v", plugins)
+		}
+	}
+
+	dp, err := loader.LoadByName(context.Background(), "fixture")
+	if err != nil {
+		t.Fatalf("LoadByName: %v", err)
+	}
+	if dp.Source != "workspace" || dp.Path != workspacePath {
+		t.Errorf("LoadByName = %s %s, want workspace %s", dp.Source, dp.Path, workspacePath)
+	}
+
+	// A policy that vouches for where plugins come from leaves the
+	// workspace's out
+	SetPolicy(&Policy{RequireSignatures: true})
+	t.Cleanup(func() { SetPolicy(nil) })
+	plugins, err := loader.DiscoverAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Source != "local" {
+		t.Errorf("expected the user's fixture under the policy, got %+v", plugins)
+	}
+	if dp, err := loader.LoadByName(context.Background(), "fixture"); err != nil || dp.Source != "local" {
+		t.Errorf("LoadByName under the policy = %+v, %v; want the user's fixture", dp, err)
+	}
+}
+
+func TestNewLoader_UntrustedWorkspace(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	t.Chdir(project)
+	ws := filepath.Join(project, workspace.DirName)
+	if err := os.MkdirAll(filepath.Join(ws, "plugins"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A checked-out repository's plugins would run with the grants of the
+	// ones they shadow
+	if dir := NewLoader(embed.FS{}, t.TempDir(), nil, "").workspaceDir; dir != "" {
+		t.Errorf("expected an untrusted workspace's plugins to be ignored, got %s", dir)
+	}
+
+	if err := workspace.Trust(ws); err != nil {
+		t.Fatal(err)
+	}
+	if dir, want := NewLoader(embed.FS{}, t.TempDir(), nil, "").workspaceDir, filepath.Join(ws, "plugins"); dir != want {
+		t.Errorf("workspaceDir = %q, want %q once trusted", dir, want)
+	}
+}
+
 func TestDefaultPluginsDir(t *testing.T) {
 	dir := DefaultPluginsDir()
 	if dir == "" {
@@ -139,3 +221,209 @@ func TestLoader_OnDemandLoading(t *testing.T) {
 		t.Error("Loader did not read modified file from disk (still using cached bytes)")
 	}
 }
+
+func TestSplitQualifiedName(t *testing.T) {
+	tests := []struct {
+		in, source, name string
+		ok               bool
+	}{
+		{"community/dns", "community", "dns", true},
+		{"community/dns@1.2.0", "community", "dns@1.2.0", true},
+		{"dns", "", "dns", false},
+		{"ghcr.io/org/dns:1.0.0", "", "ghcr.io/org/dns:1.0.0", false},
+		{"localhost:5000/dns", "", "localhost:5000/dns", false},
+		{"localhost/dns", "", "localhost/dns", false},
+		{"a/b/c", "", "a/b/c", false},
+	}
+	for _, tt := range tests {
+		source, name, ok := SplitQualifiedName(tt.in)
+		if source != tt.source || name != tt.name || ok != tt.ok {
+			t.Errorf("SplitQualifiedName(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.in, source, name, ok, tt.source, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestLoader_LoadQualified(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	cache := NewDiscoveryCache()
+	if _, existed := cache.RecordInstall("dns", InstallRecord{Source: "community", Reference: "ghcr.io/community/dns:1.0.0"}); existed {
+		t.Fatal("RecordInstall reported a previous record for an empty cache")
+	}
+	if err := cache.Save(cachePath); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if got := LoadCache(cachePath).Installed["dns"]; got.QualifiedName("dns") != "community/dns" {
+		t.Errorf("reloaded record = %+v", got)
+	}
+
+	loader := NewLoader(embed.FS{}, t.TempDir(), nil, "")
+	loader.cachePath = cachePath
+
+	for name, want := range map[string]string{
+		"official/dns":  `installed as "community/dns"`,
+		"community/tcp": `not installed from "community"`,
+		"community/dns": "requires the plugin service",
+	} {
+		_, err := loader.LoadByName(context.Background(), name)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadByName(%q) error = %v, want containing %q", name, err, want)
+		}
+	}
+}
+
+func TestLoader_ContentDigestCache(t *testing.T) {
+	wasmData, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+
+	dir := t.TempDir()
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+
+	// An installed plugin with the digest recorded at install time, and a
+	// copy of the same binary under another name.
+	installed := filepath.Join(loader.pluginsDir, "fixture")
+	if err := os.MkdirAll(installed, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	digest := ContentDigest(wasmData)
+	_ = os.WriteFile(filepath.Join(installed, "plugin.wasm"), wasmData, 0o644)
+	_ = os.WriteFile(filepath.Join(installed, "digest.txt"), []byte(digest), 0o600)
+	_ = os.WriteFile(filepath.Join(loader.pluginsDir, "copy.wasm"), wasmData, 0o644)
+
+	plugins, err := loader.DiscoverAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Digest != digest {
+		t.Fatalf("expected one plugin with digest %s, got %+v", digest, plugins)
+	}
+	cache := LoadCache(loader.cachePath)
+	if _, ok := cache.Lookup(digest); !ok || len(cache.Files) != 1 {
+		t.Fatalf("expected a single cache entry keyed by digest, got %v", cache.Files)
+	}
+
+	// A same-size edit of the installed binary no longer matches its
+	// recorded digest and is skipped.
+	tampered := append([]byte(nil), wasmData...)
+	tampered[len(tampered)-1] ^= 0xff
+	_ = os.WriteFile(filepath.Join(installed, "plugin.wasm"), tampered, 0o644)
+	if _, _, err := readPluginFile(filepath.Join(installed, "plugin.wasm")); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected ErrDigestMismatch, got %v", err)
+	}
+	if _, err := loader.loadLocalFile(context.Background(), "local", filepath.Join(installed, "plugin.wasm")); err == nil {
+		t.Error("expected loading a tampered plugin to fail")
+	}
+}
+
+func TestLoadCache_DropsLegacyEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	legacy := `{"files": {"/plugins/dns.wasm": {"mod_time": "2024-01-01T00:00:00Z", "size": 10, "manifest": {"name": "dns"}},
+		"sha256:abc": {"digest": "sha256:abc", "manifest": {"name": "tcp"}}}}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := LoadCache(path)
+	if len(cache.Files) != 1 {
+		t.Fatalf("expected only the digest-keyed entry, got %v", cache.Files)
+	}
+	if m, ok := cache.Lookup("sha256:abc"); !ok || m.Name != "tcp" {
+		t.Errorf("Lookup = %+v, %v", m, ok)
+	}
+}
+
+func TestLoader_WarmCacheSkipsReads(t *testing.T) {
+	wasmData, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+
+	dir := t.TempDir()
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	path := filepath.Join(loader.pluginsDir, "fixture.wasm")
+	_ = os.MkdirAll(loader.pluginsDir, 0o755)
+	if err := os.WriteFile(path, wasmData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.DiscoverAll(context.Background()); err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	info, _ := os.Stat(path)
+	if digest, ok := LoadCache(loader.cachePath).KnownDigest(path, info); !ok || digest != ContentDigest(wasmData) {
+		t.Fatalf("expected the path to be recorded, got %q, %v", digest, ok)
+	}
+
+	// Rewrite the file with the same size and modification time: the warm
+	// start lists it from the cache, and loading it notices the change.
+	tampered := append([]byte(nil), wasmData...)
+	tampered[len(tampered)-1] ^= 0xff
+	_ = os.WriteFile(path, tampered, 0o644)
+	_ = os.Chtimes(path, info.ModTime(), info.ModTime())
+
+	plugins, err := loader.DiscoverAll(context.Background())
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("DiscoverAll = %v, %v", plugins, err)
+	}
+	if _, err := plugins[0].Loader(); err == nil || !strings.Contains(err.Error(), "changed since it was discovered") {
+		t.Errorf("expected a changed-plugin error, got %v", err)
+	}
+	if _, ok := LoadCache(loader.cachePath).Paths[path]; ok {
+		t.Error("expected the stale path entry to be dropped")
+	}
+}
+
+func TestLoader_RefreshAndClaim(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	_ = os.MkdirAll(loader.pluginsDir, 0o755)
+	wasm, err := EmbedManifestSection(emptyModule, abi.Manifest{Name: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(loader.pluginsDir, "ping.wasm"), wasm, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cold run reads every binary, which counts as a refresh
+	if _, err := loader.DiscoverAll(context.Background()); err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if loader.ClaimRefresh(0) {
+		t.Error("expected no refresh to be claimed after a cold run")
+	}
+
+	cache := LoadCache(loader.cachePath)
+	cache.RecordInstall("ping", InstallRecord{Source: "community", Reference: "example.com/ping:1.0.0"})
+	cache.RefreshedAt = time.Now().Add(-48 * time.Hour)
+	if err := cache.Save(loader.cachePath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loader.DiscoverAll(context.Background()); err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if !loader.ClaimRefresh(24 * time.Hour) {
+		t.Fatal("expected a warm run on a day-old cache to claim a refresh")
+	}
+	if loader.ClaimRefresh(24 * time.Hour) {
+		t.Error("expected a claimed refresh not to be claimed again")
+	}
+
+	plugins, err := loader.Refresh(context.Background())
+	if err != nil || len(plugins) != 1 || plugins[0].Manifest.Name != "ping" {
+		t.Fatalf("Refresh = %+v, %v", plugins, err)
+	}
+	cache = LoadCache(loader.cachePath)
+	if cache.Installed["ping"].Source != "community" {
+		t.Error("expected install records to survive a refresh")
+	}
+	if len(cache.Files) != 1 || len(cache.Paths) != 1 {
+		t.Errorf("expected the cache to be rebuilt, got %d files and %d paths", len(cache.Files), len(cache.Paths))
+	}
+}
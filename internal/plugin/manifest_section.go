@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// ManifestSection is the name of the optional WASM custom section holding
+// a plugin's manifest as JSON. Discovery reads it instead of instantiating
+// the module; plugins without it are instantiated as before. The section
+// is only trusted for listing a plugin: its capabilities are checked
+// against the module's own manifest whenever the module is instantiated.
+const ManifestSection = "tack.manifest"
+
+// ErrManifestMismatch marks a plugin whose manifest section does not match
+// the manifest its module reports, so the section cannot be trusted.
+var ErrManifestMismatch = errcode.New(errcode.ManifestMismatch, "plugin manifest section does not match its module")
+
+func init() {
+	runtime.SetManifestCheck(CheckManifestSection)
+}
+
+var wasmHeader = []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+// wasmSection is a section of a WASM binary. start and end bound the whole
+// section, id and size included.
+type wasmSection struct {
+	id         byte
+	name       string // custom sections only
+	payload    []byte
+	start, end int
+}
+
+// wasmSections splits a WASM binary into its sections.
+func wasmSections(wasm []byte) ([]wasmSection, error) {
+	if !bytes.HasPrefix(wasm, wasmHeader) {
+		return nil, errors.New("not a WASM binary")
+	}
+	var sections []wasmSection
+	for pos := len(wasmHeader); pos < len(wasm); {
+		start := pos
+		id := wasm[pos]
+		size, n := binary.Uvarint(wasm[pos+1:])
+		if n <= 0 || size > uint64(len(wasm)-pos-1-n) {
+			return nil, fmt.Errorf("malformed section at offset %d", start)
+		}
+		pos += 1 + n
+		body := wasm[pos : pos+int(size)]
+		pos += int(size)
+
+		s := wasmSection{id: id, payload: body, start: start, end: pos}
+		if id == 0 {
+			nameLen, m := binary.Uvarint(body)
+			if m <= 0 || nameLen > uint64(len(body)-m) {
+				return nil, fmt.Errorf("malformed custom section at offset %d", start)
+			}
+			s.name = string(body[m : m+int(nameLen)])
+			s.payload = body[m+int(nameLen):]
+		}
+		sections = append(sections, s)
+	}
+	return sections, nil
+}
+
+// ReadManifestSection returns the manifest embedded in a WASM binary's
+// ManifestSection. ok is false when the binary has no such section.
+func ReadManifestSection(wasm []byte) (manifest abi.Manifest, ok bool, err error) {
+	sections, err := wasmSections(wasm)
+	if err != nil {
+		return abi.Manifest{}, false, err
+	}
+	for _, s := range sections {
+		if s.id != 0 || s.name != ManifestSection {
+			continue
+		}
+		if err := json.Unmarshal(s.payload, &manifest); err != nil {
+			return abi.Manifest{}, false, fmt.Errorf("parsing %s section: %w", ManifestSection, err)
+		}
+		return manifest, true, nil
+	}
+	return abi.Manifest{}, false, nil
+}
+
+// CheckManifestSection returns ErrManifestMismatch when wasm has a manifest
+// section naming another plugin or other capabilities than manifest, the
+// manifest its instantiated module reports. Binaries without a readable
+// section pass, since nothing was read from one.
+func CheckManifestSection(wasm []byte, manifest abi.Manifest) error {
+	section, ok, err := ReadManifestSection(wasm)
+	if err != nil || !ok {
+		return nil
+	}
+	if section.Name != manifest.Name {
+		return fmt.Errorf("%s: %w: the section names %q", manifest.Name, ErrManifestMismatch, section.Name)
+	}
+	unlisted := AddedCapabilities(&section.Capabilities, &manifest.Capabilities)
+	extra := AddedCapabilities(&manifest.Capabilities, &section.Capabilities)
+	if len(unlisted) == 0 && len(extra) == 0 {
+		return nil
+	}
+	var diffs []string
+	if len(unlisted) > 0 {
+		diffs = append(diffs, "the module requests "+strings.Join(unlisted, ", ")+" not in the section")
+	}
+	if len(extra) > 0 {
+		diffs = append(diffs, "the section lists "+strings.Join(extra, ", ")+" the module does not request")
+	}
+	return fmt.Errorf("%s: %w: %s", manifest.Name, ErrManifestMismatch, strings.Join(diffs, "; "))
+}
+
+// ModuleManifest returns the manifest dp's module reports when
+// instantiated. Plugins discovered through their manifest section are
+// instantiated in a throwaway runtime to read it, failing with
+// ErrManifestMismatch when it differs from the section; the manifest of
+// other plugins was read from the module already. Use it rather than
+// dp.Manifest to decide or report what a plugin may access.
+func ModuleManifest(ctx context.Context, dp DiscoveredPlugin) (abi.Manifest, error) {
+	data, err := dp.Loader()
+	if err != nil {
+		return abi.Manifest{}, err
+	}
+	if _, ok, err := ReadManifestSection(data); err != nil || !ok {
+		return dp.Manifest, nil
+	}
+	runner, err := runtime.NewPluginRunner(ctx)
+	if err != nil {
+		return abi.Manifest{}, err
+	}
+	defer func() { _ = runner.Close(ctx) }()
+	return runner.ReadManifest(ctx, data)
+}
+
+// EmbedManifestSection returns wasm with manifest stored in its
+// ManifestSection, replacing any section already there.
+func EmbedManifestSection(wasm []byte, manifest abi.Manifest) ([]byte, error) {
+	sections, err := wasmSections(wasm)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	out := append([]byte(nil), wasmHeader...)
+	for _, s := range sections {
+		if s.id == 0 && s.name == ManifestSection {
+			continue
+		}
+		out = append(out, wasm[s.start:s.end]...)
+	}
+
+	// Custom sections may appear anywhere; this one goes last
+	body := binary.AppendUvarint(nil, uint64(len(ManifestSection)))
+	body = append(body, ManifestSection...)
+	body = append(body, payload...)
+	out = append(out, 0)
+	out = binary.AppendUvarint(out, uint64(len(body)))
+	return append(out, body...), nil
+}
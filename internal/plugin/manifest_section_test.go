@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// emptyModule is the smallest valid WASM module. It exports no _manifest,
+// so it can only be discovered through its manifest section.
+var emptyModule = []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+// manifestModule builds a WASM module whose _manifest export reports
+// manifest, as a plugin built with the SDK would, and does nothing else.
+func manifestModule(t *testing.T, manifest abi.Manifest) []byte {
+	t.Helper()
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := func(id byte, body ...byte) []byte {
+		return append(binary.AppendUvarint([]byte{id}, uint64(len(body))), body...)
+	}
+	name := func(s string) []byte { return append(binary.AppendUvarint(nil, uint64(len(s))), s...) }
+
+	// _manifest returns the JSON's offset (0) and length packed into an
+	// i64; the length is a positive signed LEB128 constant
+	code := []byte{0x00, 0x42}
+	for n := len(payload); ; n >>= 7 {
+		if n < 0x40 {
+			code = append(code, byte(n))
+			break
+		}
+		code = append(code, byte(n&0x7f)|0x80)
+	}
+	code = append(code, 0x0b)
+	data := append([]byte{0x01, 0x00, 0x41, 0x00, 0x0b}, binary.AppendUvarint(nil, uint64(len(payload)))...)
+	data = append(data, payload...)
+	exports := append([]byte{0x02}, name("memory")...)
+	exports = append(exports, 0x02, 0x00)
+	exports = append(exports, name("_manifest")...)
+	exports = append(exports, 0x00, 0x00)
+
+	wasm := append([]byte(nil), emptyModule...)
+	wasm = append(wasm, section(1, 0x01, 0x60, 0x00, 0x01, 0x7e)...) // type () -> i64
+	wasm = append(wasm, section(3, 0x01, 0x00)...)                   // one function
+	wasm = append(wasm, section(5, 0x01, 0x00, 0x01)...)             // one page of memory
+	wasm = append(wasm, section(7, exports...)...)
+	wasm = append(wasm, section(10, append([]byte{0x01}, append(binary.AppendUvarint(nil, uint64(len(code))), code...)...)...)...)
+	return append(wasm, section(11, data...)...)
+}
+
+func TestManifestSection_RoundTrip(t *testing.T) {
+	if _, ok, err := ReadManifestSection(emptyModule); ok || err != nil {
+		t.Fatalf("expected no section, got ok=%v err=%v", ok, err)
+	}
+
+	wasm, err := EmbedManifestSection(emptyModule, abi.Manifest{Name: "ping", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("EmbedManifestSection: %v", err)
+	}
+	// Embedding again replaces the section
+	wasm, err = EmbedManifestSection(wasm, abi.Manifest{Name: "ping", Version: "1.1.0"})
+	if err != nil {
+		t.Fatalf("EmbedManifestSection: %v", err)
+	}
+	sections, err := wasmSections(wasm)
+	if err != nil || len(sections) != 1 {
+		t.Fatalf("expected one section, got %d (%v)", len(sections), err)
+	}
+
+	manifest, ok, err := ReadManifestSection(wasm)
+	if err != nil || !ok || manifest.Name != "ping" || manifest.Version != "1.1.0" {
+		t.Errorf("ReadManifestSection = %+v, %v, %v", manifest, ok, err)
+	}
+
+	if _, _, err := ReadManifestSection(wasm[:len(wasm)-3]); err == nil {
+		t.Error("expected an error for a truncated binary")
+	}
+	if _, _, err := ReadManifestSection([]byte("wasm")); err == nil {
+		t.Error("expected an error for a non-WASM file")
+	}
+}
+
+func TestLoader_ReadsManifestSection(t *testing.T) {
+	dir := t.TempDir()
+	pluginsDir := filepath.Join(dir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wasm, err := EmbedManifestSection(emptyModule, abi.Manifest{Name: "ping", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginsDir, "ping.wasm"), wasm, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader(embed.FS{}, pluginsDir, nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	plugins, err := loader.DiscoverAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Manifest.Name != "ping" || plugins[0].Manifest.Version != "1.0.0" {
+		t.Errorf("expected ping from its manifest section, got %+v", plugins)
+	}
+}
+
+func TestCheckManifestSection(t *testing.T) {
+	network := hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}}}
+	module := abi.Manifest{Name: "ping", Version: "1.0.0", Capabilities: network}
+
+	if err := CheckManifestSection(emptyModule, module); err != nil {
+		t.Errorf("expected a binary without a section to pass, got %v", err)
+	}
+	tests := []struct {
+		name    string
+		section abi.Manifest
+		ok      bool
+	}{
+		{"same", module, true},
+		{"other version", abi.Manifest{Name: "ping", Version: "0.9.0", Capabilities: network}, true},
+		{"capabilities left out", abi.Manifest{Name: "ping", Version: "1.0.0"}, false},
+		{"capabilities added", abi.Manifest{Name: "ping", Capabilities: hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"sh"}}}}, false},
+		{"other plugin", abi.Manifest{Name: "pong", Capabilities: network}, false},
+	}
+	for _, tt := range tests {
+		wasm, err := EmbedManifestSection(emptyModule, tt.section)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CheckManifestSection(wasm, module)
+		if tt.ok != (err == nil) || (err != nil && !errors.Is(err, ErrManifestMismatch)) {
+			t.Errorf("%s: CheckManifestSection = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestManifestSection_RefusedWhenModuleDisagrees(t *testing.T) {
+	fixture, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+	ctx := context.Background()
+
+	// The fixture requests network access; its section claims none
+	wasm, err := EmbedManifestSection(fixture, abi.Manifest{Name: "fixture", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	pluginsDir := filepath.Join(dir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginsDir, "fixture.wasm"), wasm, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoader(embed.FS{}, pluginsDir, nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	dp, err := loader.LoadByName(ctx, "fixture")
+	if err != nil {
+		t.Fatalf("LoadByName: %v", err)
+	}
+	if !dp.Manifest.Capabilities.IsEmpty() {
+		t.Fatalf("expected the section's manifest for listing, got %+v", dp.Manifest.Capabilities)
+	}
+
+	if _, err := ModuleManifest(ctx, *dp); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("ModuleManifest: expected ErrManifestMismatch, got %v", err)
+	}
+	runner, err := runtime.NewPluginRunner(ctx, runtime.WithTrustPlugins(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+	if _, err := runner.LoadPluginFor(ctx, dp.Digest, dp.Loader); !errors.Is(err, ErrManifestMismatch) {
+		t.Errorf("LoadPluginFor: expected ErrManifestMismatch, got %v", err)
+	}
+
+	// A truthful section passes
+	module, err := runner.ReadManifest(ctx, fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wasm, err = EmbedManifestSection(fixture, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.LoadPlugin(ctx, wasm); err != nil {
+		t.Errorf("expected a matching section to load, got %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+)
+
+// OCILayoutPrefix marks install targets that name an OCI image-layout
+// directory instead of a registry reference.
+const OCILayoutPrefix = "oci-layout:"
+
+// RegistryAdapter implements ports.PluginRegistry with oras-go. Unlike the
+// host SDK adapter, it reaches registries listed as plain HTTP without TLS.
+type RegistryAdapter struct {
+	auth      ports.AuthProvider
+	plainHTTP map[string]bool
+
+	mu     sync.Mutex
+	pulled map[string]Artifact // artifact, without its binary, pulled for each reference
+}
+
+// NewRegistryAdapter returns a registry adapter that talks plain HTTP to
+// the given registry hosts (host or host:port).
+func NewRegistryAdapter(auth ports.AuthProvider, plainHTTP []string) *RegistryAdapter {
+	hosts := make(map[string]bool, len(plainHTTP))
+	for _, h := range plainHTTP {
+		hosts[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	return &RegistryAdapter{auth: auth, plainHTTP: hosts, pulled: map[string]Artifact{}}
+}
+
+// PlainHTTP reports whether the registry host is reached over plain HTTP.
+func (a *RegistryAdapter) PlainHTTP(host string) bool {
+	return a.plainHTTP[strings.ToLower(host)]
+}
+
+// Pull downloads a plugin artifact from its registry. The WASM binary is
+// streamed from the registry as it is read and checked against its layer
+// digest at the end, rather than held in memory.
+func (a *RegistryAdapter) Pull(ctx context.Context, ref values.PluginReference) (*dto.PluginArtifactDTO, error) {
+	parsed, err := registry.ParseReference(ref.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref.String(), err)
+	}
+	repo, err := NewRemoteRepository(ctx, parsed, a.auth, a.PlainHTTP(parsed.Registry))
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, layer, err := resolveArtifact(ctx, repo, parsed.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckRegistryArtifactSize(parsed.Registry+"/"+parsed.Repository, layer.Size); err != nil {
+		return nil, fmt.Errorf("%s: %w", parsed, err)
+	}
+	if err := enforceLicense(ctx, repo, parsed.Reference, &artifact); err != nil {
+		return nil, err
+	}
+	rc, err := repo.Fetch(ctx, layer)
+	if err != nil {
+		return nil, fmt.Errorf("fetching wasm: %w", err)
+	}
+	a.mu.Lock()
+	a.pulled[ref.String()] = artifact
+	a.mu.Unlock()
+	plugin := entities.NewPlugin(ref, artifact.Digest, artifact.Metadata)
+	return dto.NewPluginArtifactDTO(plugin, newVerifiedLayer(rc, layer)), nil
+}
+
+// PulledVariant returns the variant chosen when ref was last pulled from a
+// multi-variant index by this adapter.
+func (a *RegistryAdapter) PulledVariant(ref values.PluginReference) (Variant, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	artifact, ok := a.pulled[ref.String()]
+	return artifact.Variant, ok && artifact.Variant.Target != ""
+}
+
+// PulledProvenance returns the provenance annotations of the artifact last
+// pulled for ref by this adapter.
+func (a *RegistryAdapter) PulledProvenance(ref values.PluginReference) (Provenance, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	artifact, ok := a.pulled[ref.String()]
+	return artifact.Provenance, ok
+}
+
+// verifiedLayer reads a blob, failing the final read if the content does
+// not match its descriptor.
+type verifiedLayer struct {
+	rc io.ReadCloser
+	vr *content.VerifyReader
+}
+
+func newVerifiedLayer(rc io.ReadCloser, desc ocispec.Descriptor) *verifiedLayer {
+	return &verifiedLayer{rc: rc, vr: content.NewVerifyReader(rc, desc)}
+}
+
+func (l *verifiedLayer) Read(p []byte) (int, error) {
+	n, err := l.vr.Read(p)
+	if err == io.EOF {
+		if verr := l.vr.Verify(); verr != nil {
+			return n, fmt.Errorf("verifying wasm: %w", verr)
+		}
+	}
+	return n, err
+}
+
+func (l *verifiedLayer) Close() error {
+	return l.rc.Close()
+}
+
+// Push is not supported; plugins are published with Publish.
+func (a *RegistryAdapter) Push(ctx context.Context, artifact *dto.PluginArtifactDTO) error {
+	return errors.New("pushing through the plugin service is not supported; use plugin publish")
+}
+
+// Resolve resolves a reference to its manifest digest.
+func (a *RegistryAdapter) Resolve(ctx context.Context, ref values.PluginReference) (values.Digest, error) {
+	parsed, err := registry.ParseReference(ref.String())
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("invalid reference %q: %w", ref.String(), err)
+	}
+	repo, err := NewRemoteRepository(ctx, parsed, a.auth, a.PlainHTTP(parsed.Registry))
+	if err != nil {
+		return values.Digest{}, err
+	}
+	desc, err := repo.Resolve(ctx, parsed.Reference)
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("resolving %s: %w", parsed, err)
+	}
+	return values.ParseDigest(desc.Digest.String())
+}
+
+// Artifact is a plugin read from an OCI target.
+type Artifact struct {
+	Metadata   values.PluginMetadata
+	Digest     values.Digest // digest of the WASM layer
+	Variant    Variant       // chosen from an index; zero for a single manifest
+	Provenance Provenance    // from the manifest's OCI annotations
+	WASM       []byte        // as stored in the layer, possibly zstd-compressed
+}
+
+// FetchArtifact reads the plugin metadata and WASM binary of the artifact
+// tagged reference in target, once the license policy permits it.
+func FetchArtifact(ctx context.Context, target oras.ReadOnlyTarget, reference string) (Artifact, error) {
+	artifact, layer, err := resolveArtifact(ctx, target, reference)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if err := enforceLicense(ctx, target, reference, &artifact); err != nil {
+		return Artifact{}, err
+	}
+	wasm, err := content.FetchAll(ctx, target, layer)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("fetching wasm: %w", err)
+	}
+	artifact.WASM = wasm
+	return artifact, nil
+}
+
+// resolveArtifact reads the plugin metadata of the artifact tagged
+// reference in target and returns it, without the WASM binary, along with
+// the descriptor of the WASM layer, which may be zstd-compressed. When
+// reference names an OCI index of variants, the one SelectVariant picks is
+// read. Layers over the size limit are refused.
+func resolveArtifact(ctx context.Context, target oras.ReadOnlyTarget, reference string) (Artifact, ocispec.Descriptor, error) {
+	_, data, err := oras.FetchBytes(ctx, target, reference, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("fetching %s: %w", reference, err)
+	}
+
+	var (
+		variant          Variant
+		indexAnnotations map[string]string
+		index            ocispec.Index
+	)
+	if err := json.Unmarshal(data, &index); err == nil && isIndex(index) {
+		desc, v, err := SelectVariant(index.Manifests)
+		if err != nil {
+			return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, err)
+		}
+		if data, err = content.FetchAll(ctx, target, desc); err != nil {
+			return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("fetching %s variant: %w", v, err)
+		}
+		variant = v
+		indexAnnotations = index.Annotations
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("parsing OCI manifest: %w", err)
+	}
+	if manifest.ArtifactType != ArtifactType && manifest.Config.MediaType != MediaTypePluginConfig {
+		return Artifact{}, ocispec.Descriptor{}, errNotPlugin
+	}
+
+	raw, err := content.FetchAll(ctx, target, manifest.Config)
+	if err != nil {
+		return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("fetching config: %w", err)
+	}
+	var cfg struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Description  string   `json:"description"`
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("parsing config: %w", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypePluginWASM && layer.MediaType != MediaTypePluginWASMZstd {
+			continue
+		}
+		if err := CheckArtifactSize(layer.Size); err != nil {
+			return Artifact{}, ocispec.Descriptor{}, err
+		}
+		digest, err := values.ParseDigest(layer.Digest.String())
+		if err != nil {
+			return Artifact{}, ocispec.Descriptor{}, err
+		}
+		return Artifact{
+			Metadata:   values.NewPluginMetadata(cfg.Name, cfg.Version, cfg.Description, cfg.Capabilities),
+			Digest:     digest,
+			Variant:    variant,
+			Provenance: provenanceOf(manifest.Annotations, indexAnnotations),
+		}, layer, nil
+	}
+	return Artifact{}, ocispec.Descriptor{}, fmt.Errorf("artifact has no %s layer", MediaTypePluginWASM)
+}
+
+// isIndex reports whether a fetched document is an OCI index rather than a
+// manifest. Some registries leave mediaType out of indexes.
+func isIndex(index ocispec.Index) bool {
+	return index.MediaType == ocispec.MediaTypeImageIndex || (index.MediaType == "" && len(index.Manifests) > 0)
+}
+
+// ParseOCILayout splits an install target of the form
+// "oci-layout:<dir>[:<tag>]" into the directory and tag.
+func ParseOCILayout(target string) (dir, tag string, ok bool) {
+	rest, ok := strings.CutPrefix(target, OCILayoutPrefix)
+	if !ok {
+		return "", "", false
+	}
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, tag = rest[:i], rest[i+1:]
+	}
+	return rest, tag, true
+}
+
+// FetchLayoutArtifact reads a plugin from the OCI image layout in dir.
+// Without a tag, the layout must hold exactly one tagged artifact.
+func FetchLayoutArtifact(ctx context.Context, dir, tag string) (Artifact, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return Artifact{}, fmt.Errorf("opening OCI layout: %w", err)
+	}
+	store, err := oci.NewFromFS(ctx, os.DirFS(dir))
+	if err != nil {
+		return Artifact{}, fmt.Errorf("opening OCI layout %s: %w", dir, err)
+	}
+
+	if tag == "" {
+		var tags []string
+		if err := store.Tags(ctx, "", func(page []string) error {
+			tags = append(tags, page...)
+			return nil
+		}); err != nil {
+			return Artifact{}, fmt.Errorf("listing tags in %s: %w", dir, err)
+		}
+		switch len(tags) {
+		case 0:
+			return Artifact{}, fmt.Errorf("OCI layout %s has no tagged artifacts", dir)
+		case 1:
+			tag = tags[0]
+		default:
+			sort.Strings(tags)
+			return Artifact{}, fmt.Errorf("OCI layout %s has several tags; pick one with %s%s:<tag> (tags: %s)",
+				dir, OCILayoutPrefix, dir, strings.Join(tags, ", "))
+		}
+	}
+
+	artifact, err := FetchArtifact(ctx, store, tag)
+	if errors.Is(err, errNotPlugin) {
+		return Artifact{}, fmt.Errorf("%s in %s is not a plugin artifact", tag, dir)
+	}
+	return artifact, err
+}
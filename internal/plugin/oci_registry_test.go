@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestParseOCILayout(t *testing.T) {
+	tests := []struct {
+		target, dir, tag string
+		ok               bool
+	}{
+		{"oci-layout:./dist", "./dist", "", true},
+		{"oci-layout:./dist:1.0.0", "./dist", "1.0.0", true},
+		{"oci-layout:/tmp/a:b/layout", "/tmp/a:b/layout", "", true},
+		{"./dist", "", "", false},
+	}
+	for _, tt := range tests {
+		dir, tag, ok := ParseOCILayout(tt.target)
+		if dir != tt.dir || tag != tt.tag || ok != tt.ok {
+			t.Errorf("ParseOCILayout(%q) = %q, %q, %v; want %q, %q, %v", tt.target, dir, tag, ok, tt.dir, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestFetchLayoutArtifact(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := oci.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest()); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	artifact, err := FetchLayoutArtifact(ctx, dir, "")
+	if err != nil {
+		t.Fatalf("FetchLayoutArtifact: %v", err)
+	}
+	if artifact.Metadata.Name() != "ping" || artifact.Metadata.Version() != "1.0.0" || string(artifact.WASM) != "wasm" {
+		t.Errorf("unexpected artifact: %+v", artifact)
+	}
+	if err := artifact.Digest.Verify(artifact.WASM); err != nil {
+		t.Errorf("digest does not match wasm: %v", err)
+	}
+
+	m := testPluginManifest()
+	m.Version = "1.1.0"
+	if _, err := Pack(ctx, store, "1.1.0", []byte("wasm2"), m); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if _, err := FetchLayoutArtifact(ctx, dir, ""); err == nil || !strings.Contains(err.Error(), "1.0.0, 1.1.0") {
+		t.Errorf("expected an error listing the tags, got %v", err)
+	}
+	artifact, err = FetchLayoutArtifact(ctx, dir, "1.1.0")
+	if err != nil || string(artifact.WASM) != "wasm2" {
+		t.Errorf("expected the 1.1.0 artifact, got %q (%v)", artifact.WASM, err)
+	}
+}
+
+func TestRegistryAdapter_PullPlainHTTP(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	manifest, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed ocispec.Manifest
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	blobs := make(map[string][]byte)
+	for _, d := range append([]ocispec.Descriptor{parsed.Config}, parsed.Layers...) {
+		data, err := content.FetchAll(ctx, store, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[d.Digest.String()] = data
+	}
+
+	// A minimal read-only registry serving the packed artifact.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/v2/acme/plugins/ping/"
+		switch {
+		case r.URL.Path == prefix+"manifests/1.0.0" || r.URL.Path == prefix+"manifests/"+desc.Digest.String():
+			w.Header().Set("Content-Type", desc.MediaType)
+			w.Header().Set("Docker-Content-Digest", desc.Digest.String())
+			_, _ = w.Write(manifest)
+		case strings.HasPrefix(r.URL.Path, prefix+"blobs/"):
+			data, ok := blobs[strings.TrimPrefix(r.URL.Path, prefix+"blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	ref, err := values.ParsePluginReference(host + "/acme/plugins/ping:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRegistryAdapter(nil, nil).Pull(ctx, ref); err == nil {
+		t.Fatal("expected HTTPS pull from a plain HTTP registry to fail")
+	}
+
+	adapter := NewRegistryAdapter(nil, []string{host})
+	artifact, err := adapter.Pull(ctx, ref)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if artifact.Plugin.Metadata().Name() != "ping" || artifact.Plugin.Metadata().Version() != "1.0.0" {
+		t.Errorf("unexpected metadata: %+v", artifact.Plugin.Metadata())
+	}
+	wasm, err := io.ReadAll(artifact.WASM)
+	_ = artifact.Close()
+	if err != nil || string(wasm) != "wasm" {
+		t.Errorf("streamed wasm = %q (%v)", wasm, err)
+	}
+
+	// A blob that does not match its digest fails the read
+	for d, data := range blobs {
+		if string(data) == "wasm" {
+			blobs[d] = []byte("evil")
+		}
+	}
+	artifact, err = adapter.Pull(ctx, ref)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if _, err := io.ReadAll(artifact.WASM); err == nil {
+		t.Error("expected a tampered layer to fail verification")
+	}
+	_ = artifact.Close()
+
+	SetMaxArtifactSize(2)
+	defer SetMaxArtifactSize(0)
+	if _, err := adapter.Pull(ctx, ref); !errors.Is(err, ErrArtifactTooLarge) {
+		t.Errorf("expected ErrArtifactTooLarge, got %v", err)
+	}
+
+	digest, err := adapter.Resolve(ctx, ref)
+	if err != nil || digest.String() != desc.Digest.String() {
+		t.Errorf("Resolve = %v (%v), want %s", digest, err, desc.Digest)
+	}
+}
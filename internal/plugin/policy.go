@@ -0,0 +1,418 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyEnv and PolicyKeyEnv name the organization policy bundle and the
+// comma-separated public keys, one of which must have signed it. They are
+// read from the environment only, so user config cannot override or drop
+// the policy.
+const (
+	PolicyEnv    = "TACK_POLICY"
+	PolicyKeyEnv = "TACK_POLICY_KEY"
+)
+
+// ErrPolicyDenied is returned when the organization policy refuses a
+// plugin, registry, or capability.
+var ErrPolicyDenied = errcode.New(errcode.PolicyDenied, "denied by policy")
+
+// Policy is an organization-wide policy bundle. A nil *Policy allows
+// everything.
+type Policy struct {
+	// AllowedRegistries are the registry prefixes plugins may be installed
+	// or pulled from, such as "ghcr.io/acme". Empty allows any registry.
+	// When set, plugins cannot be installed from local files.
+	AllowedRegistries []string `yaml:"allowed_registries,omitempty" json:"allowed_registries,omitempty"`
+
+	// RequireSignatures makes plugin signature verification mandatory,
+	// whatever require_signing says in user config.
+	RequireSignatures bool `yaml:"require_signatures,omitempty" json:"require_signatures,omitempty"`
+
+	// CapabilityCeiling bounds the capabilities any plugin may be granted.
+	// Patterns are globs as in grants: * stays within a path segment, and a
+	// path ending in /** covers everything below it.
+	CapabilityCeiling *hostfunc.GrantSet `yaml:"capability_ceiling,omitempty" json:"capability_ceiling,omitempty"`
+
+	// BlockedPlugins are plugin names that may not be installed or run.
+	BlockedPlugins []string `yaml:"blocked_plugins,omitempty" json:"blocked_plugins,omitempty"`
+}
+
+var activePolicy atomic.Pointer[Policy]
+
+// SetPolicy makes p the policy enforced by the loader, the installer, and
+// capability grants. A nil p removes it.
+func SetPolicy(p *Policy) {
+	activePolicy.Store(p)
+	if p == nil {
+		runtime.SetCapabilityPolicy(nil)
+		return
+	}
+	runtime.SetCapabilityPolicy(p)
+}
+
+// ActivePolicy returns the enforced policy, or nil if there is none.
+func ActivePolicy() *Policy {
+	return activePolicy.Load()
+}
+
+// LoadPolicy reads the bundle named by PolicyEnv: a file path or an
+// http(s)://, file://, or oci:// URL, in YAML or JSON. Its detached base64
+// signature (the location plus ".sig", or the index.json.sig layer of an
+// OCI artifact) must verify against a key in PolicyKeyEnv. It returns nil
+// when PolicyEnv is unset.
+func LoadPolicy(ctx context.Context) (*Policy, error) {
+	location := os.Getenv(PolicyEnv)
+	if location == "" {
+		return nil, nil
+	}
+	keys := os.Getenv(PolicyKeyEnv)
+	if keys == "" {
+		return nil, fmt.Errorf("%s is set but %s names no public key to verify it", PolicyEnv, PolicyKeyEnv)
+	}
+	if !strings.Contains(location, "://") {
+		location = "file://" + location
+	}
+
+	data, sig, err := fetchIndexData(ctx, location, true)
+	if err != nil {
+		return nil, fmt.Errorf("fetching policy: %w", err)
+	}
+	if sig == nil {
+		return nil, errors.New("policy bundle is not signed")
+	}
+	if err := verifyPolicy(data, string(sig), strings.Split(keys, ",")); err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// verifyPolicy checks the bundle signature against each key in turn.
+func verifyPolicy(data []byte, sig string, keyPaths []string) error {
+	var errs []error
+	for _, keyPath := range keyPaths {
+		verifier, err := LoadVerifier(strings.TrimSpace(keyPath))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := VerifyBlob(data, sig, verifier); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", keyPath, err))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("policy signature not verified: %w", errors.Join(errs...))
+}
+
+// ParsePolicy parses a YAML or JSON policy bundle. Unknown fields are
+// rejected so a misspelled rule is not silently ignored.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+	return &p, nil
+}
+
+// CheckPlugin refuses blocked plugins.
+func (p *Policy) CheckPlugin(name string) error {
+	if p != nil && slices.Contains(p.BlockedPlugins, name) {
+		return fmt.Errorf("plugin %s is blocked: %w", name, ErrPolicyDenied)
+	}
+	return nil
+}
+
+// CheckReference refuses OCI references to blocked plugins or outside the
+// allowed registries.
+func (p *Policy) CheckReference(ref string) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.CheckPlugin(referenceName(ref)); err != nil {
+		return err
+	}
+	if len(p.AllowedRegistries) == 0 {
+		return nil
+	}
+	for _, allowed := range p.AllowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if ref == allowed || strings.HasPrefix(ref, allowed+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("registry of %s is not allowed (allowed: %s): %w", ref, strings.Join(p.AllowedRegistries, ", "), ErrPolicyDenied)
+}
+
+// CheckLocalInstall refuses installing a plugin from a file or OCI layout
+// when the policy restricts registries or requires signatures, which a
+// local install cannot honor.
+func (p *Policy) CheckLocalInstall(name string) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.CheckPlugin(name); err != nil {
+		return err
+	}
+	if len(p.AllowedRegistries) > 0 || p.RequireSignatures {
+		return fmt.Errorf("installing %s from a local file is not allowed: %w", name, ErrPolicyDenied)
+	}
+	return nil
+}
+
+// CheckWorkspacePlugins refuses plugins from a workspace's plugins
+// directory when the policy restricts registries or requires signatures:
+// any checkout can put an unsigned binary there.
+func (p *Policy) CheckWorkspacePlugins() error {
+	if p != nil && (len(p.AllowedRegistries) > 0 || p.RequireSignatures) {
+		return fmt.Errorf("workspace plugins are not allowed: %w", ErrPolicyDenied)
+	}
+	return nil
+}
+
+// referenceName returns the plugin name of an OCI reference: its last path
+// element without tag or digest.
+func referenceName(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	name := path.Base(ref)
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
+
+// CheckCapabilities refuses blocked plugins and capabilities beyond the
+// ceiling. caps may be nil to check only the plugin.
+func (p *Policy) CheckCapabilities(pluginName string, caps *hostfunc.GrantSet) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.CheckPlugin(pluginName); err != nil {
+		return err
+	}
+	if caps == nil || p.CapabilityCeiling == nil {
+		return nil
+	}
+	if excess := uncovered(p.CapabilityCeiling, caps); len(excess) > 0 {
+		return fmt.Errorf("plugin %s requests %s beyond the policy ceiling: %w", pluginName, excess[0], ErrPolicyDenied)
+	}
+	return nil
+}
+
+// AllowsGrant reports whether the ceiling covers one rule of a grant: a
+// "host:port" for "network", a path for "fs read" and "fs write", a
+// variable for "env", a command for "exec", and a key for "kv <op>".
+// Stored grants beyond the ceiling are dropped with it.
+func (p *Policy) AllowsGrant(kind, value string) bool {
+	if p == nil || p.CapabilityCeiling == nil {
+		return true
+	}
+	limit := p.CapabilityCeiling
+	switch kind {
+	case "network":
+		i := strings.LastIndex(value, ":")
+		return i >= 0 && networkCovered(limit.Network, value[:i], value[i+1:])
+	case "fs read", "fs write":
+		return fsCovered(limit.FS, value, kind == "fs write")
+	case "env":
+		return limit.Env != nil && anyCovers(limit.Env.Variables, value)
+	case "exec":
+		return limit.Exec != nil && anyCovers(limit.Exec.Commands, value)
+	}
+	if op, ok := strings.CutPrefix(kind, "kv "); ok {
+		return kvCovered(limit.KV, op, value)
+	}
+	return false
+}
+
+// capabilityRequest is one piece of access a plugin asks for, such as
+// network access to "api.example.com:443".
+type capabilityRequest struct {
+	kind, value string
+}
+
+func (r capabilityRequest) String() string {
+	return fmt.Sprintf("%s %q", r.kind, r.value)
+}
+
+// uncovered returns the access caps requests that limit does not cover. A
+// nil limit covers nothing.
+func uncovered(limit, caps *hostfunc.GrantSet) []capabilityRequest {
+	if caps == nil {
+		return nil
+	}
+	if limit == nil {
+		limit = &hostfunc.GrantSet{}
+	}
+	var out []capabilityRequest
+	add := func(kind, value string) {
+		out = append(out, capabilityRequest{kind: kind, value: value})
+	}
+
+	if caps.Network != nil {
+		for _, rule := range caps.Network.Rules {
+			for _, host := range rule.Hosts {
+				for _, port := range rule.Ports {
+					if !networkCovered(limit.Network, host, port) {
+						add("network access", host+":"+port)
+					}
+				}
+			}
+		}
+	}
+	if caps.FS != nil {
+		for _, rule := range caps.FS.Rules {
+			for _, fp := range rule.Read {
+				if !fsCovered(limit.FS, fp, false) {
+					add("filesystem read", fp)
+				}
+			}
+			for _, fp := range rule.Write {
+				if !fsCovered(limit.FS, fp, true) {
+					add("filesystem write", fp)
+				}
+			}
+		}
+	}
+	if caps.Env != nil {
+		for _, v := range caps.Env.Variables {
+			if limit.Env == nil || !anyCovers(limit.Env.Variables, v) {
+				add("environment variable", v)
+			}
+		}
+	}
+	if caps.Exec != nil {
+		for _, c := range caps.Exec.Commands {
+			if limit.Exec == nil || !anyCovers(limit.Exec.Commands, c) {
+				add("command", c)
+			}
+		}
+	}
+	if caps.KV != nil {
+		for _, rule := range caps.KV.Rules {
+			for _, key := range rule.Keys {
+				if !kvCovered(limit.KV, rule.Operation, key) {
+					add("key-value "+rule.Operation, key)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func networkCovered(ceiling *hostfunc.NetworkCapability, host, port string) bool {
+	if ceiling == nil {
+		return false
+	}
+	for _, rule := range ceiling.Rules {
+		if !anyCovers(rule.Hosts, host) {
+			continue
+		}
+		for _, allowed := range rule.Ports {
+			if portCovers(allowed, port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fsCovered(ceiling *hostfunc.FileSystemCapability, p string, write bool) bool {
+	if ceiling == nil {
+		return false
+	}
+	for _, rule := range ceiling.Rules {
+		patterns := rule.Read
+		if write {
+			patterns = rule.Write
+		}
+		if anyCovers(patterns, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func kvCovered(ceiling *hostfunc.KeyValueCapability, op, key string) bool {
+	if ceiling == nil {
+		return false
+	}
+	for _, rule := range ceiling.Rules {
+		if (rule.Operation == op || rule.Operation == "read-write") && anyCovers(rule.Keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyCovers reports whether any pattern covers value.
+func anyCovers(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if covers(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether a ceiling pattern covers a requested value, which
+// may itself be a pattern. "*" and "**" cover anything; other patterns
+// match as the capability checker matches grants, so "dir/**" covers dir
+// and everything below it. A ** request reaches any depth, so only a
+// pattern with ** of its own covers it: "/data/*" does not cover
+// "/data/**", which * would otherwise match literally.
+func covers(pattern, value string) bool {
+	if pattern == "*" || pattern == "**" || pattern == value {
+		return true
+	}
+	if strings.Contains(value, "**") && !strings.Contains(pattern, "**") {
+		return false
+	}
+	matched, err := doublestar.Match(pattern, value)
+	return err == nil && matched
+}
+
+// portCovers reports whether an allowed port, range ("8000-9000"), or "*"
+// covers a requested one.
+func portCovers(allowed, requested string) bool {
+	if allowed == "*" {
+		return true
+	}
+	if requested == "*" {
+		return false
+	}
+	alo, ahi, ok := portRange(allowed)
+	if !ok {
+		return false
+	}
+	rlo, rhi, ok := portRange(requested)
+	return ok && rlo >= alo && rhi <= ahi
+}
+
+func portRange(s string) (lo, hi int, ok bool) {
+	from, to, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return lo, lo, true
+	}
+	hi, err = strconv.Atoi(to)
+	return lo, hi, err == nil && hi >= lo
+}
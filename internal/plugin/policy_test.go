@@ -0,0 +1,207 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	t.Setenv(PasswordEnv, "pw")
+	dir := t.TempDir()
+	keyPath, pubPath, err := GenerateKeys(filepath.Join(dir, "org"), []byte("pw"), false)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	signer, err := LoadSigner(keyPath)
+	if err != nil {
+		t.Fatalf("LoadSigner: %v", err)
+	}
+
+	bundle := []byte("allowed_registries: [ghcr.io/acme]\nblocked_plugins: [command]\n")
+	sig, err := SignBlob(bundle, signer)
+	if err != nil {
+		t.Fatalf("SignBlob: %v", err)
+	}
+	policyPath := filepath.Join(dir, "policy.yaml")
+	_ = os.WriteFile(policyPath, bundle, 0o644)
+
+	t.Setenv(PolicyEnv, "")
+	if p, err := LoadPolicy(context.Background()); p != nil || err != nil {
+		t.Fatalf("expected no policy when %s is unset, got %v, %v", PolicyEnv, p, err)
+	}
+
+	t.Setenv(PolicyEnv, policyPath)
+	t.Setenv(PolicyKeyEnv, "")
+	if _, err := LoadPolicy(context.Background()); err == nil {
+		t.Error("expected a policy without a key to be refused")
+	}
+
+	t.Setenv(PolicyKeyEnv, pubPath)
+	if _, err := LoadPolicy(context.Background()); err == nil {
+		t.Error("expected an unsigned policy to be refused")
+	}
+
+	_ = os.WriteFile(policyPath+".sig", []byte(sig), 0o644)
+	p, err := LoadPolicy(context.Background())
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	if len(p.AllowedRegistries) != 1 || p.CheckPlugin("command") == nil {
+		t.Errorf("unexpected policy %+v", p)
+	}
+
+	_ = os.WriteFile(policyPath, append(bundle, "require_signatures: true\n"...), 0o644)
+	if _, err := LoadPolicy(context.Background()); err == nil {
+		t.Error("expected a tampered policy to be refused")
+	}
+}
+
+func TestParsePolicy_UnknownField(t *testing.T) {
+	if _, err := ParsePolicy([]byte("blocked_plugin: [command]\n")); err == nil {
+		t.Error("expected a misspelled field to be rejected")
+	}
+}
+
+func TestPolicyChecks(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+allowed_registries: [ghcr.io/acme/]
+blocked_plugins: [command]
+capability_ceiling:
+  network:
+    rules:
+      - hosts: ["*.acme.internal"]
+        ports: ["443", "8000-9000"]
+  fs:
+    rules:
+      - read: ["/etc/**"]
+  env:
+    vars: ["AWS_*"]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+
+	refs := map[string]bool{
+		"ghcr.io/acme/dns:1.0.0":          true,
+		"ghcr.io/acme/plugins/http:1.0.0": true,
+		"ghcr.io/acmecorp/dns:1.0.0":      false,
+		"docker.io/acme/dns:1.0.0":        false,
+		"ghcr.io/acme/command:1.0.0":      false,
+	}
+	for ref, ok := range refs {
+		if err := p.CheckReference(ref); (err == nil) != ok {
+			t.Errorf("CheckReference(%s) = %v, want allowed=%v", ref, err, ok)
+		}
+	}
+	if err := p.CheckLocalInstall("dns"); !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("expected local installs to be denied with allowed registries, got %v", err)
+	}
+
+	caps := []struct {
+		name string
+		caps hostfunc.GrantSet
+		ok   bool
+	}{
+		{"within", hostfunc.GrantSet{
+			Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.acme.internal"}, Ports: []string{"443", "8080-8090"}}}},
+			FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}}}},
+			Env:     &hostfunc.EnvironmentCapability{Variables: []string{"AWS_REGION"}},
+		}, true},
+		{"any host", hostfunc.GrantSet{
+			Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}},
+		}, false},
+		{"port outside range", hostfunc.GrantSet{
+			Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.acme.internal"}, Ports: []string{"8000-9100"}}}},
+		}, false},
+		{"write", hostfunc.GrantSet{
+			FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Write: []string{"/etc/hosts"}}}},
+		}, false},
+		{"exec", hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"sh"}}}, false},
+	}
+	for _, tt := range caps {
+		if err := p.CheckCapabilities("http", &tt.caps); (err == nil) != tt.ok {
+			t.Errorf("%s: CheckCapabilities = %v, want allowed=%v", tt.name, err, tt.ok)
+		}
+	}
+	if err := p.CheckCapabilities("command", nil); !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("expected a blocked plugin to be denied, got %v", err)
+	}
+
+	// A ceiling without ** never covers a ** request, which reaches every
+	// level below it
+	p.CapabilityCeiling.FS = &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+		{Read: []string{"/data/*", "/etc/**"}},
+	}}
+	paths := map[string]bool{
+		"/data/a.txt":       true,
+		"/data/*.log":       true,
+		"/data/**":          false,
+		"/data/**/*.txt":    false,
+		"/data/sub/a.txt":   false,
+		"/etc":              true,
+		"/etc/ssl/**":       true,
+		"/etc/ssl/cert.pem": true,
+		"/etcetera/passwd":  false,
+	}
+	for path, ok := range paths {
+		caps := &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{path}}}}}
+		if err := p.CheckCapabilities("files", caps); (err == nil) != ok {
+			t.Errorf("read of %s: CheckCapabilities = %v, want allowed=%v", path, err, ok)
+		}
+	}
+
+	var none *Policy
+	if none.CheckReference("docker.io/x/y:1") != nil || none.CheckCapabilities("x", &hostfunc.GrantSet{}) != nil {
+		t.Error("expected a nil policy to allow everything")
+	}
+}
+
+func TestPolicyAllowsGrant(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+capability_ceiling:
+  network:
+    rules:
+      - hosts: ["*.acme.internal"]
+        ports: ["443"]
+  fs:
+    rules:
+      - read: ["/data/*"]
+  kv:
+    rules:
+      - op: read
+        keys: ["config/*"]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	grants := []struct {
+		kind, value string
+		ok          bool
+	}{
+		{"network", "api.acme.internal:443", true},
+		{"network", "api.acme.internal:80", false},
+		{"network", "example.com:443", false},
+		{"fs read", "/data/a.txt", true},
+		{"fs read", "/data/**", false},
+		{"fs write", "/data/a.txt", false},
+		{"env", "HOME", false},
+		{"exec", "sh", false},
+		{"kv read", "config/app", true},
+		{"kv write", "config/app", false},
+	}
+	for _, tt := range grants {
+		if got := p.AllowsGrant(tt.kind, tt.value); got != tt.ok {
+			t.Errorf("AllowsGrant(%s, %s) = %v, want %v", tt.kind, tt.value, got, tt.ok)
+		}
+	}
+
+	var none *Policy
+	if !none.AllowsGrant("exec", "sh") || !(&Policy{}).AllowsGrant("exec", "sh") {
+		t.Error("expected no ceiling to allow every grant")
+	}
+}
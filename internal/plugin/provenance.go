@@ -0,0 +1,32 @@
+package plugin
+
+import ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+// Provenance is what a plugin artifact's standard OCI annotations say about
+// where it comes from and who maintains it.
+type Provenance struct {
+	License       string `json:"license,omitempty" yaml:"license,omitempty"`             // org.opencontainers.image.licenses
+	Homepage      string `json:"homepage,omitempty" yaml:"homepage,omitempty"`           // org.opencontainers.image.url
+	Source        string `json:"source,omitempty" yaml:"source,omitempty"`               // org.opencontainers.image.source
+	Authors       string `json:"authors,omitempty" yaml:"authors,omitempty"`             // org.opencontainers.image.authors
+	Documentation string `json:"documentation,omitempty" yaml:"documentation,omitempty"` // org.opencontainers.image.documentation
+}
+
+// provenanceOf reads the provenance annotations of a manifest. Fields the
+// manifest leaves out are taken from fallback, such as the annotations of
+// the index the manifest was chosen from.
+func provenanceOf(annotations, fallback map[string]string) Provenance {
+	get := func(key string) string {
+		if v := annotations[key]; v != "" {
+			return v
+		}
+		return fallback[key]
+	}
+	return Provenance{
+		License:       get(ocispec.AnnotationLicenses),
+		Homepage:      get(ocispec.AnnotationURL),
+		Source:        get(ocispec.AnnotationSource),
+		Authors:       get(ocispec.AnnotationAuthors),
+		Documentation: get(ocispec.AnnotationDocumentation),
+	}
+}
@@ -0,0 +1,28 @@
+package plugin
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestProvenanceOf(t *testing.T) {
+	got := provenanceOf(map[string]string{
+		ocispec.AnnotationLicenses: "MIT",
+		ocispec.AnnotationSource:   "https://github.com/me/ping",
+		ocispec.AnnotationAuthors:  "",
+	}, map[string]string{
+		ocispec.AnnotationLicenses:      "Apache-2.0",
+		ocispec.AnnotationAuthors:       "Ping Maintainers",
+		ocispec.AnnotationDocumentation: "https://docs.example/ping",
+	})
+	want := Provenance{
+		License:       "MIT",
+		Source:        "https://github.com/me/ping",
+		Authors:       "Ping Maintainers",
+		Documentation: "https://docs.example/ping",
+	}
+	if got != want {
+		t.Errorf("provenanceOf = %+v, want %+v", got, want)
+	}
+}
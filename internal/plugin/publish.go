@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// OCI media types for plugin artifacts. The WASM layer type matches what the
+// host SDK registry adapter looks for when pulling.
+const (
+	ArtifactType          = "application/vnd.reglet.plugin.v1"
+	MediaTypePluginConfig = "application/vnd.reglet.plugin.config.v1+json"
+	MediaTypePluginWASM   = "application/vnd.reglet.plugin.wasm.v1"
+	MediaTypeManifestJSON = "application/vnd.reglet.plugin.manifest.v1+json"
+
+	// AnnotationCapabilities lists the capability kinds a plugin requests.
+	AnnotationCapabilities = "dev.reglet.plugin.capabilities"
+)
+
+// PublishResult describes a pushed artifact.
+type PublishResult struct {
+	Reference string // registry/repository:tag
+	Digest    string // manifest digest
+}
+
+// Pack stores a plugin artifact in store and tags it. The artifact has a
+// config blob with the plugin metadata, the WASM binary, and the full
+// manifest as JSON so registries and indexes can read it without running
+// the plugin. A wasm compressed by CompressWASM is pushed as a zstd layer.
+func Pack(ctx context.Context, store oras.Target, tag string, wasm []byte, m abi.Manifest) (ocispec.Descriptor, error) {
+	caps := CapabilityKinds(m)
+	cfg, err := json.Marshal(struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Description  string   `json:"description"`
+		Capabilities []string `json:"capabilities"`
+	}{m.Name, m.Version, m.Description, caps})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("encoding config: %w", err)
+	}
+	manifestJSON, err := json.Marshal(m)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	configDesc, err := pushBlob(ctx, store, MediaTypePluginConfig, cfg, nil)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	wasmType, wasmTitle := MediaTypePluginWASM, m.Name+".wasm"
+	if IsCompressed(wasm) {
+		wasmType, wasmTitle = MediaTypePluginWASMZstd, m.Name+".wasm.zst"
+	}
+	wasmDesc, err := pushBlob(ctx, store, wasmType, wasm, map[string]string{
+		ocispec.AnnotationTitle: wasmTitle,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	manifestDesc, err := pushBlob(ctx, store, MediaTypeManifestJSON, manifestJSON, map[string]string{
+		ocispec.AnnotationTitle: "manifest.json",
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	annotations := map[string]string{
+		ocispec.AnnotationTitle:       m.Name,
+		ocispec.AnnotationVersion:     m.Version,
+		ocispec.AnnotationDescription: m.Description,
+		ocispec.AnnotationCreated:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(caps) > 0 {
+		capsJSON, _ := json.Marshal(caps)
+		annotations[AnnotationCapabilities] = string(capsJSON)
+	}
+
+	desc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, ArtifactType, oras.PackManifestOptions{
+		ConfigDescriptor:    &configDesc,
+		Layers:              []ocispec.Descriptor{wasmDesc, manifestDesc},
+		ManifestAnnotations: annotations,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("packing manifest: %w", err)
+	}
+	if err := store.Tag(ctx, desc, tag); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("tagging artifact: %w", err)
+	}
+	return desc, nil
+}
+
+// Publish packs a plugin and pushes it to the registry reference ref
+// (registry/repository:tag).
+func Publish(ctx context.Context, ref string, wasm []byte, m abi.Manifest, authProvider ports.AuthProvider, plainHTTP bool) (PublishResult, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return PublishResult{}, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	if err := parsed.ValidateReferenceAsTag(); err != nil {
+		return PublishResult{}, fmt.Errorf("reference %q must include a tag: %w", ref, err)
+	}
+
+	repo, err := NewRemoteRepository(ctx, parsed, authProvider, plainHTTP)
+	if err != nil {
+		return PublishResult{}, err
+	}
+
+	store := memory.New()
+	desc, err := Pack(ctx, store, parsed.Reference, wasm, m)
+	if err != nil {
+		return PublishResult{}, err
+	}
+	if _, err := oras.Copy(ctx, store, parsed.Reference, repo, parsed.Reference, oras.DefaultCopyOptions); err != nil {
+		return PublishResult{}, fmt.Errorf("pushing artifact: %w", err)
+	}
+
+	return PublishResult{Reference: parsed.String(), Digest: desc.Digest.String()}, nil
+}
+
+// NewRemoteRepository returns a client for the repository in ref, using
+// credentials from authProvider when it has any. Rate-limited requests are
+// retried and bearer tokens are shared with other clients in the process.
+func NewRemoteRepository(ctx context.Context, ref registry.Reference, authProvider ports.AuthProvider, plainHTTP bool) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("creating repository client: %w", err)
+	}
+	repo.PlainHTTP = plainHTTP
+	repo.Client = registryClient(ctx, ref.Registry, authProvider)
+	return repo, nil
+}
+
+// CapabilityKinds returns the capability kinds a manifest requests, in a
+// fixed order.
+func CapabilityKinds(m abi.Manifest) []string {
+	g := m.Capabilities
+	var kinds []string
+	if g.Network != nil && len(g.Network.Rules) > 0 {
+		kinds = append(kinds, "network")
+	}
+	if g.FS != nil && len(g.FS.Rules) > 0 {
+		kinds = append(kinds, "fs")
+	}
+	if g.Env != nil && len(g.Env.Variables) > 0 {
+		kinds = append(kinds, "env")
+	}
+	if g.Exec != nil && len(g.Exec.Commands) > 0 {
+		kinds = append(kinds, "exec")
+	}
+	if g.KV != nil && len(g.KV.Rules) > 0 {
+		kinds = append(kinds, "kv")
+	}
+	return kinds
+}
+
+func pushBlob(ctx context.Context, pusher content.Pusher, mediaType string, data []byte, annotations map[string]string) (ocispec.Descriptor, error) {
+	desc := content.NewDescriptorFromBytes(mediaType, data)
+	desc.Annotations = annotations
+	if err := pusher.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("pushing %s blob: %w", mediaType, err)
+	}
+	return desc, nil
+}
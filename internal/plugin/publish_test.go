@@ -0,0 +1,232 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+func testPluginManifest() abi.Manifest {
+	return abi.Manifest{
+		Name:        "ping",
+		Version:     "1.0.0",
+		Description: "Ping checks",
+		Capabilities: hostfunc.GrantSet{
+			Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"*"}}}},
+		},
+	}
+}
+
+func TestPack(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+
+	resolved, err := store.Resolve(ctx, "1.0.0")
+	if err != nil || resolved.Digest != desc.Digest {
+		t.Fatalf("expected tag to resolve to %s, got %v (%v)", desc.Digest, resolved.Digest, err)
+	}
+
+	raw, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		t.Fatalf("fetching manifest: %v", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		t.Fatalf("decoding manifest: %v", err)
+	}
+
+	if manifest.ArtifactType != ArtifactType || manifest.Config.MediaType != MediaTypePluginConfig {
+		t.Errorf("unexpected artifact/config type: %s / %s", manifest.ArtifactType, manifest.Config.MediaType)
+	}
+	if len(manifest.Layers) != 2 || manifest.Layers[0].MediaType != MediaTypePluginWASM {
+		t.Fatalf("expected WASM layer first, got %+v", manifest.Layers)
+	}
+	if manifest.Annotations[ocispec.AnnotationVersion] != "1.0.0" || manifest.Annotations[AnnotationCapabilities] != `["network"]` {
+		t.Errorf("unexpected annotations: %v", manifest.Annotations)
+	}
+
+	cfg, _ := content.FetchAll(ctx, store, manifest.Config)
+	var meta struct {
+		Name         string   `json:"name"`
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.Unmarshal(cfg, &meta); err != nil || meta.Name != "ping" {
+		t.Errorf("unexpected config %s: %v", cfg, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("pw"), nil })
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	sv, err := cosign.LoadPrivateKey(keys.PrivateBytes, []byte("pw"), nil)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if _, err := Sign(ctx, store, "registry.example/plugins/ping", desc.Digest.String(), sv); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	sigDesc, err := store.Resolve(ctx, SignatureTag(desc.Digest.String()))
+	if err != nil {
+		t.Fatalf("resolving signature tag: %v", err)
+	}
+	raw, _ := content.FetchAll(ctx, store, sigDesc)
+	var manifest ocispec.Manifest
+	_ = json.Unmarshal(raw, &manifest)
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != MediaTypeSimpleSigning {
+		t.Fatalf("unexpected signature manifest: %s", raw)
+	}
+
+	payload, _ := content.FetchAll(ctx, store, manifest.Layers[0])
+	sig, _ := base64.StdEncoding.DecodeString(manifest.Layers[0].Annotations[AnnotationSignature])
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(payload)); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if !bytes.Contains(payload, []byte(desc.Digest.String())) {
+		t.Errorf("payload does not reference the artifact digest: %s", payload)
+	}
+}
+
+func TestGenerateKeysSignAndVerify(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(PasswordEnv, "pw")
+	prefix := filepath.Join(t.TempDir(), "cosign")
+
+	keyPath, pubPath, err := GenerateKeys(prefix, []byte("pw"), false)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if _, _, err := GenerateKeys(prefix, []byte("pw"), false); err == nil {
+		t.Error("expected error overwriting existing keys without force")
+	}
+
+	signer, err := LoadSigner(keyPath)
+	if err != nil {
+		t.Fatalf("LoadSigner: %v", err)
+	}
+	verifier, err := LoadVerifier(pubPath)
+	if err != nil {
+		t.Fatalf("LoadVerifier: %v", err)
+	}
+
+	sig, err := SignBlob([]byte("wasm"), signer)
+	if err != nil {
+		t.Fatalf("SignBlob: %v", err)
+	}
+	if err := VerifyBlob([]byte("wasm"), sig, verifier); err != nil {
+		t.Errorf("VerifyBlob: %v", err)
+	}
+	if err := VerifyBlob([]byte("tampered"), sig, verifier); err == nil {
+		t.Error("expected verification failure for tampered data")
+	}
+
+	store := memory.New()
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if err := Verify(ctx, store, desc.Digest.String(), verifier); err == nil {
+		t.Error("expected error verifying an unsigned artifact")
+	}
+	if _, err := Sign(ctx, store, "registry.example/plugins/ping", desc.Digest.String(), signer); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := Verify(ctx, store, desc.Digest.String(), verifier); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+// untaggedSignatures is a registry that has garbage-collected signature
+// tags, leaving signatures reachable only through the referrers API.
+type untaggedSignatures struct {
+	*memory.Store
+}
+
+func (s untaggedSignatures) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if strings.HasSuffix(reference, ".sig") {
+		return ocispec.Descriptor{}, errdef.ErrNotFound
+	}
+	return s.Store.Resolve(ctx, reference)
+}
+
+func TestVerifyWithReferrers(t *testing.T) {
+	ctx := context.Background()
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("pw"), nil })
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	sv, err := cosign.LoadPrivateKey(keys.PrivateBytes, []byte("pw"), nil)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+
+	// Registries resolve manifests by digest; tag the memory store to match
+	store := memory.New()
+	desc, err := Pack(ctx, store, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	digest := desc.Digest.String()
+	if err := store.Tag(ctx, desc, digest); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(ctx, untaggedSignatures{store}, digest, sv); err == nil {
+		t.Error("expected error verifying an unsigned artifact")
+	}
+	sigDesc, err := Sign(ctx, store, "registry.example/plugins/ping", digest, sv)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	referrers, err := registry.Referrers(ctx, store, desc, ArtifactTypeSignature)
+	if err != nil || len(referrers) != 1 || referrers[0].Digest != sigDesc.Digest {
+		t.Fatalf("expected the signature among the referrers, got %v (%v)", referrers, err)
+	}
+	if err := Verify(ctx, untaggedSignatures{store}, digest, sv); err != nil {
+		t.Errorf("Verify through referrers: %v", err)
+	}
+
+	// A signature only under the tag convention still verifies
+	legacy := memory.New()
+	desc, err = Pack(ctx, legacy, "1.0.0", []byte("wasm"), testPluginManifest())
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if _, err := Sign(ctx, legacy, "registry.example/plugins/ping", desc.Digest.String(), sv); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := legacy.Tag(ctx, desc, desc.Digest.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(ctx, legacy, desc.Digest.String(), sv); err != nil {
+		t.Errorf("Verify through the signature tag: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// quarantineRecordFile holds the QuarantineRecord inside each quarantined
+// entry's directory.
+const quarantineRecordFile = "quarantine.json"
+
+// QuarantineRecord describes a plugin artifact that failed verification and
+// was moved out of the plugins directory.
+type QuarantineRecord struct {
+	// ID names the entry in the quarantine directory.
+	ID string `json:"id" yaml:"id"`
+
+	// Name is the plugin name, from the artifact's path.
+	Name string `json:"name" yaml:"name"`
+
+	// Path is where the artifact was installed; restoring moves it back.
+	Path string `json:"path" yaml:"path"`
+
+	// Reason is the verification error.
+	Reason string `json:"reason" yaml:"reason"`
+
+	QuarantinedAt time.Time `json:"quarantined_at" yaml:"quarantined_at"`
+}
+
+// QuarantineDir returns the quarantine directory for a plugins directory:
+// a sibling of it, so quarantined artifacts are never discovered.
+func QuarantineDir(pluginsDir string) string {
+	return filepath.Join(filepath.Dir(pluginsDir), "quarantine")
+}
+
+// Quarantine moves the plugin artifact at path into dir with a record of
+// why. An artifact installed into the plugin repository (plugin.wasm next to
+// its digest and metadata) is moved with the rest of its directory.
+func Quarantine(dir, path, reason string) (QuarantineRecord, error) {
+	src, name := path, strings.TrimSuffix(filepath.Base(path), ".wasm")
+	if filepath.Base(path) == "plugin.wasm" {
+		// <root>/<registry>/<org>/<repo>/<name>:<version>/plugin.wasm
+		src = filepath.Dir(path)
+		name, _, _ = strings.Cut(filepath.Base(src), ":")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return QuarantineRecord{}, fmt.Errorf("creating quarantine directory: %w", err)
+	}
+	entry, err := os.MkdirTemp(dir, name+"-")
+	if err != nil {
+		return QuarantineRecord{}, fmt.Errorf("creating quarantine entry: %w", err)
+	}
+
+	record := QuarantineRecord{
+		ID:            filepath.Base(entry),
+		Name:          name,
+		Path:          src,
+		Reason:        reason,
+		QuarantinedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return QuarantineRecord{}, err
+	}
+	if err := os.WriteFile(filepath.Join(entry, quarantineRecordFile), data, 0o600); err != nil {
+		_ = os.RemoveAll(entry)
+		return QuarantineRecord{}, fmt.Errorf("writing quarantine record: %w", err)
+	}
+	if err := os.Rename(src, filepath.Join(entry, filepath.Base(src))); err != nil {
+		_ = os.RemoveAll(entry)
+		return QuarantineRecord{}, fmt.Errorf("quarantining %s: %w", src, err)
+	}
+	return record, nil
+}
+
+// ListQuarantine returns the quarantined artifacts in dir, oldest first.
+// Entries without a readable record are skipped.
+func ListQuarantine(dir string) ([]QuarantineRecord, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []QuarantineRecord
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if r, err := readQuarantineRecord(dir, e.Name()); err == nil {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].QuarantinedAt.Before(records[j].QuarantinedAt)
+	})
+	return records, nil
+}
+
+func readQuarantineRecord(dir, id string) (QuarantineRecord, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return QuarantineRecord{}, fmt.Errorf("invalid quarantine id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id, quarantineRecordFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return QuarantineRecord{}, fmt.Errorf("no quarantined plugin %q", id)
+		}
+		return QuarantineRecord{}, err
+	}
+	var r QuarantineRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return QuarantineRecord{}, fmt.Errorf("reading quarantine record %s: %w", id, err)
+	}
+	r.ID = id
+	return r, nil
+}
+
+// RestoreQuarantined moves a quarantined artifact back to where it was
+// installed. It refuses when something has been installed there since. The
+// artifact is not verified again until it is next discovered or loaded.
+func RestoreQuarantined(dir, id string) (QuarantineRecord, error) {
+	r, err := readQuarantineRecord(dir, id)
+	if err != nil {
+		return QuarantineRecord{}, err
+	}
+	if _, err := os.Stat(r.Path); err == nil {
+		return QuarantineRecord{}, fmt.Errorf("cannot restore %s: %s already exists", id, r.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return QuarantineRecord{}, err
+	}
+	if err := os.Rename(filepath.Join(dir, id, filepath.Base(r.Path)), r.Path); err != nil {
+		return QuarantineRecord{}, fmt.Errorf("restoring %s: %w", id, err)
+	}
+	return r, os.RemoveAll(filepath.Join(dir, id))
+}
+
+// PurgeQuarantine deletes the given quarantined artifacts, or all of them
+// when ids is empty. It returns the records it removed.
+func PurgeQuarantine(dir string, ids ...string) ([]QuarantineRecord, error) {
+	var records []QuarantineRecord
+	if len(ids) == 0 {
+		all, err := ListQuarantine(dir)
+		if err != nil {
+			return nil, err
+		}
+		records = all
+	} else {
+		for _, id := range ids {
+			r, err := readQuarantineRecord(dir, id)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, r)
+		}
+	}
+	for _, r := range records {
+		if err := os.RemoveAll(filepath.Join(dir, r.ID)); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// QuarantineFailedPull runs pull, which fetches ref into the repository.
+// When pull fails after storing an artifact that was not there before, as
+// a signature that does not verify does, the artifact is quarantined so it
+// is not picked up from the cache unverified later.
+func (s *PluginStack) QuarantineFailedPull(ctx context.Context, ref hostvalues.PluginReference, pull func() error) error {
+	_, _, findErr := s.Repository.Find(ctx, ref)
+	existed := findErr == nil
+
+	err := pull()
+	if err == nil || existed {
+		return err
+	}
+	_, path, findErr := s.Repository.Find(ctx, ref)
+	if findErr != nil {
+		return err
+	}
+	record, qErr := Quarantine(s.QuarantineDir, path, err.Error())
+	if qErr != nil {
+		return errors.Join(err, qErr)
+	}
+	return fmt.Errorf("%w (quarantined as %s)", err, record.ID)
+}
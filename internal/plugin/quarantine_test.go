@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+func TestQuarantineLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	quarantine := QuarantineDir(filepath.Join(dir, "plugins"))
+	installed := filepath.Join(dir, "plugins", "ghcr.io", "acme", "plugins", "dns:1.0.0")
+	_ = os.MkdirAll(installed, 0o755)
+	_ = os.WriteFile(filepath.Join(installed, "plugin.wasm"), []byte("wasm"), 0o644)
+	_ = os.WriteFile(filepath.Join(installed, "digest.txt"), []byte("sha256:0"), 0o644)
+
+	record, err := Quarantine(quarantine, filepath.Join(installed, "plugin.wasm"), "digest mismatch")
+	if err != nil {
+		t.Fatalf("Quarantine: %v", err)
+	}
+	if record.Name != "dns" || record.Path != installed {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if _, err := os.Stat(installed); !os.IsNotExist(err) {
+		t.Error("expected the install directory to be moved")
+	}
+
+	records, err := ListQuarantine(quarantine)
+	if err != nil || len(records) != 1 || records[0].Reason != "digest mismatch" {
+		t.Fatalf("ListQuarantine = %+v, %v", records, err)
+	}
+
+	if _, err := RestoreQuarantined(quarantine, "../plugins"); err == nil {
+		t.Error("expected an id outside the quarantine to be refused")
+	}
+	if _, err := RestoreQuarantined(quarantine, record.ID); err != nil {
+		t.Fatalf("RestoreQuarantined: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installed, "digest.txt")); err != nil {
+		t.Errorf("expected the install directory back: %v", err)
+	}
+	if records, _ := ListQuarantine(quarantine); len(records) != 0 {
+		t.Errorf("expected an empty quarantine after restore, got %+v", records)
+	}
+
+	loose := filepath.Join(dir, "plugins", "ping.wasm")
+	_ = os.WriteFile(loose, []byte("wasm"), 0o644)
+	if _, err := Quarantine(quarantine, loose, "bad"); err != nil {
+		t.Fatalf("Quarantine: %v", err)
+	}
+	purged, err := PurgeQuarantine(quarantine)
+	if err != nil || len(purged) != 1 || purged[0].Name != "ping" {
+		t.Fatalf("PurgeQuarantine = %+v, %v", purged, err)
+	}
+	if records, _ := ListQuarantine(quarantine); len(records) != 0 {
+		t.Errorf("expected an empty quarantine after purge, got %+v", records)
+	}
+}
+
+func TestLoader_QuarantinesDigestMismatch(t *testing.T) {
+	dir := t.TempDir()
+	pluginsDir := filepath.Join(dir, "plugins")
+	installed := filepath.Join(pluginsDir, "ping")
+	_ = os.MkdirAll(installed, 0o755)
+	wasm, err := EmbedManifestSection(emptyModule, abi.Manifest{Name: "ping", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(installed, "plugin.wasm"), wasm, 0o644)
+	_ = os.WriteFile(filepath.Join(installed, "digest.txt"), []byte("sha256:recorded"), 0o644)
+
+	loader := NewLoader(embed.FS{}, pluginsDir, nil, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	plugins, err := loader.DiscoverAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiscoverAll: %v", err)
+	}
+	if len(plugins) != 0 {
+		t.Errorf("expected the tampered plugin to be left out, got %+v", plugins)
+	}
+	records, _ := ListQuarantine(QuarantineDir(pluginsDir))
+	if len(records) != 1 || records[0].Name != "ping" || records[0].Path != installed {
+		t.Fatalf("expected ping in quarantine, got %+v", records)
+	}
+
+	if _, err := loader.loadLocalFile(context.Background(), "local", filepath.Join(installed, "plugin.wasm")); err == nil {
+		t.Error("expected the quarantined plugin not to load")
+	}
+	if _, err := RestoreQuarantined(QuarantineDir(pluginsDir), records[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loader.loadLocalFile(context.Background(), "local", filepath.Join(installed, "plugin.wasm"))
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected a restored plugin to be checked again, got %v", err)
+	}
+}
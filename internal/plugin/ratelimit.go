@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// Registry requests that are rate limited (429) or fail with a server error
+// (5xx) are retried up to registryRetries times, waiting as long as the
+// registry's Retry-After header asks, or else backing off exponentially
+// from retryBackoff. A registry asking for more than maxRetryWait is not
+// waited for.
+const (
+	registryRetries = 4
+	maxRetryWait    = time.Minute
+)
+
+var retryBackoff = time.Second
+
+// retryNotice receives a line each time a registry request is retried.
+var retryNotice io.Writer = os.Stderr
+
+// tokenCache holds the bearer tokens registries issue, so each registry and
+// scope is authorized once per process rather than once per client.
+var tokenCache = auth.NewCache()
+
+// registryClient returns the client for requests to the registry host:
+// authorized with the credentials authProvider has for it, if any, sharing
+// cached tokens, and retrying rate-limited requests.
+func registryClient(ctx context.Context, host string, authProvider ports.AuthProvider) *auth.Client {
+	client := &auth.Client{
+		Client: &http.Client{Transport: &retryTransport{}},
+		Cache:  tokenCache,
+	}
+	if authProvider != nil {
+		username, password, err := authProvider.GetCredentials(ctx, host)
+		if err == nil && username != "" {
+			client.Credential = auth.StaticCredential(host, auth.Credential{Username: username, Password: password})
+		}
+	}
+	return client
+}
+
+// retryTransport retries registry requests that are rate limited or fail
+// with a server error. A request still rate limited after the last retry
+// fails with a RegistryRateLimited error.
+type retryTransport struct {
+	base http.RoundTripper // nil means http.DefaultTransport
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// A request whose body cannot be read again is sent once
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		limited := resp.StatusCode == http.StatusTooManyRequests
+		if !limited && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		wait := retryWait(resp, attempt)
+		if attempt >= registryRetries || wait > maxRetryWait || !replayable {
+			if !limited {
+				return resp, nil
+			}
+			_ = resp.Body.Close()
+			return nil, errcode.Errorf(errcode.RateLimited, "rate limited by registry %s after %d attempts; try again in %s",
+				req.URL.Host, attempt+1, wait.Round(time.Second))
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+		_ = resp.Body.Close()
+
+		if limited {
+			_, _ = fmt.Fprintf(retryNotice, "Warning: rate limited by registry %s, retrying in %s\n", req.URL.Host, wait.Round(time.Second))
+		} else {
+			_, _ = fmt.Fprintf(retryNotice, "Warning: registry %s returned %s, retrying in %s\n", req.URL.Host, resp.Status, wait.Round(time.Second))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryWait returns how long to wait before retrying: the response's
+// Retry-After, in seconds or as a date, or else an exponential backoff.
+func retryWait(resp *http.Response, attempt int) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+	return retryBackoff << attempt
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var notices bytes.Buffer
+	oldNotice, oldBackoff := retryNotice, retryBackoff
+	retryNotice, retryBackoff = &notices, time.Millisecond
+	defer func() { retryNotice, retryBackoff = oldNotice, oldBackoff }()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch {
+		case r.URL.Path == "/always":
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n == 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &retryTransport{}}
+
+	resp, err := client.Post(srv.URL+"/blob", "text/plain", strings.NewReader("layer"))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "layer" || calls.Load() != 3 {
+		t.Errorf("expected the body replayed on the third attempt, got %q after %d", body, calls.Load())
+	}
+	if !strings.Contains(notices.String(), "rate limited by registry "+srv.Listener.Addr().String()+", retrying in 0s") ||
+		!strings.Contains(notices.String(), "returned 503 Service Unavailable") {
+		t.Errorf("unexpected notices:\n%s", notices.String())
+	}
+
+	calls.Store(0)
+	_, err = client.Get(srv.URL + "/always")
+	if errcode.Of(err) != errcode.RateLimited || !strings.Contains(err.Error(), "rate limited by registry") {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+	if calls.Load() != registryRetries+1 {
+		t.Errorf("expected %d attempts, got %d", registryRetries+1, calls.Load())
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	header := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+	if got := retryWait(header("7"), 0); got != 7*time.Second {
+		t.Errorf("seconds: got %s", got)
+	}
+	at := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := retryWait(header(at), 0); got < 59*time.Minute {
+		t.Errorf("date: got %s", got)
+	}
+	if got := retryWait(&http.Response{Header: http.Header{}}, 2); got != 4*retryBackoff {
+		t.Errorf("backoff: got %s", got)
+	}
+}
+
+func TestRegistryClientSharesTokens(t *testing.T) {
+	var tokens atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokens.Add(1)
+			_, _ = w.Write([]byte(`{"token":"abc"}`))
+		case r.Header.Get("Authorization") != "Bearer abc":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test",scope="repository:plugins/ping:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:"+strings.Repeat("a", 64))
+			w.Header().Set("Content-Length", "2")
+		}
+	}))
+	defer srv.Close()
+
+	ref, err := registry.ParseReference(srv.Listener.Addr().String() + "/plugins/ping:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		repo, err := NewRemoteRepository(context.Background(), ref, nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Resolve(context.Background(), ref.Reference); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+	if tokens.Load() != 1 {
+		t.Errorf("expected one token request across clients, got %d", tokens.Load())
+	}
+}
@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Requirements is an installable list of plugins, as written by "plugin
+// freeze" and read by "plugin install -f". JSON documents parse too.
+type Requirements struct {
+	Plugins []Requirement `json:"plugins" yaml:"plugins"`
+}
+
+// Requirement pins one plugin to a version in a registry, and to the
+// digest the installed artifact must have.
+type Requirement struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Digest  string `json:"digest,omitempty" yaml:"digest,omitempty"`
+
+	// Registry is the repository prefix the plugin is pulled from, such as
+	// "ghcr.io/reglet-dev/reglet-plugins".
+	Registry string `json:"registry" yaml:"registry"`
+
+	// Index is the index the plugin was chosen from, recorded again on
+	// install so source-qualified names keep resolving.
+	Index string `json:"index,omitempty" yaml:"index,omitempty"`
+}
+
+// Reference returns the OCI reference the requirement is pulled from.
+func (r Requirement) Reference() string {
+	return fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(r.Registry, "/"), r.Name, r.Version)
+}
+
+// ParseRequirements parses a requirements document, refusing entries that
+// leave out the name, version, or registry.
+func ParseRequirements(data []byte) (*Requirements, error) {
+	var reqs Requirements
+	if err := yaml.Unmarshal(data, &reqs); err != nil {
+		return nil, fmt.Errorf("parsing requirements: %w", err)
+	}
+	var errs []error
+	for i, r := range reqs.Plugins {
+		var missing []string
+		for _, f := range [][2]string{{"name", r.Name}, {"version", r.Version}, {"registry", r.Registry}} {
+			if f[1] == "" {
+				missing = append(missing, f[0])
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("plugin %d: missing %s", i+1, strings.Join(missing, ", ")))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid requirements: %w", err)
+	}
+	return &reqs, nil
+}
+
+// LoadRequirements reads a requirements document from path, or from
+// stdin when path is "-".
+func LoadRequirements(path string) (*Requirements, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading requirements: %w", err)
+	}
+	return ParseRequirements(data)
+}
@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRequirements(t *testing.T) {
+	reqs, err := ParseRequirements([]byte(`
+plugins:
+  - name: dns
+    version: 1.2.0
+    digest: sha256:abc
+    registry: ghcr.io/reglet-dev/reglet-plugins/
+    index: official
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs.Plugins) != 1 || reqs.Plugins[0].Index != "official" {
+		t.Fatalf("parsed %+v", reqs)
+	}
+	if got, want := reqs.Plugins[0].Reference(), "ghcr.io/reglet-dev/reglet-plugins/dns:1.2.0"; got != want {
+		t.Errorf("Reference() = %q, want %q", got, want)
+	}
+
+	// JSON, as written by "plugin freeze --output json", parses too
+	if _, err := ParseRequirements([]byte(`{"plugins": [{"name": "dns", "version": "1.2.0", "registry": "ghcr.io/a/b"}]}`)); err != nil {
+		t.Errorf("expected JSON requirements to parse, got %v", err)
+	}
+
+	_, err = ParseRequirements([]byte("plugins:\n  - name: dns\n  - version: 1.0.0\n    registry: ghcr.io/a/b\n"))
+	if err == nil || !strings.Contains(err.Error(), "plugin 1: missing version, registry") || !strings.Contains(err.Error(), "plugin 2: missing name") {
+		t.Errorf("expected every incomplete entry to be reported, got %v", err)
+	}
+}
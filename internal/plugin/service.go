@@ -7,13 +7,15 @@ import (
 	"path/filepath"
 
 	hostplugin "github.com/reglet-dev/reglet-host-sdk/plugin"
-	hostoci "github.com/reglet-dev/reglet-host-sdk/plugin/oci"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
 	hostrepository "github.com/reglet-dev/reglet-host-sdk/plugin/repository"
 	hostresolvers "github.com/reglet-dev/reglet-host-sdk/plugin/resolvers"
 	hostservices "github.com/reglet-dev/reglet-host-sdk/plugin/services"
 	hostsigning "github.com/reglet-dev/reglet-host-sdk/plugin/signing"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
 
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
 )
 
 // PluginServiceConfig holds configuration for the plugin service stack.
@@ -25,6 +27,10 @@ type PluginServiceConfig struct {
 	// RequireSigning controls whether signature verification is mandatory.
 	RequireSigning bool
 
+	// PlainHTTPRegistries lists registry hosts pulled over plain HTTP,
+	// such as a local development registry.
+	PlainHTTPRegistries []string
+
 	// Logger for plugin operations. If nil, uses slog.Default().
 	Logger *slog.Logger
 }
@@ -33,6 +39,10 @@ type PluginServiceConfig struct {
 type PluginStack struct {
 	Service    *hostplugin.PluginService
 	Repository *hostrepository.FSPluginRepository
+	Registry   ports.PluginRegistry
+
+	// QuarantineDir receives artifacts that fail verification.
+	QuarantineDir string
 }
 
 // NewPluginStack creates the full host-sdk plugin management stack.
@@ -43,12 +53,13 @@ func NewPluginStack(cfg PluginServiceConfig) (*PluginStack, error) {
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
+	cfg.Logger = NewRedactingLogger(cfg.Logger)
 
-	// 1. Auth Provider (env-based: REGISTRY_USERNAME, REGISTRY_PASSWORD)
-	authProvider := hostoci.NewEnvAuthProvider()
+	// 1. Auth Provider (REGISTRY_USERNAME, REGISTRY_PASSWORD, then the keyring)
+	authProvider := NewCredentialProvider()
 
 	// 2. OCI Registry Adapter
-	registryAdapter := hostoci.NewOCIRegistryAdapter(authProvider)
+	registryAdapter := NewRegistryAdapter(authProvider, cfg.PlainHTTPRegistries)
 
 	// 3. Local Plugin Cache
 	repository, err := hostrepository.NewFSPluginRepository(cfg.CacheDir)
@@ -80,11 +91,31 @@ func NewPluginStack(cfg PluginServiceConfig) (*PluginStack, error) {
 	)
 
 	return &PluginStack{
-		Service:    service,
-		Repository: repository,
+		Service:       service,
+		Repository:    repository,
+		Registry:      registryAdapter,
+		QuarantineDir: QuarantineDir(cfg.CacheDir),
 	}, nil
 }
 
+// PulledVariant returns the variant chosen when ref was last pulled from a
+// multi-variant index during this process.
+func (s *PluginStack) PulledVariant(ref hostvalues.PluginReference) (Variant, bool) {
+	if a, ok := s.Registry.(*RegistryAdapter); ok {
+		return a.PulledVariant(ref)
+	}
+	return Variant{}, false
+}
+
+// PulledProvenance returns the provenance annotations of the artifact last
+// pulled for ref during this process.
+func (s *PluginStack) PulledProvenance(ref hostvalues.PluginReference) (Provenance, bool) {
+	if a, ok := s.Registry.(*RegistryAdapter); ok {
+		return a.PulledProvenance(ref)
+	}
+	return Provenance{}, false
+}
+
 // DefaultPluginsDir returns the default local plugin cache directory.
 // ~/.tack/plugins/
 func DefaultPluginsDir() string {
@@ -95,6 +126,20 @@ func DefaultPluginsDir() string {
 	return filepath.Join(home, "."+meta.AppName, "plugins")
 }
 
+// ProjectPluginsDir returns the plugins directory of the workspace, whose
+// plugins take precedence over the user's once it is trusted, or ./.tack/plugins/ to start a
+// workspace in the working directory when there is none.
+func ProjectPluginsDir() string {
+	if dir := workspace.Dir(); dir != "" {
+		return filepath.Join(dir, "plugins")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.Join(workspace.DirName, "plugins")
+	}
+	return filepath.Join(wd, workspace.DirName, "plugins")
+}
+
 // EnsurePluginsDir creates the plugins directory if it doesn't exist.
 func EnsurePluginsDir(dir string) error {
 	return os.MkdirAll(dir, 0o755)
@@ -0,0 +1,320 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// Cosign signature layout constants.
+const (
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	AnnotationSignature    = "dev.cosignproject.cosign/signature"
+
+	// ArtifactTypeSignature is the artifact type cosign gives signatures
+	// it attaches through the OCI referrers API.
+	ArtifactTypeSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// PasswordEnv is the environment variable holding the signing key
+	// password, shared with the cosign CLI.
+	PasswordEnv = "COSIGN_PASSWORD"
+)
+
+// DefaultKeyPrefix returns the default key pair location without extension.
+// ~/.tack/cosign
+func DefaultKeyPrefix() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "."+meta.AppName, "cosign")
+	}
+	return filepath.Join(home, "."+meta.AppName, "cosign")
+}
+
+// DefaultSigningKeyPath returns the default private key location.
+// ~/.tack/cosign.key
+func DefaultSigningKeyPath() string {
+	return DefaultKeyPrefix() + ".key"
+}
+
+// DefaultPublicKeyPath returns the default public key location.
+// ~/.tack/cosign.pub
+func DefaultPublicKeyPath() string {
+	return DefaultKeyPrefix() + ".pub"
+}
+
+// SignatureTag returns the tag cosign uses for signatures of a manifest
+// digest: "sha256:abc..." becomes "sha256-abc....sig".
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// LoadSigner reads a cosign private key. The password comes from
+// COSIGN_PASSWORD; unencrypted keys need none.
+func LoadSigner(keyPath string) (signature.SignerVerifier, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+	sv, err := cosign.LoadPrivateKey(data, []byte(os.Getenv(PasswordEnv)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("loading signing key %s: %w", keyPath, err)
+	}
+	return sv, nil
+}
+
+// simpleSigningPayload returns the cosign "simple signing" payload binding
+// a repository to a manifest digest.
+func simpleSigningPayload(repository, digest string) ([]byte, error) {
+	type identity struct {
+		DockerReference string `json:"docker-reference"`
+	}
+	type image struct {
+		DockerManifestDigest string `json:"docker-manifest-digest"`
+	}
+	return json.Marshal(map[string]any{
+		"critical": map[string]any{
+			"identity": identity{repository},
+			"image":    image{digest},
+			"type":     "cosign container image signature",
+		},
+		"optional": nil,
+	})
+}
+
+// Sign signs the manifest digest of an artifact in repository and stores a
+// cosign-compatible signature manifest in target. The signature names the
+// artifact as its subject, so the referrers API lists it, and is also
+// tagged SignatureTag(digest) for clients that only know the tag
+// convention. When target cannot resolve digest, only the tag is written.
+func Sign(ctx context.Context, target oras.Target, repository, digest string, signer signature.Signer) (ocispec.Descriptor, error) {
+	payload, err := simpleSigningPayload(repository, digest)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("encoding signature payload: %w", err)
+	}
+	sig, err := signer.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("signing: %w", err)
+	}
+
+	layer, err := pushBlob(ctx, target, MediaTypeSimpleSigning, payload, map[string]string{
+		AnnotationSignature: base64.StdEncoding.EncodeToString(sig),
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	// Cosign signature images carry a minimal image config.
+	cfg, _ := json.Marshal(map[string]any{
+		"architecture": "",
+		"os":           "",
+		"config":       map[string]any{},
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": []string{layer.Digest.String()}},
+	})
+	configDesc, err := pushBlob(ctx, target, ocispec.MediaTypeImageConfig, cfg, nil)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	opts := oras.PackManifestOptions{
+		ConfigDescriptor: &configDesc,
+		Layers:           []ocispec.Descriptor{layer},
+	}
+	if subject, err := target.Resolve(ctx, digest); err == nil {
+		opts.Subject = &subject
+	}
+	desc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, ArtifactTypeSignature, opts)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("packing signature: %w", err)
+	}
+	if err := target.Tag(ctx, desc, SignatureTag(digest)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("tagging signature: %w", err)
+	}
+	return desc, nil
+}
+
+// LoadVerifier reads a PEM-encoded public key.
+func LoadVerifier(pubPath string) (signature.Verifier, error) {
+	data, err := os.ReadFile(pubPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %s: %w", pubPath, err)
+	}
+	v, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("loading public key %s: %w", pubPath, err)
+	}
+	return v, nil
+}
+
+// GenerateKeys creates a cosign key pair encrypted with password and writes
+// it to <prefix>.key and <prefix>.pub. Existing files are only replaced when
+// force is set.
+func GenerateKeys(prefix string, password []byte, force bool) (keyPath, pubPath string, err error) {
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return password, nil })
+	if err != nil {
+		return "", "", fmt.Errorf("generating key pair: %w", err)
+	}
+	return writeKeys(prefix, keys, force)
+}
+
+// ImportKeys converts an existing PEM private key (PKCS#1, PKCS#8, or EC)
+// into a cosign key pair encrypted with password.
+func ImportKeys(srcPath, prefix string, password []byte, force bool) (keyPath, pubPath string, err error) {
+	keys, err := cosign.ImportKeyPair(srcPath, func(bool) ([]byte, error) { return password, nil })
+	if err != nil {
+		return "", "", fmt.Errorf("importing key %s: %w", srcPath, err)
+	}
+	return writeKeys(prefix, keys, force)
+}
+
+func writeKeys(prefix string, keys *cosign.KeysBytes, force bool) (string, string, error) {
+	keyPath, pubPath := prefix+".key", prefix+".pub"
+	if !force {
+		for _, p := range []string{keyPath, pubPath} {
+			if _, err := os.Stat(p); err == nil {
+				return "", "", fmt.Errorf("%s already exists (use --force to overwrite)", p)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+		return "", "", fmt.Errorf("creating key directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, keys.PrivateBytes, 0o600); err != nil {
+		return "", "", fmt.Errorf("writing private key: %w", err)
+	}
+	if err := os.WriteFile(pubPath, keys.PublicBytes, 0o644); err != nil {
+		return "", "", fmt.Errorf("writing public key: %w", err)
+	}
+	return keyPath, pubPath, nil
+}
+
+// SignBlob signs a local file's contents and returns the base64 signature,
+// compatible with "cosign sign-blob" / "cosign verify-blob".
+func SignBlob(data []byte, signer signature.Signer) (string, error) {
+	sig, err := signer.SignMessage(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("signing: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyBlob checks a base64 signature produced by SignBlob.
+func VerifyBlob(data []byte, sigB64 string, verifier signature.Verifier) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sigB64))
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)); err != nil {
+		return errcode.Errorf(errcode.SignatureInvalid, "signature does not match: %w", err)
+	}
+	return nil
+}
+
+// Verify checks that target holds a cosign signature for digest, made by
+// verifier's key, whose payload names that digest. Signatures are looked up
+// with the referrers API and under SignatureTag(digest), so registries that
+// garbage-collect signature tags, or only keep referrers, still verify.
+func Verify(ctx context.Context, target oras.ReadOnlyTarget, digest string, verifier signature.Verifier) error {
+	sigs, err := signatureManifests(ctx, target, digest)
+	if err != nil {
+		return err
+	}
+	for _, sigDesc := range sigs {
+		ok, err := verifySignatureManifest(ctx, target, sigDesc, digest, verifier)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return errcode.Errorf(errcode.SignatureInvalid, "no signature for %s matches the given key", digest)
+}
+
+// signatureManifests returns the signature manifests for digest: those the
+// referrers API lists, then the one tagged SignatureTag(digest) if it is
+// not among them. A registry without the referrers API, or one that fails
+// to list them, falls back to the tag.
+func signatureManifests(ctx context.Context, target oras.ReadOnlyTarget, digest string) ([]ocispec.Descriptor, error) {
+	var sigs []ocispec.Descriptor
+	if store, ok := target.(content.ReadOnlyGraphStorage); ok {
+		if subject, err := target.Resolve(ctx, digest); err == nil {
+			referrers, err := registry.Referrers(ctx, store, subject, ArtifactTypeSignature)
+			if err != nil && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			sigs = append(sigs, referrers...)
+		}
+	}
+
+	tagged, err := target.Resolve(ctx, SignatureTag(digest))
+	if err != nil {
+		if len(sigs) == 0 {
+			return nil, errcode.Errorf(errcode.SignatureInvalid, "no signature found for %s: %w", digest, err)
+		}
+		return sigs, nil
+	}
+	for _, desc := range sigs {
+		if desc.Digest == tagged.Digest {
+			return sigs, nil
+		}
+	}
+	return append(sigs, tagged), nil
+}
+
+// verifySignatureManifest reports whether a signature manifest holds a
+// signature by verifier's key whose payload names digest.
+func verifySignatureManifest(ctx context.Context, target oras.ReadOnlyTarget, sigDesc ocispec.Descriptor, digest string, verifier signature.Verifier) (bool, error) {
+	raw, err := content.FetchAll(ctx, target, sigDesc)
+	if err != nil {
+		return false, fmt.Errorf("fetching signature manifest: %w", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return false, fmt.Errorf("decoding signature manifest: %w", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeSimpleSigning {
+			continue
+		}
+		payload, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return false, fmt.Errorf("fetching signature payload: %w", err)
+		}
+		if VerifyBlob(payload, layer.Annotations[AnnotationSignature], verifier) != nil {
+			continue
+		}
+
+		var p struct {
+			Critical struct {
+				Image struct {
+					Digest string `json:"docker-manifest-digest"`
+				} `json:"image"`
+			} `json:"critical"`
+		}
+		if err := json.Unmarshal(payload, &p); err == nil && p.Critical.Image.Digest == digest {
+			return true, nil
+		}
+	}
+	return false, nil
+}
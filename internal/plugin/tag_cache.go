@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// DefaultTagCacheTTL is how long a tag's resolved digest is trusted before
+// the registry is asked again, unless SetTagCacheTTL says otherwise.
+const DefaultTagCacheTTL = 5 * time.Minute
+
+var tagCacheTTL atomic.Int64
+
+func init() {
+	tagCacheTTL.Store(int64(DefaultTagCacheTTL))
+}
+
+// SetTagCacheTTL sets how long a tag's resolved digest is trusted. Zero or
+// less resolves every tag against its registry, as --no-cache does.
+func SetTagCacheTTL(d time.Duration) {
+	tagCacheTTL.Store(int64(max(d, 0)))
+}
+
+// TagEntry records what an OCI tag resolved to.
+type TagEntry struct {
+	// Digest is the manifest digest the tag pointed at.
+	Digest string `json:"digest"`
+
+	// ResolvedAt is when the registry last confirmed it.
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// RefreshTag keeps the cached copy of a tagged reference in step with its
+// registry. A tag resolved within the TTL is trusted without asking the
+// registry. Otherwise the tag is resolved with a HEAD request for its
+// manifest; when it has moved, the cached copy is removed so that the next
+// pull fetches what it points at now. A registry that cannot be reached
+// leaves the cached copy in place, so plugins keep loading offline.
+func (s *PluginStack) RefreshTag(ctx context.Context, ref hostvalues.PluginReference, cachePath string) error {
+	if s.Registry == nil || ref.IsEmbedded() {
+		return nil
+	}
+	key := ref.String()
+	cache := LoadCache(cachePath)
+	prev, known := cache.Tags[key]
+	if known && time.Since(prev.ResolvedAt) < time.Duration(tagCacheTTL.Load()) {
+		return nil
+	}
+
+	digest, err := s.Registry.Resolve(ctx, ref)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return nil
+	}
+
+	if known && prev.Digest != digest.String() {
+		if _, _, err := s.Repository.Find(ctx, ref); err == nil {
+			if err := s.Repository.Delete(ctx, ref); err != nil {
+				return err
+			}
+		}
+	}
+	cache.Tags[key] = TagEntry{Digest: digest.String(), ResolvedAt: time.Now()}
+	_ = cache.Save(cachePath)
+	return nil
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// resolvingRegistry resolves every tag to digest, or fails with err, and
+// counts the calls.
+type resolvingRegistry struct {
+	digest string
+	err    error
+	calls  int
+}
+
+func (r *resolvingRegistry) Pull(context.Context, hostvalues.PluginReference) (*dto.PluginArtifactDTO, error) {
+	return nil, errors.New("not supported")
+}
+
+func (r *resolvingRegistry) Push(context.Context, *dto.PluginArtifactDTO) error {
+	return errors.New("not supported")
+}
+
+func (r *resolvingRegistry) Resolve(context.Context, hostvalues.PluginReference) (hostvalues.Digest, error) {
+	r.calls++
+	if r.err != nil {
+		return hostvalues.Digest{}, r.err
+	}
+	return hostvalues.ParseDigest(r.digest)
+}
+
+func TestRefreshTag(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	stack, err := NewPluginStack(PluginServiceConfig{CacheDir: filepath.Join(dir, "plugins")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &resolvingRegistry{digest: "sha256:" + string(bytes.Repeat([]byte("a"), 64))}
+	stack.Registry = registry
+	cachePath := filepath.Join(dir, "cache.json")
+	t.Cleanup(func() { SetTagCacheTTL(DefaultTagCacheTTL) })
+
+	ref, _ := hostvalues.ParsePluginReference("ghcr.io/acme/plugins/dns:latest")
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	digest, _ := hostvalues.ComputeDigestSHA256(bytes.NewReader(wasm))
+	p := hostentities.NewPlugin(ref, digest, hostvalues.NewPluginMetadata("dns", "1.0.0", "", nil))
+	if _, err := stack.Repository.Store(ctx, p, bytes.NewReader(wasm)); err != nil {
+		t.Fatal(err)
+	}
+	cached := func() bool {
+		_, _, err := stack.Repository.Find(ctx, ref)
+		return err == nil
+	}
+
+	// The first resolve records the digest and keeps the cached copy
+	if err := stack.RefreshTag(ctx, ref, cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if registry.calls != 1 || !cached() {
+		t.Fatalf("expected one resolve that keeps the copy, got %d calls, cached %v", registry.calls, cached())
+	}
+
+	// Within the TTL the registry is not asked again
+	if err := stack.RefreshTag(ctx, ref, cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if registry.calls != 1 {
+		t.Errorf("expected the cached resolution to be used, got %d calls", registry.calls)
+	}
+
+	// Without the cache, an unchanged tag keeps the copy
+	SetTagCacheTTL(0)
+	if err := stack.RefreshTag(ctx, ref, cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if registry.calls != 2 || !cached() {
+		t.Errorf("expected a fresh resolve that keeps the copy, got %d calls, cached %v", registry.calls, cached())
+	}
+
+	// An unreachable registry leaves the copy in place
+	registry.err = errors.New("connection refused")
+	if err := stack.RefreshTag(ctx, ref, cachePath); err != nil || !cached() {
+		t.Errorf("expected an offline refresh to keep the copy, got %v, cached %v", err, cached())
+	}
+
+	// A moved tag drops the copy so that the next pull fetches it
+	registry.err = nil
+	registry.digest = "sha256:" + string(bytes.Repeat([]byte("b"), 64))
+	if err := stack.RefreshTag(ctx, ref, cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if cached() {
+		t.Error("expected the copy of a moved tag to be removed")
+	}
+	if got := LoadCache(cachePath).Tags[ref.String()]; got.Digest != registry.digest || time.Since(got.ResolvedAt) > time.Minute {
+		t.Errorf("unexpected tag entry: %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportOptions configures outbound HTTP for index fetches and OCI
+// registry traffic.
+type TransportOptions struct {
+	// Proxy is the proxy URL for HTTP and HTTPS requests. When empty, the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string
+
+	// NoProxy lists hosts, domains (".corp.example") and CIDRs reached
+	// directly, comma-separated. It is only used with Proxy.
+	NoProxy string
+
+	// CAFiles are PEM bundles trusted in addition to the system roots,
+	// for TLS-intercepting proxies and private registries.
+	CAFiles []string
+
+	// InsecureHosts are hosts ("registry.internal" or
+	// "registry.internal:5000") whose TLS certificates are not verified.
+	InsecureHosts []string
+}
+
+// baseTransport is the standard library transport configured transports
+// start from.
+var baseTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+// ConfigureHTTP installs a transport built from opts as
+// http.DefaultTransport, which index fetches and OCI registry clients
+// (including the host SDK's) use.
+func ConfigureHTTP(opts TransportOptions) error {
+	t, err := NewTransport(opts)
+	if err != nil {
+		return err
+	}
+	http.DefaultTransport = t
+	return nil
+}
+
+// NewTransport returns an HTTP transport honoring opts.
+func NewTransport(opts TransportOptions) (http.RoundTripper, error) {
+	secure := baseTransport.Clone()
+
+	if opts.Proxy != "" {
+		if _, err := url.Parse(opts.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", opts.Proxy, err)
+		}
+		proxy := (&httpproxy.Config{HTTPProxy: opts.Proxy, HTTPSProxy: opts.Proxy, NoProxy: opts.NoProxy}).ProxyFunc()
+		secure.Proxy = func(req *http.Request) (*url.URL, error) { return proxy(req.URL) }
+	}
+
+	if len(opts.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, path := range opts.CAFiles {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", path)
+			}
+		}
+		secure.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if len(opts.InsecureHosts) == 0 {
+		return secure, nil
+	}
+	insecure := secure.Clone()
+	if insecure.TLSClientConfig == nil {
+		insecure.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	insecure.TLSClientConfig.InsecureSkipVerify = true
+
+	hosts := make(map[string]bool, len(opts.InsecureHosts))
+	for _, h := range opts.InsecureHosts {
+		hosts[strings.ToLower(strings.TrimSpace(h))] = true
+	}
+	return &hostTransport{secure: secure, insecure: insecure, insecureHosts: hosts}, nil
+}
+
+// hostTransport skips TLS verification for selected hosts only.
+type hostTransport struct {
+	secure, insecure *http.Transport
+	insecureHosts    map[string]bool
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.skipVerify(req.URL) {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
+
+// skipVerify matches a URL's host, with or without its port.
+func (t *hostTransport) skipVerify(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	if t.insecureHosts[host] {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return t.insecureHosts[h]
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransport_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	get := func(opts TransportOptions) error {
+		rt, err := NewTransport(opts)
+		if err != nil {
+			t.Fatalf("NewTransport: %v", err)
+		}
+		resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(TransportOptions{}); err == nil {
+		t.Error("expected an untrusted certificate to be rejected")
+	}
+	if err := get(TransportOptions{InsecureHosts: []string{"other.example"}}); err == nil {
+		t.Error("expected verification for hosts not listed")
+	}
+	if err := get(TransportOptions{InsecureHosts: []string{host}}); err != nil {
+		t.Errorf("expected an insecure host to skip verification: %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	_ = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644)
+	if err := get(TransportOptions{CAFiles: []string{caFile}}); err != nil {
+		t.Errorf("expected the CA bundle to be trusted: %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	_ = os.WriteFile(empty, []byte("not a certificate"), 0o644)
+	if _, err := NewTransport(TransportOptions{CAFiles: []string{empty}}); err == nil {
+		t.Error("expected an error for a bundle without certificates")
+	}
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	rt, err := NewTransport(TransportOptions{Proxy: "http://proxy.corp:3128", NoProxy: ".internal"})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	proxy := rt.(*http.Transport).Proxy
+
+	req, _ := http.NewRequest(http.MethodGet, "https://ghcr.io/v2/", nil)
+	if u, err := proxy(req); err != nil || u == nil || u.Host != "proxy.corp:3128" {
+		t.Errorf("expected the proxy for ghcr.io, got %v, %v", u, err)
+	}
+	req.URL, _ = url.Parse("https://registry.internal/v2/")
+	if u, err := proxy(req); err != nil || u != nil {
+		t.Errorf("expected no proxy for a no_proxy domain, got %v, %v", u, err)
+	}
+}
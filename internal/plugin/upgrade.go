@@ -0,0 +1,176 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+// ErrNewCapabilities is returned when a newer version of a plugin requests
+// access the installed version did not have and the upgrade was not
+// confirmed.
+var ErrNewCapabilities = errcode.New(errcode.NewCapabilities, "plugin requests new capabilities")
+
+// CapabilityChange describes the access a newly fetched plugin version
+// requests beyond the version installed before it.
+type CapabilityChange struct {
+	Plugin      string
+	FromVersion string
+	ToVersion   string
+	Added       []string // e.g. `network access "api.example.com:443"`
+}
+
+// Err describes the change as a refusal.
+func (c CapabilityChange) Err() error {
+	return fmt.Errorf("%s %s requests access %s did not have (%s): %w",
+		c.Plugin, c.ToVersion, c.FromVersion, strings.Join(c.Added, ", "), ErrNewCapabilities)
+}
+
+// ConfirmFunc decides whether a plugin upgrade that requests new
+// capabilities may proceed. Returning an error refuses it.
+type ConfirmFunc func(CapabilityChange) error
+
+// AddedCapabilities lists the access next requests that prev does not
+// already cover, so widening a host to a wildcard is reported and
+// narrowing one is not.
+func AddedCapabilities(prev, next *hostfunc.GrantSet) []string {
+	var added []string
+	for _, r := range uncovered(prev, next) {
+		added = append(added, r.String())
+	}
+	return added
+}
+
+// ConfirmUpgradesWith sets how plugins fetched by LoadByName are confirmed
+// when they request more access than the version installed before them.
+// Without it such upgrades are refused.
+func (l *Loader) ConfirmUpgradesWith(confirm ConfirmFunc) *Loader {
+	l.confirm = confirm
+	return l
+}
+
+// UpgradeGuard remembers the newest installed version of a plugin before a
+// pull, so that whatever the pull installs can be compared with it.
+type UpgradeGuard struct {
+	loader   *Loader
+	name     string
+	version  string
+	manifest *abi.Manifest   // nil when no earlier version is installed
+	digests  map[string]bool // binaries installed before the pull
+}
+
+// GuardUpgrade snapshots the installed versions of the named plugin. An
+// installed version whose module cannot be instantiated, or whose manifest
+// section does not match it, is treated as absent.
+func (l *Loader) GuardUpgrade(ctx context.Context, name string) *UpgradeGuard {
+	g := &UpgradeGuard{loader: l, name: name, digests: map[string]bool{}}
+	if l.stack == nil {
+		return g
+	}
+	plugins, err := l.stack.Service.ListCachedPlugins(ctx)
+	if err != nil {
+		return g
+	}
+	var newest hostvalues.PluginReference
+	for _, p := range plugins {
+		meta := p.Metadata()
+		if meta.Name() != name {
+			continue
+		}
+		g.digests[p.Digest().String()] = true
+		if g.version == "" || NewerVersion(meta.Version(), g.version) {
+			g.version, newest = meta.Version(), p.Reference()
+		}
+	}
+	if g.version == "" {
+		return g
+	}
+	if manifest, _, err := l.installedManifest(ctx, newest); err == nil {
+		g.manifest = &manifest
+	}
+	return g
+}
+
+// Check compares the plugin now installed at ref with the version the
+// guard remembered, by the manifests their modules report rather than
+// their manifest sections. When it is a new binary that requests more
+// access, confirm decides; a nil confirm refuses. A refused version, or
+// one whose manifest section does not match its module, is removed again,
+// so it is not picked up unconfirmed later.
+func (g *UpgradeGuard) Check(ctx context.Context, ref hostvalues.PluginReference, confirm ConfirmFunc) error {
+	if g.manifest == nil {
+		return nil
+	}
+	next, digest, err := g.loader.installedManifest(ctx, ref)
+	if errors.Is(err, ErrManifestMismatch) {
+		return g.remove(ctx, ref, err)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s manifest: %w", g.name, err)
+	}
+	if g.digests[digest] {
+		return nil
+	}
+	added := AddedCapabilities(&g.manifest.Capabilities, &next.Capabilities)
+	if len(added) == 0 {
+		return nil
+	}
+
+	change := CapabilityChange{
+		Plugin:      g.name,
+		FromVersion: g.version,
+		ToVersion:   next.Version,
+		Added:       added,
+	}
+	if change.ToVersion == "" {
+		change.ToVersion = ref.Version()
+	}
+	err = change.Err()
+	if confirm != nil {
+		err = confirm(change)
+	}
+	if err != nil {
+		return g.remove(ctx, ref, err)
+	}
+	return nil
+}
+
+// remove deletes the refused version at ref and returns err.
+func (g *UpgradeGuard) remove(ctx context.Context, ref hostvalues.PluginReference, err error) error {
+	if delErr := g.loader.stack.Repository.Delete(ctx, ref); delErr != nil {
+		return errors.Join(err, fmt.Errorf("removing %s: %w", ref, delErr))
+	}
+	return err
+}
+
+// installedManifest reads the manifest an installed plugin's module
+// reports. Neither the discovery cache nor the manifest section is used,
+// since both may hold what the section claims; a section that disagrees
+// with the module fails with ErrManifestMismatch. Binaries are inspected
+// in a throwaway runtime so no instance is kept for a version that may
+// never run.
+func (l *Loader) installedManifest(ctx context.Context, ref hostvalues.PluginReference) (abi.Manifest, string, error) {
+	_, path, err := l.stack.Repository.Find(ctx, ref)
+	if err != nil {
+		return abi.Manifest{}, "", err
+	}
+	data, digest, err := readPluginFile(path)
+	if err != nil {
+		return abi.Manifest{}, "", err
+	}
+
+	runner, err := runtime.NewPluginRunner(ctx)
+	if err != nil {
+		return abi.Manifest{}, "", err
+	}
+	defer func() { _ = runner.Close(ctx) }()
+	manifest, err := runner.ReadManifest(ctx, data)
+	return manifest, digest, err
+}
@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	hostentities "github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+func TestAddedCapabilities(t *testing.T) {
+	prev := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*.example.com"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/**"}}}},
+		Env:     &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+	}
+	next := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com", "evil.test"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}, Write: []string{"/tmp/out"}}}},
+		Env:     &hostfunc.EnvironmentCapability{Variables: []string{"HOME", "AWS_SECRET_ACCESS_KEY"}},
+		Exec:    &hostfunc.ExecCapability{Commands: []string{"sh"}},
+	}
+
+	want := []string{
+		`network access "evil.test:443"`,
+		`filesystem write "/tmp/out"`,
+		`environment variable "AWS_SECRET_ACCESS_KEY"`,
+		`command "sh"`,
+	}
+	if got := AddedCapabilities(prev, next); !slices.Equal(got, want) {
+		t.Errorf("AddedCapabilities = %q, want %q", got, want)
+	}
+	want = []string{`network access "*.example.com:443"`, `filesystem read "/etc/**"`}
+	if got := AddedCapabilities(next, prev); !slices.Equal(got, want) {
+		t.Errorf("expected the wider host and path when downgrading, got %q", got)
+	}
+	if got := AddedCapabilities(nil, &hostfunc.GrantSet{Exec: next.Exec}); len(got) != 1 {
+		t.Errorf("expected everything to be new without a previous version, got %q", got)
+	}
+}
+
+func TestUpgradeGuard(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	stack, err := NewPluginStack(PluginServiceConfig{CacheDir: filepath.Join(dir, "plugins")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), stack, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+
+	store := func(version string, caps hostfunc.GrantSet) hostvalues.PluginReference {
+		t.Helper()
+		return storeModule(t, stack, version, manifestModule(t, abi.Manifest{Name: "dns", Version: version, Capabilities: caps}))
+	}
+	exec := hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"dig"}}}
+
+	// The first install has nothing to compare with
+	guard := loader.GuardUpgrade(ctx, "dns")
+	v1 := store("1.0.0", hostfunc.GrantSet{})
+	if err := guard.Check(ctx, v1, nil); err != nil {
+		t.Fatalf("first install: %v", err)
+	}
+
+	// An upgrade that adds a command is refused and removed again
+	guard = loader.GuardUpgrade(ctx, "dns")
+	v2 := store("2.0.0", exec)
+	if err := guard.Check(ctx, v2, nil); !errors.Is(err, ErrNewCapabilities) {
+		t.Fatalf("expected ErrNewCapabilities, got %v", err)
+	}
+	if _, _, err := stack.Repository.Find(ctx, v2); err == nil {
+		t.Error("expected the refused version to be removed")
+	}
+
+	// ... unless it is confirmed
+	var seen CapabilityChange
+	guard = loader.GuardUpgrade(ctx, "dns")
+	v2 = store("2.0.0", exec)
+	err = guard.Check(ctx, v2, func(c CapabilityChange) error { seen = c; return nil })
+	if err != nil {
+		t.Fatalf("confirmed upgrade: %v", err)
+	}
+	if seen.FromVersion != "1.0.0" || seen.ToVersion != "2.0.0" || len(seen.Added) != 1 {
+		t.Errorf("unexpected change %+v", seen)
+	}
+
+	// Reinstalling a version already present asks nothing
+	guard = loader.GuardUpgrade(ctx, "dns")
+	if err := guard.Check(ctx, v2, nil); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+}
+
+func TestUpgradeGuard_SectionDisagreesWithModule(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	stack, err := NewPluginStack(PluginServiceConfig{CacheDir: filepath.Join(dir, "plugins")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), stack, "")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+
+	guard := loader.GuardUpgrade(ctx, "dns")
+	v1 := storeModule(t, stack, "1.0.0", manifestModule(t, abi.Manifest{Name: "dns", Version: "1.0.0"}))
+	if err := guard.Check(ctx, v1, nil); err != nil {
+		t.Fatalf("first install: %v", err)
+	}
+
+	// 2.0.0 runs commands but its section claims it needs nothing, as 1.0.0
+	module := manifestModule(t, abi.Manifest{Name: "dns", Version: "2.0.0", Capabilities: hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"sh"}}}})
+	wasm, err := EmbedManifestSection(module, abi.Manifest{Name: "dns", Version: "2.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard = loader.GuardUpgrade(ctx, "dns")
+	v2 := storeModule(t, stack, "2.0.0", wasm)
+	confirmed := false
+	err = guard.Check(ctx, v2, func(CapabilityChange) error { confirmed = true; return nil })
+	if !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("expected ErrManifestMismatch, got %v", err)
+	}
+	if confirmed {
+		t.Error("expected the upgrade to be refused without asking")
+	}
+	if _, _, err := stack.Repository.Find(ctx, v2); err == nil {
+		t.Error("expected the refused version to be removed")
+	}
+}
+
+// storeModule stores wasm in the plugin repository as dns at version.
+func storeModule(t *testing.T, stack *PluginStack, version string, wasm []byte) hostvalues.PluginReference {
+	t.Helper()
+	ref, _ := hostvalues.ParsePluginReference("ghcr.io/acme/plugins/dns:" + version)
+	digest, _ := hostvalues.ComputeDigestSHA256(bytes.NewReader(wasm))
+	p := hostentities.NewPlugin(ref, digest, hostvalues.NewPluginMetadata("dns", version, "", nil))
+	if _, err := stack.Repository.Store(context.Background(), p, bytes.NewReader(wasm)); err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// WASMArchitecture is the platform architecture of plugin variants in an
+// OCI index.
+const WASMArchitecture = "wasm"
+
+// hostTargets lists the WASI targets the runtime runs, best first.
+var hostTargets = []string{"wasip1"}
+
+// hostFeatures lists the optional WebAssembly features the runtime
+// enables: wazero's WebAssembly 2.0 core features.
+var hostFeatures = []string{
+	"bulk-memory",
+	"multi-value",
+	"mutable-global",
+	"nontrapping-float-to-int",
+	"reference-types",
+	"sign-extension",
+	"simd",
+}
+
+// Variant identifies one build of a plugin published in an OCI index: the
+// WASI target it is built for (platform.os) and the optional WebAssembly
+// features it needs (platform.os.features).
+type Variant struct {
+	Target   string   `json:"target" yaml:"target"`
+	Features []string `json:"features,omitempty" yaml:"features,omitempty"`
+}
+
+// String returns the variant as target+feature+..., such as "wasip1+simd".
+func (v Variant) String() string {
+	return strings.Join(append([]string{v.Target}, v.Features...), "+")
+}
+
+// runsOnHost reports whether the runtime supports the variant's target and
+// every feature it needs.
+func (v Variant) runsOnHost() bool {
+	if !slices.Contains(hostTargets, v.Target) {
+		return false
+	}
+	for _, f := range v.Features {
+		if !slices.Contains(hostFeatures, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// variantOf reads the variant of a manifest listed in an OCI index. ok is
+// false for manifests without a WASM platform, such as signatures.
+func variantOf(desc ocispec.Descriptor) (Variant, bool) {
+	p := desc.Platform
+	if p == nil || p.Architecture != WASMArchitecture || p.OS == "" {
+		return Variant{}, false
+	}
+	features := slices.Clone(p.OSFeatures)
+	slices.Sort(features)
+	return Variant{Target: p.OS, Features: features}, true
+}
+
+// SelectVariant picks the manifest of an OCI index that the runtime runs
+// best: on the first of its supported WASI targets, with the most optional
+// features among those it supports. Ties go to the earlier manifest.
+func SelectVariant(manifests []ocispec.Descriptor) (ocispec.Descriptor, Variant, error) {
+	var (
+		best      ocispec.Descriptor
+		bestV     Variant
+		found     bool
+		available []string
+	)
+	for _, desc := range manifests {
+		v, ok := variantOf(desc)
+		if !ok {
+			continue
+		}
+		available = append(available, v.String())
+		if !v.runsOnHost() {
+			continue
+		}
+		if found {
+			rank, bestRank := slices.Index(hostTargets, v.Target), slices.Index(hostTargets, bestV.Target)
+			if rank > bestRank || (rank == bestRank && len(v.Features) <= len(bestV.Features)) {
+				continue
+			}
+		}
+		best, bestV, found = desc, v, true
+	}
+	switch {
+	case found:
+		return best, bestV, nil
+	case len(available) == 0:
+		return ocispec.Descriptor{}, Variant{}, fmt.Errorf("index lists no %s variants", WASMArchitecture)
+	default:
+		return ocispec.Descriptor{}, Variant{}, fmt.Errorf("no variant runs on this host (available: %s; supported: %s with %s)",
+			strings.Join(available, ", "), strings.Join(hostTargets, ", "), strings.Join(hostFeatures, ", "))
+	}
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func variantDescriptor(target string, features ...string) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString(target + strings.Join(features, "+")),
+		Platform:  &ocispec.Platform{Architecture: WASMArchitecture, OS: target, OSFeatures: features},
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests []ocispec.Descriptor
+		want      string
+		err       string
+	}{
+		{"most features", []ocispec.Descriptor{variantDescriptor("wasip1"), variantDescriptor("wasip1", "simd"), variantDescriptor("wasip2")}, "wasip1+simd", ""},
+		{"unsupported feature", []ocispec.Descriptor{variantDescriptor("wasip1", "threads"), variantDescriptor("wasip1")}, "wasip1", ""},
+		{"features sorted", []ocispec.Descriptor{variantDescriptor("wasip1", "simd", "bulk-memory")}, "wasip1+bulk-memory+simd", ""},
+		{"none runs", []ocispec.Descriptor{variantDescriptor("wasip2"), variantDescriptor("wasip1", "threads")}, "", "available: wasip2, wasip1+threads"},
+		{"no platforms", []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageManifest}}, "", "no wasm variants"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, v, err := SelectVariant(tt.manifests)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil || v.String() != tt.want {
+				t.Errorf("SelectVariant = %s, %v; want %s", v, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchArtifactFromIndex(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	var manifests []ocispec.Descriptor
+	for _, build := range []struct {
+		wasm     string
+		target   string
+		features []string
+	}{
+		{"plain", "wasip1", nil},
+		{"simd", "wasip1", []string{"simd"}},
+		{"p2", "wasip2", nil},
+	} {
+		m := testPluginManifest()
+		m.Description = build.wasm + " build"
+		desc, err := Pack(ctx, store, build.wasm, []byte(build.wasm), m)
+		if err != nil {
+			t.Fatalf("Pack: %v", err)
+		}
+		desc.Platform = &ocispec.Platform{Architecture: WASMArchitecture, OS: build.target, OSFeatures: build.features}
+		manifests = append(manifests, desc)
+	}
+
+	index, _ := json.Marshal(ocispec.Index{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		MediaType:   ocispec.MediaTypeImageIndex,
+		Manifests:   manifests,
+		Annotations: map[string]string{ocispec.AnnotationLicenses: "Apache-2.0"},
+	})
+	indexDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: digest.FromBytes(index), Size: int64(len(index))}
+	if err := store.Push(ctx, indexDesc, bytes.NewReader(index)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, indexDesc, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	artifact, err := FetchArtifact(ctx, store, "1.0.0")
+	if err != nil {
+		t.Fatalf("FetchArtifact: %v", err)
+	}
+	if string(artifact.WASM) != "simd" || artifact.Variant.String() != "wasip1+simd" {
+		t.Errorf("expected the simd variant, got %q (%s)", artifact.WASM, artifact.Variant)
+	}
+	if artifact.Provenance.License != "Apache-2.0" {
+		t.Errorf("expected the index's license annotation, got %+v", artifact.Provenance)
+	}
+
+	// A single manifest has no variant
+	artifact, err = FetchArtifact(ctx, store, "plain")
+	if err != nil || artifact.Variant.Target != "" {
+		t.Errorf("expected no variant for a single manifest, got %+v (%v)", artifact.Variant, err)
+	}
+}
@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	hostvalues "github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// Candidate states in a Resolution.
+const (
+	CandidateSelected    = "selected"    // the candidate a load would use
+	CandidateShadowed    = "shadowed"    // present, but an earlier candidate is used
+	CandidateAbsent      = "absent"      // nothing there
+	CandidateRefused     = "refused"     // tried first, but loading it fails
+	CandidateUnavailable = "unavailable" // the source cannot be used in this setup
+	CandidateQuarantined = "quarantined" // moved out of the plugins directory
+)
+
+// Candidate is one place a plugin name may resolve to.
+type Candidate struct {
+	// Source is "index", "workspace", "local", "embedded", "oci", or
+	// "quarantine".
+	Source string `json:"source" yaml:"source"`
+
+	// Location is a file path, an embedded path, or an OCI reference.
+	Location string `json:"location" yaml:"location"`
+
+	Status string `json:"status" yaml:"status"`
+
+	// Reason says why the candidate has its status, when that is not
+	// obvious from the status alone.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// Path is the cached file an OCI reference resolves to.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Digest  string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// Resolution explains how LoadByName resolves a name: every candidate in
+// precedence order, and the one it would load.
+type Resolution struct {
+	Name       string      `json:"name" yaml:"name"`
+	Candidates []Candidate `json:"candidates" yaml:"candidates"`
+
+	// Selected is the candidate that would be loaded, or nil when loading
+	// fails; Error then says why.
+	Selected *Candidate `json:"selected,omitempty" yaml:"selected,omitempty"`
+	Error    string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Explain reports how name resolves without loading, pulling, or
+// quarantining anything. Candidates are listed in the order LoadByName
+// tries them, followed by quarantined artifacts of the same plugin.
+func (l *Loader) Explain(ctx context.Context, name string) Resolution {
+	res := Resolution{Name: name}
+	chosen := -1
+
+	// consider adds a candidate that exists. The first one is inspected
+	// and decides the result; later ones are shadowed by it.
+	consider := func(c Candidate, inspect func(*Candidate)) {
+		if chosen >= 0 {
+			first := res.Candidates[chosen]
+			c.Status = CandidateShadowed
+			c.Reason = fmt.Sprintf("%s %s is tried first", first.Source, first.Location)
+		} else {
+			c.Status = CandidateSelected
+			inspect(&c)
+		}
+		res.Candidates = append(res.Candidates, c)
+		if chosen < 0 && c.Status != CandidateUnavailable {
+			chosen = len(res.Candidates) - 1
+		}
+	}
+	absent := func(source, location string) {
+		res.Candidates = append(res.Candidates, Candidate{Source: source, Location: location, Status: CandidateAbsent})
+	}
+
+	pluginName, _ := parseNameVersion(name)
+	if source, rest, ok := SplitQualifiedName(name); ok {
+		pluginName, _ = parseNameVersion(rest)
+		record, ok := LoadCache(l.cachePath).Installed[pluginName]
+		switch {
+		case !ok:
+			res.Candidates = append(res.Candidates, Candidate{Source: "index", Location: source, Status: CandidateAbsent,
+				Reason: fmt.Sprintf("%s is not installed from %s", pluginName, source)})
+		case record.Source != source:
+			res.Candidates = append(res.Candidates, Candidate{Source: "index", Location: source, Status: CandidateAbsent,
+				Reason: fmt.Sprintf("%s is installed as %s", pluginName, record.QualifiedName(pluginName))})
+		default:
+			consider(Candidate{Source: "oci", Location: record.Reference, Reason: "installed from " + source}, func(c *Candidate) {
+				l.inspectOCI(ctx, c)
+			})
+		}
+	} else {
+		for _, dir := range l.localDirs() {
+			localPath := filepath.Join(dir.path, name+".wasm")
+			if _, err := os.Stat(localPath); err == nil {
+				consider(Candidate{Source: dir.source, Location: localPath}, func(c *Candidate) {
+					l.inspectLocal(ctx, c)
+				})
+			} else {
+				absent(dir.source, localPath)
+			}
+
+			// Versioned files are tried newest first by name
+			pattern := filepath.Join(dir.path, name+"@*.wasm")
+			matches, _ := filepath.Glob(pattern)
+			slices.Reverse(matches)
+			for _, m := range matches {
+				consider(Candidate{Source: dir.source, Location: m}, func(c *Candidate) {
+					l.inspectLocal(ctx, c)
+				})
+			}
+			if len(matches) == 0 {
+				absent(dir.source, pattern)
+			}
+		}
+
+		embeddedPath := "plugins/" + name + ".wasm"
+		if _, err := l.embeddedFS.Open(embeddedPath); err == nil {
+			consider(Candidate{Source: "embedded", Location: embeddedPath}, func(c *Candidate) {
+				l.inspectEmbedded(ctx, c)
+			})
+		} else {
+			absent("embedded", embeddedPath)
+		}
+
+		consider(Candidate{Source: "oci", Location: l.resolveOCIReference(name)}, func(c *Candidate) {
+			l.inspectOCI(ctx, c)
+		})
+	}
+
+	records, _ := ListQuarantine(l.quarantine)
+	for _, r := range records {
+		if r.Name == pluginName {
+			res.Candidates = append(res.Candidates, Candidate{
+				Source:   "quarantine",
+				Location: r.Path,
+				Status:   CandidateQuarantined,
+				Reason:   fmt.Sprintf("%s (%s, %s)", r.Reason, r.ID, r.QuarantinedAt.Format(time.RFC3339)),
+			})
+		}
+	}
+
+	switch {
+	case chosen < 0:
+		res.Error = fmt.Sprintf("plugin %q not found", name)
+	case res.Candidates[chosen].Status == CandidateSelected:
+		selected := res.Candidates[chosen]
+		res.Selected = &selected
+	default:
+		res.Error = res.Candidates[chosen].Reason
+	}
+	return res
+}
+
+func (l *Loader) inspectLocal(ctx context.Context, c *Candidate) {
+	data, digest, err := readPluginFile(c.Location)
+	if err != nil {
+		c.Status, c.Reason = CandidateRefused, err.Error()
+		return
+	}
+	l.inspectBytes(ctx, c, data, digest)
+}
+
+func (l *Loader) inspectEmbedded(ctx context.Context, c *Candidate) {
+	data, err := l.embeddedFS.ReadFile(c.Location)
+	if err != nil {
+		c.Status, c.Reason = CandidateRefused, err.Error()
+		return
+	}
+	l.inspectBytes(ctx, c, data, ContentDigest(data))
+}
+
+// inspectOCI describes the copy of an OCI reference in the local plugin
+// repository, which is what a load uses while its tag is fresh.
+func (l *Loader) inspectOCI(ctx context.Context, c *Candidate) {
+	if l.stack == nil {
+		c.Status, c.Reason = CandidateUnavailable, "no plugin service; registries are not consulted"
+		return
+	}
+	if err := ActivePolicy().CheckReference(c.Location); err != nil {
+		c.Status, c.Reason = CandidateRefused, err.Error()
+		return
+	}
+	ref, err := hostvalues.ParsePluginReference(c.Location)
+	if err != nil {
+		c.Status, c.Reason = CandidateRefused, fmt.Sprintf("invalid plugin reference: %v", err)
+		return
+	}
+
+	note := c.Reason
+	if tag, ok := LoadCache(l.cachePath).Tags[ref.String()]; ok {
+		note = joinNotes(note, fmt.Sprintf("tag resolved to %s at %s", tag.Digest, tag.ResolvedAt.Format(time.RFC3339)))
+	}
+	_, path, err := l.stack.Repository.Find(ctx, ref)
+	if err != nil {
+		c.Reason = joinNotes(note, "not cached; it would be pulled from the registry")
+		return
+	}
+	c.Path = path
+	data, digest, err := readPluginFile(path)
+	if err != nil {
+		c.Status, c.Reason = CandidateRefused, err.Error()
+		return
+	}
+	c.Reason = note
+	l.inspectBytes(ctx, c, data, digest)
+}
+
+// inspectBytes fills in a candidate's version and digest, refusing it when
+// the policy blocks the plugin it turns out to be.
+func (l *Loader) inspectBytes(ctx context.Context, c *Candidate, data []byte, digest string) {
+	c.Digest = digest
+	manifest, err := l.readManifest(ctx, digest, data)
+	if err != nil {
+		c.Status, c.Reason = CandidateRefused, fmt.Sprintf("reading manifest: %v", err)
+		return
+	}
+	c.Version = manifest.Version
+	if err := ActivePolicy().CheckPlugin(manifest.Name); err != nil {
+		c.Status, c.Reason = CandidateRefused, err.Error()
+	}
+}
+
+// joinNotes joins two reasons, either of which may be empty.
+func joinNotes(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "; " + b
+}
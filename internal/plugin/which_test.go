@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	"context"
+	"embed"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoader_Explain(t *testing.T) {
+	wasmData, err := os.ReadFile("../runtime/testdata/fixture.wasm")
+	if err != nil {
+		t.Skip("Fixture WASM binary not found")
+	}
+
+	dir := t.TempDir()
+	loader := NewLoader(embed.FS{}, filepath.Join(dir, "plugins"), nil, "ghcr.io/acme/plugins")
+	loader.cachePath = filepath.Join(dir, "cache.json")
+	loader.workspaceDir = filepath.Join(dir, "workspace")
+	if err := os.MkdirAll(loader.pluginsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"fixture.wasm", "fixture@1.0.0.wasm", "fixture@2.0.0.wasm"} {
+		if err := os.WriteFile(filepath.Join(loader.pluginsDir, name), wasmData, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A quarantined copy from the plugin repository
+	broken := filepath.Join(loader.pluginsDir, "ghcr.io", "fixture:0.9.0", "plugin.wasm")
+	if err := os.MkdirAll(filepath.Dir(broken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(broken, wasmData, 0o644)
+	if _, err := Quarantine(loader.quarantine, broken, "digest mismatch"); err != nil {
+		t.Fatal(err)
+	}
+
+	status := func(res Resolution) []string {
+		var out []string
+		for _, c := range res.Candidates {
+			out = append(out, c.Source+":"+filepath.Base(c.Location)+":"+c.Status)
+		}
+		return out
+	}
+
+	res := loader.Explain(context.Background(), "fixture")
+	want := []string{
+		"workspace:fixture.wasm:absent",
+		"workspace:fixture@*.wasm:absent",
+		"local:fixture.wasm:selected",
+		"local:fixture@2.0.0.wasm:shadowed",
+		"local:fixture@1.0.0.wasm:shadowed",
+		"embedded:fixture.wasm:absent",
+		"oci:fixture:latest:shadowed",
+		"quarantine:fixture:0.9.0:quarantined",
+	}
+	if got := status(res); !slices.Equal(got, want) {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+	if res.Selected == nil || res.Selected.Digest != ContentDigest(wasmData) || res.Selected.Version == "" {
+		t.Errorf("selected = %+v, want the local file with its version and digest", res.Selected)
+	}
+
+	// Without the unversioned file, the newest versioned one is used
+	_ = os.Remove(filepath.Join(loader.pluginsDir, "fixture.wasm"))
+	res = loader.Explain(context.Background(), "fixture")
+	if res.Selected == nil || filepath.Base(res.Selected.Location) != "fixture@2.0.0.wasm" {
+		t.Errorf("selected = %+v, want fixture@2.0.0.wasm", res.Selected)
+	}
+
+	// The policy refuses the plugin the file turns out to be
+	SetPolicy(&Policy{BlockedPlugins: []string{"fixture"}})
+	t.Cleanup(func() { SetPolicy(nil) })
+	res = loader.Explain(context.Background(), "fixture")
+	if res.Selected != nil || res.Candidates[3].Status != CandidateRefused || res.Error == "" {
+		t.Errorf("expected the blocked plugin to be refused, got %+v", res)
+	}
+
+	SetPolicy(nil)
+
+	// A workspace copy is tried before the user's files
+	if err := os.MkdirAll(loader.workspaceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(loader.workspaceDir, "fixture.wasm"), wasmData, 0o644)
+	res = loader.Explain(context.Background(), "fixture")
+	if res.Selected == nil || res.Selected.Source != "workspace" || res.Candidates[3].Status != CandidateShadowed {
+		t.Errorf("expected the workspace copy to be selected, got %+v", res)
+	}
+
+	res = loader.Explain(context.Background(), "missing")
+	if res.Selected != nil || res.Error != `plugin "missing" not found` || res.Candidates[5].Status != CandidateUnavailable {
+		t.Errorf("expected missing to resolve to nothing, got %+v", res)
+	}
+}
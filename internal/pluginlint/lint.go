@@ -0,0 +1,369 @@
+// Package pluginlint checks plugin manifests for problems that break
+// command generation, documentation, or capability grants.
+package pluginlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Severity levels.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is a single lint result.
+type Finding struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+var (
+	pluginNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	fieldNameRe  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// flagTypes are the schema property types that become CLI flags.
+var flagTypes = map[string]bool{"string": true, "integer": true, "number": true, "boolean": true, "array": true, "object": true}
+
+// linter accumulates findings.
+type linter struct {
+	findings []Finding
+}
+
+func (l *linter) errorf(path, format string, args ...any) {
+	l.findings = append(l.findings, Finding{SeverityError, path, fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(path, format string, args ...any) {
+	l.findings = append(l.findings, Finding{SeverityWarning, path, fmt.Sprintf(format, args...)})
+}
+
+// Lint checks a manifest and returns its findings, errors first.
+func Lint(m abi.Manifest) []Finding {
+	l := &linter{}
+
+	switch {
+	case m.Name == "":
+		l.errorf("name", "plugin name is required")
+	case !pluginNameRe.MatchString(m.Name):
+		l.errorf("name", "plugin name %q must be lowercase letters, digits, '-' or '_', starting with a letter", m.Name)
+	}
+	if m.Version == "" {
+		l.warnf("version", "version is empty")
+	}
+	if m.Description == "" {
+		l.warnf("description", "description is empty")
+	}
+
+	properties := l.lintConfigSchema(m.ConfigSchema)
+	l.lintServices(m, properties)
+	l.lintCapabilities(&m.Capabilities)
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Severity == SeverityError && l.findings[j].Severity != SeverityError
+	})
+	return l.findings
+}
+
+// HasErrors reports whether findings contain an error, or any finding at
+// all when strict is set.
+func HasErrors(findings []Finding, strict bool) bool {
+	for _, f := range findings {
+		if strict || f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintConfigSchema validates the config schema and returns its properties,
+// or nil if the schema could not be read.
+func (l *linter) lintConfigSchema(raw json.RawMessage) map[string]string {
+	if len(raw) == 0 {
+		l.warnf("config_schema", "no config schema; operations will have no flags")
+		return nil
+	}
+	if _, err := compileSchema(raw); err != nil {
+		l.errorf("config_schema", "invalid JSON Schema: %v", err)
+		return nil
+	}
+
+	var schema struct {
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+		Required          []string            `json:"required"`
+		MutuallyExclusive [][]string          `json:"x-mutually-exclusive"`
+		RequiredTogether  [][]string          `json:"x-required-together"`
+		DependentRequired map[string][]string `json:"dependentRequired"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		l.errorf("config_schema", "config schema must be a JSON object: %v", err)
+		return nil
+	}
+	if schema.Type != "object" {
+		l.errorf("config_schema.type", "config schema type must be \"object\", got %q", schema.Type)
+	}
+
+	properties := make(map[string]string, len(schema.Properties))
+	for _, name := range sortedKeys(schema.Properties) {
+		prop := schema.Properties[name]
+		path := "config_schema.properties." + name
+		properties[name] = prop.Type
+		if !fieldNameRe.MatchString(name) {
+			l.errorf(path, "property %q must be snake_case", name)
+		}
+		if prop.Type == "" {
+			l.warnf(path, "property has no type and will not become a flag")
+		} else if !flagTypes[prop.Type] {
+			l.warnf(path, "property type %q is not supported as a flag (use string, integer, number, boolean, array, or object)", prop.Type)
+		}
+	}
+	for _, r := range schema.Required {
+		if _, ok := properties[r]; !ok {
+			l.errorf("config_schema.required", "required field %q is not a declared property", r)
+		}
+	}
+
+	// Flag group fields may be declared in oneOf/anyOf variants, which are
+	// not tracked here, so unknown ones only warn
+	groups := map[string][][]string{
+		"x-mutually-exclusive": schema.MutuallyExclusive,
+		"x-required-together":  schema.RequiredTogether,
+	}
+	for _, field := range sortedKeys(schema.DependentRequired) {
+		groups["dependentRequired"] = append(groups["dependentRequired"], append([]string{field}, schema.DependentRequired[field]...))
+	}
+	for _, key := range sortedKeys(groups) {
+		for _, group := range groups[key] {
+			for _, field := range group {
+				if _, ok := properties[field]; !ok {
+					l.warnf("config_schema."+key, "field %q is not a declared property", field)
+				}
+			}
+		}
+	}
+	return properties
+}
+
+func (l *linter) lintServices(m abi.Manifest, properties map[string]string) {
+	if len(m.Services) == 0 {
+		l.errorf("services", "plugin declares no services")
+		return
+	}
+
+	styles := map[string][]string{}
+	for _, key := range sortedKeys(m.Services) {
+		svc := m.Services[key]
+		path := "services." + key
+		if svc.Name != key {
+			l.errorf(path+".name", "service name %q does not match its key %q", svc.Name, key)
+		}
+		if !pluginNameRe.MatchString(key) {
+			l.errorf(path, "service name %q must be lowercase letters, digits, '-' or '_', starting with a letter", key)
+		}
+		if len(svc.Operations) == 0 {
+			l.errorf(path+".operations", "service declares no operations")
+		}
+
+		seen := map[string]bool{}
+		for i, op := range svc.Operations {
+			opPath := fmt.Sprintf("%s.operations[%d]", path, i)
+			if op.Name != "" {
+				opPath = path + "." + op.Name
+			}
+
+			switch {
+			case op.Name == "":
+				l.errorf(opPath, "operation name is required")
+			case !pluginNameRe.MatchString(op.Name):
+				l.errorf(opPath, "operation name %q must be lowercase letters, digits, '-' or '_', starting with a letter", op.Name)
+			case op.Name == "service" || op.Name == "operation":
+				l.errorf(opPath, "operation name %q is reserved", op.Name)
+			}
+			if seen[op.Name] {
+				l.errorf(opPath, "duplicate operation %q", op.Name)
+			}
+			seen[op.Name] = true
+			if strings.Contains(op.Name, "-") {
+				styles["kebab-case"] = append(styles["kebab-case"], op.Name)
+			} else if strings.Contains(op.Name, "_") {
+				styles["snake_case"] = append(styles["snake_case"], op.Name)
+			}
+
+			if op.Description == "" {
+				l.warnf(opPath, "operation has no description")
+			}
+			if len(op.Examples) == 0 {
+				l.warnf(opPath, "operation has no examples")
+			}
+			if len(op.OutputSchema) > 0 {
+				if _, err := compileSchema(op.OutputSchema); err != nil {
+					l.errorf(opPath+".output_schema", "invalid JSON Schema: %v", err)
+				}
+			}
+
+			inputs := map[string]bool{}
+			for _, f := range op.InputFields {
+				inputs[f] = true
+				if properties != nil {
+					if _, ok := properties[f]; !ok {
+						l.errorf(opPath+".input_fields", "input field %q is not a config schema property", f)
+					}
+				}
+			}
+			l.lintExamples(opPath, op, properties, inputs)
+		}
+	}
+
+	if len(styles) > 1 {
+		l.warnf("services", "operation names mix kebab-case (%s) and snake_case (%s)",
+			strings.Join(styles["kebab-case"], ", "), strings.Join(styles["snake_case"], ", "))
+	}
+}
+
+func (l *linter) lintExamples(opPath string, op abi.OperationManifest, properties map[string]string, inputs map[string]bool) {
+	names := map[string]bool{}
+	for i, ex := range op.Examples {
+		path := fmt.Sprintf("%s.examples[%d]", opPath, i)
+		if ex.Name == "" {
+			l.warnf(path, "example has no name")
+		} else if names[ex.Name] {
+			l.errorf(path, "duplicate example name %q", ex.Name)
+		}
+		names[ex.Name] = true
+
+		var input map[string]any
+		if len(ex.Input) == 0 {
+			l.warnf(path+".input", "example has no input")
+		} else if err := json.Unmarshal(ex.Input, &input); err != nil {
+			l.errorf(path+".input", "input must be a JSON object: %v", err)
+		}
+		for _, k := range sortedKeys(input) {
+			if properties != nil {
+				if _, ok := properties[k]; !ok {
+					l.errorf(path+".input", "input field %q is not a config schema property", k)
+					continue
+				}
+			}
+			if len(inputs) > 0 && !inputs[k] {
+				l.errorf(path+".input", "input field %q is not listed in input_fields", k)
+			}
+		}
+
+		if len(ex.ExpectedOutput) > 0 && !json.Valid(ex.ExpectedOutput) {
+			l.errorf(path+".expected_output", "expected_output is not valid JSON")
+		}
+		if ex.ExpectedError != "" && len(ex.ExpectedOutput) > 0 {
+			l.warnf(path, "example sets both expected_error and expected_output")
+		}
+	}
+}
+
+func (l *linter) lintCapabilities(g *hostfunc.GrantSet) {
+	if g.Network != nil {
+		for i, r := range g.Network.Rules {
+			path := fmt.Sprintf("capabilities.network.rules[%d]", i)
+			if len(r.Hosts) == 0 {
+				l.errorf(path+".hosts", "network rule has no hosts")
+			}
+			for _, h := range r.Hosts {
+				if strings.TrimSpace(h) == "" {
+					l.errorf(path+".hosts", "empty host")
+				}
+			}
+			if len(r.Ports) == 0 {
+				l.errorf(path+".ports", "network rule has no ports")
+			}
+			for _, p := range r.Ports {
+				if !validPortSpec(p) {
+					l.errorf(path+".ports", "invalid port %q (use \"*\", a port, or a range like \"8000-9000\")", p)
+				}
+			}
+		}
+	}
+	if g.FS != nil {
+		for i, r := range g.FS.Rules {
+			path := fmt.Sprintf("capabilities.fs.rules[%d]", i)
+			if len(r.Read) == 0 && len(r.Write) == 0 {
+				l.errorf(path, "filesystem rule grants neither read nor write")
+			}
+			for _, p := range append(append([]string{}, r.Read...), r.Write...) {
+				if !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "~") && !strings.HasPrefix(p, "*") {
+					l.warnf(path, "path %q is relative; grants are matched against absolute paths", p)
+				}
+			}
+		}
+	}
+	if g.Env != nil {
+		for _, v := range g.Env.Variables {
+			if strings.TrimSpace(v) == "" {
+				l.errorf("capabilities.env.vars", "empty environment variable name")
+			}
+		}
+	}
+	if g.Exec != nil {
+		for _, c := range g.Exec.Commands {
+			if strings.TrimSpace(c) == "" {
+				l.errorf("capabilities.exec.commands", "empty command")
+			}
+		}
+	}
+	if g.KV != nil {
+		for i, r := range g.KV.Rules {
+			path := fmt.Sprintf("capabilities.kv.rules[%d]", i)
+			switch r.Operation {
+			case "read", "write", "read-write":
+			default:
+				l.errorf(path+".op", "invalid operation %q (use read, write, or read-write)", r.Operation)
+			}
+			if len(r.Keys) == 0 {
+				l.errorf(path+".keys", "kv rule has no keys")
+			}
+		}
+	}
+}
+
+// validPortSpec accepts "*", "N", or "N-M" with 1 <= N <= M <= 65535.
+func validPortSpec(p string) bool {
+	if p == "*" {
+		return true
+	}
+	lo, hi, isRange := strings.Cut(p, "-")
+	if !isRange {
+		hi = lo
+	}
+	a, err1 := strconv.Atoi(lo)
+	b, err2 := strconv.Atoi(hi)
+	return err1 == nil && err2 == nil && a >= 1 && b <= 65535 && a <= b
+}
+
+// compileSchema checks raw against the JSON Schema metaschema.
+func compileSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(string(raw))); err != nil {
+		return nil, err
+	}
+	return c.Compile("schema.json")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package pluginlint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+func validManifest() abi.Manifest {
+	return abi.Manifest{
+		Name:        "dns",
+		Version:     "1.0.0",
+		Description: "DNS checks",
+		ConfigSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"hostname": {"type": "string"},
+				"record_type": {"type": "string", "enum": ["A", "AAAA"]}
+			},
+			"required": ["hostname"]
+		}`),
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{
+				Name:        "resolve",
+				Description: "Resolve a hostname",
+				InputFields: []string{"hostname", "record_type"},
+				Examples: []abi.OperationExample{
+					{Name: "basic", Input: json.RawMessage(`{"hostname": "example.com"}`)},
+				},
+			}}},
+		},
+		Capabilities: hostfunc.GrantSet{
+			Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"53"}}}},
+		},
+	}
+}
+
+func TestLint_Valid(t *testing.T) {
+	if findings := Lint(validManifest()); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+}
+
+func TestLint_Findings(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*abi.Manifest)
+		path   string
+		sev    string
+	}{
+		{"bad name", func(m *abi.Manifest) { m.Name = "DNS" }, "name", SeverityError},
+		{"invalid schema", func(m *abi.Manifest) { m.ConfigSchema = json.RawMessage(`{"type": 5}`) }, "config_schema", SeverityError},
+		{"unknown required", func(m *abi.Manifest) {
+			m.ConfigSchema = json.RawMessage(`{"type":"object","properties":{"hostname":{"type":"string"},"record_type":{"type":"string"}},"required":["host"]}`)
+		}, "config_schema.required", SeverityError},
+		{"unsupported property type", func(m *abi.Manifest) {
+			m.ConfigSchema = json.RawMessage(`{"type":"object","properties":{"hostname":{"type":"string"},"record_type":{"type":"null"}}}`)
+		}, "config_schema.properties.record_type", SeverityWarning},
+		{"unknown flag group field", func(m *abi.Manifest) {
+			m.ConfigSchema = json.RawMessage(`{"type":"object","properties":{"hostname":{"type":"string"},"record_type":{"type":"string"}},"x-mutually-exclusive":[["hostname","ip"]]}`)
+		}, "config_schema.x-mutually-exclusive", SeverityWarning},
+		{"unknown input field", func(m *abi.Manifest) {
+			op := &m.Services["dns"].Operations[0]
+			op.InputFields = append(op.InputFields, "port")
+		}, "services.dns.resolve.input_fields", SeverityError},
+		{"missing description", func(m *abi.Manifest) {
+			m.Services["dns"].Operations[0].Description = ""
+		}, "services.dns.resolve", SeverityWarning},
+		{"example field outside input_fields", func(m *abi.Manifest) {
+			m.Services["dns"].Operations[0].InputFields = []string{"record_type"}
+		}, "services.dns.resolve.examples[0].input", SeverityError},
+		{"bad port", func(m *abi.Manifest) { m.Capabilities.Network.Rules[0].Ports = []string{"70000"} }, "capabilities.network.rules[0].ports", SeverityError},
+		{"service key mismatch", func(m *abi.Manifest) {
+			m.Services["lookup"] = m.Services["dns"]
+		}, "services.lookup.name", SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validManifest()
+			// Copy operations so mutations do not leak between cases.
+			svc := m.Services["dns"]
+			svc.Operations = append([]abi.OperationManifest(nil), svc.Operations...)
+			m.Services = map[string]abi.ServiceManifest{"dns": svc}
+			tt.mutate(&m)
+
+			findings := Lint(m)
+			for _, f := range findings {
+				if f.Path == tt.path && f.Severity == tt.sev {
+					return
+				}
+			}
+			t.Errorf("expected %s at %s, got %+v", tt.sev, tt.path, findings)
+		})
+	}
+}
+
+func TestLint_MixedNamingStyles(t *testing.T) {
+	m := validManifest()
+	m.Services = map[string]abi.ServiceManifest{"dns": {Name: "dns", Operations: []abi.OperationManifest{
+		{Name: "list_zones", Description: "x", Examples: []abi.OperationExample{{Name: "a", Input: json.RawMessage(`{}`)}}},
+		{Name: "get-zone", Description: "x", Examples: []abi.OperationExample{{Name: "a", Input: json.RawMessage(`{}`)}}},
+	}}}
+
+	findings := Lint(m)
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "mix kebab-case") {
+		t.Errorf("expected a single naming-style warning, got %+v", findings)
+	}
+	if HasErrors(findings, false) || !HasErrors(findings, true) {
+		t.Error("expected warning to fail only in strict mode")
+	}
+}
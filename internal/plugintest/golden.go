@@ -0,0 +1,123 @@
+package plugintest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+)
+
+// DefaultGoldenFormats are the formatters recorded when Golden.Formats is empty.
+var DefaultGoldenFormats = []string{"json", "yaml", "table"}
+
+// Golden stores each example's formatted output under Dir, one file per
+// formatter:
+//
+//	<dir>/<service>/<operation>/<example>.<json|yaml|txt>
+//
+// Timestamps and run metadata are cleared before formatting so files are
+// stable between runs.
+type Golden struct {
+	Dir     string
+	Formats []string
+	// Update rewrites golden files instead of comparing against them.
+	Update bool
+}
+
+// Path returns the golden file for a case and format.
+func (g Golden) Path(c Case, format string) string {
+	ext := format
+	if format == "table" {
+		ext = "txt"
+	}
+	return filepath.Join(g.Dir, sanitize(c.Service), sanitize(c.Operation), sanitize(c.Example)+"."+ext)
+}
+
+// check compares or records the formatted result for c. It returns the
+// number of files written.
+func (g Golden) check(c Case, op abi.OperationManifest, result abi.Result) (int, error) {
+	formats := g.Formats
+	if len(formats) == 0 {
+		formats = DefaultGoldenFormats
+	}
+
+	result.Timestamp = time.Time{}
+	result.Metadata = nil
+
+	written := 0
+	for _, format := range formats {
+		formatter, err := output.NewFormatter(format)
+		if err != nil {
+			return written, err
+		}
+		var buf bytes.Buffer
+		if err := formatter.Format(&buf, result, op.OutputSchema); err != nil {
+			return written, fmt.Errorf("formatting %s output: %w", format, err)
+		}
+
+		path := g.Path(c, format)
+		if g.Update {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return written, fmt.Errorf("creating golden directory: %w", err)
+			}
+			if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				return written, fmt.Errorf("writing golden file: %w", err)
+			}
+			written++
+			continue
+		}
+
+		want, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return written, fmt.Errorf("missing golden file %s (run with --update-golden)", path)
+		}
+		if err != nil {
+			return written, fmt.Errorf("reading golden file: %w", err)
+		}
+		if diff := firstDiff(string(want), buf.String()); diff != "" {
+			return written, fmt.Errorf("%s output differs from %s: %s", format, path, diff)
+		}
+	}
+	return written, nil
+}
+
+// firstDiff describes the first differing line between want and got, or
+// returns "" if they are equal.
+func firstDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g || i >= len(wl) || i >= len(gl) {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, w, g)
+		}
+	}
+	return "contents differ"
+}
+
+// sanitize makes a name safe for use as a path element.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', ' ', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		return r
+	}, name)
+}
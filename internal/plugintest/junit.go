@@ -0,0 +1,72 @@
+package plugintest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes reports as JUnit XML, one test suite per report.
+func WriteJUnit(w io.Writer, reports ...Report) error {
+	doc := junitSuites{}
+	for _, r := range reports {
+		suite := junitSuite{
+			Name:     r.Plugin,
+			Tests:    len(r.Cases),
+			Failures: r.Failed,
+			Time:     seconds(r.Duration.Seconds()),
+		}
+		for _, c := range r.Cases {
+			jc := junitCase{
+				Name:      c.Operation + "/" + c.Example,
+				ClassName: r.Plugin + "." + c.Service,
+				Time:      seconds(c.Duration.Seconds()),
+			}
+			if !c.Passed {
+				jc.Failure = &junitFailure{Message: c.Message, Text: c.Message}
+			}
+			suite.Cases = append(suite.Cases, jc)
+		}
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
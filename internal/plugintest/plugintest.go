@@ -0,0 +1,239 @@
+// Package plugintest runs the examples declared in a plugin manifest as tests.
+package plugintest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// CheckFunc executes one operation with a complete plugin config,
+// including the "service" and "operation" keys.
+type CheckFunc func(ctx context.Context, config map[string]any) (abi.Result, error)
+
+// Case is the outcome of one example.
+type Case struct {
+	Service   string        `json:"service"`
+	Operation string        `json:"operation"`
+	Example   string        `json:"example"`
+	Passed    bool          `json:"passed"`
+	Message   string        `json:"message,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Name returns the case's display name.
+func (c Case) Name() string {
+	return c.Service + "/" + c.Operation + "/" + c.Example
+}
+
+// Report is the outcome of a test run.
+type Report struct {
+	Plugin   string        `json:"plugin"`
+	Version  string        `json:"version,omitempty"`
+	Cases    []Case        `json:"cases"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Duration time.Duration `json:"duration_ns"`
+
+	// GoldenUpdated counts golden files written by WithGolden in update mode.
+	GoldenUpdated int `json:"golden_updated,omitempty"`
+}
+
+// Option configures Run.
+type Option func(*runConfig)
+
+type runConfig struct {
+	golden *Golden
+}
+
+// WithGolden compares (or, with Update set, records) each example's
+// formatted output against golden files.
+func WithGolden(g Golden) Option {
+	return func(c *runConfig) {
+		c.golden = &g
+	}
+}
+
+// Run executes every example in the manifest, in service-name order and
+// declaration order within a service.
+//
+// An example passes when:
+//   - ExpectedError is set and the operation errors, or returns an error
+//     result, with a message containing ExpectedError (case-insensitive);
+//   - ExpectedOutput is set and every field in it is present with an equal
+//     value in the result data (extra fields in the data are allowed);
+//   - neither is set and the result status is success.
+//
+// With WithGolden, an example that passes must also match its golden files.
+func Run(ctx context.Context, manifest abi.Manifest, check CheckFunc, opts ...Option) Report {
+	cfg := &runConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	report := Report{Plugin: manifest.Name, Version: manifest.Version}
+	start := time.Now()
+
+	services := make([]string, 0, len(manifest.Services))
+	for name := range manifest.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	for _, svcName := range services {
+		svc := manifest.Services[svcName]
+		for _, op := range svc.Operations {
+			for i, ex := range op.Examples {
+				name := ex.Name
+				if name == "" {
+					name = fmt.Sprintf("example-%d", i+1)
+				}
+				c := Case{Service: svcName, Operation: op.Name, Example: name}
+
+				caseStart := time.Now()
+				var result *abi.Result
+				c.Passed, c.Message, result = runExample(ctx, svcName, op.Name, ex, check)
+				c.Duration = time.Since(caseStart)
+
+				if c.Passed && cfg.golden != nil && result != nil {
+					updated, err := cfg.golden.check(c, op, *result)
+					if err != nil {
+						c.Passed, c.Message = false, err.Error()
+					}
+					report.GoldenUpdated += updated
+				}
+
+				if c.Passed {
+					report.Passed++
+				} else {
+					report.Failed++
+				}
+				report.Cases = append(report.Cases, c)
+			}
+		}
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// runExample runs one example. The result is returned for golden comparison
+// when the plugin produced one.
+func runExample(ctx context.Context, service, operation string, ex abi.OperationExample, check CheckFunc) (bool, string, *abi.Result) {
+	config := map[string]any{}
+	if len(ex.Input) > 0 {
+		if err := json.Unmarshal(ex.Input, &config); err != nil {
+			return false, fmt.Sprintf("invalid example input: %v", err), nil
+		}
+	}
+	config["service"] = service
+	config["operation"] = operation
+
+	result, err := check(ctx, config)
+	var res *abi.Result
+	if err == nil {
+		res = &result
+	}
+
+	if ex.ExpectedError != "" {
+		msg := errorMessage(result, err)
+		if msg == "" {
+			return false, fmt.Sprintf("expected error containing %q, got status %q", ex.ExpectedError, result.Status), res
+		}
+		if !strings.Contains(strings.ToLower(msg), strings.ToLower(ex.ExpectedError)) {
+			return false, fmt.Sprintf("expected error containing %q, got %q", ex.ExpectedError, msg), res
+		}
+		return true, "", res
+	}
+
+	if err != nil {
+		return false, err.Error(), res
+	}
+
+	if len(ex.ExpectedOutput) > 0 {
+		var want any
+		if err := json.Unmarshal(ex.ExpectedOutput, &want); err != nil {
+			return false, fmt.Sprintf("invalid expected output: %v", err), res
+		}
+		got, err := normalize(result.Data)
+		if err != nil {
+			return false, fmt.Sprintf("encoding result data: %v", err), res
+		}
+		if path, ok := subset(want, got, "data"); !ok {
+			return false, fmt.Sprintf("output mismatch at %s", path), res
+		}
+		return true, "", res
+	}
+
+	if result.Status != abi.ResultStatusSuccess {
+		if msg := errorMessage(result, nil); msg != "" {
+			return false, fmt.Sprintf("status %s: %s", result.Status, msg), res
+		}
+		return false, fmt.Sprintf("status %s", result.Status), res
+	}
+	return true, "", res
+}
+
+// errorMessage returns the error reported by a call, or "" if it succeeded.
+func errorMessage(result abi.Result, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case result.Error != nil && result.Error.Message != "":
+		return result.Error.Message
+	case result.Status == abi.ResultStatusError:
+		if result.Message != "" {
+			return result.Message
+		}
+		return string(result.Status)
+	}
+	return ""
+}
+
+// normalize round-trips v through JSON so it compares equal to decoded
+// expectations (numbers as float64, slices as []any).
+func normalize(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+// subset reports whether every field of want is present in got with an
+// equal value. Objects match recursively; other values must be equal.
+// On mismatch it returns the path of the first differing field.
+func subset(want, got any, path string) (string, bool) {
+	wm, ok := want.(map[string]any)
+	if !ok {
+		return path, reflect.DeepEqual(want, got)
+	}
+	gm, ok := got.(map[string]any)
+	if !ok {
+		return path, false
+	}
+
+	keys := make([]string, 0, len(wm))
+	for k := range wm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		gv, present := gm[k]
+		if !present {
+			return path + "." + k, false
+		}
+		if p, ok := subset(wm[k], gv, path+"."+k); !ok {
+			return p, false
+		}
+	}
+	return "", true
+}
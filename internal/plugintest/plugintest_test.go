@@ -0,0 +1,131 @@
+package plugintest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+func testManifest() abi.Manifest {
+	return abi.Manifest{
+		Name: "dns",
+		Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{
+				Name: "resolve",
+				Examples: []abi.OperationExample{
+					{Name: "match", Input: json.RawMessage(`{"hostname":"a"}`), ExpectedOutput: json.RawMessage(`{"records":["1.2.3.4"]}`)},
+					{Name: "mismatch", Input: json.RawMessage(`{"hostname":"b"}`), ExpectedOutput: json.RawMessage(`{"records":["5.6.7.8"]}`)},
+					{Name: "expected-error", Input: json.RawMessage(`{"hostname":""}`), ExpectedError: "hostname is required"},
+					{Name: "plain", Input: json.RawMessage(`{"hostname":"a"}`)},
+				},
+			}}},
+		},
+	}
+}
+
+func fakeCheck(_ context.Context, config map[string]any) (abi.Result, error) {
+	if config["service"] != "dns" || config["operation"] != "resolve" {
+		return abi.Result{}, errors.New("bad routing")
+	}
+	if config["hostname"] == "" {
+		return abi.Result{Status: abi.ResultStatusError, Error: &hostfunc.ErrorDetail{Message: "Hostname is required"}}, nil
+	}
+	return abi.ResultSuccess("", map[string]any{"records": []string{"1.2.3.4"}, "ttl": 60}), nil
+}
+
+func TestRun(t *testing.T) {
+	report := Run(context.Background(), testManifest(), fakeCheck)
+
+	if report.Passed != 3 || report.Failed != 1 {
+		t.Fatalf("expected 3 passed / 1 failed, got %+v", report)
+	}
+	for _, c := range report.Cases {
+		if c.Example == "mismatch" {
+			if c.Passed || !strings.Contains(c.Message, "data.records") {
+				t.Errorf("expected mismatch at data.records, got %+v", c)
+			}
+		} else if !c.Passed {
+			t.Errorf("expected %s to pass: %s", c.Name(), c.Message)
+		}
+	}
+}
+
+func TestSubset(t *testing.T) {
+	got := map[string]any{"a": 1.0, "b": map[string]any{"c": "x", "d": true}}
+	if _, ok := subset(map[string]any{"b": map[string]any{"c": "x"}}, got, "data"); !ok {
+		t.Error("expected nested subset to match")
+	}
+	if path, ok := subset(map[string]any{"b": map[string]any{"e": 1.0}}, got, "data"); ok || path != "data.b.e" {
+		t.Errorf("expected missing field at data.b.e, got %q", path)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := Run(context.Background(), testManifest(), fakeCheck)
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, report); err != nil {
+		t.Fatalf("WriteJUnit: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`<testsuite name="dns" tests="4" failures="1"`, `<testcase name="resolve/match" classname="dns.dns"`, "<failure message="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JUnit output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunGolden(t *testing.T) {
+	dir := t.TempDir()
+	golden := Golden{Dir: dir, Formats: []string{"json", "table"}}
+
+	report := Run(context.Background(), testManifest(), fakeCheck, WithGolden(golden))
+	if report.Failed != 4 {
+		t.Fatalf("expected every case to fail without golden files, got %+v", report)
+	}
+
+	golden.Update = true
+	report = Run(context.Background(), testManifest(), fakeCheck, WithGolden(golden))
+	if report.GoldenUpdated != 6 {
+		t.Fatalf("expected 6 golden files written for 3 passing cases, got %d", report.GoldenUpdated)
+	}
+	c := Case{Service: "dns", Operation: "resolve", Example: "plain"}
+	if _, err := os.Stat(golden.Path(c, "table")); err != nil {
+		t.Errorf("expected table golden file: %v", err)
+	}
+
+	golden.Update = false
+	report = Run(context.Background(), testManifest(), fakeCheck, WithGolden(golden))
+	if report.Passed != 3 || report.Failed != 1 {
+		t.Fatalf("expected golden files to match, got %+v", report)
+	}
+
+	if err := os.WriteFile(golden.Path(c, "json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report = Run(context.Background(), testManifest(), fakeCheck, WithGolden(golden))
+	for _, rc := range report.Cases {
+		if rc.Example == "plain" && (rc.Passed || !strings.Contains(rc.Message, "line 1")) {
+			t.Errorf("expected json golden mismatch for plain, got %+v", rc)
+		}
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	if d := firstDiff("a\nb\n", "a\nb\n"); d != "" {
+		t.Errorf("expected no diff, got %q", d)
+	}
+	if d := firstDiff("a\nb\n", "a\nc\n"); !strings.Contains(d, "line 2") {
+		t.Errorf("expected diff at line 2, got %q", d)
+	}
+	if d := firstDiff("a\n", "a\nb\n"); !strings.Contains(d, "line 2") {
+		t.Errorf("expected diff at line 2 for extra line, got %q", d)
+	}
+}
@@ -0,0 +1,230 @@
+// Package results keeps the full result of every plugin operation in a
+// local SQLite database, for querying and trend analysis, and pushes it to
+// HTTP endpoints and files for event pipelines.
+package results
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+
+	// Registers the pure Go "sqlite" driver.
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned by Get for an unknown result ID.
+var ErrNotFound = errors.New("result not found")
+
+// Record is a stored operation result.
+type Record struct {
+	ID        int64          `json:"id" yaml:"id"`
+	Plugin    string         `json:"plugin" yaml:"plugin"`
+	Version   string         `json:"version,omitempty" yaml:"version,omitempty"`
+	Digest    string         `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Service   string         `json:"service,omitempty" yaml:"service,omitempty"`
+	Operation string         `json:"operation" yaml:"operation"`
+	Status    string         `json:"status" yaml:"status"`
+	Message   string         `json:"message,omitempty" yaml:"message,omitempty"`
+	Data      map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+	Schema    map[string]any `json:"schema,omitempty" yaml:"schema,omitempty"` // output schema of the operation
+	StartedAt time.Time      `json:"started_at" yaml:"started_at"`
+	Duration  time.Duration  `json:"duration_ns" yaml:"duration_ns"`
+}
+
+// Filter selects records. Zero fields match everything.
+type Filter struct {
+	Plugin string
+	Status string
+	Since  time.Time
+	Limit  int
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS results (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	plugin      TEXT    NOT NULL,
+	version     TEXT    NOT NULL DEFAULT '',
+	digest      TEXT    NOT NULL DEFAULT '',
+	service     TEXT    NOT NULL DEFAULT '',
+	operation   TEXT    NOT NULL,
+	status      TEXT    NOT NULL,
+	message     TEXT    NOT NULL DEFAULT '',
+	data        TEXT,
+	schema      TEXT,
+	started_at  INTEGER NOT NULL,
+	duration_ns INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_started_at ON results (started_at);
+CREATE INDEX IF NOT EXISTS results_plugin ON results (plugin, started_at);
+`
+
+// Store is a result database. It is safe for concurrent use, including by
+// several processes sharing the file.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath returns the default result database location.
+// ~/.tack/results.db
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "."+meta.AppName, "results.db")
+	}
+	return filepath.Join(home, "."+meta.AppName, "results.db")
+}
+
+// Open opens the database at path, creating it and its directory if needed.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating results directory: %w", err)
+	}
+	// Writers from other processes wait for the lock instead of failing
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening results: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("opening results: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores r and returns the ID it was given.
+func (s *Store) Add(ctx context.Context, r Record) (int64, error) {
+	data, err := encodeJSON(r.Data)
+	if err != nil {
+		return 0, fmt.Errorf("encoding result data: %w", err)
+	}
+	schema, err := encodeJSON(r.Schema)
+	if err != nil {
+		return 0, fmt.Errorf("encoding result schema: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO results (plugin, version, digest, service, operation, status, message, data, schema, started_at, duration_ns)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Plugin, r.Version, r.Digest, r.Service, r.Operation, r.Status, r.Message, data, schema,
+		r.StartedAt.UnixNano(), int64(r.Duration))
+	if err != nil {
+		return 0, fmt.Errorf("storing result: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// List returns the records f selects, newest first.
+func (s *Store) List(ctx context.Context, f Filter) ([]Record, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.Plugin != "" {
+		where = append(where, "plugin = ?")
+		args = append(args, f.Plugin)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, f.Since.UnixNano())
+	}
+
+	query := "SELECT " + columns + " FROM results"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying results: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []Record
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying results: %w", err)
+	}
+	return records, nil
+}
+
+// Get returns the record with the given ID.
+func (s *Store) Get(ctx context.Context, id int64) (Record, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+columns+" FROM results WHERE id = ?", id)
+	r, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return r, err
+}
+
+const columns = "id, plugin, version, digest, service, operation, status, message, data, schema, started_at, duration_ns"
+
+// scanRecord reads a row selected with columns.
+func scanRecord(row interface{ Scan(...any) error }) (Record, error) {
+	var (
+		r                 Record
+		data, schema      sql.NullString
+		started, duration int64
+	)
+	err := row.Scan(&r.ID, &r.Plugin, &r.Version, &r.Digest, &r.Service, &r.Operation, &r.Status, &r.Message,
+		&data, &schema, &started, &duration)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, err
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("reading result: %w", err)
+	}
+	r.StartedAt = time.Unix(0, started)
+	r.Duration = time.Duration(duration)
+	if data.Valid {
+		if err := json.Unmarshal([]byte(data.String), &r.Data); err != nil {
+			return Record{}, fmt.Errorf("reading result %d data: %w", r.ID, err)
+		}
+	}
+	if schema.Valid {
+		if err := json.Unmarshal([]byte(schema.String), &r.Schema); err != nil {
+			return Record{}, fmt.Errorf("reading result %d schema: %w", r.ID, err)
+		}
+	}
+	return r, nil
+}
+
+// encodeJSON encodes m, or returns nil, stored as NULL, when m is empty.
+func encodeJSON(m map[string]any) (any, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,78 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_AddListGet(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "nested", "results.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	now := time.Now()
+	records := []Record{
+		{Plugin: "dns", Operation: "resolve", Status: "success", StartedAt: now.Add(-10 * 24 * time.Hour)},
+		{Plugin: "dns", Operation: "resolve", Status: "failure", StartedAt: now.Add(-2 * time.Hour), Message: "no answer"},
+		{Plugin: "http", Operation: "get", Status: "success", StartedAt: now.Add(-time.Hour),
+			Digest: "sha256:abc", Data: map[string]any{"status_code": float64(200)},
+			Schema: map[string]any{"type": "object"}, Duration: 150 * time.Millisecond},
+	}
+	var ids []int64
+	for _, r := range records {
+		id, err := s.Add(ctx, r)
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	all, err := s.List(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 3 || all[0].Plugin != "http" || all[2].Status != "success" {
+		t.Fatalf("expected all records newest first, got %+v", all)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{name: "plugin", filter: Filter{Plugin: "dns"}, want: 2},
+		{name: "status", filter: Filter{Plugin: "dns", Status: "failure"}, want: 1},
+		{name: "since", filter: Filter{Since: now.Add(-7 * 24 * time.Hour)}, want: 2},
+		{name: "limit", filter: Filter{Limit: 1}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("List(%+v) returned %d records, want %d", tt.filter, len(got), tt.want)
+			}
+		})
+	}
+
+	r, err := s.Get(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if r.Digest != "sha256:abc" || r.Data["status_code"] != float64(200) || r.Schema["type"] != "object" ||
+		r.Duration != 150*time.Millisecond || !r.StartedAt.Equal(records[2].StartedAt) {
+		t.Errorf("Get returned %+v", r)
+	}
+
+	if _, err := s.Get(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(999) error = %v, want ErrNotFound", err)
+	}
+}
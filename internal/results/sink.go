@@ -0,0 +1,181 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// Event is the envelope pushed to result sinks for every completed
+// operation.
+type Event struct {
+	Plugin     string         `json:"plugin"`
+	Version    string         `json:"version,omitempty"`
+	Digest     string         `json:"digest,omitempty"` // of the plugin binary
+	Service    string         `json:"service,omitempty"`
+	Operation  string         `json:"operation"`
+	ConfigHash string         `json:"config_hash"` // of the operation config, so runs can be grouped without exposing it
+	Status     string         `json:"status"`
+	Message    string         `json:"message,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Duration   time.Duration  `json:"duration_ns"`
+}
+
+// sink delivers encoded events.
+type sink interface {
+	push(ctx context.Context, event []byte) error
+	close() error
+}
+
+// Pusher sends events to the configured result sinks. It is safe for
+// concurrent use.
+type Pusher struct {
+	names []string
+	sinks []sink
+}
+
+// NewPusher builds a Pusher from config. Returns nil if cfgs is empty; Push
+// and Close on a nil Pusher do nothing.
+func NewPusher(cfgs []config.ResultSinkConfig) (*Pusher, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	p := &Pusher{}
+	for i, c := range cfgs {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		var s sink
+		switch {
+		case c.URL != "" && c.File != "":
+			return nil, fmt.Errorf("result sink %q: set url or file, not both", name)
+		case c.URL != "":
+			s = &webhookSink{url: c.URL, headers: c.Headers}
+		case c.File != "":
+			s = &fileSink{path: c.File}
+		default:
+			return nil, fmt.Errorf("result sink %q: url or file is required", name)
+		}
+		p.names = append(p.names, name)
+		p.sinks = append(p.sinks, s)
+	}
+	return p, nil
+}
+
+// Push sends ev to every sink. Delivery failures are reported on stderr
+// and never interrupt the caller.
+func (p *Pusher) Push(ctx context.Context, ev Event) {
+	if p == nil {
+		return
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: result sinks: encoding result: %v\n", err)
+		return
+	}
+	for i, s := range p.sinks {
+		if err := s.push(ctx, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: result sink %q: %v\n", p.names[i], err)
+		}
+	}
+}
+
+// Close closes the files sinks write to.
+func (p *Pusher) Close() error {
+	if p == nil {
+		return nil
+	}
+	var firstErr error
+	for _, s := range p.sinks {
+		if err := s.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookSink POSTs each event as JSON.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+}
+
+func (s *webhookSink) push(ctx context.Context, event []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(event))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", meta.AppName)
+	for k, v := range s.headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting result: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) close() error { return nil }
+
+// fileSink appends each event as a line to a file. The file is opened on
+// the first event and kept open, so a FIFO reader sees one stream; opening
+// a FIFO waits for its reader.
+type fileSink struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (s *fileSink) push(_ context.Context, event []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", s.path, err)
+		}
+		s.f = f
+	}
+	if _, err := s.f.Write(append(event, '\n')); err != nil {
+		// Reopened on the next event, e.g. once a FIFO has a reader again
+		_ = s.f.Close()
+		s.f = nil
+		return fmt.Errorf("writing %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *fileSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
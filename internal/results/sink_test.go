@@ -0,0 +1,87 @@
+package results
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestPusher_WebhookAndFile(t *testing.T) {
+	var received []Event
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var ev Event
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		received = append(received, ev)
+	}))
+	defer srv.Close()
+	t.Setenv("SINK_TOKEN", "abc")
+
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	p, err := NewPusher([]config.ResultSinkConfig{
+		{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer ${SINK_TOKEN}"}},
+		{File: path},
+	})
+	if err != nil {
+		t.Fatalf("NewPusher: %v", err)
+	}
+	start := time.Now()
+	ev := Event{Plugin: "dns", Operation: "resolve", ConfigHash: "sha256:abc", Status: "success",
+		StartedAt: start, FinishedAt: start.Add(time.Second), Duration: time.Second}
+	p.Push(context.Background(), ev)
+	p.Push(context.Background(), Event{Plugin: "dns", Operation: "resolve", Status: "failure"})
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(received) != 2 || received[0].ConfigHash != "sha256:abc" || received[0].Duration != time.Second {
+		t.Errorf("webhook received %+v", received)
+	}
+	if auth != "Bearer abc" {
+		t.Errorf("expected expanded header, got %q", auth)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var statuses []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		statuses = append(statuses, e.Status)
+	}
+	if strings.Join(statuses, ",") != "success,failure" {
+		t.Errorf("file lines have statuses %v", statuses)
+	}
+}
+
+func TestNewPusher_InvalidConfig(t *testing.T) {
+	for _, cfgs := range [][]config.ResultSinkConfig{
+		{{Name: "empty"}},
+		{{URL: "http://example.com", File: "out.jsonl"}},
+	} {
+		if _, err := NewPusher(cfgs); err == nil {
+			t.Errorf("expected an error for %+v", cfgs)
+		}
+	}
+	p, err := NewPusher(nil)
+	if p != nil || err != nil {
+		t.Errorf("expected no pusher without sinks, got %v, %v", p, err)
+	}
+	p.Push(context.Background(), Event{})
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+)
+
+var hostCallLog atomic.Pointer[io.Writer]
+
+// SetHostCallLog makes every runner in the process write one line per
+// plugin host function call to w: the plugin, the function, how long it
+// took, and whether it succeeded, was blocked by the capability check, or
+// failed. A nil w turns the log off.
+func SetHostCallLog(w io.Writer) {
+	if w == nil {
+		hostCallLog.Store(nil)
+		return
+	}
+	hostCallLog.Store(&w)
+}
+
+// hostCallLogMiddleware writes each host function call to the host call
+// log while one is set. It wraps the capability middleware, so refused calls
+// are logged as blocked.
+func hostCallLogMiddleware() hostlib.Middleware {
+	return func(next hostlib.ByteHandler) hostlib.ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			w := hostCallLog.Load()
+			if w == nil {
+				return next(ctx, payload)
+			}
+			hc, ok := ctx.(hostlib.HostContext)
+			if !ok {
+				return next(ctx, payload)
+			}
+
+			start := time.Now()
+			resp, err := next(ctx, payload)
+			status := "ok"
+			switch {
+			case err != nil:
+				status = "error: " + err.Error()
+			case isValidationError(resp):
+				status = "blocked"
+			}
+			plugin, _ := hostlib.CapabilityPluginNameFromContext(ctx)
+			_, _ = fmt.Fprintf(*w, "host call plugin=%s function=%s duration=%s status=%s\n",
+				plugin, hc.FunctionName(), time.Since(start).Round(time.Microsecond), status)
+			return resp, err
+		}
+	}
+}
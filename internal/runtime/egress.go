@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+)
+
+// EgressRecord counts the calls a plugin made to one host and port through
+// one host function.
+type EgressRecord struct {
+	Plugin   string `json:"plugin" yaml:"plugin"`
+	Function string `json:"function" yaml:"function"`
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Calls    int    `json:"calls" yaml:"calls"`
+
+	// Blocked counts the calls the capability check refused.
+	Blocked int `json:"blocked,omitempty" yaml:"blocked,omitempty"`
+}
+
+type egressKey struct {
+	plugin, function, host string
+	port                   int
+}
+
+var egress struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	calls   map[egressKey]*EgressRecord
+}
+
+// SetEgressRecording turns recording of plugin network calls on or off for
+// every runner in the process.
+func SetEgressRecording(on bool) {
+	egress.enabled.Store(on)
+}
+
+// Egress returns the network calls recorded so far, ordered by plugin, host,
+// and port.
+func Egress() []EgressRecord {
+	egress.mu.Lock()
+	defer egress.mu.Unlock()
+	records := make([]EgressRecord, 0, len(egress.calls))
+	for _, r := range egress.calls {
+		records = append(records, *r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Plugin != b.Plugin {
+			return a.Plugin < b.Plugin
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Function < b.Function
+	})
+	return records
+}
+
+// egressMiddleware records the destination of each network host function
+// call while recording is on. It wraps the capability middleware, so calls
+// the capability check refuses are recorded as blocked.
+func egressMiddleware() hostlib.Middleware {
+	return func(next hostlib.ByteHandler) hostlib.ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			if !egress.enabled.Load() {
+				return next(ctx, payload)
+			}
+			hc, ok := ctx.(hostlib.HostContext)
+			if !ok {
+				return next(ctx, payload)
+			}
+			host, port, ok := egressDestination(hc.FunctionName(), payload)
+			if !ok {
+				return next(ctx, payload)
+			}
+
+			resp, err := next(ctx, payload)
+			plugin, _ := hostlib.CapabilityPluginNameFromContext(ctx)
+			recordEgress(egressKey{plugin: plugin, function: hc.FunctionName(), host: host, port: port}, isValidationError(resp))
+			return resp, err
+		}
+	}
+}
+
+// egressDestination returns the host and port a network host function call
+// is for.
+func egressDestination(function string, payload []byte) (string, int, bool) {
+	switch function {
+	case "dns_lookup":
+		var req hostfunc.DNSRequest
+		if json.Unmarshal(payload, &req) != nil {
+			return "", 0, false
+		}
+		return req.Hostname, 53, true
+	case "tcp_connect":
+		var req hostfunc.TCPRequest
+		if json.Unmarshal(payload, &req) != nil {
+			return "", 0, false
+		}
+		port, _ := strconv.Atoi(req.Port)
+		return req.Host, port, true
+	case "smtp_connect":
+		var req hostfunc.SMTPRequest
+		if json.Unmarshal(payload, &req) != nil {
+			return "", 0, false
+		}
+		port, _ := strconv.Atoi(req.Port)
+		return req.Host, port, true
+	case "http_request":
+		var req hostfunc.HTTPRequest
+		if json.Unmarshal(payload, &req) != nil {
+			return "", 0, false
+		}
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return "", 0, false
+		}
+		port, _ := strconv.Atoi(u.Port())
+		if port == 0 {
+			port = 80
+			if u.Scheme == "https" {
+				port = 443
+			}
+		}
+		return u.Hostname(), port, true
+	}
+	return "", 0, false
+}
+
+func recordEgress(key egressKey, blocked bool) {
+	egress.mu.Lock()
+	defer egress.mu.Unlock()
+	if egress.calls == nil {
+		egress.calls = make(map[egressKey]*EgressRecord)
+	}
+	r, ok := egress.calls[key]
+	if !ok {
+		r = &EgressRecord{Plugin: key.plugin, Function: key.function, Host: key.host, Port: key.port}
+		egress.calls[key] = r
+	}
+	r.Calls++
+	if blocked {
+		r.Blocked++
+	}
+}
+
+// isValidationError reports whether a host function response is the
+// validation error the capability middleware returns for refused calls.
+func isValidationError(resp []byte) bool {
+	var e hostlib.ErrorResponse
+	return json.Unmarshal(resp, &e) == nil && e.Error == "VALIDATION_ERROR"
+}
@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+)
+
+func TestEgressMiddleware(t *testing.T) {
+	egress.calls = nil
+	SetEgressRecording(true)
+	t.Cleanup(func() {
+		SetEgressRecording(false)
+		egress.calls = nil
+	})
+
+	checker := hostlib.NewCapabilityChecker(map[string]*hostfunc.GrantSet{
+		"web": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"example.com"}, Ports: []string{"443", "53"}}}}},
+	})
+	handler := egressMiddleware()(hostlib.CapabilityMiddleware(checker)(func(ctx context.Context, payload []byte) ([]byte, error) {
+		return []byte(`{}`), nil
+	}))
+	call := func(function string, req any) {
+		t.Helper()
+		payload, _ := json.Marshal(req)
+		ctx := hostlib.NewHostContext(hostlib.WithCapabilityPluginName(context.Background(), "web"), function)
+		if _, err := handler(ctx, payload); err != nil {
+			t.Fatalf("%s: %v", function, err)
+		}
+	}
+
+	call("http_request", hostfunc.HTTPRequest{URL: "https://example.com/a"})
+	call("http_request", hostfunc.HTTPRequest{URL: "https://example.com/b"})
+	call("dns_lookup", hostfunc.DNSRequest{Hostname: "example.com"})
+	call("tcp_connect", hostfunc.TCPRequest{Host: "evil.test", Port: "4444"})
+	call("exec_command", hostfunc.ExecRequest{Command: "ls"})
+
+	want := []EgressRecord{
+		{Plugin: "web", Function: "tcp_connect", Host: "evil.test", Port: 4444, Calls: 1, Blocked: 1},
+		{Plugin: "web", Function: "dns_lookup", Host: "example.com", Port: 53, Calls: 1},
+		{Plugin: "web", Function: "http_request", Host: "example.com", Port: 443, Calls: 2},
+	}
+	got := Egress()
+	if len(got) != len(want) {
+		t.Fatalf("Egress() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	SetEgressRecording(false)
+	call("http_request", hostfunc.HTTPRequest{URL: "https://example.com/c"})
+	if got := Egress(); got[2].Calls != 2 {
+		t.Errorf("expected no recording when off, got %+v", got[2])
+	}
+}
+
+func TestHostCallLog(t *testing.T) {
+	var log bytes.Buffer
+	SetHostCallLog(&log)
+	t.Cleanup(func() { SetHostCallLog(nil) })
+
+	checker := hostlib.NewCapabilityChecker(map[string]*hostfunc.GrantSet{
+		"web": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"example.com"}, Ports: []string{"443"}}}}},
+	})
+	handler := hostCallLogMiddleware()(hostlib.CapabilityMiddleware(checker)(func(ctx context.Context, payload []byte) ([]byte, error) {
+		return []byte(`{}`), nil
+	}))
+	call := func(req hostfunc.HTTPRequest) {
+		t.Helper()
+		payload, _ := json.Marshal(req)
+		ctx := hostlib.NewHostContext(hostlib.WithCapabilityPluginName(context.Background(), "web"), "http_request")
+		if _, err := handler(ctx, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call(hostfunc.HTTPRequest{URL: "https://example.com/"})
+	call(hostfunc.HTTPRequest{URL: "https://evil.test/"})
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", log.String())
+	}
+	if !strings.HasPrefix(lines[0], "host call plugin=web function=http_request ") || !strings.HasSuffix(lines[0], "status=ok") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "status=blocked") {
+		t.Errorf("expected the refused call to be blocked, got %q", lines[1])
+	}
+
+	SetHostCallLog(nil)
+	call(hostfunc.HTTPRequest{URL: "https://example.com/"})
+	if strings.Count(log.String(), "\n") != 2 {
+		t.Errorf("expected no logging when off, got %q", log.String())
+	}
+}
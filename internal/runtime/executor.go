@@ -5,19 +5,23 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sync"
+	"sync/atomic"
 
 	abi "github.com/reglet-dev/reglet-abi"
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	hostlib "github.com/reglet-dev/reglet-host-sdk"
 	"github.com/reglet-dev/reglet-host-sdk/capability"
-	"github.com/reglet-dev/reglet-host-sdk/capability/gatekeeper"
 	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
 	"github.com/reglet-dev/reglet-host-sdk/extractor"
 	"github.com/reglet-dev/reglet-host-sdk/host"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
 	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
 )
 
+// defaultGlobalCache is shared by every runner, so a binary is compiled
+// once per process and, through the on-disk cache, once across runs.
 var defaultGlobalCache = host.NewPersistentCompilationCache(meta.AppName)
 
 // PluginRunner loads and executes WASM plugins.
@@ -26,6 +30,19 @@ type PluginRunner struct {
 	checker    *hostlib.CapabilityChecker
 	extractors *capability.Registry
 	trustAll   bool
+	fixed      *hostfunc.GrantSet // grants every plugin gets, when set
+	reviewer   *grantReviewer
+
+	mu        sync.Mutex
+	preloaded map[string]preloadedModule // instances from Preload, by digest
+}
+
+// preloadedModule is an instance whose manifest was read but which has not
+// been granted capabilities or run.
+type preloadedModule struct {
+	instance *host.PluginInstance
+	manifest abi.Manifest
+	memory   *memoryMeter
 }
 
 // RunnerOption configures a PluginRunner.
@@ -34,6 +51,7 @@ type RunnerOption func(*runnerConfig)
 type runnerConfig struct {
 	verbose      bool
 	trustPlugins bool
+	grants       *hostfunc.GrantSet
 }
 
 // WithVerbose enables or disables verbose logging.
@@ -50,6 +68,15 @@ func WithTrustPlugins(trust bool) RunnerOption {
 	}
 }
 
+// WithGrants gives every plugin exactly grants, without consulting the
+// grant store or asking, for running operations authorized elsewhere.
+// Policy and the exec switch still apply.
+func WithGrants(grants *hostfunc.GrantSet) RunnerOption {
+	return func(c *runnerConfig) {
+		c.grants = grants
+	}
+}
+
 // NewPluginRunner creates a PluginRunner with all standard host functions registered.
 //
 // It sets up:
@@ -58,6 +85,8 @@ func WithTrustPlugins(trust bool) RunnerOption {
 //   - SMTPBundle: smtp_connect
 //   - NetfilterBundle: ssrf_check
 //   - PanicRecoveryMiddleware: catches panics in host functions
+//   - egressMiddleware: records network destinations for --egress-report
+//   - usageMiddleware: counts host calls for --stats
 //
 // The caller must call Close() when done to release WASM runtime resources.
 func NewPluginRunner(ctx context.Context, opts ...RunnerOption) (*PluginRunner, error) {
@@ -71,6 +100,9 @@ func NewPluginRunner(ctx context.Context, opts ...RunnerOption) (*PluginRunner,
 
 	registry, err := hostlib.NewRegistry(
 		hostlib.WithMiddleware(hostlib.PanicRecoveryMiddleware()),
+		hostlib.WithMiddleware(hostCallLogMiddleware()),
+		hostlib.WithMiddleware(egressMiddleware()),
+		hostlib.WithMiddleware(usageMiddleware()),
 		hostlib.WithMiddleware(hostlib.CapabilityMiddleware(checker)),
 		hostlib.WithBundle(hostlib.AllBundles()),
 	)
@@ -95,6 +127,9 @@ func NewPluginRunner(ctx context.Context, opts ...RunnerOption) (*PluginRunner,
 		checker:    checker,
 		extractors: extractors,
 		trustAll:   config.trustPlugins,
+		fixed:      config.grants,
+		reviewer:   newGrantReviewer(os.Stdin, os.Stderr),
+		preloaded:  make(map[string]preloadedModule),
 	}, nil
 }
 
@@ -107,41 +142,76 @@ func (r *PluginRunner) Close(ctx context.Context) error {
 type LoadedPlugin struct {
 	runner   *PluginRunner
 	instance *host.PluginInstance
+	memory   *memoryMeter       // nil unless usage recording was on at load
+	granted  *hostfunc.GrantSet // capabilities registered for the plugin
 	Manifest abi.Manifest
 }
 
 // LoadPlugin loads a WASM binary and reads its manifest.
 // Returns a LoadedPlugin ready for Check() calls.
 func (r *PluginRunner) LoadPlugin(ctx context.Context, wasmBytes []byte) (*LoadedPlugin, error) {
-	instance, err := r.executor.LoadPlugin(ctx, wasmBytes)
+	instCtx, memory := meterMemory(ctx)
+	instance, err := r.executor.LoadPlugin(instCtx, wasmBytes)
 	if err != nil {
 		return nil, fmt.Errorf("loading plugin: %w", err)
 	}
 
-	manifest, err := instance.Manifest(ctx)
+	manifest, err := instanceManifest(ctx, instance, wasmBytes)
+	if err != nil {
+		return nil, err
+	}
+	return r.prepare(instance, manifest, memory)
+}
+
+// LoadPluginFor is LoadPlugin for the binary with the given digest. An
+// instance kept by Preload for that digest is used instead of calling load,
+// so a plugin read during discovery is not read and instantiated again.
+// Each preloaded instance is used once.
+func (r *PluginRunner) LoadPluginFor(ctx context.Context, digest string, load func() ([]byte, error)) (*LoadedPlugin, error) {
+	r.mu.Lock()
+	m, ok := r.preloaded[digest]
+	delete(r.preloaded, digest)
+	r.mu.Unlock()
+	if ok && digest != "" {
+		return r.prepare(m.instance, m.manifest, m.memory)
+	}
+
+	wasmBytes, err := load()
 	if err != nil {
-		return nil, fmt.Errorf("reading manifest: %w", err)
+		return nil, err
 	}
+	return r.LoadPlugin(ctx, wasmBytes)
+}
 
-	// Handle grant requests (interactive prompting)
+// prepare grants the capabilities a freshly instantiated plugin needs.
+func (r *PluginRunner) prepare(instance *host.PluginInstance, manifest abi.Manifest, memory *memoryMeter) (*LoadedPlugin, error) {
+	// Handle grant requests (the interactive review screen)
 	// If we have an extractor for this plugin, we defer prompting until Check()
 	// to get "exact" capabilities. Otherwise, we prompt for the manifest now.
 	_, hasExtractor := r.extractors.Get(manifest.Name)
 
-	if !manifest.Capabilities.IsEmpty() && !hasExtractor {
-		store := r.getGrantStore()
-		gk := gatekeeper.NewGatekeeper(
-			gatekeeper.WithStore(store),
-		)
-
-		info := map[string]capability.CapabilityInfo{
-			manifest.Name: {PluginName: manifest.Name},
-		}
+	// Plugins with an extractor have their capabilities checked in Check()
+	// The exec switch goes by the manifest even when an extractor narrows
+	// the capabilities later
+	if err := checkExec(manifest.Name, &manifest.Capabilities); err != nil {
+		return nil, err
+	}
+	caps := &manifest.Capabilities
+	if hasExtractor {
+		caps = nil
+	}
+	if err := checkPolicy(manifest.Name, caps); err != nil {
+		return nil, err
+	}
 
-		granted, err := gk.GrantCapabilities(&manifest.Capabilities, info, r.trustAll)
+	var granted *hostfunc.GrantSet
+	if !manifest.Capabilities.IsEmpty() && !hasExtractor {
+		var err error
+		granted, err = r.grantCapabilities(manifest.Name, &manifest.Capabilities)
 		if err != nil {
-			return nil, fmt.Errorf("granting capabilities: %w", err)
+			return nil, errcode.Errorf(errcode.CapabilityDenied, "granting capabilities: %w", err)
 		}
+		granted = limitGrants(granted)
 
 		// Update the checker with the granted capabilities for this plugin
 		r.checker.RegisterGrants(manifest.Name, granted)
@@ -150,14 +220,84 @@ func (r *PluginRunner) LoadPlugin(ctx context.Context, wasmBytes []byte) (*Loade
 	return &LoadedPlugin{
 		runner:   r,
 		instance: instance,
+		memory:   memory,
+		granted:  granted,
 		Manifest: manifest,
 	}, nil
 }
 
+// ReadManifest instantiates a WASM binary and returns its manifest without
+// granting any capabilities. Use it to inspect plugins that will not be run.
+func (r *PluginRunner) ReadManifest(ctx context.Context, wasmBytes []byte) (abi.Manifest, error) {
+	instance, err := r.executor.LoadPlugin(ctx, wasmBytes)
+	if err != nil {
+		return abi.Manifest{}, fmt.Errorf("loading plugin: %w", err)
+	}
+	return instanceManifest(ctx, instance, wasmBytes)
+}
+
+// Preload is ReadManifest for the binary with the given digest, keeping the
+// instance for a later LoadPluginFor with that digest.
+func (r *PluginRunner) Preload(ctx context.Context, digest string, wasmBytes []byte) (abi.Manifest, error) {
+	instCtx, memory := meterMemory(ctx)
+	instance, err := r.executor.LoadPlugin(instCtx, wasmBytes)
+	if err != nil {
+		return abi.Manifest{}, fmt.Errorf("loading plugin: %w", err)
+	}
+
+	manifest, err := instanceManifest(ctx, instance, wasmBytes)
+	if err != nil {
+		return abi.Manifest{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.preloaded[digest]; !ok && digest != "" {
+		r.preloaded[digest] = preloadedModule{instance: instance, manifest: manifest, memory: memory}
+	}
+	return manifest, nil
+}
+
+// ManifestCheck compares the manifest an instantiated module reports with
+// what its binary claims about it elsewhere. An error refuses the module.
+type ManifestCheck func(wasmBytes []byte, manifest abi.Manifest) error
+
+var manifestCheck atomic.Pointer[ManifestCheck]
+
+// SetManifestCheck sets the check every instantiated module's manifest
+// passes before it is used to grant capabilities. Nil removes it.
+func SetManifestCheck(check ManifestCheck) {
+	if check == nil {
+		manifestCheck.Store(nil)
+		return
+	}
+	manifestCheck.Store(&check)
+}
+
+// instanceManifest reads the manifest an instantiated module reports and
+// runs it through the manifest check.
+func instanceManifest(ctx context.Context, instance *host.PluginInstance, wasmBytes []byte) (abi.Manifest, error) {
+	manifest, err := instance.Manifest(ctx)
+	if err != nil {
+		return abi.Manifest{}, fmt.Errorf("reading manifest: %w", err)
+	}
+	if check := manifestCheck.Load(); check != nil {
+		if err := (*check)(wasmBytes, manifest); err != nil {
+			return abi.Manifest{}, err
+		}
+	}
+	return manifest, nil
+}
+
 func (r *PluginRunner) getGrantStore() capability.GrantStore {
-	home, _ := os.UserHomeDir()
-	grantsPath := filepath.Join(home, "."+meta.AppName, "grants.yaml")
-	return grantstore.NewFileStore(grantstore.WithPath(grantsPath))
+	return grantstore.NewFileStore(grantstore.WithPath(GrantsPath()))
+}
+
+// GrantsPath returns the file capability grants are remembered in:
+// grants.yaml in a trusted workspace, or ~/.tack/grants.yaml otherwise. An
+// untrusted workspace cannot grant its plugins anything.
+func GrantsPath() string {
+	return workspace.TrustedPath("grants.yaml")
 }
 
 // Check executes a plugin operation with the given config.
@@ -170,29 +310,52 @@ func (r *PluginRunner) getGrantStore() capability.GrantStore {
 //   - Error: structured error details (if status is "error")
 func (p *LoadedPlugin) Check(ctx context.Context, config map[string]any) (abi.Result, error) {
 	// 1. Precise capability extraction
-	if ext, ok := p.runner.extractors.Get(p.Manifest.Name); ok {
-		required := ext.Extract(config)
-		if required != nil && !required.IsEmpty() {
-			store := p.runner.getGrantStore()
-			gk := gatekeeper.NewGatekeeper(
-				gatekeeper.WithStore(store),
-			)
-
-			info := map[string]capability.CapabilityInfo{
-				p.Manifest.Name: {PluginName: p.Manifest.Name},
-			}
-
-			granted, err := gk.GrantCapabilities(required, info, p.runner.trustAll)
-			if err != nil {
-				return abi.Result{}, fmt.Errorf("granting runtime capabilities: %w", err)
-			}
-
-			// Merge with any existing grants for this session
-			p.runner.checker.RegisterGrants(p.Manifest.Name, granted)
-		}
+	if err := p.grantFor(config); err != nil {
+		return abi.Result{}, err
 	}
 
 	// 2. Propagate plugin name for runtime enforcement
 	ctx = hostlib.WithCapabilityPluginName(ctx, p.Manifest.Name)
-	return p.instance.Check(ctx, config)
+	return measureCheck(ctx, p.Manifest, p.memory, config, func(ctx context.Context) (abi.Result, error) {
+		return p.instance.Check(ctx, config)
+	})
+}
+
+// Grants returns the capabilities the plugin is granted to run config,
+// asking for them as Check would, without running it. Use it to run the
+// operation elsewhere with the same grants.
+func (p *LoadedPlugin) Grants(config map[string]any) (*hostfunc.GrantSet, error) {
+	if err := p.grantFor(config); err != nil {
+		return nil, err
+	}
+	if p.granted == nil {
+		return &hostfunc.GrantSet{}, nil
+	}
+	return p.granted.Clone(), nil
+}
+
+// grantFor grants the capabilities a plugin with an extractor needs for
+// config, which are known only once the config is.
+func (p *LoadedPlugin) grantFor(config map[string]any) error {
+	ext, ok := p.runner.extractors.Get(p.Manifest.Name)
+	if !ok {
+		return nil
+	}
+	required := ext.Extract(config)
+	if required == nil || required.IsEmpty() {
+		return nil
+	}
+	if err := checkPolicy(p.Manifest.Name, required); err != nil {
+		return err
+	}
+	granted, err := p.runner.grantCapabilities(p.Manifest.Name, required)
+	if err != nil {
+		return errcode.Errorf(errcode.CapabilityDenied, "granting runtime capabilities: %w", err)
+	}
+	granted = limitGrants(granted)
+
+	// Merge with any existing grants for this session
+	p.runner.checker.RegisterGrants(p.Manifest.Name, granted)
+	p.granted = granted
+	return nil
 }
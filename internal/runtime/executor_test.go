@@ -3,6 +3,8 @@ package runtime_test
 import (
 	"context"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/whiskeyjimb/tack-cli/internal/runtime"
@@ -78,3 +80,143 @@ func TestPluginRunner_Check(t *testing.T) {
 		t.Errorf("expected echo 'hello world' in data, got %v", result.Data["echo"])
 	}
 }
+
+func TestPluginRunner_ReadManifest(t *testing.T) {
+	wasmBytes := testWASMPath(t)
+	ctx := context.Background()
+
+	runner, err := runtime.NewPluginRunner(ctx)
+	if err != nil {
+		t.Fatalf("NewPluginRunner: %v", err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+
+	manifest, err := runner.ReadManifest(ctx, wasmBytes)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if manifest.Name != "fixture" {
+		t.Errorf("expected manifest name 'fixture', got %q", manifest.Name)
+	}
+
+	if _, err := runner.ReadManifest(ctx, []byte("not wasm")); err == nil {
+		t.Error("expected error for invalid WASM")
+	}
+}
+
+func TestSharedRunner(t *testing.T) {
+	wasmBytes := testWASMPath(t)
+	ctx := context.Background()
+
+	shared := runtime.NewSharedRunner(runtime.WithTrustPlugins(true))
+	first, err := shared.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	// A cancelled caller context does not tie down the shared runner
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	second, err := shared.Get(cancelled)
+	if err != nil || second != first {
+		t.Fatalf("expected the same runner, got %p (%v), want %p", second, err, first)
+	}
+
+	for range 2 {
+		plugin, err := first.LoadPlugin(ctx, wasmBytes)
+		if err != nil {
+			t.Fatalf("LoadPlugin: %v", err)
+		}
+		if _, err := plugin.Check(ctx, map[string]any{"action": "echo_test", "input": "hi"}); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+
+	if err := shared.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := shared.Get(ctx); err == nil {
+		t.Error("expected Get after Close to fail")
+	}
+}
+
+func TestModulePool(t *testing.T) {
+	wasmBytes := testWASMPath(t)
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	load := func() ([]byte, error) {
+		loads.Add(1)
+		return wasmBytes, nil
+	}
+
+	pool := runtime.NewModulePool(2, runtime.WithTrustPlugins(true))
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Check(ctx, "echo", load, map[string]any{"action": "echo_test", "input": "hi"}); err != nil {
+				t.Errorf("Check: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Modules are reused and never exceed the limit
+	if n := loads.Load(); n < 1 || n > 2 {
+		t.Errorf("expected 1 or 2 instantiations, got %d", n)
+	}
+	before := loads.Load()
+	if _, err := pool.Check(ctx, "echo", load, map[string]any{"action": "echo_test", "input": "hi"}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if loads.Load() != before {
+		t.Error("expected an idle module to be reused")
+	}
+
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := pool.Check(ctx, "echo", load, nil); err == nil {
+		t.Error("expected Check after Close to fail")
+	}
+}
+
+func TestPluginRunner_PreloadedInstanceReused(t *testing.T) {
+	wasmBytes := testWASMPath(t)
+	ctx := context.Background()
+
+	runner, err := runtime.NewPluginRunner(ctx, runtime.WithTrustPlugins(true))
+	if err != nil {
+		t.Fatalf("NewPluginRunner: %v", err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+
+	manifest, err := runner.Preload(ctx, "sha256:fixture", wasmBytes)
+	if err != nil {
+		t.Fatalf("Preload: %v", err)
+	}
+
+	loads := 0
+	load := func() ([]byte, error) {
+		loads++
+		return wasmBytes, nil
+	}
+	for range 2 {
+		plugin, err := runner.LoadPluginFor(ctx, "sha256:fixture", load)
+		if err != nil {
+			t.Fatalf("LoadPluginFor: %v", err)
+		}
+		if plugin.Manifest.Name != manifest.Name {
+			t.Errorf("manifest name = %q, want %q", plugin.Manifest.Name, manifest.Name)
+		}
+		if _, err := plugin.Check(ctx, map[string]any{"action": "echo_test", "input": "hi"}); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	// The preloaded instance serves the first load only
+	if loads != 1 {
+		t.Errorf("expected the binary to be read once, got %d", loads)
+	}
+}
@@ -0,0 +1,149 @@
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+)
+
+// ErrOutsideMounts is returned when a plugin requests filesystem access
+// outside the configured mounts.
+var ErrOutsideMounts = errcode.New(errcode.OutsideMounts, "outside the mounted paths")
+
+// FSMount exposes a host directory to plugins at a virtual path.
+type FSMount struct {
+	// HostPath is the directory on disk.
+	HostPath string
+
+	// Path is where plugins see it, such as "/work".
+	Path string
+
+	// ReadOnly refuses writes under Path.
+	ReadOnly bool
+}
+
+var fsMounts atomic.Pointer[[]FSMount]
+
+// SetFSMounts sets the mounts every runner evaluates filesystem capabilities
+// against. While any are set, plugins see only the mounted paths: read rules
+// must fall under a mount and write rules under a writable one. Relative
+// host paths are resolved against the working directory. No mounts leaves
+// filesystem capabilities unrestricted.
+func SetFSMounts(mounts []FSMount) error {
+	if len(mounts) == 0 {
+		fsMounts.Store(nil)
+		return nil
+	}
+	cleaned := make([]FSMount, 0, len(mounts))
+	seen := make(map[string]bool, len(mounts))
+	for _, m := range mounts {
+		if m.HostPath == "" {
+			return fmt.Errorf("mount %q: host path is required", m.Path)
+		}
+		if !path.IsAbs(m.Path) {
+			return fmt.Errorf("mount %q: path must be absolute", m.Path)
+		}
+		if hasGlobMeta(m.Path) {
+			return fmt.Errorf("mount %q: path must not contain wildcards", m.Path)
+		}
+		host, err := filepath.Abs(m.HostPath)
+		if err != nil {
+			return fmt.Errorf("mount %q: resolving %s: %w", m.Path, m.HostPath, err)
+		}
+		m.HostPath, m.Path = host, path.Clean(m.Path)
+		if seen[m.Path] {
+			return fmt.Errorf("mount %q: path is mounted twice", m.Path)
+		}
+		seen[m.Path] = true
+		cleaned = append(cleaned, m)
+	}
+	fsMounts.Store(&cleaned)
+	return nil
+}
+
+// ResolveFSPath maps a plugin-visible path to the host path it stands for
+// under the most specific mount. It reports false when no mount covers it,
+// or always when no mounts are set.
+func ResolveFSPath(virtual string) (host string, readOnly, ok bool) {
+	m, ok := mountFor(path.Clean("/" + virtual))
+	if !ok {
+		return "", false, false
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(path.Clean("/"+virtual), m.Path), "/")
+	return filepath.Join(m.HostPath, filepath.FromSlash(rel)), m.ReadOnly, true
+}
+
+// checkFSMounts returns ErrOutsideMounts when mounts are set and caps
+// include filesystem rules they do not cover.
+func checkFSMounts(pluginName string, caps *hostfunc.GrantSet) error {
+	if fsMounts.Load() == nil || caps == nil || caps.FS == nil {
+		return nil
+	}
+	for _, rule := range caps.FS.Rules {
+		for _, p := range rule.Read {
+			if _, ok := mountFor(patternDir(p)); !ok {
+				return fmt.Errorf("plugin %s requests filesystem read of %q: %w", pluginName, p, ErrOutsideMounts)
+			}
+		}
+		for _, p := range rule.Write {
+			m, ok := mountFor(patternDir(p))
+			if !ok {
+				return fmt.Errorf("plugin %s requests filesystem write of %q: %w", pluginName, p, ErrOutsideMounts)
+			}
+			if m.ReadOnly {
+				return fmt.Errorf("plugin %s requests filesystem write of %q: %s is mounted read-only", pluginName, p, m.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// mountAllows reports whether the mounts cover reading, or writing, the
+// paths pattern matches.
+func mountAllows(pattern string, write bool) bool {
+	m, ok := mountFor(patternDir(pattern))
+	return ok && (!write || !m.ReadOnly)
+}
+
+// mountFor returns the most specific mount containing the clean absolute
+// path p. An empty p is contained only by a "/" mount.
+func mountFor(p string) (FSMount, bool) {
+	mounts := fsMounts.Load()
+	if mounts == nil {
+		return FSMount{}, false
+	}
+	var best FSMount
+	found := false
+	for _, m := range *mounts {
+		if p != m.Path && m.Path != "/" && !strings.HasPrefix(p, m.Path+"/") {
+			continue
+		}
+		if !found || len(m.Path) > len(best.Path) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// patternDir returns the directory a path pattern is confined to: the
+// pattern up to the last separator before its first wildcard. Relative
+// patterns have no fixed directory, so only a "/" mount covers them.
+func patternDir(pattern string) string {
+	if !path.IsAbs(pattern) {
+		return ""
+	}
+	p := pattern
+	if i := strings.IndexAny(p, "*?[{"); i >= 0 {
+		p = p[:strings.LastIndex(p[:i], "/")+1]
+	}
+	return path.Clean(p)
+}
+
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[{")
+}
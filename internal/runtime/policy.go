@@ -0,0 +1,219 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/whiskeyjimb/tack-cli/internal/errcode"
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+)
+
+// ErrReadOnly is returned when read-only mode blocks a plugin from running.
+var ErrReadOnly = errcode.New(errcode.ReadOnly, "blocked by read-only mode")
+
+var readOnly atomic.Bool
+
+// SetReadOnly turns read-only mode on or off for every runner in the
+// process. In read-only mode, plugins that need filesystem writes or
+// command execution are refused before any capability prompt, write and
+// exec grants remembered from earlier runs are not applied, and capability
+// reviews are not remembered.
+func SetReadOnly(on bool) {
+	readOnly.Store(on)
+}
+
+// checkReadOnly returns ErrReadOnly when read-only mode is on and caps
+// include filesystem writes or command execution.
+func checkReadOnly(pluginName string, caps *hostfunc.GrantSet) error {
+	if !readOnly.Load() || caps == nil {
+		return nil
+	}
+	var blocked []string
+	if caps.FS != nil {
+		for _, rule := range caps.FS.Rules {
+			if len(rule.Write) > 0 {
+				blocked = append(blocked, "filesystem writes")
+				break
+			}
+		}
+	}
+	if caps.Exec != nil && len(caps.Exec.Commands) > 0 {
+		blocked = append(blocked, "command execution")
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+	return fmt.Errorf("plugin %s needs %s: %w", pluginName, strings.Join(blocked, " and "), ErrReadOnly)
+}
+
+// limitGrants returns the part of granted that the capability policy,
+// read-only mode, and the filesystem mounts still allow. Requests are
+// checked before they are granted, but the grant store may hold rules from
+// earlier runs, and the checker enforces whatever it is given.
+func limitGrants(granted *hostfunc.GrantSet) *hostfunc.GrantSet {
+	policy := capabilityPolicy.Load()
+	ro, mounted := readOnly.Load(), fsMounts.Load() != nil
+	if granted == nil || (policy == nil && !ro && !mounted) {
+		return granted
+	}
+	return filterGrants(granted, func(kind, value string) bool {
+		if policy != nil && !(*policy).AllowsGrant(kind, value) {
+			return false
+		}
+		switch kind {
+		case "fs read":
+			return !mounted || mountAllows(value, false)
+		case "fs write":
+			return !ro && (!mounted || mountAllows(value, true))
+		case "exec":
+			return !ro
+		}
+		return true
+	})
+}
+
+// filterGrants returns the rules of gs that keep accepts, given their kind
+// as the review screen names it and a single value: "host:port" for
+// network rules, the path, variable, or command otherwise, and the key of
+// "kv <operation>" rules.
+func filterGrants(gs *hostfunc.GrantSet, keep func(kind, value string) bool) *hostfunc.GrantSet {
+	out := &hostfunc.GrantSet{}
+	if gs.Network != nil {
+		for _, rule := range gs.Network.Rules {
+			for _, host := range rule.Hosts {
+				for _, port := range rule.Ports {
+					if keep("network", host+":"+port) {
+						out.Merge(&hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{
+							Rules: []hostfunc.NetworkRule{{Hosts: []string{host}, Ports: []string{port}}},
+						}})
+					}
+				}
+			}
+		}
+	}
+	if gs.FS != nil {
+		var fs hostfunc.FileSystemRule
+		for _, rule := range gs.FS.Rules {
+			for _, p := range rule.Read {
+				if keep("fs read", p) {
+					fs.Read = append(fs.Read, p)
+				}
+			}
+			for _, p := range rule.Write {
+				if keep("fs write", p) {
+					fs.Write = append(fs.Write, p)
+				}
+			}
+		}
+		if len(fs.Read) > 0 || len(fs.Write) > 0 {
+			out.FS = &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{fs}}
+		}
+	}
+	if gs.Env != nil {
+		var vars []string
+		for _, v := range gs.Env.Variables {
+			if keep("env", v) {
+				vars = append(vars, v)
+			}
+		}
+		if len(vars) > 0 {
+			out.Env = &hostfunc.EnvironmentCapability{Variables: vars}
+		}
+	}
+	if gs.Exec != nil {
+		var commands []string
+		for _, c := range gs.Exec.Commands {
+			if keep("exec", c) {
+				commands = append(commands, c)
+			}
+		}
+		if len(commands) > 0 {
+			out.Exec = &hostfunc.ExecCapability{Commands: commands}
+		}
+	}
+	if gs.KV != nil {
+		var rules []hostfunc.KeyValueRule
+		for _, rule := range gs.KV.Rules {
+			var keys []string
+			for _, key := range rule.Keys {
+				if keep("kv "+rule.Operation, key) {
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) > 0 {
+				rules = append(rules, hostfunc.KeyValueRule{Operation: rule.Operation, Keys: keys})
+			}
+		}
+		if len(rules) > 0 {
+			out.KV = &hostfunc.KeyValueCapability{Rules: rules}
+		}
+	}
+	return out
+}
+
+// ErrExecDisabled is returned when a plugin requests command execution
+// while exec plugins are disabled.
+var ErrExecDisabled = errcode.New(errcode.ExecDisabled, "plugins that execute commands are disabled")
+
+var execDisabled atomic.Bool
+
+// SetAllowExecPlugins sets whether plugins that request command execution
+// may be loaded. When they may not, no grant or --trust-plugins lets them
+// run.
+func SetAllowExecPlugins(on bool) {
+	execDisabled.Store(!on)
+}
+
+// checkExec returns ErrExecDisabled when exec plugins are disabled and caps
+// include command execution.
+func checkExec(pluginName string, caps *hostfunc.GrantSet) error {
+	if !execDisabled.Load() || caps == nil || caps.Exec == nil || len(caps.Exec.Commands) == 0 {
+		return nil
+	}
+	return fmt.Errorf("plugin %s requests command execution (%s): %w; set allow_exec_plugins: true in the config, or %s_ALLOW_EXEC_PLUGINS=true, to load it",
+		pluginName, strings.Join(caps.Exec.Commands, ", "), ErrExecDisabled, strings.ToUpper(meta.AppName))
+}
+
+// CapabilityPolicy is an organization policy every runner applies to
+// capabilities; see SetCapabilityPolicy.
+type CapabilityPolicy interface {
+	// CheckCapabilities refuses a plugin or the capabilities it requests.
+	// caps is nil when they are not known until the plugin runs.
+	CheckCapabilities(pluginName string, caps *hostfunc.GrantSet) error
+
+	// AllowsGrant reports whether one rule of a grant may be applied, named
+	// as filterGrants names them.
+	AllowsGrant(kind, value string) bool
+}
+
+var capabilityPolicy atomic.Pointer[CapabilityPolicy]
+
+// SetCapabilityPolicy installs a policy that every runner checks requests
+// against before granting a plugin capabilities, and that limits the
+// grants applied. A nil policy removes it.
+func SetCapabilityPolicy(policy CapabilityPolicy) {
+	if policy == nil {
+		capabilityPolicy.Store(nil)
+		return
+	}
+	capabilityPolicy.Store(&policy)
+}
+
+// checkPolicy applies the exec switch, read-only mode, the filesystem
+// mounts, and the capability policy.
+func checkPolicy(pluginName string, caps *hostfunc.GrantSet) error {
+	if err := checkExec(pluginName, caps); err != nil {
+		return err
+	}
+	if policy := capabilityPolicy.Load(); policy != nil {
+		if err := (*policy).CheckCapabilities(pluginName, caps); err != nil {
+			return err
+		}
+	}
+	if err := checkFSMounts(pluginName, caps); err != nil {
+		return err
+	}
+	return checkReadOnly(pluginName, caps)
+}
@@ -0,0 +1,208 @@
+package runtime
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	reads := &hostfunc.GrantSet{
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/**"}}}},
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}},
+	}
+	writes := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Write: []string{"/tmp/**"}}}},
+	}
+	execs := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"ls"}}}
+
+	t.Cleanup(func() { SetReadOnly(false) })
+	if err := checkReadOnly("files", writes); err != nil {
+		t.Errorf("expected writes to be allowed outside read-only mode, got %v", err)
+	}
+
+	SetReadOnly(true)
+	if err := checkReadOnly("http", reads); err != nil {
+		t.Errorf("expected reads and network to be allowed, got %v", err)
+	}
+	for name, caps := range map[string]*hostfunc.GrantSet{"files": writes, "command": execs} {
+		if err := checkReadOnly(name, caps); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+}
+
+func TestLimitGrants_ReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	reviewer, _ := scriptedReviewer("")
+	r := &PluginRunner{reviewer: reviewer}
+
+	// A write and a command granted by an earlier run
+	stored := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/etc/hosts"}}, {Write: []string{"/tmp/**"}},
+		}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"sh"}},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+	}
+	if err := r.getGrantStore().Save(stored); err != nil {
+		t.Fatal(err)
+	}
+	required := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}}}},
+	}
+	granted, err := r.grantCapabilities("files", required)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { SetReadOnly(false) })
+	if got := limitGrants(granted); !got.Contains(stored) {
+		t.Errorf("expected grants untouched outside read-only mode, got %+v", got)
+	}
+	SetReadOnly(true)
+	got := limitGrants(granted)
+	if !got.Contains(required) || got.Env == nil {
+		t.Errorf("expected reads and variables kept, got %+v", got)
+	}
+	if got.Exec != nil || len(got.FS.Rules) != 1 || len(got.FS.Rules[0].Write) != 0 {
+		t.Errorf("expected writes and commands removed in read-only mode, got %+v", got)
+	}
+}
+
+// ceilingPolicy allows the grants it lists, by kind and value.
+type ceilingPolicy map[string]bool
+
+func (c ceilingPolicy) CheckCapabilities(string, *hostfunc.GrantSet) error { return nil }
+
+func (c ceilingPolicy) AllowsGrant(kind, value string) bool { return c[kind+" "+value] }
+
+func TestLimitGrants_Policy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	reviewer, _ := scriptedReviewer("")
+	r := &PluginRunner{reviewer: reviewer}
+
+	// Grants stored before the policy was, beyond its ceiling
+	stored := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com", "*"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/data/a.txt"}}, {Read: []string{"/data/**"}}}},
+	}
+	if err := r.getGrantStore().Save(stored); err != nil {
+		t.Fatal(err)
+	}
+	granted, err := r.grantCapabilities("files", &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/data/a.txt"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetCapabilityPolicy(ceilingPolicy{"network api.example.com:443": true, "fs read /data/a.txt": true})
+	t.Cleanup(func() { SetCapabilityPolicy(nil) })
+	want := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/data/a.txt"}}}},
+	}
+	got := limitGrants(granted)
+	if !got.Contains(want) || !want.Contains(got) {
+		t.Errorf("expected the grants clipped to the policy, got %+v", got)
+	}
+}
+
+func TestCheckExec(t *testing.T) {
+	execs := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"ls"}}}
+	reads := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/**"}}}},
+	}
+
+	t.Cleanup(func() { SetAllowExecPlugins(true) })
+	if err := checkPolicy("command", execs); err != nil {
+		t.Errorf("expected exec to be allowed by default, got %v", err)
+	}
+
+	SetAllowExecPlugins(false)
+	if err := checkPolicy("command", execs); !errors.Is(err, ErrExecDisabled) {
+		t.Errorf("expected ErrExecDisabled, got %v", err)
+	} else if !strings.Contains(err.Error(), "allow_exec_plugins: true") {
+		t.Errorf("expected the error to explain how to enable exec plugins, got %v", err)
+	}
+	if err := checkPolicy("files", reads); err != nil {
+		t.Errorf("expected plugins without exec to load, got %v", err)
+	}
+}
+
+func TestCheckFSMounts(t *testing.T) {
+	fs := func(read, write []string) *hostfunc.GrantSet {
+		return &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: read, Write: write}}}}
+	}
+	t.Cleanup(func() { _ = SetFSMounts(nil) })
+
+	if err := checkFSMounts("files", fs([]string{"/etc/**"}, nil)); err != nil {
+		t.Errorf("expected no restriction without mounts, got %v", err)
+	}
+
+	if err := SetFSMounts([]FSMount{
+		{HostPath: "data", Path: "/work", ReadOnly: true},
+		{HostPath: "/tmp/out", Path: "/work/out/"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	allowed := []*hostfunc.GrantSet{
+		fs([]string{"/work/**", "/work/a.txt"}, nil),
+		fs(nil, []string{"/work/out/*.json"}),
+		{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}}},
+	}
+	for i, caps := range allowed {
+		if err := checkFSMounts("files", caps); err != nil {
+			t.Errorf("caps %d: expected allowed, got %v", i, err)
+		}
+	}
+	outside := []*hostfunc.GrantSet{
+		fs([]string{"/etc/**"}, nil),
+		fs([]string{"/work*"}, nil),
+		fs([]string{"/workspace/**"}, nil),
+		fs([]string{"/work/../etc/passwd"}, nil),
+		fs([]string{"**"}, nil),
+	}
+	for i, caps := range outside {
+		if err := checkFSMounts("files", caps); !errors.Is(err, ErrOutsideMounts) {
+			t.Errorf("caps %d: expected ErrOutsideMounts, got %v", i, err)
+		}
+	}
+	if err := checkFSMounts("files", fs(nil, []string{"/work/a.txt"})); err == nil {
+		t.Error("expected a write to a read-only mount to be refused")
+	}
+
+	// Grants stored by earlier runs are confined to the mounts as well
+	granted := fs([]string{"/work/**", "/etc/passwd"}, []string{"/work/a.txt", "/work/out/*.json", "/var/**"})
+	granted.Network = &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}}
+	limited := limitGrants(granted)
+	if want := fs([]string{"/work/**"}, []string{"/work/out/*.json"}); !limited.Contains(want) || !want.Contains(&hostfunc.GrantSet{FS: limited.FS}) {
+		t.Errorf("expected only mounted paths granted, got %+v", limited.FS)
+	}
+	if limited.Network == nil {
+		t.Error("expected network grants kept")
+	}
+
+	host, readOnly, ok := ResolveFSPath("/work/out/report.json")
+	if want := filepath.Join("/tmp/out", "report.json"); !ok || host != want || readOnly {
+		t.Errorf("ResolveFSPath = %q, %v, %v; want %q, false, true", host, readOnly, ok, want)
+	}
+	if _, _, ok := ResolveFSPath("/etc/passwd"); ok {
+		t.Error("expected /etc/passwd to be unmapped")
+	}
+
+	for _, bad := range [][]FSMount{
+		{{HostPath: "data", Path: "work"}},
+		{{HostPath: "data", Path: "/work/*"}},
+		{{Path: "/work"}},
+		{{HostPath: "a", Path: "/work"}, {HostPath: "b", Path: "/work/"}},
+	} {
+		if err := SetFSMounts(bad); err == nil {
+			t.Errorf("SetFSMounts(%+v): expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// ModulePool keeps instantiated plugin modules for reuse across operations,
+// keyed by the digest of the plugin binary. At most max modules of a plugin
+// exist at once; further callers wait for one to become idle.
+//
+// Each module has a runner of its own, so capability grants made for one
+// module never race with host calls from another.
+type ModulePool struct {
+	max  int
+	opts []RunnerOption
+
+	mu      sync.Mutex
+	plugins map[string]*pluginModules
+	closed  bool
+}
+
+// pluginModules holds the modules of one plugin binary.
+type pluginModules struct {
+	slots chan struct{} // one token per module that may exist
+	mu    sync.Mutex
+	idle  []*pooledModule
+}
+
+type pooledModule struct {
+	runner *PluginRunner
+	plugin *LoadedPlugin
+}
+
+// NewModulePool returns a pool allowing limit concurrent modules per
+// plugin, each created with opts. A limit below 1 is treated as 1.
+func NewModulePool(limit int, opts ...RunnerOption) *ModulePool {
+	return &ModulePool{
+		max:     max(limit, 1),
+		opts:    opts,
+		plugins: make(map[string]*pluginModules),
+	}
+}
+
+// Check runs an operation on an idle module of the plugin whose binary has
+// the given digest, instantiating one from load when none is idle and the
+// plugin is below the limit.
+//
+// If ctx is done first, Check returns at once; the module finishes in the
+// background and is then returned to the pool. A module whose operation
+// fails is discarded rather than reused.
+func (p *ModulePool) Check(ctx context.Context, digest string, load func() ([]byte, error), config map[string]any) (abi.Result, error) {
+	modules, err := p.modules(digest)
+	if err != nil {
+		return abi.Result{}, err
+	}
+
+	select {
+	case modules.slots <- struct{}{}:
+	case <-ctx.Done():
+		return abi.Result{}, ctxError(ctx)
+	}
+
+	type outcome struct {
+		result abi.Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() { <-modules.slots }()
+
+		m, err := p.acquire(ctx, modules, load)
+		if err != nil {
+			done <- outcome{err: err}
+			return
+		}
+		result, err := m.plugin.Check(ctx, config)
+		if err != nil {
+			_ = m.runner.Close(context.Background())
+			done <- outcome{err: fmt.Errorf("executing operation: %w", err)}
+			return
+		}
+		p.release(modules, m)
+		done <- outcome{result: result}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-ctx.Done():
+		return abi.Result{}, ctxError(ctx)
+	}
+}
+
+// Close releases every idle module. Modules still running are released
+// when they finish.
+func (p *ModulePool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	var errs []error
+	for _, modules := range p.plugins {
+		modules.mu.Lock()
+		for _, m := range modules.idle {
+			errs = append(errs, m.runner.Close(ctx))
+		}
+		modules.idle = nil
+		modules.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (p *ModulePool) modules(digest string) (*pluginModules, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errors.New("module pool is closed")
+	}
+	modules, ok := p.plugins[digest]
+	if !ok {
+		modules = &pluginModules{slots: make(chan struct{}, p.max)}
+		p.plugins[digest] = modules
+	}
+	return modules, nil
+}
+
+// acquire takes an idle module or instantiates a new one.
+func (p *ModulePool) acquire(ctx context.Context, modules *pluginModules, load func() ([]byte, error)) (*pooledModule, error) {
+	modules.mu.Lock()
+	if n := len(modules.idle); n > 0 {
+		m := modules.idle[n-1]
+		modules.idle = modules.idle[:n-1]
+		modules.mu.Unlock()
+		return m, nil
+	}
+	modules.mu.Unlock()
+
+	wasm, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("loading plugin: %w", err)
+	}
+	runner, err := NewPluginRunner(context.WithoutCancel(ctx), p.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating runtime: %w", err)
+	}
+	plugin, err := runner.LoadPlugin(ctx, wasm)
+	if err != nil {
+		_ = runner.Close(context.Background())
+		return nil, fmt.Errorf("loading plugin: %w", err)
+	}
+	return &pooledModule{runner: runner, plugin: plugin}, nil
+}
+
+// release returns a module to the pool, or closes it once the pool is
+// closed.
+func (p *ModulePool) release(modules *pluginModules, m *pooledModule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		_ = m.runner.Close(context.Background())
+		return
+	}
+	modules.mu.Lock()
+	modules.idle = append(modules.idle, m)
+	modules.mu.Unlock()
+}
+
+// ctxError describes why ctx ended.
+func ctxError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("operation timed out: %w", ctx.Err())
+	}
+	return fmt.Errorf("operation cancelled: %w", ctx.Err())
+}
@@ -0,0 +1,367 @@
+package runtime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+	"github.com/reglet-dev/reglet-host-sdk/capability/gatekeeper"
+	"gopkg.in/yaml.v3"
+)
+
+// grantRule is one reviewable rule of a capability request: a network
+// rule, a single filesystem path, an environment variable, or a command.
+type grantRule struct {
+	Kind   string   // "network", "fs read", "fs write", "env", or "exec"
+	Values []string // hosts for network, otherwise a single value
+	Ports  []string // network only
+}
+
+func (g grantRule) String() string {
+	if g.Kind == "network" {
+		return fmt.Sprintf("network %s:%s", strings.Join(g.Values, ","), strings.Join(g.Ports, ","))
+	}
+	return g.Kind + " " + strings.Join(g.Values, ",")
+}
+
+// grantSet returns the rule as a grant.
+func (g grantRule) grantSet() *hostfunc.GrantSet {
+	switch g.Kind {
+	case "network":
+		return &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: g.Values, Ports: g.Ports}},
+		}}
+	case "fs read":
+		return &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{{Read: g.Values}},
+		}}
+	case "fs write":
+		return &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{{Write: g.Values}},
+		}}
+	case "env":
+		return &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: g.Values}}
+	case "exec":
+		return &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: g.Values}}
+	}
+	return &hostfunc.GrantSet{}
+}
+
+// splitRules breaks a grant set into the rules the review screen lists,
+// one per network rule, path, variable, and command.
+func splitRules(gs *hostfunc.GrantSet) []grantRule {
+	var rules []grantRule
+	if gs.Network != nil {
+		for _, r := range gs.Network.Rules {
+			rules = append(rules, grantRule{Kind: "network", Values: r.Hosts, Ports: r.Ports})
+		}
+	}
+	if gs.FS != nil {
+		for _, r := range gs.FS.Rules {
+			for _, p := range r.Read {
+				rules = append(rules, grantRule{Kind: "fs read", Values: []string{p}})
+			}
+			for _, p := range r.Write {
+				rules = append(rules, grantRule{Kind: "fs write", Values: []string{p}})
+			}
+		}
+	}
+	if gs.Env != nil {
+		for _, v := range gs.Env.Variables {
+			rules = append(rules, grantRule{Kind: "env", Values: []string{v}})
+		}
+	}
+	if gs.Exec != nil {
+		for _, c := range gs.Exec.Commands {
+			rules = append(rules, grantRule{Kind: "exec", Values: []string{c}})
+		}
+	}
+	return rules
+}
+
+// grantReview is what the user decided for a set of requested rules.
+type grantReview struct {
+	Approved []grantRule // as approved, including modified rules
+	Changed  []grantRule // requested rules that were denied or modified
+	Remember bool
+}
+
+// grantReviewer shows the capability review screen.
+type grantReviewer struct {
+	in  *bufio.Reader
+	out io.Writer
+	tty bool
+}
+
+// stdinReader buffers stdin once for every reviewer in the process, so
+// one runner's reader cannot swallow lines typed for another's review.
+var stdinReader = sync.OnceValue(func() *bufio.Reader { return bufio.NewReader(os.Stdin) })
+
+func newGrantReviewer(in *os.File, out io.Writer) *grantReviewer {
+	info, err := in.Stat()
+	reader := stdinReader()
+	if in != os.Stdin {
+		reader = bufio.NewReader(in)
+	}
+	return &grantReviewer{
+		in:  reader,
+		out: out,
+		tty: err == nil && info.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+// errReviewAborted is returned when input ends during a review.
+var errReviewAborted = errors.New("capability review aborted")
+
+// review lists the requested rules and asks, rule by rule, whether to
+// approve, deny, or modify each one.
+func (v *grantReviewer) review(pluginName string, rules []grantRule) (grantReview, error) {
+	_, _ = fmt.Fprintf(v.out, "\nPlugin %q requests:\n", pluginName)
+	tw := tabwriter.NewWriter(v.out, 0, 0, 2, ' ', 0)
+	for i, r := range rules {
+		value := strings.TrimPrefix(r.String(), r.Kind+" ")
+		_, _ = fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", i+1, r.Kind, value, riskOf(r))
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprintln(v.out, "\nReview each rule: [a]pprove, [d]eny, or [m]odify (for example, narrow * hosts to one domain).")
+
+	var result grantReview
+	for i, r := range rules {
+		decided := false
+		for !decided {
+			answer, err := v.ask(fmt.Sprintf("%d %s [a/d/m]: ", i+1, r))
+			if err != nil {
+				return grantReview{}, err
+			}
+			decided = true
+			switch strings.ToLower(answer) {
+			case "a", "approve":
+				result.Approved = append(result.Approved, r)
+			case "d", "deny":
+				result.Changed = append(result.Changed, r)
+			case "m", "modify":
+				modified, err := v.modify(r)
+				if err != nil {
+					return grantReview{}, err
+				}
+				result.Approved = append(result.Approved, modified)
+				result.Changed = append(result.Changed, r)
+			default:
+				_, _ = fmt.Fprintln(v.out, "  answer a, d, or m")
+				decided = false
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintln(v.out, "\nGranting:")
+	for _, r := range result.Approved {
+		_, _ = fmt.Fprintf(v.out, "  %s\n", r)
+	}
+	if readOnly.Load() {
+		// Read-only mode leaves the grant files alone.
+		_, _ = fmt.Fprintln(v.out, "Read-only mode: these decisions apply to this run only.")
+		return result, nil
+	}
+	answer, err := v.ask("Remember these decisions? [y/N] ")
+	if err != nil {
+		return grantReview{}, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		result.Remember = true
+	}
+	return result, nil
+}
+
+// modify asks for the values that replace those of r.
+func (v *grantReviewer) modify(r grantRule) (grantRule, error) {
+	label := "value"
+	if r.Kind == "network" {
+		label = "hosts"
+	}
+	values, err := v.askList(fmt.Sprintf("  %s (%s): ", label, strings.Join(r.Values, ",")), r.Values)
+	if err != nil {
+		return grantRule{}, err
+	}
+	modified := grantRule{Kind: r.Kind, Values: values}
+	if r.Kind == "network" {
+		if modified.Ports, err = v.askList(fmt.Sprintf("  ports (%s): ", strings.Join(r.Ports, ",")), r.Ports); err != nil {
+			return grantRule{}, err
+		}
+	}
+	return modified, nil
+}
+
+// askList reads a comma-separated answer, keeping current when it is blank.
+func (v *grantReviewer) askList(prompt string, current []string) ([]string, error) {
+	answer, err := v.ask(prompt)
+	if err != nil || answer == "" {
+		return current, err
+	}
+	var values []string
+	for _, s := range strings.Split(answer, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			values = append(values, s)
+		}
+	}
+	if len(values) == 0 {
+		return current, nil
+	}
+	return values, nil
+}
+
+func (v *grantReviewer) ask(prompt string) (string, error) {
+	_, _ = fmt.Fprint(v.out, prompt)
+	line, err := v.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", errReviewAborted
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// riskOf describes why a rule is risky, or returns "" when it is not.
+func riskOf(r grantRule) string {
+	factors := capability.AnalyzeRisk(r.grantSet()).RiskFactors
+	if len(factors) == 0 {
+		return ""
+	}
+	return factors[0].Description
+}
+
+// reviewMu serializes grant reviews across every runner in the process:
+// they share one terminal and one grants file, so parallel runs (fan-out,
+// matrix) take turns, and each sees the grants the previous one saved.
+var reviewMu sync.Mutex
+
+// grantCapabilities grants required to pluginName. Rules the grant store
+// does not cover, and that were not denied or modified in a remembered
+// review, are put to the user on the review screen. A runner made
+// WithGrants grants its fixed set instead.
+func (r *PluginRunner) grantCapabilities(pluginName string, required *hostfunc.GrantSet) (*hostfunc.GrantSet, error) {
+	if r.fixed != nil {
+		return r.fixed.Clone(), nil
+	}
+	if r.trustAll {
+		slog.Warn("Auto-granting all requested capabilities (--trust-plugins enabled)")
+		return required.Clone(), nil
+	}
+
+	reviewMu.Lock()
+	defer reviewMu.Unlock()
+
+	store := r.getGrantStore()
+	existing, err := store.Load()
+	if err != nil {
+		existing = &hostfunc.GrantSet{}
+	}
+	missing := required.Difference(existing)
+	missing.Deduplicate()
+
+	reviews, err := loadReviews(ReviewsPath())
+	if err != nil {
+		return nil, err
+	}
+	var pending []grantRule
+	for _, rule := range splitRules(missing) {
+		if !reviews.has(pluginName, rule) {
+			pending = append(pending, rule)
+		}
+	}
+	if len(pending) == 0 {
+		return existing, nil
+	}
+
+	if !r.reviewer.tty {
+		var gs hostfunc.GrantSet
+		for _, rule := range pending {
+			gs.Merge(rule.grantSet())
+		}
+		return nil, gatekeeper.NewTerminalPrompter().FormatNonInteractiveError(&gs)
+	}
+	review, err := r.reviewer.review(pluginName, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := existing.Clone()
+	for _, rule := range review.Approved {
+		granted.Merge(rule.grantSet())
+	}
+	if review.Remember {
+		if err := store.Save(granted); err != nil {
+			_, _ = fmt.Fprintf(r.reviewer.out, "Warning: failed to save grants: %v\n", err)
+		} else {
+			_, _ = fmt.Fprintf(r.reviewer.out, "Permissions saved to %s\n", store.ConfigPath())
+		}
+		for _, rule := range review.Changed {
+			reviews.add(pluginName, rule)
+		}
+		if len(review.Changed) > 0 {
+			if err := reviews.save(ReviewsPath()); err != nil {
+				_, _ = fmt.Fprintf(r.reviewer.out, "Warning: failed to save review decisions: %v\n", err)
+			}
+		}
+	}
+	return granted, nil
+}
+
+// grantReviews records, per plugin, the requested rules a remembered
+// review denied or replaced, so they are not asked about again.
+type grantReviews map[string][]string
+
+// ReviewsPath returns the file remembered review decisions are kept in,
+// next to the grants: ~/.tack/grant-reviews.yaml outside a trusted
+// workspace.
+func ReviewsPath() string {
+	return filepath.Join(filepath.Dir(GrantsPath()), "grant-reviews.yaml")
+}
+
+func loadReviews(path string) (grantReviews, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return grantReviews{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	reviews := grantReviews{}
+	if err := yaml.Unmarshal(data, &reviews); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return reviews, nil
+}
+
+func (g grantReviews) has(pluginName string, rule grantRule) bool {
+	for _, s := range g[pluginName] {
+		if s == rule.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g grantReviews) add(pluginName string, rule grantRule) {
+	if !g.has(pluginName, rule) {
+		g[pluginName] = append(g[pluginName], rule.String())
+	}
+}
+
+func (g grantReviews) save(path string) error {
+	data, err := yaml.Marshal(g)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,222 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
+	"github.com/whiskeyjimb/tack-cli/internal/workspace"
+)
+
+func scriptedReviewer(input string) (*grantReviewer, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &grantReviewer{in: bufio.NewReader(strings.NewReader(input)), out: &out, tty: true}, &out
+}
+
+func TestGrantCapabilitiesReview(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	required := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}}}},
+		Env:     &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+	}
+
+	// Narrow the hosts, keep the port, approve the path, deny the variable,
+	// and remember the decisions.
+	reviewer, out := scriptedReviewer("x\nm\napi.example.com\n\na\nd\ny\n")
+	r := &PluginRunner{reviewer: reviewer}
+	granted, err := r.grantCapabilities("http", required)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"network  *:443", "fs read  /etc/hosts", "answer a, d, or m", "network api.example.com:443"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected review output to contain %q:\n%s", want, out.String())
+		}
+	}
+
+	wantGranted := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}}},
+		FS:      &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}}}},
+	}
+	if !granted.Contains(wantGranted) || granted.Contains(required) || granted.Env != nil {
+		t.Errorf("granted = %+v, want exactly the approved rules", granted)
+	}
+
+	// The remembered review covers the request: nothing is asked again.
+	reviewer, out = scriptedReviewer("")
+	r.reviewer = reviewer
+	again, err := r.grantCapabilities("http", required)
+	if err != nil {
+		t.Fatalf("expected remembered decisions to be reused, got %v", err)
+	}
+	if out.Len() != 0 || !again.Contains(wantGranted) || again.Env != nil {
+		t.Errorf("second grant = %+v, output %q", again, out.String())
+	}
+
+	// Another plugin making the same request is reviewed afresh.
+	reviewer, _ = scriptedReviewer("")
+	r.reviewer = reviewer
+	if _, err := r.grantCapabilities("other", required); !errors.Is(err, errReviewAborted) {
+		t.Errorf("expected a new review for another plugin, got %v", err)
+	}
+}
+
+func TestGrantCapabilitiesParallelReviews(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Parallel runs (--parallel, matrix) each have a runner but share the
+	// terminal; every review approves its rule and remembers it.
+	const runs = 8
+	reviewer, out := scriptedReviewer(strings.Repeat("a\ny\n", runs))
+	var wg sync.WaitGroup
+	errs := make([]error, runs)
+	for i := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &PluginRunner{reviewer: reviewer}
+			required := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+				{Hosts: []string{fmt.Sprintf("host%d.example.com", i)}, Ports: []string{"443"}},
+			}}}
+			_, errs[i] = r.grantCapabilities("http", required)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	if got := strings.Count(out.String(), "Plugin \"http\" requests"); got != runs {
+		t.Errorf("expected %d reviews, got %d:\n%s", runs, got, out.String())
+	}
+
+	// No run's save overwrote another's.
+	saved, err := (&PluginRunner{}).getGrantStore().Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range runs {
+		host := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{fmt.Sprintf("host%d.example.com", i)}, Ports: []string{"443"}},
+		}}}
+		if !saved.Contains(host) {
+			t.Errorf("saved grants lost host%d.example.com: %+v", i, saved)
+		}
+	}
+}
+
+func TestGrantCapabilitiesParallelSameRequest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Matrix combinations run the same plugin with the same request: one
+	// review, remembered, covers every run.
+	const runs = 4
+	reviewer, out := scriptedReviewer("a\ny\n")
+	required := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+		{Hosts: []string{"api.example.com"}, Ports: []string{"443"}},
+	}}}
+	var wg sync.WaitGroup
+	errs := make([]error, runs)
+	for i := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &PluginRunner{reviewer: reviewer}
+			_, errs[i] = r.grantCapabilities("http", required)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("run %d: %v", i, err)
+		}
+	}
+	if got := strings.Count(out.String(), "Plugin \"http\" requests"); got != 1 {
+		t.Errorf("expected one review, got %d:\n%s", got, out.String())
+	}
+}
+
+func TestGrantCapabilitiesReadOnlyNotRemembered(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { SetReadOnly(false) })
+	SetReadOnly(true)
+
+	// No remember question is asked: the script approves the rule only.
+	reviewer, out := scriptedReviewer("a\n")
+	r := &PluginRunner{reviewer: reviewer}
+	required := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+	granted, err := r.grantCapabilities("env", required)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !granted.Contains(required) {
+		t.Errorf("granted = %+v, want the approved rule for this run", granted)
+	}
+	if strings.Contains(out.String(), "Remember") {
+		t.Errorf("expected no remember prompt in read-only mode:\n%s", out.String())
+	}
+	if _, err := os.Stat(r.getGrantStore().ConfigPath()); !os.IsNotExist(err) {
+		t.Errorf("expected no grants file in read-only mode, got %v", err)
+	}
+}
+
+func TestGrantCapabilitiesNonInteractive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	reviewer, _ := scriptedReviewer("")
+	reviewer.tty = false
+	r := &PluginRunner{reviewer: reviewer}
+	required := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"ls"}}}
+	if _, err := r.grantCapabilities("command", required); err == nil {
+		t.Fatal("expected missing grants to fail without a terminal")
+	}
+
+	r.trustAll = true
+	granted, err := r.grantCapabilities("command", required)
+	if err != nil || !granted.Contains(required) {
+		t.Errorf("expected --trust-plugins to grant everything, got %+v, %v", granted, err)
+	}
+}
+
+func TestGrantsPathIgnoresUntrustedWorkspace(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	t.Chdir(project)
+
+	// A checked-out repository granting its plugins everything
+	ws := filepath.Join(project, workspace.DirName)
+	if err := os.MkdirAll(ws, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	required := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"sh"}}}
+	if err := grantstore.NewFileStore(grantstore.WithPath(filepath.Join(ws, "grants.yaml"))).Save(required); err != nil {
+		t.Fatal(err)
+	}
+	reviewer, _ := scriptedReviewer("")
+	reviewer.tty = false
+	r := &PluginRunner{reviewer: reviewer}
+
+	if got, want := GrantsPath(), filepath.Join(home, workspace.DirName, "grants.yaml"); got != want {
+		t.Errorf("GrantsPath = %q, want %q until the workspace is trusted", got, want)
+	}
+	if _, err := r.grantCapabilities("command", required); err == nil {
+		t.Fatal("expected an untrusted workspace's grants to be ignored")
+	}
+
+	if err := workspace.Trust(ws); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.grantCapabilities("command", required); err != nil {
+		t.Errorf("expected a trusted workspace's grants to apply, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SharedRunner hands out a single PluginRunner, created on first use, so the
+// wazero runtime and host function registry are built once per process
+// rather than once per plugin. It is safe for concurrent use.
+type SharedRunner struct {
+	opts []RunnerOption
+
+	mu     sync.Mutex
+	runner *PluginRunner
+	closed bool
+}
+
+// NewSharedRunner returns a SharedRunner whose runner is created with opts.
+func NewSharedRunner(opts ...RunnerOption) *SharedRunner {
+	return &SharedRunner{opts: opts}
+}
+
+// Get returns the shared runner, creating it on first use.
+func (s *SharedRunner) Get(ctx context.Context) (*PluginRunner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errors.New("plugin runner is closed")
+	}
+	if s.runner == nil {
+		// The runner outlives the call that happens to create it
+		runner, err := NewPluginRunner(context.WithoutCancel(ctx), s.opts...)
+		if err != nil {
+			return nil, err
+		}
+		s.runner = runner
+	}
+	return s.runner, nil
+}
+
+// Close releases the runner if one was created. Later calls to Get fail.
+func (s *SharedRunner) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.runner == nil {
+		return nil
+	}
+	err := s.runner.Close(ctx)
+	s.runner = nil
+	return err
+}
@@ -0,0 +1,169 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// Usage is the resources one plugin operation used. The runtime has no
+// fuel metering, so instructions executed are not counted.
+type Usage struct {
+	Plugin    string `json:"plugin" yaml:"plugin"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	Service   string `json:"service,omitempty" yaml:"service,omitempty"`
+	Operation string `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Status    string `json:"status" yaml:"status"`
+
+	StartedAt time.Time     `json:"started_at" yaml:"started_at"`
+	WallTime  time.Duration `json:"wall_time_ns" yaml:"wall_time_ns"`
+
+	// PeakMemoryBytes is the largest the module's linear memory has grown,
+	// since it was instantiated.
+	PeakMemoryBytes uint64 `json:"peak_memory_bytes" yaml:"peak_memory_bytes"`
+
+	// HostCalls counts calls to each host function.
+	HostCalls map[string]int `json:"host_calls,omitempty" yaml:"host_calls,omitempty"`
+}
+
+var usage struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	runs    []Usage
+}
+
+// SetUsageRecording turns recording of per-operation resource usage on or
+// off for every runner in the process. Modules instantiated while it is
+// off report no memory use.
+func SetUsageRecording(on bool) {
+	usage.enabled.Store(on)
+}
+
+// Usages returns the resource usage recorded so far, in the order the
+// operations finished.
+func Usages() []Usage {
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	return append([]Usage(nil), usage.runs...)
+}
+
+func recordUsage(u Usage) {
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.runs = append(usage.runs, u)
+}
+
+// usageKey carries the host call counter of a running operation.
+type usageKey struct{}
+
+type hostCallCounter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *hostCallCounter) add(function string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[function]++
+}
+
+// usageMiddleware counts host function calls for the operation whose
+// context carries a counter.
+func usageMiddleware() hostlib.Middleware {
+	return func(next hostlib.ByteHandler) hostlib.ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			if counter, ok := ctx.Value(usageKey{}).(*hostCallCounter); ok {
+				if hc, ok := ctx.(hostlib.HostContext); ok {
+					counter.add(hc.FunctionName())
+				}
+			}
+			return next(ctx, payload)
+		}
+	}
+}
+
+// measureCheck runs check and records its usage while recording is on.
+func measureCheck(ctx context.Context, manifest abi.Manifest, memory *memoryMeter, config map[string]any, check func(context.Context) (abi.Result, error)) (abi.Result, error) {
+	if !usage.enabled.Load() {
+		return check(ctx)
+	}
+	counter := &hostCallCounter{calls: map[string]int{}}
+	start := time.Now()
+	result, err := check(context.WithValue(ctx, usageKey{}, counter))
+
+	u := Usage{
+		Plugin:    manifest.Name,
+		Version:   manifest.Version,
+		Status:    string(result.Status),
+		StartedAt: start,
+		WallTime:  time.Since(start),
+	}
+	u.Service, _ = config["service"].(string)
+	u.Operation, _ = config["operation"].(string)
+	if err != nil {
+		u.Status = "error"
+	}
+	if memory != nil {
+		u.PeakMemoryBytes = memory.peak.Load()
+	}
+	counter.mu.Lock()
+	if len(counter.calls) > 0 {
+		u.HostCalls = counter.calls
+	}
+	counter.mu.Unlock()
+	recordUsage(u)
+	return result, err
+}
+
+// memoryMeter tracks the peak size of a module's linear memory. It backs
+// the memory with a plain growable slice, as the runtime's default does.
+type memoryMeter struct {
+	peak atomic.Uint64
+}
+
+// meterMemory returns ctx set up to meter the memory of a module
+// instantiated with it, or ctx unchanged and nil while recording is off.
+func meterMemory(ctx context.Context) (context.Context, *memoryMeter) {
+	if !usage.enabled.Load() {
+		return ctx, nil
+	}
+	m := &memoryMeter{}
+	return experimental.WithMemoryAllocator(ctx, experimental.MemoryAllocatorFunc(func(capacity, maximum uint64) experimental.LinearMemory {
+		return &meteredMemory{meter: m, buf: make([]byte, 0, capacity), max: maximum}
+	})), m
+}
+
+type meteredMemory struct {
+	meter *memoryMeter
+	buf   []byte
+	max   uint64
+}
+
+func (m *meteredMemory) Reallocate(size uint64) []byte {
+	if size > m.max {
+		return nil
+	}
+	if size > uint64(cap(m.buf)) {
+		grown := make([]byte, size, min(max(size, 2*uint64(cap(m.buf))), m.max))
+		copy(grown, m.buf)
+		m.buf = grown
+	} else {
+		m.buf = m.buf[:size]
+	}
+	for {
+		peak := m.meter.peak.Load()
+		if size <= peak || m.meter.peak.CompareAndSwap(peak, size) {
+			break
+		}
+	}
+	return m.buf
+}
+
+func (m *meteredMemory) Free() {
+	m.buf = nil
+}
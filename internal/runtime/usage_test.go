@@ -0,0 +1,51 @@
+package runtime_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/whiskeyjimb/tack-cli/internal/runtime"
+)
+
+func TestUsageRecording(t *testing.T) {
+	wasmBytes := testWASMPath(t)
+	ctx := context.Background()
+
+	runtime.SetUsageRecording(true)
+	t.Cleanup(func() { runtime.SetUsageRecording(false) })
+
+	runner, err := runtime.NewPluginRunner(ctx, runtime.WithTrustPlugins(true))
+	if err != nil {
+		t.Fatalf("NewPluginRunner: %v", err)
+	}
+	defer func() { _ = runner.Close(ctx) }()
+
+	plugin, err := runner.LoadPlugin(ctx, wasmBytes)
+	if err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	before := len(runtime.Usages())
+	if _, err := plugin.Check(ctx, map[string]any{"operation": "echo", "action": "echo_test", "input": "hi"}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	usages := runtime.Usages()
+	if len(usages) != before+1 {
+		t.Fatalf("expected one usage record, got %d", len(usages)-before)
+	}
+	u := usages[len(usages)-1]
+	if u.Plugin != "fixture" || u.Operation != "echo" || u.Status == "" {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	if u.PeakMemoryBytes == 0 || u.WallTime <= 0 {
+		t.Errorf("expected memory and wall time to be measured, got %+v", u)
+	}
+
+	runtime.SetUsageRecording(false)
+	if _, err := plugin.Check(ctx, map[string]any{"action": "echo_test", "input": "hi"}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(runtime.Usages()) != before+1 {
+		t.Error("expected nothing recorded while recording is off")
+	}
+}
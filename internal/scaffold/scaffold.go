@@ -0,0 +1,158 @@
+// Package scaffold generates new plugin projects for plugin authors.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// SDKVersion is the reglet plugin SDK version new projects depend on.
+const SDKVersion = "v0.6.2"
+
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Options describes the plugin to generate.
+type Options struct {
+	// Name is the plugin name, used as its command name.
+	Name string
+	// Module is the Go module path. Defaults to Name.
+	Module string
+	// Description is the one-line plugin description.
+	Description string
+	// Operations lists operations as "op" or "service/op". Operations without
+	// a service belong to a service named after the plugin. Default: "check".
+	Operations []string
+}
+
+// Service is a service and its operations, as passed to templates.
+type Service struct {
+	Name       string
+	Operations []string
+}
+
+// data is the template context.
+type data struct {
+	Name        string
+	Module      string
+	Description string
+	SDKVersion  string
+	Services    []Service
+}
+
+// Generate writes a new plugin project into dir, which must not exist or be
+// empty. It returns the paths of the files written, relative to dir.
+func Generate(dir string, opts Options) ([]string, error) {
+	d, err := prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("directory %s already exists and is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+
+	names, err := fs.Glob(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		tmpl, err := template.New(filepath.Base(name)).Funcs(template.FuncMap{
+			"title": title,
+		}).ParseFS(templates, name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, d); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", name, err)
+		}
+
+		// "Makefile.tmpl" -> "Makefile", "main.go.tmpl" -> "main.go"
+		out := strings.TrimSuffix(filepath.Base(name), ".tmpl")
+		if out == "gitignore" {
+			out = ".gitignore"
+		}
+		if err := os.WriteFile(filepath.Join(dir, out), buf.Bytes(), 0o644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", out, err)
+		}
+		written = append(written, out)
+	}
+	return written, nil
+}
+
+// prepare validates opts and builds the template context.
+func prepare(opts Options) (data, error) {
+	if !nameRe.MatchString(opts.Name) {
+		return data{}, fmt.Errorf("invalid plugin name %q: use lowercase letters, digits, '-' or '_', starting with a letter", opts.Name)
+	}
+
+	d := data{
+		Name:        opts.Name,
+		Module:      opts.Module,
+		Description: opts.Description,
+		SDKVersion:  SDKVersion,
+	}
+	if d.Module == "" {
+		d.Module = opts.Name
+	}
+	if d.Description == "" {
+		d.Description = fmt.Sprintf("The %s plugin", opts.Name)
+	}
+
+	ops := opts.Operations
+	if len(ops) == 0 {
+		ops = []string{"check"}
+	}
+
+	index := map[string]int{}
+	seen := map[string]bool{}
+	for _, op := range ops {
+		svc, name := opts.Name, op
+		if i := strings.Index(op, "/"); i >= 0 {
+			svc, name = op[:i], op[i+1:]
+		}
+		if !nameRe.MatchString(svc) || !nameRe.MatchString(name) {
+			return data{}, fmt.Errorf("invalid operation %q: expected \"operation\" or \"service/operation\"", op)
+		}
+		if seen[svc+"/"+name] {
+			return data{}, fmt.Errorf("duplicate operation %q", op)
+		}
+		seen[svc+"/"+name] = true
+
+		i, ok := index[svc]
+		if !ok {
+			i = len(d.Services)
+			index[svc] = i
+			d.Services = append(d.Services, Service{Name: svc})
+		}
+		d.Services[i].Operations = append(d.Services[i].Operations, name)
+	}
+	return d, nil
+}
+
+// title converts a snake/kebab-case name to CamelCase for Go identifiers.
+func title(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' })
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,73 @@
+package scaffold
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ping")
+	files, err := Generate(dir, Options{
+		Name:       "ping",
+		Module:     "github.com/example/ping",
+		Operations: []string{"check", "icmp/echo"},
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	for _, want := range []string{"go.mod", "main.go", "plugin.go", "plugin_test.go", "Makefile", ".gitignore", "README.md"} {
+		if !contains(files, want) {
+			t.Errorf("expected %s to be generated, got %v", want, files)
+		}
+	}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f, ".go") {
+			continue
+		}
+		src, _ := os.ReadFile(filepath.Join(dir, f))
+		if _, err := format.Source(src); err != nil {
+			t.Errorf("%s is not valid Go: %v\n%s", f, err, src)
+		}
+	}
+
+	plugin, _ := os.ReadFile(filepath.Join(dir, "plugin.go"))
+	for _, want := range []string{`"ping": {`, `"icmp": {`, `case "icmp/echo":`, "func IcmpEcho("} {
+		if !strings.Contains(string(plugin), want) {
+			t.Errorf("plugin.go missing %q", want)
+		}
+	}
+
+	mod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if !strings.HasPrefix(string(mod), "module github.com/example/ping\n") {
+		t.Errorf("unexpected go.mod:\n%s", mod)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Generate(filepath.Join(dir, "x"), Options{Name: "Bad Name"}); err == nil {
+		t.Error("expected invalid name error")
+	}
+	if _, err := Generate(filepath.Join(dir, "x"), Options{Name: "ok", Operations: []string{"a", "a"}}); err == nil {
+		t.Error("expected duplicate operation error")
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "existing"), []byte("x"), 0o644)
+	if _, err := Generate(dir, Options{Name: "ok"}); err == nil {
+		t.Error("expected error for non-empty directory")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
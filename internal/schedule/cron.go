@@ -0,0 +1,189 @@
+// Package schedule runs plugin operations on cron-like schedules.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec computes activation times for a schedule expression.
+type Spec interface {
+	// Next returns the first activation time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses a schedule expression.
+//
+// Supported forms:
+//
+//	*/5 * * * *     standard 5-field cron (minute hour day-of-month month day-of-week)
+//	@every 30s      fixed interval (any time.ParseDuration value)
+//	@hourly         0 * * * *
+//	@daily          0 0 * * *  (also @midnight)
+//	@weekly         0 0 * * 0
+//	@monthly        0 0 1 * *
+//
+// Cron fields accept "*", single values, ranges (1-5), lists (1,3,5), and
+// steps (*/15, 0-30/10). Day-of-week is 0-6 with 0 meaning Sunday (7 is also
+// accepted as Sunday). When both day-of-month and day-of-week are restricted,
+// a day matches if either field matches, as in Vixie cron.
+func Parse(expr string) (Spec, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("@every interval must be at least 1s, got %s", d)
+		}
+		return everySpec{interval: d}, nil
+	}
+
+	switch expr {
+	case "@hourly":
+		expr = "0 * * * *"
+	case "@daily", "@midnight":
+		expr = "0 0 * * *"
+	case "@weekly":
+		expr = "0 0 * * 0"
+	case "@monthly":
+		expr = "0 0 1 * *"
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields or an @ descriptor", expr)
+	}
+
+	var (
+		spec cronSpec
+		err  error
+	)
+	if spec.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute field: %w", err)
+	}
+	if spec.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour field: %w", err)
+	}
+	if spec.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day-of-month field: %w", err)
+	}
+	if spec.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month field: %w", err)
+	}
+	if spec.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day-of-week field: %w", err)
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1 << 0
+	}
+	spec.domStar = fields[2] == "*"
+	spec.dowStar = fields[4] == "*"
+
+	return spec, nil
+}
+
+// everySpec fires at a fixed interval.
+type everySpec struct {
+	interval time.Duration
+}
+
+func (s everySpec) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronSpec holds one bitset per cron field.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Next walks forward field by field, resetting lower fields whenever a
+// higher one advances. A match is always found within five years for any
+// parseable expression, but the search is bounded to avoid spinning on
+// impossible dates like "0 0 31 2 *".
+func (s cronSpec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s cronSpec) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses a comma-separated cron field into a bitset.
+func parseField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangePart = part[:idx]
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			start = n
+			end = n
+			if step > 1 {
+				end = hi
+			}
+		}
+
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("value out of range [%d-%d] in %q", lo, hi, part)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
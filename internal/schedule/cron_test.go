@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC) // Saturday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2026, 3, 14, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 3, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := spec.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_DayOfMonthOrDayOfWeek(t *testing.T) {
+	// Both restricted: the 20th OR any Monday, whichever comes first.
+	spec, err := Parse("0 0 20 * 1")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	base := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	if got := spec.Next(base); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every nope",
+		"@every 10ms",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestCronSpec_ImpossibleDate(t *testing.T) {
+	spec, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := spec.Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected zero time for impossible date, got %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// metricStatuses are the values of the tack_check_status state set.
+var metricStatuses = []string{
+	string(abi.ResultStatusSuccess),
+	string(abi.ResultStatusFailure),
+	string(abi.ResultStatusError),
+}
+
+// WriteMetrics writes check state in the Prometheus text exposition format.
+// Checks that have not run yet only report their run counters.
+func (s *Scheduler) WriteMetrics(w io.Writer) error {
+	statuses := s.Status()
+	var b strings.Builder
+
+	family := func(name, typ, help string, value func(st CheckStatus) (string, bool)) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, st := range statuses {
+			if v, ok := value(st); ok {
+				fmt.Fprintf(&b, "%s{%s} %s\n", name, checkLabels(st), v)
+			}
+		}
+	}
+	ran := func(st CheckStatus) bool { return !st.LastRun.IsZero() }
+
+	family("tack_check_success", "gauge", "Whether the last run of the check succeeded.",
+		func(st CheckStatus) (string, bool) {
+			if st.Status == string(abi.ResultStatusSuccess) {
+				return "1", ran(st)
+			}
+			return "0", ran(st)
+		})
+
+	b.WriteString("# HELP tack_check_status Status of the last run of the check, one series per status.\n# TYPE tack_check_status gauge\n")
+	for _, st := range statuses {
+		if !ran(st) {
+			continue
+		}
+		for _, status := range metricStatuses {
+			v := 0
+			if st.Status == status {
+				v = 1
+			}
+			fmt.Fprintf(&b, "tack_check_status{%s,status=\"%s\"} %d\n", checkLabels(st), status, v)
+		}
+	}
+
+	family("tack_check_duration_seconds", "gauge", "Duration of the last run of the check.",
+		func(st CheckStatus) (string, bool) { return formatFloat(st.Duration.Seconds()), ran(st) })
+	family("tack_check_last_run_timestamp_seconds", "gauge", "Unix time the check last ran.",
+		func(st CheckStatus) (string, bool) { return formatUnix(st.LastRun.UnixMilli()), ran(st) })
+	family("tack_check_last_success_timestamp_seconds", "gauge", "Unix time the check last succeeded.",
+		func(st CheckStatus) (string, bool) {
+			return formatUnix(st.LastSuccess.UnixMilli()), !st.LastSuccess.IsZero()
+		})
+	family("tack_check_runs_total", "counter", "Runs of the check.",
+		func(st CheckStatus) (string, bool) { return fmt.Sprint(st.Runs), true })
+	family("tack_check_failures_total", "counter", "Runs of the check that did not succeed.",
+		func(st CheckStatus) (string, bool) { return fmt.Sprint(st.Failures), true })
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// MetricsHandler serves WriteMetrics output for Prometheus to scrape.
+func (s *Scheduler) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = s.WriteMetrics(w)
+	})
+}
+
+// checkLabels formats the labels identifying a check.
+func checkLabels(st CheckStatus) string {
+	return fmt.Sprintf(`check="%s",plugin="%s",service="%s",operation="%s"`,
+		escapeLabel(st.Name), escapeLabel(st.Plugin), escapeLabel(st.Service), escapeLabel(st.Operation))
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return fmt.Sprintf("%g", f)
+}
+
+// formatUnix formats a Unix time in milliseconds as seconds.
+func formatUnix(ms int64) string {
+	return fmt.Sprintf("%d.%03d", ms/1000, ms%1000)
+}
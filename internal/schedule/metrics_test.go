@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	exec := stubExecutor{"dns": abi.ResultSuccess("", nil)}
+	checks := []config.ScheduledCheck{
+		{Name: "ok", Schedule: "@every 1m", Plugin: "dns", Operation: "resolve"},
+		{Name: `bad "one"`, Schedule: "@every 1m", Plugin: "nope", Service: "svc", Operation: "resolve"},
+	}
+	s, err := New(checks, exec, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.now = func() time.Time { return time.Unix(1700000000, 250_000_000) }
+
+	var before strings.Builder
+	if err := s.WriteMetrics(&before); err != nil {
+		t.Fatalf("WriteMetrics: %v", err)
+	}
+	if strings.Contains(before.String(), "tack_check_success{") {
+		t.Errorf("expected no success series before the first run:\n%s", before.String())
+	}
+
+	s.RunOnce(context.Background())
+	var b strings.Builder
+	if err := s.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics: %v", err)
+	}
+	out := b.String()
+
+	okLabels := `check="ok",plugin="dns",service="",operation="resolve"`
+	badLabels := `check="bad \"one\"",plugin="nope",service="svc",operation="resolve"`
+	for _, want := range []string{
+		"# TYPE tack_check_success gauge",
+		"tack_check_success{" + okLabels + "} 1",
+		"tack_check_success{" + badLabels + "} 0",
+		"tack_check_status{" + badLabels + `,status="error"} 1`,
+		"tack_check_status{" + badLabels + `,status="success"} 0`,
+		"tack_check_last_run_timestamp_seconds{" + okLabels + "} 1700000000.250",
+		"tack_check_last_success_timestamp_seconds{" + okLabels + "} 1700000000.250",
+		"# TYPE tack_check_runs_total counter",
+		"tack_check_failures_total{" + badLabels + "} 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "tack_check_last_success_timestamp_seconds{"+badLabels) {
+		t.Errorf("failing check should have no last success timestamp:\n%s", out)
+	}
+}
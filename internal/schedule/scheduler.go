@@ -0,0 +1,327 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/notify"
+	"gopkg.in/yaml.v3"
+)
+
+// Executor runs a single plugin operation.
+type Executor interface {
+	Execute(ctx context.Context, plugin, service, operation string, config map[string]any) (abi.Result, error)
+}
+
+// CheckStatus is the latest known state of a scheduled check.
+type CheckStatus struct {
+	Name        string        `json:"name"`
+	Schedule    string        `json:"schedule"`
+	Plugin      string        `json:"plugin"`
+	Service     string        `json:"service,omitempty"`
+	Operation   string        `json:"operation"`
+	Status      string        `json:"status,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	LastRun     time.Time     `json:"last_run,omitempty"`
+	LastSuccess time.Time     `json:"last_success,omitempty"`
+	Duration    time.Duration `json:"duration_ns,omitempty"`
+	NextRun     time.Time     `json:"next_run"`
+	Runs        int           `json:"runs"`
+	Failures    int           `json:"failures"`
+}
+
+// File is the on-disk format of a schedule file.
+type File struct {
+	Checks []config.ScheduledCheck `yaml:"checks"`
+}
+
+// LoadFile reads scheduled checks from a YAML file.
+func LoadFile(path string) ([]config.ScheduledCheck, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schedule file: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing schedule file %s: %w", path, err)
+	}
+	return f.Checks, nil
+}
+
+// entry pairs a check with its parsed schedule.
+type entry struct {
+	check config.ScheduledCheck
+	spec  Spec
+}
+
+// Scheduler runs checks on their schedules, recording outcomes in a history
+// store. Runs of the same check never overlap; a slow run delays its next
+// activation instead of stacking up.
+type Scheduler struct {
+	entries []entry
+	exec    Executor
+	store   *history.Store
+	notify  *notify.Notifier
+	mask    func(plugin, service, operation string, data map[string]any) map[string]any
+	runLog  io.Writer
+	now     func() time.Time
+	atStart bool
+
+	mu     sync.RWMutex
+	status map[string]*CheckStatus
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithNotifier sends an event whenever a check transitions into a failing
+// state (failure or error) from success or its first run.
+func WithNotifier(n *notify.Notifier) Option {
+	return func(s *Scheduler) {
+		s.notify = n
+	}
+}
+
+// WithDataMask applies mask to result data before it is sent in
+// notifications or recorded in history, such as to hide sensitive fields.
+func WithDataMask(mask func(plugin, service, operation string, data map[string]any) map[string]any) Option {
+	return func(s *Scheduler) {
+		s.mask = mask
+	}
+}
+
+// WithRunLog writes a line to w for every run: the check, its status and
+// duration, and the message of runs that did not succeed.
+func WithRunLog(w io.Writer) Option {
+	return func(s *Scheduler) {
+		s.runLog = w
+	}
+}
+
+// WithRunAtStart runs every check as soon as Run starts, before waiting for
+// its first scheduled activation.
+func WithRunAtStart() Option {
+	return func(s *Scheduler) {
+		s.atStart = true
+	}
+}
+
+// New validates the checks and returns a Scheduler. store may be nil to
+// disable history recording.
+func New(checks []config.ScheduledCheck, exec Executor, store *history.Store, opts ...Option) (*Scheduler, error) {
+	if len(checks) == 0 {
+		return nil, fmt.Errorf("no scheduled checks configured")
+	}
+
+	s := &Scheduler{
+		exec:   exec,
+		store:  store,
+		now:    time.Now,
+		status: make(map[string]*CheckStatus, len(checks)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for i, c := range checks {
+		if c.Name == "" {
+			return nil, fmt.Errorf("check %d: name is required", i+1)
+		}
+		if _, dup := s.status[c.Name]; dup {
+			return nil, fmt.Errorf("duplicate check name %q", c.Name)
+		}
+		if c.Plugin == "" || c.Operation == "" {
+			return nil, fmt.Errorf("check %q: plugin and operation are required", c.Name)
+		}
+		spec, err := Parse(c.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("check %q: %w", c.Name, err)
+		}
+		s.entries = append(s.entries, entry{check: c, spec: spec})
+		s.status[c.Name] = &CheckStatus{
+			Name:      c.Name,
+			Schedule:  c.Schedule,
+			Plugin:    c.Plugin,
+			Service:   c.Service,
+			Operation: c.Operation,
+		}
+	}
+
+	return s, nil
+}
+
+// Run blocks, executing checks on schedule until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, e := range s.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// RunOnce executes every check immediately, once, in definition order.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	for _, e := range s.entries {
+		s.runCheck(ctx, e)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	if s.atStart {
+		s.runCheck(ctx, e)
+	}
+	for {
+		next := e.spec.Next(s.now())
+		if next.IsZero() {
+			return
+		}
+		s.update(e.check.Name, func(st *CheckStatus) { st.NextRun = next })
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runCheck(ctx, e)
+	}
+}
+
+// runCheck executes a check and records its outcome.
+func (s *Scheduler) runCheck(ctx context.Context, e entry) {
+	c := e.check
+	start := s.now()
+	result, err := s.exec.Execute(ctx, c.Plugin, c.Service, c.Operation, cloneConfig(c.With))
+	duration := s.now().Sub(start)
+
+	if s.mask != nil {
+		result.Data = s.mask(c.Plugin, c.Service, c.Operation, result.Data)
+	}
+
+	status, message := string(result.Status), result.Message
+	if err != nil {
+		status, message = string(abi.ResultStatusError), err.Error()
+	} else if result.Error != nil && result.Error.Message != "" {
+		message = result.Error.Message
+	}
+
+	if s.runLog != nil {
+		line := fmt.Sprintf("check %q plugin=%s operation=%s status=%s duration=%s", c.Name, c.Plugin, c.Operation, status, duration.Round(time.Millisecond))
+		if status != string(abi.ResultStatusSuccess) && message != "" {
+			line += ": " + message
+		}
+		_, _ = fmt.Fprintln(s.runLog, line)
+	}
+
+	var previous string
+	s.update(c.Name, func(st *CheckStatus) {
+		previous = st.Status
+		st.Status = status
+		st.Message = message
+		st.LastRun = start
+		st.Duration = duration
+		st.Runs++
+		if status == string(abi.ResultStatusSuccess) {
+			st.LastSuccess = start
+		} else {
+			st.Failures++
+		}
+	})
+
+	if notify.IsFailing(status) && !notify.IsFailing(previous) {
+		s.notify.Notify(ctx, notify.Event{
+			Source:         "schedule",
+			Name:           c.Name,
+			Plugin:         c.Plugin,
+			Operation:      c.Operation,
+			Status:         status,
+			PreviousStatus: previous,
+			Message:        message,
+			Data:           result.Data,
+			Time:           start,
+		})
+	}
+
+	if s.store != nil {
+		rec := history.Record{
+			Source:    "schedule",
+			Check:     c.Name,
+			Plugin:    c.Plugin,
+			Service:   c.Service,
+			Operation: c.Operation,
+			Status:    status,
+			Message:   message,
+			Data:      result.Data,
+			StartedAt: start,
+			Duration:  duration,
+		}
+		if err := s.store.Append(rec); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording history for %q: %v\n", c.Name, err)
+		}
+	}
+}
+
+func (s *Scheduler) update(name string, fn func(*CheckStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.status[name])
+}
+
+// Status returns a snapshot of every check's state, sorted by name.
+func (s *Scheduler) Status() []CheckStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]CheckStatus, 0, len(s.status))
+	for _, st := range s.status {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Handler returns an HTTP handler exposing scheduler state:
+//
+//	GET /status   JSON array of CheckStatus
+//	GET /metrics  Prometheus metrics (see WriteMetrics)
+//	GET /healthz  200 OK while the scheduler is running
+func (s *Scheduler) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(s.Status())
+	})
+	mux.Handle("GET /metrics", s.MetricsHandler())
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// cloneConfig copies the top level of a check's config so executors can
+// add service/operation keys without mutating the shared definition.
+func cloneConfig(with map[string]any) map[string]any {
+	out := make(map[string]any, len(with))
+	for k, v := range with {
+		out[k] = v
+	}
+	return out
+}
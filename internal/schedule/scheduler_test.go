@@ -0,0 +1,171 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/config"
+	"github.com/whiskeyjimb/tack-cli/internal/history"
+	"github.com/whiskeyjimb/tack-cli/internal/notify"
+)
+
+type stubExecutor map[string]abi.Result
+
+func (s stubExecutor) Execute(_ context.Context, plugin, _, _ string, _ map[string]any) (abi.Result, error) {
+	if r, ok := s[plugin]; ok {
+		return r, nil
+	}
+	return abi.Result{}, errors.New("plugin not found")
+}
+
+func TestNew_Validation(t *testing.T) {
+	exec := stubExecutor{}
+	cases := map[string][]config.ScheduledCheck{
+		"empty":        nil,
+		"missing name": {{Schedule: "@hourly", Plugin: "dns", Operation: "resolve"}},
+		"bad schedule": {{Name: "a", Schedule: "nope", Plugin: "dns", Operation: "resolve"}},
+		"duplicate": {
+			{Name: "a", Schedule: "@hourly", Plugin: "dns", Operation: "resolve"},
+			{Name: "a", Schedule: "@hourly", Plugin: "dns", Operation: "resolve"},
+		},
+	}
+	for name, checks := range cases {
+		if _, err := New(checks, exec, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRunOnce_RecordsStatusAndHistory(t *testing.T) {
+	store := history.Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	exec := stubExecutor{
+		"dns": abi.ResultSuccess("", map[string]any{"records": []any{"1.2.3.4"}}),
+	}
+	checks := []config.ScheduledCheck{
+		{Name: "ok", Schedule: "@every 1m", Plugin: "dns", Operation: "resolve"},
+		{Name: "missing", Schedule: "@every 1m", Plugin: "nope", Operation: "resolve"},
+	}
+
+	s, err := New(checks, exec, store)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.RunOnce(context.Background())
+
+	status := s.Status()
+	if len(status) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(status))
+	}
+	byName := map[string]CheckStatus{}
+	for _, st := range status {
+		byName[st.Name] = st
+	}
+	if byName["ok"].Status != "success" || byName["ok"].LastSuccess.IsZero() {
+		t.Errorf("unexpected ok status: %+v", byName["ok"])
+	}
+	if byName["missing"].Status != "error" || byName["missing"].Failures != 1 {
+		t.Errorf("unexpected missing status: %+v", byName["missing"])
+	}
+
+	records, err := store.List(0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 2 || records[0].Source != "schedule" {
+		t.Errorf("expected 2 schedule records, got %+v", records)
+	}
+}
+
+func TestHandler_Status(t *testing.T) {
+	s, err := New([]config.ScheduledCheck{
+		{Name: "ok", Schedule: "@hourly", Plugin: "dns", Operation: "resolve"},
+	}, stubExecutor{}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	if rec.Code != 200 {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var got []CheckStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "ok" {
+		t.Errorf("unexpected status body: %s", rec.Body.String())
+	}
+}
+
+func TestRunCheck_NotifiesOnTransition(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+	defer srv.Close()
+
+	n, err := notify.New([]config.NotificationConfig{{Type: "webhook", URL: srv.URL}})
+	if err != nil {
+		t.Fatalf("notify.New: %v", err)
+	}
+	exec := stubExecutor{}
+	s, err := New([]config.ScheduledCheck{
+		{Name: "dns", Schedule: "@hourly", Plugin: "dns", Operation: "resolve"},
+	}, exec, nil, WithNotifier(n))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := context.Background()
+	s.RunOnce(ctx) // first run fails: notify
+	s.RunOnce(ctx) // still failing: no repeat
+	exec["dns"] = abi.ResultSuccess("", nil)
+	s.RunOnce(ctx) // recovered
+	delete(exec, "dns")
+	s.RunOnce(ctx) // fails again: notify
+
+	if calls != 2 {
+		t.Errorf("expected 2 notifications, got %d", calls)
+	}
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func TestRunLog(t *testing.T) {
+	exec := stubExecutor{"dns": abi.ResultSuccess("", nil)}
+	checks := []config.ScheduledCheck{
+		{Name: "ok", Schedule: "@every 1m", Plugin: "dns", Operation: "resolve"},
+		{Name: "missing", Schedule: "@every 1m", Plugin: "nope", Operation: "resolve"},
+	}
+	var log lockedBuffer
+	s, err := New(checks, exec, nil, WithRunLog(&log))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.RunOnce(context.Background())
+
+	out := log.buf.String()
+	for _, want := range []string{`check "ok" plugin=dns operation=resolve status=success`, `check "missing" plugin=nope operation=resolve status=error duration=0s: plugin not found`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("run log missing %q:\n%s", want, out)
+		}
+	}
+}
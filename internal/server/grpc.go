@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	tackv1 "github.com/whiskeyjimb/tack-cli/api/tack/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxConcurrency caps the concurrency an Execute call may request.
+const maxConcurrency = 16
+
+// GRPCServer returns a gRPC server offering tack.v1.TackService over the
+// same operations and executor as Handler. With a token, every call must
+// carry "authorization: Bearer <token>" metadata.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	if s.token != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+				if err := s.checkMetadata(ctx); err != nil {
+					return nil, err
+				}
+				return next(ctx, req)
+			}),
+			grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+				if err := s.checkMetadata(ss.Context()); err != nil {
+					return err
+				}
+				return next(srv, ss)
+			}),
+		)
+	}
+	gs := grpc.NewServer(opts...)
+	tackv1.RegisterTackServiceServer(gs, &grpcService{s: s})
+	return gs
+}
+
+// checkMetadata verifies the bearer token in a call's metadata.
+func (s *Server) checkMetadata(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if s.validToken(header) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// grpcService implements tackv1.TackServiceServer.
+type grpcService struct {
+	tackv1.UnimplementedTackServiceServer
+	s *Server
+}
+
+func (g *grpcService) ListPlugins(context.Context, *tackv1.ListPluginsRequest) (*tackv1.ListPluginsResponse, error) {
+	resp := &tackv1.ListPluginsResponse{}
+	var plugin *tackv1.Plugin
+	var service *tackv1.Service
+	// Operations are ordered by plugin and service, so each starts a run.
+	for _, op := range g.s.ops {
+		if plugin == nil || plugin.Name != op.Plugin {
+			plugin = &tackv1.Plugin{Name: op.Plugin, Version: op.pluginVersion, Description: op.pluginDescription}
+			resp.Plugins = append(resp.Plugins, plugin)
+			service = nil
+		}
+		if service == nil || service.Name != op.Service {
+			service = &tackv1.Service{Name: op.Service, Description: op.serviceDescription}
+			plugin.Services = append(plugin.Services, service)
+		}
+		service.Operations = append(service.Operations, &tackv1.Operation{Name: op.Operation, Description: op.Description})
+	}
+	return resp, nil
+}
+
+func (g *grpcService) DescribeOperation(_ context.Context, req *tackv1.DescribeOperationRequest) (*tackv1.DescribeOperationResponse, error) {
+	op, err := g.s.lookup(req.GetPlugin(), req.GetService(), req.GetOperation())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	configSchema, err := schemaStruct(op.ConfigSchema)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "config schema: %v", err)
+	}
+	outputSchema, err := schemaStruct(op.OutputSchema)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "output schema: %v", err)
+	}
+	return &tackv1.DescribeOperationResponse{
+		Plugin:       op.Plugin,
+		Service:      op.Service,
+		Operation:    op.Operation,
+		Description:  op.Description,
+		InputFields:  op.InputFields,
+		ConfigSchema: configSchema,
+		OutputSchema: outputSchema,
+	}, nil
+}
+
+// Execute runs each invocation, at most req.Concurrency at a time, and
+// streams results in completion order. Invocations that cannot run are
+// reported in the response's error rather than failing the call.
+func (g *grpcService) Execute(req *tackv1.ExecuteRequest, stream grpc.ServerStreamingServer[tackv1.ExecuteResponse]) error {
+	if len(req.GetInvocations()) == 0 {
+		return status.Error(codes.InvalidArgument, "no invocations")
+	}
+	concurrency := int(req.GetConcurrency())
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	concurrency = min(concurrency, maxConcurrency)
+
+	ctx := stream.Context()
+	var (
+		mu      sync.Mutex
+		sendErr error
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for i, inv := range req.GetInvocations() {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			resp := g.invoke(ctx, inv)
+			resp.Index = uint32(i)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if sendErr == nil {
+				sendErr = stream.Send(resp)
+			}
+		}()
+	}
+	wg.Wait()
+	return sendErr
+}
+
+// invoke runs a single invocation.
+func (g *grpcService) invoke(ctx context.Context, inv *tackv1.Invocation) *tackv1.ExecuteResponse {
+	op, err := g.s.lookup(inv.GetPlugin(), inv.GetService(), inv.GetOperation())
+	if err != nil {
+		return &tackv1.ExecuteResponse{Error: err.Error()}
+	}
+	config := inv.GetConfig().AsMap()
+	result, err := g.s.exec.Execute(ctx, op.Plugin, op.Service, op.Operation, config)
+	if err != nil {
+		return &tackv1.ExecuteResponse{Error: err.Error()}
+	}
+	pb, err := resultProto(result)
+	if err != nil {
+		return &tackv1.ExecuteResponse{Error: fmt.Sprintf("encoding result: %v", err)}
+	}
+	return &tackv1.ExecuteResponse{Result: pb}
+}
+
+// resultProto converts a plugin result to its protobuf form.
+func resultProto(r abi.Result) (*tackv1.Result, error) {
+	pb := &tackv1.Result{Status: string(r.Status), Message: r.Message}
+	if r.Data != nil {
+		data, err := toStruct(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		pb.Data = data
+	}
+	if r.Error != nil {
+		pb.Error = &tackv1.ResultError{Message: r.Error.Message, Type: r.Error.Type, Code: r.Error.Code}
+	}
+	return pb, nil
+}
+
+// schemaStruct converts a JSON Schema to a Struct, or nil if it is empty.
+func schemaStruct(schema json.RawMessage) (*structpb.Struct, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(schema, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// toStruct converts v to a Struct through its JSON encoding, which accepts
+// the typed slices and maps plugins return.
+func toStruct(v any) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return schemaStruct(raw)
+}
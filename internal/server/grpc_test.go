@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	tackv1 "github.com/whiskeyjimb/tack-cli/api/tack/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCServer(t *testing.T) {
+	exec := &fakeExecutor{}
+	gs := New(exec, Operations(testManifests()), WithToken("s3cret")).GRPCServer()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := tackv1.NewTackServiceClient(conn)
+
+	if _, err := client.ListPlugins(context.Background(), &tackv1.ListPluginsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret")
+	list, err := client.ListPlugins(ctx, &tackv1.ListPluginsRequest{})
+	if err != nil {
+		t.Fatalf("ListPlugins: %v", err)
+	}
+	if len(list.Plugins) != 2 || list.Plugins[0].Name != "aws" || len(list.Plugins[0].Services) != 2 {
+		t.Errorf("unexpected plugins: %v", list.Plugins)
+	}
+
+	if _, err := client.DescribeOperation(ctx, &tackv1.DescribeOperationRequest{Plugin: "dns", Operation: "nope"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+	desc, err := client.DescribeOperation(ctx, &tackv1.DescribeOperationRequest{Plugin: "dns", Operation: "resolve"})
+	if err != nil || desc.Service != "dns" {
+		t.Errorf("DescribeOperation: %v, %v", desc, err)
+	}
+
+	config, _ := structpb.NewStruct(map[string]any{"hostname": "example.com"})
+	stream, err := client.Execute(ctx, &tackv1.ExecuteRequest{Invocations: []*tackv1.Invocation{
+		{Plugin: "dns", Operation: "resolve", Config: config},
+		{Plugin: "nope", Operation: "resolve"},
+	}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	got := map[uint32]*tackv1.ExecuteResponse{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		got[resp.Index] = resp
+	}
+	if r := got[0].GetResult(); r.GetStatus() != "success" || r.GetData().AsMap()["records"] == nil {
+		t.Errorf("unexpected result: %v", got[0])
+	}
+	if exec.config["hostname"] != "example.com" {
+		t.Errorf("config not passed through: %v", exec.config)
+	}
+	if got[1].GetError() == "" {
+		t.Errorf("expected an error for the unknown plugin, got %v", got[1])
+	}
+}
@@ -0,0 +1,277 @@
+// Package server exposes plugin operations over HTTP.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/whiskeyjimb/tack-cli/internal/output"
+)
+
+// maxBodyBytes bounds the size of an operation's JSON config.
+const maxBodyBytes = 1 << 20
+
+// Executor runs a single plugin operation.
+type Executor interface {
+	Execute(ctx context.Context, plugin, service, operation string, config map[string]any) (abi.Result, error)
+}
+
+// Operation is a plugin operation exposed by the server.
+type Operation struct {
+	Plugin      string `json:"plugin"`
+	Service     string `json:"service"`
+	Operation   string `json:"operation"`
+	Description string `json:"description,omitempty"`
+	Path        string `json:"path"`
+
+	// InputFields are the config fields the operation reads.
+	InputFields []string `json:"input_fields,omitempty"`
+
+	// ConfigSchema is the plugin's config schema, and OutputSchema the
+	// schema of the result data, also used to lay out table output.
+	ConfigSchema json.RawMessage `json:"-"`
+	OutputSchema json.RawMessage `json:"-"`
+
+	pluginVersion      string
+	pluginDescription  string
+	serviceDescription string
+}
+
+// Operations lists the operations of the given plugin manifests, ordered
+// by plugin, service, and operation.
+func Operations(manifests []abi.Manifest) []Operation {
+	var ops []Operation
+	for _, m := range manifests {
+		for _, svc := range m.Services {
+			for _, op := range svc.Operations {
+				ops = append(ops, Operation{
+					Plugin:             m.Name,
+					Service:            svc.Name,
+					Operation:          op.Name,
+					Description:        op.Description,
+					Path:               "/plugins/" + m.Name + "/" + svc.Name + "/" + op.Name,
+					InputFields:        op.InputFields,
+					ConfigSchema:       m.ConfigSchema,
+					OutputSchema:       op.OutputSchema,
+					pluginVersion:      m.Version,
+					pluginDescription:  m.Description,
+					serviceDescription: svc.Description,
+				})
+			}
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		a, b := ops[i], ops[j]
+		if a.Plugin != b.Plugin {
+			return a.Plugin < b.Plugin
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Operation < b.Operation
+	})
+	return ops
+}
+
+// Server serves plugin operations as REST endpoints.
+type Server struct {
+	exec  Executor
+	ops   []Operation
+	byKey map[string]Operation
+	token string
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken requires requests to carry "Authorization: Bearer <token>".
+// /healthz is always open.
+func WithToken(token string) Option {
+	return func(s *Server) { s.token = token }
+}
+
+// New creates a Server for the given operations.
+func New(exec Executor, ops []Operation, opts ...Option) *Server {
+	s := &Server{exec: exec, ops: ops, byKey: make(map[string]Operation, len(ops))}
+	for _, op := range ops {
+		s.byKey[op.Plugin+"/"+op.Service+"/"+op.Operation] = op
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the HTTP handler for the server:
+//
+//	GET  /healthz                                   liveness, no auth
+//	GET  /plugins                                   the exposed operations
+//	POST /plugins/{plugin}/{service}/{operation}    run an operation
+//	POST /plugins/{plugin}/{operation}              same, for single-service plugins
+//
+// An operation's request body is a JSON object with its config. The
+// response is the plugin result as JSON ({"status", "data", "error"}), or
+// with ?format=json|yaml|table, the output the CLI would print. The result
+// status is also sent in the X-Tack-Status header.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.Handle("GET /plugins", s.authorize(http.HandlerFunc(s.handleList)))
+	mux.Handle("POST /plugins/{plugin}/{service}/{operation}", s.authorize(http.HandlerFunc(s.handleRun)))
+	mux.Handle("POST /plugins/{plugin}/{operation}", s.authorize(http.HandlerFunc(s.handleRun)))
+	return mux
+}
+
+// authorize rejects requests without the server's bearer token.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.validToken(r.Header.Get("Authorization")) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tack"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken reports whether an Authorization header carries the server's
+// bearer token.
+func (s *Server) validToken(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) handleList(w http.ResponseWriter, _ *http.Request) {
+	ops := s.ops
+	if ops == nil {
+		ops = []Operation{}
+	}
+	writeJSON(w, http.StatusOK, ops)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	op, err := s.lookup(r.PathValue("plugin"), r.PathValue("service"), r.PathValue("operation"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	formatter, err := newFormatter(format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	config, err := decodeConfig(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.exec.Execute(r.Context(), op.Plugin, op.Service, op.Operation, config)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("X-Tack-Status", string(result.Status))
+	if formatter == nil {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	_ = formatter.Format(w, result, op.OutputSchema)
+}
+
+// lookup finds an exposed operation. An empty service matches the only
+// service of a single-service plugin.
+func (s *Server) lookup(plugin, service, operation string) (Operation, error) {
+	if service != "" {
+		if op, ok := s.byKey[plugin+"/"+service+"/"+operation]; ok {
+			return op, nil
+		}
+		return Operation{}, fmt.Errorf("unknown operation %s/%s/%s", plugin, service, operation)
+	}
+
+	var found []Operation
+	services := map[string]bool{}
+	for _, op := range s.ops {
+		if op.Plugin != plugin {
+			continue
+		}
+		services[op.Service] = true
+		if op.Operation == operation {
+			found = append(found, op)
+		}
+	}
+	if len(services) > 1 {
+		return Operation{}, fmt.Errorf("plugin %q has multiple services; use /plugins/%s/{service}/%s", plugin, plugin, operation)
+	}
+	if len(found) == 0 {
+		return Operation{}, fmt.Errorf("unknown operation %s/%s", plugin, operation)
+	}
+	return found[0], nil
+}
+
+// contentTypes maps ?format values to response content types.
+var contentTypes = map[string]string{
+	"json":  "application/json",
+	"yaml":  "application/yaml",
+	"table": "text/plain; charset=utf-8",
+}
+
+// newFormatter returns the output formatter for a ?format value, or nil for
+// the default JSON result.
+func newFormatter(format string) (output.Formatter, error) {
+	if format == "" {
+		return nil, nil
+	}
+	if _, ok := contentTypes[format]; !ok {
+		return nil, fmt.Errorf("unsupported format: %q (supported: json, table, yaml)", format)
+	}
+	return output.NewFormatter(format)
+}
+
+// decodeConfig reads an operation's JSON config. An empty body is an
+// empty config.
+func decodeConfig(body io.Reader) (map[string]any, error) {
+	config := map[string]any{}
+	err := json.NewDecoder(body).Decode(&config)
+	if errors.Is(err, io.EOF) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config body: %w", err)
+	}
+	if config == nil {
+		// A JSON null body
+		config = map[string]any{}
+	}
+	return config, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// fakeExecutor records the last call and returns a fixed result.
+type fakeExecutor struct {
+	plugin, service, operation string
+	config                     map[string]any
+	err                        error
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, plugin, service, operation string, config map[string]any) (abi.Result, error) {
+	f.plugin, f.service, f.operation, f.config = plugin, service, operation, config
+	if f.err != nil {
+		return abi.Result{}, f.err
+	}
+	return abi.Result{Status: abi.ResultStatusSuccess, Data: map[string]any{"records": []any{"1.2.3.4"}}}, nil
+}
+
+func testManifests() []abi.Manifest {
+	return []abi.Manifest{
+		{Name: "dns", Services: map[string]abi.ServiceManifest{
+			"dns": {Name: "dns", Operations: []abi.OperationManifest{{Name: "resolve"}}},
+		}},
+		{Name: "aws", Services: map[string]abi.ServiceManifest{
+			"ec2": {Name: "ec2", Operations: []abi.OperationManifest{{Name: "describe"}}},
+			"s3":  {Name: "s3", Operations: []abi.OperationManifest{{Name: "list_buckets"}}},
+		}},
+	}
+}
+
+func TestOperations(t *testing.T) {
+	var paths []string
+	for _, op := range Operations(testManifests()) {
+		paths = append(paths, op.Path)
+	}
+	want := "/plugins/aws/ec2/describe,/plugins/aws/s3/list_buckets,/plugins/dns/dns/resolve"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	exec := &fakeExecutor{}
+	h := New(exec, Operations(testManifests()), WithToken("s3cret")).Handler()
+
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.Header.Set("Authorization", "Bearer s3cret")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("GET", "/healthz", "", false); rec.Code != http.StatusOK {
+		t.Errorf("healthz: expected 200, got %d", rec.Code)
+	}
+	if rec := do("GET", "/plugins", "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated list: expected 401, got %d", rec.Code)
+	}
+
+	rec := do("POST", "/plugins/dns/resolve", `{"hostname":"example.com"}`, true)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Tack-Status") != "success" {
+		t.Fatalf("run: got %d %s", rec.Code, rec.Body.String())
+	}
+	var result abi.Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || !result.IsSuccess() {
+		t.Errorf("unexpected result (%v): %s", err, rec.Body.String())
+	}
+	if exec.service != "dns" || exec.operation != "resolve" || exec.config["hostname"] != "example.com" {
+		t.Errorf("unexpected call: %+v", exec)
+	}
+
+	if rec := do("POST", "/plugins/aws/s3/list_buckets?format=table", "", true); rec.Code != http.StatusOK ||
+		!strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("table run: got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if exec.service != "s3" || len(exec.config) != 0 {
+		t.Errorf("unexpected call: %+v", exec)
+	}
+
+	for path, want := range map[string]int{
+		"/plugins/aws/list_buckets":       http.StatusNotFound, // multi-service needs a service
+		"/plugins/dns/dns/lookup":         http.StatusNotFound,
+		"/plugins/dns/resolve?format=xml": http.StatusBadRequest,
+	} {
+		if rec := do("POST", path, "", true); rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+	if rec := do("POST", "/plugins/dns/resolve", `["not an object"]`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: expected 400, got %d", rec.Code)
+	}
+
+	exec.err = errors.New("operation timed out")
+	if rec := do("POST", "/plugins/dns/resolve", "", true); rec.Code != http.StatusInternalServerError ||
+		!strings.Contains(rec.Body.String(), "timed out") {
+		t.Errorf("failed run: got %d %s", rec.Code, rec.Body.String())
+	}
+}
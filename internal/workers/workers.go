@@ -0,0 +1,26 @@
+// Package workers runs work on a bounded number of goroutines.
+package workers
+
+import "sync"
+
+// ForEach calls fn for every index in [0, n) on at most limit goroutines
+// and waits for all calls to return. A limit below one means one.
+func ForEach(n, limit int, fn func(i int)) {
+	workers := max(1, min(limit, n))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
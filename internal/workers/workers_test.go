@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		seen    = make(map[int]int)
+		running atomic.Int32
+		peak    atomic.Int32
+	)
+	ForEach(50, 4, func(i int) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		seen[i]++
+		mu.Unlock()
+	})
+
+	if len(seen) != 50 {
+		t.Errorf("expected 50 indexes, got %d", len(seen))
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("index %d ran %d times", i, n)
+		}
+	}
+	if peak.Load() > 4 {
+		t.Errorf("expected at most 4 concurrent calls, got %d", peak.Load())
+	}
+
+	ForEach(0, 4, func(int) { t.Error("unexpected call") })
+}
@@ -0,0 +1,303 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// Step statuses reported by Run. The first three mirror abi.ResultStatus.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// Executor runs a single plugin operation. The service may be empty for
+// single-service plugins; implementations resolve it from the manifest.
+type Executor interface {
+	Execute(ctx context.Context, plugin, service, operation string, config map[string]any) (abi.Result, error)
+}
+
+// StepReport is the outcome of a single step.
+type StepReport struct {
+	ID        string         `json:"id" yaml:"id"`
+	Plugin    string         `json:"plugin" yaml:"plugin"`
+	Service   string         `json:"service,omitempty" yaml:"service,omitempty"`
+	Operation string         `json:"operation" yaml:"operation"`
+	DependsOn []string       `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	Status    string         `json:"status" yaml:"status"`
+	Message   string         `json:"message,omitempty" yaml:"message,omitempty"`
+	Duration  time.Duration  `json:"duration_ns" yaml:"duration_ns"`
+	Data      map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// Report is the DAG-aware outcome of a workflow run.
+// Steps are listed in topological order.
+type Report struct {
+	Workflow string        `json:"workflow" yaml:"workflow"`
+	Duration time.Duration `json:"duration_ns" yaml:"duration_ns"`
+	Steps    []StepReport  `json:"steps" yaml:"steps"`
+}
+
+// Succeeded reports whether every step completed successfully.
+func (r *Report) Succeeded() bool {
+	for _, s := range r.Steps {
+		if s.Status != StatusSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// RunOptions tunes workflow execution.
+type RunOptions struct {
+	// Concurrency overrides the workflow's concurrency when > 0.
+	Concurrency int
+
+	// FailFast stops starting steps once any step does not succeed. Steps
+	// already running finish; the rest are skipped.
+	FailFast bool
+
+	// StepTimeout bounds each step's run time when > 0, unless the step
+	// sets its own timeout.
+	StepTimeout time.Duration
+}
+
+// Run executes the workflow, starting each step once all of its dependencies
+// have finished. Steps whose dependencies did not succeed are skipped.
+// At most Concurrency steps execute at the same time.
+func Run(ctx context.Context, wf *Workflow, exec Executor, opts RunOptions) (*Report, error) {
+	order, err := wf.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := wf.Concurrency
+	if opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	start := time.Now()
+	sem := make(chan struct{}, concurrency)
+	done := make(map[string]chan struct{}, len(order))
+	for _, id := range order {
+		done[id] = make(chan struct{})
+	}
+
+	var (
+		mu       sync.Mutex
+		reports  = make(map[string]StepReport, len(order))
+		wg       sync.WaitGroup
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	for _, id := range order {
+		step := wf.step(id)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[step.ID])
+
+			for _, dep := range step.DependsOn {
+				<-done[dep]
+			}
+
+			mu.Lock()
+			outputs := make(map[string]any, len(step.DependsOn))
+			var blocked []string
+			for _, dep := range step.DependsOn {
+				r := reports[dep]
+				if r.Status != StatusSuccess {
+					blocked = append(blocked, dep)
+				}
+				outputs[dep] = map[string]any{
+					"status":  r.Status,
+					"message": r.Message,
+					"data":    r.Data,
+				}
+			}
+			mu.Unlock()
+
+			var report StepReport
+			if len(blocked) > 0 {
+				report = newStepReport(step)
+				report.Status = StatusSkipped
+				report.Message = fmt.Sprintf("dependency did not succeed: %s", strings.Join(blocked, ", "))
+			} else {
+				select {
+				case sem <- struct{}{}:
+					if stopped() {
+						report = failFastSkip(step)
+					} else {
+						report = runStep(ctx, step, outputs, exec, opts.StepTimeout)
+					}
+					<-sem
+				case <-stop:
+					report = failFastSkip(step)
+				case <-ctx.Done():
+					report = newStepReport(step)
+					report.Status = StatusSkipped
+					report.Message = ctx.Err().Error()
+				}
+			}
+			if opts.FailFast && (report.Status == StatusFailure || report.Status == StatusError) {
+				stopOnce.Do(func() { close(stop) })
+			}
+
+			mu.Lock()
+			reports[step.ID] = report
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Workflow: wf.Name,
+		Duration: time.Since(start),
+		Steps:    make([]StepReport, 0, len(order)),
+	}
+	for _, id := range order {
+		report.Steps = append(report.Steps, reports[id])
+	}
+	return report, nil
+}
+
+// failFastSkip reports a step that was not started because an earlier step
+// did not succeed and RunOptions.FailFast is set.
+func failFastSkip(step Step) StepReport {
+	report := newStepReport(step)
+	report.Status = StatusSkipped
+	report.Message = "not run: an earlier step did not succeed (fail-fast)"
+	return report
+}
+
+// runStep renders the step config and executes it, bounded by the step's
+// timeout or else by timeout when > 0.
+func runStep(ctx context.Context, step Step, outputs map[string]any, exec Executor, timeout time.Duration) StepReport {
+	report := newStepReport(step)
+	start := time.Now()
+
+	if step.Timeout != "" {
+		timeout, _ = time.ParseDuration(step.Timeout)
+	}
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	config, err := renderConfig(step.With, map[string]any{"steps": outputs})
+	if err != nil {
+		report.Status = StatusError
+		report.Message = err.Error()
+		report.Duration = time.Since(start)
+		return report
+	}
+
+	result, err := exec.Execute(runCtx, step.Plugin, step.Service, step.Operation, config)
+	report.Duration = time.Since(start)
+	if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		report.Status = StatusError
+		report.Message = fmt.Sprintf("step timed out after %s", timeout)
+		return report
+	}
+	if err != nil {
+		report.Status = StatusError
+		report.Message = err.Error()
+		return report
+	}
+
+	report.Status = string(result.Status)
+	report.Message = result.Message
+	report.Data = result.Data
+	if result.Error != nil && result.Error.Message != "" {
+		report.Message = result.Error.Message
+	}
+	return report
+}
+
+func newStepReport(step Step) StepReport {
+	return StepReport{
+		ID:        step.ID,
+		Plugin:    step.Plugin,
+		Service:   step.Service,
+		Operation: step.Operation,
+		DependsOn: step.DependsOn,
+	}
+}
+
+// templateFuncs are available inside step input templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// renderConfig deep-copies with, rendering every string value as a template.
+func renderConfig(with map[string]any, data map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(with))
+	for k, v := range with {
+		rendered, err := renderValue(v, data)
+		if err != nil {
+			return nil, fmt.Errorf("rendering %q: %w", k, err)
+		}
+		out[k] = rendered
+	}
+	return out, nil
+}
+
+func renderValue(v any, data map[string]any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		tmpl, err := template.New("input").Funcs(templateFuncs).Option("missingkey=error").Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	case map[string]any:
+		return renderConfig(val, data)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			rendered, err := renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	default:
+		return val, nil
+	}
+}
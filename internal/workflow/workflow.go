@@ -0,0 +1,203 @@
+// Package workflow implements DAG-based orchestration of plugin operations.
+//
+// A workflow is a YAML document listing steps. Each step runs a single plugin
+// operation and may declare dependencies on other steps. Step inputs can be
+// templated from the outputs of the steps they depend on:
+//
+//	name: edge-check
+//	concurrency: 4
+//	steps:
+//	  - id: resolve
+//	    plugin: dns
+//	    operation: resolve
+//	    with:
+//	      hostname: example.com
+//	  - id: connect
+//	    plugin: tcp
+//	    operation: connect
+//	    depends_on: [resolve]
+//	    with:
+//	      host: '{{ index .steps.resolve.data.records 0 }}'
+//	      port: 443
+//	    timeout: 10s
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a named set of steps with declared dependencies.
+type Workflow struct {
+	// Name identifies the workflow in reports.
+	Name string `yaml:"name" json:"name"`
+
+	// Description is free-form help text.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Concurrency bounds how many steps run at once. Zero means 1.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// Steps lists the operations to run.
+	Steps []Step `yaml:"steps" json:"steps"`
+}
+
+// Step is a single plugin operation within a workflow.
+type Step struct {
+	// ID uniquely identifies the step and is used in depends_on and templates.
+	ID string `yaml:"id" json:"id"`
+
+	// Plugin is the plugin name (as shown by "plugin list").
+	Plugin string `yaml:"plugin" json:"plugin"`
+
+	// Service selects the service for multi-service plugins.
+	// Optional for single-service plugins.
+	Service string `yaml:"service,omitempty" json:"service,omitempty"`
+
+	// Operation is the operation name to run.
+	Operation string `yaml:"operation" json:"operation"`
+
+	// With holds the operation config. String values are rendered as
+	// Go templates against the outputs of completed steps.
+	With map[string]any `yaml:"with,omitempty" json:"with,omitempty"`
+
+	// DependsOn lists step IDs that must succeed before this step runs.
+	DependsOn []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+
+	// Timeout bounds the step's run time, as a Go duration such as "30s".
+	// It overrides RunOptions.StepTimeout.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Load reads and validates a workflow definition from a YAML file.
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workflow: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a workflow definition.
+func Parse(data []byte) (*Workflow, error) {
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("parsing workflow: %w", err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate checks that step IDs are unique, required fields are set,
+// dependencies reference known steps, and the dependency graph is acyclic.
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("workflow has no steps")
+	}
+
+	ids := make(map[string]bool, len(w.Steps))
+	for i, s := range w.Steps {
+		if s.ID == "" {
+			return fmt.Errorf("step %d: id is required", i+1)
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("duplicate step id %q", s.ID)
+		}
+		ids[s.ID] = true
+		if s.Plugin == "" {
+			return fmt.Errorf("step %q: plugin is required", s.ID)
+		}
+		if s.Operation == "" {
+			return fmt.Errorf("step %q: operation is required", s.ID)
+		}
+		if s.Timeout != "" {
+			if d, err := time.ParseDuration(s.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("step %q: timeout %q is not a positive duration", s.ID, s.Timeout)
+			}
+		}
+	}
+
+	for _, s := range w.Steps {
+		for _, dep := range s.DependsOn {
+			if !ids[dep] {
+				return fmt.Errorf("step %q depends on unknown step %q", s.ID, dep)
+			}
+			if dep == s.ID {
+				return fmt.Errorf("step %q depends on itself", s.ID)
+			}
+		}
+	}
+
+	if _, err := w.Order(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Order returns the step IDs in a deterministic topological order.
+// Steps with no ordering constraint between them are sorted by their
+// position in the definition. Returns an error naming the steps involved
+// if the graph contains a cycle.
+func (w *Workflow) Order() ([]string, error) {
+	position := make(map[string]int, len(w.Steps))
+	indegree := make(map[string]int, len(w.Steps))
+	dependents := make(map[string][]string, len(w.Steps))
+	for i, s := range w.Steps {
+		position[s.ID] = i
+		indegree[s.ID] += 0
+		for _, dep := range s.DependsOn {
+			indegree[s.ID]++
+			dependents[dep] = append(dependents[dep], s.ID)
+		}
+	}
+
+	var ready []string
+	for _, s := range w.Steps {
+		if indegree[s.ID] == 0 {
+			ready = append(ready, s.ID)
+		}
+	}
+
+	order := make([]string, 0, len(w.Steps))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return position[ready[i]] < position[ready[j]] })
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, next := range dependents[id] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if len(order) != len(w.Steps) {
+		var cyclic []string
+		for _, s := range w.Steps {
+			if indegree[s.ID] > 0 {
+				cyclic = append(cyclic, s.ID)
+			}
+		}
+		return nil, fmt.Errorf("dependency cycle between steps: %s", strings.Join(cyclic, ", "))
+	}
+
+	return order, nil
+}
+
+// step returns the step with the given ID.
+func (w *Workflow) step(id string) Step {
+	for _, s := range w.Steps {
+		if s.ID == id {
+			return s
+		}
+	}
+	return Step{}
+}
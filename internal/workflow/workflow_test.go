@@ -0,0 +1,252 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+func TestParse_Valid(t *testing.T) {
+	wf, err := Parse([]byte(`
+name: edge
+steps:
+  - id: resolve
+    plugin: dns
+    operation: resolve
+    with:
+      hostname: example.com
+  - id: connect
+    plugin: tcp
+    operation: connect
+    depends_on: [resolve]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(wf.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(wf.Steps))
+	}
+	if wf.Steps[1].DependsOn[0] != "resolve" {
+		t.Errorf("expected connect to depend on resolve, got %v", wf.Steps[1].DependsOn)
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no steps", `name: x`, "no steps"},
+		{"missing id", "steps:\n  - plugin: dns\n    operation: resolve", "id is required"},
+		{"duplicate", "steps:\n  - {id: a, plugin: p, operation: o}\n  - {id: a, plugin: p, operation: o}", "duplicate step id"},
+		{"unknown dep", "steps:\n  - {id: a, plugin: p, operation: o, depends_on: [b]}", "unknown step"},
+		{"self dep", "steps:\n  - {id: a, plugin: p, operation: o, depends_on: [a]}", "depends on itself"},
+		{"cycle", "steps:\n  - {id: a, plugin: p, operation: o, depends_on: [b]}\n  - {id: b, plugin: p, operation: o, depends_on: [a]}", "cycle"},
+		{"bad timeout", "steps:\n  - {id: a, plugin: p, operation: o, timeout: soon}", "not a positive duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %q", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestOrder_Deterministic(t *testing.T) {
+	wf := &Workflow{Steps: []Step{
+		{ID: "report", Plugin: "p", Operation: "o", DependsOn: []string{"a", "b"}},
+		{ID: "b", Plugin: "p", Operation: "o"},
+		{ID: "a", Plugin: "p", Operation: "o"},
+	}}
+
+	order, err := wf.Order()
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "b,a,report" {
+		t.Errorf("expected order b,a,report, got %s", got)
+	}
+}
+
+// fakeExecutor records calls and returns results keyed by plugin name.
+type fakeExecutor struct {
+	mu      sync.Mutex
+	calls   []string
+	configs map[string]map[string]any
+	results map[string]abi.Result
+}
+
+func (f *fakeExecutor) Execute(_ context.Context, plugin, _, operation string, config map[string]any) (abi.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, plugin)
+	if f.configs == nil {
+		f.configs = make(map[string]map[string]any)
+	}
+	f.configs[plugin] = config
+	if r, ok := f.results[plugin]; ok {
+		return r, nil
+	}
+	return abi.Result{}, fmt.Errorf("no result for %s/%s", plugin, operation)
+}
+
+func TestRun_TemplatesDependencyOutputs(t *testing.T) {
+	wf := &Workflow{Name: "edge", Steps: []Step{
+		{ID: "resolve", Plugin: "dns", Operation: "resolve"},
+		{ID: "connect", Plugin: "tcp", Operation: "connect", DependsOn: []string{"resolve"},
+			With: map[string]any{"host": "{{ index .steps.resolve.data.records 0 }}", "port": 443}},
+	}}
+	exec := &fakeExecutor{results: map[string]abi.Result{
+		"dns": abi.ResultSuccess("", map[string]any{"records": []any{"93.184.216.34"}}),
+		"tcp": abi.ResultSuccess("", map[string]any{"connected": true}),
+	}}
+
+	report, err := Run(context.Background(), wf, exec, RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.Succeeded() {
+		t.Fatalf("expected success, got %+v", report.Steps)
+	}
+	if exec.configs["tcp"]["host"] != "93.184.216.34" {
+		t.Errorf("expected templated host, got %v", exec.configs["tcp"]["host"])
+	}
+	if exec.configs["tcp"]["port"] != 443 {
+		t.Errorf("expected non-string values to pass through, got %v", exec.configs["tcp"]["port"])
+	}
+}
+
+func TestRun_SkipsDependentsOfFailedSteps(t *testing.T) {
+	wf := &Workflow{Steps: []Step{
+		{ID: "a", Plugin: "fails", Operation: "o"},
+		{ID: "b", Plugin: "ok", Operation: "o", DependsOn: []string{"a"}},
+		{ID: "c", Plugin: "ok", Operation: "o"},
+	}}
+	exec := &fakeExecutor{results: map[string]abi.Result{
+		"fails": abi.ResultFailure("nope", nil),
+		"ok":    abi.ResultSuccess("", nil),
+	}}
+
+	report, err := Run(context.Background(), wf, exec, RunOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Succeeded() {
+		t.Fatal("expected workflow to fail")
+	}
+
+	statuses := map[string]string{}
+	for _, s := range report.Steps {
+		statuses[s.ID] = s.Status
+	}
+	if statuses["a"] != StatusFailure || statuses["b"] != StatusSkipped || statuses["c"] != StatusSuccess {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+	if len(exec.calls) != 2 {
+		t.Errorf("expected skipped step not to execute, got calls %v", exec.calls)
+	}
+}
+
+func TestRun_TemplateErrorFailsStep(t *testing.T) {
+	wf := &Workflow{Steps: []Step{
+		{ID: "a", Plugin: "ok", Operation: "o", With: map[string]any{"x": "{{ .steps.missing.data.y }}"}},
+	}}
+	exec := &fakeExecutor{results: map[string]abi.Result{"ok": abi.ResultSuccess("", nil)}}
+
+	report, err := Run(context.Background(), wf, exec, RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Steps[0].Status != StatusError {
+		t.Errorf("expected error status for bad template, got %q", report.Steps[0].Status)
+	}
+}
+
+// gatedExecutor fails plugin "fails" once plugin "gate" has started, and
+// holds "gate" until "fails" has finished, so that "gate" is still running
+// when fail-fast trips.
+type gatedExecutor struct {
+	mu      sync.Mutex
+	calls   []string
+	started chan struct{}
+	failed  chan struct{}
+}
+
+func (g *gatedExecutor) Execute(_ context.Context, plugin, _, _ string, _ map[string]any) (abi.Result, error) {
+	g.mu.Lock()
+	g.calls = append(g.calls, plugin)
+	g.mu.Unlock()
+	switch plugin {
+	case "fails":
+		<-g.started
+		defer close(g.failed)
+		return abi.ResultFailure("nope", nil), nil
+	case "gate":
+		close(g.started)
+		<-g.failed
+		time.Sleep(20 * time.Millisecond)
+	}
+	return abi.ResultSuccess("", nil), nil
+}
+
+func TestRun_FailFastSkipsRemainingSteps(t *testing.T) {
+	wf := &Workflow{Steps: []Step{
+		{ID: "a", Plugin: "fails", Operation: "o"},
+		{ID: "gate", Plugin: "gate", Operation: "o"},
+		{ID: "b", Plugin: "ok", Operation: "o", DependsOn: []string{"gate"}},
+	}}
+	exec := &gatedExecutor{started: make(chan struct{}), failed: make(chan struct{})}
+
+	report, err := Run(context.Background(), wf, exec, RunOptions{Concurrency: 2, FailFast: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, s := range report.Steps {
+		statuses[s.ID] = s.Status
+	}
+	if statuses["a"] != StatusFailure || statuses["gate"] != StatusSuccess || statuses["b"] != StatusSkipped {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+	if len(exec.calls) != 2 {
+		t.Errorf("expected the step after the failure not to execute, got calls %v", exec.calls)
+	}
+}
+
+// slowExecutor blocks until its context is done.
+type slowExecutor struct{}
+
+func (slowExecutor) Execute(ctx context.Context, _, _, _ string, _ map[string]any) (abi.Result, error) {
+	<-ctx.Done()
+	return abi.Result{}, ctx.Err()
+}
+
+func TestRun_StepTimeout(t *testing.T) {
+	wf := &Workflow{Steps: []Step{
+		{ID: "default", Plugin: "p", Operation: "o"},
+		{ID: "own", Plugin: "p", Operation: "o", Timeout: "20ms"},
+	}}
+
+	report, err := Run(context.Background(), wf, slowExecutor{}, RunOptions{Concurrency: 2, StepTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := map[string]string{"default": "step timed out after 10ms", "own": "step timed out after 20ms"}
+	for _, s := range report.Steps {
+		if s.Status != StatusError || s.Message != want[s.ID] {
+			t.Errorf("step %s: got %s %q, want error %q", s.ID, s.Status, s.Message, want[s.ID])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package workspace finds the project workspace: the nearest .tack
+// directory at or above the working directory, found the way git finds
+// .git. History is kept in the workspace when there is one, rather than in
+// the user's home directory.
+//
+// Anyone can commit a .tack directory to a repository, so what it says
+// about trust is only used once the user has trusted the workspace:
+// plugins, capability grants, remembered reviews, and configuration come
+// from the home directory until then.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/whiskeyjimb/tack-cli/internal/meta"
+	"gopkg.in/yaml.v3"
+)
+
+// DirName is the name of the directory that marks a workspace.
+const DirName = "." + meta.AppName
+
+// Dir returns the .tack directory of the workspace containing the working
+// directory, or "" outside a workspace.
+func Dir() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return Find(wd)
+}
+
+// Find returns the .tack directory nearest to start, looking in start and
+// then each parent, or "" when there is none. The .tack directory in the
+// user's home directory holds global state and is not a workspace; the
+// search stops there.
+func Find(start string) string {
+	home, _ := os.UserHomeDir()
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	for {
+		if home != "" && dir == home {
+			return ""
+		}
+		candidate := filepath.Join(dir, DirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Path returns name in the workspace when there is one, and in the user's
+// ~/.tack directory otherwise.
+func Path(name string) string {
+	if dir := Dir(); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	return homePath(name)
+}
+
+// homePath returns name in the user's ~/.tack directory.
+func homePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", DirName, name)
+	}
+	return filepath.Join(home, DirName, name)
+}
+
+// TrustedPath returns name in the workspace when there is one and it is
+// trusted, and in the user's ~/.tack directory otherwise.
+func TrustedPath(name string) string {
+	if dir := Dir(); dir != "" && Trusted(dir) {
+		return filepath.Join(dir, name)
+	}
+	return homePath(name)
+}
+
+// TrustPath returns the file the trusted workspaces are listed in. It is
+// always in the home directory, so a workspace cannot trust itself.
+func TrustPath() string {
+	return homePath("trusted-workspaces.yaml")
+}
+
+// Trusted reports whether the workspace .tack directory dir is trusted.
+func Trusted(dir string) bool {
+	dirs, err := loadTrusted()
+	return err == nil && slices.Contains(dirs, absPath(dir))
+}
+
+// Trust trusts the workspace .tack directory dir.
+func Trust(dir string) error {
+	dirs, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	if dir = absPath(dir); slices.Contains(dirs, dir) {
+		return nil
+	}
+	return saveTrusted(append(dirs, dir))
+}
+
+// Untrust stops trusting the workspace .tack directory dir.
+func Untrust(dir string) error {
+	dirs, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	dir = absPath(dir)
+	if !slices.Contains(dirs, dir) {
+		return nil
+	}
+	return saveTrusted(slices.DeleteFunc(dirs, func(d string) bool { return d == dir }))
+}
+
+func loadTrusted() ([]string, error) {
+	path := TrustPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var dirs []string
+	if err := yaml.Unmarshal(data, &dirs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return dirs, nil
+}
+
+func saveTrusted(dirs []string) error {
+	path := TrustPath()
+	data, err := yaml.Marshal(dirs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+func absPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
@@ -0,0 +1,95 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	project := filepath.Join(home, "src", "workspace")
+	nested := filepath.Join(project, "a", "b")
+	for _, dir := range []string{filepath.Join(home, DirName), filepath.Join(project, DirName), nested} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name, start, want string
+	}{
+		{"in the workspace root", project, filepath.Join(project, DirName)},
+		{"below the workspace root", nested, filepath.Join(project, DirName)},
+		{"home directory is not a workspace", filepath.Join(home, "src"), ""},
+		{"outside home", root, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(tt.start); got != tt.want {
+				t.Errorf("Find(%q) = %q, want %q", tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	project := filepath.Join(t.TempDir(), "workspace")
+	if err := os.MkdirAll(filepath.Join(project, DirName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	t.Chdir(project)
+	if got, want := Path("grants.yaml"), filepath.Join(project, DirName, "grants.yaml"); got != want {
+		t.Errorf("in a workspace: Path = %q, want %q", got, want)
+	}
+
+	t.Chdir(home)
+	if got, want := Path("grants.yaml"), filepath.Join(home, DirName, "grants.yaml"); got != want {
+		t.Errorf("outside a workspace: Path = %q, want %q", got, want)
+	}
+}
+
+func TestTrust(t *testing.T) {
+	home := t.TempDir()
+	project := filepath.Join(t.TempDir(), "workspace")
+	ws := filepath.Join(project, DirName)
+	if err := os.MkdirAll(ws, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Chdir(project)
+
+	homeGrants := filepath.Join(home, DirName, "grants.yaml")
+	if got := TrustedPath("grants.yaml"); got != homeGrants {
+		t.Errorf("untrusted: TrustedPath = %q, want %q", got, homeGrants)
+	}
+
+	if err := Trust(ws); err != nil {
+		t.Fatalf("Trust: %v", err)
+	}
+	if err := Trust("./" + DirName); err != nil {
+		t.Fatalf("Trust again: %v", err)
+	}
+	if got, want := TrustedPath("grants.yaml"), filepath.Join(ws, "grants.yaml"); got != want {
+		t.Errorf("trusted: TrustedPath = %q, want %q", got, want)
+	}
+	if dirs, _ := loadTrusted(); len(dirs) != 1 {
+		t.Errorf("expected the workspace listed once, got %v", dirs)
+	}
+	if !strings.HasPrefix(TrustPath(), home) {
+		t.Errorf("expected the trust list in the home directory, got %s", TrustPath())
+	}
+
+	if err := Untrust(ws); err != nil {
+		t.Fatalf("Untrust: %v", err)
+	}
+	if Trusted(ws) || TrustedPath("grants.yaml") != homeGrants {
+		t.Error("expected the workspace untrusted")
+	}
+}